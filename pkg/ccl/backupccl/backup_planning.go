@@ -1509,6 +1509,12 @@ func createBackupManifest(
 	if err != nil {
 		return backuppb.BackupManifest{}, err
 	}
+	if jobDetails.FullCluster {
+		tableSpans, err = restrictJobInfoSpansToExecutionDetails(ctx, execCfg, endTime, tables, tableSpans)
+		if err != nil {
+			return backuppb.BackupManifest{}, err
+		}
+	}
 	spans = append(spans, tableSpans...)
 
 	if len(prevBackups) > 0 {
@@ -697,7 +697,9 @@ func TestClusterRestoreFailCleanup(t *testing.T) {
 	t.Run("retry-during-custom-system-table-restore", func(t *testing.T) {
 		customRestoreSystemTables := make([]string, 0)
 		for table, config := range systemTableBackupConfiguration {
-			if config.customRestoreFunc != nil {
+			// Tables which are only backed up if a setting is enabled are not
+			// present in the backup being restored.
+			if config.customRestoreFunc != nil && config.shouldIncludeInClusterBackup == optInToClusterBackup {
 				customRestoreSystemTables = append(customRestoreSystemTables, table)
 			}
 		}
@@ -1077,6 +1079,69 @@ func TestClusterRevisionDoesNotBackupOptOutSystemTables(t *testing.T) {
 	sqlDB.Exec(t, `BACKUP TO 'nodelocal://1/foo' WITH revision_history;`)
 }
 
+// TestClusterRestoreExecutionDetails verifies that the execution details
+// collected for a job are only carried over by a cluster restore if the backup
+// was taken with bulkio.backup.include_job_execution_details.enabled, in which
+// case they are attached to the restore job since jobs are not restored.
+func TestClusterRestoreExecutionDetails(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const countExecutionDetails = `SELECT count(*) FROM system.job_info WHERE info_key LIKE '~profiler/%'`
+
+	testutils.RunTrueAndFalse(t, "include", func(t *testing.T, include bool) {
+		sqlDB, tempDir, cleanupFn := createEmptyCluster(t, singleNode)
+		_, sqlDBRestore, cleanupEmptyCluster := backupRestoreTestSetupEmpty(t, singleNode, tempDir, InitManualReplication,
+			// Disabling the default test tenant due to test failures. More
+			// investigation is required. Tracked with #76378.
+			base.TestClusterArgs{ServerArgs: base.TestServerArgs{DefaultTestTenant: base.TODOTestTenantDisabled}})
+		defer cleanupFn()
+		defer cleanupEmptyCluster()
+
+		sqlDB.Exec(t, `SET CLUSTER SETTING bulkio.backup.include_job_execution_details.enabled = $1`, include)
+		sqlDB.Exec(t, `CREATE TABLE foo (id INT)`)
+		collect := func(dest string) (jobspb.JobID, int) {
+			var jobID jobspb.JobID
+			sqlDB.QueryRow(t, `BACKUP TABLE foo TO $1`, dest).Scan(&jobID)
+			sqlDB.Exec(t, `SELECT crdb_internal.request_job_execution_details($1)`, jobID)
+			var numDetails int
+			sqlDB.QueryRow(t, fmt.Sprintf(
+				`SELECT count(*) FROM system.job_info WHERE job_id = %d AND info_key LIKE '~profiler/%%'`, jobID),
+			).Scan(&numDetails)
+			require.Greater(t, numDetails, 0)
+			return jobID, numDetails
+		}
+
+		// The execution details of a job which only exists by the time of the
+		// incremental backup are introduced by it.
+		fullJobID, fullDetails := collect("nodelocal://1/full")
+		sqlDB.Exec(t, `BACKUP INTO $1`, localFoo)
+		incJobID, incDetails := collect("nodelocal://1/inc")
+		sqlDB.Exec(t, `BACKUP INTO LATEST IN $1`, localFoo)
+		sqlDBRestore.Exec(t, `RESTORE FROM LATEST IN $1`, localFoo)
+		if !include {
+			sqlDBRestore.CheckQueryResults(t, countExecutionDetails, [][]string{{"0"}})
+			return
+		}
+		sqlDBRestore.CheckQueryResults(t, countExecutionDetails,
+			[][]string{{strconv.Itoa(fullDetails + incDetails)}})
+		var restoreJobID jobspb.JobID
+		sqlDBRestore.QueryRow(t,
+			`SELECT job_id FROM [SHOW JOBS] WHERE job_type = 'RESTORE'`).Scan(&restoreJobID)
+		for jobID, numDetails := range map[jobspb.JobID]int{fullJobID: fullDetails, incJobID: incDetails} {
+			sqlDBRestore.CheckQueryResults(t, fmt.Sprintf(
+				`SELECT count(*) FROM system.job_info WHERE job_id = %d AND info_key LIKE '~profiler/restored-job-%d-%%'`,
+				restoreJobID, jobID),
+				[][]string{{strconv.Itoa(numDetails)}})
+			// Only the execution details are restored, not the rest of the
+			// information stored by the backed up jobs.
+			sqlDBRestore.CheckQueryResults(t, fmt.Sprintf(
+				`SELECT count(*) FROM system.job_info WHERE job_id = %d`, jobID),
+				[][]string{{"0"}})
+		}
+	})
+}
+
 func TestRestoreWithRecreatedDefaultDB(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
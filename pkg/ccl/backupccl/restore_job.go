@@ -1568,6 +1568,12 @@ func (r *restoreResumer) doResume(ctx context.Context, execCtx interface{}) erro
 	if err != nil {
 		return err
 	}
+	if details.DescriptorCoverage == tree.AllDescriptors {
+		backupSpans := backupManifests[lastBackupIndex].Spans
+		for _, data := range []*restorationDataBase{preData, preValidateData, &mainData.restorationDataBase} {
+			data.spans = restrictJobInfoSpansToBackup(backupCodec, sqlDescs, data.spans, backupSpans)
+		}
+	}
 
 	// Refresh the job details since they may have been updated when creating the
 	// importing descriptors.
@@ -3061,6 +3067,7 @@ func (r *restoreResumer) restoreSystemTables(
 			deps := customRestoreFuncDeps{
 				settings: r.execCfg.Settings,
 				codec:    r.execCfg.Codec,
+				jobID:    r.job.ID(),
 			}
 			log.Eventf(ctx, "restoring system table %s", systemTable.systemTableName)
 			err := restoreFunc(ctx, deps, txn, systemTable.systemTableName, systemTable.stagingTableName)
@@ -17,8 +17,11 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/clusterversion"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobsprofiler/profilerconstants"
 	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
@@ -27,10 +30,13 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descs"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/systemschema"
 	"github.com/cockroachdb/cockroach/pkg/sql/isql"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/catconstants"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/catid"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/errors"
 )
@@ -50,6 +56,21 @@ const (
 	// optOutOfClusterBackup indicates that the system table
 	// should not be included in the cluster backup.
 	optOutOfClusterBackup
+	// optInToClusterBackupIfEnabled indicates that the system table should
+	// only be included in the cluster backup if the setting in
+	// systemBackupConfiguration.includeSetting is enabled. The table is
+	// restored whenever the backup being restored includes it.
+	optInToClusterBackupIfEnabled
+)
+
+// includeJobExecutionDetails controls whether cluster backups include the
+// execution details collected for jobs by the job profiler.
+var includeJobExecutionDetails = settings.RegisterBoolSetting(
+	settings.TenantWritable,
+	"bulkio.backup.include_job_execution_details.enabled",
+	"set to true to include the execution details collected by the job profiler in cluster backups; "+
+		"they are excluded by default since they are debugging artifacts that can be large",
+	false,
 )
 
 // systemBackupConfiguration holds any configuration related to backing up
@@ -65,6 +86,9 @@ const (
 //     cluster so there is no need to rewrite system table data.
 type systemBackupConfiguration struct {
 	shouldIncludeInClusterBackup clusterBackupInclusion
+	// includeSetting controls whether a system table which is
+	// optInToClusterBackupIfEnabled is included in a cluster backup.
+	includeSetting *settings.BoolSetting
 	// restoreBeforeData indicates that this system table should be fully restored
 	// before restoring the user data. If a system table is restored before the
 	// user data, the restore will see this system table during the restore.
@@ -96,6 +120,8 @@ type systemBackupConfiguration struct {
 type customRestoreFuncDeps struct {
 	settings *cluster.Settings
 	codec    keys.SQLCodec
+	// jobID is the ID of the restore job.
+	jobID jobspb.JobID
 }
 
 // roleIDSequenceRestoreOrder is set to 1 since it must be after system.users
@@ -131,6 +157,112 @@ func defaultSystemTableRestoreFunc(
 
 // Custom restore functions for different system tables.
 
+// jobExecutionDetailsRestoreFunc restores the execution details collected by
+// the job profiler into system.job_info. Jobs are not restored, so the
+// execution details are attached to the restore job instead, with the names of
+// their files prefixed by the ID of the job they were collected for. This keeps
+// them reachable through the execution details of the restore job, and has
+// them removed along with it. Only the execution details are backed up, see
+// restrictJobInfoSpansToExecutionDetails. The rows of the jobs in the restoring
+// cluster, such as the restore job itself, are left in place.
+func jobExecutionDetailsRestoreFunc(
+	ctx context.Context,
+	deps customRestoreFuncDeps,
+	txn isql.Txn,
+	systemTableName, tempTableName string,
+) error {
+	restoreQuery := fmt.Sprintf(`UPSERT INTO system.%s (job_id, info_key, written, value)
+SELECT $1, $2 || 'restored-job-' || job_id::STRING || '-' || substr(info_key, $3), written, value
+FROM %s WHERE info_key LIKE $4`,
+		systemTableName, tempTableName)
+	opName := systemTableName + "-data-insert"
+	if _, err := txn.Exec(ctx, opName, txn.KV(), restoreQuery,
+		deps.jobID,
+		profilerconstants.ExecutionDetailsChunkKeyPrefix,
+		len(profilerconstants.ExecutionDetailsChunkKeyPrefix)+1,
+		profilerconstants.ExecutionDetailsChunkKeyPrefix+"%"); err != nil {
+		return errors.Wrapf(err, "inserting data to system.%s", systemTableName)
+	}
+	return nil
+}
+
+// restrictJobInfoSpansToExecutionDetails restricts the spans of system.job_info
+// backed up by a cluster backup to the execution details collected by the job
+// profiler, since the remaining rows of the table belong to jobs, which are not
+// restored. The execution details of a job are stored under its ID, so they
+// are backed up one job at a time. They are removed along with the job they
+// were collected for, so only the jobs which exist as of the end time of the
+// backup may have any.
+func restrictJobInfoSpansToExecutionDetails(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	endTime hlc.Timestamp,
+	tables []catalog.TableDescriptor,
+	spans []roachpb.Span,
+) ([]roachpb.Span, error) {
+	var jobInfo catalog.TableDescriptor
+	for _, table := range tables {
+		if table.GetParentID() == keys.SystemDatabaseID &&
+			table.GetName() == systemschema.SystemJobInfoTable.GetName() {
+			jobInfo = table
+			break
+		}
+	}
+	if jobInfo == nil {
+		return spans, nil
+	}
+	prefix := roachpb.Key(rowenc.MakeIndexKeyPrefix(execCfg.Codec, jobInfo.GetID(), jobInfo.GetPrimaryIndexID()))
+	spans = roachpb.SubtractSpans(spans, roachpb.Spans{{Key: prefix, EndKey: prefix.PrefixEnd()}})
+
+	rows, err := execCfg.InternalDB.Executor().QueryBufferedEx(
+		ctx, "backup-job-execution-details", nil, /* txn */
+		sessiondata.NodeUserSessionDataOverride,
+		fmt.Sprintf(`SELECT id FROM system.jobs AS OF SYSTEM TIME %s`, endTime.AsOfSystemTime()),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the jobs whose execution details to back up")
+	}
+	startInfoKey := encoding.EncodeStringAscending(nil, profilerconstants.ExecutionDetailsChunkKeyPrefix)
+	endInfoKey := encoding.EncodeStringAscending(nil,
+		string(roachpb.Key(profilerconstants.ExecutionDetailsChunkKeyPrefix).PrefixEnd()))
+	for _, row := range rows {
+		jobPrefix := encoding.EncodeVarintAscending(prefix.Clone(), int64(tree.MustBeDInt(row[0])))
+		spans = append(spans, roachpb.Span{
+			Key:    append(jobPrefix[:len(jobPrefix):len(jobPrefix)], startInfoKey...),
+			EndKey: append(jobPrefix[:len(jobPrefix):len(jobPrefix)], endInfoKey...),
+		})
+	}
+	merged, _ := roachpb.MergeSpans(&spans)
+	return merged, nil
+}
+
+// restrictJobInfoSpansToBackup removes the parts of the span of system.job_info
+// which the backup being restored doesn't cover from the spans restored by a
+// cluster restore, since cluster backups only cover the execution details
+// stored in the table, see restrictJobInfoSpansToExecutionDetails. The
+// descriptors and spans are those of the backup, before they are rekeyed.
+func restrictJobInfoSpansToBackup(
+	codec keys.SQLCodec, descs []catalog.Descriptor, spans, backupSpans []roachpb.Span,
+) []roachpb.Span {
+	if len(spans) == 0 {
+		return spans
+	}
+	for _, desc := range descs {
+		table, ok := desc.(catalog.TableDescriptor)
+		if !ok || table.GetParentID() != keys.SystemDatabaseID ||
+			table.GetName() != systemschema.SystemJobInfoTable.GetName() {
+			continue
+		}
+		jobInfoSpan := table.IndexSpan(codec, table.GetPrimaryIndexID())
+		uncovered := roachpb.SubtractSpans(roachpb.Spans{jobInfoSpan}, append(roachpb.Spans(nil), backupSpans...))
+		if len(uncovered) == 0 {
+			return spans
+		}
+		return roachpb.SubtractSpans(append(roachpb.Spans(nil), spans...), uncovered)
+	}
+	return spans
+}
+
 // tenantSettingsTableRestoreFunc restores the system.tenant_settings table. It
 // returns an error when trying to restore a non-empty tenant_settings table
 // into a non-system tenant.
@@ -795,7 +927,15 @@ var systemTableBackupConfiguration = map[string]systemBackupConfiguration{
 		shouldIncludeInClusterBackup: optOutOfClusterBackup,
 	},
 	systemschema.SystemJobInfoTable.GetName(): {
-		shouldIncludeInClusterBackup: optOutOfClusterBackup,
+		// Jobs are not carried over by a cluster restore, and so neither is most
+		// of the information they store. The execution details collected by the
+		// job profiler are ephemeral debugging artifacts which would bloat
+		// backups, so they are only backed up if includeJobExecutionDetails is
+		// enabled, in which case the rest of the table isn't, and they are
+		// restored into the restore job.
+		shouldIncludeInClusterBackup: optInToClusterBackupIfEnabled,
+		includeSetting:               includeJobExecutionDetails,
+		customRestoreFunc:            jobExecutionDetailsRestoreFunc,
 	},
 	systemschema.SpanStatsUniqueKeysTable.GetName(): {
 		shouldIncludeInClusterBackup: optOutOfClusterBackup,
@@ -904,6 +1044,35 @@ func GetSystemTablesToIncludeInClusterBackup() map[string]struct{} {
 	return systemTablesToInclude
 }
 
+// getSystemTablesToBackUp returns the set of system table names that are
+// included in a cluster backup taken with the given settings. In addition to
+// the tables returned by GetSystemTablesToIncludeInClusterBackup, this includes
+// the optInToClusterBackupIfEnabled tables whose setting is enabled.
+func getSystemTablesToBackUp(sv *settings.Values) map[string]struct{} {
+	systemTablesToInclude := GetSystemTablesToIncludeInClusterBackup()
+	for systemTableName, backupConfig := range systemTableBackupConfiguration {
+		if backupConfig.shouldIncludeInClusterBackup == optInToClusterBackupIfEnabled &&
+			backupConfig.includeSetting.Get(sv) {
+			systemTablesToInclude[systemTableName] = struct{}{}
+		}
+	}
+
+	return systemTablesToInclude
+}
+
+// getSystemTablesToRestore returns the set of system table names that a
+// cluster restore restores if they are present in the backup.
+func getSystemTablesToRestore() map[string]struct{} {
+	systemTablesToInclude := GetSystemTablesToIncludeInClusterBackup()
+	for systemTableName, backupConfig := range systemTableBackupConfiguration {
+		if backupConfig.shouldIncludeInClusterBackup == optInToClusterBackupIfEnabled {
+			systemTablesToInclude[systemTableName] = struct{}{}
+		}
+	}
+
+	return systemTablesToInclude
+}
+
 // GetSystemTableIDsToExcludeFromClusterBackup returns a set of system table ids
 // that should be excluded from a cluster backup.
 func GetSystemTableIDsToExcludeFromClusterBackup(
@@ -911,7 +1080,11 @@ func GetSystemTableIDsToExcludeFromClusterBackup(
 ) (map[descpb.ID]struct{}, error) {
 	systemTableIDsToExclude := make(map[descpb.ID]struct{})
 	for systemTableName, backupConfig := range systemTableBackupConfiguration {
-		if backupConfig.shouldIncludeInClusterBackup == optOutOfClusterBackup {
+		exclude := backupConfig.shouldIncludeInClusterBackup == optOutOfClusterBackup
+		if backupConfig.shouldIncludeInClusterBackup == optInToClusterBackupIfEnabled {
+			exclude = !backupConfig.includeSetting.Get(&execCfg.Settings.SV)
+		}
+		if exclude {
 			err := sql.DescsTxn(ctx, execCfg, func(ctx context.Context, txn isql.Txn, col *descs.Collection) error {
 				tn := tree.MakeTableNameWithSchema("system", catconstants.PublicSchemaName, tree.Name(systemTableName))
 				_, desc, err := descs.PrefixAndTable(ctx, col.ByNameWithLeased(txn.KV()).MaybeGet(), &tn)
@@ -54,7 +54,8 @@ func TestConfigurationDetailsOnlySetForIncludedTables(t *testing.T) {
 			// If some restore options were specified, we probably want to also
 			// include in in the set of system tables that are looked at by cluster
 			// backup/restore.
-			if optInToClusterBackup != configuration.shouldIncludeInClusterBackup {
+			if configuration.shouldIncludeInClusterBackup != optInToClusterBackup &&
+				configuration.shouldIncludeInClusterBackup != optInToClusterBackupIfEnabled {
 				t.Fatalf("custom restore function specified for table %q, but it's not included in cluster backups",
 					systemTable)
 			}
@@ -249,15 +249,13 @@ func getAllDescChanges(
 
 // fullClusterTargets returns all of the descriptors to be included in a full
 // cluster backup, along with all the "complete databases" that we are backing
-// up.
+// up. Only the system tables in systemTablesToBackup are included.
 func fullClusterTargets(
-	allDescs []catalog.Descriptor,
+	allDescs []catalog.Descriptor, systemTablesToBackup map[string]struct{},
 ) ([]catalog.Descriptor, []catalog.DatabaseDescriptor, error) {
 	fullClusterDescs := make([]catalog.Descriptor, 0, len(allDescs))
 	fullClusterDBs := make([]catalog.DatabaseDescriptor, 0)
 
-	systemTablesToBackup := GetSystemTablesToIncludeInClusterBackup()
-
 	for _, desc := range allDescs {
 		// If a descriptor is in the DROP state at `EndTime` we do not want to
 		// include it in the backup.
@@ -309,7 +307,7 @@ func fullClusterTargetsRestore(
 	_ = ctx // ctx is currently unused, but this new ctx should be used below in the future.
 	defer span.Finish()
 
-	fullClusterDescs, fullClusterDBs, err := fullClusterTargets(allDescs)
+	fullClusterDescs, fullClusterDBs, err := fullClusterTargets(allDescs, getSystemTablesToRestore())
 	var filteredDescs []catalog.Descriptor
 	var filteredDBs []catalog.DatabaseDescriptor
 	for _, desc := range fullClusterDescs {
@@ -345,7 +343,9 @@ func fullClusterTargetsBackup(
 		return nil, nil, err
 	}
 
-	fullClusterDescs, fullClusterDBs, err := fullClusterTargets(allDescs)
+	fullClusterDescs, fullClusterDBs, err := fullClusterTargets(
+		allDescs, getSystemTablesToBackUp(&execCfg.Settings.SV),
+	)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -696,6 +696,24 @@ func TestingGetFamilyIDFromKey(
 	return familyID, err
 }
 
+// MakeRowFromDatums returns a Row described by the given event descriptor,
+// holding the given datums, which are those of the public columns of the table
+// in order. Unlike the rows decoded from KVs, the datums of the row are read by
+// a SQL query, e.g. the rows of a table created by CREATE TABLE AS which are
+// published to a sink.
+func MakeRowFromDatums(ed *EventDescriptor, datums tree.Datums, mvcc hlc.Timestamp) Row {
+	encRow := make(rowenc.EncDatumRow, len(datums))
+	for i, d := range datums {
+		encRow[i] = rowenc.DatumToEncDatum(d.ResolvedType(), d)
+	}
+	return Row{
+		EventDescriptor: ed,
+		MvccTimestamp:   mvcc,
+		datums:          encRow,
+		alloc:           &tree.DatumAlloc{},
+	}
+}
+
 // MakeRowFromTuple converts a SQL datum produced by, for example, SELECT ROW(foo.*),
 // into the same kind of cdcevent.Row you'd get as a result of an insert, but without
 // the primary key.
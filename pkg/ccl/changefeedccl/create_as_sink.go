@@ -0,0 +1,229 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package changefeedccl
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/cdcevent"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/kvevent"
+	"github.com/cockroachdb/cockroach/pkg/ccl/utilccl"
+	"github.com/cockroachdb/cockroach/pkg/cloud/cloudprivilege"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowexec"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+)
+
+func init() {
+	sql.CreateTableAsSinkAuthorizeCCL = authorizeCreateTableAsSink
+	rowexec.NewCreateTableAsSink = makeCreateTableAsSink
+}
+
+// authorizeCreateTableAsSink implements sql.CreateTableAsSinkAuthorizeCCL. The
+// rows published to the sink are those of the source tables of the statement,
+// so it requires the license and the privileges of an enterprise changefeed on
+// them. The sink is also checked like the destination of a backup: a cloud
+// storage sink using implicit credentials requires the EXTERNALIOIMPLICITACCESS
+// privilege, and an external connection requires the USAGE privilege on it.
+func authorizeCreateTableAsSink(
+	ctx context.Context, p sql.PlanHookState, sinkURI string, sources []catalog.TableDescriptor,
+) error {
+	if err := utilccl.CheckEnterpriseEnabled(
+		p.ExecCfg().Settings, p.ExecCfg().NodeInfo.LogicalClusterID(), "CHANGEFEED",
+	); err != nil {
+		return err
+	}
+	hasSelectPrivOnAllTables, hasChangefeedPrivOnAllTables := true, true
+	for _, desc := range sources {
+		if desc.GetObjectType() != privilege.Table {
+			// Sequences and virtual tables can't be the targets of a changefeed,
+			// and reading them is authorized by the query of the statement.
+			continue
+		}
+		hasSelect, hasChangefeed, err := checkPrivilegesForDescriptor(ctx, p, desc)
+		if err != nil {
+			return err
+		}
+		hasSelectPrivOnAllTables = hasSelectPrivOnAllTables && hasSelect
+		hasChangefeedPrivOnAllTables = hasChangefeedPrivOnAllTables && hasChangefeed
+	}
+	if err := authorizeUserToCreateChangefeed(
+		ctx, p, sinkURI, hasSelectPrivOnAllTables, hasChangefeedPrivOnAllTables,
+	); err != nil {
+		return err
+	}
+	u, err := url.Parse(sinkURI)
+	if err != nil {
+		return err
+	}
+	if u.Scheme == changefeedbase.SinkSchemeExternalConnection || isCloudStorageSink(u) {
+		return cloudprivilege.CheckDestinationPrivileges(ctx, p, []string{sinkURI})
+	}
+	return nil
+}
+
+// createTableAsSink publishes the rows of the table of a CREATE TABLE AS ...
+// WITH (sink = ...) statement to a changefeed sink, encoded as the rows of the
+// initial scan of a changefeed on the table with the default options would be.
+type createTableAsSink struct {
+	sink       EventSink
+	encoder    Encoder
+	topic      TopicDescriptor
+	topicNamer *TopicNamer
+	ed         *cdcevent.EventDescriptor
+	// ts is the timestamp which the rows are read at, which is reported as
+	// their update and MVCC timestamps.
+	ts hlc.Timestamp
+}
+
+var _ rowexec.CreateTableAsSink = (*createTableAsSink)(nil)
+
+// createTableAsTimestampOracle is the timestamp lower bound of the rows
+// published by a createTableAsSink, which are all read at the same timestamp.
+type createTableAsTimestampOracle hlc.Timestamp
+
+func (o createTableAsTimestampOracle) inclusiveLowerBoundTS() hlc.Timestamp {
+	return hlc.Timestamp(o)
+}
+
+// makeCreateTableAsSink implements rowexec.NewCreateTableAsSink.
+func makeCreateTableAsSink(
+	ctx context.Context,
+	cfg *execinfra.ServerConfig,
+	user username.SQLUsername,
+	sinkURI string,
+	table catalog.TableDescriptor,
+	ts hlc.Timestamp,
+) (_ rowexec.CreateTableAsSink, retErr error) {
+	details := jobspb.ChangefeedDetails{
+		SinkURI: sinkURI,
+		Opts:    map[string]string{},
+		TargetSpecifications: []jobspb.ChangefeedTargetSpecification{{
+			Type:              jobspb.ChangefeedTargetSpecification_PRIMARY_FAMILY_ONLY,
+			TableID:           table.GetID(),
+			StatementTimeName: table.GetName(),
+		}},
+	}
+	targets := AllTargets(details)
+	sli, err := cfg.JobRegistry.MetricsStruct().Changefeed.(*Metrics).getSLIMetrics(defaultSLIScope)
+	if err != nil {
+		return nil, err
+	}
+	sink, err := getAndDialSink(ctx, cfg, details,
+		createTableAsTimestampOracle(ts), user, 0 /* jobID */, sli)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if retErr != nil {
+			_ = sink.Close()
+		}
+	}()
+
+	// Like a changefeed, the key and topic of the rows are included in their
+	// value for the sinks which don't deliver them otherwise.
+	opts := changefeedbase.MakeStatementOptions(details.Opts)
+	if requiresKeyInValue(sink) {
+		if err := opts.ForceKeyInValue(); err != nil {
+			return nil, err
+		}
+	}
+	if requiresTopicInValue(sink) {
+		if err := opts.ForceTopicInValue(); err != nil {
+			return nil, err
+		}
+	}
+	encodingOpts, err := opts.GetEncodingOptions()
+	if err != nil {
+		return nil, err
+	}
+	encoder, err := getEncoder(encodingOpts, targets, false, /* encodeForQuery */
+		makeExternalConnectionProvider(ctx, cfg.DB), sli)
+	if err != nil {
+		return nil, err
+	}
+	var topicNamer *TopicNamer
+	if encodingOpts.TopicInValue {
+		if topicNamer, err = MakeTopicNamer(targets); err != nil {
+			return nil, err
+		}
+	}
+
+	family, err := catalog.MustFindFamilyByID(table, 0 /* familyID */)
+	if err != nil {
+		return nil, err
+	}
+	ed, err := cdcevent.NewEventDescriptor(table, family, false /* includeVirtualColumns */, false /* keyOnly */, ts)
+	if err != nil {
+		return nil, err
+	}
+	var target changefeedbase.Target
+	if err := targets.EachTarget(func(t changefeedbase.Target) error {
+		target = t
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	topic, err := makeTopicDescriptorFromSpec(target, ed.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	return &createTableAsSink{
+		sink:       sink,
+		encoder:    encoder,
+		topic:      topic,
+		topicNamer: topicNamer,
+		ed:         ed,
+		ts:         ts,
+	}, nil
+}
+
+// EmitRow implements the rowexec.CreateTableAsSink interface.
+func (s *createTableAsSink) EmitRow(ctx context.Context, datums tree.Datums) error {
+	row := cdcevent.MakeRowFromDatums(s.ed, datums, s.ts)
+	evCtx := eventContext{updated: s.ts, mvcc: s.ts}
+	if s.topicNamer != nil {
+		topic, err := s.topicNamer.Name(s.topic)
+		if err != nil {
+			return err
+		}
+		evCtx.topic = topic
+	}
+	// NB: The encoded key and value are only valid until the next call to the
+	// encoder, so they are copied before they are enqueued.
+	encodedKey, err := s.encoder.EncodeKey(ctx, row)
+	if err != nil {
+		return err
+	}
+	key := append([]byte(nil), encodedKey...)
+	encodedValue, err := s.encoder.EncodeValue(ctx, evCtx, row, cdcevent.Row{})
+	if err != nil {
+		return err
+	}
+	value := append([]byte(nil), encodedValue...)
+	return s.sink.EmitRow(ctx, s.topic, key, value, s.ts, s.ts, kvevent.Alloc{})
+}
+
+// Flush implements the rowexec.CreateTableAsSink interface.
+func (s *createTableAsSink) Flush(ctx context.Context) error {
+	return s.sink.Flush(ctx)
+}
+
+// Close implements the rowexec.CreateTableAsSink interface.
+func (s *createTableAsSink) Close() error {
+	return s.sink.Close()
+}
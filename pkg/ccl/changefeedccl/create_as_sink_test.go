@@ -0,0 +1,94 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/licenses/CCL.txt
+
+package changefeedccl
+
+import (
+	"context"
+	gosql "database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/cdctest"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCreateTableAsSink tests that the rows of a table created by CREATE
+// TABLE AS ... WITH (sink = ...) are published to the sink.
+func TestCreateTableAsSink(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	srv, db, _ := serverutils.StartServer(t, base.TestServerArgs{
+		DefaultTestTenant: base.TODOTestTenantDisabled,
+	})
+	defer srv.Stopper().Stop(ctx)
+	sqlDB := sqlutils.MakeSQLRunner(db)
+	sqlDB.Exec(t, `CREATE TABLE src (k INT PRIMARY KEY, v STRING)`)
+	sqlDB.Exec(t, `INSERT INTO src VALUES (1, 'a'), (2, 'b'), (3, 'c')`)
+
+	cert, _, err := cdctest.NewCACertBase64Encoded()
+	require.NoError(t, err)
+	sinkDest, err := cdctest.StartMockWebhookSink(cert)
+	require.NoError(t, err)
+	defer sinkDest.Close()
+
+	sinkURI := fmt.Sprintf("webhook-%s?insecure_tls_skip_verify=true", sinkDest.URL())
+	sqlDB.Exec(t, `CREATE TABLE ctas WITH (sink = $1) AS SELECT k, v FROM src`, sinkURI)
+	sqlDB.CheckQueryResults(t, `SELECT count(*) FROM ctas`, [][]string{{"3"}})
+
+	// The statement waits for its schema change job, which publishes the rows
+	// before the table is made public.
+	var messages []string
+	for m := sinkDest.Pop(); m != ""; m = sinkDest.Pop() {
+		messages = append(messages, m)
+	}
+	published := strings.Join(messages, "\n")
+	for _, expected := range []string{
+		`"after": {"k": 1, "v": "a"}, "key": [1], "topic": "ctas"`,
+		`"after": {"k": 2, "v": "b"}, "key": [2], "topic": "ctas"`,
+		`"after": {"k": 3, "v": "c"}, "key": [3], "topic": "ctas"`,
+	} {
+		require.Contains(t, published, expected)
+	}
+
+	// An invalid sink fails the statement before the table is created.
+	sqlDB.ExpectErr(t, `unsupported sink`,
+		`CREATE TABLE ctas_bad WITH (sink = 'bogus://sink') AS SELECT k, v FROM src`)
+	sqlDB.ExpectErr(t, `relation "ctas_bad" does not exist`, `SELECT * FROM ctas_bad`)
+
+	// A sink URI holding secrets is rejected, since it is persisted in the
+	// descriptor of the table until the table is populated.
+	sqlDB.ExpectErr(t, `the sink URI can't hold secrets`,
+		`CREATE TABLE ctas_secret WITH (sink = 'kafka://nope:9092?sasl_password=secret') AS SELECT k, v FROM src`)
+
+	// Like a changefeed, publishing the rows of the source tables to a sink
+	// requires the CHANGEFEED privilege on them.
+	sqlDB.Exec(t, `CREATE USER testuser`)
+	sqlDB.Exec(t, `GRANT CREATE ON DATABASE defaultdb TO testuser`)
+	sqlDB.Exec(t, `GRANT SELECT ON src TO testuser`)
+	pgURL, cleanup := sqlutils.PGUrl(t, srv.ServingSQLAddr(), t.Name(), url.User(username.TestUser))
+	defer cleanup()
+	userConn, err := gosql.Open("postgres", pgURL.String())
+	require.NoError(t, err)
+	defer userConn.Close()
+	userDB := sqlutils.MakeSQLRunner(userConn)
+	createAsSink := fmt.Sprintf(`CREATE TABLE ctas_user WITH (sink = '%s') AS SELECT k, v FROM src`, sinkURI)
+	userDB.ExpectErr(t, `requires the CHANGEFEED privilege on all target tables`, createAsSink)
+	sqlDB.Exec(t, `GRANT CHANGEFEED ON src TO testuser`)
+	userDB.Exec(t, createAsSink)
+}
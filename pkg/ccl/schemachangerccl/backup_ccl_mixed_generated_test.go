@@ -23,6 +23,11 @@ func TestBackupMixedVersionElements_ccl_create_index(t *testing.T) {
 	defer log.Scope(t).Close(t)
 	sctest.BackupMixedVersionElements(t, "pkg/ccl/schemachangerccl/testdata/end_to_end/create_index", newMultiRegionMixedCluster)
 }
+func TestBackupMixedVersionElements_ccl_drop_column(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	sctest.BackupMixedVersionElements(t, "pkg/ccl/schemachangerccl/testdata/end_to_end/drop_column", newMultiRegionMixedCluster)
+}
 func TestBackupMixedVersionElements_ccl_drop_database_multiregion_primary_region(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
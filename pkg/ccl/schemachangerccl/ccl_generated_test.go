@@ -43,6 +43,31 @@ func TestRollback_ccl_create_index(t *testing.T) {
 	defer log.Scope(t).Close(t)
 	sctest.Rollback(t, "pkg/ccl/schemachangerccl/testdata/end_to_end/create_index", newMultiRegionCluster)
 }
+func TestBackup_ccl_drop_column(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	sctest.Backup(t, "pkg/ccl/schemachangerccl/testdata/end_to_end/drop_column", newMultiRegionCluster)
+}
+func TestEndToEndSideEffects_ccl_drop_column(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	sctest.EndToEndSideEffects(t, "pkg/ccl/schemachangerccl/testdata/end_to_end/drop_column", newMultiRegionCluster)
+}
+func TestGenerateSchemaChangeCorpus_ccl_drop_column(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	sctest.GenerateSchemaChangeCorpus(t, "pkg/ccl/schemachangerccl/testdata/end_to_end/drop_column", newMultiRegionCluster)
+}
+func TestPause_ccl_drop_column(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	sctest.Pause(t, "pkg/ccl/schemachangerccl/testdata/end_to_end/drop_column", newMultiRegionCluster)
+}
+func TestRollback_ccl_drop_column(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	sctest.Rollback(t, "pkg/ccl/schemachangerccl/testdata/end_to_end/drop_column", newMultiRegionCluster)
+}
 func TestBackup_ccl_drop_database_multiregion_primary_region(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
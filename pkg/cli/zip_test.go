@@ -79,6 +79,7 @@ table_name NOT IN (
 	'forward_dependencies',
 	'gossip_network',
 	'index_columns',
+	'job_profiler_capabilities',
   'index_spans',
   'kv_builtin_function_comments',
 	'kv_catalog_comments',
@@ -93,6 +94,7 @@ table_name NOT IN (
 	'kv_flow_token_deductions',
 	'lost_descriptors_with_data',
 	'table_columns',
+	'table_provenance',
 	'table_row_statistics',
 	'ranges',
 	'ranges_no_leases',
@@ -14,8 +14,11 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobsprofiler/profilerconstants"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/isql"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
@@ -159,7 +162,7 @@ func (i InfoStorage) iterate(
 	ctx context.Context,
 	iterMode iterateMode,
 	infoPrefix string,
-	fn func(infoKey string, value []byte) error,
+	fn func(infoKey string, value []byte, written time.Time) error,
 ) (retErr error) {
 	if i.txn == nil {
 		return errors.New("cannot iterate over the job info table without an associated txn")
@@ -182,7 +185,7 @@ func (i InfoStorage) iterate(
 	rows, err := i.txn.QueryIteratorEx(
 		ctx, "job-info-iter", i.txn.KV(),
 		sessiondata.NodeUserSessionDataOverride,
-		`SELECT info_key, value
+		`SELECT info_key, value, written
 		FROM system.job_info
 		WHERE job_id = $1 AND info_key >= $2 AND info_key < $3
 		`+iterConfig,
@@ -216,7 +219,11 @@ func (i InfoStorage) iterate(
 		if !ok {
 			return errors.AssertionFailedf("job info: expected value to be DBytes (was %T)", row[1])
 		}
-		if err = fn(infoKey, []byte(*value)); err != nil {
+		written, ok := row[2].(*tree.DTimestampTZ)
+		if !ok {
+			return errors.AssertionFailedf("job info: expected written to be DTimestampTZ (was %T)", row[2])
+		}
+		if err = fn(infoKey, []byte(*value), written.Time); err != nil {
 			return err
 		}
 	}
@@ -262,6 +269,19 @@ func (i InfoStorage) DeleteRange(ctx context.Context, startInfoKey, endInfoKey s
 // Iterate iterates though the info records for a given job and info key prefix.
 func (i InfoStorage) Iterate(
 	ctx context.Context, infoPrefix string, fn func(infoKey string, value []byte) error,
+) (retErr error) {
+	return i.iterate(ctx, iterateAll, infoPrefix,
+		func(infoKey string, value []byte, _ time.Time) error {
+			return fn(infoKey, value)
+		})
+}
+
+// IterateWithWritten is like Iterate, but also passes the time at which each
+// info record was written to fn.
+func (i InfoStorage) IterateWithWritten(
+	ctx context.Context,
+	infoPrefix string,
+	fn func(infoKey string, value []byte, written time.Time) error,
 ) (retErr error) {
 	return i.iterate(ctx, iterateAll, infoPrefix, fn)
 }
@@ -271,7 +291,10 @@ func (i InfoStorage) Iterate(
 func (i InfoStorage) GetLast(
 	ctx context.Context, infoPrefix string, fn func(infoKey string, value []byte) error,
 ) (retErr error) {
-	return i.iterate(ctx, getLast, infoPrefix, fn)
+	return i.iterate(ctx, getLast, infoPrefix,
+		func(infoKey string, value []byte, _ time.Time) error {
+			return fn(infoKey, value)
+		})
 }
 
 type iterateMode bool
@@ -318,6 +341,21 @@ func (i InfoStorage) WriteLegacyProgress(ctx context.Context, progress []byte) e
 	return i.Write(ctx, LegacyProgressKey, progress)
 }
 
+// GetRunAttempt returns the number of the current run of the job, as recorded
+// in the transaction which marks each run of the job started. The first run of
+// a job is attempt 1. It returns 0 if no run of the job was recorded.
+func (i InfoStorage) GetRunAttempt(ctx context.Context) (int, error) {
+	value, ok, err := i.Get(ctx, profilerconstants.RunAttemptInfoKey)
+	if err != nil || !ok {
+		return 0, err
+	}
+	attempt, err := strconv.Atoi(string(value))
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to parse the run attempt of job %d", i.j.ID())
+	}
+	return attempt, nil
+}
+
 // BackfillLegacyPayload copies a legacy payload from system.jobs. #104798.
 func (i InfoStorage) BackfillLegacyPayload(ctx context.Context) ([]byte, error) {
 	return i.backfillMissing(ctx, "payload")
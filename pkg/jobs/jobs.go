@@ -16,11 +16,13 @@ import (
 	gojson "encoding/json"
 	"fmt"
 	"reflect"
+	"strconv"
 	"sync/atomic"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/clusterversion"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobsprofiler/profilerconstants"
 	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/security/username"
 	"github.com/cockroachdb/cockroach/pkg/settings"
@@ -263,7 +265,7 @@ func (j *Job) taskName() string {
 // Started marks the tracked job as started by updating status to running in
 // jobs table.
 func (u Updater) started(ctx context.Context) error {
-	return u.Update(ctx, func(_ isql.Txn, md JobMetadata, ju *JobUpdater) error {
+	return u.Update(ctx, func(txn isql.Txn, md JobMetadata, ju *JobUpdater) error {
 		if md.Status != StatusPending && md.Status != StatusRunning {
 			return errors.Errorf("job with status %s cannot be marked started", md.Status)
 		}
@@ -282,6 +284,21 @@ func (u Updater) started(ctx context.Context) error {
 		if md.RunStats != nil {
 			ju.UpdateRunStats(md.RunStats.NumRuns+1, u.now())
 		}
+		// Record the attempt of this run of the job, so that the execution
+		// details persisted during the run can be attributed to it. Unlike
+		// num_runs, which is reset when the job is paused, the attempt counts
+		// every run of the job.
+		if u.j.registry.settings.Version.IsActive(ctx, clusterversion.V23_1) {
+			infoStorage := InfoStorageForJob(txn, u.j.ID())
+			attempt, err := infoStorage.GetRunAttempt(ctx)
+			if err != nil {
+				return err
+			}
+			if err := infoStorage.Write(ctx, profilerconstants.RunAttemptInfoKey,
+				[]byte(strconv.Itoa(attempt+1))); err != nil {
+				return err
+			}
+		}
 		return nil
 	})
 }
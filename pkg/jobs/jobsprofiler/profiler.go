@@ -13,6 +13,8 @@ package jobsprofiler
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strconv"
 
 	"github.com/cockroachdb/cockroach/pkg/jobs"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
@@ -21,13 +23,16 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/sql/isql"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
 	"github.com/cockroachdb/cockroach/pkg/util/stop"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 )
 
 // StorePlanDiagram stores the DistSQL diagram generated from p in the job info
-// table. The generation of the plan diagram and persistence to the info table
-// are done asynchronously and this method does not block on their completion.
+// table, along with a marshaled execinfrapb.PhysicalPlanSpec of p and the run
+// attempt of the job executing p. The generation of the plan diagram and
+// persistence to the info table are done asynchronously and this method does
+// not block on their completion.
 func StorePlanDiagram(
 	ctx context.Context, stopper *stop.Stopper, p *sql.PhysicalPlan, db isql.DB, jobID jobspb.JobID,
 ) {
@@ -44,9 +49,32 @@ func StorePlanDiagram(
 				return err
 			}
 
-			dspKey := profilerconstants.MakeDSPDiagramInfoKey(timeutil.Now().UnixNano())
+			now := timeutil.Now().UnixNano()
+			dspKey := profilerconstants.MakeDSPDiagramInfoKey(now)
 			infoStorage := jobs.InfoStorageForJob(txn, jobID)
-			return infoStorage.Write(ctx, dspKey, []byte(diagURL.String()))
+			if err := infoStorage.Write(ctx, dspKey, []byte(diagURL.String())); err != nil {
+				return err
+			}
+
+			// Also persist the flows as a proto, for consumers which need a
+			// structured representation of the plan.
+			planSpec := execinfrapb.MakePhysicalPlanSpec(flowSpecs)
+			planSpecBytes, err := protoutil.Marshal(&planSpec)
+			if err != nil {
+				return err
+			}
+			if err := infoStorage.Write(ctx, profilerconstants.MakeDSPPlanSpecInfoKey(now), planSpecBytes); err != nil {
+				return err
+			}
+
+			// Record the run of the job which executed the plan, so that the plans
+			// of successive runs can be told apart.
+			attempt, err := infoStorage.GetRunAttempt(ctx)
+			if err != nil || attempt == 0 {
+				return err
+			}
+			return infoStorage.Write(ctx, profilerconstants.MakeDSPAttemptInfoKey(now),
+				[]byte(strconv.Itoa(attempt)))
 		})
 		if err != nil {
 			log.Warningf(ctx, "failed to generate and write DistSQL diagram for job %d: %v",
@@ -58,6 +86,47 @@ func StorePlanDiagram(
 	}
 }
 
+// StoreFlowStats stores the execution statistics collected by the components
+// of the job's DistSQL flows, as extracted from the trace of the flows by
+// execinfrapb.ExtractStatsFromSpans, in the job info table. Nothing is stored
+// if no statistics were collected.
+func StoreFlowStats(
+	ctx context.Context,
+	db isql.DB,
+	jobID jobspb.JobID,
+	stats map[execinfrapb.ComponentID]*execinfrapb.ComponentStats,
+) {
+	if len(stats) == 0 {
+		return
+	}
+	var statsList execinfrapb.ComponentStatsList
+	for _, s := range stats {
+		statsList.Stats = append(statsList.Stats, *s)
+	}
+	sort.Slice(statsList.Stats, func(i, j int) bool {
+		a, b := statsList.Stats[i].Component, statsList.Stats[j].Component
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.SQLInstanceID != b.SQLInstanceID {
+			return a.SQLInstanceID < b.SQLInstanceID
+		}
+		return a.ID < b.ID
+	})
+	statsBytes, err := protoutil.Marshal(&statsList)
+	if err != nil {
+		log.Warningf(ctx, "failed to marshal flow stats for job %d: %v", jobID, err.Error())
+		return
+	}
+	if err := db.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		infoStorage := jobs.InfoStorageForJob(txn, jobID)
+		key := profilerconstants.MakeDSPFlowStatsInfoKey(timeutil.Now().UnixNano())
+		return infoStorage.Write(ctx, key, statsBytes)
+	}); err != nil {
+		log.Warningf(ctx, "failed to write flow stats for job %d: %v", jobID, err.Error())
+	}
+}
+
 // StorePerNodeProcessorProgressFraction stores the progress fraction for each
 // node and processor executing as part of the job's DistSQL flow as and when it
 // is sent on the progressCh. It is the callers responsibility to close the
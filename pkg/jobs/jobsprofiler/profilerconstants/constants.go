@@ -26,6 +26,43 @@ func MakeDSPDiagramInfoKey(timestampInNanos int64) string {
 	return fmt.Sprintf("%s%d", DSPDiagramInfoKeyPrefix, timestampInNanos)
 }
 
+// DSPPlanSpecInfoKeyPrefix is the prefix of the info key used for rows that
+// store a marshaled execinfrapb.PhysicalPlanSpec of the DistSQL plan being
+// executed by a job.
+const DSPPlanSpecInfoKeyPrefix = "~dsp-plan-spec-"
+
+// MakeDSPPlanSpecInfoKey constructs an ephemeral DSP plan spec info key.
+func MakeDSPPlanSpecInfoKey(timestampInNanos int64) string {
+	return fmt.Sprintf("%s%d", DSPPlanSpecInfoKeyPrefix, timestampInNanos)
+}
+
+// DSPAttemptInfoKeyPrefix is the prefix of the info key used for rows that
+// store the run attempt of the job, as recorded under RunAttemptInfoKey, during
+// which a DistSQL plan was stored. The row is keyed by the same timestamp as
+// the diagram and the spec of the plan.
+const DSPAttemptInfoKeyPrefix = "~dsp-attempt-"
+
+// MakeDSPAttemptInfoKey constructs an ephemeral DSP attempt info key.
+func MakeDSPAttemptInfoKey(timestampInNanos int64) string {
+	return fmt.Sprintf("%s%d", DSPAttemptInfoKeyPrefix, timestampInNanos)
+}
+
+// RunAttemptInfoKey is the info key used for the row that stores the number of
+// the current run of a job. The first run of a job is attempt 1, and every
+// subsequent run, e.g. after a retry, a pause or the failure of the node
+// running the job, increments it.
+const RunAttemptInfoKey = "~run-attempt"
+
+// DSPFlowStatsInfoKeyPrefix is the prefix of the info key used for rows that
+// store a marshaled execinfrapb.ComponentStatsList of the execution statistics
+// collected by the DistSQL flows of a job.
+const DSPFlowStatsInfoKeyPrefix = "~dsp-flow-stats-"
+
+// MakeDSPFlowStatsInfoKey constructs an ephemeral DSP flow stats info key.
+func MakeDSPFlowStatsInfoKey(timestampInNanos int64) string {
+	return fmt.Sprintf("%s%d", DSPFlowStatsInfoKeyPrefix, timestampInNanos)
+}
+
 // NodeProcessorProgressInfoKeyPrefix is the prefix of the info key used for
 // rows that store the per node, per processor progress for a job.
 const NodeProcessorProgressInfoKeyPrefix = "~node-processor-progress-"
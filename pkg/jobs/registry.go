@@ -14,6 +14,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -1569,6 +1570,19 @@ func RegisterConstructor(typ jobspb.Type, fn Constructor, opts ...RegisterOption
 	globalMu.options[typ] = resOpts
 }
 
+// RegisteredJobTypes returns the types of jobs for which a Resumer
+// constructor is registered, in ascending order.
+func RegisteredJobTypes() []jobspb.Type {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+	typs := make([]jobspb.Type, 0, len(globalMu.constructors))
+	for typ := range globalMu.constructors {
+		typs = append(typs, typ)
+	}
+	sort.Slice(typs, func(i, j int) bool { return typs[i] < typs[j] })
+	return typs
+}
+
 func (r *Registry) createResumer(job *Job, settings *cluster.Settings) (Resumer, error) {
 	payload := job.Payload()
 	fn := func() Constructor {
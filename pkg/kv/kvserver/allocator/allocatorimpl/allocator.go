@@ -1917,6 +1917,50 @@ func (a Allocator) RebalanceNonVoter(
 	)
 }
 
+// RebalanceCandidateScores returns the scores of the stores as candidates to
+// replace each existing voting replica of a range, along with the score of the
+// existing replica, as they are computed when considering a rebalance of the
+// range's voters, see RebalanceVoter. Unlike RebalanceVoter, it returns the
+// score of every candidate, rather than only of those which are better than
+// the existing replica, and doesn't consider whether the range should be
+// rebalanced at all. It is used to explain the rebalancing decisions made for
+// a range outside of the allocator, e.g. in simulation.
+func (a Allocator) RebalanceCandidateScores(
+	ctx context.Context,
+	storePool storepool.AllocatorStorePool,
+	conf roachpb.SpanConfig,
+	existingVoters, existingNonVoters []roachpb.ReplicaDescriptor,
+	options ScorerOptions,
+) []CandidateScore {
+	sl, _, _ := storePool.GetStoreList(storepool.StoreFilterThrottled)
+	existingReplicas := make([]roachpb.ReplicaDescriptor, 0, len(existingVoters)+len(existingNonVoters))
+	existingReplicas = append(existingReplicas, existingVoters...)
+	existingReplicas = append(existingReplicas, existingNonVoters...)
+	analyzedOverallConstraints := constraint.AnalyzeConstraints(
+		storePool,
+		existingReplicas,
+		conf.NumReplicas,
+		conf.Constraints,
+	)
+	analyzedVoterConstraints := constraint.AnalyzeConstraints(
+		storePool,
+		existingVoters,
+		conf.GetNumVoters(),
+		conf.VoterConstraints,
+	)
+	replicaSetForDiversityCalc := getReplicasForDiversityCalc(VoterTarget, existingVoters, existingReplicas)
+	return rebalanceCandidateScores(
+		ctx,
+		sl,
+		voterConstraintsCheckerForRemoval(analyzedOverallConstraints, analyzedVoterConstraints),
+		voterConstraintsCheckerForRebalance(analyzedOverallConstraints, analyzedVoterConstraints),
+		existingVoters,
+		storePool.GetLocalitiesByStore(replicaSetForDiversityCalc),
+		storePool.IsStoreReadyForRoutineReplicaTransfer,
+		options,
+	)
+}
+
 // ScorerOptions returns the default scorer option, for use in the rebalancing
 // machinery to achieve range count convergence.
 func (a *Allocator) ScorerOptions(ctx context.Context) *RangeCountScorerOptions {
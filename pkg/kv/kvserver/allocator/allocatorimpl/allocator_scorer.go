@@ -1778,6 +1778,148 @@ func rankedCandidateListForRebalancing(
 	return results
 }
 
+// CandidateScore is the score of a store as a candidate to replace an existing
+// replica of a range when rebalancing, or of the existing replica itself, see
+// Allocator.RebalanceCandidateScores.
+type CandidateScore struct {
+	// Existing is the store of the existing replica the candidate is compared
+	// to. It is the store of the candidate for the score of an existing replica.
+	Existing     roachpb.StoreID
+	StoreID      roachpb.StoreID
+	Valid        bool
+	FullDisk     bool
+	Necessary    bool
+	Diversity    float64
+	IOOverloaded bool
+	IOOverload   float64
+	Converges    int
+	Balance      int
+	RangeCount   int
+	// Better is true if the candidate is a better fit for the range than the
+	// existing replica, i.e. if the allocator would consider replacing the
+	// existing replica with it.
+	Better bool
+}
+
+func makeCandidateScore(existing, c candidate) CandidateScore {
+	return CandidateScore{
+		Existing:     existing.store.StoreID,
+		StoreID:      c.store.StoreID,
+		Valid:        c.valid,
+		FullDisk:     c.fullDisk,
+		Necessary:    c.necessary,
+		Diversity:    c.diversityScore,
+		IOOverloaded: c.ioOverloaded,
+		IOOverload:   c.ioOverloadScore,
+		Converges:    c.convergesScore,
+		Balance:      int(c.balanceScore),
+		RangeCount:   c.rangeCount,
+		Better:       c.store.StoreID != existing.store.StoreID && existing.less(c),
+	}
+}
+
+// rebalanceCandidateScores scores the existing replicas and every other store
+// as a candidate to replace them, in the same way as
+// rankedCandidateListForRebalancing, see Allocator.RebalanceCandidateScores.
+// The balance and converges scores of every candidate are computed against the
+// equivalence class of the existing replica, as the allocator does for the
+// candidates in the class. The scores are returned grouped by existing
+// replica, with the existing replica first followed by its candidates from
+// best to worst.
+func rebalanceCandidateScores(
+	ctx context.Context,
+	allStores storepool.StoreList,
+	removalConstraintsChecker constraintsCheckFn,
+	rebalanceConstraintsChecker rebalanceConstraintsCheckFn,
+	existingReplicas []roachpb.ReplicaDescriptor,
+	existingStoreLocalities map[roachpb.StoreID]roachpb.Locality,
+	isStoreValidForRoutineReplicaTransfer func(context.Context, roachpb.StoreID) bool,
+	options ScorerOptions,
+) []CandidateScore {
+	isExisting := make(map[roachpb.StoreID]bool, len(existingReplicas))
+	for _, repl := range existingReplicas {
+		isExisting[repl.StoreID] = true
+	}
+	curDiversityScore := RangeDiversityScore(existingStoreLocalities)
+
+	var scores []CandidateScore
+	for _, existingStore := range allStores.Stores {
+		if !isExisting[existingStore.StoreID] {
+			continue
+		}
+		valid, necessary := removalConstraintsChecker(existingStore)
+		existing := candidate{
+			store:          existingStore,
+			valid:          valid,
+			necessary:      necessary,
+			fullDisk:       !options.getDiskOptions().maxCapacityCheck(existingStore),
+			diversityScore: curDiversityScore,
+		}
+
+		var candidates, comparableCands candidateList
+		for _, store := range allStores.Stores {
+			if store.StoreID == existing.store.StoreID ||
+				!isStoreValidForRoutineReplicaTransfer(ctx, store.StoreID) {
+				continue
+			}
+			constraintsOK, necessary := rebalanceConstraintsChecker(store, existing.store)
+			cand := candidate{
+				store:          store,
+				valid:          constraintsOK,
+				necessary:      necessary,
+				fullDisk:       !options.getDiskOptions().maxCapacityCheck(store),
+				diversityScore: diversityRebalanceFromScore(store, existing.store.StoreID, existingStoreLocalities),
+			}
+			// NB: Like rankedCandidateListForRebalancing, the stores of the other
+			// existing replicas are part of the equivalence class, but aren't
+			// candidates themselves.
+			if !isExisting[store.StoreID] {
+				candidates = append(candidates, cand)
+			}
+			if !cand.less(existing) {
+				comparableCands = append(comparableCands, cand)
+			}
+		}
+		sort.Sort(sort.Reverse(byScoreAndID(comparableCands)))
+		bestCands := comparableCands.best()
+		bestStores := make([]roachpb.StoreDescriptor, len(bestCands))
+		for i := range bestCands {
+			bestStores[i] = bestCands[i].store
+		}
+		eqClass := equivalenceClass{
+			existing:    existing.store,
+			candidateSL: storepool.MakeStoreList(bestStores),
+			candidates:  bestCands,
+		}
+
+		if existing.valid {
+			existing.convergesScore = options.rebalanceFromConvergesScore(eqClass)
+			existing.balanceScore = options.balanceScore(eqClass.candidateSL, existing.store.Capacity)
+			existing.rangeCount = int(existing.store.Capacity.RangeCount)
+		}
+		for i := range candidates {
+			s := candidates[i].store
+			candidates[i].fullDisk = !options.getDiskOptions().rebalanceToMaxCapacityCheck(s)
+			candidates[i].ioOverloadScore, _ = s.Capacity.IOThreshold.Score()
+			candidates[i].ioOverloaded = !options.getIOOverloadOptions().rebalanceReplicaToCheck(
+				ctx,
+				s,
+				eqClass.candidateSL.CandidateIOOverloadScores.Mean,
+			)
+			candidates[i].balanceScore = options.balanceScore(eqClass.candidateSL, s.Capacity)
+			candidates[i].convergesScore = options.rebalanceToConvergesScore(eqClass, s)
+			candidates[i].rangeCount = int(s.Capacity.RangeCount)
+		}
+		sort.Sort(sort.Reverse(byScoreAndID(candidates)))
+
+		scores = append(scores, makeCandidateScore(existing, existing))
+		for _, cand := range candidates {
+			scores = append(scores, makeCandidateScore(existing, cand))
+		}
+	}
+	return scores
+}
+
 // bestRebalanceTarget returns the best target to try to rebalance to out of
 // the provided options, and removes it from the relevant candidate list.
 // Also returns the existing replicas that the chosen candidate was compared to.
@@ -2342,6 +2484,18 @@ func underfullRangeThreshold(options *RangeCountScorerOptions, mean float64) flo
 	return mean - math.Max(mean*options.rangeRebalanceThreshold, minRangeRebalanceThreshold)
 }
 
+// RangeCountThresholds returns the range counts below which a store is
+// considered underfull, and at or above which it is considered overfull, by
+// the range count scorer, given the mean range count of the candidate stores
+// and the range rebalance threshold. It is used to observe the classification
+// of stores outside of the allocator, e.g. in simulation.
+func RangeCountThresholds(
+	mean, rangeRebalanceThreshold float64,
+) (underfullThreshold, overfullThreshold float64) {
+	options := &RangeCountScorerOptions{rangeRebalanceThreshold: rangeRebalanceThreshold}
+	return underfullRangeThreshold(options, mean), overfullRangeThreshold(options, mean)
+}
+
 func rebalanceConvergesRangeCountOnMean(
 	sl storepool.StoreList, sc roachpb.StoreCapacity, newRangeCount int32,
 ) bool {
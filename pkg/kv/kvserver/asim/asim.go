@@ -12,6 +12,8 @@ package asim
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/config"
@@ -24,6 +26,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/storerebalancer"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/workload"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
 )
 
 // Simulator simulates an entire cluster, and runs the allocator of each store
@@ -61,6 +64,16 @@ type Simulator struct {
 
 	metrics *metrics.Tracker
 	history History
+
+	// lastMoves is the number of replica moves and lease transfers made as of
+	// the last tick, and idleTicks the number of consecutive ticks it has been
+	// unchanged for.
+	lastMoves int64
+	idleTicks int
+
+	// ticks is the number of ticks run so far, out of totalTicks.
+	ticks      int
+	totalTicks int
 }
 
 // History contains recorded information that summarizes a simulation run.
@@ -69,6 +82,14 @@ type Simulator struct {
 type History struct {
 	Recorded [][]metrics.StoreMetrics
 	S        state.State
+	// StabilizedAt is the tick at which the simulation stopped early, after
+	// making no replica moves or lease transfers for the configured number of
+	// stabilization ticks. It is zero when the simulation ran until its end.
+	StabilizedAt time.Time
+	// InvalidState is the invariant violation found when validating the state
+	// at the end of a tick, which stopped the simulation at that tick. It is
+	// nil unless the state is validated, see TestingValidateState.
+	InvalidState error
 }
 
 // Listen implements the metrics.StoreMetricListener interface.
@@ -89,13 +110,16 @@ func NewSimulator(
 	rqs := make(map[state.StoreID]queue.RangeQueue)
 	sqs := make(map[state.StoreID]queue.RangeQueue)
 	srs := make(map[state.StoreID]storerebalancer.StoreRebalancer)
-	changer := state.NewReplicaChanger()
+	changer := state.NewBudgetedReplicaChanger(
+		settings.TickInterval, settings.ReplicaMoveBudget, settings.LeaseTransferBudget,
+		settings.SnapshotSendBytesPerSecond, settings.SnapshotRecvBytesPerSecond)
 	controllers := make(map[state.StoreID]op.Controller)
 
 	s := &Simulator{
 		AmbientContext: log.MakeTestingAmbientCtxWithNewTracer(),
 		curr:           settings.StartTime,
 		end:            settings.StartTime.Add(duration),
+		totalTicks:     int(duration / settings.TickInterval),
 		interval:       settings.TickInterval,
 		generators:     wgs,
 		state:          initialState,
@@ -216,6 +240,10 @@ func (s *Simulator) RunSim(ctx context.Context) {
 		// Tick any events.
 		s.tickEvents(ctx, tick)
 
+		// Update the state with the background load of each store, ahead of
+		// the generated load which it competes with.
+		s.state.ApplyBackgroundLoad()
+
 		// Update the state with generated load.
 		s.tickWorkload(ctx, tick)
 
@@ -235,21 +263,52 @@ func (s *Simulator) RunSim(ctx context.Context) {
 		// Simulate the store rebalancer logic.
 		s.tickStoreRebalancers(ctx, tick, stateForAlloc)
 
+		// Count the tick as idle if the allocators made no moves.
+		s.tickIdle()
+
+		// Record the work backed up in each store's queues.
+		s.tickQueueLengths()
+
 		// Print tick metrics.
 		s.tickMetrics(ctx, tick)
+
+		// Log the progress of the simulation.
+		s.tickProgress(ctx, tick)
+
+		// Check the state remains consistent after this tick's changes.
+		if s.settings.TestingValidateState {
+			if err := s.state.Validate(); err != nil {
+				log.Errorf(ctx, "invalid state (tick=%s): %v", tick, err)
+				s.history.InvalidState = errors.Wrapf(err, "invalid state at tick %s", tick)
+				break
+			}
+		}
+
+		// Stop early if the simulation has reached a steady state.
+		if s.stabilized() {
+			log.Infof(ctx, "stabilized after %d ticks without moves (tick=%s)", s.idleTicks, tick)
+			s.history.StabilizedAt = tick
+			break
+		}
 	}
+	s.metrics.Close(ctx)
 }
 
-// tickWorkload gets the next workload events and applies them to state.
+// tickWorkload gets the next workload events and applies them to state. The
+// events of all generators are applied as a single load batch, so that store
+// admission considers them in priority order regardless of the order in which
+// the generators are ticked.
 func (s *Simulator) tickWorkload(ctx context.Context, tick time.Time) {
 	s.shuffler(
 		len(s.generators),
 		func(i, j int) { s.generators[i], s.generators[j] = s.generators[j], s.generators[i] },
 	)
+	var lb workload.LoadBatch
 	for _, generator := range s.generators {
-		event := generator.Tick(tick)
-		s.state.ApplyLoad(event)
+		lb = append(lb, generator.Tick(tick)...)
 	}
+	sort.Stable(lb)
+	s.state.ApplyLoad(lb)
 }
 
 // tickStateChanges ticks atomic pending changes, in the changer. Then, for
@@ -276,8 +335,8 @@ func (s *Simulator) tickStoreClocks(tick time.Time) {
 }
 
 // tickQueues iterates over the next replicas for each store to
-// consider. It then enqueues each of these and ticks the replicate queue for
-// processing.
+// consider, up to the allocator decision budget. It then enqueues each of
+// these and ticks the replicate queue for processing.
 func (s *Simulator) tickQueues(ctx context.Context, tick time.Time, state state.State) {
 	stores := s.state.Stores()
 	s.shuffler(len(stores), func(i, j int) { stores[i], stores[j] = stores[j], stores[i] })
@@ -300,7 +359,12 @@ func (s *Simulator) tickQueues(ctx context.Context, tick time.Time, state state.
 			}
 		}
 
-		for {
+		evaluated := 0
+		budget := s.settings.AllocatorDecisionBudget
+		for budget <= 0 || evaluated < budget {
+			// NB: Once the decision budget is exhausted, the remaining
+			// replicas due at this tick are left with the pacer, which
+			// returns them on the following ticks.
 			r := s.pacers[storeID].Next(tick)
 			if r == nil {
 				// No replicas to consider at this tick.
@@ -312,12 +376,14 @@ func (s *Simulator) tickQueues(ctx context.Context, tick time.Time, state state.
 			if !r.HoldsLease() {
 				continue
 			}
+			evaluated++
 
 			// Try adding the replica to the split queue.
 			s.sqs[storeID].MaybeAdd(ctx, r, state)
 			// Try adding the replica to the replicate queue.
 			s.rqs[storeID].MaybeAdd(ctx, r, state)
 		}
+		state.ClusterUsageInfo().RecordEvaluatedRanges(storeID, evaluated)
 	}
 }
 
@@ -331,11 +397,67 @@ func (s *Simulator) tickStoreRebalancers(ctx context.Context, tick time.Time, st
 	}
 }
 
+// tickQueueLengths records the length of the replicate, lease and split queues
+// of each store. The lease queue of a store is made up of the lease transfers
+// dispatched by its store rebalancer, which are not yet done.
+func (s *Simulator) tickQueueLengths() {
+	usage := s.state.ClusterUsageInfo()
+	for _, store := range s.state.Stores() {
+		storeID := store.StoreID()
+		usage.RecordQueueLengths(
+			storeID,
+			s.rqs[storeID].Len(),
+			s.controllers[storeID].PendingLeaseTransfers(),
+			s.sqs[storeID].Len(),
+		)
+	}
+}
+
 // tickMetrics prints the metrics up to the given tick.
 func (s *Simulator) tickMetrics(ctx context.Context, tick time.Time) {
 	s.metrics.Tick(ctx, tick, s.state)
 }
 
+// tickProgress counts the tick, and writes the progress of the simulation to
+// the progress writer every ProgressLogTicks ticks.
+func (s *Simulator) tickProgress(ctx context.Context, tick time.Time) {
+	s.ticks++
+	w, every := s.settings.ProgressWriter, s.settings.ProgressLogTicks
+	if w == nil || every <= 0 || s.ticks%every != 0 {
+		return
+	}
+	if _, err := fmt.Fprintf(w, "tick %d/%d (%s)\n", s.ticks, s.totalTicks, tick.Sub(s.settings.StartTime)); err != nil {
+		log.Errorf(ctx, "error writing simulation progress %s", err.Error())
+	}
+}
+
+// tickIdle records whether any replica move or lease transfer was made in this
+// tick. Ticks without one are counted towards the cluster's idle ticks.
+func (s *Simulator) tickIdle() {
+	usage := s.state.ClusterUsageInfo()
+	var moves int64
+	for _, u := range usage.StoreUsage {
+		moves += u.Rebalances + u.LeaseTransfers
+	}
+	if moves != s.lastMoves {
+		s.lastMoves = moves
+		s.idleTicks = 0
+		return
+	}
+	s.idleTicks++
+	usage.IdleTicks++
+}
+
+// stabilized returns true once there have been no replica moves or lease
+// transfers for the configured number of consecutive ticks. It always returns
+// false when stabilization ticks aren't configured.
+func (s *Simulator) stabilized() bool {
+	if s.settings.StabilizationTicks <= 0 {
+		return false
+	}
+	return s.idleTicks >= s.settings.StabilizationTicks
+}
+
 // tickEvents ticks the registered simulation events.
 func (s *Simulator) tickEvents(ctx context.Context, tick time.Time) {
 	var idx int
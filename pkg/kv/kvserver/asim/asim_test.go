@@ -12,15 +12,22 @@ package asim_test
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/config"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/event"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/metrics"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/workload"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/liveness/livenesspb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/admission/admissionpb"
+	"github.com/cockroachdb/errors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -38,6 +45,65 @@ func TestRunAllocatorSimulator(t *testing.T) {
 	sim.RunSim(ctx)
 }
 
+// invalidAfterState is a state which fails validation once it has been
+// validated a number of times.
+type invalidAfterState struct {
+	state.State
+	validations, invalidAfter int
+}
+
+// Validate implements the state.State interface.
+func (s *invalidAfterState) Validate() error {
+	s.validations++
+	if s.validations > s.invalidAfter {
+		return errors.New("r1 has no replicas")
+	}
+	return s.State.Validate()
+}
+
+// TestRunAllocatorSimulatorValidateState asserts that the state remains
+// consistent at the end of every tick of a simulation moving replicas and
+// leases, and that a simulation whose state becomes inconsistent stops at that
+// tick and reports the violation, rather than panicking.
+func TestRunAllocatorSimulatorValidateState(t *testing.T) {
+	ctx := context.Background()
+	duration := 1000 * time.Second
+	newSettings := func() *config.SimulationSettings {
+		settings := config.DefaultSimulationSettings()
+		settings.TickInterval = 10 * time.Second
+		settings.TestingValidateState = true
+		return settings
+	}
+	newWorkload := func(settings *config.SimulationSettings) []workload.Generator {
+		return []workload.Generator{
+			workload.TestCreateWorkloadGenerator(settings.Seed, settings.StartTime, 1, 10),
+		}
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		settings := newSettings()
+		s := state.LoadConfig(state.ComplexConfig, state.SingleRangeConfig, settings)
+		sim := asim.NewSimulator(duration, newWorkload(settings), s, settings, metrics.NewTracker(settings.MetricsInterval))
+		sim.RunSim(ctx)
+		require.NoError(t, sim.History().InvalidState)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		settings := newSettings()
+		s := &invalidAfterState{
+			State:        state.LoadConfig(state.ComplexConfig, state.SingleRangeConfig, settings),
+			invalidAfter: 3,
+		}
+		sim := asim.NewSimulator(duration, newWorkload(settings), s, settings, metrics.NewTracker(settings.MetricsInterval))
+		sim.RunSim(ctx)
+		// The simulation stopped at the fourth tick, where the state was first
+		// found to be invalid.
+		require.Equal(t, 4, s.validations)
+		require.EqualError(t, sim.History().InvalidState,
+			fmt.Sprintf("invalid state at tick %s: r1 has no replicas", settings.StartTime.Add(4*settings.TickInterval)))
+	})
+}
+
 func TestAllocatorSimulatorDeterministic(t *testing.T) {
 
 	settings := config.DefaultSimulationSettings()
@@ -90,3 +156,434 @@ func TestAllocatorSimulatorDeterministic(t *testing.T) {
 		require.Equal(t, refRun.Recorded, history.Recorded)
 	}
 }
+
+// TestVerifyReplay asserts that two runs of the same seeded simulation are
+// verified as identical, and that the first tick and column at which two runs
+// diverge are reported otherwise.
+func TestVerifyReplay(t *testing.T) {
+	ctx := context.Background()
+	settings := config.DefaultSimulationSettings()
+	duration := 5 * time.Minute
+	settings.TickInterval = 10 * time.Second
+
+	stores := 4
+	ranges := 100
+	keyspace := 3 * ranges
+	// NB: All of the replicas start on the first three stores, so that the
+	// allocators rebalance towards the last store during the run.
+	replicaDistribution := []float64{1.0 / 3, 1.0 / 3, 1.0 / 3, 0}
+	newSim := func(seed func() int64) func(m *metrics.Tracker) *asim.Simulator {
+		return func(m *metrics.Tracker) *asim.Simulator {
+			rwg := []workload.Generator{
+				workload.TestCreateWorkloadGenerator(seed(), settings.StartTime, stores, int64(keyspace)),
+			}
+			s := state.NewStateWithDistribution(replicaDistribution, ranges, 3 /* replicationFactor */, keyspace, settings)
+			return asim.NewSimulator(duration, rwg, s, settings, m)
+		}
+	}
+
+	require.NoError(t, asim.VerifyReplay(ctx, settings.TickInterval,
+		newSim(func() int64 { return settings.Seed })))
+
+	// A workload seeded differently in each run makes the runs diverge.
+	seed := settings.Seed
+	err := asim.VerifyReplay(ctx, settings.TickInterval, newSim(func() int64 {
+		seed++
+		return seed
+	}))
+	require.Error(t, err)
+	require.Regexp(t, "^runs diverged at tick [0-9]+ \\(.+\\): store [0-9]+ column [a-z_]+: .+ != .+$",
+		err.Error())
+}
+
+// TestDiskWriteStall asserts that leases transfer away from a store whose
+// writes are stalled and that writes with a leaseholder on the stalled store
+// are rejected while it is stalled.
+func TestDiskWriteStall(t *testing.T) {
+	ctx := context.Background()
+	settings := config.DefaultSimulationSettings()
+	duration := 15 * time.Minute
+	stallStart := settings.StartTime.Add(time.Minute)
+	stallDuration := 12 * time.Minute
+	keyspace := 10000
+	stalledStore := state.StoreID(1)
+
+	rwg := []workload.Generator{
+		workload.TestCreateWorkloadGenerator(settings.Seed, settings.StartTime, 500, int64(keyspace)),
+	}
+	m := metrics.NewTracker(settings.MetricsInterval) // no output
+	s := state.NewStateEvenDistribution(5, 100, 3, keyspace, settings)
+	events := event.NewDiskWriteStallEvents(stalledStore, stallStart, stallDuration)
+
+	sim := asim.NewSimulator(duration, rwg, s, settings, m, events...)
+	sim.RunSim(ctx)
+	history := sim.History()
+
+	stallEnd := stallStart.Add(stallDuration)
+	var leasesBeforeStall, leasesDuringStall int64
+	var stalledWriteBytes int64
+	for _, sms := range history.Recorded {
+		sm := sms[stalledStore-1]
+		require.Equal(t, int64(stalledStore), sm.StoreID)
+		switch {
+		case sm.Tick.Before(stallStart):
+			require.Zero(t, sm.WriteStalled)
+			leasesBeforeStall = sm.Leases
+		case sm.Tick.Before(stallEnd):
+			require.Equal(t, int64(1), sm.WriteStalled)
+			leasesDuringStall = sm.Leases
+			stalledWriteBytes = sm.StalledWriteBytes
+		default:
+			require.Zero(t, sm.WriteStalled)
+		}
+	}
+	require.Greater(t, leasesBeforeStall, int64(0))
+	// The stalled store's node fails its liveness heartbeats, so its leases
+	// should be transferred away before the stall ends.
+	require.Less(t, leasesDuringStall, leasesBeforeStall/2)
+	require.Greater(t, stalledWriteBytes, int64(0))
+}
+
+// fixedGenerator generates the same load batch every tick.
+type fixedGenerator struct {
+	lb workload.LoadBatch
+}
+
+// Tick implements the workload.Generator interface.
+func (g fixedGenerator) Tick(time.Time) workload.LoadBatch {
+	return append(workload.LoadBatch(nil), g.lb...)
+}
+
+// TestAdmissionAcrossGenerators asserts that store admission sheds low
+// priority load before high priority load generated by a different generator,
+// whichever order the generators are ticked in.
+func TestAdmissionAcrossGenerators(t *testing.T) {
+	ctx := context.Background()
+	for seed := int64(1); seed <= 5; seed++ {
+		settings := config.DefaultSimulationSettings()
+		settings.Seed = seed
+		settings.TickInterval = time.Second
+		settings.AdmissionWriteBytesPerSecond = 1000
+		// Each tick, the store receives 1200 bytes of writes from two
+		// generators, whilst only being able to admit 1000 bytes.
+		rwg := []workload.Generator{
+			workload.NewPriorityGenerator(fixedGenerator{workload.LoadBatch{
+				{Key: 10, Writes: 1, WriteSize: 600},
+			}}, admissionpb.LowPri),
+			workload.NewPriorityGenerator(fixedGenerator{workload.LoadBatch{
+				{Key: 20, Writes: 1, WriteSize: 600},
+			}}, admissionpb.HighPri),
+		}
+		m := metrics.NewTracker(settings.MetricsInterval) // no output
+		s := state.NewStateWithReplCounts(map[state.StoreID]int{1: 1}, 1 /* replicationFactor */, 1000 /* keyspace */, settings)
+
+		sim := asim.NewSimulator(time.Minute, rwg, s, settings, m)
+		sim.RunSim(ctx)
+
+		usage := s.ClusterUsageInfo()
+		require.Zero(t, usage.AdmissionUsage[admissionpb.HighPri].RejectedBytes, "seed %d", seed)
+		require.Greater(t, usage.AdmissionUsage[admissionpb.LowPri].RejectedBytes, int64(0), "seed %d", seed)
+	}
+}
+
+// TestReplicateQueueLength asserts that the replicate queues back up when
+// the replicas are imbalanced across the stores, then drain as the
+// rebalancing moves complete.
+func TestReplicateQueueLength(t *testing.T) {
+	ctx := context.Background()
+	settings := config.DefaultSimulationSettings()
+	duration := 30 * time.Minute
+	settings.TickInterval = 2 * time.Second
+
+	stores := 6
+	replsPerRange := 3
+	ranges := 300
+	keyspace := 3 * ranges
+
+	// NB: Half of the stores have all of the replicas, the other half have
+	// none.
+	replicaDistribution := make([]float64, stores)
+	for i := 0; i < stores/2; i++ {
+		replicaDistribution[i] = 1.0 / float64(stores/2)
+	}
+	rwg := []workload.Generator{
+		workload.TestCreateWorkloadGenerator(settings.Seed, settings.StartTime, stores, int64(keyspace)),
+	}
+	m := metrics.NewTracker(settings.TickInterval) // no output
+	s := state.NewStateWithDistribution(replicaDistribution, ranges, replsPerRange, keyspace, settings)
+
+	sim := asim.NewSimulator(duration, rwg, s, settings, m)
+	sim.RunSim(ctx)
+	history := sim.History()
+
+	queued := make([]int64, len(history.Recorded))
+	var peak int64
+	for i, sms := range history.Recorded {
+		for _, sm := range sms {
+			require.GreaterOrEqual(t, sm.ReplicateQueueLength, int64(0))
+			queued[i] += sm.ReplicateQueueLength
+		}
+		if queued[i] > peak {
+			peak = queued[i]
+		}
+	}
+	require.Greater(t, peak, int64(0))
+	require.Less(t, queued[len(queued)-1], peak)
+}
+
+// TestStabilizationStopsEarly asserts that a simulation configured with
+// stabilization ticks stops before its end time once the initially imbalanced
+// cluster has been rebalanced and no more moves occur.
+func TestStabilizationStopsEarly(t *testing.T) {
+	ctx := context.Background()
+	settings := config.DefaultSimulationSettings()
+	duration := 4 * time.Hour
+	settings.TickInterval = 2 * time.Second
+	settings.StabilizationTicks = 150
+
+	stores := 6
+	replsPerRange := 3
+	ranges := 300
+	keyspace := 3 * ranges
+
+	// NB: Half of the stores have all of the replicas, the other half have
+	// none.
+	replicaDistribution := make([]float64, stores)
+	for i := 0; i < stores/2; i++ {
+		replicaDistribution[i] = 1.0 / float64(stores/2)
+	}
+	rwg := []workload.Generator{
+		workload.TestCreateWorkloadGenerator(settings.Seed, settings.StartTime, stores, int64(keyspace)),
+	}
+	m := metrics.NewTracker(settings.TickInterval) // no output
+	s := state.NewStateWithDistribution(replicaDistribution, ranges, replsPerRange, keyspace, settings)
+
+	sim := asim.NewSimulator(duration, rwg, s, settings, m)
+	sim.RunSim(ctx)
+	history := sim.History()
+
+	require.False(t, history.StabilizedAt.IsZero())
+	require.True(t, history.StabilizedAt.Before(settings.StartTime.Add(duration)))
+	// The cluster should have been rebalanced towards the empty stores before
+	// stabilizing.
+	last := history.Recorded[len(history.Recorded)-1]
+	for _, sm := range last {
+		require.Greater(t, sm.Replicas, int64(0))
+	}
+}
+
+// TestIdleTicks asserts that nearly every tick of a simulation starting from a
+// balanced cluster is idle, whereas the early ticks of a simulation starting
+// from an imbalanced cluster are active, as the allocators move replicas
+// towards the empty stores.
+func TestIdleTicks(t *testing.T) {
+	ctx := context.Background()
+	settings := config.DefaultSimulationSettings()
+	duration := 10 * time.Minute
+	settings.TickInterval = 2 * time.Second
+	totalTicks := int64(duration / settings.TickInterval)
+
+	stores := 6
+	replsPerRange := 3
+	ranges := 300
+	keyspace := 3 * ranges
+
+	run := func(replicaDistribution []float64) (idle int64, recorded [][]metrics.StoreMetrics) {
+		rwg := []workload.Generator{
+			workload.TestCreateWorkloadGenerator(settings.Seed, settings.StartTime, stores, int64(keyspace)),
+		}
+		m := metrics.NewTracker(settings.TickInterval) // no output
+		s := state.NewStateWithDistribution(replicaDistribution, ranges, replsPerRange, keyspace, settings)
+		sim := asim.NewSimulator(duration, rwg, s, settings, m)
+		sim.RunSim(ctx)
+		history := sim.History()
+		return history.S.ClusterUsageInfo().IdleTicks, history.Recorded
+	}
+
+	balanced := make([]float64, stores)
+	for i := range balanced {
+		balanced[i] = 1.0 / float64(stores)
+	}
+	balancedIdle, _ := run(balanced)
+	require.Greater(t, balancedIdle, totalTicks*9/10)
+
+	// NB: Half of the stores have all of the replicas, the other half have
+	// none.
+	imbalanced := make([]float64, stores)
+	for i := 0; i < stores/2; i++ {
+		imbalanced[i] = 1.0 / float64(stores/2)
+	}
+	imbalancedIdle, recorded := run(imbalanced)
+	require.Less(t, imbalancedIdle, balancedIdle)
+	// The early ticks should be active, so the idle tick count shouldn't keep
+	// up with the number of ticks elapsed at the start of the run.
+	early := recorded[len(recorded)/10]
+	require.Less(t, early[0].IdleTicks, int64(len(recorded)/10))
+}
+
+// TestProgressLog asserts that the progress of a simulation is written to the
+// progress writer at the configured cadence.
+func TestProgressLog(t *testing.T) {
+	ctx := context.Background()
+	settings := config.DefaultSimulationSettings()
+	duration := 5 * time.Minute
+	settings.TickInterval = 10 * time.Second
+	settings.ProgressLogTicks = 7
+	var progress strings.Builder
+	settings.ProgressWriter = &progress
+
+	rwg := []workload.Generator{
+		workload.TestCreateWorkloadGenerator(settings.Seed, settings.StartTime, 3, 100),
+	}
+	m := metrics.NewTracker(settings.MetricsInterval) // no output
+	s := state.NewStateWithDistribution([]float64{0.4, 0.3, 0.3}, 10, 3, 100, settings)
+	sim := asim.NewSimulator(duration, rwg, s, settings, m)
+	sim.RunSim(ctx)
+
+	// 30 ticks are run, so the progress is logged every 7 ticks, 4 times.
+	lines := strings.Split(strings.TrimSpace(progress.String()), "\n")
+	require.Equal(t, []string{
+		"tick 7/30 (1m10s)",
+		"tick 14/30 (2m20s)",
+		"tick 21/30 (3m30s)",
+		"tick 28/30 (4m40s)",
+	}, lines)
+}
+
+// TestAllocatorDecisionBudget asserts that a tight allocator decision budget
+// limits the ranges each store evaluates per tick, and that an imbalanced
+// cluster with many ranges then takes more ticks to converge than with an
+// unlimited budget.
+func TestAllocatorDecisionBudget(t *testing.T) {
+	ctx := context.Background()
+	duration := 30 * time.Minute
+
+	stores := 6
+	replsPerRange := 3
+	ranges := 300
+	keyspace := 3 * ranges
+
+	run := func(budget int) (convergedAt time.Time, converged bool, recorded [][]metrics.StoreMetrics) {
+		settings := config.DefaultSimulationSettings()
+		settings.TickInterval = 2 * time.Second
+		// NB: The pacer visits every replica once a minute, so that without a
+		// budget each store evaluates several ranges per tick.
+		settings.PacerLoopInterval = time.Minute
+		settings.AllocatorDecisionBudget = budget
+
+		// NB: Half of the stores have all of the replicas, the other half have
+		// none. There is no load, so there are no splits and only the replica
+		// count drives rebalancing.
+		replicaDistribution := make([]float64, stores)
+		for i := 0; i < stores/2; i++ {
+			replicaDistribution[i] = 1.0 / float64(stores/2)
+		}
+		s := state.NewStateWithDistribution(replicaDistribution, ranges, replsPerRange, keyspace, settings)
+		et := metrics.NewPlacementEntropyTracker(metrics.DefaultEntropyConvergenceThreshold) // no output
+		m := metrics.NewTracker(settings.TickInterval, et)
+
+		sim := asim.NewSimulator(duration, []workload.Generator{}, s, settings, m)
+		sim.RunSim(ctx)
+		convergedAt, converged = et.ConvergedAt()
+		return convergedAt, converged, sim.History().Recorded
+	}
+
+	unlimitedAt, ok, recorded := run(0 /* budget */)
+	require.True(t, ok)
+	var peak int64
+	for _, sms := range recorded {
+		for _, sm := range sms {
+			if sm.EvaluatedRanges > peak {
+				peak = sm.EvaluatedRanges
+			}
+		}
+	}
+	require.Greater(t, peak, int64(1))
+
+	budgetedAt, ok, recorded := run(1 /* budget */)
+	for _, sms := range recorded {
+		for _, sm := range sms {
+			require.LessOrEqual(t, sm.EvaluatedRanges, int64(1))
+		}
+	}
+	// The budgeted simulation may not converge at all within the duration,
+	// otherwise it should converge later than the unlimited one.
+	if ok {
+		require.True(t, budgetedAt.After(unlimitedAt),
+			"budgeted converged at %s, unlimited converged at %s", budgetedAt, unlimitedAt)
+	}
+}
+
+// TestCompareRunsFailure injects the same store failure into two runs which
+// only differ in their replica move budget, and asserts that the run with the
+// higher budget recovers from the failure faster.
+func TestCompareRunsFailure(t *testing.T) {
+	ctx := context.Background()
+	duration := 20 * time.Minute
+
+	stores := 5
+	ranges := 100
+	// NB: The replicas are spread evenly, however none of the leases are on
+	// the last store, which fails.
+	replicaWeights := make([]float64, stores)
+	leaseWeights := make([]float64, stores)
+	for i := range replicaWeights {
+		replicaWeights[i] = 1.0 / float64(stores)
+		if i < stores-1 {
+			leaseWeights[i] = 1.0 / float64(stores-1)
+		}
+	}
+	failedStore := state.StoreID(stores)
+	newState := func(settings *config.SimulationSettings) state.State {
+		s := state.LoadClusterInfo(state.ClusterInfoWithStoreCount(stores, 1 /* storesPerNode */), settings)
+		storeIDs := make([]state.StoreID, stores)
+		for i, store := range s.Stores() {
+			storeIDs[i] = store.StoreID()
+		}
+		state.LoadRangeInfo(s, state.RangesInfoWithDistribution(
+			storeIDs,
+			replicaWeights,
+			leaseWeights,
+			ranges,
+			roachpb.SpanConfig{NumReplicas: 3, NumVoters: 3},
+			int64(state.MinKey),
+			int64(3*ranges),
+			0, /* rangeSize */
+		)...)
+		return s
+	}
+	run := func(name string, budget int) asim.RunConfig {
+		settings := config.DefaultSimulationSettings()
+		settings.TickInterval = time.Second
+		settings.PacerLoopInterval = 10 * time.Second
+		settings.ReplicaMoveBudget = budget
+		return asim.RunConfig{Name: name, Settings: settings}
+	}
+	a, b := run("budget=1", 1), run("budget=10", 10)
+	failure := event.DelayedEvent{
+		At: a.Settings.StartTime.Add(time.Minute),
+		EventFn: func(ctx context.Context, tick time.Time, s state.State) {
+			store, ok := s.Store(failedStore)
+			require.True(t, ok)
+			s.SetNodeLiveness(store.NodeID(), livenesspb.NodeLivenessStatus_DEAD)
+		},
+	}
+
+	rc, err := asim.CompareRuns(ctx, duration, newState, []event.DelayedEvent{failure}, a, b)
+	require.NoError(t, err)
+	for _, r := range rc.Runs {
+		require.False(t, r.Recovering, "%s", rc)
+		require.GreaterOrEqual(t, r.RecoveryTicks, int64(0), "%s", rc)
+	}
+	require.Less(t, rc.Runs[1].RecoveryTicks, rc.Runs[0].RecoveryTicks, "%s", rc)
+	report := strings.Split(rc.String(), "\n")
+	require.Regexp(t, "^ +budget=1 +budget=10$", report[0])
+	require.Regexp(t, fmt.Sprintf("^recovery_ticks +%d +%d$",
+		rc.Runs[0].RecoveryTicks, rc.Runs[1].RecoveryTicks), report[1])
+
+	// The failure can only be injected at the same tick of runs which tick at
+	// the same interval.
+	b.Settings.TickInterval = 2 * time.Second
+	_, err = asim.CompareRuns(ctx, duration, newState, []event.DelayedEvent{failure}, a, b)
+	require.Error(t, err)
+}
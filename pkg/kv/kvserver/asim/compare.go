@@ -0,0 +1,121 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package asim
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/config"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/event"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/metrics"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/workload"
+	"github.com/cockroachdb/errors"
+)
+
+// RunConfig is a named configuration of a simulation run, which is compared
+// against another by CompareRuns.
+type RunConfig struct {
+	Name     string
+	Settings *config.SimulationSettings
+}
+
+// RunResult contains the recovery metrics of a simulation run.
+type RunResult struct {
+	Name string
+	// RecoveryTicks is the number of ticks the cluster took to recover from
+	// the last store failure it recovered from, see metrics.RecoveryTracker.
+	// It is -1 if the cluster didn't recover from any failure.
+	RecoveryTicks int64
+	// Recovering is true when the cluster was still recovering from a store
+	// failure at the end of the run.
+	Recovering bool
+	// ReplicaMoves is the number of replicas moved by the allocators over the
+	// run.
+	ReplicaMoves int64
+	History      History
+}
+
+// RunComparison contains the results of the runs compared by CompareRuns.
+type RunComparison struct {
+	Runs [2]RunResult
+}
+
+// String returns the recovery metrics of the runs side by side.
+func (rc RunComparison) String() string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 2, 1, 2, ' ', 0)
+	fmt.Fprintf(w, "\t%s\t%s\n", rc.Runs[0].Name, rc.Runs[1].Name)
+	fmt.Fprintf(w, "recovery_ticks\t%d\t%d\n", rc.Runs[0].RecoveryTicks, rc.Runs[1].RecoveryTicks)
+	fmt.Fprintf(w, "recovering\t%t\t%t\n", rc.Runs[0].Recovering, rc.Runs[1].Recovering)
+	fmt.Fprintf(w, "replica_moves\t%d\t%d\n", rc.Runs[0].ReplicaMoves, rc.Runs[1].ReplicaMoves)
+	_ = w.Flush()
+	return buf.String()
+}
+
+// CompareRuns runs a simulation for each of the two configurations given, and
+// returns their recovery metrics for comparison. Both runs start from a state
+// returned by newState, and have the same events injected at the same ticks,
+// e.g. the failure of the same store, so that the runs only differ by their
+// settings. There is no workload, so that the allocators only react to the
+// events. Since the events are injected at the same ticks, the runs must have
+// the same start time and tick interval, otherwise an error is returned.
+func CompareRuns(
+	ctx context.Context,
+	duration time.Duration,
+	newState func(settings *config.SimulationSettings) state.State,
+	events []event.DelayedEvent,
+	a, b RunConfig,
+) (RunComparison, error) {
+	if !a.Settings.StartTime.Equal(b.Settings.StartTime) ||
+		a.Settings.TickInterval != b.Settings.TickInterval {
+		return RunComparison{}, errors.Newf(
+			"runs %s and %s must have the same start time and tick interval to inject the same events",
+			a.Name, b.Name)
+	}
+	var rc RunComparison
+	for i, run := range []RunConfig{a, b} {
+		rc.Runs[i] = compareRun(ctx, duration, newState(run.Settings), events, run)
+	}
+	return rc, nil
+}
+
+func compareRun(
+	ctx context.Context,
+	duration time.Duration,
+	s state.State,
+	events []event.DelayedEvent,
+	run RunConfig,
+) RunResult {
+	rt := metrics.NewRecoveryTracker(run.Settings.TickInterval) // no output
+	m := metrics.NewTracker(run.Settings.TickInterval)
+	m.RegisterStateListener(rt)
+
+	// NB: The simulator expects the events in order of their ticks.
+	runEvents := make([]event.DelayedEvent, len(events))
+	copy(runEvents, events)
+	sort.Stable(event.DelayedEventList(runEvents))
+	sim := NewSimulator(duration, []workload.Generator{}, s, run.Settings, m, runEvents...)
+	sim.RunSim(ctx)
+
+	result := RunResult{Name: run.Name, History: sim.History()}
+	result.RecoveryTicks, _ = rt.RecoveryTicks()
+	_, result.Recovering = rt.Recovering()
+	for _, u := range s.ClusterUsageInfo().StoreUsage {
+		result.ReplicaMoves += u.Rebalances
+	}
+	return result
+}
@@ -10,7 +10,10 @@
 
 package config
 
-import "time"
+import (
+	"io"
+	"time"
+)
 
 const (
 	defaultTickInteval             = 500 * time.Millisecond
@@ -33,6 +36,8 @@ const (
 	defaultLBRebalanceQPSThreshold = 0.1
 	defaultLBMinRequiredQPSDiff    = 200
 	defaultLBRebalancingObjective  = 0 // QPS
+	defaultIntraRegionLatency      = time.Millisecond
+	defaultInterRegionLatency      = 60 * time.Millisecond
 )
 
 var (
@@ -88,6 +93,13 @@ type SimulationSettings struct {
 	// PacerMaxIterIterval is the maximum amount of time the pacer may wait
 	// between visiting replicas.
 	PacerMaxIterIterval time.Duration
+	// AllocatorDecisionBudget is the maximum number of ranges each store's
+	// allocator evaluates per tick, modeling the limited time the allocator
+	// has to make decisions. Ranges the pacer would visit beyond the budget
+	// are deferred to later ticks, so that the pacer continues round-robin
+	// from where it left off. When zero or less, there is no budget and every
+	// range the pacer visits is evaluated.
+	AllocatorDecisionBudget int
 	// StateExchangeInterval is the interval at which state updates will be
 	// broadcast to other stores.
 	StateExchangeInterval time.Duration
@@ -115,6 +127,93 @@ type SimulationSettings struct {
 	// rebalancer would care to reconcile (via lease or replica rebalancing) between
 	// any two stores.
 	LBMinRequiredQPSDiff float64
+	// AdmissionWriteBytesPerSecond is the rate of write bytes per second that a
+	// store will admit, before shedding load. Load is shed in order of
+	// ascending priority. When zero or less, all load is admitted.
+	AdmissionWriteBytesPerSecond int64
+	// ReplicaMoveBudget is the maximum number of replica changes that will be
+	// initiated in a single tick. Replica changes beyond the budget are queued
+	// and initiated in a later tick. When zero or less, there is no limit.
+	ReplicaMoveBudget int
+	// LeaseTransferBudget is the maximum number of lease transfers that will
+	// be initiated in a single tick. Lease transfers beyond the budget are
+	// queued and initiated in a later tick. When zero or less, there is no
+	// limit.
+	LeaseTransferBudget int
+	// SnapshotSendBytesPerSecond and SnapshotRecvBytesPerSecond are the
+	// bandwidth of each store for sending and receiving the snapshots of
+	// replica changes. A store sends, and receives, one snapshot at a time, at
+	// the lower of the sender's and the receiver's rates, so that further
+	// snapshots queue behind it. When zero or less, the bandwidth is
+	// unlimited.
+	SnapshotSendBytesPerSecond int64
+	SnapshotRecvBytesPerSecond int64
+	// SecondaryIndexes is the number of secondary indexes of the table written
+	// to by the workload. Each logical write fans out to a KV write per index,
+	// in addition to the write to the primary index, which amplifies the write
+	// load and disk usage of the ranges written to. Since the simulated
+	// keyspace has no notion of tables or indexes, the index writes apply to
+	// the range of the primary index write.
+	SecondaryIndexes int
+	// IntraRegionLatency is the round-trip latency between two stores, or a
+	// store and a client, in the same region. Stores and clients without a
+	// region are considered to be in the same region as any other.
+	IntraRegionLatency time.Duration
+	// InterRegionLatency is the round-trip latency between two stores, or a
+	// store and a client, in different regions.
+	InterRegionLatency time.Duration
+	// StorageClassReadLatency is the latency added to a read served by a
+	// store, keyed by the storage class of the store, e.g. "ssd" or "hdd". The
+	// storage class of a store is the first of its attributes with an entry
+	// here. Reads are served by the replica with the lowest latency, so they
+	// are routed away from slower storage classes where possible. When empty,
+	// the storage of a store adds no latency.
+	StorageClassReadLatency map[string]time.Duration
+	// WriteConcurrencyLimit is the maximum number of writes which may be in
+	// flight on a leaseholder store at once, each until it is committed by a
+	// quorum of voters. Writes beyond the limit are rejected. When zero or
+	// less, there is no limit.
+	WriteConcurrencyLimit int
+	// LeaseTransferStallTicks is the number of ticks after a lease transfer,
+	// for which the range rejects all load, modeling the brief unavailability
+	// of the range whilst the new leaseholder acquires the lease and warms up.
+	// When zero or less, lease transfers don't stall the range.
+	LeaseTransferStallTicks int
+	// LeaseReacquisitionTicks is the number of ticks after a node restarts,
+	// for which the ranges whose leaseholder is on one of its stores reject
+	// all load, modeling the time taken to reacquire their leases. When zero
+	// or less, the leases are reacquired immediately.
+	LeaseReacquisitionTicks int
+	// ReplicaGCDelay is the delay after a replica is removed from a store,
+	// before the replica is garbage collected. Until then, the store still
+	// accounts for the disk space used by the replica. When zero or less,
+	// removed replicas are garbage collected immediately.
+	ReplicaGCDelay time.Duration
+	// BackgroundReadBytesPerSecond and BackgroundWriteBytesPerSecond are the
+	// rates of read and write bytes per second consumed on every store by
+	// background processes, such as MVCC GC, compactions and consistency
+	// checks, independent of the workload. Background writes consume the
+	// store's write admission tokens ahead of foreground load. When zero or
+	// less, there is no background load.
+	BackgroundReadBytesPerSecond  int64
+	BackgroundWriteBytesPerSecond int64
+	// StabilizationTicks is the number of consecutive ticks without any
+	// replica moves or lease transfers, after which the simulation is
+	// considered to have reached a steady state and stops early. When zero or
+	// less, the simulation runs until its end time.
+	StabilizationTicks int
+	// ProgressWriter is written a line reporting the progress of the
+	// simulation, such as "tick 5000/100000", every ProgressLogTicks ticks.
+	// This is separate from the metrics output, and is intended to show that
+	// a long simulation is still running. When nil, or when ProgressLogTicks
+	// is zero or less, no progress is logged.
+	ProgressWriter   io.Writer
+	ProgressLogTicks int
+	// TestingValidateState controls whether the state is validated at the end
+	// of every tick. When true, the simulation stops on the first tick where
+	// the state violates one of its invariants, which is reported in the
+	// simulation's history. This is intended for tests.
+	TestingValidateState bool
 }
 
 // DefaultSimulationSettings returns a set of default settings for simulation.
@@ -141,6 +240,8 @@ func DefaultSimulationSettings() *SimulationSettings {
 		LBRebalancingInterval:   defaultLBRebalancingInterval,
 		LBRebalanceQPSThreshold: defaultLBRebalanceQPSThreshold,
 		LBMinRequiredQPSDiff:    defaultLBMinRequiredQPSDiff,
+		IntraRegionLatency:      defaultIntraRegionLatency,
+		InterRegionLatency:      defaultInterRegionLatency,
 	}
 }
 
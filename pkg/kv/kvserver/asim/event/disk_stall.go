@@ -0,0 +1,42 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package event
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+)
+
+// NewDiskWriteStallEvents returns the events which simulate a disk write
+// stall on the store with ID storeID. The writes of the store are stalled at
+// the time given and resume after the duration given. While stalled, writes
+// to ranges whose leaseholder is on the store are rejected and the store's
+// node fails its liveness heartbeats, see state.SetStoreWriteStall.
+func NewDiskWriteStallEvents(
+	storeID state.StoreID, at time.Time, duration time.Duration,
+) DelayedEventList {
+	return DelayedEventList{
+		{
+			At: at,
+			EventFn: func(ctx context.Context, tick time.Time, s state.State) {
+				s.SetStoreWriteStall(storeID, true)
+			},
+		},
+		{
+			At: at.Add(duration),
+			EventFn: func(ctx context.Context, tick time.Time, s state.State) {
+				s.SetStoreWriteStall(storeID, false)
+			},
+		},
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/metrics"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/workload"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
 )
 
 // SettingsGen provides a method to generate simulations settings given a seed.
@@ -200,6 +201,18 @@ type BasicRanges struct {
 	KeySpace          int
 	ReplicationFactor int
 	Bytes             int64
+	// ReplicationFactorOverrides replace the replication factor of the ranges
+	// within their key spans, applied in order after the ranges are created.
+	ReplicationFactorOverrides []ReplicationFactorOverride
+}
+
+// ReplicationFactorOverride is a replication factor which applies to the
+// ranges in the key span [StartKey, EndKey), in place of the replication
+// factor the ranges were created with. This is used to simulate clusters with
+// mixed zone configs, e.g. where some tables have 5 replicas and others 3.
+type ReplicationFactorOverride struct {
+	StartKey, EndKey  int64
+	ReplicationFactor int
 }
 
 // Generate returns an updated simulator state, where the cluster is loaded
@@ -219,6 +232,17 @@ func (br BasicRanges) Generate(
 		rangeInfo.Size = br.Bytes
 	}
 	state.LoadRangeInfo(s, rangesInfo...)
+	for _, o := range br.ReplicationFactorOverrides {
+		// Keep the rest of the span config the ranges were created with, only
+		// the number of replicas is overridden.
+		conf := s.RangeFor(state.Key(o.StartKey)).SpanConfig()
+		conf.NumReplicas = int32(o.ReplicationFactor)
+		conf.NumVoters = int32(o.ReplicationFactor)
+		s.SetSpanConfig(roachpb.Span{
+			Key:    state.Key(o.StartKey).ToRKey().AsRawKey(),
+			EndKey: state.Key(o.EndKey).ToRKey().AsRawKey(),
+		}, conf)
+	}
 	return s
 }
 
@@ -0,0 +1,88 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package gen
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/config"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/metrics"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+)
+
+// HotRangeScenario is an end-to-end evaluation of how the allocator resolves a
+// hotspot. The cluster starts with a single range, which receives a skewed
+// (zipfian) read workload at a rate above the load based split threshold. The
+// range is expected to be split by load, and the resulting ranges rebalanced
+// across the cluster by the store rebalancer, dissipating the hotspot over
+// time.
+type HotRangeScenario struct {
+	// Stores is the number of stores in the cluster, each on its own node.
+	Stores int
+	// ReplicationFactor is the replication factor of the hot range.
+	ReplicationFactor int
+	// KeySpace is the number of keys in the hot range.
+	KeySpace int
+	// Rate is the rate, in ops/s, of the skewed workload.
+	Rate float64
+	// SplitQPSThreshold is the QPS above which a range will be split by load.
+	SplitQPSThreshold float64
+}
+
+// DefaultHotRangeScenario returns a HotRangeScenario where a three store
+// cluster receives a load of four times the default split threshold.
+func DefaultHotRangeScenario() HotRangeScenario {
+	settings := config.DefaultSimulationSettings()
+	return HotRangeScenario{
+		Stores:            3,
+		ReplicationFactor: 3,
+		KeySpace:          10000,
+		Rate:              4 * settings.SplitQPSThreshold,
+		SplitQPSThreshold: settings.SplitQPSThreshold,
+	}
+}
+
+// Run runs the scenario for the given duration and returns the QPS of every
+// range at each sampled tick, which forms the hotspot resolution timeline. The
+// heatmap's MaxQPS is the QPS of the hottest range over time.
+func (hrs HotRangeScenario) Run(
+	ctx context.Context, duration time.Duration, seed int64,
+) *metrics.RangeQPSHeatmap {
+	settings := config.DefaultSimulationSettings()
+	settings.Seed = seed
+	settings.SplitQPSThreshold = hrs.SplitQPSThreshold
+	// Rebalance both leases and replicas based on load, so that the ranges
+	// split off the hot range are moved away from its store.
+	settings.LBRebalancingMode = 2
+
+	s := state.NewStateEvenDistribution(
+		hrs.Stores, 1 /* ranges */, hrs.ReplicationFactor, hrs.KeySpace, settings)
+	// The workload is read only, so that ranges are only split by load and not
+	// by size.
+	load := BasicLoad{
+		RWRatio:      1,
+		Rate:         hrs.Rate,
+		SkewedAccess: true,
+		MinBlockSize: 1,
+		MaxBlockSize: 1,
+		MinKey:       0,
+		MaxKey:       int64(hrs.KeySpace),
+	}
+
+	heatmap := metrics.NewRangeQPSHeatmap()
+	tracker := metrics.NewTracker(settings.MetricsInterval)
+	tracker.RegisterStateListener(heatmap)
+	sim := asim.NewSimulator(duration, load.Generate(seed, settings), s, settings, tracker)
+	sim.RunSim(ctx)
+	return heatmap
+}
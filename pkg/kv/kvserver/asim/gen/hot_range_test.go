@@ -0,0 +1,42 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package gen
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestHotRangeScenario asserts that the hot range is split and its load spread
+// out, so that the QPS of the hottest range drops over the run.
+func TestHotRangeScenario(t *testing.T) {
+	ctx := context.Background()
+	scenario := DefaultHotRangeScenario()
+	maxQPS := scenario.Run(ctx, 5*time.Minute, 42 /* seed */).MaxQPS()
+	require.NotEmpty(t, maxQPS)
+
+	// Initially, all of the load is on the single hot range. By the end of the
+	// run, it should have been split into ranges which each receive at most
+	// around the split threshold. A single key may still be hotter than the
+	// threshold under a zipfian workload, so allow some leeway.
+	threshold := 2 * scenario.SplitQPSThreshold
+	var peak float64
+	for _, qps := range maxQPS {
+		if qps > peak {
+			peak = qps
+		}
+	}
+	require.Greater(t, peak, threshold)
+	require.Less(t, maxQPS[len(maxQPS)-1], threshold)
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+	"github.com/cockroachdb/cockroach/pkg/util/admission/admissionpb"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding/csv"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// AdmissionTracker writes the write bytes admitted and rejected by store
+// admission for each priority, in a CSV format. There is a row per priority at
+// each tick, ordered by descending priority.
+type AdmissionTracker struct {
+	writers []*csv.Writer
+}
+
+var _ StateListener = &AdmissionTracker{}
+
+// NewAdmissionTracker returns a new AdmissionTracker which writes to the
+// writers given. It should be registered against a Tracker using
+// RegisterStateListener.
+func NewAdmissionTracker(writers ...io.Writer) *AdmissionTracker {
+	at := &AdmissionTracker{}
+	for _, w := range writers {
+		at.writers = append(at.writers, csv.NewWriter(w))
+	}
+	// The admitted and rejected bytes are cumulative, up to the tick.
+	_ = at.write([]string{"tick", "priority", "c_admitted_b", "c_rejected_b"})
+	return at
+}
+
+func (at *AdmissionTracker) write(record []string) error {
+	for _, w := range at.writers {
+		if err := w.Write(record); err != nil {
+			return err
+		}
+		w.Flush()
+	}
+	return nil
+}
+
+// ListenState implements the StateListener interface.
+func (at *AdmissionTracker) ListenState(ctx context.Context, tick time.Time, s state.State) {
+	usage := s.ClusterUsageInfo().AdmissionUsage
+	priorities := make([]admissionpb.WorkPriority, 0, len(usage))
+	for priority := range usage {
+		priorities = append(priorities, priority)
+	}
+	sort.Slice(priorities, func(i, j int) bool { return priorities[i] > priorities[j] })
+
+	for _, priority := range priorities {
+		u := usage[priority]
+		record := []string{
+			tick.String(),
+			priority.String(),
+			fmt.Sprintf("%d", u.AdmittedBytes),
+			fmt.Sprintf("%d", u.RejectedBytes),
+		}
+		if err := at.write(record); err != nil {
+			log.Errorf(ctx, "Error writing admission metrics %s", err.Error())
+		}
+	}
+}
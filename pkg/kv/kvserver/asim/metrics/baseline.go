@@ -0,0 +1,140 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/cockroachdb/cockroach/pkg/util/encoding/csv"
+	"github.com/cockroachdb/errors"
+)
+
+// Tolerance is the relative difference from a baseline value which is
+// accepted when comparing the CSV output of a tracker against a baseline,
+// e.g. 0.01 accepts values within 1% of the baseline value.
+type Tolerance struct {
+	// Default is the tolerance of numeric columns not in Columns.
+	Default float64
+	// Columns is the tolerance of specific columns, keyed by the column name
+	// in the header.
+	Columns map[string]float64
+}
+
+func (t Tolerance) of(column string) float64 {
+	if tol, ok := t.Columns[column]; ok {
+		return tol
+	}
+	return t.Default
+}
+
+// BaselineMismatch is a value of a run which differs from its baseline value
+// by more than the column's tolerance.
+type BaselineMismatch struct {
+	// Tick is the value of the first column of the row, which is the tick for
+	// the output of the trackers.
+	Tick   string
+	Column string
+	// Value and Baseline are the values of the run and the baseline. They are
+	// only set for numeric values.
+	Value, Baseline float64
+	// RawValue and RawBaseline are the values of the run and the baseline as
+	// written.
+	RawValue, RawBaseline string
+	Tolerance             float64
+}
+
+// String returns a description of the mismatch.
+func (m BaselineMismatch) String() string {
+	if m.Tolerance == 0 {
+		return fmt.Sprintf("tick %s column %s: %s differs from the baseline %s",
+			m.Tick, m.Column, m.RawValue, m.RawBaseline)
+	}
+	return fmt.Sprintf("tick %s column %s: %s differs from the baseline %s by more than %g%%",
+		m.Tick, m.Column, m.RawValue, m.RawBaseline, m.Tolerance*100)
+}
+
+// CompareBaseline compares the CSV output of a run against a baseline with
+// the same header, row by row. A numeric value matches its baseline value if
+// the relative difference between them is within the column's tolerance, so a
+// baseline value of zero must match exactly. Non numeric values, such as the
+// tick, must match exactly. The values which don't match are returned, in the
+// order they appear in the run. An error is returned if either CSV can't be
+// parsed, or if the run and the baseline don't have the same header and number
+// of rows.
+func CompareBaseline(run, baseline io.Reader, tolerance Tolerance) ([]BaselineMismatch, error) {
+	runRows, err := csv.NewReader(run).ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing run")
+	}
+	baselineRows, err := csv.NewReader(baseline).ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing baseline")
+	}
+	if len(runRows) == 0 || len(baselineRows) == 0 {
+		return nil, errors.Newf("expected a header, found %d run rows and %d baseline rows",
+			len(runRows), len(baselineRows))
+	}
+
+	header, baselineHeader := recordValues(runRows[0]), recordValues(baselineRows[0])
+	if len(header) != len(baselineHeader) {
+		return nil, errors.Newf("run header %v doesn't match the baseline header %v",
+			header, baselineHeader)
+	}
+	for i := range header {
+		if header[i] != baselineHeader[i] {
+			return nil, errors.Newf("run header %v doesn't match the baseline header %v",
+				header, baselineHeader)
+		}
+	}
+	if len(runRows) != len(baselineRows) {
+		return nil, errors.Newf("run has %d rows, whilst the baseline has %d rows",
+			len(runRows)-1, len(baselineRows)-1)
+	}
+
+	var mismatches []BaselineMismatch
+	for i := 1; i < len(runRows); i++ {
+		runRow, baselineRow := recordValues(runRows[i]), recordValues(baselineRows[i])
+		for j, column := range header {
+			m := BaselineMismatch{
+				Tick:        runRow[0],
+				Column:      column,
+				RawValue:    runRow[j],
+				RawBaseline: baselineRow[j],
+				Tolerance:   tolerance.of(column),
+			}
+			value, valueErr := strconv.ParseFloat(m.RawValue, 64)
+			base, baseErr := strconv.ParseFloat(m.RawBaseline, 64)
+			if valueErr != nil || baseErr != nil {
+				m.Tolerance = 0
+				if m.RawValue != m.RawBaseline {
+					mismatches = append(mismatches, m)
+				}
+				continue
+			}
+			m.Value, m.Baseline = value, base
+			if math.Abs(value-base) > m.Tolerance*math.Abs(base) {
+				mismatches = append(mismatches, m)
+			}
+		}
+	}
+	return mismatches, nil
+}
+
+func recordValues(records []csv.Record) []string {
+	values := make([]string, len(records))
+	for i, r := range records {
+		values[i] = r.Val
+	}
+	return values
+}
@@ -0,0 +1,73 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package metrics_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCompareBaseline asserts that values within the tolerance of their
+// column match the baseline, whilst the values outside of it are reported
+// along with their tick and column.
+func TestCompareBaseline(t *testing.T) {
+	const baseline = `tick,c_write,c_replica_moves
+2022-03-21 11:00:10 +0000 UTC,1000,0
+2022-03-21 11:00:20 +0000 UTC,2000,4
+`
+	tolerance := metrics.Tolerance{
+		Default: 0.01,
+		Columns: map[string]float64{"c_replica_moves": 0.5},
+	}
+	compare := func(run string) []string {
+		mismatches, err := metrics.CompareBaseline(
+			strings.NewReader(run), strings.NewReader(baseline), tolerance)
+		require.NoError(t, err)
+		var ret []string
+		for _, m := range mismatches {
+			ret = append(ret, m.String())
+		}
+		return ret
+	}
+
+	require.Empty(t, compare(baseline))
+	// Every value is within the tolerance of its column.
+	require.Empty(t, compare(`tick,c_write,c_replica_moves
+2022-03-21 11:00:10 +0000 UTC,1009,0
+2022-03-21 11:00:20 +0000 UTC,1981,6
+`))
+	// A baseline value of zero must match exactly, and the tick isn't numeric.
+	require.Equal(t, []string{
+		"tick 2022-03-21 11:00:10 +0000 UTC column c_write: 1011 differs from the baseline 1000 by more than 1%",
+		"tick 2022-03-21 11:00:10 +0000 UTC column c_replica_moves: 1 differs from the baseline 0 by more than 50%",
+		"tick 2022-03-21 11:00:30 +0000 UTC column tick: 2022-03-21 11:00:30 +0000 UTC differs from the baseline 2022-03-21 11:00:20 +0000 UTC",
+		"tick 2022-03-21 11:00:30 +0000 UTC column c_replica_moves: 7 differs from the baseline 4 by more than 50%",
+	}, compare(`tick,c_write,c_replica_moves
+2022-03-21 11:00:10 +0000 UTC,1011,1
+2022-03-21 11:00:30 +0000 UTC,2000,7
+`))
+
+	for _, tc := range []struct {
+		run, err string
+	}{
+		{"tick,c_read,c_replica_moves\n", "doesn't match the baseline header"},
+		{"tick,c_write,c_replica_moves\n2022-03-21 11:00:10 +0000 UTC,1000,0\n",
+			"run has 1 rows, whilst the baseline has 2 rows"},
+		{"tick,c_write,c_replica_moves\n2022-03-21 11:00:10 +0000 UTC,1000\n", "parsing run"},
+	} {
+		_, err := metrics.CompareBaseline(
+			strings.NewReader(tc.run), strings.NewReader(baseline), tolerance)
+		require.ErrorContains(t, err, tc.err)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding/csv"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// CandidateScoreTracker writes the allocator's scores of every store as a
+// candidate to replace each voter of the traced ranges, at each sampled tick,
+// in a CSV format. The scores are those computed by the allocator of the
+// range's leaseholder store when considering a rebalance of the range, with a
+// row per existing voter and candidate store. This is verbose, so ranges are
+// traced only when marked, it is intended for debugging why a particular range
+// is, or isn't, rebalanced, e.g. by comparing the scores of a candidate store
+// to those of the existing replica it would replace.
+type CandidateScoreTracker struct {
+	ranges      []state.RangeID
+	writers     []*csv.Writer
+	wroteHeader bool
+}
+
+var _ StateListener = &CandidateScoreTracker{}
+
+// NewCandidateScoreTracker returns a new CandidateScoreTracker which traces
+// the ranges given and writes to the writers given. It should be registered
+// against a Tracker using RegisterStateListener.
+func NewCandidateScoreTracker(ranges []state.RangeID, writers ...io.Writer) *CandidateScoreTracker {
+	ct := &CandidateScoreTracker{ranges: append([]state.RangeID(nil), ranges...)}
+	sort.Slice(ct.ranges, func(i, j int) bool { return ct.ranges[i] < ct.ranges[j] })
+	for _, w := range writers {
+		ct.writers = append(ct.writers, csv.NewWriter(w))
+	}
+	return ct
+}
+
+// candidateScoreHeader is the header of the CSV output of a
+// CandidateScoreTracker.
+var candidateScoreHeader = []string{
+	"tick", "range", "leaseholder", "existing", "store", "valid", "full_disk",
+	"necessary", "diversity", "io_overloaded", "io_overload", "converges",
+	"balance", "range_count", "better",
+}
+
+// ListenState implements the StateListener interface.
+func (ct *CandidateScoreTracker) ListenState(ctx context.Context, tick time.Time, s state.State) {
+	var records [][]string
+	if !ct.wroteHeader {
+		records = append(records, candidateScoreHeader)
+		ct.wroteHeader = true
+	}
+	for _, rangeID := range ct.ranges {
+		rng, ok := s.Range(rangeID)
+		if !ok {
+			continue
+		}
+		// The range is scored by the allocator of its leaseholder, as the
+		// replicate queue of the leaseholder's store makes its decisions.
+		leaseholder, ok := s.LeaseholderStore(rangeID)
+		if !ok {
+			continue
+		}
+		storeID := leaseholder.StoreID()
+		allocator := s.MakeAllocator(storeID)
+		replicas := rng.Descriptor().Replicas()
+		scores := allocator.RebalanceCandidateScores(
+			ctx,
+			s.StorePool(storeID),
+			rng.SpanConfig(),
+			replicas.VoterDescriptors(),
+			replicas.NonVoterDescriptors(),
+			allocator.ScorerOptions(ctx),
+		)
+		for _, score := range scores {
+			records = append(records, []string{
+				tick.String(),
+				fmt.Sprintf("%d", rangeID),
+				fmt.Sprintf("%d", storeID),
+				fmt.Sprintf("%d", score.Existing),
+				fmt.Sprintf("%d", score.StoreID),
+				fmt.Sprintf("%t", score.Valid),
+				fmt.Sprintf("%t", score.FullDisk),
+				fmt.Sprintf("%t", score.Necessary),
+				fmt.Sprintf("%.2f", score.Diversity),
+				fmt.Sprintf("%t", score.IOOverloaded),
+				fmt.Sprintf("%.2f", score.IOOverload),
+				fmt.Sprintf("%d", score.Converges),
+				fmt.Sprintf("%d", score.Balance),
+				fmt.Sprintf("%d", score.RangeCount),
+				fmt.Sprintf("%t", score.Better),
+			})
+		}
+	}
+	for _, w := range ct.writers {
+		if err := w.WriteAll(records); err != nil {
+			log.Errorf(ctx, "Error writing candidate scores %s", err.Error())
+		}
+	}
+}
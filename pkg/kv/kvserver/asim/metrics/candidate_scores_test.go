@@ -0,0 +1,109 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package metrics_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/config"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/metrics"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/workload"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCandidateScoreTracker asserts that the candidate score tracker traces
+// only the marked range, and that the trace of a misplaced range explains its
+// expected move: the empty store scores better than the overfull stores which
+// hold the range's replicas.
+func TestCandidateScoreTracker(t *testing.T) {
+	ctx := context.Background()
+	settings := config.DefaultSimulationSettings()
+	duration := 5 * time.Minute
+
+	// The ranges are all on the first three stores, the fourth store is empty,
+	// so every range is misplaced w.r.t. range count balance.
+	s := state.NewStateWithReplCounts(
+		map[state.StoreID]int{1: 10, 2: 10, 3: 10, 4: 0}, 3 /* replicationFactor */, 1000 /* keyspace */, settings)
+	var traced state.RangeID
+	for _, rng := range s.Ranges() {
+		if len(rng.Replicas()) == 3 {
+			traced = rng.RangeID()
+			break
+		}
+	}
+	require.NotZero(t, traced)
+
+	var buf bytes.Buffer
+	tracker := metrics.NewTracker(testingMetricsInterval)
+	tracker.RegisterStateListener(metrics.NewCandidateScoreTracker([]state.RangeID{traced}, &buf))
+	sim := asim.NewSimulator(duration, []workload.Generator{}, s, settings, tracker)
+	sim.RunSim(ctx)
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Greater(t, len(records), 1)
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[name] = i
+	}
+	get := func(record []string, name string) string {
+		i, ok := columns[name]
+		require.True(t, ok, "missing column %s", name)
+		return record[i]
+	}
+	atoi := func(record []string, name string) int {
+		v, err := strconv.Atoi(get(record, name))
+		require.NoError(t, err)
+		return v
+	}
+
+	// Find the scores of the first tick at which the empty store was scored
+	// as a candidate, before the range was moved.
+	var tick string
+	for _, record := range records[1:] {
+		require.Equal(t, strconv.Itoa(int(traced)), get(record, "range"))
+		if tick == "" && get(record, "store") == "4" {
+			tick = get(record, "tick")
+		}
+	}
+	require.NotEmpty(t, tick)
+	existingBalance := make(map[string]int)
+	candidateBalance := make(map[string]int)
+	for _, record := range records[1:] {
+		if get(record, "tick") != tick {
+			continue
+		}
+		existing, store := get(record, "existing"), get(record, "store")
+		if existing == store {
+			existingBalance[existing] = atoi(record, "balance")
+			require.Equal(t, "false", get(record, "better"))
+		} else if store == "4" {
+			candidateBalance[existing] = atoi(record, "balance")
+			require.Equal(t, "true", get(record, "better"))
+		}
+	}
+	require.NotEmpty(t, candidateBalance)
+	for existing, balance := range candidateBalance {
+		require.Greater(t, balance, existingBalance[existing],
+			"expected the empty store to score higher than s%s", existing)
+	}
+
+	// The scores explain the move made by the allocator: the empty store
+	// received replicas.
+	require.NotEmpty(t, s.Replicas(4))
+}
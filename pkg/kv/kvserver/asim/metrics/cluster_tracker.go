@@ -11,28 +11,251 @@
 package metrics
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/util/encoding/csv"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 )
 
+// MetadataTag is a key/value pair describing a simulation run, such as a
+// parameter value or a label. Metadata tags are written out before any metrics,
+// so that downstream tooling can group the output of many runs.
+type MetadataTag struct {
+	Key, Value string
+}
+
+// ClusterMetricsTrackerOption configures optional behavior of a
+// ClusterMetricsTracker.
+type ClusterMetricsTrackerOption func(*ClusterMetricsTracker)
+
+// WithMetadata returns an option which writes the given metadata tags before
+// any metrics. CSV output is prefixed by a comment block with a "# key=value"
+// line per tag, while JSON-lines output has a leading metadata record.
+func WithMetadata(tags ...MetadataTag) ClusterMetricsTrackerOption {
+	return func(m *ClusterMetricsTracker) {
+		m.metadata = append(m.metadata, tags...)
+	}
+}
+
+// WithJSONLines returns an option which additionally writes tick metrics to the
+// given writers, with a JSON object per line.
+func WithJSONLines(writers ...io.Writer) ClusterMetricsTrackerOption {
+	return func(m *ClusterMetricsTracker) {
+		for _, w := range writers {
+			m.jsonWriters = append(m.jsonWriters, json.NewEncoder(w))
+		}
+	}
+}
+
+// WithChangesOnly returns an option which skips writing a tick's metrics when
+// they are identical to the last written metrics, ignoring the tick itself.
+// This compresses the output of long steady-state periods. The last skipped
+// tick is written on Close, so that the output has a clear end.
+func WithChangesOnly() ClusterMetricsTrackerOption {
+	return func(m *ClusterMetricsTracker) {
+		m.changesOnly = true
+	}
+}
+
+// WithoutHeader returns an option which skips writing the CSV header, so that
+// only data rows are written. This allows appending the output of a run to an
+// existing file, which already contains a header.
+func WithoutHeader() ClusterMetricsTrackerOption {
+	return func(m *ClusterMetricsTracker) {
+		m.noHeader = true
+	}
+}
+
+// WithPhaseLabel returns an option which adds a trailing phase column to the
+// metrics, labelling each tick with the workload phase it belongs to, as
+// returned by phaseAt. This segments the output of a simulation which runs
+// several workloads one after the other, so that the allocator's behavior can
+// be compared across phases.
+func WithPhaseLabel(phaseAt func(tick time.Time) string) ClusterMetricsTrackerOption {
+	return func(m *ClusterMetricsTracker) {
+		m.phaseAt = phaseAt
+	}
+}
+
+// WithBuckets returns an option which aggregates the metrics of the ticks
+// falling within each bucket of the given duration of simulated time into a
+// single row, written once the bucket ends. Unlike a coarser metrics interval,
+// which drops the ticks in between, every tick contributes to its bucket: the
+// cumulative counters are those of the last tick of the bucket, so they account
+// for all of its ticks, whilst the point in time values, such as c_ranges or
+// c_unavailable_ranges, are averaged over the ticks of the bucket. The row of a
+// bucket is labelled with its last tick. The last bucket is written on Close.
+func WithBuckets(bucket time.Duration) ClusterMetricsTrackerOption {
+	return func(m *ClusterMetricsTracker) {
+		m.bucket = bucket
+	}
+}
+
+// WithMetrics returns an option which additionally writes the given optional
+// metrics, following the default metrics. The optional metrics are written in
+// the order of their declaration, whatever the order given.
+func WithMetrics(cms ...ClusterMetric) ClusterMetricsTrackerOption {
+	return func(m *ClusterMetricsTracker) {
+		for _, cm := range cms {
+			m.optional[cm] = true
+		}
+	}
+}
+
+// ClusterMetric is an optional metric of the cluster metrics tracker, which is
+// only written when requested using WithMetrics.
+type ClusterMetric int
+
+const (
+	// UnavailableRanges is the number of ranges which have lost quorum.
+	UnavailableRanges ClusterMetric = iota
+	// OverReplicatedRanges is the number of ranges with more replicas than
+	// their replication target.
+	OverReplicatedRanges
+	// UnderReplicatedRanges is the number of ranges with fewer replicas than
+	// their replication target.
+	UnderReplicatedRanges
+	// DeferredMoves is the number of replica and lease moves deferred due to
+	// the rebalance budget.
+	DeferredMoves
+	// WriteStalledStores is the number of stores with stalled writes.
+	WriteStalledStores
+	// StalledWriteBytes is the write bytes rejected due to stalled writes.
+	StalledWriteBytes
+	// LeaseStallBytes is the read and write bytes rejected by ranges stalled
+	// after a lease transfer.
+	LeaseStallBytes
+	// MaxGCPendingReplicas is the max number of replicas pending garbage
+	// collection on a single store.
+	MaxGCPendingReplicas
+	// LeaseReacquiringRanges is the number of ranges reacquiring their lease
+	// after the node of their leaseholder restarted.
+	LeaseReacquiringRanges
+	// IdleTicks is the number of ticks where the allocators made no replica
+	// move or lease transfer.
+	IdleTicks
+	// MaxBackgroundReadBytes is the max bytes read by background processes on
+	// a single store.
+	MaxBackgroundReadBytes
+	// MaxBackgroundWriteBytes is the max bytes written by background processes
+	// on a single store.
+	MaxBackgroundWriteBytes
+	// LeaselessRanges is the number of ranges with no active leaseholder,
+	// whilst their lease is acquired after a lease transfer or a restart.
+	LeaselessRanges
+	// RebalanceReadBytes is the bytes read from the stores sending snapshots
+	// to service replica moves, apart from the foreground reads in c_read_b.
+	RebalanceReadBytes
+	// LeaseCPUImbalance is how far the leaseholder CPU, in nanoseconds per
+	// second, of the store with the most is above the mean of the stores. Lease
+	// placement, rather than replica placement, drives it, so it isolates the
+	// effect of load-based lease rebalancing.
+	LeaseCPUImbalance
+	numClusterMetrics
+)
+
+// clusterMetricColumns are the column name of each optional metric, and its
+// value in the metrics of a tick.
+var clusterMetricColumns = [numClusterMetrics]struct {
+	name  string
+	value func(r clusterMetricsRecord) int64
+}{
+	UnavailableRanges:       {"c_unavailable_ranges", func(r clusterMetricsRecord) int64 { return r.UnavailableRanges }},
+	OverReplicatedRanges:    {"c_over_replicated", func(r clusterMetricsRecord) int64 { return r.OverReplicated }},
+	UnderReplicatedRanges:   {"c_under_replicated", func(r clusterMetricsRecord) int64 { return r.UnderReplicated }},
+	DeferredMoves:           {"c_deferred_moves", func(r clusterMetricsRecord) int64 { return r.DeferredMoves }},
+	WriteStalledStores:      {"c_write_stalled_stores", func(r clusterMetricsRecord) int64 { return r.WriteStalledStores }},
+	StalledWriteBytes:       {"c_stalled_write_b", func(r clusterMetricsRecord) int64 { return r.StalledWriteBytes }},
+	LeaseStallBytes:         {"c_lease_stall_b", func(r clusterMetricsRecord) int64 { return r.LeaseStallBytes }},
+	MaxGCPendingReplicas:    {"s_gc_pending_replicas", func(r clusterMetricsRecord) int64 { return r.MaxGCPendingReplicas }},
+	LeaseReacquiringRanges:  {"c_lease_reacquiring_ranges", func(r clusterMetricsRecord) int64 { return r.LeaseReacquiring }},
+	IdleTicks:               {"c_idle_ticks", func(r clusterMetricsRecord) int64 { return r.IdleTicks }},
+	MaxBackgroundReadBytes:  {"s_bg_read_b", func(r clusterMetricsRecord) int64 { return r.MaxBgReadBytes }},
+	MaxBackgroundWriteBytes: {"s_bg_write_b", func(r clusterMetricsRecord) int64 { return r.MaxBgWriteBytes }},
+	LeaselessRanges:         {"c_leaseless_ranges", func(r clusterMetricsRecord) int64 { return r.LeaselessRanges }},
+	RebalanceReadBytes:      {"c_rebalance_read_b", func(r clusterMetricsRecord) int64 { return r.RebalanceReadBytes }},
+	LeaseCPUImbalance:       {"c_lease_cpu_imbalance", func(r clusterMetricsRecord) int64 { return r.LeaseCPUImbalance }},
+}
+
+// AllClusterMetrics returns every optional metric of the cluster metrics
+// tracker.
+func AllClusterMetrics() []ClusterMetric {
+	cms := make([]ClusterMetric, 0, numClusterMetrics)
+	for cm := ClusterMetric(0); cm < numClusterMetrics; cm++ {
+		cms = append(cms, cm)
+	}
+	return cms
+}
+
 // ClusterMetricsTracker gathers metrics and prints those to stdout.
 type ClusterMetricsTracker struct {
-	writers []*csv.Writer
+	writers     []*csv.Writer
+	jsonWriters []*json.Encoder
+	metadata    []MetadataTag
+	noHeader    bool
+	// optional is set for the optional metrics which are written.
+	optional [numClusterMetrics]bool
+	// phaseAt returns the workload phase label of a tick, it is nil unless
+	// the metrics are labelled by phase.
+	phaseAt func(tick time.Time) string
+
+	changesOnly bool
+	// lastRecord is the last CSV record written, it is only maintained when
+	// changesOnly is set.
+	lastRecord []string
+	// skippedRecord and skippedJSON are the last tick's metrics which were
+	// skipped because they were identical to lastRecord. They are written on
+	// Close.
+	skippedRecord []string
+	skippedJSON   *clusterMetricsRecord
+
+	// bucket is the duration of the buckets which the metrics of ticks are
+	// aggregated into, it is zero unless the metrics are bucketed.
+	bucket time.Duration
+	// bucketStart is the start of the current bucket and bucketRecords are
+	// the metrics of its ticks seen so far.
+	bucketStart   time.Time
+	bucketRecords []clusterMetricsRecord
 }
 
 // NewClusterMetricsTracker returns a MetricsTracker object that prints tick metrics to
 // Stdout, in a CSV format.
 func NewClusterMetricsTracker(writers ...io.Writer) *ClusterMetricsTracker {
+	return NewClusterMetricsTrackerWithOptions(writers)
+}
+
+// NewClusterMetricsTrackerWithOptions returns a MetricsTracker object that
+// prints tick metrics to the given writers in a CSV format, configured with
+// the given options.
+func NewClusterMetricsTrackerWithOptions(
+	writers []io.Writer, opts ...ClusterMetricsTrackerOption,
+) *ClusterMetricsTracker {
 	m := &ClusterMetricsTracker{}
+	for _, opt := range opts {
+		opt(m)
+	}
 
 	for _, w := range writers {
+		for _, tag := range m.metadata {
+			_, _ = fmt.Fprintf(w, "# %s=%s\n", tag.Key, tag.Value)
+		}
 		m.writers = append(m.writers, csv.NewWriter(w))
 	}
+	if len(m.metadata) > 0 {
+		metadata := make(map[string]string, len(m.metadata))
+		for _, tag := range m.metadata {
+			metadata[tag.Key] = tag.Value
+		}
+		_ = m.writeJSON(struct {
+			Metadata map[string]string `json:"metadata"`
+		}{Metadata: metadata})
+	}
 
 	headline := []string{
 		// The rest of the data is cumulative, up to this tick.
@@ -44,7 +267,16 @@ func NewClusterMetricsTracker(writers ...io.Writer) *ClusterMetricsTracker {
 		// The churn in the cluster.
 		"c_lease_moves", "c_replica_moves", "c_replica_b_moves",
 	}
-	_ = m.write(headline)
+	for _, cm := range m.optionalMetrics() {
+		headline = append(headline, clusterMetricColumns[cm].name)
+	}
+	if m.phaseAt != nil {
+		// The workload phase which the tick belongs to.
+		headline = append(headline, "phase")
+	}
+	if !m.noHeader {
+		_ = m.write(headline)
+	}
 	return m
 }
 
@@ -58,6 +290,94 @@ func (m *ClusterMetricsTracker) write(record []string) error {
 	return nil
 }
 
+// optionalMetrics returns the optional metrics which are written, in order.
+func (m *ClusterMetricsTracker) optionalMetrics() []ClusterMetric {
+	var cms []ClusterMetric
+	for cm := ClusterMetric(0); cm < numClusterMetrics; cm++ {
+		if m.optional[cm] {
+			cms = append(cms, cm)
+		}
+	}
+	return cms
+}
+
+func (m *ClusterMetricsTracker) writeJSON(record interface{}) error {
+	for _, w := range m.jsonWriters {
+		if err := w.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clusterMetricsRecord holds the metrics written at each tick, both the default
+// and the optional metrics.
+type clusterMetricsRecord struct {
+	Tick                 string
+	TotalRangeCount      int64
+	TotalWriteKeys       int64
+	TotalWriteBytes      int64
+	TotalReadKeys        int64
+	TotalReadBytes       int64
+	MaxWriteKeys         int64
+	MaxWriteBytes        int64
+	MaxReadKeys          int64
+	MaxReadBytes         int64
+	TotalLeaseTransfers  int64
+	TotalRebalances      int64
+	TotalBytesRebalanced int64
+	UnavailableRanges    int64
+	OverReplicated       int64
+	UnderReplicated      int64
+	DeferredMoves        int64
+	WriteStalledStores   int64
+	StalledWriteBytes    int64
+	LeaseStallBytes      int64
+	MaxGCPendingReplicas int64
+	LeaseReacquiring     int64
+	IdleTicks            int64
+	MaxBgReadBytes       int64
+	MaxBgWriteBytes      int64
+	LeaselessRanges      int64
+	RebalanceReadBytes   int64
+	LeaseCPUImbalance    int64
+	Phase                string
+}
+
+// jsonField is a field of a JSON-lines record.
+type jsonField struct {
+	key   string
+	value interface{}
+}
+
+// jsonFields is a JSON object whose fields are written in order, so that the
+// JSON-lines records are laid out like the CSV records.
+type jsonFields []jsonField
+
+// MarshalJSON implements the json.Marshaler interface.
+func (fs jsonFields) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range fs {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(f.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
 func max(a, b int64) int64 {
 	if a > b {
 		return a
@@ -81,10 +401,27 @@ func (m *ClusterMetricsTracker) Listen(ctx context.Context, sms []StoreMetrics)
 		maxWriteBytes        int64
 		maxReadKeys          int64
 		maxReadBytes         int64
+		unavailableRanges    int64
+		overReplicated       int64
+		underReplicated      int64
+		deferredMoves        int64
+		writeStalledStores   int64
+		stalledWriteBytes    int64
+		leaseStallBytes      int64
+		maxGCPendingReplicas int64
+		leaseReacquiring     int64
+		idleTicks            int64
+		maxBgReadBytes       int64
+		maxBgWriteBytes      int64
+		leaselessRanges      int64
+		rebalanceReadBytes   int64
+		totalLeaseCPU        int64
+		maxLeaseCPU          int64
 	)
 
 	for _, u := range sms {
 		tick = u.Tick
+		idleTicks = u.IdleTicks
 		totalRangeCount += u.Leases
 		totalLeaseTransfers += u.LeaseTransfers
 		totalRebalances += u.Rebalances
@@ -97,24 +434,210 @@ func (m *ClusterMetricsTracker) Listen(ctx context.Context, sms []StoreMetrics)
 		maxWriteBytes = max(maxWriteBytes, u.WriteBytes)
 		maxReadKeys = max(maxReadKeys, u.ReadKeys)
 		maxReadBytes = max(maxReadBytes, u.ReadBytes)
+		unavailableRanges += u.UnavailableRanges
+		overReplicated += u.OverReplicatedRanges
+		underReplicated += u.UnderReplicatedRanges
+		deferredMoves += u.DeferredMoves
+		writeStalledStores += u.WriteStalled
+		stalledWriteBytes += u.StalledWriteBytes
+		leaseStallBytes += u.LeaseStallBytes
+		maxGCPendingReplicas = max(maxGCPendingReplicas, u.GCPendingReplicas)
+		leaseReacquiring += u.LeaseReacquiringRanges
+		maxBgReadBytes = max(maxBgReadBytes, u.BackgroundReadBytes)
+		maxBgWriteBytes = max(maxBgWriteBytes, u.BackgroundWriteBytes)
+		leaselessRanges += u.LeaselessRanges
+		// The snapshot of a replica move is read from the store which sends
+		// it, so every byte sent is a byte read.
+		rebalanceReadBytes += u.RebalanceSentBytes
+		totalLeaseCPU += u.LeaseCPU
+		maxLeaseCPU = max(maxLeaseCPU, u.LeaseCPU)
+	}
+	var leaseCPUImbalance int64
+	if len(sms) > 0 {
+		meanLeaseCPU := float64(totalLeaseCPU) / float64(len(sms))
+		leaseCPUImbalance = int64(math.Round(float64(maxLeaseCPU) - meanLeaseCPU))
 	}
 
-	record := make([]string, 0, 10)
-	record = append(record, tick.String())
-	record = append(record, fmt.Sprintf("%d", totalRangeCount))
-	record = append(record, fmt.Sprintf("%d", totalWriteKeys))
-	record = append(record, fmt.Sprintf("%d", totalWriteBytes))
-	record = append(record, fmt.Sprintf("%d", totalReadKeys))
-	record = append(record, fmt.Sprintf("%d", totalReadBytes))
-	record = append(record, fmt.Sprintf("%d", maxWriteKeys))
-	record = append(record, fmt.Sprintf("%d", maxWriteBytes))
-	record = append(record, fmt.Sprintf("%d", maxReadKeys))
-	record = append(record, fmt.Sprintf("%d", maxReadBytes))
-	record = append(record, fmt.Sprintf("%d", totalLeaseTransfers))
-	record = append(record, fmt.Sprintf("%d", totalRebalances))
-	record = append(record, fmt.Sprintf("%d", totalBytesRebalanced))
+	rec := clusterMetricsRecord{
+		Tick:                 tick.String(),
+		TotalRangeCount:      totalRangeCount,
+		TotalWriteKeys:       totalWriteKeys,
+		TotalWriteBytes:      totalWriteBytes,
+		TotalReadKeys:        totalReadKeys,
+		TotalReadBytes:       totalReadBytes,
+		MaxWriteKeys:         maxWriteKeys,
+		MaxWriteBytes:        maxWriteBytes,
+		MaxReadKeys:          maxReadKeys,
+		MaxReadBytes:         maxReadBytes,
+		TotalLeaseTransfers:  totalLeaseTransfers,
+		TotalRebalances:      totalRebalances,
+		TotalBytesRebalanced: totalBytesRebalanced,
+		UnavailableRanges:    unavailableRanges,
+		OverReplicated:       overReplicated,
+		UnderReplicated:      underReplicated,
+		DeferredMoves:        deferredMoves,
+		WriteStalledStores:   writeStalledStores,
+		StalledWriteBytes:    stalledWriteBytes,
+		LeaseStallBytes:      leaseStallBytes,
+		MaxGCPendingReplicas: maxGCPendingReplicas,
+		LeaseReacquiring:     leaseReacquiring,
+		IdleTicks:            idleTicks,
+		MaxBgReadBytes:       maxBgReadBytes,
+		MaxBgWriteBytes:      maxBgWriteBytes,
+		LeaselessRanges:      leaselessRanges,
+		RebalanceReadBytes:   rebalanceReadBytes,
+		LeaseCPUImbalance:    leaseCPUImbalance,
+	}
+	if m.phaseAt != nil {
+		rec.Phase = m.phaseAt(tick)
+	}
+
+	if m.bucket > 0 {
+		if len(m.bucketRecords) == 0 {
+			m.bucketStart = tick
+		} else if !tick.Before(m.bucketStart.Add(m.bucket)) {
+			m.emit(ctx, aggregateBucket(m.bucketRecords))
+			m.bucketRecords = m.bucketRecords[:0]
+			m.bucketStart = m.bucketStart.Add(tick.Sub(m.bucketStart).Truncate(m.bucket))
+		}
+		m.bucketRecords = append(m.bucketRecords, rec)
+		return
+	}
+	m.emit(ctx, rec)
+}
+
+// emit writes the metrics of a tick, or of a bucket of ticks, unless only
+// changed metrics are written and they are unchanged.
+func (m *ClusterMetricsTracker) emit(ctx context.Context, rec clusterMetricsRecord) {
+	record := m.csvRecord(rec)
+	if m.changesOnly {
+		if m.lastRecord != nil && unchangedRecord(m.lastRecord, record) {
+			m.skippedRecord, m.skippedJSON = record, &rec
+			return
+		}
+		m.lastRecord = record
+		m.skippedRecord, m.skippedJSON = nil, nil
+	}
+	m.writeRecord(ctx, record, rec)
+}
 
+func (m *ClusterMetricsTracker) writeRecord(
+	ctx context.Context, record []string, rec clusterMetricsRecord,
+) {
 	if err := m.write(record); err != nil {
 		log.Errorf(ctx, "Error writing cluster metrics %s", err.Error())
 	}
+	if err := m.writeJSON(m.jsonRecord(rec)); err != nil {
+		log.Errorf(ctx, "Error writing cluster metrics %s", err.Error())
+	}
+}
+
+// csvRecord returns the CSV record of the metrics, with the optional metrics
+// which are written, and a trailing phase column if the metrics are labelled by
+// phase.
+func (m *ClusterMetricsTracker) csvRecord(r clusterMetricsRecord) []string {
+	record := make([]string, 0, 10)
+	record = append(record, r.Tick)
+	record = append(record, fmt.Sprintf("%d", r.TotalRangeCount))
+	record = append(record, fmt.Sprintf("%d", r.TotalWriteKeys))
+	record = append(record, fmt.Sprintf("%d", r.TotalWriteBytes))
+	record = append(record, fmt.Sprintf("%d", r.TotalReadKeys))
+	record = append(record, fmt.Sprintf("%d", r.TotalReadBytes))
+	record = append(record, fmt.Sprintf("%d", r.MaxWriteKeys))
+	record = append(record, fmt.Sprintf("%d", r.MaxWriteBytes))
+	record = append(record, fmt.Sprintf("%d", r.MaxReadKeys))
+	record = append(record, fmt.Sprintf("%d", r.MaxReadBytes))
+	record = append(record, fmt.Sprintf("%d", r.TotalLeaseTransfers))
+	record = append(record, fmt.Sprintf("%d", r.TotalRebalances))
+	record = append(record, fmt.Sprintf("%d", r.TotalBytesRebalanced))
+	for _, cm := range m.optionalMetrics() {
+		record = append(record, fmt.Sprintf("%d", clusterMetricColumns[cm].value(r)))
+	}
+	if m.phaseAt != nil {
+		record = append(record, r.Phase)
+	}
+	return record
+}
+
+// jsonRecord returns the JSON-lines record of the metrics, with the optional
+// metrics which are written, and the phase if the metrics are labelled by
+// phase.
+func (m *ClusterMetricsTracker) jsonRecord(r clusterMetricsRecord) jsonFields {
+	fields := jsonFields{
+		{"tick", r.Tick},
+		{"c_ranges", r.TotalRangeCount},
+		{"c_write", r.TotalWriteKeys},
+		{"c_write_b", r.TotalWriteBytes},
+		{"c_read", r.TotalReadKeys},
+		{"c_read_b", r.TotalReadBytes},
+		{"s_write", r.MaxWriteKeys},
+		{"s_write_b", r.MaxWriteBytes},
+		{"s_read", r.MaxReadKeys},
+		{"s_read_b", r.MaxReadBytes},
+		{"c_lease_moves", r.TotalLeaseTransfers},
+		{"c_replica_moves", r.TotalRebalances},
+		{"c_replica_b_moves", r.TotalBytesRebalanced},
+	}
+	for _, cm := range m.optionalMetrics() {
+		fields = append(fields, jsonField{clusterMetricColumns[cm].name, clusterMetricColumns[cm].value(r)})
+	}
+	if r.Phase != "" {
+		fields = append(fields, jsonField{"phase", r.Phase})
+	}
+	return fields
+}
+
+// aggregateBucket returns the metrics of a bucket of ticks, given the metrics
+// of each of its ticks in order. The cumulative counters, and the phase, are
+// those of the last tick, whilst the point in time values are averaged over
+// the ticks, rounded to the nearest integer.
+func aggregateBucket(records []clusterMetricsRecord) clusterMetricsRecord {
+	agg := records[len(records)-1]
+	mean := func(value func(r clusterMetricsRecord) int64) int64 {
+		var sum int64
+		for _, r := range records {
+			sum += value(r)
+		}
+		return int64(math.Round(float64(sum) / float64(len(records))))
+	}
+	agg.TotalRangeCount = mean(func(r clusterMetricsRecord) int64 { return r.TotalRangeCount })
+	agg.UnavailableRanges = mean(func(r clusterMetricsRecord) int64 { return r.UnavailableRanges })
+	agg.OverReplicated = mean(func(r clusterMetricsRecord) int64 { return r.OverReplicated })
+	agg.UnderReplicated = mean(func(r clusterMetricsRecord) int64 { return r.UnderReplicated })
+	agg.WriteStalledStores = mean(func(r clusterMetricsRecord) int64 { return r.WriteStalledStores })
+	agg.MaxGCPendingReplicas = mean(func(r clusterMetricsRecord) int64 { return r.MaxGCPendingReplicas })
+	agg.LeaseReacquiring = mean(func(r clusterMetricsRecord) int64 { return r.LeaseReacquiring })
+	agg.LeaselessRanges = mean(func(r clusterMetricsRecord) int64 { return r.LeaselessRanges })
+	agg.LeaseCPUImbalance = mean(func(r clusterMetricsRecord) int64 { return r.LeaseCPUImbalance })
+	return agg
+}
+
+// unchangedRecord returns true if the two records are identical, ignoring
+// the leading tick column.
+func unchangedRecord(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := 1; i < len(a); i++ {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Close implements the StoreMetricsCloser interface. When bucketing metrics,
+// the metrics of the last bucket are written. When only writing changed
+// metrics, the last tick's metrics are written if they were skipped.
+func (m *ClusterMetricsTracker) Close(ctx context.Context) {
+	if len(m.bucketRecords) > 0 {
+		m.emit(ctx, aggregateBucket(m.bucketRecords))
+		m.bucketRecords = m.bucketRecords[:0]
+	}
+	if m.skippedRecord == nil {
+		return
+	}
+	m.writeRecord(ctx, m.skippedRecord, *m.skippedJSON)
+	m.lastRecord = m.skippedRecord
+	m.skippedRecord, m.skippedJSON = nil, nil
 }
@@ -0,0 +1,105 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding/csv"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// LatencyTracker writes the p50 and p99 write and read latency of the cluster,
+// along with the highest p99 write and read latency of any store, in a CSV
+// format. Latencies are in milliseconds and only consider the requests served
+// since the previous sampled tick.
+type LatencyTracker struct {
+	writers []*csv.Writer
+	// prevWrite and prevRead are the cumulative latency distributions of each
+	// store at the previous sampled tick.
+	prevWrite, prevRead map[state.StoreID]state.LatencyDistribution
+}
+
+var _ StateListener = &LatencyTracker{}
+
+// NewLatencyTracker returns a new LatencyTracker which writes to the writers
+// given. It should be registered against a Tracker using
+// RegisterStateListener.
+func NewLatencyTracker(writers ...io.Writer) *LatencyTracker {
+	lt := &LatencyTracker{
+		prevWrite: make(map[state.StoreID]state.LatencyDistribution),
+		prevRead:  make(map[state.StoreID]state.LatencyDistribution),
+	}
+	for _, w := range writers {
+		lt.writers = append(lt.writers, csv.NewWriter(w))
+	}
+	_ = lt.write([]string{
+		"tick",
+		"c_write_latency_p50", "c_write_latency_p99",
+		"c_read_latency_p50", "c_read_latency_p99",
+		"s_write_latency_p99", "s_read_latency_p99",
+	})
+	return lt
+}
+
+func (lt *LatencyTracker) write(record []string) error {
+	for _, w := range lt.writers {
+		if err := w.Write(record); err != nil {
+			return err
+		}
+		w.Flush()
+	}
+	return nil
+}
+
+// ListenState implements the StateListener interface.
+func (lt *LatencyTracker) ListenState(ctx context.Context, tick time.Time, s state.State) {
+	clusterWrite := make(state.LatencyDistribution)
+	clusterRead := make(state.LatencyDistribution)
+	var maxStoreWrite, maxStoreRead time.Duration
+	for storeID, u := range s.ClusterUsageInfo().StoreUsage {
+		write := u.WriteLatency.Since(lt.prevWrite[storeID])
+		read := u.ReadLatency.Since(lt.prevRead[storeID])
+		lt.prevWrite[storeID] = u.WriteLatency.Clone()
+		lt.prevRead[storeID] = u.ReadLatency.Clone()
+
+		clusterWrite.Merge(write)
+		clusterRead.Merge(read)
+		if p99 := write.Percentile(99); p99 > maxStoreWrite {
+			maxStoreWrite = p99
+		}
+		if p99 := read.Percentile(99); p99 > maxStoreRead {
+			maxStoreRead = p99
+		}
+	}
+
+	record := []string{
+		tick.String(),
+		formatLatency(clusterWrite.Percentile(50)),
+		formatLatency(clusterWrite.Percentile(99)),
+		formatLatency(clusterRead.Percentile(50)),
+		formatLatency(clusterRead.Percentile(99)),
+		formatLatency(maxStoreWrite),
+		formatLatency(maxStoreRead),
+	}
+	if err := lt.write(record); err != nil {
+		log.Errorf(ctx, "Error writing latency metrics %s", err.Error())
+	}
+}
+
+// formatLatency formats the latency given in milliseconds.
+func formatLatency(latency time.Duration) string {
+	return fmt.Sprintf("%.2f", float64(latency)/float64(time.Millisecond))
+}
@@ -0,0 +1,113 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/cockroachdb/cockroach/pkg/util/encoding/csv"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/montanaflynn/stats"
+)
+
+// LeaseColocationTracker measures how closely the leases in the cluster follow
+// the replicas. At each tick, it writes the correlation between the store
+// replica counts and lease counts, along with the lease skew. The lease skew
+// is the fraction of leases which would need to move for every store's share
+// of the leases to match its share of the replicas. A cluster whose leases
+// are proportional to its replicas has a skew of zero, whilst a cluster whose
+// leases are concentrated on a few stores has a skew approaching one.
+type LeaseColocationTracker struct {
+	writers         []*csv.Writer
+	lastCorrelation float64
+	lastSkew        float64
+}
+
+var _ StoreMetricsListener = &LeaseColocationTracker{}
+
+// NewLeaseColocationTracker returns a new LeaseColocationTracker which writes
+// to the writers given, in a CSV format.
+func NewLeaseColocationTracker(writers ...io.Writer) *LeaseColocationTracker {
+	lt := &LeaseColocationTracker{}
+	for _, w := range writers {
+		lt.writers = append(lt.writers, csv.NewWriter(w))
+	}
+	_ = lt.write([]string{"tick", "s_replica_lease_corr", "c_lease_skew"})
+	return lt
+}
+
+func (lt *LeaseColocationTracker) write(record []string) error {
+	for _, w := range lt.writers {
+		if err := w.Write(record); err != nil {
+			return err
+		}
+		w.Flush()
+	}
+	return nil
+}
+
+// leaseSkew returns the total variation distance between the distribution of
+// leases and the distribution of replicas over the stores given.
+func leaseSkew(replicas, leases []float64) float64 {
+	var totalReplicas, totalLeases float64
+	for i := range replicas {
+		totalReplicas += replicas[i]
+		totalLeases += leases[i]
+	}
+	if totalReplicas == 0 || totalLeases == 0 {
+		return 0
+	}
+	var skew float64
+	for i := range replicas {
+		skew += math.Abs(leases[i]/totalLeases - replicas[i]/totalReplicas)
+	}
+	return skew / 2
+}
+
+// Listen implements the StoreMetricsListener interface.
+func (lt *LeaseColocationTracker) Listen(ctx context.Context, sms []StoreMetrics) {
+	if len(sms) == 0 {
+		return
+	}
+	replicas := make([]float64, 0, len(sms))
+	leases := make([]float64, 0, len(sms))
+	for _, sm := range sms {
+		replicas = append(replicas, float64(sm.Replicas))
+		leases = append(leases, float64(sm.Leases))
+	}
+	// NB: The correlation is zero when either the replica or lease counts are
+	// the same on every store.
+	lt.lastCorrelation, _ = stats.Correlation(replicas, leases)
+	lt.lastSkew = leaseSkew(replicas, leases)
+
+	record := []string{
+		sms[0].Tick.String(),
+		fmt.Sprintf("%.2f", lt.lastCorrelation),
+		fmt.Sprintf("%.2f", lt.lastSkew),
+	}
+	if err := lt.write(record); err != nil {
+		log.Errorf(ctx, "Error writing lease co-location metrics %s", err.Error())
+	}
+}
+
+// Correlation returns the correlation between the store replica counts and
+// lease counts, as of the last tick recorded.
+func (lt *LeaseColocationTracker) Correlation() float64 {
+	return lt.lastCorrelation
+}
+
+// Skew returns the lease skew, as of the last tick recorded.
+func (lt *LeaseColocationTracker) Skew() float64 {
+	return lt.lastSkew
+}
@@ -0,0 +1,69 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package metrics_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/metrics"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLeaseColocation asserts that the lease co-location metrics reflect
+// leases being concentrated on a store with few replicas, and that they
+// normalize once the leases are rebalanced in proportion to the replicas.
+func TestLeaseColocation(t *testing.T) {
+	ctx := context.Background()
+	start := state.TestingStartTime()
+
+	// makeTick returns the store metrics for a tick, where each store has the
+	// number of replicas and leases given.
+	makeTick := func(tick int64, replicas, leases []int64) []metrics.StoreMetrics {
+		sms := make([]metrics.StoreMetrics, len(replicas))
+		for i := range replicas {
+			sms[i] = metrics.StoreMetrics{
+				Tick:     state.OffsetTick(start, tick),
+				StoreID:  int64(i + 1),
+				Replicas: replicas[i],
+				Leases:   leases[i],
+			}
+		}
+		return sms
+	}
+
+	var buf bytes.Buffer
+	lt := metrics.NewLeaseColocationTracker(&buf)
+	replicas := []int64{12, 9, 9}
+
+	// Every lease is on the third store, which has the fewest replicas.
+	lt.Listen(ctx, makeTick(0, replicas, []int64{0, 0, 10}))
+	require.InDelta(t, 0.7, lt.Skew(), 0.01)
+	require.Less(t, lt.Correlation(), float64(0))
+
+	// Rebalancing the leases in proportion to the replicas removes the skew.
+	lt.Listen(ctx, makeTick(10, replicas, []int64{4, 3, 3}))
+	require.InDelta(t, 0, lt.Skew(), 0.01)
+	require.InDelta(t, 1, lt.Correlation(), 0.01)
+
+	// When the replica counts are the same on every store, the correlation is
+	// zero but the skew still reflects the lease concentration.
+	lt.Listen(ctx, makeTick(20, []int64{10, 10, 10}, []int64{10, 0, 0}))
+	require.InDelta(t, 0.67, lt.Skew(), 0.01)
+	require.Equal(t, float64(0), lt.Correlation())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 4)
+	require.Equal(t, "tick,s_replica_lease_corr,c_lease_skew", lines[0])
+}
@@ -0,0 +1,72 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding/csv"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// MemoryTracker writes the number of ranges and replicas in the simulation
+// state, along with the approximate memory footprint of the state, in a CSV
+// format. This is useful to gauge how large a simulation is feasible, as the
+// footprint grows with the number of ranges and replicas.
+type MemoryTracker struct {
+	writers []*csv.Writer
+}
+
+var _ StateListener = &MemoryTracker{}
+
+// NewMemoryTracker returns a new MemoryTracker which writes to the writers
+// given. It should be registered against a Tracker using
+// RegisterStateListener.
+func NewMemoryTracker(writers ...io.Writer) *MemoryTracker {
+	mt := &MemoryTracker{}
+	for _, w := range writers {
+		mt.writers = append(mt.writers, csv.NewWriter(w))
+	}
+	_ = mt.write([]string{"tick", "c_ranges", "c_replicas", "c_state_mem_b"})
+	return mt
+}
+
+func (mt *MemoryTracker) write(record []string) error {
+	for _, w := range mt.writers {
+		if err := w.Write(record); err != nil {
+			return err
+		}
+		w.Flush()
+	}
+	return nil
+}
+
+// ListenState implements the StateListener interface.
+func (mt *MemoryTracker) ListenState(ctx context.Context, tick time.Time, s state.State) {
+	ranges := s.Ranges()
+	var replicas int
+	for _, r := range ranges {
+		replicas += len(r.Replicas())
+	}
+	record := []string{
+		tick.String(),
+		fmt.Sprintf("%d", len(ranges)),
+		fmt.Sprintf("%d", replicas),
+		fmt.Sprintf("%d", s.MemoryEstimate()),
+	}
+	if err := mt.write(record); err != nil {
+		log.Errorf(ctx, "Error writing memory metrics %s", err.Error())
+	}
+}
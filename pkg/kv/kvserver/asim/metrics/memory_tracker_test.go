@@ -0,0 +1,59 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package metrics_test
+
+import (
+	"context"
+	"encoding/csv"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/config"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/metrics"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMemoryTracker asserts that the memory footprint reported for a state
+// with many ranges is larger than the footprint reported for a state with few
+// ranges.
+func TestMemoryTracker(t *testing.T) {
+	ctx := context.Background()
+	settings := config.DefaultSimulationSettings()
+
+	// footprint returns the ranges, replicas and memory footprint reported for
+	// a state with the given number of ranges.
+	footprint := func(ranges int) (int64, int64, int64) {
+		s := state.NewStateEvenDistribution(5, ranges, 3, 100000, settings)
+		var buf strings.Builder
+		mt := metrics.NewMemoryTracker(&buf)
+		mt.ListenState(ctx, state.TestingStartTime(), s)
+
+		records, err := csv.NewReader(strings.NewReader(buf.String())).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		require.Equal(t, []string{"tick", "c_ranges", "c_replicas", "c_state_mem_b"}, records[0])
+		values := make([]int64, 3)
+		for i := range values {
+			values[i], err = strconv.ParseInt(records[1][i+1], 10, 64)
+			require.NoError(t, err)
+		}
+		return values[0], values[1], values[2]
+	}
+
+	smallRanges, smallReplicas, smallMem := footprint(10)
+	largeRanges, largeReplicas, largeMem := footprint(1000)
+	require.Greater(t, largeRanges, smallRanges)
+	require.Greater(t, largeReplicas, smallReplicas)
+	require.Greater(t, smallMem, int64(0))
+	require.Greater(t, largeMem, smallMem)
+}
@@ -13,7 +13,10 @@ package metrics_test
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"io"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -24,6 +27,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/workload"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/admission/admissionpb"
 	"github.com/stretchr/testify/require"
 )
 
@@ -67,6 +71,233 @@ func TestTickEmptyState(t *testing.T) {
 	require.Equal(t, expected, buf.String())
 }
 
+// TestTickWithMetadata asserts that metadata tags supplied to the cluster
+// metrics tracker are written before the CSV header, and as the first record
+// of the JSON-lines output.
+func TestTickWithMetadata(t *testing.T) {
+	ctx := context.Background()
+	start := state.TestingStartTime()
+	s := state.LoadConfig(state.ComplexConfig, state.SingleRangeConfig, config.DefaultSimulationSettings())
+
+	var csvBuf, jsonBuf bytes.Buffer
+	m := metrics.NewTracker(testingMetricsInterval, metrics.NewClusterMetricsTrackerWithOptions(
+		[]io.Writer{&csvBuf},
+		metrics.WithMetadata(
+			metrics.MetadataTag{Key: "label", Value: "sweep"},
+			metrics.MetadataTag{Key: "seed", Value: "42"},
+		),
+		metrics.WithJSONLines(&jsonBuf),
+	))
+
+	m.Tick(ctx, start, s)
+
+	expectedCSV :=
+		"# label=sweep\n" +
+			"# seed=42\n" +
+			"tick,c_ranges,c_write,c_write_b,c_read,c_read_b,s_ranges,s_write,s_write_b,s_read,s_read_b,c_lease_moves,c_replica_moves,c_replica_b_moves\n" +
+			"2022-03-21 11:00:00 +0000 UTC,1,0,0,0,0,0,0,0,0,0,0,0\n"
+	require.Equal(t, expectedCSV, csvBuf.String())
+
+	expectedJSON :=
+		`{"metadata":{"label":"sweep","seed":"42"}}` + "\n" +
+			`{"tick":"2022-03-21 11:00:00 +0000 UTC","c_ranges":1,"c_write":0,"c_write_b":0,"c_read":0,"c_read_b":0,` +
+			`"s_write":0,"s_write_b":0,"s_read":0,"s_read_b":0,"c_lease_moves":0,"c_replica_moves":0,"c_replica_b_moves":0}` + "\n"
+	require.Equal(t, expectedJSON, jsonBuf.String())
+}
+
+// TestTickWithMetrics asserts that the optional metrics requested are written
+// after the default metrics, in the order of their declaration, to both the CSV
+// and the JSON-lines output.
+func TestTickWithMetrics(t *testing.T) {
+	ctx := context.Background()
+	start := state.TestingStartTime()
+	s := state.LoadConfig(state.ComplexConfig, state.SingleRangeConfig, config.DefaultSimulationSettings())
+
+	var csvBuf, jsonBuf bytes.Buffer
+	m := metrics.NewTracker(testingMetricsInterval, metrics.NewClusterMetricsTrackerWithOptions(
+		[]io.Writer{&csvBuf},
+		metrics.WithJSONLines(&jsonBuf),
+		metrics.WithMetrics(metrics.IdleTicks, metrics.UnavailableRanges),
+	))
+
+	m.Tick(ctx, start, s)
+
+	expectedCSV :=
+		"tick,c_ranges,c_write,c_write_b,c_read,c_read_b,s_ranges,s_write,s_write_b,s_read,s_read_b,c_lease_moves,c_replica_moves,c_replica_b_moves,c_unavailable_ranges,c_idle_ticks\n" +
+			"2022-03-21 11:00:00 +0000 UTC,1,0,0,0,0,0,0,0,0,0,0,0,0,0\n"
+	require.Equal(t, expectedCSV, csvBuf.String())
+
+	expectedJSON :=
+		`{"tick":"2022-03-21 11:00:00 +0000 UTC","c_ranges":1,"c_write":0,"c_write_b":0,"c_read":0,"c_read_b":0,` +
+			`"s_write":0,"s_write_b":0,"s_read":0,"s_read_b":0,"c_lease_moves":0,"c_replica_moves":0,"c_replica_b_moves":0,"c_unavailable_ranges":0,"c_idle_ticks":0}` + "\n"
+	require.Equal(t, expectedJSON, jsonBuf.String())
+}
+
+// TestTickChangesOnly asserts that when only writing changed metrics, ticks
+// with metrics identical to the last written tick are skipped, apart from the
+// final tick which is written on Close.
+func TestTickChangesOnly(t *testing.T) {
+	ctx := context.Background()
+	start := state.TestingStartTime()
+	s := state.LoadConfig(state.ComplexConfig, state.SingleRangeConfig, config.DefaultSimulationSettings())
+
+	var buf bytes.Buffer
+	m := metrics.NewTracker(testingMetricsInterval, metrics.NewClusterMetricsTrackerWithOptions(
+		[]io.Writer{&buf}, metrics.WithChangesOnly()))
+
+	// The state has no load applied, so the metrics are identical at every
+	// tick.
+	for i := 0; i < 5; i++ {
+		m.Tick(ctx, start.Add(time.Duration(i)*testingMetricsInterval), s)
+	}
+	m.Close(ctx)
+
+	expected :=
+		"tick,c_ranges,c_write,c_write_b,c_read,c_read_b,s_ranges,s_write,s_write_b,s_read,s_read_b,c_lease_moves,c_replica_moves,c_replica_b_moves\n" +
+			"2022-03-21 11:00:00 +0000 UTC,1,0,0,0,0,0,0,0,0,0,0,0\n" +
+			"2022-03-21 11:00:40 +0000 UTC,1,0,0,0,0,0,0,0,0,0,0,0\n"
+	require.Equal(t, expected, buf.String())
+
+	// Closing again shouldn't write the final tick twice.
+	m.Close(ctx)
+	require.Equal(t, expected, buf.String())
+}
+
+// TestTickBuckets asserts that with 1 minute buckets, the metrics of six 10
+// second ticks collapse into a single row, labelled with the last tick of the
+// bucket, which holds the cumulative counters of that tick and the average of
+// the point in time values over the ticks.
+func TestTickBuckets(t *testing.T) {
+	ctx := context.Background()
+	start := state.TestingStartTime()
+
+	var buf bytes.Buffer
+	m := metrics.NewClusterMetricsTrackerWithOptions(
+		[]io.Writer{&buf}, metrics.WithoutHeader(), metrics.WithBuckets(time.Minute),
+		metrics.WithMetrics(metrics.UnavailableRanges))
+
+	listen := func(i int, unavailable int64) {
+		m.Listen(ctx, []metrics.StoreMetrics{
+			{
+				Tick:              start.Add(time.Duration(i) * 10 * time.Second),
+				StoreID:           1,
+				Leases:            1,
+				WriteKeys:         int64(10 * (i + 1)),
+				WriteBytes:        int64(100 * (i + 1)),
+				UnavailableRanges: unavailable,
+			},
+			{
+				Tick:      start.Add(time.Duration(i) * 10 * time.Second),
+				StoreID:   2,
+				Leases:    1,
+				WriteKeys: int64(5 * (i + 1)),
+			},
+		})
+	}
+	// The first store has 3 unavailable ranges for 4 of the 6 ticks, so 2 on
+	// average.
+	for i := 0; i < 6; i++ {
+		var unavailable int64
+		if i >= 2 {
+			unavailable = 3
+		}
+		listen(i, unavailable)
+	}
+	require.Empty(t, buf.String())
+
+	// The first tick of the next bucket writes the previous one.
+	listen(6, 0)
+	expected := "2022-03-21 11:00:50 +0000 UTC,2,90,600,0,0,60,600,0,0,0,0,0,2\n"
+	require.Equal(t, expected, buf.String())
+
+	// The last bucket is written on Close, even though it has a single tick.
+	m.Close(ctx)
+	expected += "2022-03-21 11:01:00 +0000 UTC,2,105,700,0,0,70,700,0,0,0,0,0,0\n"
+	require.Equal(t, expected, buf.String())
+}
+
+// TestTickLeaseCPUImbalance asserts that the leaseholder CPU imbalance is the
+// CPU of the busiest store above the mean, so that it is high when the
+// leaseholder CPU is concentrated on one store and zero once it is balanced.
+func TestTickLeaseCPUImbalance(t *testing.T) {
+	ctx := context.Background()
+	start := state.TestingStartTime()
+
+	var buf bytes.Buffer
+	m := metrics.NewClusterMetricsTrackerWithOptions(
+		[]io.Writer{&buf}, metrics.WithoutHeader(),
+		metrics.WithMetrics(metrics.LeaseCPUImbalance))
+
+	listen := func(i int, leaseCPU ...int64) {
+		var sms []metrics.StoreMetrics
+		for j, cpu := range leaseCPU {
+			sms = append(sms, metrics.StoreMetrics{
+				Tick:     start.Add(time.Duration(i) * 10 * time.Second),
+				StoreID:  int64(j + 1),
+				Leases:   1,
+				LeaseCPU: cpu,
+			})
+		}
+		m.Listen(ctx, sms)
+	}
+	listen(0, 300, 0, 0)
+	listen(1, 200, 100, 0)
+	listen(2, 100, 100, 100)
+
+	expected :=
+		"2022-03-21 11:00:00 +0000 UTC,3,0,0,0,0,0,0,0,0,0,0,0,200\n" +
+			"2022-03-21 11:00:10 +0000 UTC,3,0,0,0,0,0,0,0,0,0,0,0,100\n" +
+			"2022-03-21 11:00:20 +0000 UTC,3,0,0,0,0,0,0,0,0,0,0,0,0\n"
+	require.Equal(t, expected, buf.String())
+}
+
+// TestTickWithoutHeader asserts that a tracker created without a header only
+// writes data rows, so that its output can be appended to an existing file.
+func TestTickWithoutHeader(t *testing.T) {
+	ctx := context.Background()
+	start := state.TestingStartTime()
+	s := state.LoadConfig(state.ComplexConfig, state.SingleRangeConfig, config.DefaultSimulationSettings())
+
+	var buf bytes.Buffer
+	m := metrics.NewTracker(testingMetricsInterval, metrics.NewClusterMetricsTrackerWithOptions(
+		[]io.Writer{&buf}, metrics.WithoutHeader()))
+
+	m.Tick(ctx, start, s)
+
+	expected := "2022-03-21 11:00:00 +0000 UTC,1,0,0,0,0,0,0,0,0,0,0,0\n"
+	require.Equal(t, expected, buf.String())
+}
+
+// TestTickManyRanges asserts that a config loaded with many ranges is reported
+// by the cluster metrics tracker, and that its replicas are balanced across the
+// stores.
+func TestTickManyRanges(t *testing.T) {
+	ctx := context.Background()
+	start := state.TestingStartTime()
+	const stores, ranges, rf = 10, 1000, 3
+	s := state.LoadConfigWithRanges(stores, ranges, rf, 100000 /* keyspace */, config.DefaultSimulationSettings())
+
+	var buf bytes.Buffer
+	m := metrics.NewTracker(testingMetricsInterval, metrics.NewClusterMetricsTrackerWithOptions(
+		[]io.Writer{&buf}, metrics.WithoutHeader()))
+
+	m.Tick(ctx, start, s)
+
+	record := strings.Split(strings.TrimSpace(buf.String()), ",")
+	require.Equal(t, fmt.Sprintf("%d", ranges), record[1])
+	for _, store := range s.Stores() {
+		require.Len(t, s.Replicas(store.StoreID()), ranges*rf/stores)
+	}
+	// Every store should hold some of the leases.
+	leaseholders := make(map[state.StoreID]bool)
+	for _, rng := range s.Ranges() {
+		store, ok := s.LeaseholderStore(rng.RangeID())
+		require.True(t, ok)
+		leaseholders[store.StoreID()] = true
+	}
+	require.Len(t, leaseholders, stores)
+}
+
 func Example_multipleWriters() {
 	ctx := context.Background()
 	start := state.TestingStartTime()
@@ -132,6 +363,67 @@ func Example_rebalance() {
 	//2022-03-21 11:00:00 +0000 UTC,1,3,21,2,9,1,7,2,9,0,1,7
 }
 
+func Example_admission() {
+	ctx := context.Background()
+	start := state.TestingStartTime()
+	settings := config.DefaultSimulationSettings()
+	settings.AdmissionWriteBytesPerSecond = 10
+	s := state.LoadConfig(state.ComplexConfig, state.SingleRangeConfig, settings)
+	m := metrics.NewTracker(testingMetricsInterval)
+	m.RegisterStateListener(metrics.NewAdmissionTracker(os.Stdout))
+
+	s.ApplyLoad(workload.LoadBatch{
+		workload.LoadEvent{Key: 1, Writes: 1, WriteSize: 7, Priority: admissionpb.LowPri},
+		workload.LoadEvent{Key: 5, Writes: 1, WriteSize: 7, Priority: admissionpb.HighPri},
+	})
+	m.Tick(ctx, start, s)
+	// Output:
+	//tick,priority,c_admitted_b,c_rejected_b
+	//2022-03-21 11:00:00 +0000 UTC,high-pri,7,0
+	//2022-03-21 11:00:00 +0000 UTC,low-pri,0,7
+}
+
+func Example_readLocality() {
+	ctx := context.Background()
+	start := state.TestingStartTime()
+	settings := config.DefaultSimulationSettings()
+	// The replicas of the single range are on stores 1, 2 and 3, which are all
+	// in the US_East region.
+	s := state.LoadConfig(state.ComplexConfig, state.SingleRangeConfig, settings)
+	m := metrics.NewTracker(testingMetricsInterval)
+	m.RegisterStateListener(metrics.NewReadLocalityTracker(os.Stdout))
+
+	s.ApplyLoad(workload.LoadBatch{
+		workload.LoadEvent{Key: 1, Reads: 3, ReadSize: 7, ClientRegion: "US_East"},
+		workload.LoadEvent{Key: 5, Reads: 1, ReadSize: 7, ClientRegion: "EU"},
+	})
+	m.Tick(ctx, start, s)
+	// Output:
+	//tick,c_local_read,c_cross_region_read,c_local_read_fraction
+	//2022-03-21 11:00:00 +0000 UTC,3,1,0.75
+}
+
+func Example_regionUsage() {
+	ctx := context.Background()
+	start := state.TestingStartTime()
+	settings := config.DefaultSimulationSettings()
+	// The replicas of the single range are on stores 1, 2 and 3, which are all
+	// in the US_East region. Store 1 is the leaseholder.
+	s := state.LoadConfig(state.ComplexConfig, state.SingleRangeConfig, settings)
+	m := metrics.NewTracker(testingMetricsInterval)
+	m.RegisterStateListener(metrics.NewRegionUsageTracker(os.Stdout))
+
+	s.ApplyLoad(workload.LoadBatch{
+		workload.LoadEvent{Key: 1, Reads: 1, ReadSize: 7, Writes: 1, WriteSize: 3, ClientRegion: "EU"},
+	})
+	m.Tick(ctx, start, s)
+	// Output:
+	//tick,region,region_read_b,region_write_b
+	//2022-03-21 11:00:00 +0000 UTC,EU,0,0
+	//2022-03-21 11:00:00 +0000 UTC,US_East,7,9
+	//2022-03-21 11:00:00 +0000 UTC,US_West,0,0
+}
+
 func Example_workload() {
 	ctx := context.Background()
 	settings := config.DefaultSimulationSettings()
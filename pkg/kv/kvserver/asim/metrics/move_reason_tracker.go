@@ -0,0 +1,67 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding/csv"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// MoveReasonTracker writes a histogram of the replica moves made during the
+// simulation, broken down by the reason of the move, in a CSV format. The
+// histogram is written once, when the simulation has finished, with a row per
+// reason. This can be used to check why the allocators moved replicas, e.g.
+// that the moves following a decommission are attributed to it.
+type MoveReasonTracker struct {
+	writers []*csv.Writer
+	moves   map[state.MoveReason]int64
+}
+
+var _ StateListener = &MoveReasonTracker{}
+var _ StoreMetricsCloser = &MoveReasonTracker{}
+
+// NewMoveReasonTracker returns a new MoveReasonTracker which writes to the
+// writers given. It should be registered against a Tracker using
+// RegisterStateListener.
+func NewMoveReasonTracker(writers ...io.Writer) *MoveReasonTracker {
+	mt := &MoveReasonTracker{moves: make(map[state.MoveReason]int64)}
+	for _, w := range writers {
+		mt.writers = append(mt.writers, csv.NewWriter(w))
+	}
+	return mt
+}
+
+// ListenState implements the StateListener interface.
+func (mt *MoveReasonTracker) ListenState(ctx context.Context, tick time.Time, s state.State) {
+	// The moves per reason are cumulative, so only the latest counts are kept.
+	for reason, moves := range s.ClusterUsageInfo().MoveReasons {
+		mt.moves[reason] = moves
+	}
+}
+
+// Close implements the StoreMetricsCloser interface.
+func (mt *MoveReasonTracker) Close(ctx context.Context) {
+	records := [][]string{{"reason", "c_replica_moves"}}
+	for _, reason := range state.MoveReasons {
+		records = append(records, []string{reason.String(), fmt.Sprintf("%d", mt.moves[reason])})
+	}
+	for _, w := range mt.writers {
+		if err := w.WriteAll(records); err != nil {
+			log.Errorf(ctx, "Error writing move reason metrics %s", err.Error())
+		}
+	}
+}
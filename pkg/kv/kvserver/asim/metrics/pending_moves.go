@@ -0,0 +1,101 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/allocator/storepool"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding/csv"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// PendingMovesTracker writes the number of replica moves which the allocators
+// currently recommend, in a CSV format. The moves are only recommended, not
+// applied, so this gives a forward looking signal of the distance to a
+// balanced cluster, as opposed to the replica moves which have already
+// happened.
+type PendingMovesTracker struct {
+	writers []*csv.Writer
+}
+
+var _ StateListener = &PendingMovesTracker{}
+
+// NewPendingMovesTracker returns a new PendingMovesTracker which writes to the
+// writers given. It should be registered against a Tracker using
+// RegisterStateListener.
+func NewPendingMovesTracker(writers ...io.Writer) *PendingMovesTracker {
+	pt := &PendingMovesTracker{}
+	for _, w := range writers {
+		pt.writers = append(pt.writers, csv.NewWriter(w))
+	}
+	_ = pt.write([]string{"tick", "c_pending_rebalance_moves"})
+	return pt
+}
+
+func (pt *PendingMovesTracker) write(record []string) error {
+	for _, w := range pt.writers {
+		if err := w.Write(record); err != nil {
+			return err
+		}
+		w.Flush()
+	}
+	return nil
+}
+
+// ListenState implements the StateListener interface.
+func (pt *PendingMovesTracker) ListenState(ctx context.Context, tick time.Time, s state.State) {
+	record := []string{
+		tick.String(),
+		fmt.Sprintf("%d", PendingRebalanceMoves(ctx, s)),
+	}
+	if err := pt.write(record); err != nil {
+		log.Errorf(ctx, "Error writing pending rebalance moves %s", err.Error())
+	}
+}
+
+// PendingRebalanceMoves returns the number of ranges for which the allocator
+// of the range's leaseholder store recommends rebalancing a voter, in the
+// given state. The allocator is asked in the same way as when the replicate
+// queue considers the range, but throttled stores aren't filtered out, as
+// throttling only delays a move. It is zero once the allocators consider the
+// cluster balanced.
+func PendingRebalanceMoves(ctx context.Context, s state.State) int64 {
+	var pending int64
+	for _, rng := range s.Ranges() {
+		rangeID := rng.RangeID()
+		leaseholder, ok := s.LeaseholderStore(rangeID)
+		if !ok {
+			continue
+		}
+		storeID := leaseholder.StoreID()
+		allocator := s.MakeAllocator(storeID)
+		replicas := rng.Descriptor().Replicas()
+		if _, _, _, ok := allocator.RebalanceVoter(
+			ctx,
+			s.StorePool(storeID),
+			rng.SpanConfig(),
+			s.RaftStatus(rangeID, storeID),
+			replicas.VoterDescriptors(),
+			replicas.NonVoterDescriptors(),
+			s.RangeUsageInfo(rangeID, storeID),
+			storepool.StoreFilterNone,
+			allocator.ScorerOptions(ctx),
+		); ok {
+			pending++
+		}
+	}
+	return pending
+}
@@ -0,0 +1,95 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package metrics_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/config"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/metrics"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/workload"
+	"github.com/stretchr/testify/require"
+)
+
+// pendingMovesRecorder records the pending rebalance moves of the state at
+// every tick it is notified of.
+type pendingMovesRecorder struct {
+	pending []int64
+}
+
+func (pr *pendingMovesRecorder) ListenState(ctx context.Context, _ time.Time, s state.State) {
+	pr.pending = append(pr.pending, metrics.PendingRebalanceMoves(ctx, s))
+}
+
+// TestPendingRebalanceMoves asserts that there are no pending rebalance moves
+// in a balanced cluster, and that the moves recommended by the allocators of
+// an imbalanced cluster are written.
+func TestPendingRebalanceMoves(t *testing.T) {
+	ctx := context.Background()
+	settings := config.DefaultSimulationSettings()
+	stores, ranges := 6, 300
+
+	balanced := state.NewStateEvenDistribution(stores, ranges, 3 /* replicationFactor */, 3*ranges, settings)
+	require.Equal(t, int64(0), metrics.PendingRebalanceMoves(ctx, balanced))
+
+	imbalanced := state.NewStateWithDistribution(
+		state.TestingHalfEmptyDistribution(stores), ranges, 3 /* replicationFactor */, 3*ranges, settings)
+	pending := metrics.PendingRebalanceMoves(ctx, imbalanced)
+	require.Greater(t, pending, int64(0))
+	require.LessOrEqual(t, pending, int64(ranges))
+
+	var buf bytes.Buffer
+	pt := metrics.NewPendingMovesTracker(&buf)
+	pt.ListenState(ctx, state.TestingStartTime(), imbalanced)
+	require.Equal(t,
+		"tick,c_pending_rebalance_moves\n"+
+			fmt.Sprintf("2022-03-21 11:00:00 +0000 UTC,%d\n", pending),
+		buf.String())
+}
+
+// TestPendingRebalanceMovesDecrease asserts that the pending rebalance moves
+// of an imbalanced cluster decrease monotonically towards zero, as the
+// simulator rebalances replicas.
+func TestPendingRebalanceMovesDecrease(t *testing.T) {
+	ctx := context.Background()
+	settings := config.DefaultSimulationSettings()
+	duration := 30 * time.Minute
+	settings.TickInterval = 2 * time.Second
+
+	stores := 6
+	ranges := 300
+	// There is no load, so there are no splits and only the replica count
+	// drives rebalancing.
+	s := state.NewStateWithDistribution(
+		state.TestingHalfEmptyDistribution(stores), ranges, 3 /* replicationFactor */, 3*ranges, settings)
+	pr := &pendingMovesRecorder{}
+	m := metrics.NewTracker(settings.TickInterval) // no output
+	m.RegisterStateListener(pr)
+
+	sim := asim.NewSimulator(duration, []workload.Generator{}, s, settings, m)
+	sim.RunSim(ctx)
+
+	require.NotEmpty(t, pr.pending)
+	initial := pr.pending[0]
+	require.Greater(t, initial, int64(0))
+	last := initial
+	for i, pending := range pr.pending {
+		require.LessOrEqual(t, pending, last, "pending rebalance moves increased at sample %d", i)
+		last = pending
+	}
+	require.Equal(t, int64(0), last)
+}
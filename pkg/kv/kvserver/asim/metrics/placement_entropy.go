@@ -0,0 +1,130 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/util/encoding/csv"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// DefaultEntropyConvergenceThreshold is the replica placement entropy at or
+// above which a cluster is considered to have converged to a balanced replica
+// placement, unless another threshold is given.
+const DefaultEntropyConvergenceThreshold = 0.99
+
+// PlacementEntropyTracker writes the normalized entropy of the replica count
+// distribution across stores, in a CSV format. Unlike the standard deviation
+// of the replica counts, the entropy doesn't depend on the number of replicas
+// and stores: it is 1 when the replicas are spread evenly, and decreases
+// towards 0 as they are concentrated on fewer stores. The tracker also records
+// the tick at which the cluster converged, i.e. the entropy reached the
+// convergence threshold and hasn't dropped below it since.
+type PlacementEntropyTracker struct {
+	writers   []*csv.Writer
+	threshold float64
+	// lastEntropy is the entropy at the last tick recorded.
+	lastEntropy float64
+	// convergedAt is the tick at which the entropy last reached the threshold,
+	// it is zero while the entropy is below the threshold.
+	convergedAt time.Time
+}
+
+var _ StoreMetricsListener = &PlacementEntropyTracker{}
+
+// NewPlacementEntropyTracker returns a new PlacementEntropyTracker which
+// considers the cluster converged once the entropy is at or above threshold,
+// and writes to the writers given.
+func NewPlacementEntropyTracker(threshold float64, writers ...io.Writer) *PlacementEntropyTracker {
+	et := &PlacementEntropyTracker{threshold: threshold}
+	for _, w := range writers {
+		et.writers = append(et.writers, csv.NewWriter(w))
+	}
+	_ = et.write([]string{"tick", "c_replica_entropy", "c_converged"})
+	return et
+}
+
+func (et *PlacementEntropyTracker) write(record []string) error {
+	for _, w := range et.writers {
+		if err := w.Write(record); err != nil {
+			return err
+		}
+		w.Flush()
+	}
+	return nil
+}
+
+// Listen implements the StoreMetricsListener interface.
+func (et *PlacementEntropyTracker) Listen(ctx context.Context, sms []StoreMetrics) {
+	if len(sms) == 0 {
+		return
+	}
+	et.lastEntropy = ReplicaPlacementEntropy(sms)
+	if et.lastEntropy < et.threshold {
+		et.convergedAt = time.Time{}
+	} else if et.convergedAt.IsZero() {
+		et.convergedAt = sms[0].Tick
+	}
+
+	record := []string{
+		sms[0].Tick.String(),
+		fmt.Sprintf("%.4f", et.lastEntropy),
+		fmt.Sprintf("%t", !et.convergedAt.IsZero()),
+	}
+	if err := et.write(record); err != nil {
+		log.Errorf(ctx, "Error writing replica placement entropy %s", err.Error())
+	}
+}
+
+// Entropy returns the replica placement entropy as of the last tick recorded.
+func (et *PlacementEntropyTracker) Entropy() float64 {
+	return et.lastEntropy
+}
+
+// ConvergedAt returns the tick at which the replica placement entropy reached
+// the convergence threshold, without dropping below it up to the last tick
+// recorded. It returns false if the entropy is below the threshold as of the
+// last tick recorded.
+func (et *PlacementEntropyTracker) ConvergedAt() (time.Time, bool) {
+	return et.convergedAt, !et.convergedAt.IsZero()
+}
+
+// ReplicaPlacementEntropy returns the Shannon entropy of the share of replicas
+// held by each store, normalized by the entropy of an even spread, so that it
+// ranges from 0, when every replica is on a single store, to 1, when every
+// store holds the same number of replicas. A cluster with a single store, or
+// without replicas, is trivially balanced.
+func ReplicaPlacementEntropy(sms []StoreMetrics) float64 {
+	if len(sms) < 2 {
+		return 1
+	}
+	var total int64
+	for _, sm := range sms {
+		total += sm.Replicas
+	}
+	if total == 0 {
+		return 1
+	}
+	var entropy float64
+	for _, sm := range sms {
+		if sm.Replicas == 0 {
+			continue
+		}
+		p := float64(sm.Replicas) / float64(total)
+		entropy -= p * math.Log(p)
+	}
+	return entropy / math.Log(float64(len(sms)))
+}
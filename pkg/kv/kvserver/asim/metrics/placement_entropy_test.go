@@ -0,0 +1,112 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package metrics_test
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/config"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/metrics"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/workload"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReplicaPlacementEntropy asserts that the replica placement entropy is 0
+// when every replica is on a single store, 1 when the replicas are spread
+// evenly, and doesn't depend on the number of replicas.
+func TestReplicaPlacementEntropy(t *testing.T) {
+	ctx := context.Background()
+	start := state.TestingStartTime()
+
+	makeTick := func(tick int64, replicas ...int64) []metrics.StoreMetrics {
+		sms := make([]metrics.StoreMetrics, len(replicas))
+		for i := range replicas {
+			sms[i] = metrics.StoreMetrics{
+				Tick:     state.OffsetTick(start, tick),
+				StoreID:  int64(i + 1),
+				Replicas: replicas[i],
+			}
+		}
+		return sms
+	}
+
+	require.Equal(t, float64(1), metrics.ReplicaPlacementEntropy(nil))
+	require.Equal(t, float64(1), metrics.ReplicaPlacementEntropy(makeTick(0, 0, 0, 0)))
+	require.Equal(t, float64(0), metrics.ReplicaPlacementEntropy(makeTick(0, 30, 0, 0)))
+	require.InDelta(t, 1, metrics.ReplicaPlacementEntropy(makeTick(0, 10, 10, 10)), 1e-9)
+	require.InDelta(t, math.Log(2)/math.Log(4), metrics.ReplicaPlacementEntropy(makeTick(0, 5, 5, 0, 0)), 1e-9)
+	require.InDelta(t,
+		metrics.ReplicaPlacementEntropy(makeTick(0, 20, 10, 5)),
+		metrics.ReplicaPlacementEntropy(makeTick(0, 2000, 1000, 500)), 1e-9)
+
+	var buf bytes.Buffer
+	et := metrics.NewPlacementEntropyTracker(0.9, &buf)
+	et.Listen(ctx, makeTick(0, 30, 0, 0))
+	et.Listen(ctx, makeTick(10, 10, 10, 10))
+	require.Equal(t,
+		"tick,c_replica_entropy,c_converged\n"+
+			"2022-03-21 11:00:00 +0000 UTC,0.0000,false\n"+
+			"2022-03-21 11:00:10 +0000 UTC,1.0000,true\n",
+		buf.String())
+	convergedAt, ok := et.ConvergedAt()
+	require.True(t, ok)
+	require.Equal(t, state.OffsetTick(start, 10), convergedAt)
+
+	// The cluster is no longer converged once the entropy drops below the
+	// threshold.
+	et.Listen(ctx, makeTick(20, 20, 10, 0))
+	_, ok = et.ConvergedAt()
+	require.False(t, ok)
+}
+
+// TestReplicaPlacementEntropyRises asserts that the replica placement entropy
+// of an imbalanced cluster rises towards 1, and converges, as the simulator
+// rebalances replicas.
+func TestReplicaPlacementEntropyRises(t *testing.T) {
+	ctx := context.Background()
+	settings := config.DefaultSimulationSettings()
+	duration := 30 * time.Minute
+	settings.TickInterval = 2 * time.Second
+
+	stores := 6
+	ranges := 300
+	// NB: Half of the stores have all of the replicas, the other half have
+	// none. There is no load, so there are no splits and only the replica
+	// count drives rebalancing.
+	replicaDistribution := make([]float64, stores)
+	for i := 0; i < stores/2; i++ {
+		replicaDistribution[i] = 1.0 / float64(stores/2)
+	}
+	s := state.NewStateWithDistribution(replicaDistribution, ranges, 3 /* replicationFactor */, 3*ranges, settings)
+	et := metrics.NewPlacementEntropyTracker(metrics.DefaultEntropyConvergenceThreshold) // no output
+	m := metrics.NewTracker(settings.TickInterval, et)
+
+	sim := asim.NewSimulator(duration, []workload.Generator{}, s, settings, m)
+	sim.RunSim(ctx)
+	history := sim.History()
+
+	require.NotEmpty(t, history.Recorded)
+	// The replicas are initially spread over half of the stores, for an
+	// entropy of log(3)/log(6) ~= 0.61.
+	initial := metrics.ReplicaPlacementEntropy(history.Recorded[0])
+	require.Less(t, initial, 0.7)
+	require.Greater(t, et.Entropy(), initial)
+	require.GreaterOrEqual(t, et.Entropy(), metrics.DefaultEntropyConvergenceThreshold)
+	convergedAt, ok := et.ConvergedAt()
+	require.True(t, ok)
+	require.True(t, convergedAt.After(history.Recorded[0][0].Tick))
+}
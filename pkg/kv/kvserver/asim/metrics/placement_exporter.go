@@ -0,0 +1,93 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// ReplicaPlacementExporter writes the placement of every range in the cluster
+// at each sampled tick, in a JSON-lines format. Each line is an object
+// containing the tick and, for every range, the stores which hold a replica of
+// the range along with the leaseholder store. This is considerably larger
+// than the scalar metrics output, it is intended for visualizing how replicas
+// move over a simulation run e.g. to drive an animation.
+type ReplicaPlacementExporter struct {
+	writers []*json.Encoder
+}
+
+var _ StateListener = &ReplicaPlacementExporter{}
+
+// NewReplicaPlacementExporter returns a new ReplicaPlacementExporter which
+// writes to the writers given. It should be registered against a Tracker using
+// RegisterStateListener.
+func NewReplicaPlacementExporter(writers ...io.Writer) *ReplicaPlacementExporter {
+	pe := &ReplicaPlacementExporter{}
+	for _, w := range writers {
+		pe.writers = append(pe.writers, json.NewEncoder(w))
+	}
+	return pe
+}
+
+// replicaPlacementRecord is the JSON-lines representation of the range
+// placements written at each tick.
+type replicaPlacementRecord struct {
+	Tick   string           `json:"tick"`
+	Ranges []rangePlacement `json:"ranges"`
+}
+
+// rangePlacement is the placement of a single range. The leaseholder is
+// omitted when the range has no leaseholder.
+type rangePlacement struct {
+	RangeID     int64   `json:"range_id"`
+	Stores      []int64 `json:"stores"`
+	Leaseholder int64   `json:"leaseholder,omitempty"`
+}
+
+// ListenState implements the StateListener interface.
+func (pe *ReplicaPlacementExporter) ListenState(
+	ctx context.Context, tick time.Time, s state.State,
+) {
+	ranges := s.Ranges()
+	record := replicaPlacementRecord{
+		Tick:   tick.String(),
+		Ranges: make([]rangePlacement, 0, len(ranges)),
+	}
+	for _, r := range ranges {
+		placement := rangePlacement{RangeID: int64(r.RangeID())}
+		for _, repl := range r.Replicas() {
+			placement.Stores = append(placement.Stores, int64(repl.StoreID()))
+			if repl.HoldsLease() {
+				placement.Leaseholder = int64(repl.StoreID())
+			}
+		}
+		sort.Slice(placement.Stores, func(i, j int) bool {
+			return placement.Stores[i] < placement.Stores[j]
+		})
+		record.Ranges = append(record.Ranges, placement)
+	}
+	sort.Slice(record.Ranges, func(i, j int) bool {
+		return record.Ranges[i].RangeID < record.Ranges[j].RangeID
+	})
+
+	for _, w := range pe.writers {
+		if err := w.Encode(record); err != nil {
+			log.Errorf(ctx, "Error writing replica placements %s", err.Error())
+		}
+	}
+}
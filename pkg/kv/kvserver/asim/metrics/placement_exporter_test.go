@@ -0,0 +1,113 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package metrics_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/config"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/event"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/metrics"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/workload"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReplicaPlacementExporter asserts that the replica placement exporter
+// writes a record per sampled tick, and that a replica move is reflected
+// between the records written before and after the move.
+func TestReplicaPlacementExporter(t *testing.T) {
+	ctx := context.Background()
+	settings := config.DefaultSimulationSettings()
+	duration := 2 * time.Minute
+	moveAt := settings.StartTime.Add(time.Minute)
+	var movedRange state.RangeID
+	movedFrom, movedTo := state.StoreID(0), state.StoreID(4)
+
+	// Start with a single range on the first three stores, the fourth store
+	// is empty.
+	s := state.NewStateWithReplCounts(
+		map[state.StoreID]int{1: 1, 2: 1, 3: 1, 4: 0}, 3 /* replicationFactor */, 1000 /* keyspace */, settings)
+	// Force a replica of the range to move to the empty store, from a store
+	// which doesn't hold the lease.
+	move := event.DelayedEvent{
+		At: moveAt,
+		EventFn: func(ctx context.Context, tick time.Time, s state.State) {
+			rng := s.Ranges()[0]
+			movedRange = rng.RangeID()
+			for _, repl := range rng.Replicas() {
+				if !repl.HoldsLease() {
+					movedFrom = repl.StoreID()
+				}
+			}
+			_, ok := s.AddReplica(rng.RangeID(), movedTo, roachpb.VOTER_FULL)
+			require.True(t, ok)
+			require.True(t, s.RemoveReplica(rng.RangeID(), movedFrom))
+		},
+	}
+
+	var buf bytes.Buffer
+	exporter := metrics.NewReplicaPlacementExporter(&buf)
+	tracker := metrics.NewTracker(testingMetricsInterval)
+	tracker.RegisterStateListener(exporter)
+
+	sim := asim.NewSimulator(duration, []workload.Generator{}, s, settings, tracker, move)
+	sim.RunSim(ctx)
+
+	type placement struct {
+		RangeID     int64   `json:"range_id"`
+		Stores      []int64 `json:"stores"`
+		Leaseholder int64   `json:"leaseholder"`
+	}
+	type record struct {
+		Tick   string      `json:"tick"`
+		Ranges []placement `json:"ranges"`
+	}
+	var records []record
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var r record
+		require.NoError(t, dec.Decode(&r))
+		records = append(records, r)
+	}
+	require.NotEmpty(t, records)
+
+	// Find the placement of the moved range in the last record written before
+	// the move and the first written after, the two should differ.
+	var before, after []int64
+	for _, r := range records {
+		tick, err := time.Parse("2006-01-02 15:04:05 -0700 MST", r.Tick)
+		require.NoError(t, err)
+		for _, p := range r.Ranges {
+			if p.RangeID != int64(movedRange) {
+				continue
+			}
+			if tick.Before(moveAt) {
+				before = p.Stores
+			} else if after == nil {
+				after = p.Stores
+			}
+		}
+	}
+	require.NotNil(t, before)
+	require.NotNil(t, after)
+	require.NotEqual(t, before, after)
+	require.Contains(t, before, int64(movedFrom))
+	require.NotContains(t, before, int64(movedTo))
+	require.Contains(t, after, int64(movedTo))
+	require.NotContains(t, after, int64(movedFrom))
+}
@@ -0,0 +1,129 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding/csv"
+)
+
+// RangeQPSHeatmap records the QPS of every range in the cluster at each
+// sampled tick. At the end of a simulation run, the recorded heatmap can be
+// written out as a CSV matrix, where each row is a range and each column is a
+// sampled tick. This is useful for identifying ranges which remained hot over
+// the run.
+type RangeQPSHeatmap struct {
+	ticks []time.Time
+	// qps maps a range to its QPS at each sampled tick. A range which didn't
+	// exist at a sampled tick (e.g. it was split off later), has zero QPS
+	// recorded for that tick.
+	qps map[state.RangeID][]float64
+}
+
+var _ StateListener = &RangeQPSHeatmap{}
+
+// NewRangeQPSHeatmap returns a new RangeQPSHeatmap. It should be registered
+// against a Tracker using RegisterStateListener.
+func NewRangeQPSHeatmap() *RangeQPSHeatmap {
+	return &RangeQPSHeatmap{
+		qps: make(map[state.RangeID][]float64),
+	}
+}
+
+// ListenState implements the StateListener interface.
+func (h *RangeQPSHeatmap) ListenState(ctx context.Context, tick time.Time, s state.State) {
+	h.ticks = append(h.ticks, tick)
+	for _, r := range s.Ranges() {
+		var qps float64
+		rangeID := r.RangeID()
+		if store, ok := s.LeaseholderStore(rangeID); ok {
+			qps = s.RangeUsageInfo(rangeID, store.StoreID()).QueriesPerSecond
+		}
+		h.qps[rangeID] = append(h.paddedSeries(rangeID, len(h.ticks)-1), qps)
+	}
+}
+
+// paddedSeries returns the series recorded for the range with ID rangeID,
+// padded with zeros up to n entries.
+func (h *RangeQPSHeatmap) paddedSeries(rangeID state.RangeID, n int) []float64 {
+	series := h.qps[rangeID]
+	for len(series) < n {
+		series = append(series, 0)
+	}
+	return series
+}
+
+// Series returns the recorded QPS series for every range, keyed by range ID.
+// Each series has one entry per sampled tick.
+func (h *RangeQPSHeatmap) Series() map[state.RangeID][]float64 {
+	ret := make(map[state.RangeID][]float64, len(h.qps))
+	for rangeID := range h.qps {
+		ret[rangeID] = h.paddedSeries(rangeID, len(h.ticks))
+	}
+	return ret
+}
+
+// MaxQPS returns the highest QPS of any range at each sampled tick. When load
+// is skewed, a decreasing series indicates that the hotspot is being
+// dissipated, e.g. by load based splitting and rebalancing.
+func (h *RangeQPSHeatmap) MaxQPS() []float64 {
+	ret := make([]float64, len(h.ticks))
+	for _, series := range h.qps {
+		for i, qps := range series {
+			if qps > ret[i] {
+				ret[i] = qps
+			}
+		}
+	}
+	return ret
+}
+
+// Write writes the recorded heatmap to w in a CSV format. The first row is a
+// header containing the sampled ticks, every following row contains the QPS of
+// a range at each sampled tick, ordered by range ID.
+func (h *RangeQPSHeatmap) Write(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	header := make([]string, 0, len(h.ticks)+1)
+	header = append(header, "range")
+	for _, tick := range h.ticks {
+		header = append(header, tick.String())
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	series := h.Series()
+	rangeIDs := make([]state.RangeID, 0, len(series))
+	for rangeID := range series {
+		rangeIDs = append(rangeIDs, rangeID)
+	}
+	sort.Slice(rangeIDs, func(i, j int) bool { return rangeIDs[i] < rangeIDs[j] })
+
+	for _, rangeID := range rangeIDs {
+		record := make([]string, 0, len(h.ticks)+1)
+		record = append(record, fmt.Sprintf("%d", rangeID))
+		for _, qps := range series[rangeID] {
+			record = append(record, fmt.Sprintf("%.2f", qps))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
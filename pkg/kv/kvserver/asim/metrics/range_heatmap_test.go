@@ -0,0 +1,85 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package metrics_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/config"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/metrics"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/workload"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRangeQPSHeatmap asserts that the range QPS heatmap records a row per
+// range and a column per tick, and that under a skewed workload the range
+// containing the hottest keys stands out.
+func TestRangeQPSHeatmap(t *testing.T) {
+	ctx := context.Background()
+	settings := config.DefaultSimulationSettings()
+	duration := 200 * time.Second
+	keyspace := 10000
+	rwg := []workload.Generator{
+		workload.NewRandomGenerator(
+			settings.StartTime,
+			settings.Seed,
+			workload.NewZipfianKeyGen(
+				0, int64(keyspace), 1.1, 1, rand.New(rand.NewSource(settings.Seed))),
+			500,  /* rate */
+			0.95, /* readRatio */
+			1,    /* maxSize */
+			1,    /* minSize */
+		),
+	}
+	s := state.NewStateEvenDistribution(3, 10, 3, keyspace, settings)
+	heatmap := metrics.NewRangeQPSHeatmap()
+	tracker := metrics.NewTracker(testingMetricsInterval)
+	tracker.RegisterStateListener(heatmap)
+
+	sim := asim.NewSimulator(duration, rwg, s, settings, tracker)
+	sim.RunSim(ctx)
+
+	var buf bytes.Buffer
+	require.NoError(t, heatmap.Write(&buf))
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+
+	series := heatmap.Series()
+	require.Len(t, records, len(series)+1)
+	header := records[0]
+	require.Equal(t, "range", header[0])
+
+	var hottest, total float64
+	for _, record := range records[1:] {
+		require.Len(t, record, len(header))
+		var rangeTotal float64
+		for _, field := range record[1:] {
+			qps, err := strconv.ParseFloat(field, 64)
+			require.NoError(t, err)
+			rangeTotal += qps
+		}
+		if rangeTotal > hottest {
+			hottest = rangeTotal
+		}
+		total += rangeTotal
+	}
+	// The zipfian key generator concentrates most of the load on the lowest
+	// keys, which are contained in a single range.
+	require.Greater(t, hottest, total/2)
+}
@@ -0,0 +1,69 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding/csv"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// ReadLocalityTracker writes the number of reads which were served from a
+// replica in the same region as the client issuing them, and the number which
+// were served cross-region, in a CSV format. Only reads issued by clients with
+// a known region are counted.
+type ReadLocalityTracker struct {
+	writers []*csv.Writer
+}
+
+var _ StateListener = &ReadLocalityTracker{}
+
+// NewReadLocalityTracker returns a new ReadLocalityTracker which writes to the
+// writers given. It should be registered against a Tracker using
+// RegisterStateListener.
+func NewReadLocalityTracker(writers ...io.Writer) *ReadLocalityTracker {
+	rt := &ReadLocalityTracker{}
+	for _, w := range writers {
+		rt.writers = append(rt.writers, csv.NewWriter(w))
+	}
+	// The read counts are cumulative, up to the tick.
+	_ = rt.write([]string{"tick", "c_local_read", "c_cross_region_read", "c_local_read_fraction"})
+	return rt
+}
+
+func (rt *ReadLocalityTracker) write(record []string) error {
+	for _, w := range rt.writers {
+		if err := w.Write(record); err != nil {
+			return err
+		}
+		w.Flush()
+	}
+	return nil
+}
+
+// ListenState implements the StateListener interface.
+func (rt *ReadLocalityTracker) ListenState(ctx context.Context, tick time.Time, s state.State) {
+	usage := s.ClusterUsageInfo().ReadLocality
+	record := []string{
+		tick.String(),
+		fmt.Sprintf("%d", usage.LocalReads),
+		fmt.Sprintf("%d", usage.CrossRegionReads),
+		fmt.Sprintf("%.2f", usage.LocalReadFraction()),
+	}
+	if err := rt.write(record); err != nil {
+		log.Errorf(ctx, "Error writing read locality metrics %s", err.Error())
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/cockroachdb/cockroach/pkg/util/encoding/csv"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/montanaflynn/stats"
+)
+
+// RebalanceEfficiencyTracker measures how much data the allocator moves in
+// order to balance the replica count across stores. At each tick, it writes
+// the cumulative bytes rebalanced, the standard deviation of the store replica
+// counts and the bytes rebalanced per unit of standard deviation reduction,
+// relative to the first tick. An allocator which thrashes, moving replicas
+// without improving balance, has a high number of bytes per improvement.
+type RebalanceEfficiencyTracker struct {
+	writers []*csv.Writer
+	// initialStdDev is the standard deviation of the store replica counts at
+	// the first tick, it is negative until the first tick is recorded.
+	initialStdDev float64
+	lastRatio     float64
+}
+
+var _ StoreMetricsListener = &RebalanceEfficiencyTracker{}
+
+// NewRebalanceEfficiencyTracker returns a new RebalanceEfficiencyTracker
+// which writes to the writers given, in a CSV format.
+func NewRebalanceEfficiencyTracker(writers ...io.Writer) *RebalanceEfficiencyTracker {
+	rt := &RebalanceEfficiencyTracker{initialStdDev: -1}
+	for _, w := range writers {
+		rt.writers = append(rt.writers, csv.NewWriter(w))
+	}
+	_ = rt.write([]string{"tick", "c_replica_b_moves", "s_replicas_stddev", "c_replica_b_per_improvement"})
+	return rt
+}
+
+func (rt *RebalanceEfficiencyTracker) write(record []string) error {
+	for _, w := range rt.writers {
+		if err := w.Write(record); err != nil {
+			return err
+		}
+		w.Flush()
+	}
+	return nil
+}
+
+// bytesPerImprovement returns the bytes rebalanced per unit of standard
+// deviation reduction. When no bytes have been rebalanced, this is zero. When
+// bytes have been rebalanced without any improvement, this is +Inf.
+func bytesPerImprovement(bytes int64, improvement float64) float64 {
+	if bytes == 0 {
+		return 0
+	}
+	if improvement <= 0 {
+		return math.Inf(1)
+	}
+	return float64(bytes) / improvement
+}
+
+// Listen implements the StoreMetricsListener interface.
+func (rt *RebalanceEfficiencyTracker) Listen(ctx context.Context, sms []StoreMetrics) {
+	if len(sms) == 0 {
+		return
+	}
+	var bytesRebalanced int64
+	replicas := make([]float64, 0, len(sms))
+	for _, sm := range sms {
+		bytesRebalanced += sm.RebalanceRcvdBytes
+		replicas = append(replicas, float64(sm.Replicas))
+	}
+	stdDev, _ := stats.StandardDeviationPopulation(replicas)
+	if rt.initialStdDev < 0 {
+		rt.initialStdDev = stdDev
+	}
+	rt.lastRatio = bytesPerImprovement(bytesRebalanced, rt.initialStdDev-stdDev)
+
+	record := []string{
+		sms[0].Tick.String(),
+		fmt.Sprintf("%d", bytesRebalanced),
+		fmt.Sprintf("%.2f", stdDev),
+		fmt.Sprintf("%.2f", rt.lastRatio),
+	}
+	if err := rt.write(record); err != nil {
+		log.Errorf(ctx, "Error writing rebalance efficiency metrics %s", err.Error())
+	}
+}
+
+// BytesPerImprovement returns the bytes rebalanced per unit of replica count
+// standard deviation reduction, as of the last tick recorded.
+func (rt *RebalanceEfficiencyTracker) BytesPerImprovement() float64 {
+	return rt.lastRatio
+}
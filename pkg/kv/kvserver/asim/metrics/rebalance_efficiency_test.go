@@ -0,0 +1,76 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package metrics_test
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/metrics"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRebalanceEfficiency asserts that an allocator which reaches the same
+// balance whilst moving more data, has a higher number of bytes rebalanced per
+// unit of improvement.
+func TestRebalanceEfficiency(t *testing.T) {
+	ctx := context.Background()
+	start := state.TestingStartTime()
+
+	// makeTick returns the store metrics for a tick, where each store has the
+	// number of replicas given and the cluster has received rcvdBytes in
+	// total.
+	makeTick := func(tick int64, rcvdBytes int64, replicas ...int64) []metrics.StoreMetrics {
+		sms := make([]metrics.StoreMetrics, len(replicas))
+		for i := range replicas {
+			sms[i] = metrics.StoreMetrics{
+				Tick:     state.OffsetTick(start, tick),
+				StoreID:  int64(i + 1),
+				Replicas: replicas[i],
+			}
+		}
+		sms[0].RebalanceRcvdBytes = rcvdBytes
+		return sms
+	}
+
+	run := func(history ...[]metrics.StoreMetrics) float64 {
+		var buf bytes.Buffer
+		rt := metrics.NewRebalanceEfficiencyTracker(&buf)
+		for _, sms := range history {
+			rt.Listen(ctx, sms)
+		}
+		return rt.BytesPerImprovement()
+	}
+
+	// Both allocators balance the stores from (30, 0, 0) to (10, 10, 10). The
+	// good allocator moves each replica once, the aggressive allocator moves
+	// replicas back and forth before settling.
+	good := run(
+		makeTick(0, 0, 30, 0, 0),
+		makeTick(10, 10<<20, 20, 5, 5),
+		makeTick(20, 20<<20, 10, 10, 10),
+	)
+	aggressive := run(
+		makeTick(0, 0, 30, 0, 0),
+		makeTick(10, 30<<20, 20, 10, 0),
+		makeTick(20, 60<<20, 10, 10, 10),
+	)
+	require.Greater(t, good, float64(0))
+	require.Greater(t, aggressive, good)
+
+	// Moving data without any improvement is infinitely inefficient, whilst
+	// not moving any data is not penalized.
+	require.True(t, math.IsInf(run(makeTick(0, 0, 10, 10, 10), makeTick(10, 1<<20, 10, 10, 10)), 1))
+	require.Equal(t, float64(0), run(makeTick(0, 0, 30, 0, 0), makeTick(10, 0, 30, 0, 0)))
+}
@@ -0,0 +1,148 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/liveness/livenesspb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding/csv"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// RecoveryTracker measures how long the cluster takes to recover from a store
+// failure, in a CSV format. A store fails when its node becomes dead, the
+// cluster has recovered once no range has fewer replicas on live stores than
+// its replication target. Unlike the under-replicated ranges reported in the
+// store metrics, replicas on dead stores aren't counted, so the ranges of a
+// failed store are under-replicated until the allocator has replaced the
+// replica elsewhere. The recovery time is reported in simulator ticks, from
+// the first tick the failure was observed at until the first tick the cluster
+// was observed to have recovered at. The recovery ticks written are those
+// elapsed since the failure whilst recovering, otherwise those the last
+// recovery took, or -1 if the cluster has yet to recover from a failure.
+type RecoveryTracker struct {
+	writers      []*csv.Writer
+	tickInterval time.Duration
+	// deadStores are the stores which were on a dead node as of the last tick
+	// recorded.
+	deadStores map[state.StoreID]bool
+	// failedAt is the tick at which the ongoing recovery began, it is zero when
+	// the cluster isn't recovering from a failure.
+	failedAt time.Time
+	// recoveryTicks is the number of ticks the last completed recovery took, it
+	// is negative when no recovery has completed.
+	recoveryTicks int64
+}
+
+var _ StateListener = &RecoveryTracker{}
+
+// NewRecoveryTracker returns a new RecoveryTracker which reports the recovery
+// time in ticks of tickInterval, and writes to the writers given. It should be
+// registered against a Tracker using RegisterStateListener.
+func NewRecoveryTracker(tickInterval time.Duration, writers ...io.Writer) *RecoveryTracker {
+	rt := &RecoveryTracker{
+		tickInterval:  tickInterval,
+		deadStores:    make(map[state.StoreID]bool),
+		recoveryTicks: -1,
+	}
+	for _, w := range writers {
+		rt.writers = append(rt.writers, csv.NewWriter(w))
+	}
+	_ = rt.write([]string{"tick", "c_dead_stores", "c_under_replicated_live", "c_recovery_ticks"})
+	return rt
+}
+
+func (rt *RecoveryTracker) write(record []string) error {
+	for _, w := range rt.writers {
+		if err := w.Write(record); err != nil {
+			return err
+		}
+		w.Flush()
+	}
+	return nil
+}
+
+// ListenState implements the StateListener interface.
+func (rt *RecoveryTracker) ListenState(ctx context.Context, tick time.Time, s state.State) {
+	livenessFn := s.NodeLivenessFn()
+	deadStores := make(map[state.StoreID]bool)
+	failed := false
+	for _, store := range s.Stores() {
+		if livenessFn(roachpb.NodeID(store.NodeID())) != livenesspb.NodeLivenessStatus_DEAD {
+			continue
+		}
+		deadStores[store.StoreID()] = true
+		if !rt.deadStores[store.StoreID()] {
+			failed = true
+		}
+	}
+	rt.deadStores = deadStores
+
+	var underReplicated int64
+	for _, r := range s.Ranges() {
+		target, ok := s.RangeReplicationTarget(r.RangeID())
+		if !ok {
+			continue
+		}
+		live := 0
+		for _, repl := range r.Replicas() {
+			if !deadStores[repl.StoreID()] {
+				live++
+			}
+		}
+		if live < target {
+			underReplicated++
+		}
+	}
+
+	// NB: A store which fails while the cluster is recovering from an earlier
+	// failure extends the ongoing recovery, rather than starting another.
+	if failed && rt.failedAt.IsZero() {
+		rt.failedAt = tick
+	}
+	ticks := rt.recoveryTicks
+	if !rt.failedAt.IsZero() {
+		ticks = int64(tick.Sub(rt.failedAt) / rt.tickInterval)
+		if underReplicated == 0 {
+			rt.recoveryTicks = ticks
+			rt.failedAt = time.Time{}
+		}
+	}
+
+	record := []string{
+		tick.String(),
+		fmt.Sprintf("%d", len(deadStores)),
+		fmt.Sprintf("%d", underReplicated),
+		fmt.Sprintf("%d", ticks),
+	}
+	if err := rt.write(record); err != nil {
+		log.Errorf(ctx, "Error writing recovery metrics %s", err.Error())
+	}
+}
+
+// RecoveryTicks returns the number of ticks the cluster took to recover from
+// the last store failure it has recovered from. It returns false if the
+// cluster hasn't recovered from any store failure.
+func (rt *RecoveryTracker) RecoveryTicks() (int64, bool) {
+	return rt.recoveryTicks, rt.recoveryTicks >= 0
+}
+
+// Recovering returns whether the cluster was recovering from a store failure
+// as of the last tick recorded, along with the tick the recovery began at.
+func (rt *RecoveryTracker) Recovering() (time.Time, bool) {
+	return rt.failedAt, !rt.failedAt.IsZero()
+}
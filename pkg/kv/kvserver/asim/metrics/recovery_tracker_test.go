@@ -0,0 +1,150 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package metrics_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/config"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/event"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/metrics"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/workload"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/liveness/livenesspb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRecoveryTracker asserts that the recovery tracker counts the ticks from
+// a store failing until its replica is replaced on a live store.
+func TestRecoveryTracker(t *testing.T) {
+	ctx := context.Background()
+	settings := config.DefaultSimulationSettings()
+	start := state.TestingStartTime()
+
+	// A single range on the first three stores, the fourth store is empty.
+	s := state.NewStateWithReplCounts(
+		map[state.StoreID]int{1: 1, 2: 1, 3: 1, 4: 0}, 3 /* replicationFactor */, 1000 /* keyspace */, settings)
+	rangeID := s.Ranges()[0].RangeID()
+
+	var buf bytes.Buffer
+	rt := metrics.NewRecoveryTracker(time.Second, &buf)
+	rt.ListenState(ctx, state.OffsetTick(start, 0), s)
+	_, ok := rt.RecoveryTicks()
+	require.False(t, ok)
+
+	s.SetNodeLiveness(3, livenesspb.NodeLivenessStatus_DEAD)
+	rt.ListenState(ctx, state.OffsetTick(start, 10), s)
+	failedAt, ok := rt.Recovering()
+	require.True(t, ok)
+	require.Equal(t, state.OffsetTick(start, 10), failedAt)
+
+	// Replace the replica on the dead store. The cluster has recovered as soon
+	// as the replica is added, removing the dead replica doesn't matter.
+	_, ok = s.AddReplica(rangeID, 4, roachpb.VOTER_FULL)
+	require.True(t, ok)
+	rt.ListenState(ctx, state.OffsetTick(start, 20), s)
+	require.True(t, s.RemoveReplica(rangeID, 3))
+	rt.ListenState(ctx, state.OffsetTick(start, 30), s)
+
+	_, ok = rt.Recovering()
+	require.False(t, ok)
+	recoveryTicks, ok := rt.RecoveryTicks()
+	require.True(t, ok)
+	require.Equal(t, int64(10), recoveryTicks)
+	require.Equal(t,
+		"tick,c_dead_stores,c_under_replicated_live,c_recovery_ticks\n"+
+			"2022-03-21 11:00:00 +0000 UTC,0,0,-1\n"+
+			"2022-03-21 11:00:10 +0000 UTC,1,1,0\n"+
+			"2022-03-21 11:00:20 +0000 UTC,1,0,10\n"+
+			"2022-03-21 11:00:30 +0000 UTC,1,0,10\n",
+		buf.String())
+}
+
+// TestRecoveryTrackerNodeFailure fails a store in a simulation and asserts
+// that the cluster up-replicates the store's ranges elsewhere within the time
+// bound implied by the replica change delay, i.e. the snapshot rate, and the
+// rate at which the replicate queues visit replicas.
+func TestRecoveryTrackerNodeFailure(t *testing.T) {
+	ctx := context.Background()
+	settings := config.DefaultSimulationSettings()
+	duration := 20 * time.Minute
+	settings.TickInterval = time.Second
+	settings.PacerLoopInterval = time.Minute
+	settings.ReplicaChangeBaseDelay = 5 * time.Second
+	failAt := settings.StartTime.Add(time.Minute)
+
+	stores := 5
+	ranges := 25
+	// NB: The replicas are spread evenly, however none of the leases are on
+	// the last store, which fails. There is no load, so there are no splits.
+	replicaWeights := make([]float64, stores)
+	leaseWeights := make([]float64, stores)
+	for i := range replicaWeights {
+		replicaWeights[i] = 1.0 / float64(stores)
+		if i < stores-1 {
+			leaseWeights[i] = 1.0 / float64(stores-1)
+		}
+	}
+	s := state.LoadClusterInfo(state.ClusterInfoWithStoreCount(stores, 1 /* storesPerNode */), settings)
+	storeIDs := make([]state.StoreID, stores)
+	for i, store := range s.Stores() {
+		storeIDs[i] = store.StoreID()
+	}
+	state.LoadRangeInfo(s, state.RangesInfoWithDistribution(
+		storeIDs,
+		replicaWeights,
+		leaseWeights,
+		ranges,
+		roachpb.SpanConfig{NumReplicas: 3, NumVoters: 3},
+		int64(state.MinKey),
+		int64(3*ranges),
+		0, /* rangeSize */
+	)...)
+	failedStore := storeIDs[stores-1]
+	failedReplicas := len(s.Replicas(failedStore))
+	require.NotZero(t, failedReplicas)
+
+	store, ok := s.Store(failedStore)
+	require.True(t, ok)
+	failure := event.DelayedEvent{
+		At: failAt,
+		EventFn: func(ctx context.Context, tick time.Time, s state.State) {
+			s.SetNodeLiveness(store.NodeID(), livenesspb.NodeLivenessStatus_DEAD)
+		},
+	}
+
+	rt := metrics.NewRecoveryTracker(settings.TickInterval) // no output
+	m := metrics.NewTracker(testingMetricsInterval)
+	m.RegisterStateListener(rt)
+
+	sim := asim.NewSimulator(duration, []workload.Generator{}, s, settings, m, failure)
+	sim.RunSim(ctx)
+
+	_, recovering := rt.Recovering()
+	require.False(t, recovering)
+	recoveryTicks, ok := rt.RecoveryTicks()
+	require.True(t, ok)
+	recovery := time.Duration(recoveryTicks) * settings.TickInterval
+
+	// Each replica on the failed store must be replaced by adding a replica
+	// elsewhere, which takes at least the replica change delay. In the worst
+	// case, the replicate queues only visit the ranges at the end of their loop
+	// and the additions happen one after another.
+	addDelay := settings.ReplicaChangeDelayFn()(0 /* rangeSize */, true /* add */)
+	require.GreaterOrEqual(t, recovery, addDelay)
+	require.LessOrEqual(t, recovery,
+		settings.PacerLoopInterval+time.Duration(failedReplicas)*addDelay+2*testingMetricsInterval)
+}
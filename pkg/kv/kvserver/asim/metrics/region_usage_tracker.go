@@ -0,0 +1,75 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding/csv"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// RegionUsageTracker writes the bytes read and written by the stores of each
+// region, in a CSV format. There is a row per region of the cluster at each
+// tick, ordered by region name. This can be used to validate that the traffic
+// of a geo-partitioned workload stays within the regions it is pinned to.
+type RegionUsageTracker struct {
+	writers []*csv.Writer
+}
+
+var _ StateListener = &RegionUsageTracker{}
+
+// NewRegionUsageTracker returns a new RegionUsageTracker which writes to the
+// writers given. It should be registered against a Tracker using
+// RegisterStateListener.
+func NewRegionUsageTracker(writers ...io.Writer) *RegionUsageTracker {
+	rt := &RegionUsageTracker{}
+	for _, w := range writers {
+		rt.writers = append(rt.writers, csv.NewWriter(w))
+	}
+	// The read and write bytes are cumulative, up to the tick.
+	_ = rt.write([]string{"tick", "region", "region_read_b", "region_write_b"})
+	return rt
+}
+
+func (rt *RegionUsageTracker) write(record []string) error {
+	for _, w := range rt.writers {
+		if err := w.Write(record); err != nil {
+			return err
+		}
+		w.Flush()
+	}
+	return nil
+}
+
+// ListenState implements the StateListener interface.
+func (rt *RegionUsageTracker) ListenState(ctx context.Context, tick time.Time, s state.State) {
+	usage := s.ClusterUsageInfo().RegionUsage
+	for _, region := range state.Regions(s) {
+		var u state.RegionUsageInfo
+		if ru, ok := usage[region]; ok {
+			u = *ru
+		}
+		record := []string{
+			tick.String(),
+			region,
+			fmt.Sprintf("%d", u.ReadBytes),
+			fmt.Sprintf("%d", u.WriteBytes),
+		}
+		if err := rt.write(record); err != nil {
+			log.Errorf(ctx, "Error writing region usage metrics %s", err.Error())
+		}
+	}
+}
@@ -38,6 +38,30 @@ func MakeTS(metrics [][]StoreMetrics) map[string][][]float64 {
 	ret["replica_b_sent"] = make([][]float64, stores)
 	ret["range_splits"] = make([][]float64, stores)
 	ret["disk_fraction_used"] = make([][]float64, stores)
+	ret["unavailable_ranges"] = make([][]float64, stores)
+	ret["over_replicated_ranges"] = make([][]float64, stores)
+	ret["under_replicated_ranges"] = make([][]float64, stores)
+	ret["deferred_moves"] = make([][]float64, stores)
+	ret["write_stalled"] = make([][]float64, stores)
+	ret["stalled_write_b"] = make([][]float64, stores)
+	ret["lease_stall_b"] = make([][]float64, stores)
+	ret["throttled_write"] = make([][]float64, stores)
+	ret["replicate_queue"] = make([][]float64, stores)
+	ret["lease_queue"] = make([][]float64, stores)
+	ret["split_queue"] = make([][]float64, stores)
+	ret["evaluated_ranges"] = make([][]float64, stores)
+	ret["snapshot_send_util"] = make([][]float64, stores)
+	ret["snapshot_recv_util"] = make([][]float64, stores)
+	ret["gc_pending_replicas"] = make([][]float64, stores)
+	ret["lease_reacquiring_ranges"] = make([][]float64, stores)
+	ret["leaseless_ranges"] = make([][]float64, stores)
+	ret["idle_ticks"] = make([][]float64, stores)
+	ret["bg_read_b"] = make([][]float64, stores)
+	ret["bg_write_b"] = make([][]float64, stores)
+	ret["range_mean_delta"] = make([][]float64, stores)
+	ret["range_overfull"] = make([][]float64, stores)
+	ret["range_underfull"] = make([][]float64, stores)
+	ret["lease_cpu"] = make([][]float64, stores)
 
 	for _, sms := range metrics {
 		for i, sm := range sms {
@@ -54,6 +78,30 @@ func MakeTS(metrics [][]StoreMetrics) map[string][][]float64 {
 			ret["replica_b_sent"][i] = append(ret["replica_b_sent"][i], float64(sm.RebalanceSentBytes))
 			ret["range_splits"][i] = append(ret["range_splits"][i], float64(sm.RangeSplits))
 			ret["disk_fraction_used"][i] = append(ret["disk_fraction_used"][i], sm.DiskFractionUsed)
+			ret["unavailable_ranges"][i] = append(ret["unavailable_ranges"][i], float64(sm.UnavailableRanges))
+			ret["over_replicated_ranges"][i] = append(ret["over_replicated_ranges"][i], float64(sm.OverReplicatedRanges))
+			ret["under_replicated_ranges"][i] = append(ret["under_replicated_ranges"][i], float64(sm.UnderReplicatedRanges))
+			ret["deferred_moves"][i] = append(ret["deferred_moves"][i], float64(sm.DeferredMoves))
+			ret["write_stalled"][i] = append(ret["write_stalled"][i], float64(sm.WriteStalled))
+			ret["stalled_write_b"][i] = append(ret["stalled_write_b"][i], float64(sm.StalledWriteBytes))
+			ret["lease_stall_b"][i] = append(ret["lease_stall_b"][i], float64(sm.LeaseStallBytes))
+			ret["throttled_write"][i] = append(ret["throttled_write"][i], float64(sm.ThrottledWrites))
+			ret["replicate_queue"][i] = append(ret["replicate_queue"][i], float64(sm.ReplicateQueueLength))
+			ret["lease_queue"][i] = append(ret["lease_queue"][i], float64(sm.LeaseQueueLength))
+			ret["split_queue"][i] = append(ret["split_queue"][i], float64(sm.SplitQueueLength))
+			ret["evaluated_ranges"][i] = append(ret["evaluated_ranges"][i], float64(sm.EvaluatedRanges))
+			ret["snapshot_send_util"][i] = append(ret["snapshot_send_util"][i], sm.SnapshotSendUtilization)
+			ret["snapshot_recv_util"][i] = append(ret["snapshot_recv_util"][i], sm.SnapshotRecvUtilization)
+			ret["gc_pending_replicas"][i] = append(ret["gc_pending_replicas"][i], float64(sm.GCPendingReplicas))
+			ret["lease_reacquiring_ranges"][i] = append(ret["lease_reacquiring_ranges"][i], float64(sm.LeaseReacquiringRanges))
+			ret["leaseless_ranges"][i] = append(ret["leaseless_ranges"][i], float64(sm.LeaselessRanges))
+			ret["idle_ticks"][i] = append(ret["idle_ticks"][i], float64(sm.IdleTicks))
+			ret["bg_read_b"][i] = append(ret["bg_read_b"][i], float64(sm.BackgroundReadBytes))
+			ret["bg_write_b"][i] = append(ret["bg_write_b"][i], float64(sm.BackgroundWriteBytes))
+			ret["range_mean_delta"][i] = append(ret["range_mean_delta"][i], sm.RangeMeanDelta)
+			ret["range_overfull"][i] = append(ret["range_overfull"][i], float64(sm.RangeOverfull))
+			ret["range_underfull"][i] = append(ret["range_underfull"][i], float64(sm.RangeUnderfull))
+			ret["lease_cpu"][i] = append(ret["lease_cpu"][i], float64(sm.LeaseCPU))
 		}
 	}
 	return ret
@@ -0,0 +1,82 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding/csv"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// StorageClassTracker writes the number of reads served by the stores of each
+// storage class, e.g. "ssd" or "hdd", along with their p50 and p99 latency, in
+// a CSV format. There is a row per storage class which has served reads at
+// each tick, ordered by storage class. This can be used to check that a
+// placement strategy routes the reads of hot data to faster storage.
+type StorageClassTracker struct {
+	writers []*csv.Writer
+}
+
+var _ StateListener = &StorageClassTracker{}
+
+// NewStorageClassTracker returns a new StorageClassTracker which writes to the
+// writers given. It should be registered against a Tracker using
+// RegisterStateListener.
+func NewStorageClassTracker(writers ...io.Writer) *StorageClassTracker {
+	st := &StorageClassTracker{}
+	for _, w := range writers {
+		st.writers = append(st.writers, csv.NewWriter(w))
+	}
+	// The reads, and their latency, are cumulative up to the tick.
+	_ = st.write([]string{
+		"tick", "storage_class", "class_reads", "class_read_latency_p50", "class_read_latency_p99",
+	})
+	return st
+}
+
+func (st *StorageClassTracker) write(record []string) error {
+	for _, w := range st.writers {
+		if err := w.Write(record); err != nil {
+			return err
+		}
+		w.Flush()
+	}
+	return nil
+}
+
+// ListenState implements the StateListener interface.
+func (st *StorageClassTracker) ListenState(ctx context.Context, tick time.Time, s state.State) {
+	usage := s.ClusterUsageInfo().StorageClassUsage
+	classes := make([]string, 0, len(usage))
+	for class := range usage {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	for _, class := range classes {
+		u := usage[class]
+		record := []string{
+			tick.String(),
+			class,
+			fmt.Sprintf("%d", u.Reads),
+			formatLatency(u.ReadLatency.Percentile(50)),
+			formatLatency(u.ReadLatency.Percentile(99)),
+		}
+		if err := st.write(record); err != nil {
+			log.Errorf(ctx, "Error writing storage class metrics %s", err.Error())
+		}
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"sort"
 	"time"
 
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/allocator/allocatorimpl"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
 )
 
@@ -42,6 +43,75 @@ type StoreMetrics struct {
 	RebalanceRcvdBytes int64
 	RangeSplits        int64
 	DiskFractionUsed   float64
+	// UnavailableRanges tracks the number of ranges, whose leaseholder is on
+	// this store, that have lost quorum and cannot make progress.
+	UnavailableRanges int64
+	// LeaseReacquiringRanges tracks the number of ranges, whose leaseholder
+	// is on this store, that are reacquiring their lease after the store's
+	// node restarted.
+	LeaseReacquiringRanges int64
+	// LeaselessRanges tracks the number of ranges, whose lease is on this
+	// store, that have no active leaseholder because the lease is still being
+	// acquired after a lease transfer or a restart.
+	LeaselessRanges int64
+	// OverReplicatedRanges and UnderReplicatedRanges track the number of
+	// ranges, whose leaseholder is on this store, that have more or fewer
+	// replicas than their configured replication target.
+	OverReplicatedRanges  int64
+	UnderReplicatedRanges int64
+	// DeferredMoves tracks the number of replica changes and lease transfers
+	// authored by this store, which were deferred due to the rebalance budget.
+	DeferredMoves int64
+	// WriteStalled is 1 when the writes of this store are stalled and 0
+	// otherwise. StalledWriteBytes tracks the number of write bytes rejected
+	// whilst this store held the lease and its writes were stalled.
+	WriteStalled      int64
+	StalledWriteBytes int64
+	// LeaseStallBytes tracks the number of read and write bytes rejected
+	// whilst a range, whose lease was recently transferred to this store, was
+	// stalled.
+	LeaseStallBytes int64
+	// ThrottledWrites tracks the number of writes rejected whilst this store
+	// held the lease, because all of its write concurrency was occupied by
+	// writes waiting to reach quorum.
+	ThrottledWrites int64
+	// ReplicateQueueLength, LeaseQueueLength and SplitQueueLength track the
+	// amount of work which is backed up on this store: the replicas in its
+	// replicate queue, the lease transfers it dispatched which are not yet
+	// done and the replicas in its split queue.
+	ReplicateQueueLength int64
+	LeaseQueueLength     int64
+	SplitQueueLength     int64
+	// EvaluatedRanges tracks the number of ranges this store's allocator
+	// evaluated in the last tick.
+	EvaluatedRanges int64
+	// SnapshotSendUtilization and SnapshotRecvUtilization track the fractions
+	// of this store's snapshot send and receive bandwidth used in the last
+	// tick.
+	SnapshotSendUtilization float64
+	SnapshotRecvUtilization float64
+	// GCPendingReplicas tracks the number of replicas removed from this store,
+	// which are pending garbage collection.
+	GCPendingReplicas int64
+	// IdleTicks tracks the number of ticks where the allocators made no
+	// replica move or lease transfer. It is cluster wide, so identical for
+	// every store.
+	IdleTicks int64
+	// BackgroundReadBytes and BackgroundWriteBytes track the number of bytes
+	// read and written by background processes on this store.
+	BackgroundReadBytes  int64
+	BackgroundWriteBytes int64
+	// RangeMeanDelta tracks how far the replica count of this store is above,
+	// when positive, or below, when negative, the mean replica count of the
+	// stores. RangeOverfull and RangeUnderfull are 1 when the allocator
+	// considers this store overfull, i.e. a candidate for shedding replicas, or
+	// underfull, i.e. a candidate for receiving replicas, and 0 otherwise.
+	RangeMeanDelta float64
+	RangeOverfull  int64
+	RangeUnderfull int64
+	// LeaseCPU tracks the CPU nanoseconds per second spent by this store
+	// evaluating the requests of the ranges whose lease it holds.
+	LeaseCPU int64
 }
 
 // the MetricsTracker to report new store metrics for a tick.
@@ -49,10 +119,24 @@ type StoreMetricsListener interface {
 	Listen(context.Context, []StoreMetrics)
 }
 
+// StoreMetricsCloser is implemented by a StoreMetricsListener which needs to be
+// notified once the simulation has finished, e.g. to flush buffered output.
+type StoreMetricsCloser interface {
+	Close(context.Context)
+}
+
+// StateListener is registered against the Tracker to observe the simulation
+// state directly, at the same ticks where store metrics are reported. This is
+// useful for metrics that aren't aggregated per-store, such as per-range load.
+type StateListener interface {
+	ListenState(context.Context, time.Time, state.State)
+}
+
 // Tracker maintains a list of listeners and updates them with new
 // StoreMetrics information when ticked.
 type Tracker struct {
 	storeListeners []StoreMetricsListener
+	stateListeners []StateListener
 	lastTick       time.Time
 	interval       time.Duration
 }
@@ -71,6 +155,13 @@ func (mt *Tracker) Register(listeners ...StoreMetricsListener) {
 	mt.storeListeners = append(mt.storeListeners, listeners...)
 }
 
+// RegisterStateListener registers StateListener's against the tracker.
+// Subsequent calls to Tick will also update the listeners with the state at
+// the tick.
+func (mt *Tracker) RegisterStateListener(listeners ...StateListener) {
+	mt.stateListeners = append(mt.stateListeners, listeners...)
+}
+
 // Tick updates all listeners attached to the metrics tracker with the state at
 // the tick given.
 func (mt *Tracker) Tick(ctx context.Context, tick time.Time, s state.State) {
@@ -79,11 +170,15 @@ func (mt *Tracker) Tick(ctx context.Context, tick time.Time, s state.State) {
 		return
 	}
 
-	if len(mt.storeListeners) < 1 {
+	if len(mt.storeListeners) < 1 && len(mt.stateListeners) < 1 {
 		// There are no listeners, so there is no point updating metrics here.
 		return
 	}
 
+	for _, listener := range mt.stateListeners {
+		listener.ListenState(ctx, tick, s)
+	}
+
 	sms := []StoreMetrics{}
 	usage := s.ClusterUsageInfo()
 
@@ -92,10 +187,51 @@ func (mt *Tracker) Tick(ctx context.Context, tick time.Time, s state.State) {
 		storeIDs = append(storeIDs, store.StoreID())
 	}
 
+	unavailable := make(map[state.StoreID]int64)
+	overReplicated := make(map[state.StoreID]int64)
+	underReplicated := make(map[state.StoreID]int64)
+	reacquiringLease := make(map[state.StoreID]int64)
+	leaseless := make(map[state.StoreID]int64)
+	for _, r := range s.Ranges() {
+		store, ok := s.LeaseholderStore(r.RangeID())
+		if !ok {
+			continue
+		}
+		if s.RangeUnavailable(r.RangeID()) {
+			unavailable[store.StoreID()]++
+		}
+		if s.RangeReacquiringLease(r.RangeID()) {
+			reacquiringLease[store.StoreID()]++
+		}
+		if s.RangeLeaseless(r.RangeID()) {
+			leaseless[store.StoreID()]++
+		}
+		if target, ok := s.RangeReplicationTarget(r.RangeID()); ok {
+			if replicas := len(r.Replicas()); replicas > target {
+				overReplicated[store.StoreID()]++
+			} else if replicas < target {
+				underReplicated[store.StoreID()]++
+			}
+		}
+	}
+
 	// Recompute the store descriptors. We access them directly via the store
 	// interface below.
 	_ = s.StoreDescriptors(false, storeIDs...)
 
+	// Classify the stores by their replica count, as the allocator's range
+	// count scorer does. Every simulated store uses the default cluster
+	// settings, so the default range rebalance threshold applies.
+	var meanRanges float64
+	for _, store := range s.Stores() {
+		meanRanges += float64(store.Descriptor().Capacity.RangeCount)
+	}
+	if len(storeIDs) > 0 {
+		meanRanges /= float64(len(storeIDs))
+	}
+	underfullRanges, overfullRanges := allocatorimpl.RangeCountThresholds(
+		meanRanges, allocatorimpl.RangeRebalanceThreshold.Default())
+
 	for storeID, u := range usage.StoreUsage {
 		store, ok := s.Store(storeID)
 		if !ok {
@@ -108,6 +244,7 @@ func (mt *Tracker) Tick(ctx context.Context, tick time.Time, s state.State) {
 			Tick:               tick,
 			StoreID:            int64(storeID),
 			QPS:                int64(desc.Capacity.QueriesPerSecond),
+			LeaseCPU:           int64(desc.Capacity.CPUPerSecond),
 			WriteKeys:          u.WriteKeys,
 			WriteBytes:         u.WriteBytes,
 			ReadKeys:           u.ReadKeys,
@@ -120,6 +257,37 @@ func (mt *Tracker) Tick(ctx context.Context, tick time.Time, s state.State) {
 			RebalanceRcvdBytes: u.RebalanceRcvdBytes,
 			RangeSplits:        u.RangeSplits,
 			DiskFractionUsed:   desc.Capacity.FractionUsed(),
+			UnavailableRanges:  unavailable[storeID],
+
+			OverReplicatedRanges:  overReplicated[storeID],
+			UnderReplicatedRanges: underReplicated[storeID],
+			DeferredMoves:         u.DeferredMoves,
+			StalledWriteBytes:     u.StalledWriteBytes,
+			LeaseStallBytes:       u.LeaseStallBytes,
+			ThrottledWrites:       u.ThrottledWrites,
+			ReplicateQueueLength:  u.ReplicateQueueLength,
+			LeaseQueueLength:      u.LeaseQueueLength,
+			SplitQueueLength:      u.SplitQueueLength,
+			EvaluatedRanges:       u.EvaluatedRanges,
+			GCPendingReplicas:     int64(s.GCPendingReplicas(storeID)),
+
+			LeaseReacquiringRanges: reacquiringLease[storeID],
+			LeaselessRanges:        leaseless[storeID],
+			IdleTicks:              usage.IdleTicks,
+			BackgroundReadBytes:    u.BackgroundReadBytes,
+			BackgroundWriteBytes:   u.BackgroundWriteBytes,
+
+			SnapshotSendUtilization: u.SnapshotSendUtilization,
+			SnapshotRecvUtilization: u.SnapshotRecvUtilization,
+		}
+		if s.StoreWriteStalled(storeID) {
+			sm.WriteStalled = 1
+		}
+		sm.RangeMeanDelta = float64(sm.Replicas) - meanRanges
+		if rangeCount := float64(sm.Replicas); rangeCount >= overfullRanges {
+			sm.RangeOverfull = 1
+		} else if rangeCount < underfullRanges {
+			sm.RangeUnderfull = 1
 		}
 		sms = append(sms, sm)
 	}
@@ -132,3 +300,18 @@ func (mt *Tracker) Tick(ctx context.Context, tick time.Time, s state.State) {
 		listener.Listen(ctx, sms)
 	}
 }
+
+// Close notifies any registered StoreMetricsListener's and StateListener's
+// which implement StoreMetricsCloser that no more metrics will be reported.
+func (mt *Tracker) Close(ctx context.Context) {
+	for _, listener := range mt.storeListeners {
+		if closer, ok := listener.(StoreMetricsCloser); ok {
+			closer.Close(ctx)
+		}
+	}
+	for _, listener := range mt.stateListeners {
+		if closer, ok := listener.(StoreMetricsCloser); ok {
+			closer.Close(ctx)
+		}
+	}
+}
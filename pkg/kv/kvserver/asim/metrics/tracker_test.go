@@ -11,15 +11,19 @@
 package metrics_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/config"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/metrics"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/workload"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/stretchr/testify/require"
 )
 
@@ -50,3 +54,272 @@ func TestTracker(t *testing.T) {
 
 	require.Equal(t, l1.history, l2.history)
 }
+
+// TestUnderReplicatedRanges asserts that removing a replica causes the range to
+// be reported as under-replicated, until the simulator adds a replica back.
+func TestUnderReplicatedRanges(t *testing.T) {
+	ctx := context.Background()
+	settings := config.DefaultSimulationSettings()
+	duration := 10 * time.Minute
+	rwg := []workload.Generator{
+		workload.TestCreateWorkloadGenerator(settings.Seed, settings.StartTime, 10, 10000),
+	}
+	s := state.NewStateEvenDistribution(5, 1, 3, 10000, settings)
+
+	// Remove a replica, which isn't the leaseholder, from the replicated range.
+	var removed bool
+	for _, r := range s.Ranges() {
+		lhStore, ok := s.LeaseholderStore(r.RangeID())
+		if !ok || len(r.Replicas()) != 3 {
+			continue
+		}
+		for _, repl := range r.Replicas() {
+			if repl.StoreID() != lhStore.StoreID() {
+				removed = s.RemoveReplica(r.RangeID(), repl.StoreID())
+				break
+			}
+		}
+		break
+	}
+	require.True(t, removed)
+
+	l := &mockListener{history: [][]metrics.StoreMetrics{}}
+	tracker := metrics.NewTracker(testingMetricsInterval, l)
+	sim := asim.NewSimulator(duration, rwg, s, settings, tracker)
+	sim.RunSim(ctx)
+
+	underReplicated := make([]int64, len(l.history))
+	for i, sms := range l.history {
+		for _, sm := range sms {
+			require.Zero(t, sm.OverReplicatedRanges)
+			underReplicated[i] += sm.UnderReplicatedRanges
+		}
+	}
+	require.NotEmpty(t, underReplicated)
+	require.Equal(t, int64(1), underReplicated[0])
+	require.Equal(t, int64(0), underReplicated[len(underReplicated)-1])
+}
+
+// TestThrottledWrites asserts that the writes rejected by a leaseholder store,
+// because its write concurrency is exhausted, are reported in its metrics.
+func TestThrottledWrites(t *testing.T) {
+	ctx := context.Background()
+	throttledWrites := func(limit int) int64 {
+		settings := config.DefaultSimulationSettings()
+		settings.WriteConcurrencyLimit = limit
+		duration := time.Minute
+		rwg := []workload.Generator{
+			workload.TestCreateWorkloadGenerator(settings.Seed, settings.StartTime, 100000, 10000),
+		}
+		s := state.NewStateEvenDistribution(3, 1, 3, 10000, settings)
+		l := &mockListener{history: [][]metrics.StoreMetrics{}}
+		tracker := metrics.NewTracker(testingMetricsInterval, l)
+		sim := asim.NewSimulator(duration, rwg, s, settings, tracker)
+		sim.RunSim(ctx)
+
+		require.NotEmpty(t, l.history)
+		var throttled int64
+		for _, sm := range l.history[len(l.history)-1] {
+			throttled += sm.ThrottledWrites
+		}
+		return throttled
+	}
+
+	require.Zero(t, throttledWrites(0 /* limit */))
+	require.Greater(t, throttledWrites(1 /* limit */), int64(0))
+}
+
+// TestRebalanceBudget asserts that with a tight rebalance budget, replica moves
+// and lease transfers on an imbalanced cluster are deferred rather than being
+// initiated all at once, whilst rebalancing still makes progress.
+func TestRebalanceBudget(t *testing.T) {
+	ctx := context.Background()
+	settings := config.DefaultSimulationSettings()
+	settings.ReplicaMoveBudget = 1
+	settings.LeaseTransferBudget = 1
+	duration := 5 * time.Minute
+	rwg := []workload.Generator{
+		workload.TestCreateWorkloadGenerator(settings.Seed, settings.StartTime, 10, 10000),
+	}
+	s := state.NewStateWithReplCounts(
+		map[state.StoreID]int{1: 10, 2: 10, 3: 10, 4: 0, 5: 0, 6: 0}, 3 /* replicationFactor */, 10000, settings)
+
+	l := &mockListener{history: [][]metrics.StoreMetrics{}}
+	tracker := metrics.NewTracker(testingMetricsInterval, l)
+	sim := asim.NewSimulator(duration, rwg, s, settings, tracker)
+	sim.RunSim(ctx)
+
+	require.NotEmpty(t, l.history)
+	var deferred, moves int64
+	for _, sm := range l.history[len(l.history)-1] {
+		deferred += sm.DeferredMoves
+		moves += sm.Rebalances + sm.LeaseTransfers
+	}
+	require.Greater(t, deferred, int64(0))
+	require.Greater(t, moves, int64(0))
+}
+
+// TestOverfullUnderfullStores asserts that on a cluster skewed towards some
+// stores, the stores which are classified as overfull subsequently lose
+// replicas, whilst those classified as underfull gain replicas.
+func TestOverfullUnderfullStores(t *testing.T) {
+	ctx := context.Background()
+	settings := config.DefaultSimulationSettings()
+	duration := 10 * time.Minute
+	rwg := []workload.Generator{
+		workload.TestCreateWorkloadGenerator(settings.Seed, settings.StartTime, 10, 10000),
+	}
+	s := state.NewStateWithReplCounts(
+		map[state.StoreID]int{1: 30, 2: 30, 3: 30, 4: 0, 5: 0, 6: 0}, 3 /* replicationFactor */, 10000, settings)
+
+	l := &mockListener{history: [][]metrics.StoreMetrics{}}
+	tracker := metrics.NewTracker(testingMetricsInterval, l)
+	sim := asim.NewSimulator(duration, rwg, s, settings, tracker)
+	sim.RunSim(ctx)
+
+	require.NotEmpty(t, l.history)
+	first, last := l.history[0], l.history[len(l.history)-1]
+	require.Len(t, last, len(first))
+	var overfull, underfull int
+	for i, sm := range first {
+		require.Equal(t, sm.StoreID, last[i].StoreID)
+		require.False(t, sm.RangeOverfull == 1 && sm.RangeUnderfull == 1)
+		switch {
+		case sm.RangeOverfull == 1:
+			overfull++
+			require.Positive(t, sm.RangeMeanDelta)
+			require.Less(t, last[i].Replicas, sm.Replicas, "overfull store s%d", sm.StoreID)
+		case sm.RangeUnderfull == 1:
+			underfull++
+			require.Negative(t, sm.RangeMeanDelta)
+			require.Greater(t, last[i].Replicas, sm.Replicas, "underfull store s%d", sm.StoreID)
+		}
+	}
+	require.Equal(t, 3, overfull)
+	require.Equal(t, 3, underfull)
+}
+
+// TestLeaselessRanges asserts that a range is reported as leaseless for the
+// brief window after its lease is transferred, whilst the new leaseholder
+// acquires the lease, and that it is attributed to the new leaseholder.
+func TestLeaselessRanges(t *testing.T) {
+	ctx := context.Background()
+	settings := config.DefaultSimulationSettings()
+	settings.LeaseTransferStallTicks = 2
+	s := state.NewStateEvenDistribution(3, 1, 3, 10000, settings)
+	r := s.RangeFor(100)
+	lhStore, ok := s.LeaseholderStore(r.RangeID())
+	require.True(t, ok)
+	var target state.StoreID
+	for _, repl := range r.Replicas() {
+		if repl.StoreID() != lhStore.StoreID() {
+			target = repl.StoreID()
+			break
+		}
+	}
+
+	l := &mockListener{history: [][]metrics.StoreMetrics{}}
+	tracker := metrics.NewTracker(settings.TickInterval, l)
+	changer := state.NewReplicaChanger()
+	// The transfer takes a tick to complete, during which the old leaseholder
+	// still holds the lease.
+	_, ok = changer.Push(settings.StartTime, &state.LeaseTransferChange{
+		RangeID:        r.RangeID(),
+		TransferTarget: target,
+		Author:         lhStore.StoreID(),
+		Wait:           settings.TickInterval,
+	})
+	require.True(t, ok)
+
+	var leaseless, targetLeaseless []int64
+	for i := 0; i < 6; i++ {
+		tick := settings.StartTime.Add(time.Duration(i) * settings.TickInterval)
+		s.TickClock(tick)
+		changer.Tick(tick, s)
+		s.ApplyLoad(workload.LoadBatch{workload.LoadEvent{Key: 100, Writes: 1, WriteSize: 10}})
+		tracker.Tick(ctx, tick, s)
+		require.Len(t, l.history, i+1)
+		var total, onTarget int64
+		for _, sm := range l.history[len(l.history)-1] {
+			total += sm.LeaselessRanges
+			if state.StoreID(sm.StoreID) == target {
+				onTarget += sm.LeaselessRanges
+			}
+		}
+		leaseless = append(leaseless, total)
+		targetLeaseless = append(targetLeaseless, onTarget)
+	}
+	// The range is leaseless from the tick the transfer completes, until the
+	// stall after the transfer is over.
+	require.Equal(t, []int64{0, 1, 1, 1, 0, 0}, leaseless)
+	require.Equal(t, leaseless, targetLeaseless)
+}
+
+// TestRebalanceReadBytes asserts that the bytes read to service replica moves
+// add up to the size of the moved replicas, and are accounted separately from
+// the foreground reads of the workload.
+func TestRebalanceReadBytes(t *testing.T) {
+	ctx := context.Background()
+	settings := config.DefaultSimulationSettings()
+	const keyspace = 10000
+	s := state.NewStateWithReplCounts(
+		map[state.StoreID]int{1: 5, 2: 5, 3: 5, 4: 0}, 3 /* replicationFactor */, keyspace, settings)
+
+	var foregroundReadBytes int64
+	var ranges []state.Range
+	seen := make(map[state.RangeID]bool)
+	for key := int64(0); key < keyspace; key += keyspace / 20 {
+		s.ApplyLoad(workload.LoadBatch{
+			workload.LoadEvent{Key: key, Writes: 1, WriteSize: 100, Reads: 2, ReadSize: 30},
+		})
+		foregroundReadBytes += 30
+		if r := s.RangeFor(state.Key(key)); !seen[r.RangeID()] {
+			seen[r.RangeID()] = true
+			ranges = append(ranges, r)
+		}
+	}
+
+	// Move a replica of every range written to, other than the leaseholder's,
+	// onto the empty store.
+	var movedBytes int64
+	for _, r := range ranges {
+		lhStore, ok := s.LeaseholderStore(r.RangeID())
+		require.True(t, ok)
+		var from state.StoreID
+		for _, repl := range r.Replicas() {
+			if repl.StoreID() != lhStore.StoreID() {
+				from = repl.StoreID()
+				break
+			}
+		}
+		movedBytes += r.Size()
+		change := &state.ReplicaChange{
+			RangeID: r.RangeID(),
+			Author:  lhStore.StoreID(),
+			Changes: append(kvpb.MakeReplicationChanges(roachpb.ADD_VOTER, roachpb.ReplicationTarget{
+				NodeID:  4,
+				StoreID: 4,
+			}), kvpb.MakeReplicationChanges(roachpb.REMOVE_VOTER, roachpb.ReplicationTarget{
+				NodeID:  roachpb.NodeID(from),
+				StoreID: roachpb.StoreID(from),
+			})...),
+		}
+		change.Apply(s)
+	}
+	require.Greater(t, movedBytes, int64(0))
+
+	var buf bytes.Buffer
+	m := metrics.NewTracker(testingMetricsInterval, metrics.NewClusterMetricsTrackerWithOptions(
+		nil /* writers */, metrics.WithJSONLines(&buf), metrics.WithMetrics(metrics.RebalanceReadBytes)))
+	m.Tick(ctx, settings.StartTime, s)
+
+	var record struct {
+		ReadBytes          int64 `json:"c_read_b"`
+		ReplicaMoveBytes   int64 `json:"c_replica_b_moves"`
+		RebalanceReadBytes int64 `json:"c_rebalance_read_b"`
+	}
+	require.NoError(t, json.NewDecoder(&buf).Decode(&record))
+	require.Equal(t, movedBytes, record.RebalanceReadBytes)
+	require.Equal(t, record.ReplicaMoveBytes, record.RebalanceReadBytes)
+	require.Equal(t, foregroundReadBytes, record.ReadBytes)
+}
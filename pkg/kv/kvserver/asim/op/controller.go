@@ -42,6 +42,9 @@ type Controller interface {
 	// given. If the ticket exists, it returns the operation and true, else
 	// false.
 	Check(DispatchedTicket) (ControlledOperation, bool)
+	// PendingLeaseTransfers returns the number of lease transfer operations
+	// which have been dispatched but are not yet done.
+	PendingLeaseTransfers() int
 }
 
 type controller struct {
@@ -126,6 +129,18 @@ func (c *controller) Check(ticket DispatchedTicket) (op ControlledOperation, ok
 	return op, ok
 }
 
+// PendingLeaseTransfers returns the number of lease transfer operations which
+// have been dispatched but are not yet done.
+func (c *controller) PendingLeaseTransfers() int {
+	var pending int
+	for _, qop := range c.pending.items {
+		if _, ok := qop.ControlledOperation.(*TransferLeaseOp); ok {
+			pending++
+		}
+	}
+	return pending
+}
+
 func (c *controller) process(
 	ctx context.Context, tick time.Time, state state.State, co ControlledOperation,
 ) {
@@ -187,15 +202,19 @@ func (c *controller) processRelocateRange(
 		return nil
 	}
 
+	// Relocations are only issued by the store rebalancer, which moves
+	// replicas to balance the load across stores.
 	change := state.ReplicaChange{
 		RangeID: rng.RangeID(),
 		Author:  c.storeID,
 		Changes: ops,
+		Reason:  state.MoveReasonLoad,
 	}
 
 	targets := kvserver.SynthesizeTargetsByChangeType(ops)
 	if len(targets.VoterAdditions) > 0 || len(targets.NonVoterAdditions) > 0 {
 		change.Wait = c.settings.ReplicaChangeDelayFn()(rng.Size(), true /* use range size */)
+		change.SnapshotBytes = rng.Size()
 	}
 
 	completeAt, ok := c.changer.Push(tick, &change)
@@ -0,0 +1,94 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package queue
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/allocator/allocatorimpl"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+)
+
+// moveReason returns the reason the replicate queue moves a replica of the
+// range, given the allocator action which planned the move. The reason for
+// rebalancing actions isn't recorded by the allocator, so it is inferred by
+// comparing the store the replica is moved off with the store it is moved
+// to: a move off a store whose disk is too full to receive rebalances, onto
+// one which isn't, is attributed to capacity, a move which improves the
+// locality diversity of the range to diversity and any other move to
+// balancing the replica counts.
+func moveReason(
+	action allocatorimpl.AllocatorAction,
+	diskOptions allocatorimpl.DiskCapacityOptions,
+	rng state.Range,
+	chgs kvpb.ReplicationChanges,
+	s state.State,
+) state.MoveReason {
+	switch action {
+	case allocatorimpl.AllocatorReplaceDecommissioningVoter,
+		allocatorimpl.AllocatorReplaceDecommissioningNonVoter,
+		allocatorimpl.AllocatorRemoveDecommissioningVoter,
+		allocatorimpl.AllocatorRemoveDecommissioningNonVoter:
+		return state.MoveReasonDecommission
+	case allocatorimpl.AllocatorReplaceDeadVoter,
+		allocatorimpl.AllocatorReplaceDeadNonVoter,
+		allocatorimpl.AllocatorRemoveDeadVoter,
+		allocatorimpl.AllocatorRemoveDeadNonVoter:
+		return state.MoveReasonDead
+	case allocatorimpl.AllocatorConsiderRebalance:
+	default:
+		return state.MoveReasonOther
+	}
+
+	targets := kvserver.SynthesizeTargetsByChangeType(chgs)
+	var additions, removals []roachpb.ReplicationTarget
+	additions = append(additions, targets.VoterAdditions...)
+	additions = append(additions, targets.NonVoterAdditions...)
+	removals = append(removals, targets.VoterRemovals...)
+	removals = append(removals, targets.NonVoterRemovals...)
+	if len(additions) == 0 || len(removals) == 0 {
+		return state.MoveReasonOther
+	}
+	added, removed := state.StoreID(additions[0].StoreID), state.StoreID(removals[0].StoreID)
+	descs := s.StoreDescriptors(true /* cached */, added, removed)
+	if len(descs) != 2 {
+		return state.MoveReasonOther
+	}
+	addedDesc, removedDesc := descs[0], descs[1]
+
+	if removedDesc.Capacity.FractionUsed() >= diskOptions.RebalanceToThreshold &&
+		addedDesc.Capacity.FractionUsed() < diskOptions.RebalanceToThreshold {
+		return state.MoveReasonCapacity
+	}
+
+	// Compare the diversity the added store and the removed store each have
+	// with the replicas of the range which are kept.
+	var others []state.StoreID
+	for _, repl := range rng.Replicas() {
+		if storeID := repl.StoreID(); storeID != removed {
+			others = append(others, storeID)
+		}
+	}
+	var addedDiversity, removedDiversity float64
+	for _, desc := range s.StoreDescriptors(true /* cached */, others...) {
+		addedDiversity += diversityScore(addedDesc, desc)
+		removedDiversity += diversityScore(removedDesc, desc)
+	}
+	if addedDiversity > removedDiversity {
+		return state.MoveReasonDiversity
+	}
+	return state.MoveReasonCountBalance
+}
+
+func diversityScore(a, b roachpb.StoreDescriptor) float64 {
+	return a.Node.Locality.DiversityScore(b.Node.Locality)
+}
@@ -37,6 +37,9 @@ type RangeQueue interface {
 	// on the action taken. Replicas in the queue are processed in order of
 	// priority, then in FIFO order on ties.
 	Tick(ctx context.Context, tick time.Time, state state.State)
+	// Len returns the number of replicas enqueued, which are yet to be
+	// processed.
+	Len() int
 }
 
 // replicaItem represents an item in the replica queue.
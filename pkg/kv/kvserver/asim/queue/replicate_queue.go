@@ -28,9 +28,10 @@ import (
 
 type replicateQueue struct {
 	baseQueue
-	planner  plan.ReplicationPlanner
-	clock    *hlc.Clock
-	settings *config.SimulationSettings
+	planner   plan.ReplicationPlanner
+	allocator allocatorimpl.Allocator
+	clock     *hlc.Clock
+	settings  *config.SimulationSettings
 }
 
 // NewReplicateQueue returns a new replicate queue.
@@ -53,7 +54,8 @@ func NewReplicateQueue(
 		settings: settings,
 		planner: plan.NewReplicaPlanner(
 			allocator, storePool, plan.ReplicaPlannerTestingKnobs{}),
-		clock: storePool.Clock(),
+		allocator: allocator,
+		clock:     storePool.Clock(),
 	}
 	rq.AddLogTag("rq", nil)
 	return &rq
@@ -136,7 +138,7 @@ func (rq *replicateQueue) Tick(ctx context.Context, tick time.Time, s state.Stat
 
 		log.VEventf(ctx, 1, "conf=%+v", rng.SpanConfig())
 
-		rq.applyChange(ctx, change, rng, tick)
+		rq.applyChange(ctx, change, rng, tick, s)
 	}
 
 	rq.lastTick = tick
@@ -151,7 +153,11 @@ func (rq *replicateQueue) Tick(ctx context.Context, tick time.Time, s state.Stat
 // replicate queue and the store rebalancer and specifically for operations
 // rather than changes.
 func (rq *replicateQueue) applyChange(
-	ctx context.Context, change plan.ReplicateChange, rng state.Range, tick time.Time,
+	ctx context.Context,
+	change plan.ReplicateChange,
+	rng state.Range,
+	tick time.Time,
+	s state.State,
 ) {
 	var stateChange state.Change
 	switch op := change.Op.(type) {
@@ -174,6 +180,10 @@ func (rq *replicateQueue) applyChange(
 			Changes: op.Chgs,
 			Author:  rq.storeID,
 			Wait:    rq.settings.ReplicaChangeDelayFn()(rng.Size(), true),
+			Reason:  moveReason(change.Action, rq.allocator.DiskOptions(), rng, op.Chgs, s),
+			// NB: The snapshot is only sent when the change adds a replica, see
+			// ReplicaChange.Target.
+			SnapshotBytes: rng.Size(),
 		}
 	default:
 		panic(fmt.Sprintf("Unknown operation %+v, unable to apply replicate queue change", op))
@@ -0,0 +1,107 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package asim
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/metrics"
+	"github.com/cockroachdb/errors"
+)
+
+// VerifyReplay runs the simulation returned by newSim twice and returns an
+// error if the runs diverged, i.e. if the metrics recorded by the runs, or the
+// output of a metrics.ClusterMetricsTracker listening to them, are not
+// identical. newSim must return a new simulation, with its own state and
+// workload generators, which reports to the given tracker each time it is
+// called. Since the simulation is seeded, the runs should be identical, unless
+// nondeterminism such as map iteration order or the wall clock crept into the
+// simulation. The error reports the first tick and column at which the runs
+// diverged.
+func VerifyReplay(
+	ctx context.Context, interval time.Duration, newSim func(m *metrics.Tracker) *Simulator,
+) error {
+	var histories [2]History
+	var outputs [2]bytes.Buffer
+	for i := range histories {
+		m := metrics.NewTracker(interval, metrics.NewClusterMetricsTracker(&outputs[i]))
+		sim := newSim(m)
+		sim.RunSim(ctx)
+		histories[i] = sim.History()
+	}
+	if err := compareRecorded(histories[0].Recorded, histories[1].Recorded); err != nil {
+		return err
+	}
+	// NB: The recorded metrics are identical, so any difference in the output
+	// comes from the tracker itself, e.g. it iterated over a map.
+	if !bytes.Equal(outputs[0].Bytes(), outputs[1].Bytes()) {
+		a := bytes.Split(outputs[0].Bytes(), []byte("\n"))
+		b := bytes.Split(outputs[1].Bytes(), []byte("\n"))
+		for line := 0; line < len(a) && line < len(b); line++ {
+			if !bytes.Equal(a[line], b[line]) {
+				return errors.Newf("runs diverged at line %d of the cluster metrics output: %q != %q",
+					line+1, a[line], b[line])
+			}
+		}
+		return errors.Newf("runs diverged in the length of the cluster metrics output: %d != %d lines",
+			len(a), len(b))
+	}
+	return nil
+}
+
+// compareRecorded returns an error describing the first tick and column, i.e.
+// the metric of a store, which differ between the metrics recorded by two
+// runs, see VerifyReplay.
+func compareRecorded(a, b [][]metrics.StoreMetrics) error {
+	for tick := 0; tick < len(a) && tick < len(b); tick++ {
+		if reflect.DeepEqual(a[tick], b[tick]) {
+			continue
+		}
+		var at time.Time
+		if len(a[tick]) > 0 {
+			at = a[tick][0].Tick
+		}
+		if len(a[tick]) != len(b[tick]) {
+			return errors.Newf("runs diverged at tick %d (%s): %d != %d stores",
+				tick, at, len(a[tick]), len(b[tick]))
+		}
+		tsA := metrics.MakeTS(a[tick : tick+1])
+		tsB := metrics.MakeTS(b[tick : tick+1])
+		names := make([]string, 0, len(tsA))
+		for name := range tsA {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for store := range a[tick] {
+			for _, name := range names {
+				if va, vb := tsA[name][store][0], tsB[name][store][0]; va != vb {
+					return errors.Newf("runs diverged at tick %d (%s): store %d column %s: %v != %v",
+						tick, at, a[tick][store].StoreID, name, va, vb)
+				}
+			}
+		}
+		// NB: Not every field of the store metrics is a column of the series.
+		for store := range a[tick] {
+			if !reflect.DeepEqual(a[tick][store], b[tick][store]) {
+				return errors.Newf("runs diverged at tick %d (%s): store %d: %+v != %+v",
+					tick, at, a[tick][store].StoreID, a[tick][store], b[tick][store])
+			}
+		}
+	}
+	if len(a) != len(b) {
+		return errors.Newf("runs diverged in their number of ticks: %d != %d", len(a), len(b))
+	}
+	return nil
+}
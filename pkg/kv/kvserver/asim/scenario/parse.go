@@ -0,0 +1,430 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package scenario
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/gen"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+	"github.com/cockroachdb/errors"
+)
+
+// Load parses the scenario in the file at the given path.
+func Load(path string) (*Scenario, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse parses a scenario written in the scenario DSL. A scenario is a list of
+// directives, one per line, where each directive is a command followed by
+// key=value arguments. Blank lines and lines starting with # are ignored. The
+// following directives are supported.
+//
+//   - cluster [nodes=<int>] [stores_per_node=<int>] [config=<name>]
+//     The topology of the cluster. Either a number of nodes and stores per
+//     node, or one of the predefined configs: single_region,
+//     single_region_multi_store, multi_region or complex. The default is 3
+//     nodes with 1 store each.
+//
+//   - ranges [ranges=<int>] [repl_factor=<int>] [keyspace=<int>]
+//     [bytes=<int>] [placement_skew=<bool>]
+//     The initial ranges and their replica placement. The default is 1 range
+//     with a replication factor of 3 over a keyspace of 10000.
+//
+//   - repl_factor_override start_key=<int> end_key=<int> repl_factor=<int>
+//     The replication factor of the ranges in [start_key, end_key), in place
+//     of the replication factor of the ranges directive. Ranges are split at
+//     the keys when they aren't already range boundaries. Overrides apply in
+//     order, so a later override takes precedence where they intersect.
+//
+//   - phase [name=<string>] [start=<duration>] [end=<duration>]
+//     [rate=<float>] [rw_ratio=<float>] [skewed=<bool>] [min_block=<int>]
+//     [max_block=<int>] [min_key=<int>] [max_key=<int>]
+//     A workload which runs from start until end, relative to the start of the
+//     simulation. Phases may overlap. When end is omitted, the phase runs
+//     until the end of the simulation. Phases which run one after the other
+//     apply different workloads to the same evolving state. When any phase is
+//     named, the cluster metrics have a trailing phase column labelling each
+//     tick with the phases running at the time.
+//
+//   - event at=<duration> type=<type> ...
+//     An event injected at the given time. The supported types are
+//     node_failure node=<int>, decommission node=<int>, node_recovery
+//     node=<int> and disk_stall store=<int> duration=<duration>.
+//
+//   - setting [rebalance_mode=<int>] [rebalance_interval=<duration>]
+//     [rebalance_qps_threshold=<float>] [split_qps_threshold=<float>]
+//     [rebalance_range_threshold=<float>] [gossip_delay=<duration>]
+//     [metrics_interval=<duration>] [lease_transfer_stall_ticks=<int>]
+//     [stabilization_ticks=<int>] [replica_gc_delay=<duration>]
+//     [lease_reacquisition_ticks=<int>] [background_read_b=<int>]
+//     [background_write_b=<int>]
+//     Overrides of the default simulation settings.
+//
+//   - output metrics=<name>,...
+//     The metrics to output, see Outputs for the names.
+//
+//   - run [duration=<duration>] [seed=<int>]
+//     How long to run the simulation for and the seed to run it with. The
+//     default is to run for 10 minutes with a seed of 42.
+func Parse(r io.Reader) (*Scenario, error) {
+	sc := newScenario()
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		d, err := parseArgs(fields[0], fields[1:])
+		if err == nil {
+			err = sc.apply(d)
+		}
+		if err == nil {
+			err = d.checkUsed()
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", lineNum)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+// directive is a single parsed line of a scenario.
+type directive struct {
+	cmd  string
+	args map[string]string
+	used map[string]bool
+}
+
+func parseArgs(cmd string, fields []string) (*directive, error) {
+	d := &directive{
+		cmd:  cmd,
+		args: make(map[string]string, len(fields)),
+		used: make(map[string]bool, len(fields)),
+	}
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || key == "" {
+			return nil, errors.Newf("%s: expected key=value, found %q", cmd, field)
+		}
+		if _, ok := d.args[key]; ok {
+			return nil, errors.Newf("%s: duplicate argument %q", cmd, key)
+		}
+		d.args[key] = value
+	}
+	return d, nil
+}
+
+// checkUsed returns an error if the directive has an argument which wasn't
+// consumed, which is most likely a typo.
+func (d *directive) checkUsed() error {
+	for key := range d.args {
+		if !d.used[key] {
+			return errors.Newf("%s: unknown argument %q", d.cmd, key)
+		}
+	}
+	return nil
+}
+
+func (d *directive) lookup(key string) (string, bool) {
+	value, ok := d.args[key]
+	d.used[key] = true
+	return value, ok
+}
+
+func (d *directive) string(key string, dest *string) {
+	if value, ok := d.lookup(key); ok {
+		*dest = value
+	}
+}
+
+func (d *directive) int(key string, dest *int) error {
+	value, ok := d.lookup(key)
+	if !ok {
+		return nil
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return errors.Wrapf(err, "%s: %s", d.cmd, key)
+	}
+	*dest = v
+	return nil
+}
+
+func (d *directive) int64(key string, dest *int64) error {
+	value, ok := d.lookup(key)
+	if !ok {
+		return nil
+	}
+	v, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return errors.Wrapf(err, "%s: %s", d.cmd, key)
+	}
+	*dest = v
+	return nil
+}
+
+func (d *directive) float(key string, dest *float64) error {
+	value, ok := d.lookup(key)
+	if !ok {
+		return nil
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return errors.Wrapf(err, "%s: %s", d.cmd, key)
+	}
+	*dest = v
+	return nil
+}
+
+func (d *directive) bool(key string, dest *bool) error {
+	value, ok := d.lookup(key)
+	if !ok {
+		return nil
+	}
+	v, err := strconv.ParseBool(value)
+	if err != nil {
+		return errors.Wrapf(err, "%s: %s", d.cmd, key)
+	}
+	*dest = v
+	return nil
+}
+
+func (d *directive) duration(key string, dest *time.Duration) error {
+	value, ok := d.lookup(key)
+	if !ok {
+		return nil
+	}
+	v, err := time.ParseDuration(value)
+	if err != nil {
+		return errors.Wrapf(err, "%s: %s", d.cmd, key)
+	}
+	*dest = v
+	return nil
+}
+
+// required returns an error if the directive doesn't have the given argument.
+func (d *directive) required(keys ...string) error {
+	for _, key := range keys {
+		if _, ok := d.args[key]; !ok {
+			return errors.Newf("%s: missing required argument %q", d.cmd, key)
+		}
+	}
+	return nil
+}
+
+// firstErr returns the first non-nil error.
+func firstErr(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sc *Scenario) apply(d *directive) error {
+	switch d.cmd {
+	case "cluster":
+		var name string
+		nodes, storesPerNode := 3, 1
+		d.string("config", &name)
+		if err := firstErr(
+			d.int("nodes", &nodes),
+			d.int("stores_per_node", &storesPerNode),
+		); err != nil {
+			return err
+		}
+		if name == "" {
+			sc.Cluster = gen.BasicCluster{Nodes: nodes, StoresPerNode: storesPerNode}
+			return nil
+		}
+		info, ok := clusterConfigs[name]
+		if !ok {
+			return errors.Newf("cluster: unknown config %q", name)
+		}
+		sc.Cluster = gen.LoadedCluster{Info: info}
+	case "ranges":
+		ranges := gen.BasicRanges{
+			Ranges:            1,
+			ReplicationFactor: 3,
+			KeySpace:          defaultKeyspace,
+			PlacementType:     gen.Uniform,
+		}
+		var skew bool
+		if err := firstErr(
+			d.int("ranges", &ranges.Ranges),
+			d.int("repl_factor", &ranges.ReplicationFactor),
+			d.int("keyspace", &ranges.KeySpace),
+			d.int64("bytes", &ranges.Bytes),
+			d.bool("placement_skew", &skew),
+		); err != nil {
+			return err
+		}
+		if skew {
+			ranges.PlacementType = gen.Skewed
+		}
+		ranges.ReplicationFactorOverrides = sc.basicRanges().ReplicationFactorOverrides
+		sc.Ranges = ranges
+	case "repl_factor_override":
+		if err := d.required("start_key", "end_key", "repl_factor"); err != nil {
+			return err
+		}
+		var o gen.ReplicationFactorOverride
+		if err := firstErr(
+			d.int64("start_key", &o.StartKey),
+			d.int64("end_key", &o.EndKey),
+			d.int("repl_factor", &o.ReplicationFactor),
+		); err != nil {
+			return err
+		}
+		if o.EndKey <= o.StartKey {
+			return errors.Newf("repl_factor_override: end_key %d must be after start_key %d",
+				o.EndKey, o.StartKey)
+		}
+		if o.ReplicationFactor < 1 {
+			return errors.Newf("repl_factor_override: repl_factor must be positive, found %d",
+				o.ReplicationFactor)
+		}
+		ranges := sc.basicRanges()
+		ranges.ReplicationFactorOverrides = append(ranges.ReplicationFactorOverrides, o)
+		sc.Ranges = ranges
+	case "phase":
+		p := Phase{
+			Load: gen.BasicLoad{
+				MinBlockSize: 1,
+				MaxBlockSize: 1,
+				MinKey:       1,
+				MaxKey:       defaultKeyspace,
+			},
+		}
+		d.string("name", &p.Name)
+		if err := firstErr(
+			d.duration("start", &p.Start),
+			d.duration("end", &p.End),
+			d.float("rate", &p.Load.Rate),
+			d.float("rw_ratio", &p.Load.RWRatio),
+			d.bool("skewed", &p.Load.SkewedAccess),
+			d.int("min_block", &p.Load.MinBlockSize),
+			d.int("max_block", &p.Load.MaxBlockSize),
+			d.int64("min_key", &p.Load.MinKey),
+			d.int64("max_key", &p.Load.MaxKey),
+		); err != nil {
+			return err
+		}
+		if p.End != 0 && p.End <= p.Start {
+			return errors.Newf("phase: end %s must be after start %s", p.End, p.Start)
+		}
+		for _, other := range sc.Phases {
+			if p.Name != "" && other.Name == p.Name {
+				return errors.Newf("phase: duplicate name %q", p.Name)
+			}
+		}
+		sc.Phases = append(sc.Phases, p)
+	case "event":
+		if err := d.required("at", "type"); err != nil {
+			return err
+		}
+		var e Event
+		d.string("type", &e.Type)
+		if err := firstErr(
+			d.duration("at", &e.At),
+			d.int("node", &e.Node),
+			d.int("store", &e.Store),
+			d.duration("duration", &e.Duration),
+		); err != nil {
+			return err
+		}
+		switch e.Type {
+		case NodeFailureEvent, DecommissionEvent, NodeRecoveryEvent:
+			if err := d.required("node"); err != nil {
+				return err
+			}
+		case DiskStallEvent:
+			if err := d.required("store", "duration"); err != nil {
+				return err
+			}
+		default:
+			return errors.Newf("event: unknown type %q", e.Type)
+		}
+		sc.Events = append(sc.Events, e)
+	case "setting":
+		s := sc.Settings
+		if err := firstErr(
+			d.int64("rebalance_mode", &s.LBRebalancingMode),
+			d.duration("rebalance_interval", &s.LBRebalancingInterval),
+			d.float("rebalance_qps_threshold", &s.LBRebalanceQPSThreshold),
+			d.float("split_qps_threshold", &s.SplitQPSThreshold),
+			d.float("rebalance_range_threshold", &s.RangeRebalanceThreshold),
+			d.duration("gossip_delay", &s.StateExchangeDelay),
+			d.duration("metrics_interval", &s.MetricsInterval),
+			d.int("lease_transfer_stall_ticks", &s.LeaseTransferStallTicks),
+			d.int("stabilization_ticks", &s.StabilizationTicks),
+			d.duration("replica_gc_delay", &s.ReplicaGCDelay),
+			d.int("lease_reacquisition_ticks", &s.LeaseReacquisitionTicks),
+			d.int64("background_read_b", &s.BackgroundReadBytesPerSecond),
+			d.int64("background_write_b", &s.BackgroundWriteBytesPerSecond),
+		); err != nil {
+			return err
+		}
+	case "output":
+		if err := d.required("metrics"); err != nil {
+			return err
+		}
+		var names string
+		d.string("metrics", &names)
+		for _, name := range strings.Split(names, ",") {
+			if _, ok := Outputs[name]; !ok {
+				return errors.Newf("output: unknown metrics %q", name)
+			}
+			sc.Outputs = append(sc.Outputs, name)
+		}
+	case "run":
+		if err := firstErr(
+			d.duration("duration", &sc.Duration),
+			d.int64("seed", &sc.Seed),
+		); err != nil {
+			return err
+		}
+	default:
+		return errors.Newf("unknown directive %q", d.cmd)
+	}
+	return nil
+}
+
+// basicRanges returns the ranges of the scenario. The scenario's ranges are
+// always declared by the ranges directive, so are basic ranges.
+func (sc *Scenario) basicRanges() gen.BasicRanges {
+	return sc.Ranges.(gen.BasicRanges)
+}
+
+// clusterConfigs are the predefined cluster topologies which may be loaded by
+// name.
+var clusterConfigs = map[string]state.ClusterInfo{
+	"single_region":             state.SingleRegionConfig,
+	"single_region_multi_store": state.SingleRegionMultiStoreConfig,
+	"multi_region":              state.MultiRegionConfig,
+	"complex":                   state.ComplexConfig,
+}
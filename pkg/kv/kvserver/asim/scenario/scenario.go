@@ -0,0 +1,333 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// Package scenario runs allocator simulations which are declared in a small
+// text based DSL, rather than in Go, see Parse for the syntax.
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/config"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/event"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/gen"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/metrics"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/workload"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/liveness/livenesspb"
+	"github.com/cockroachdb/errors"
+)
+
+const (
+	defaultKeyspace = 10000
+	defaultDuration = 10 * time.Minute
+	defaultSeed     = 42
+)
+
+// The types of events which may be injected into a scenario.
+const (
+	// NodeFailureEvent marks the node as dead.
+	NodeFailureEvent = "node_failure"
+	// NodeRecoveryEvent marks the node as live again.
+	NodeRecoveryEvent = "node_recovery"
+	// DecommissionEvent marks the node as decommissioning.
+	DecommissionEvent = "decommission"
+	// DiskStallEvent stalls the writes of the store for a duration.
+	DiskStallEvent = "disk_stall"
+)
+
+// Outputs are the metrics which a scenario may output, keyed by the name used
+// to refer to them in the DSL. Each output is written as CSV to its own
+// writer. The cluster output has every optional cluster metric, since the
+// events of a scenario, e.g. node failures, are what they measure. It is
+// labelled with the workload phase of each tick when the scenario names its
+// phases, see Scenario.phaseAt.
+var Outputs = map[string]func(io.Writer, *metrics.Tracker, *Scenario){
+	"cluster": func(w io.Writer, t *metrics.Tracker, sc *Scenario) {
+		opts := []metrics.ClusterMetricsTrackerOption{metrics.WithMetrics(metrics.AllClusterMetrics()...)}
+		if sc.namedPhases() {
+			opts = append(opts, metrics.WithPhaseLabel(sc.phaseAt))
+		}
+		t.Register(metrics.NewClusterMetricsTrackerWithOptions([]io.Writer{w}, opts...))
+	},
+	"latency": func(w io.Writer, t *metrics.Tracker, _ *Scenario) {
+		t.RegisterStateListener(metrics.NewLatencyTracker(w))
+	},
+	"read_locality": func(w io.Writer, t *metrics.Tracker, _ *Scenario) {
+		t.RegisterStateListener(metrics.NewReadLocalityTracker(w))
+	},
+	"region_usage": func(w io.Writer, t *metrics.Tracker, _ *Scenario) {
+		t.RegisterStateListener(metrics.NewRegionUsageTracker(w))
+	},
+	"admission": func(w io.Writer, t *metrics.Tracker, _ *Scenario) {
+		t.RegisterStateListener(metrics.NewAdmissionTracker(w))
+	},
+	"rebalance_efficiency": func(w io.Writer, t *metrics.Tracker, _ *Scenario) {
+		t.Register(metrics.NewRebalanceEfficiencyTracker(w))
+	},
+	"storage_class": func(w io.Writer, t *metrics.Tracker, _ *Scenario) {
+		t.RegisterStateListener(metrics.NewStorageClassTracker(w))
+	},
+	"move_reasons": func(w io.Writer, t *metrics.Tracker, _ *Scenario) {
+		t.RegisterStateListener(metrics.NewMoveReasonTracker(w))
+	},
+	"pending_moves": func(w io.Writer, t *metrics.Tracker, _ *Scenario) {
+		t.RegisterStateListener(metrics.NewPendingMovesTracker(w))
+	},
+	"placement_entropy": func(w io.Writer, t *metrics.Tracker, _ *Scenario) {
+		t.Register(metrics.NewPlacementEntropyTracker(metrics.DefaultEntropyConvergenceThreshold, w))
+	},
+	"recovery": func(w io.Writer, t *metrics.Tracker, sc *Scenario) {
+		t.RegisterStateListener(metrics.NewRecoveryTracker(sc.Settings.TickInterval, w))
+	},
+}
+
+// Scenario is a simulation declared in the scenario DSL.
+type Scenario struct {
+	Cluster  gen.ClusterGen
+	Ranges   gen.RangeGen
+	Phases   []Phase
+	Events   []Event
+	Settings *config.SimulationSettings
+	// Outputs are the names of the metrics to output, see Outputs.
+	Outputs  []string
+	Duration time.Duration
+	Seed     int64
+}
+
+// Phase is a workload which runs for part of a scenario.
+type Phase struct {
+	// Name labels the phase in the cluster metrics. It is optional, unless
+	// another phase of the scenario is named, see Scenario.phaseAt.
+	Name string
+	// Start and End are the offsets from the start of the simulation during
+	// which the phase's load is generated. A zero End runs the phase until the
+	// end of the simulation.
+	Start, End time.Duration
+	Load       gen.BasicLoad
+}
+
+// Event is an event injected into a scenario.
+type Event struct {
+	// At is the offset from the start of the simulation when the event fires.
+	At   time.Duration
+	Type string
+	// Node is the node targeted by the node_failure, node_recovery and
+	// decommission events.
+	Node int
+	// Store and Duration are the store and how long its writes are stalled
+	// for, in a disk_stall event.
+	Store    int
+	Duration time.Duration
+}
+
+// String returns a description of the event.
+func (e Event) String() string {
+	if e.Type == DiskStallEvent {
+		return fmt.Sprintf("%s store=%d duration=%s", e.Type, e.Store, e.Duration)
+	}
+	return fmt.Sprintf("%s node=%d", e.Type, e.Node)
+}
+
+func newScenario() *Scenario {
+	return &Scenario{
+		Cluster: gen.BasicCluster{Nodes: 3, StoresPerNode: 1},
+		Ranges: gen.BasicRanges{
+			Ranges:            1,
+			ReplicationFactor: 3,
+			KeySpace:          defaultKeyspace,
+			PlacementType:     gen.Uniform,
+		},
+		Settings: config.DefaultSimulationSettings(),
+		Duration: defaultDuration,
+		Seed:     defaultSeed,
+	}
+}
+
+// FiredEvent is an event which fired while running a scenario.
+type FiredEvent struct {
+	Tick  time.Time
+	Event Event
+}
+
+// Report summarizes a scenario run.
+type Report struct {
+	// Events are the injected events which fired, in the order they fired.
+	Events []FiredEvent
+	// History is the recorded store metrics of the run.
+	History asim.History
+}
+
+// Run runs the scenario to completion. Each of the scenario's outputs is
+// written to the writer returned by writerFor, given the output name.
+func (sc *Scenario) Run(
+	ctx context.Context, writerFor func(output string) (io.Writer, error),
+) (Report, error) {
+	settings := *sc.Settings
+	settings.Seed = sc.Seed
+	s := sc.Cluster.Generate(sc.Seed, &settings)
+	s = sc.Ranges.Generate(sc.Seed, &settings, s)
+
+	tracker := metrics.NewTracker(settings.MetricsInterval)
+	for _, name := range sc.Outputs {
+		w, err := writerFor(name)
+		if err != nil {
+			return Report{}, errors.Wrapf(err, "opening output %s", name)
+		}
+		Outputs[name](w, tracker, sc)
+	}
+
+	var report Report
+	events := make(event.DelayedEventList, 0, len(sc.Events))
+	for _, e := range sc.Events {
+		events = append(events, sc.delayedEvents(&settings, e, &report)...)
+	}
+	sort.Stable(events)
+
+	sim := asim.NewSimulator(
+		sc.Duration, sc.workload(&settings), s, &settings, tracker, events...)
+	sim.RunSim(ctx)
+	report.History = sim.History()
+	return report, nil
+}
+
+// workload returns the workload generators of every phase of the scenario.
+// Each phase is seeded differently, so that phases with the same parameters
+// don't generate identical load.
+func (sc *Scenario) workload(settings *config.SimulationSettings) []workload.Generator {
+	seedGen := rand.New(rand.NewSource(sc.Seed))
+	var generators []workload.Generator
+	for _, p := range sc.Phases {
+		seed := seedGen.Int63()
+		if p.Load.Rate == 0 {
+			continue
+		}
+		start := settings.StartTime.Add(p.Start)
+		var end time.Time
+		if p.End != 0 {
+			end = settings.StartTime.Add(p.End)
+		}
+		var keyGen workload.KeyGenerator
+		keyRand := rand.New(rand.NewSource(seed))
+		if p.Load.SkewedAccess {
+			keyGen = workload.NewZipfianKeyGen(p.Load.MinKey, p.Load.MaxKey, 1.1, 1, keyRand)
+		} else {
+			keyGen = workload.NewUniformKeyGen(p.Load.MinKey, p.Load.MaxKey, keyRand)
+		}
+		generators = append(generators, &phaseGenerator{
+			Generator: workload.NewRandomGenerator(
+				start, seed, keyGen, p.Load.Rate, p.Load.RWRatio,
+				p.Load.MaxBlockSize, p.Load.MinBlockSize,
+			),
+			end: end,
+		})
+	}
+	return generators
+}
+
+// namedPhases returns true if any phase of the scenario is named, in which
+// case the cluster metrics are labelled by phase.
+func (sc *Scenario) namedPhases() bool {
+	for _, p := range sc.Phases {
+		if p.Name != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// phaseAt returns the label of the workload phases which are running at the
+// given tick, i.e. which started at or before the tick and haven't yet ended.
+// When phases overlap, their labels are joined with a "+", while an unnamed
+// phase is labelled by its position in the scenario, e.g. phase2. The label
+// is empty if no phase is running.
+func (sc *Scenario) phaseAt(tick time.Time) string {
+	offset := tick.Sub(sc.Settings.StartTime)
+	var labels []string
+	for i, p := range sc.Phases {
+		if offset < p.Start || (p.End != 0 && offset >= p.End) {
+			continue
+		}
+		label := p.Name
+		if label == "" {
+			label = fmt.Sprintf("phase%d", i+1)
+		}
+		labels = append(labels, label)
+	}
+	return strings.Join(labels, "+")
+}
+
+// delayedEvents returns the delayed events which inject the event into the
+// simulation, recording the event in the report when it fires.
+func (sc *Scenario) delayedEvents(
+	settings *config.SimulationSettings, e Event, report *Report,
+) event.DelayedEventList {
+	at := settings.StartTime.Add(e.At)
+	var fn func(context.Context, time.Time, state.State)
+	switch e.Type {
+	case NodeFailureEvent:
+		fn = setLiveness(e.Node, livenesspb.NodeLivenessStatus_DEAD)
+	case NodeRecoveryEvent:
+		fn = setLiveness(e.Node, livenesspb.NodeLivenessStatus_LIVE)
+	case DecommissionEvent:
+		fn = setLiveness(e.Node, livenesspb.NodeLivenessStatus_DECOMMISSIONING)
+	case DiskStallEvent:
+		events := event.NewDiskWriteStallEvents(state.StoreID(e.Store), at, e.Duration)
+		stall := events[0].EventFn
+		events[0].EventFn = func(ctx context.Context, tick time.Time, s state.State) {
+			report.Events = append(report.Events, FiredEvent{Tick: tick, Event: e})
+			stall(ctx, tick, s)
+		}
+		return events
+	default:
+		panic(fmt.Sprintf("unknown event type %s", e.Type))
+	}
+	return event.DelayedEventList{{
+		At: at,
+		EventFn: func(ctx context.Context, tick time.Time, s state.State) {
+			report.Events = append(report.Events, FiredEvent{Tick: tick, Event: e})
+			fn(ctx, tick, s)
+		},
+	}}
+}
+
+func setLiveness(
+	nodeID int, status livenesspb.NodeLivenessStatus,
+) func(context.Context, time.Time, state.State) {
+	return func(ctx context.Context, tick time.Time, s state.State) {
+		s.SetNodeLiveness(state.NodeID(nodeID), status)
+	}
+}
+
+// phaseGenerator wraps a workload generator, which starts generating load at
+// the start of its phase, so that it stops generating load at the end of its
+// phase.
+type phaseGenerator struct {
+	workload.Generator
+	// end is when the phase ends, or zero if it runs until the end of the
+	// simulation.
+	end time.Time
+}
+
+// Tick returns the load events up till time tick, from the last time the
+// workload generator was called, excluding any load after the phase ends.
+func (pg *phaseGenerator) Tick(tick time.Time) workload.LoadBatch {
+	if !pg.end.IsZero() && tick.After(pg.end) {
+		tick = pg.end
+	}
+	return pg.Generator.Tick(tick)
+}
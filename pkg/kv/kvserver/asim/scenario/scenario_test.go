@@ -0,0 +1,191 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package scenario
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/state"
+	"github.com/cockroachdb/cockroach/pkg/testutils/datapathutils"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRunScenario parses and runs a sample scenario, asserting that each
+// output has a row per tick and that the injected node failure fired.
+func TestRunScenario(t *testing.T) {
+	sc, err := Load(datapathutils.TestDataPath(t, "node_failure.scenario"))
+	require.NoError(t, err)
+	require.Len(t, sc.Phases, 2)
+	require.Len(t, sc.Events, 1)
+	require.Equal(t, []string{"cluster", "latency"}, sc.Outputs)
+	require.Equal(t, 2*time.Minute, sc.Duration)
+
+	outputs := make(map[string]*strings.Builder)
+	report, err := sc.Run(context.Background(), func(name string) (io.Writer, error) {
+		outputs[name] = &strings.Builder{}
+		return outputs[name], nil
+	})
+	require.NoError(t, err)
+
+	// Metrics are reported every tick, in addition to the header row.
+	expectedRows := int(sc.Duration/sc.Settings.TickInterval) + 1
+	for _, name := range sc.Outputs {
+		rows := strings.Split(strings.TrimSpace(outputs[name].String()), "\n")
+		require.Len(t, rows, expectedRows, name)
+	}
+	require.Len(t, report.History.Recorded, expectedRows-1)
+
+	require.Len(t, report.Events, 1)
+	fired := report.Events[0]
+	require.Equal(t, NodeFailureEvent, fired.Event.Type)
+	require.Equal(t, 5, fired.Event.Node)
+	require.False(t, fired.Tick.Before(sc.Settings.StartTime.Add(90*time.Second)))
+}
+
+// TestWorkloadShift runs a scenario which shifts from a read heavy to a write
+// heavy workload on a different part of the keyspace, asserting that the
+// cluster metrics are labelled by phase and that the allocator transfers
+// leases to follow the load after the phase boundary.
+func TestWorkloadShift(t *testing.T) {
+	sc, err := Load(datapathutils.TestDataPath(t, "workload_shift.scenario"))
+	require.NoError(t, err)
+	require.Len(t, sc.Phases, 2)
+
+	var out strings.Builder
+	_, err = sc.Run(context.Background(), func(name string) (io.Writer, error) {
+		return &out, nil
+	})
+	require.NoError(t, err)
+	rows, err := csv.NewReader(strings.NewReader(out.String())).ReadAll()
+	require.NoError(t, err)
+
+	header := rows[0]
+	require.Equal(t, "phase", header[len(header)-1])
+	leaseMovesIdx := -1
+	for i, col := range header {
+		if col == "c_lease_moves" {
+			leaseMovesIdx = i
+		}
+	}
+	require.NotEqual(t, -1, leaseMovesIdx)
+
+	// The ticks are labelled by the phase running at the time, with the read
+	// heavy phase ending where the write heavy phase starts.
+	boundary := sc.Settings.StartTime.Add(3 * time.Minute).String()
+	leaseMoves := make(map[string]int)
+	var phases []string
+	for _, row := range rows[1:] {
+		phase := row[len(row)-1]
+		if len(phases) == 0 || phases[len(phases)-1] != phase {
+			phases = append(phases, phase)
+			if phase == "write_heavy" {
+				require.Equal(t, boundary, row[0])
+			}
+		}
+		// The lease moves are cumulative, so the last row of a phase has the
+		// lease moves up to the end of the phase.
+		leaseMoves[phase], err = strconv.Atoi(row[leaseMovesIdx])
+		require.NoError(t, err)
+	}
+	require.Equal(t, []string{"read_heavy", "write_heavy"}, phases)
+	require.Greater(t, leaseMoves["read_heavy"], 0)
+	require.Greater(t, leaseMoves["write_heavy"], leaseMoves["read_heavy"])
+}
+
+// TestMixedReplicationFactors runs a scenario where part of the keyspace has a
+// replication factor override, asserting that the ranges in each part of the
+// keyspace converge to their own replication factor.
+func TestMixedReplicationFactors(t *testing.T) {
+	sc, err := Load(datapathutils.TestDataPath(t, "mixed_replication.scenario"))
+	require.NoError(t, err)
+
+	report, err := sc.Run(context.Background(), func(name string) (io.Writer, error) {
+		return io.Discard, nil
+	})
+	require.NoError(t, err)
+
+	s := report.History.S
+	require.Equal(t, 3, len(s.RangeFor(state.Key(0)).Replicas()))
+	require.Equal(t, 5, len(s.RangeFor(state.Key(5000)).Replicas()))
+	require.Equal(t, 3, len(s.RangeFor(state.Key(10000)).Replicas()))
+	for _, rng := range s.Ranges() {
+		target, ok := s.RangeReplicationTarget(rng.RangeID())
+		require.True(t, ok)
+		require.Len(t, rng.Replicas(), target, "r%d", rng.RangeID())
+	}
+
+	// Once converged, no range is over or under replicated.
+	last := report.History.Recorded[len(report.History.Recorded)-1]
+	for _, sm := range last {
+		require.Zero(t, sm.OverReplicatedRanges, "s%d", sm.StoreID)
+		require.Zero(t, sm.UnderReplicatedRanges, "s%d", sm.StoreID)
+	}
+}
+
+// TestParseErrors asserts that malformed scenarios are rejected, along with
+// the line at fault.
+func TestParseErrors(t *testing.T) {
+	for _, tc := range []struct {
+		input, err string
+	}{
+		{"foo", `line 1: unknown directive "foo"`},
+		{"cluster nodes", `line 1: cluster: expected key=value, found "nodes"`},
+		{"\ncluster nodes=x", `line 2: cluster: nodes`},
+		{"ranges replicas=3", `line 1: ranges: unknown argument "replicas"`},
+		{"event at=1m type=node_failure", `line 1: event: missing required argument "node"`},
+		{"event at=1m type=meteor", `line 1: event: unknown type "meteor"`},
+		{"repl_factor_override start_key=1 repl_factor=5", `line 1: repl_factor_override: missing required argument "end_key"`},
+		{"repl_factor_override start_key=5 end_key=1 repl_factor=5", `line 1: repl_factor_override: end_key 1 must be after start_key 5`},
+		{"phase start=2m end=1m", `line 1: phase: end 1m0s must be after start 2m0s`},
+		{"phase name=a\nphase name=a", `line 2: phase: duplicate name "a"`},
+		{"output metrics=cluster,qps", `line 1: output: unknown metrics "qps"`},
+	} {
+		t.Run(tc.input, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tc.input))
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.err)
+		})
+	}
+}
+
+// TestMoveReasons runs a scenario with a skewed workload and a decommission,
+// asserting that the replica moves are attributed to both the load and the
+// decommission and that the histogram is written when the run finishes.
+func TestMoveReasons(t *testing.T) {
+	sc, err := Load(datapathutils.TestDataPath(t, "decommission_load.scenario"))
+	require.NoError(t, err)
+
+	var out strings.Builder
+	report, err := sc.Run(context.Background(), func(name string) (io.Writer, error) {
+		return &out, nil
+	})
+	require.NoError(t, err)
+
+	moves := report.History.S.ClusterUsageInfo().MoveReasons
+	require.Greater(t, moves[state.MoveReasonDecommission], int64(0))
+	require.Greater(t, moves[state.MoveReasonLoad], int64(0))
+
+	rows, err := csv.NewReader(strings.NewReader(out.String())).ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, []string{"reason", "c_replica_moves"}, rows[0])
+	require.Len(t, rows, len(state.MoveReasons)+1)
+	for i, reason := range state.MoveReasons {
+		require.Equal(t, []string{
+			reason.String(), strconv.FormatInt(moves[reason], 10),
+		}, rows[i+1])
+	}
+}
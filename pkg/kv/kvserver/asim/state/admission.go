@@ -0,0 +1,140 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package state
+
+import (
+	"sort"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/workload"
+	"github.com/cockroachdb/cockroach/pkg/util/admission/admissionpb"
+)
+
+// storeAdmission is a simplified model of store admission control. It is a
+// token bucket of write bytes, which refills at a constant rate up to a burst
+// of one second worth of tokens.
+type storeAdmission struct {
+	tokens     float64
+	lastRefill time.Time
+}
+
+// refill adds the tokens accumulated since the last refill, up to the burst
+// limit.
+func (sa *storeAdmission) refill(now time.Time, limit int64) {
+	elapsed := now.Sub(sa.lastRefill).Seconds()
+	if elapsed > 0 {
+		sa.tokens += elapsed * float64(limit)
+		sa.lastRefill = now
+	}
+	if sa.tokens > float64(limit) {
+		sa.tokens = float64(limit)
+	}
+}
+
+func (s *state) storeAdmission(storeID StoreID, now time.Time, limit int64) *storeAdmission {
+	sa, ok := s.admission[storeID]
+	if !ok {
+		sa = &storeAdmission{tokens: float64(limit), lastRefill: now}
+		s.admission[storeID] = sa
+	}
+	sa.refill(now, limit)
+	return sa
+}
+
+// admitUnit is a set of load events which are admitted or rejected together:
+// either a single non-transactional load event, or every load event of a
+// transaction.
+type admitUnit struct {
+	events   workload.LoadBatch
+	priority admissionpb.WorkPriority
+}
+
+// admitUnits groups the load events in the load batch into the units in which
+// they are admitted. The priority of a transaction is the highest priority of
+// its load events. The units are returned in the order of their first load
+// event in the load batch.
+func admitUnits(lb workload.LoadBatch) []*admitUnit {
+	units := make([]*admitUnit, 0, len(lb))
+	txns := make(map[int64]*admitUnit)
+	for _, le := range lb {
+		if le.TxnID == 0 {
+			units = append(units, &admitUnit{events: workload.LoadBatch{le}, priority: le.Priority})
+			continue
+		}
+		unit, ok := txns[le.TxnID]
+		if !ok {
+			unit = &admitUnit{priority: le.Priority}
+			txns[le.TxnID] = unit
+			units = append(units, unit)
+		}
+		unit.events = append(unit.events, le)
+		if le.Priority > unit.priority {
+			unit.priority = le.Priority
+		}
+	}
+	return units
+}
+
+// admit returns the load events in the load batch which are admitted by the
+// leaseholder store of the range they target. Events are considered in
+// descending priority order, so that when a store is overloaded, lower
+// priority load is shed first. The load events of a transaction are admitted
+// or rejected together, a transaction is only admitted if every store it
+// writes to can admit all of its writes. The admitted and rejected bytes for
+// each priority are recorded in the cluster usage info. The returned load
+// batch is sorted by key.
+func (s *state) admit(lb workload.LoadBatch, limit int64) workload.LoadBatch {
+	now := s.clock.Now()
+	units := admitUnits(lb)
+	sort.SliceStable(units, func(i, j int) bool {
+		return units[i].priority > units[j].priority
+	})
+
+	admitted := make(workload.LoadBatch, 0, len(lb))
+	for _, unit := range units {
+		// Determine the tokens the unit requires from each store. Reads aren't
+		// subject to write admission. When there is no leaseholder, there is
+		// also no store to admit the write.
+		required := make(map[StoreID]int64)
+		for _, le := range unit.events {
+			if le.WriteSize == 0 {
+				continue
+			}
+			rng := s.rangeFor(Key(le.Key))
+			if store, ok := s.LeaseholderStore(rng.rangeID); ok {
+				required[store.StoreID()] += le.WriteSize
+			}
+		}
+		admit := true
+		for storeID, bytes := range required {
+			if s.storeAdmission(storeID, now, limit).tokens < float64(bytes) {
+				admit = false
+				break
+			}
+		}
+		if admit {
+			for storeID, bytes := range required {
+				s.storeAdmission(storeID, now, limit).tokens -= float64(bytes)
+			}
+		}
+		for _, le := range unit.events {
+			usage := s.usageInfo.admissionRef(le.Priority)
+			if admit {
+				usage.AdmittedBytes += le.WriteSize
+				admitted = append(admitted, le)
+			} else {
+				usage.RejectedBytes += le.WriteSize
+			}
+		}
+	}
+	sort.Sort(admitted)
+	return admitted
+}
@@ -0,0 +1,144 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/config"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/workload"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/admission/admissionpb"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAdmissionShedsLowPriority asserts that when a store is overloaded with
+// mixed priority load, high priority load continues to be admitted whilst low
+// priority load is shed.
+func TestAdmissionShedsLowPriority(t *testing.T) {
+	settings := config.DefaultSimulationSettings()
+	settings.AdmissionWriteBytesPerSecond = 1000
+	s := NewState(settings)
+	start := settings.StartTime
+
+	n1 := s.AddNode()
+	s1, _ := s.AddStore(n1.NodeID())
+	_, r1, _ := s.SplitRange(100)
+	s.AddReplica(r1.RangeID(), s1.StoreID(), roachpb.VOTER_FULL)
+
+	// Each second, the store receives 1200 bytes of writes, whilst only
+	// being able to admit 1000 bytes. The low priority writes are issued on a
+	// lower key, so that they would be applied first if load were admitted in
+	// key order.
+	const ticks = 10
+	for i := 0; i < ticks; i++ {
+		s.TickClock(OffsetTick(start, int64(i)))
+		s.ApplyLoad(workload.LoadBatch{
+			{Key: 100, Writes: 1, WriteSize: 600, Priority: admissionpb.LowPri},
+			{Key: 200, Writes: 1, WriteSize: 600, Priority: admissionpb.HighPri},
+		})
+	}
+
+	usage := s.ClusterUsageInfo()
+	require.Equal(t, &AdmissionUsageInfo{AdmittedBytes: ticks * 600},
+		usage.AdmissionUsage[admissionpb.HighPri])
+	require.Equal(t, &AdmissionUsageInfo{RejectedBytes: ticks * 600},
+		usage.AdmissionUsage[admissionpb.LowPri])
+	// Only the admitted writes should be reflected in the store's usage.
+	require.Equal(t, int64(ticks*600), usage.StoreUsage[s1.StoreID()].WriteBytes)
+}
+
+// TestBackgroundLoadReducesAdmittedLoad asserts that background writes consume
+// a store's write admission tokens, so that the foreground load admitted by
+// the store is reduced by the background load.
+func TestBackgroundLoadReducesAdmittedLoad(t *testing.T) {
+	const (
+		ticks          = 10
+		limit          = 1000
+		backgroundRead = 300
+		background     = 400
+	)
+	// Each second, the store receives as many bytes of foreground writes as it
+	// is able to admit.
+	run := func(backgroundWrite int64) (*ClusterUsageInfo, StoreID) {
+		settings := config.DefaultSimulationSettings()
+		settings.TickInterval = time.Second
+		settings.AdmissionWriteBytesPerSecond = limit
+		settings.BackgroundReadBytesPerSecond = backgroundRead
+		settings.BackgroundWriteBytesPerSecond = backgroundWrite
+		s := NewState(settings)
+		start := settings.StartTime
+
+		n1 := s.AddNode()
+		s1, _ := s.AddStore(n1.NodeID())
+		_, r1, _ := s.SplitRange(100)
+		s.AddReplica(r1.RangeID(), s1.StoreID(), roachpb.VOTER_FULL)
+
+		for i := 0; i < ticks; i++ {
+			s.TickClock(OffsetTick(start, int64(i)))
+			s.ApplyBackgroundLoad()
+			lb := make(workload.LoadBatch, 0, 10)
+			for k := int64(0); k < 10; k++ {
+				lb = append(lb, workload.LoadEvent{Key: 100 + k, Writes: 1, WriteSize: limit / 10})
+			}
+			s.ApplyLoad(lb)
+		}
+		return s.ClusterUsageInfo(), s1.StoreID()
+	}
+
+	// Without background writes, all of the foreground writes are admitted.
+	usage, storeID := run(0)
+	require.Equal(t, &AdmissionUsageInfo{AdmittedBytes: ticks * limit},
+		usage.AdmissionUsage[admissionpb.NormalPri])
+	require.Equal(t, int64(ticks*backgroundRead), usage.StoreUsage[storeID].BackgroundReadBytes)
+	require.Zero(t, usage.StoreUsage[storeID].BackgroundWriteBytes)
+
+	// With background writes, only the remaining capacity of the store is
+	// available to the foreground writes.
+	usage, storeID = run(background)
+	require.Equal(t, &AdmissionUsageInfo{
+		AdmittedBytes: ticks * (limit - background),
+		RejectedBytes: ticks * background,
+	}, usage.AdmissionUsage[admissionpb.NormalPri])
+	require.Equal(t, int64(ticks*(limit-background)), usage.StoreUsage[storeID].WriteBytes)
+	require.Equal(t, int64(ticks*background), usage.StoreUsage[storeID].BackgroundWriteBytes)
+	require.Equal(t, int64(ticks*backgroundRead), usage.StoreUsage[storeID].BackgroundReadBytes)
+}
+
+// TestAdmissionRejectsWholeTxn asserts that a transaction whose writes can't
+// all be admitted is rejected as a whole, rather than being partially admitted.
+func TestAdmissionRejectsWholeTxn(t *testing.T) {
+	settings := config.DefaultSimulationSettings()
+	settings.AdmissionWriteBytesPerSecond = 1000
+	s := NewState(settings)
+
+	n1 := s.AddNode()
+	s1, _ := s.AddStore(n1.NodeID())
+	_, r1, _ := s.SplitRange(100)
+	s.AddReplica(r1.RangeID(), s1.StoreID(), roachpb.VOTER_FULL)
+
+	// The transaction writes 1200 bytes, whilst the store is only able to admit
+	// 1000 bytes. Either of its writes could be admitted on its own, as could
+	// the non-transactional write.
+	s.TickClock(settings.StartTime)
+	s.ApplyLoad(workload.LoadBatch{
+		{Key: 100, Writes: 1, WriteSize: 600, TxnID: 1},
+		{Key: 200, Writes: 1, WriteSize: 600, TxnID: 1},
+		{Key: 300, Writes: 1, WriteSize: 300},
+	})
+
+	usage := s.ClusterUsageInfo()
+	require.Equal(t, &AdmissionUsageInfo{AdmittedBytes: 300, RejectedBytes: 1200},
+		usage.AdmissionUsage[admissionpb.NormalPri])
+	require.Equal(t, int64(300), usage.StoreUsage[s1.StoreID()].WriteBytes)
+	require.Zero(t, usage.TxnUsage.Committed)
+}
@@ -0,0 +1,38 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package state
+
+// ApplyBackgroundLoad implements the State interface.
+func (s *state) ApplyBackgroundLoad() {
+	interval := s.settings.TickInterval.Seconds()
+	readBytes := int64(float64(s.settings.BackgroundReadBytesPerSecond) * interval)
+	writeBytes := int64(float64(s.settings.BackgroundWriteBytesPerSecond) * interval)
+	if readBytes <= 0 && writeBytes <= 0 {
+		return
+	}
+	now := s.clock.Now()
+	for _, store := range s.Stores() {
+		usage := s.usageInfo.storeRef(store.StoreID())
+		if readBytes > 0 {
+			usage.BackgroundReadBytes += readBytes
+		}
+		if writeBytes <= 0 {
+			continue
+		}
+		usage.BackgroundWriteBytes += writeBytes
+		// Background work isn't shed by admission, so it may put the store
+		// into token debt, which is repaid before foreground writes are
+		// admitted again.
+		if limit := s.settings.AdmissionWriteBytesPerSecond; limit > 0 {
+			s.storeAdmission(store.StoreID(), now, limit).tokens -= float64(writeBytes)
+		}
+	}
+}
@@ -55,6 +55,12 @@ type ReplicaChange struct {
 	Author  StoreID
 	Changes kvpb.ReplicationChanges
 	Wait    time.Duration
+	// Reason is the reason the author moves a replica, when the change both
+	// adds and removes a replica.
+	Reason MoveReason
+	// SnapshotBytes is the size of the snapshot which the author sends to the
+	// store receiving a replica, if any.
+	SnapshotBytes int64
 }
 
 // RangeSplitChange contains information necessary to split a range at a given
@@ -350,12 +356,29 @@ func (rc *ReplicaChange) Apply(s State) {
 
 		authorUsageInfo := s.ClusterUsageInfo().storeRef(rc.Author)
 		authorUsageInfo.Rebalances++
+		s.ClusterUsageInfo().MoveReasons[rc.Reason]++
 		if requiresUpReplication {
 			authorUsageInfo.RebalanceSentBytes += r.Size()
 			s.ClusterUsageInfo().storeRef(storeNeedingSnapshot).RebalanceRcvdBytes += r.Size()
 		}
 	}
 
+	// The removed replicas are garbage collected after a delay, until which
+	// their stores still account for them. Only the replica which held the
+	// lease accounts for the bytes of the range, see state.capacity.
+	r, _ := s.Range(rc.RangeID)
+	for _, removals := range [][]roachpb.ReplicationTarget{
+		targets.VoterRemovals, targets.NonVoterRemovals,
+	} {
+		for _, removal := range removals {
+			var bytes int64
+			if StoreID(removal.StoreID) == lhStore.StoreID() {
+				bytes = r.Size()
+			}
+			s.QueueReplicaGC(rangeID, StoreID(removal.StoreID), bytes)
+		}
+	}
+
 	// We successfully made it through applying all changes. Clear the list of
 	// rollback functions.
 	rollback = nil
@@ -403,6 +426,34 @@ func (rc *ReplicaChange) Blocking() bool {
 	return true
 }
 
+// changeBudget limits the number of changes of a kind which may be initiated
+// per tick. Changes beyond the limit are deferred to the next tick with
+// remaining budget.
+type changeBudget struct {
+	limit     int
+	initiated map[time.Time]int
+}
+
+// initiateAt returns the earliest tick, at or after the tick given, that a
+// change may be initiated at without exceeding the budget. The change is
+// counted against the budget of the returned tick.
+func (cb *changeBudget) initiateAt(tick time.Time, interval time.Duration) time.Time {
+	for cb.initiated[tick] >= cb.limit {
+		tick = tick.Add(interval)
+	}
+	cb.initiated[tick]++
+	return tick
+}
+
+// gc removes the budget accounting for ticks before the tick given.
+func (cb *changeBudget) gc(tick time.Time) {
+	for t := range cb.initiated {
+		if t.Before(tick) {
+			delete(cb.initiated, t)
+		}
+	}
+}
+
 // replicaChanger is an implementation of the changer interface, for replica
 // changes. It maintains a pending list of changes for ranges, applying changes
 // to state given the delay and other pending changes for the same receiver,
@@ -413,6 +464,18 @@ type replicaChanger struct {
 	pendingTickets map[int]Change
 	pendingTarget  map[StoreID]time.Time
 	pendingRange   map[RangeID]int
+
+	// interval is the duration between ticks, it is used to defer changes
+	// which exceed the budget for a tick.
+	interval            time.Duration
+	replicaMoveBudget   *changeBudget
+	leaseTransferBudget *changeBudget
+	// deferredAuthors contains the author of every change deferred due to the
+	// budget, since the last tick. It is recorded in the cluster usage on the
+	// next tick.
+	deferredAuthors []StoreID
+	// snapshots is nil when the snapshot bandwidth of stores is unlimited.
+	snapshots *snapshotBandwidth
 }
 
 // NewReplicaChanger returns an implementation of the changer interface for
@@ -426,6 +489,44 @@ func NewReplicaChanger() Changer {
 	}
 }
 
+// NewBudgetedReplicaChanger returns an implementation of the changer
+// interface for replica changes, which initiates at most replicaMoves replica
+// changes and leaseTransfers lease transfers per tick. Changes beyond the
+// budget are queued and initiated on a later tick, where interval is the
+// duration between ticks. A budget of zero or less is unlimited. The
+// snapshots of replica changes are sent and received at most at sendRate and
+// recvRate bytes per second by each store, where a rate of zero or less is
+// unlimited.
+func NewBudgetedReplicaChanger(
+	interval time.Duration, replicaMoves, leaseTransfers int, sendRate, recvRate int64,
+) Changer {
+	rc := NewReplicaChanger().(*replicaChanger)
+	rc.interval = interval
+	if sendRate > 0 || recvRate > 0 {
+		rc.snapshots = newSnapshotBandwidth(interval, sendRate, recvRate)
+	}
+	if replicaMoves > 0 {
+		rc.replicaMoveBudget = &changeBudget{limit: replicaMoves, initiated: make(map[time.Time]int)}
+	}
+	if leaseTransfers > 0 {
+		rc.leaseTransferBudget = &changeBudget{limit: leaseTransfers, initiated: make(map[time.Time]int)}
+	}
+	return rc
+}
+
+// budgetFor returns the budget which applies to the change and its author, if
+// any.
+func (rc *replicaChanger) budgetFor(change Change) (*changeBudget, StoreID) {
+	switch c := change.(type) {
+	case *ReplicaChange:
+		return rc.replicaMoveBudget, c.Author
+	case *LeaseTransferChange:
+		return rc.leaseTransferBudget, c.Author
+	default:
+		return nil, 0
+	}
+}
+
 type pendingChange struct {
 	ticket     int
 	completeAt time.Time
@@ -454,6 +555,23 @@ func (rc *replicaChanger) Push(tick time.Time, change Change) (time.Time, bool)
 	rc.pendingTickets[ticket] = change
 	rc.pendingRange[change.Range()] = ticket
 
+	// Defer initiating the change until a tick with remaining budget, if the
+	// budget for this tick has been used up.
+	if budget, author := rc.budgetFor(change); budget != nil {
+		if initiateAt := budget.initiateAt(tick, rc.interval); initiateAt.After(tick) {
+			rc.deferredAuthors = append(rc.deferredAuthors, author)
+			tick = initiateAt
+		}
+	}
+
+	// Wait until the snapshot of the change, if any, has been transferred
+	// given the bandwidth of the sender and the receiver.
+	if rc.snapshots != nil {
+		if c, ok := change.(*ReplicaChange); ok && c.SnapshotBytes > 0 && c.Target() != 0 {
+			tick = rc.snapshots.transfer(tick, c.Author, c.Target(), c.SnapshotBytes)
+		}
+	}
+
 	completeAt := tick
 	if change.Blocking() {
 		// If there are pending changes for the target, we queue them and return
@@ -477,6 +595,19 @@ func (rc *replicaChanger) Push(tick time.Time, change Change) (time.Time, bool)
 // Tick updates state changer to apply any changes that have occurred
 // between the last tick and this one.
 func (rc *replicaChanger) Tick(tick time.Time, state State) {
+	for _, author := range rc.deferredAuthors {
+		state.ClusterUsageInfo().storeRef(author).DeferredMoves++
+	}
+	rc.deferredAuthors = nil
+	for _, budget := range []*changeBudget{rc.replicaMoveBudget, rc.leaseTransferBudget} {
+		if budget != nil {
+			budget.gc(tick)
+		}
+	}
+	if rc.snapshots != nil {
+		rc.snapshots.tick(tick, state.ClusterUsageInfo())
+	}
+
 	changeList := make(map[int]*pendingChange)
 
 	// NB: Add the smallest unit of time, in order to find all items in
@@ -716,3 +716,121 @@ func TestReplicaStateChanger(t *testing.T) {
 		})
 	}
 }
+
+// TestBudgetedReplicaChanger asserts that changes pushed beyond the per-tick
+// budget are deferred to later ticks, and that the deferrals are recorded
+// against the author of the change.
+func TestBudgetedReplicaChanger(t *testing.T) {
+	start := TestingStartTime()
+	interval := time.Second
+	s := NewStateEvenDistribution(3, 3, 3, 300, config.DefaultSimulationSettings())
+	changer := NewBudgetedReplicaChanger(
+		interval, 0 /* replicaMoves */, 1 /* leaseTransfers */, 0 /* sendRate */, 0 /* recvRate */)
+
+	var completeAts []time.Time
+	for _, rng := range s.Ranges() {
+		lhStore, ok := s.LeaseholderStore(rng.RangeID())
+		require.True(t, ok)
+		var target StoreID
+		for _, repl := range rng.Replicas() {
+			if repl.StoreID() != lhStore.StoreID() {
+				target = repl.StoreID()
+				break
+			}
+		}
+		completeAt, ok := changer.Push(start, &LeaseTransferChange{
+			RangeID:        rng.RangeID(),
+			TransferTarget: target,
+			Author:         lhStore.StoreID(),
+		})
+		require.True(t, ok)
+		completeAts = append(completeAts, completeAt)
+	}
+
+	// Only one lease transfer may be initiated per tick, the remaining
+	// transfers are initiated on the following ticks.
+	require.Equal(t, []time.Time{start, start.Add(interval), start.Add(2 * interval)}, completeAts)
+
+	changer.Tick(start, s)
+	var deferred int64
+	for _, u := range s.ClusterUsageInfo().StoreUsage {
+		deferred += u.DeferredMoves
+	}
+	require.Equal(t, int64(2), deferred)
+}
+
+// TestSnapshotBandwidth asserts that the snapshots of a burst of replica
+// changes queue behind each other on the side whose bandwidth is the lower,
+// and that the utilization of each store's send and receive bandwidth shows
+// which side is the bottleneck.
+func TestSnapshotBandwidth(t *testing.T) {
+	const mb = 1 << 20
+	start := TestingStartTime()
+	interval := time.Second
+
+	testCases := []struct {
+		desc               string
+		sendRate, recvRate int64
+		// moves are the (sender, receiver) pairs of the snapshots sent.
+		moves [][2]StoreID
+		// expectedSend and expectedRecv are the send and receive utilization
+		// of each store in the first tick.
+		expectedSend, expectedRecv map[StoreID]float64
+	}{
+		{
+			desc:         "send bound",
+			sendRate:     1 * mb,
+			recvRate:     4 * mb,
+			moves:        [][2]StoreID{{1, 2}, {1, 3}, {1, 4}},
+			expectedSend: map[StoreID]float64{1: 1, 2: 0, 3: 0, 4: 0},
+			expectedRecv: map[StoreID]float64{1: 0, 2: 0.25, 3: 0, 4: 0},
+		},
+		{
+			desc:         "receive bound",
+			sendRate:     4 * mb,
+			recvRate:     1 * mb,
+			moves:        [][2]StoreID{{2, 1}, {3, 1}, {4, 1}},
+			expectedSend: map[StoreID]float64{1: 0, 2: 0.25, 3: 0, 4: 0},
+			expectedRecv: map[StoreID]float64{1: 1, 2: 0, 3: 0, 4: 0},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			s := NewStateEvenDistribution(4, 4, 1, 400, config.DefaultSimulationSettings())
+			changer := NewBudgetedReplicaChanger(
+				interval, 0 /* replicaMoves */, 0 /* leaseTransfers */, tc.sendRate, tc.recvRate)
+
+			var completeAts []time.Time
+			for i, move := range tc.moves {
+				completeAt, ok := changer.Push(start, &ReplicaChange{
+					RangeID:       RangeID(i + 1),
+					Author:        move[0],
+					Changes:       kvpb.ReplicationChanges{testRC(move[1], roachpb.ADD_VOTER)},
+					SnapshotBytes: 10 * mb,
+				})
+				require.True(t, ok)
+				completeAts = append(completeAts, completeAt)
+			}
+			// Each 10 MiB snapshot takes 10s at the lower of the rates, and the
+			// snapshots queue behind each other on the bottlenecked store.
+			require.Equal(t, []time.Time{
+				start.Add(10 * time.Second), start.Add(20 * time.Second), start.Add(30 * time.Second),
+			}, completeAts)
+
+			changer.Tick(start.Add(interval), s)
+			send := make(map[StoreID]float64)
+			recv := make(map[StoreID]float64)
+			for _, store := range s.Stores() {
+				var u StoreUsageInfo
+				if usage, ok := s.ClusterUsageInfo().StoreUsage[store.StoreID()]; ok {
+					u = *usage
+				}
+				send[store.StoreID()] = u.SnapshotSendUtilization
+				recv[store.StoreID()] = u.SnapshotRecvUtilization
+			}
+			require.InDeltaMapValues(t, tc.expectedSend, send, 1e-9)
+			require.InDeltaMapValues(t, tc.expectedRecv, recv, 1e-9)
+		})
+	}
+}
@@ -259,6 +259,24 @@ func LoadConfig(c ClusterInfo, r RangesInfo, settings *config.SimulationSettings
 	return s
 }
 
+// LoadConfigWithRanges loads a single region cluster config with the given
+// number of stores, one per node, and ranges ranges evenly splitting a
+// keyspace of the given size. Every range has rf voting replicas and the
+// replicas and leases are initially balanced across the stores. This is useful
+// for modeling larger clusters than the predefined configurations allow.
+func LoadConfigWithRanges(
+	stores, ranges, rf, keyspace int, settings *config.SimulationSettings,
+) State {
+	if rf > stores {
+		panic(fmt.Sprintf(
+			"Unable to load config: replication factor %d exceeds the store count %d",
+			rf, stores))
+	}
+	clusterInfo := ClusterInfoWithStoreCount(stores, 1 /* storesPerNode */)
+	rangesInfo := RangesInfoEvenDistribution(stores, ranges, keyspace, rf, 0 /* rangeSize */)
+	return LoadConfig(clusterInfo, rangesInfo, settings)
+}
+
 // LoadClusterInfo loads a predefined configuration which contains cluster
 // information such as regions, zones, etc.
 func LoadClusterInfo(c ClusterInfo, settings *config.SimulationSettings) State {
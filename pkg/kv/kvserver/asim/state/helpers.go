@@ -46,6 +46,17 @@ func TestingWorkloadSeed() int64 {
 	return 42
 }
 
+// TestingHalfEmptyDistribution returns a replica distribution that may be
+// used with NewStateWithDistribution in tests, where half of the stores have
+// all of the replicas and the other half have none.
+func TestingHalfEmptyDistribution(stores int) []float64 {
+	distribution := make([]float64, stores)
+	for i := 0; i < stores/2; i++ {
+		distribution[i] = 1.0 / float64(stores/2)
+	}
+	return distribution
+}
+
 // TestingSetRangeQPS sets the QPS for the range with ID rangeID. This will
 // show on the current leaseholder replica load for this range and persist
 // between transfers.
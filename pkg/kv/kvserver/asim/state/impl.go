@@ -32,6 +32,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/spanconfig"
 	"github.com/cockroachdb/cockroach/pkg/spanconfig/spanconfigreporter"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/errors"
 	"github.com/google/btree"
 	"go.etcd.io/raft/v3"
 	"go.etcd.io/raft/v3/tracker"
@@ -47,11 +48,23 @@ type state struct {
 	newCapacityListeners    []NewCapacityListener
 	configChangeListeners   []ConfigChangeListener
 	capacityOverrides       map[StoreID]CapacityOverride
-	ranges                  *rmap
-	clusterinfo             ClusterInfo
-	usageInfo               *ClusterUsageInfo
-	clock                   *ManualSimClock
-	settings                *config.SimulationSettings
+	admission               map[StoreID]*storeAdmission
+	writeStalls             map[StoreID]bool
+	writeConcurrency        map[StoreID]*storeAdmission
+	// leaseStalls maps ranges whose lease was recently transferred, to the
+	// time until which they reject load.
+	leaseStalls map[RangeID]time.Time
+	// leaseReacquisitions maps ranges whose leaseholder's node recently
+	// restarted, to the time until which they are reacquiring their lease.
+	leaseReacquisitions map[RangeID]time.Time
+	// replicaGC maps stores to the replicas removed from them which are
+	// pending garbage collection.
+	replicaGC   map[StoreID]map[RangeID]gcPendingReplica
+	ranges      *rmap
+	clusterinfo ClusterInfo
+	usageInfo   *ClusterUsageInfo
+	clock       *ManualSimClock
+	settings    *config.SimulationSettings
 
 	// Unique ID generators for Nodes and Stores. These are incremented
 	// pre-assignment. So that IDs start from 1.
@@ -73,10 +86,17 @@ func newState(settings *config.SimulationSettings) *state {
 		loadsplits:        make(map[StoreID]LoadSplitter),
 		quickLivenessMap:  livenesspb.TestNodeVitality{},
 		capacityOverrides: make(map[StoreID]CapacityOverride),
+		admission:         make(map[StoreID]*storeAdmission),
+		writeStalls:       make(map[StoreID]bool),
+		writeConcurrency:  make(map[StoreID]*storeAdmission),
+		leaseStalls:       make(map[RangeID]time.Time),
+		replicaGC:         make(map[StoreID]map[RangeID]gcPendingReplica),
 		clock:             &ManualSimClock{nanos: settings.StartTime.UnixNano()},
 		ranges:            newRMap(),
 		usageInfo:         newClusterUsageInfo(),
 		settings:          settings,
+
+		leaseReacquisitions: make(map[RangeID]time.Time),
 	}
 	s.load = map[RangeID]ReplicaLoad{FirstRangeID: NewReplicaLoadCounter(s.clock)}
 	return s
@@ -225,6 +245,7 @@ func (s *state) capacity(storeID StoreID) roachpb.StoreCapacity {
 	capacity := store.desc.Capacity
 	capacity.QueriesPerSecond = 0
 	capacity.WritesPerSecond = 0
+	capacity.CPUPerSecond = 0
 	capacity.LogicalBytes = 0
 	capacity.LeaseCount = 0
 	capacity.RangeCount = 0
@@ -243,15 +264,26 @@ func (s *state) capacity(storeID StoreID) roachpb.StoreCapacity {
 			usage := s.RangeUsageInfo(rng.RangeID(), storeID)
 			capacity.QueriesPerSecond += usage.QueriesPerSecond
 			capacity.WritesPerSecond += usage.WritesPerSecond
+			// Only the CPU spent by the leaseholder evaluating requests is
+			// modeled, the CPU spent by every replica applying raft commands
+			// isn't.
+			capacity.CPUPerSecond += usage.RequestCPUNanosPerSecond
 			capacity.LogicalBytes += usage.LogicalBytes
 			capacity.LeaseCount++
 		}
 		capacity.RangeCount++
 	}
 
+	// Replicas removed from the store still use disk space until they are
+	// garbage collected.
+	var gcPendingBytes int64
+	for _, r := range s.gcPendingReplicas(storeID) {
+		gcPendingBytes += r.bytes
+	}
+
 	// TODO(kvoli): parameterize the logical to actual used storage bytes. At the
 	// moment we use 1.25 as a rough estimate.
-	used := int64(float64(capacity.LogicalBytes) * 1.25)
+	used := int64(float64(capacity.LogicalBytes+gcPendingBytes) * 1.25)
 	available := capacity.Capacity - used
 	capacity.Used = used
 	capacity.Available = available
@@ -500,6 +532,15 @@ func (s *state) SetStoreCapacity(storeID StoreID, capacity int64) {
 	store.desc.Capacity.Capacity = capacity
 }
 
+// SetStoreAttrs sets the attributes of the store with ID storeID.
+func (s *state) SetStoreAttrs(storeID StoreID, attrs roachpb.Attributes) {
+	store, ok := s.stores[storeID]
+	if !ok {
+		panic(fmt.Sprintf("programming error: store with ID %d doesn't exist", storeID))
+	}
+	store.desc.Attrs = attrs
+}
+
 // AddReplica modifies the state to include one additional range for the
 // Range with ID RangeID, placed on the Store with ID StoreID. This fails
 // if a Replica for the Range already exists the Store.
@@ -531,6 +572,9 @@ func (s *state) addReplica(
 
 	store.replicas[rangeID] = replica.replicaID
 	rng.replicas[storeID] = replica
+	// A replica previously removed from the store, which is yet to be garbage
+	// collected, is superseded by the new replica.
+	delete(s.replicaGC[storeID], rangeID)
 	s.publishCapacityChangeEvent(kvserver.RangeAddEvent, storeID)
 
 	// This is the first replica to be added for this range. Make it the
@@ -611,6 +655,49 @@ func (s *state) removeReplica(rangeID RangeID, storeID StoreID) bool {
 	return true
 }
 
+// gcPendingReplica is a replica removed from a store, which is pending garbage
+// collection.
+type gcPendingReplica struct {
+	bytes int64
+	gcAt  time.Time
+}
+
+// QueueReplicaGC records that the replica for the Range with ID RangeID,
+// which was removed from the Store with ID StoreID, is pending garbage
+// collection for the configured replica GC delay. Until then, the store
+// still accounts for the given bytes used by the replica.
+func (s *state) QueueReplicaGC(rangeID RangeID, storeID StoreID, bytes int64) {
+	delay := s.settings.ReplicaGCDelay
+	if delay <= 0 {
+		return
+	}
+	pending, ok := s.replicaGC[storeID]
+	if !ok {
+		pending = make(map[RangeID]gcPendingReplica)
+		s.replicaGC[storeID] = pending
+	}
+	pending[rangeID] = gcPendingReplica{bytes: bytes, gcAt: s.clock.Now().Add(delay)}
+}
+
+// GCPendingReplicas returns the number of replicas removed from the Store
+// with ID StoreID, which are pending garbage collection.
+func (s *state) GCPendingReplicas(storeID StoreID) int {
+	return len(s.gcPendingReplicas(storeID))
+}
+
+// gcPendingReplicas garbage collects the replicas removed from the store
+// whose GC delay has elapsed, then returns the remaining ones.
+func (s *state) gcPendingReplicas(storeID StoreID) map[RangeID]gcPendingReplica {
+	pending := s.replicaGC[storeID]
+	now := s.clock.Now()
+	for rangeID, r := range pending {
+		if !now.Before(r.gcAt) {
+			delete(pending, rangeID)
+		}
+	}
+	return pending
+}
+
 // SetSpanConfigForRange set the span config for the Range with ID RangeID.
 func (s *state) SetSpanConfigForRange(rangeID RangeID, spanConfig roachpb.SpanConfig) bool {
 	if rng, ok := s.ranges.rangeMap[rangeID]; ok {
@@ -870,6 +957,29 @@ func (s *state) TransferLease(rangeID RangeID, storeID StoreID) bool {
 
 	// Apply the lease transfer to state.
 	s.replaceLeaseHolder(rangeID, storeID, oldStore.StoreID())
+
+	// The new leaseholder acquires the lease as part of the transfer, which
+	// is modeled by the transfer stall below.
+	delete(s.leaseReacquisitions, rangeID)
+
+	// Stall the range for a number of ticks after the transfer.
+	if ticks := s.settings.LeaseTransferStallTicks; ticks > 0 {
+		s.leaseStalls[rangeID] = s.clock.Now().Add(time.Duration(ticks) * s.settings.TickInterval)
+	}
+	return true
+}
+
+// leaseStalled returns whether the range is stalled after a recent lease
+// transfer, i.e. within LeaseTransferStallTicks of the transfer.
+func (s *state) leaseStalled(rangeID RangeID) bool {
+	until, ok := s.leaseStalls[rangeID]
+	if !ok {
+		return false
+	}
+	if s.clock.Now().After(until) {
+		delete(s.leaseStalls, rangeID)
+		return false
+	}
 	return true
 }
 
@@ -936,6 +1046,10 @@ func (s *state) ValidTransfer(rangeID RangeID, storeID StoreID) bool {
 // This modifies specifically the leaseholder replica's RangeUsageInfo for
 // the targets of the LoadEvent.
 func (s *state) ApplyLoad(lb workload.LoadBatch) {
+	if limit := s.settings.AdmissionWriteBytesPerSecond; limit > 0 {
+		lb = s.admit(lb, limit)
+	}
+	lb = s.applyTxns(lb)
 	n := len(lb)
 	if n < 1 {
 		return
@@ -957,9 +1071,51 @@ func (s *state) ApplyLoad(lb workload.LoadBatch) {
 	})
 }
 
+// amplifyIndexWrites returns the load event with each of its logical writes
+// fanned out to a KV write per index of the table written to, see
+// config.SimulationSettings.SecondaryIndexes.
+func (s *state) amplifyIndexWrites(le workload.LoadEvent) workload.LoadEvent {
+	if indexes := int64(s.settings.SecondaryIndexes); indexes > 0 {
+		le.Writes *= 1 + indexes
+		le.WriteSize *= 1 + indexes
+	}
+	return le
+}
+
 func (s *state) applyLoad(rng *rng, le workload.LoadEvent) {
+	le = s.amplifyIndexWrites(le)
+	// A range which has lost quorum cannot serve requests, reject the load.
+	if s.rangeUnavailable(rng) {
+		return
+	}
+	// Likewise, a range cannot serve requests whilst its lease is reacquired
+	// after the node of its leaseholder restarted, reject the load.
+	if s.RangeReacquiringLease(rng.rangeID) {
+		return
+	}
+	// A range whose lease was recently transferred cannot serve requests until
+	// the new leaseholder has acquired the lease, reject the load.
+	if s.leaseStalled(rng.rangeID) {
+		if store, ok := s.LeaseholderStore(rng.rangeID); ok {
+			s.usageInfo.storeRef(store.StoreID()).LeaseStallBytes += le.WriteSize + le.ReadSize
+		}
+		return
+	}
+	// A leaseholder whose store has stalled writes cannot evaluate or persist
+	// writes, reject the write portion of the load. Reads are still served.
+	if le.Writes > 0 || le.WriteSize > 0 {
+		if store, ok := s.LeaseholderStore(rng.rangeID); ok && s.writeStalls[store.StoreID()] {
+			s.usageInfo.storeRef(store.StoreID()).StalledWriteBytes += le.WriteSize
+			le.Writes, le.WriteSize = 0, 0
+		}
+	}
+	le = s.throttleWrites(rng, le)
 	s.load[rng.rangeID].ApplyLoad(le)
 	s.usageInfo.ApplyLoad(rng, le)
+	s.recordReadLocality(rng, le)
+	s.recordRegionUsage(rng, le)
+	s.recordLatency(rng, le)
+	s.recordStorageClassReads(rng, le)
 
 	// Note that deletes are not supported currently, we are also assuming data
 	// is not compacted.
@@ -1045,12 +1201,208 @@ func (s *state) SetNodeLiveness(nodeID NodeID, status livenesspb.NodeLivenessSta
 	case livenesspb.NodeLivenessStatus_DECOMMISSIONING:
 		s.quickLivenessMap.Decommissioning(roachpb.NodeID(nodeID), true)
 	case livenesspb.NodeLivenessStatus_LIVE:
-		s.quickLivenessMap.RestartNode(roachpb.NodeID(nodeID))
+		s.restartNode(nodeID)
 	case livenesspb.NodeLivenessStatus_DEAD:
 		s.quickLivenessMap.DownNode(roachpb.NodeID(nodeID))
 	}
 }
 
+// SetStoreWriteStall sets whether the writes of the store with ID StoreID
+// are stalled. While stalled, writes to ranges whose leaseholder is on the
+// store are rejected. A stalled store is also unable to persist its node's
+// liveness heartbeats, so the node is considered dead until every stalled
+// store on it recovers. Unstalling a store which isn't stalled has no effect,
+// in particular it doesn't restart a node which is dead for another reason.
+func (s *state) SetStoreWriteStall(storeID StoreID, stalled bool) {
+	store, ok := s.stores[storeID]
+	if !ok {
+		return
+	}
+	if stalled {
+		s.writeStalls[storeID] = true
+		s.quickLivenessMap.DownNode(roachpb.NodeID(store.nodeID))
+		return
+	}
+	if !s.writeStalls[storeID] {
+		return
+	}
+	delete(s.writeStalls, storeID)
+	for _, otherStoreID := range s.nodes[store.nodeID].stores {
+		if s.writeStalls[otherStoreID] {
+			return
+		}
+	}
+	s.restartNode(store.nodeID)
+}
+
+// restartNode marks the node with ID NodeID as live. When the node was
+// previously not live, the ranges whose leaseholder is on one of its stores
+// reacquire their leases for LeaseReacquisitionTicks, before serving load.
+func (s *state) restartNode(nodeID NodeID) {
+	wasAlive := s.quickLivenessMap[roachpb.NodeID(nodeID)].Alive
+	s.quickLivenessMap.RestartNode(roachpb.NodeID(nodeID))
+	ticks := s.settings.LeaseReacquisitionTicks
+	if wasAlive || ticks <= 0 {
+		return
+	}
+	until := s.clock.Now().Add(time.Duration(ticks) * s.settings.TickInterval)
+	n, ok := s.nodes[nodeID]
+	if !ok {
+		return
+	}
+	for _, storeID := range n.stores {
+		for rangeID := range s.stores[storeID].replicas {
+			if rng, ok := s.rng(rangeID); ok && rng.replicas[storeID].holdsLease {
+				s.leaseReacquisitions[rangeID] = until
+			}
+		}
+	}
+}
+
+// RangeReacquiringLease returns whether the Range with ID RangeID is
+// reacquiring its lease, i.e. within LeaseReacquisitionTicks of the node of
+// its leaseholder restarting.
+func (s *state) RangeReacquiringLease(rangeID RangeID) bool {
+	until, ok := s.leaseReacquisitions[rangeID]
+	if !ok {
+		return false
+	}
+	if s.clock.Now().After(until) {
+		delete(s.leaseReacquisitions, rangeID)
+		return false
+	}
+	return true
+}
+
+// RangeLeaseless returns whether the Range with ID RangeID has no active
+// leaseholder: it has no leaseholder replica, or its lease was recently
+// transferred and the new leaseholder is still acquiring it, i.e. within
+// LeaseTransferStallTicks of the transfer, or it is reacquiring its lease
+// after the node of its leaseholder restarted.
+func (s *state) RangeLeaseless(rangeID RangeID) bool {
+	rng, ok := s.rng(rangeID)
+	if !ok {
+		return false
+	}
+	if rng.leaseholder == -1 {
+		return true
+	}
+	return s.leaseStalled(rangeID) || s.RangeReacquiringLease(rangeID)
+}
+
+// StoreWriteStalled returns whether the writes of the store with ID StoreID
+// are currently stalled.
+func (s *state) StoreWriteStalled(storeID StoreID) bool {
+	return s.writeStalls[storeID]
+}
+
+// RangeUnavailable returns whether the Range with ID RangeID has lost
+// quorum, i.e. a majority of its voting replicas are on dead nodes. An
+// unavailable range cannot make progress and rejects any load applied to it.
+func (s *state) RangeUnavailable(rangeID RangeID) bool {
+	rng, ok := s.rng(rangeID)
+	if !ok {
+		return false
+	}
+	return s.rangeUnavailable(rng)
+}
+
+// RangeReplicationTarget returns the number of replicas the Range with ID
+// RangeID is configured to have, as given by its span config. It returns false
+// if the range doesn't exist.
+func (s *state) RangeReplicationTarget(rangeID RangeID) (int, bool) {
+	rng, ok := s.rng(rangeID)
+	if !ok {
+		return 0, false
+	}
+	return int(rng.config.NumReplicas), true
+}
+
+func (s *state) rangeUnavailable(rng *rng) bool {
+	var voters, liveVoters int
+	for storeID, repl := range rng.replicas {
+		if !repl.desc.IsVoterNewConfig() {
+			continue
+		}
+		voters++
+		store, ok := s.stores[storeID]
+		if !ok {
+			continue
+		}
+		// NB: Nodes which are marked dead, or are otherwise not alive such as
+		// decommissioned nodes, cannot participate in the raft group.
+		if s.quickLivenessMap[roachpb.NodeID(store.nodeID)].Alive {
+			liveVoters++
+		}
+	}
+	return voters > 0 && liveVoters < voters/2+1
+}
+
+// Validate returns an error describing the first invariant of the state found
+// to be violated, if any. Every range must have at least one replica and a
+// non-negative size, exactly one replica of every range must hold its lease,
+// and the replicas of a range must agree with the replicas tracked by each
+// store.
+func (s *state) Validate() error {
+	for _, r := range s.Ranges() {
+		rng := r.(*rng)
+		if len(rng.replicas) == 0 {
+			return errors.Newf("r%d has no replicas", rng.rangeID)
+		}
+		if rng.size < 0 {
+			return errors.Newf("r%d has a negative size of %d bytes", rng.rangeID, rng.size)
+		}
+		storeIDs := make([]StoreID, 0, len(rng.replicas))
+		for storeID := range rng.replicas {
+			storeIDs = append(storeIDs, storeID)
+		}
+		sort.Slice(storeIDs, func(i, j int) bool { return storeIDs[i] < storeIDs[j] })
+
+		var leaseholders int
+		for _, storeID := range storeIDs {
+			repl := rng.replicas[storeID]
+			store, ok := s.stores[storeID]
+			if !ok {
+				return errors.Newf("r%d has a replica on s%d, which doesn't exist", rng.rangeID, storeID)
+			}
+			if replicaID, ok := store.replicas[rng.rangeID]; !ok || replicaID != repl.replicaID {
+				return errors.Newf("r%d has replica %d on s%d, which isn't tracked by the store",
+					rng.rangeID, repl.replicaID, storeID)
+			}
+			if repl.holdsLease {
+				leaseholders++
+				if repl.replicaID != rng.leaseholder {
+					return errors.Newf("r%d has replica %d on s%d holding its lease, but its leaseholder is replica %d",
+						rng.rangeID, repl.replicaID, storeID, rng.leaseholder)
+				}
+			}
+		}
+		if leaseholders != 1 {
+			return errors.Newf("r%d has %d replicas holding its lease, expected 1", rng.rangeID, leaseholders)
+		}
+	}
+
+	for _, st := range s.Stores() {
+		store := st.(*store)
+		rangeIDs := make([]RangeID, 0, len(store.replicas))
+		for rangeID := range store.replicas {
+			rangeIDs = append(rangeIDs, rangeID)
+		}
+		sort.Slice(rangeIDs, func(i, j int) bool { return rangeIDs[i] < rangeIDs[j] })
+		for _, rangeID := range rangeIDs {
+			rng, ok := s.ranges.rangeMap[rangeID]
+			if !ok {
+				return errors.Newf("s%d tracks a replica of r%d, which doesn't exist", store.storeID, rangeID)
+			}
+			if _, ok := rng.replicas[store.storeID]; !ok {
+				return errors.Newf("s%d tracks a replica of r%d, which isn't a replica of the range",
+					store.storeID, rangeID)
+			}
+		}
+	}
+	return nil
+}
+
 // NodeLivenessFn returns a function, that when called will return the
 // liveness of the Node with ID NodeID.
 // TODO(kvoli): Find a better home for this method, required by the storepool.
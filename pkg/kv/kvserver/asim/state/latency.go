@@ -0,0 +1,193 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package state
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/workload"
+)
+
+// LatencyDistribution is a distribution of request latencies, mapping each
+// latency to the number of requests which observed it. Latencies are derived
+// from the distance between the regions of clients and stores, so there are
+// few distinct values and the distribution is kept exactly.
+type LatencyDistribution map[time.Duration]int64
+
+// Record records count requests which observed the given latency.
+func (d LatencyDistribution) Record(latency time.Duration, count int64) {
+	if count > 0 {
+		d[latency] += count
+	}
+}
+
+// Count returns the number of requests recorded in the distribution.
+func (d LatencyDistribution) Count() int64 {
+	var count int64
+	for _, c := range d {
+		count += c
+	}
+	return count
+}
+
+// Percentile returns the smallest latency which is greater than or equal to
+// the latency of at least p percent of the recorded requests. It returns zero
+// when no requests were recorded.
+func (d LatencyDistribution) Percentile(p float64) time.Duration {
+	count := d.Count()
+	if count == 0 {
+		return 0
+	}
+	latencies := make([]time.Duration, 0, len(d))
+	for latency := range d {
+		latencies = append(latencies, latency)
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	rank := int64(math.Ceil(p / 100 * float64(count)))
+	var seen int64
+	for _, latency := range latencies {
+		seen += d[latency]
+		if seen >= rank {
+			return latency
+		}
+	}
+	return latencies[len(latencies)-1]
+}
+
+// Merge adds the requests recorded in other to the distribution.
+func (d LatencyDistribution) Merge(other LatencyDistribution) {
+	for latency, count := range other {
+		d.Record(latency, count)
+	}
+}
+
+// Since returns the requests recorded in the distribution, which weren't
+// recorded in the earlier distribution prev.
+func (d LatencyDistribution) Since(prev LatencyDistribution) LatencyDistribution {
+	ret := make(LatencyDistribution, len(d))
+	for latency, count := range d {
+		ret.Record(latency, count-prev[latency])
+	}
+	return ret
+}
+
+// Clone returns a copy of the distribution.
+func (d LatencyDistribution) Clone() LatencyDistribution {
+	ret := make(LatencyDistribution, len(d))
+	ret.Merge(d)
+	return ret
+}
+
+// regionLatency returns the round-trip latency between the regions a and b.
+// An empty region is considered to be the same as any other region.
+func (s *state) regionLatency(a, b string) time.Duration {
+	if a == "" || b == "" || a == b {
+		return s.settings.IntraRegionLatency
+	}
+	return s.settings.InterRegionLatency
+}
+
+// writeLatency returns the latency of a write from a client in the given
+// region to the range, whose lease is held by the given store. A write must be
+// sent to the leaseholder and is committed once a quorum of voters, including
+// the leaseholder, has acknowledged it, so the slowest voter in the quorum
+// gates the commit. Clients without a known region are assumed to be in the
+// same region as the leaseholder.
+func (s *state) writeLatency(rng *rng, leaseholder StoreID, clientRegion string) time.Duration {
+	leaseholderRegion := s.storeRegion(leaseholder)
+	var replication []time.Duration
+	for storeID, repl := range rng.replicas {
+		if !repl.desc.IsVoterNewConfig() {
+			continue
+		}
+		if storeID == leaseholder {
+			replication = append(replication, 0)
+			continue
+		}
+		replication = append(replication, s.regionLatency(leaseholderRegion, s.storeRegion(storeID)))
+	}
+	sort.Slice(replication, func(i, j int) bool { return replication[i] < replication[j] })
+	latency := s.regionLatency(clientRegion, leaseholderRegion)
+	if len(replication) > 0 {
+		latency += replication[len(replication)/2]
+	}
+	return latency
+}
+
+// throttleWrites returns the load event with only the writes which the
+// leaseholder store of the range can commit, given the write concurrency
+// limit. Every in-flight write occupies one of the limit's slots until it is
+// committed, so a store can commit at most limit/latency writes per second,
+// and ranges whose quorum spans regions commit fewer writes. This is modeled
+// as a token bucket of write-seconds, which refills at a rate of limit per
+// second, up to a burst of one second worth of tokens. The rejected writes are
+// recorded against the leaseholder store.
+func (s *state) throttleWrites(rng *rng, le workload.LoadEvent) workload.LoadEvent {
+	limit := int64(s.settings.WriteConcurrencyLimit)
+	if limit <= 0 || le.Writes == 0 {
+		return le
+	}
+	store, ok := s.LeaseholderStore(rng.rangeID)
+	if !ok {
+		return le
+	}
+	latency := s.writeLatency(rng, store.StoreID(), le.ClientRegion).Seconds()
+	if latency <= 0 {
+		return le
+	}
+	now := s.clock.Now()
+	wc, ok := s.writeConcurrency[store.StoreID()]
+	if !ok {
+		wc = &storeAdmission{tokens: float64(limit), lastRefill: now}
+		s.writeConcurrency[store.StoreID()] = wc
+	}
+	wc.refill(now, limit)
+
+	committed := int64(wc.tokens / latency)
+	if committed >= le.Writes {
+		committed = le.Writes
+	} else {
+		s.usageInfo.storeRef(store.StoreID()).ThrottledWrites += le.Writes - committed
+		le.WriteSize = le.WriteSize * committed / le.Writes
+		le.Writes = committed
+	}
+	wc.tokens -= float64(committed) * latency
+	return le
+}
+
+// recordLatency records the latency observed by the reads and writes of the
+// load event against the store holding the lease for the range. A read is
+// served by the replica with the lowest latency, which is the closest replica
+// to the client unless the storage classes of the replicas differ, see
+// readReplica.
+func (s *state) recordLatency(rng *rng, le workload.LoadEvent) {
+	if le.Writes == 0 && le.Reads == 0 {
+		return
+	}
+	store, ok := s.LeaseholderStore(rng.rangeID)
+	if !ok {
+		return
+	}
+	leaseholder := store.StoreID()
+	usage := s.usageInfo.storeRef(leaseholder)
+
+	if le.Writes > 0 {
+		usage.WriteLatency.Record(s.writeLatency(rng, leaseholder, le.ClientRegion), le.Writes)
+	}
+
+	if le.Reads > 0 {
+		_, latency := s.readReplica(rng, leaseholder, le.ClientRegion)
+		usage.ReadLatency.Record(latency, le.Reads)
+	}
+}
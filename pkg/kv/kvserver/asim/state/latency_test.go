@@ -0,0 +1,113 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package state
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/config"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/workload"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLatencyPercentiles asserts that writes to a range whose quorum spans
+// regions observe the inter-region latency, which shows up in the p99 write
+// latency while the p50 reflects the writes which reach quorum in-region.
+func TestLatencyPercentiles(t *testing.T) {
+	settings := config.DefaultSimulationSettings()
+	s := NewState(settings)
+	addStore := func(region string) StoreID {
+		n := s.AddNode()
+		s.SetNodeLocality(n.NodeID(), roachpb.Locality{
+			Tiers: []roachpb.Tier{{Key: "region", Value: region}},
+		})
+		store, _ := s.AddStore(n.NodeID())
+		return store.StoreID()
+	}
+	east1, east2 := addStore("us-east"), addStore("us-east")
+	west := addStore("us-west")
+
+	// The leaseholder of the first range can reach quorum in us-east, whilst
+	// the leaseholder of the second range must reach us-east from us-west.
+	_, local, _ := s.SplitRange(100)
+	_, remote, _ := s.SplitRange(200)
+	for _, storeID := range []StoreID{east1, east2, west} {
+		s.AddReplica(local.RangeID(), storeID, roachpb.VOTER_FULL)
+		s.AddReplica(remote.RangeID(), storeID, roachpb.VOTER_FULL)
+	}
+	// The first voter added to a range, on east1, acquires its lease.
+	require.True(t, s.TransferLease(remote.RangeID(), west))
+
+	s.ApplyLoad(workload.LoadBatch{
+		{Key: 100, Writes: 95, WriteSize: 95, Reads: 10, ReadSize: 10},
+		{Key: 200, Writes: 5, WriteSize: 5, Reads: 10, ReadSize: 10, ClientRegion: "us-east"},
+	})
+
+	write := make(LatencyDistribution)
+	read := make(LatencyDistribution)
+	for _, u := range s.ClusterUsageInfo().StoreUsage {
+		write.Merge(u.WriteLatency)
+		read.Merge(u.ReadLatency)
+	}
+	require.Equal(t, int64(100), write.Count())
+	require.Equal(t, 2*settings.IntraRegionLatency, write.Percentile(50))
+	require.Equal(t, settings.InterRegionLatency*2, write.Percentile(99))
+	require.Greater(t, write.Percentile(99), write.Percentile(50))
+
+	// Reads are served from the closest replica, which is always in the
+	// client's region.
+	require.Equal(t, int64(20), read.Count())
+	require.Equal(t, settings.IntraRegionLatency, read.Percentile(99))
+}
+
+// TestWriteQuorumCost asserts that writes to a range whose voters span regions
+// have a higher committed-write latency than writes to a range whose voters
+// are all in the same region, and that under a write concurrency limit the
+// former commit fewer writes.
+func TestWriteQuorumCost(t *testing.T) {
+	settings := config.DefaultSimulationSettings()
+	settings.WriteConcurrencyLimit = 1
+
+	// writeUsage applies writes to a range with a voter in each of the given
+	// regions, whose lease is held by the voter in the first region, and
+	// returns the usage of the leaseholder store.
+	writeUsage := func(regions ...string) *StoreUsageInfo {
+		s := NewState(settings)
+		_, r, _ := s.SplitRange(100)
+		var leaseholder StoreID
+		for i, region := range regions {
+			n := s.AddNode()
+			s.SetNodeLocality(n.NodeID(), roachpb.Locality{
+				Tiers: []roachpb.Tier{{Key: "region", Value: region}},
+			})
+			store, _ := s.AddStore(n.NodeID())
+			s.AddReplica(r.RangeID(), store.StoreID(), roachpb.VOTER_FULL)
+			if i == 0 {
+				leaseholder = store.StoreID()
+			}
+		}
+		s.ApplyLoad(workload.LoadBatch{{Key: 100, Writes: 100, WriteSize: 100}})
+		return s.ClusterUsageInfo().StoreUsage[leaseholder]
+	}
+
+	sameRegion := writeUsage("us-east", "us-east", "us-east")
+	crossRegion := writeUsage("us-east", "us-west", "us-central")
+	require.Greater(t, crossRegion.WriteLatency.Percentile(50), sameRegion.WriteLatency.Percentile(50))
+
+	// A single write slot commits one second worth of writes, 500 same-region
+	// writes of 2ms, but only 16 cross-region writes of 61ms.
+	require.Equal(t, int64(0), sameRegion.ThrottledWrites)
+	require.Equal(t, int64(100), sameRegion.WriteLatency.Count())
+	require.Equal(t, int64(84), crossRegion.ThrottledWrites)
+	require.Equal(t, int64(16), crossRegion.WriteLatency.Count())
+	require.Equal(t, int64(16), crossRegion.WriteBytes)
+}
@@ -71,6 +71,9 @@ func (rl *ReplicaLoadCounter) ApplyLoad(le workload.LoadEvent) {
 	rl.WriteKeys += le.Writes
 
 	rl.loadStats.RecordBatchRequests(LoadEventQPS(le), 0)
+	if le.RequestCPU > 0 {
+		rl.loadStats.RecordReqCPUNanos(float64(le.RequestCPU))
+	}
 	// TODO(kvoli): Recording the load on every load counter is horribly
 	// inefficient at the moment. It multiplies the time taken per test almost
 	// linearly by the number of load stats counters we bump. The other load
@@ -84,8 +87,9 @@ func (rl *ReplicaLoadCounter) Load() allocator.RangeUsageInfo {
 	stats := rl.loadStats.Stats()
 
 	return allocator.RangeUsageInfo{
-		QueriesPerSecond: stats.QueriesPerSecond,
-		WritesPerSecond:  float64(rl.WriteKeys),
+		QueriesPerSecond:         stats.QueriesPerSecond,
+		WritesPerSecond:          float64(rl.WriteKeys),
+		RequestCPUNanosPerSecond: stats.RequestCPUNanosPerSecond,
 	}
 }
 
@@ -205,6 +209,48 @@ type StoreUsageInfo struct {
 	RebalanceSentBytes int64
 	RebalanceRcvdBytes int64
 	RangeSplits        int64
+	// DeferredMoves is the number of replica changes and lease transfers
+	// authored by the store, which were queued for a later tick due to the
+	// rebalance budget.
+	DeferredMoves int64
+	// StalledWriteBytes is the number of write bytes rejected because the
+	// store held the lease for the range written to while its writes were
+	// stalled.
+	StalledWriteBytes int64
+	// WriteLatency and ReadLatency are the distributions of the latency
+	// observed by the writes and reads served by the store, while it held the
+	// lease for the range.
+	WriteLatency LatencyDistribution
+	ReadLatency  LatencyDistribution
+	// ThrottledWrites is the number of writes rejected because the store held
+	// the lease for the range written to, while all of its write concurrency
+	// was occupied by writes waiting to reach quorum.
+	ThrottledWrites int64
+	// LeaseStallBytes is the number of read and write bytes rejected because
+	// the store had recently acquired the lease for the range, through a lease
+	// transfer, and the range was stalled.
+	LeaseStallBytes int64
+	// ReplicateQueueLength, LeaseQueueLength and SplitQueueLength are the
+	// number of replicas enqueued in the store's replicate queue, lease
+	// transfers dispatched by the store which are not yet done and replicas
+	// enqueued in the store's split queue, as of the last tick.
+	ReplicateQueueLength int64
+	LeaseQueueLength     int64
+	SplitQueueLength     int64
+	// EvaluatedRanges is the number of ranges the store's allocator evaluated
+	// in the last tick, see config.SimulationSettings.AllocatorDecisionBudget.
+	EvaluatedRanges int64
+	// SnapshotSendUtilization and SnapshotRecvUtilization are the fractions of
+	// the store's snapshot send and receive bandwidth used in the last tick,
+	// see config.SimulationSettings.SnapshotSendBytesPerSecond. They are zero
+	// when the bandwidth is unlimited.
+	SnapshotSendUtilization float64
+	SnapshotRecvUtilization float64
+	// BackgroundReadBytes and BackgroundWriteBytes are the number of bytes
+	// read and written by background processes on the store, separately from
+	// the foreground load above.
+	BackgroundReadBytes  int64
+	BackgroundWriteBytes int64
 }
 
 // ClusterUsageInfo contains the load and state of the cluster. Using this we
@@ -212,19 +258,114 @@ type StoreUsageInfo struct {
 // rebalanced.
 type ClusterUsageInfo struct {
 	StoreUsage map[StoreID]*StoreUsageInfo
+	// AdmissionUsage contains the bytes admitted and rejected by store
+	// admission, for each priority.
+	AdmissionUsage map[admissionpb.WorkPriority]*AdmissionUsageInfo
+	// TxnUsage contains the outcome of the transactional load applied to the
+	// cluster.
+	TxnUsage TxnUsageInfo
+	// ReadLocality contains the number of reads which could be served from a
+	// replica in the same region as the client issuing them.
+	ReadLocality ReadLocalityInfo
+	// RegionUsage contains the bytes read and written by the stores of each
+	// region.
+	RegionUsage map[string]*RegionUsageInfo
+	// StorageClassUsage contains the reads served by the stores of each
+	// storage class, see config.SimulationSettings.StorageClassReadLatency.
+	StorageClassUsage map[string]*StorageClassUsageInfo
+	// IdleTicks is the number of ticks where the allocators made no replica
+	// move or lease transfer.
+	IdleTicks int64
+	// MoveReasons contains the number of replica moves attributed to each
+	// reason.
+	MoveReasons map[MoveReason]int64
+}
+
+// TxnUsageInfo contains the number of transactions which were committed and
+// aborted, along with the writes of the committed transactions.
+type TxnUsageInfo struct {
+	// Committed is the number of transactions whose writes were applied.
+	Committed int64
+	// Aborted is the number of transactions whose writes were all rejected,
+	// because at least one of the ranges written to was unavailable.
+	Aborted int64
+	// RangeSpanning is the number of committed transactions which wrote to
+	// more than one range.
+	RangeSpanning int64
+	WriteKeys     int64
+	WriteBytes    int64
+}
+
+// AdmissionUsageInfo contains the number of bytes admitted and rejected for
+// a specific priority.
+type AdmissionUsageInfo struct {
+	AdmittedBytes int64
+	RejectedBytes int64
 }
 
 func newClusterUsageInfo() *ClusterUsageInfo {
 	return &ClusterUsageInfo{
-		StoreUsage: make(map[StoreID]*StoreUsageInfo),
+		StoreUsage:        make(map[StoreID]*StoreUsageInfo),
+		AdmissionUsage:    make(map[admissionpb.WorkPriority]*AdmissionUsageInfo),
+		RegionUsage:       make(map[string]*RegionUsageInfo),
+		StorageClassUsage: make(map[string]*StorageClassUsageInfo),
+		MoveReasons:       make(map[MoveReason]int64),
+	}
+}
+
+// RecordQueueLengths records the current length of the replicate, lease and
+// split queues of the store given.
+func (u *ClusterUsageInfo) RecordQueueLengths(storeID StoreID, replicate, lease, split int) {
+	su := u.storeRef(storeID)
+	su.ReplicateQueueLength = int64(replicate)
+	su.LeaseQueueLength = int64(lease)
+	su.SplitQueueLength = int64(split)
+}
+
+// RecordEvaluatedRanges records the number of ranges the allocator of the
+// store given evaluated in the current tick.
+func (u *ClusterUsageInfo) RecordEvaluatedRanges(storeID StoreID, evaluated int) {
+	u.storeRef(storeID).EvaluatedRanges = int64(evaluated)
+}
+
+func (u *ClusterUsageInfo) admissionRef(priority admissionpb.WorkPriority) *AdmissionUsageInfo {
+	var a *AdmissionUsageInfo
+	var ok bool
+	if a, ok = u.AdmissionUsage[priority]; !ok {
+		a = &AdmissionUsageInfo{}
+		u.AdmissionUsage[priority] = a
+	}
+	return a
+}
+
+func (u *ClusterUsageInfo) regionRef(region string) *RegionUsageInfo {
+	var r *RegionUsageInfo
+	var ok bool
+	if r, ok = u.RegionUsage[region]; !ok {
+		r = &RegionUsageInfo{}
+		u.RegionUsage[region] = r
 	}
+	return r
+}
+
+func (u *ClusterUsageInfo) storageClassRef(class string) *StorageClassUsageInfo {
+	var c *StorageClassUsageInfo
+	var ok bool
+	if c, ok = u.StorageClassUsage[class]; !ok {
+		c = &StorageClassUsageInfo{ReadLatency: make(LatencyDistribution)}
+		u.StorageClassUsage[class] = c
+	}
+	return c
 }
 
 func (u *ClusterUsageInfo) storeRef(storeID StoreID) *StoreUsageInfo {
 	var s *StoreUsageInfo
 	var ok bool
 	if s, ok = u.StoreUsage[storeID]; !ok {
-		s = &StoreUsageInfo{}
+		s = &StoreUsageInfo{
+			WriteLatency: make(LatencyDistribution),
+			ReadLatency:  make(LatencyDistribution),
+		}
 		u.StoreUsage[storeID] = s
 	}
 	return s
@@ -0,0 +1,49 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package state
+
+import (
+	"unsafe"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+)
+
+// mapEntryOverhead approximates the per-entry overhead of a Go map, beyond the
+// size of the key and value, i.e. the bucket metadata and load factor slack.
+const mapEntryOverhead = 16
+
+// MemoryEstimate returns the approximate number of bytes of memory used by the
+// state. It accounts for the nodes, stores, ranges and replicas in the state,
+// along with their descriptors and load, which dominate the footprint of large
+// simulations. It doesn't account for the memory used by the allocator and
+// store pool of each store.
+func (s *state) MemoryEstimate() int64 {
+	var size int64
+	for _, n := range s.nodes {
+		size += int64(unsafe.Sizeof(*n)) + int64(n.desc.Size()) +
+			int64(len(n.stores))*int64(unsafe.Sizeof(StoreID(0)))
+	}
+	for _, st := range s.stores {
+		size += int64(unsafe.Sizeof(*st)) + int64(st.desc.Size()) +
+			int64(len(st.replicas))*(int64(unsafe.Sizeof(RangeID(0))+unsafe.Sizeof(ReplicaID(0)))+mapEntryOverhead)
+	}
+	replicaSize := int64(unsafe.Sizeof(replica{})+unsafe.Sizeof(roachpb.ReplicaDescriptor{})) +
+		mapEntryOverhead
+	for _, r := range s.ranges.rangeMap {
+		size += int64(unsafe.Sizeof(*r)) + int64(r.desc.Size()) + int64(r.config.Size()) +
+			mapEntryOverhead
+		size += int64(len(r.replicas)) * replicaSize
+	}
+	for range s.load {
+		size += int64(unsafe.Sizeof(ReplicaLoadCounter{})) + mapEntryOverhead
+	}
+	return size
+}
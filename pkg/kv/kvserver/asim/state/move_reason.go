@@ -0,0 +1,66 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package state
+
+// MoveReason is the reason a replica was moved from one store to another.
+type MoveReason int
+
+const (
+	// MoveReasonOther is the reason of moves which aren't attributed to any of
+	// the reasons below.
+	MoveReasonOther MoveReason = iota
+	// MoveReasonDecommission moves a replica off a decommissioning store.
+	MoveReasonDecommission
+	// MoveReasonDead moves a replica off a dead store.
+	MoveReasonDead
+	// MoveReasonDiversity moves a replica to improve the locality diversity of
+	// the range.
+	MoveReasonDiversity
+	// MoveReasonCapacity moves a replica off a store whose disk is too full.
+	MoveReasonCapacity
+	// MoveReasonLoad moves a replica to balance the load across stores.
+	MoveReasonLoad
+	// MoveReasonCountBalance moves a replica to balance the replica counts
+	// across stores.
+	MoveReasonCountBalance
+	numMoveReasons
+)
+
+// MoveReasons are all the move reasons, in the order they are reported.
+var MoveReasons = func() []MoveReason {
+	reasons := make([]MoveReason, 0, numMoveReasons)
+	for r := MoveReason(0); r < numMoveReasons; r++ {
+		reasons = append(reasons, r)
+	}
+	return reasons
+}()
+
+// String returns the name of the move reason.
+func (r MoveReason) String() string {
+	switch r {
+	case MoveReasonOther:
+		return "other"
+	case MoveReasonDecommission:
+		return "decommission"
+	case MoveReasonDead:
+		return "dead"
+	case MoveReasonDiversity:
+		return "diversity"
+	case MoveReasonCapacity:
+		return "capacity"
+	case MoveReasonLoad:
+		return "load"
+	case MoveReasonCountBalance:
+		return "count-balance"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package state
+
+import "github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/workload"
+
+// regionTierKey is the locality tier key which identifies the region of a
+// node.
+const regionTierKey = "region"
+
+// ReadLocalityInfo contains the number of reads which could be served from a
+// replica in the same region as the client issuing them, and the number which
+// had to be served from another region. Only reads from clients with a known
+// region are counted.
+type ReadLocalityInfo struct {
+	LocalReads       int64
+	CrossRegionReads int64
+}
+
+// LocalReadFraction returns the fraction of reads which could be served from
+// a replica in the same region as the client issuing them. It returns zero
+// when no reads were counted.
+func (r ReadLocalityInfo) LocalReadFraction() float64 {
+	total := r.LocalReads + r.CrossRegionReads
+	if total == 0 {
+		return 0
+	}
+	return float64(r.LocalReads) / float64(total)
+}
+
+// storeRegion returns the region of the node the store with ID storeID is on,
+// or an empty string if the node has no region locality tier.
+func (s *state) storeRegion(storeID StoreID) string {
+	store, ok := s.stores[storeID]
+	if !ok {
+		return ""
+	}
+	region, _ := s.nodes[store.nodeID].desc.Locality.Find(regionTierKey)
+	return region
+}
+
+// recordReadLocality records whether the reads of the load event could be
+// served from a replica of the range in the same region as the client. When
+// the leaseholder is not in the client's region, a follower replica in the
+// client's region is assumed to serve the read.
+//
+// NB: The load itself is still applied to the leaseholder, only the routing
+// of the reads is recorded.
+func (s *state) recordReadLocality(rng *rng, le workload.LoadEvent) {
+	if le.Reads == 0 || le.ClientRegion == "" {
+		return
+	}
+	usage := &s.usageInfo.ReadLocality
+	for storeID := range rng.replicas {
+		if s.storeRegion(storeID) == le.ClientRegion {
+			usage.LocalReads += le.Reads
+			return
+		}
+	}
+	usage.CrossRegionReads += le.Reads
+}
@@ -0,0 +1,64 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package state
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/config"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/workload"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReadLocality asserts that reads from clients in the same region as the
+// replicas of a range are counted as local, whilst reads which must be served
+// from another region are counted as cross-region.
+func TestReadLocality(t *testing.T) {
+	s := NewState(config.DefaultSimulationSettings())
+	addStore := func(region string) StoreID {
+		n := s.AddNode()
+		s.SetNodeLocality(n.NodeID(), roachpb.Locality{
+			Tiers: []roachpb.Tier{{Key: "region", Value: region}},
+		})
+		store, _ := s.AddStore(n.NodeID())
+		return store.StoreID()
+	}
+	east1, east2 := addStore("us-east"), addStore("us-east")
+	west := addStore("us-west")
+
+	// Pin the data to us-east.
+	_, r, _ := s.SplitRange(100)
+	s.AddReplica(r.RangeID(), east1, roachpb.VOTER_FULL)
+	s.AddReplica(r.RangeID(), east2, roachpb.VOTER_FULL)
+
+	read := workload.LoadBatch{{Key: 100, Reads: 10, ReadSize: 100, ClientRegion: "us-east"}}
+	s.ApplyLoad(read)
+	usage := s.ClusterUsageInfo().ReadLocality
+	require.Equal(t, ReadLocalityInfo{LocalReads: 10}, usage)
+	require.Equal(t, 1.0, usage.LocalReadFraction())
+
+	// Reads from a client without a known region aren't counted.
+	s.ApplyLoad(workload.LoadBatch{{Key: 100, Reads: 10, ReadSize: 100}})
+	require.Equal(t, ReadLocalityInfo{LocalReads: 10}, s.ClusterUsageInfo().ReadLocality)
+
+	// Force the replicas of the range out of us-east, reads from us-east
+	// clients must now be served cross-region.
+	s.AddReplica(r.RangeID(), west, roachpb.VOTER_FULL)
+	require.True(t, s.TransferLease(r.RangeID(), west))
+	require.True(t, s.RemoveReplica(r.RangeID(), east1))
+	require.True(t, s.RemoveReplica(r.RangeID(), east2))
+
+	s.ApplyLoad(read)
+	usage = s.ClusterUsageInfo().ReadLocality
+	require.Equal(t, ReadLocalityInfo{LocalReads: 10, CrossRegionReads: 10}, usage)
+	require.Equal(t, 0.5, usage.LocalReadFraction())
+}
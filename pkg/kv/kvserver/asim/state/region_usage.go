@@ -0,0 +1,62 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package state
+
+import (
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/workload"
+)
+
+// RegionUsageInfo contains the number of bytes read and written by the stores
+// of a region.
+type RegionUsageInfo struct {
+	ReadBytes  int64
+	WriteBytes int64
+}
+
+// recordRegionUsage records the bytes of the load event against the regions
+// of the stores serving it. As with the store usage, writes are added to the
+// region of every replica of the range, whilst reads are added to the region
+// of the leaseholder only. Stores without a region aren't counted.
+func (s *state) recordRegionUsage(rng *rng, le workload.LoadEvent) {
+	for storeID, repl := range rng.replicas {
+		region := s.storeRegion(storeID)
+		if region == "" {
+			continue
+		}
+		usage := s.usageInfo.regionRef(region)
+		usage.WriteBytes += le.WriteSize
+		if repl.holdsLease {
+			usage.ReadBytes += le.ReadSize
+		}
+	}
+}
+
+// Regions returns the distinct regions of the nodes in the state given, in
+// ascending order. Nodes without a region locality tier are ignored.
+func Regions(s State) []string {
+	seen := make(map[string]struct{})
+	var regions []string
+	for _, n := range s.Nodes() {
+		region, ok := n.Descriptor().Locality.Find(regionTierKey)
+		if !ok {
+			continue
+		}
+		if _, ok := seen[region]; ok {
+			continue
+		}
+		seen[region] = struct{}{}
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	return regions
+}
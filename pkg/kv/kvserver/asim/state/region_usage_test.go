@@ -0,0 +1,72 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package state
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/config"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/workload"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRegionUsage asserts that the bytes read and written are attributed to
+// the regions of the stores serving them. With a regional-by-row style setup,
+// where the rows of each region are pinned to a range whose replicas are all
+// in that region, the traffic of each region's clients stays within the
+// region.
+func TestRegionUsage(t *testing.T) {
+	s := NewState(config.DefaultSimulationSettings())
+	addStore := func(region string) StoreID {
+		n := s.AddNode()
+		s.SetNodeLocality(n.NodeID(), roachpb.Locality{
+			Tiers: []roachpb.Tier{{Key: "region", Value: region}},
+		})
+		store, _ := s.AddStore(n.NodeID())
+		return store.StoreID()
+	}
+	east1, east2 := addStore("us-east"), addStore("us-east")
+	west1, west2 := addStore("us-west"), addStore("us-west")
+	addStore("eu")
+	require.Equal(t, []string{"eu", "us-east", "us-west"}, Regions(s))
+
+	// The rows of us-east are in [100, 200), those of us-west in [200, ...).
+	_, east, _ := s.SplitRange(100)
+	_, west, _ := s.SplitRange(200)
+	s.AddReplica(east.RangeID(), east1, roachpb.VOTER_FULL)
+	s.AddReplica(east.RangeID(), east2, roachpb.VOTER_FULL)
+	s.AddReplica(west.RangeID(), west1, roachpb.VOTER_FULL)
+	s.AddReplica(west.RangeID(), west2, roachpb.VOTER_FULL)
+
+	s.ApplyLoad(workload.LoadBatch{
+		{Key: 100, Reads: 1, ReadSize: 10, Writes: 1, WriteSize: 1, ClientRegion: "us-east"},
+		{Key: 200, Reads: 1, ReadSize: 20, Writes: 1, WriteSize: 2, ClientRegion: "us-west"},
+	})
+	usage := s.ClusterUsageInfo().RegionUsage
+	// Writes are applied to both replicas of each range, reads to the
+	// leaseholder only.
+	require.Equal(t, map[string]*RegionUsageInfo{
+		"us-east": {ReadBytes: 10, WriteBytes: 2},
+		"us-west": {ReadBytes: 20, WriteBytes: 4},
+	}, usage)
+
+	// Once a replica of the us-east rows is moved to eu, the writes to those
+	// rows are no longer local to us-east.
+	eu := StoreID(5)
+	s.AddReplica(east.RangeID(), eu, roachpb.VOTER_FULL)
+	require.True(t, s.RemoveReplica(east.RangeID(), east2))
+	s.ApplyLoad(workload.LoadBatch{
+		{Key: 100, Writes: 1, WriteSize: 1, ClientRegion: "us-east"},
+	})
+	require.Equal(t, &RegionUsageInfo{ReadBytes: 10, WriteBytes: 3}, usage["us-east"])
+	require.Equal(t, &RegionUsageInfo{WriteBytes: 1}, usage["eu"])
+}
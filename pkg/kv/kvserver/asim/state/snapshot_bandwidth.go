@@ -0,0 +1,140 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package state
+
+import "time"
+
+// snapshotTransfer is a snapshot sent from one store to another, at a constant
+// rate over [start, end).
+type snapshotTransfer struct {
+	sender, receiver StoreID
+	start, end       time.Time
+	// rate is in bytes per second.
+	rate float64
+}
+
+// snapshotBandwidth models the bandwidth of stores for sending and receiving
+// the snapshots of replica changes. Each store sends one snapshot at a time,
+// and receives one snapshot at a time, so a snapshot is transferred once both
+// its sender and receiver are free, at the lower of their rates. It tracks the
+// bytes each store sent and received per tick, to report the utilization of
+// their bandwidth.
+type snapshotBandwidth struct {
+	interval           time.Duration
+	sendRate, recvRate int64
+	sendFreeAt         map[StoreID]time.Time
+	recvFreeAt         map[StoreID]time.Time
+	transfers          []snapshotTransfer
+	lastTick           time.Time
+}
+
+func newSnapshotBandwidth(interval time.Duration, sendRate, recvRate int64) *snapshotBandwidth {
+	return &snapshotBandwidth{
+		interval:   interval,
+		sendRate:   sendRate,
+		recvRate:   recvRate,
+		sendFreeAt: make(map[StoreID]time.Time),
+		recvFreeAt: make(map[StoreID]time.Time),
+	}
+}
+
+// rate returns the rate in bytes per second of a snapshot transfer, which is
+// the lower of the send and receive rates that are limited.
+func (sb *snapshotBandwidth) rate() float64 {
+	switch {
+	case sb.sendRate <= 0:
+		return float64(sb.recvRate)
+	case sb.recvRate <= 0 || sb.sendRate < sb.recvRate:
+		return float64(sb.sendRate)
+	default:
+		return float64(sb.recvRate)
+	}
+}
+
+// transfer schedules the transfer of a snapshot of the given size from sender
+// to receiver, which may start at tick, and returns the time at which the
+// transfer completes.
+func (sb *snapshotBandwidth) transfer(
+	tick time.Time, sender, receiver StoreID, bytes int64,
+) time.Time {
+	start := tick
+	if freeAt := sb.sendFreeAt[sender]; freeAt.After(start) {
+		start = freeAt
+	}
+	if freeAt := sb.recvFreeAt[receiver]; freeAt.After(start) {
+		start = freeAt
+	}
+	rate := sb.rate()
+	end := start.Add(time.Duration(float64(bytes) / rate * float64(time.Second)))
+	sb.sendFreeAt[sender] = end
+	sb.recvFreeAt[receiver] = end
+	sb.transfers = append(sb.transfers, snapshotTransfer{
+		sender:   sender,
+		receiver: receiver,
+		start:    start,
+		end:      end,
+		rate:     rate,
+	})
+	return end
+}
+
+// tick records the fraction of the send and receive bandwidth of each store
+// used since the last tick into the cluster usage, and forgets the transfers
+// which completed.
+func (sb *snapshotBandwidth) tick(tick time.Time, usage *ClusterUsageInfo) {
+	from := sb.lastTick
+	if from.IsZero() {
+		from = tick.Add(-sb.interval)
+	}
+	sb.lastTick = tick
+	elapsed := tick.Sub(from).Seconds()
+
+	for _, u := range usage.StoreUsage {
+		u.SnapshotSendUtilization = 0
+		u.SnapshotRecvUtilization = 0
+	}
+	if elapsed <= 0 {
+		return
+	}
+
+	sent := make(map[StoreID]float64)
+	rcvd := make(map[StoreID]float64)
+	remaining := sb.transfers[:0]
+	for _, t := range sb.transfers {
+		start, end := t.start, t.end
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(tick) {
+			end = tick
+		}
+		if end.After(start) {
+			bytes := end.Sub(start).Seconds() * t.rate
+			sent[t.sender] += bytes
+			rcvd[t.receiver] += bytes
+		}
+		if t.end.After(tick) {
+			remaining = append(remaining, t)
+		}
+	}
+	sb.transfers = remaining
+
+	if sb.sendRate > 0 {
+		for storeID, bytes := range sent {
+			usage.storeRef(storeID).SnapshotSendUtilization = bytes / (float64(sb.sendRate) * elapsed)
+		}
+	}
+	if sb.recvRate > 0 {
+		for storeID, bytes := range rcvd {
+			usage.storeRef(storeID).SnapshotRecvUtilization = bytes / (float64(sb.recvRate) * elapsed)
+		}
+	}
+}
@@ -93,6 +93,10 @@ type State interface {
 	AddStore(NodeID) (Store, bool)
 	// SetStoreCapacity sets the capacity in bytes of the store with ID storeID.
 	SetStoreCapacity(StoreID, int64)
+	// SetStoreAttrs sets the attributes of the store with ID storeID, e.g. the
+	// type of its storage. The attributes are part of the store's descriptor,
+	// so they may be targeted by the constraints of a span config.
+	SetStoreAttrs(StoreID, roachpb.Attributes)
 	// CanAddReplica returns whether adding a replica for the Range with ID RangeID
 	// to the Store with ID StoreID is valid.
 	CanAddReplica(RangeID, StoreID) bool
@@ -107,6 +111,14 @@ type State interface {
 	// RemoveReplica modifies the state to remove a Replica with the ID
 	// ReplicaID. It fails if this Replica does not exist.
 	RemoveReplica(RangeID, StoreID) bool
+	// QueueReplicaGC records that the replica for the Range with ID RangeID,
+	// which was removed from the Store with ID StoreID, is pending garbage
+	// collection for the configured replica GC delay. Until then, the store
+	// still accounts for the given bytes used by the replica.
+	QueueReplicaGC(RangeID, StoreID, int64)
+	// GCPendingReplicas returns the number of replicas removed from the Store
+	// with ID StoreID, which are pending garbage collection.
+	GCPendingReplicas(StoreID) int
 	// SplitRange splits the Range which contains Key in [StartKey, EndKey).
 	// The Range is partitioned into [StartKey, Key), [Key, EndKey) and
 	// returned. The right hand side of this split, is the new Range. If any
@@ -143,8 +155,15 @@ type State interface {
 	// This modifies specifically the leaseholder replica's RangeUsageInfo for
 	// the targets of the LoadEvent. The store which contains this replica is
 	// likewise modified to reflect this in it's Capacity, held in the
-	// StoreDescriptor.
+	// StoreDescriptor. When store admission is enabled, load events which are
+	// not admitted by the leaseholder store are shed, in order of ascending
+	// priority.
 	ApplyLoad(workload.LoadBatch)
+	// ApplyBackgroundLoad applies one tick worth of the configured background
+	// read and write load to every store, independent of the workload. When
+	// store admission is enabled, the background writes consume the write
+	// admission tokens of each store, reducing the foreground load admitted.
+	ApplyBackgroundLoad()
 	// RangeUsageInfo returns the usage information for the Range with ID RangeID
 	// on the store with ID StoreID.
 	RangeUsageInfo(RangeID, StoreID) allocator.RangeUsageInfo
@@ -161,6 +180,40 @@ type State interface {
 	// SetNodeLiveness sets the liveness status of the node with ID NodeID to be
 	// the status given.
 	SetNodeLiveness(NodeID, livenesspb.NodeLivenessStatus)
+	// SetStoreWriteStall sets whether the writes of the store with ID StoreID
+	// are stalled. While stalled, writes to ranges whose leaseholder is on the
+	// store are rejected and the store's node fails its liveness heartbeats.
+	SetStoreWriteStall(StoreID, bool)
+	// StoreWriteStalled returns whether the writes of the store with ID
+	// StoreID are currently stalled.
+	StoreWriteStalled(StoreID) bool
+	// RangeUnavailable returns whether the Range with ID RangeID has lost
+	// quorum, i.e. a majority of its voting replicas are on dead nodes. An
+	// unavailable range cannot make progress and rejects any load applied to
+	// it.
+	RangeUnavailable(RangeID) bool
+	// RangeReacquiringLease returns whether the Range with ID RangeID is
+	// reacquiring its lease, after the node of its leaseholder restarted. A
+	// range reacquiring its lease rejects any load applied to it.
+	RangeReacquiringLease(RangeID) bool
+	// RangeLeaseless returns whether the Range with ID RangeID has no active
+	// leaseholder, in the transient state between a lease transfer, or the
+	// restart of the node of its leaseholder, and the lease being acquired.
+	// A leaseless range rejects any load applied to it.
+	RangeLeaseless(RangeID) bool
+	// MemoryEstimate returns the approximate number of bytes of memory used by
+	// the state, which grows with the number of ranges and replicas.
+	MemoryEstimate() int64
+	// RangeReplicationTarget returns the number of replicas the Range with ID
+	// RangeID is configured to have, as given by its span config. It returns
+	// false if the range doesn't exist.
+	RangeReplicationTarget(RangeID) (int, bool)
+	// Validate returns an error describing the first invariant of the state
+	// found to be violated, if any. Every range must have at least one replica
+	// and a non-negative size, exactly one replica of every range must hold its
+	// lease, and the replicas of a range must agree with the replicas tracked
+	// by each store.
+	Validate() error
 	// NodeLivenessFn returns a function, that when called will return the
 	// liveness of the Node with ID NodeID.
 	// TODO(kvoli): Find a better home for this method, required by the
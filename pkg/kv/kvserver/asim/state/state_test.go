@@ -15,6 +15,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/config"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/workload"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/liveness/livenesspb"
@@ -290,6 +291,40 @@ func TestWorkloadApply(t *testing.T) {
 	require.Equal(t, expectedLoad, sc3)
 }
 
+// TestSecondaryIndexWriteAmplification asserts that each logical write fans out
+// to a KV write per index, which scales the write accounting of the stores
+// and the size of the range written to, whilst reads are unaffected.
+func TestSecondaryIndexWriteAmplification(t *testing.T) {
+	apply := func(indexes int) (StoreUsageInfo, int64) {
+		settings := config.DefaultSimulationSettings()
+		settings.SecondaryIndexes = indexes
+		s := NewState(settings)
+		n := s.AddNode()
+		store, _ := s.AddStore(n.NodeID())
+		_, r, _ := s.SplitRange(100)
+		s.AddReplica(r.RangeID(), store.StoreID(), roachpb.VOTER_FULL)
+		sizeBefore := r.Size()
+		s.ApplyLoad(workload.LoadBatch{
+			{Key: 100, Writes: 10, WriteSize: 1000, Reads: 5, ReadSize: 500},
+		})
+		return *s.ClusterUsageInfo().StoreUsage[store.StoreID()], r.Size() - sizeBefore
+	}
+
+	noIndexes, noIndexesGrowth := apply(0)
+	require.Equal(t, int64(10), noIndexes.WriteKeys)
+	require.Equal(t, int64(1000), noIndexes.WriteBytes)
+	require.Equal(t, int64(1000), noIndexesGrowth)
+
+	// With three secondary indexes, each logical write incurs four KV writes:
+	// one to the primary index and one to each secondary index.
+	threeIndexes, threeIndexesGrowth := apply(3)
+	require.Equal(t, 4*noIndexes.WriteKeys, threeIndexes.WriteKeys)
+	require.Equal(t, 4*noIndexes.WriteBytes, threeIndexes.WriteBytes)
+	require.Equal(t, 4*noIndexesGrowth, threeIndexesGrowth)
+	require.Equal(t, noIndexes.ReadKeys, threeIndexes.ReadKeys)
+	require.Equal(t, noIndexes.ReadBytes, threeIndexes.ReadBytes)
+}
+
 // TestReplicaLoadQPS asserts that the rated replica load accounting maintains
 // the average per second corresponding to the tick clock.
 func TestReplicaLoadQPS(t *testing.T) {
@@ -629,6 +664,209 @@ func TestSetNodeLiveness(t *testing.T) {
 	})
 }
 
+// TestRangeUnavailable asserts that a range is unavailable once a majority of
+// its voting replicas are on dead nodes, and that load applied to an
+// unavailable range is rejected.
+func TestRangeUnavailable(t *testing.T) {
+	s := NewStateEvenDistribution(5, 1, 3, 10000, config.DefaultSimulationSettings())
+	r := s.RangeFor(100)
+	rangeID := r.RangeID()
+	replicas := r.Replicas()
+	require.Len(t, replicas, 3)
+
+	killReplica := func(repl Replica) {
+		store, ok := s.Store(repl.StoreID())
+		require.True(t, ok)
+		s.SetNodeLiveness(store.NodeID(), livenesspb.NodeLivenessStatus_DEAD)
+	}
+
+	require.False(t, s.RangeUnavailable(rangeID))
+
+	// Losing a single replica out of three is within the failure tolerance of
+	// the range.
+	killReplica(replicas[0])
+	require.False(t, s.RangeUnavailable(rangeID))
+
+	// Losing a second replica, a majority, causes the range to lose quorum.
+	killReplica(replicas[1])
+	require.True(t, s.RangeUnavailable(rangeID))
+
+	// Load applied to the unavailable range should be rejected.
+	s.ApplyLoad(workload.LoadBatch{workload.LoadEvent{Key: 100, Writes: 1, WriteSize: 10}})
+	lhStore, ok := s.LeaseholderStore(rangeID)
+	require.True(t, ok)
+	require.Equal(t, float64(0), s.RangeUsageInfo(rangeID, lhStore.StoreID()).WritesPerSecond)
+	require.Equal(t, int64(0), r.Size())
+
+	// Restarting one of the dead nodes restores quorum.
+	store, _ := s.Store(replicas[1].StoreID())
+	s.SetNodeLiveness(store.NodeID(), livenesspb.NodeLivenessStatus_LIVE)
+	require.False(t, s.RangeUnavailable(rangeID))
+}
+
+// TestLeaseTransferStall asserts that the load on a range is rejected for the
+// configured number of ticks after its lease is transferred, and that the
+// rejected bytes are accounted against the new leaseholder.
+func TestLeaseTransferStall(t *testing.T) {
+	settings := config.DefaultSimulationSettings()
+	settings.LeaseTransferStallTicks = 2
+	s := NewStateEvenDistribution(3, 1, 3, 10000, settings)
+	r := s.RangeFor(100)
+	lhStore, ok := s.LeaseholderStore(r.RangeID())
+	require.True(t, ok)
+	var target StoreID
+	for _, repl := range r.Replicas() {
+		if repl.StoreID() != lhStore.StoreID() {
+			target = repl.StoreID()
+			break
+		}
+	}
+
+	tick := settings.StartTime
+	applyTick := func() {
+		tick = tick.Add(settings.TickInterval)
+		s.TickClock(tick)
+		s.ApplyLoad(workload.LoadBatch{workload.LoadEvent{Key: 100, Writes: 1, WriteSize: 10, Reads: 1, ReadSize: 5}})
+	}
+	usage := func(storeID StoreID) *StoreUsageInfo {
+		return s.ClusterUsageInfo().StoreUsage[storeID]
+	}
+
+	// Writes are accounted against every replica, reads only against the
+	// leaseholder.
+	applyTick()
+	require.Equal(t, int64(10), usage(target).WriteBytes)
+	require.Equal(t, int64(5), usage(lhStore.StoreID()).ReadBytes)
+	require.True(t, s.TransferLease(r.RangeID(), target))
+
+	// The load of the two ticks following the transfer is rejected.
+	applyTick()
+	applyTick()
+	require.Equal(t, int64(10), usage(target).WriteBytes)
+	require.Equal(t, int64(0), usage(target).ReadBytes)
+	require.Equal(t, int64(30), usage(target).LeaseStallBytes)
+
+	// Once the stall is over, the new leaseholder serves the load.
+	applyTick()
+	require.Equal(t, int64(20), usage(target).WriteBytes)
+	require.Equal(t, int64(5), usage(target).ReadBytes)
+	require.Equal(t, int64(30), usage(target).LeaseStallBytes)
+	require.Equal(t, int64(0), usage(lhStore.StoreID()).LeaseStallBytes)
+}
+
+// TestLeaseReacquisitionOnRestart asserts that the ranges whose leaseholder
+// is on a restarted node reject their load for the configured number of ticks
+// after the restart, while their leases are reacquired.
+func TestLeaseReacquisitionOnRestart(t *testing.T) {
+	settings := config.DefaultSimulationSettings()
+	settings.LeaseReacquisitionTicks = 2
+	s := NewStateEvenDistribution(3, 1, 3, 10000, settings)
+	r := s.RangeFor(100)
+	lhStore, ok := s.LeaseholderStore(r.RangeID())
+	require.True(t, ok)
+
+	tick := settings.StartTime
+	applyTick := func() {
+		tick = tick.Add(settings.TickInterval)
+		s.TickClock(tick)
+		s.ApplyLoad(workload.LoadBatch{workload.LoadEvent{Key: 100, Writes: 1, WriteSize: 10, Reads: 1, ReadSize: 5}})
+	}
+	readBytes := func() int64 {
+		return s.ClusterUsageInfo().StoreUsage[lhStore.StoreID()].ReadBytes
+	}
+
+	// Marking a live node as live again isn't a restart.
+	s.SetNodeLiveness(lhStore.NodeID(), livenesspb.NodeLivenessStatus_LIVE)
+	require.False(t, s.RangeReacquiringLease(r.RangeID()))
+	applyTick()
+	require.Equal(t, int64(5), readBytes())
+
+	// The node fails and restarts, the load of the two ticks following the
+	// restart is rejected.
+	s.SetNodeLiveness(lhStore.NodeID(), livenesspb.NodeLivenessStatus_DEAD)
+	s.SetNodeLiveness(lhStore.NodeID(), livenesspb.NodeLivenessStatus_LIVE)
+	require.True(t, s.RangeReacquiringLease(r.RangeID()))
+	applyTick()
+	applyTick()
+	require.True(t, s.RangeReacquiringLease(r.RangeID()))
+	require.Equal(t, int64(5), readBytes())
+
+	// Once the lease is reacquired, the leaseholder serves the load.
+	applyTick()
+	require.False(t, s.RangeReacquiringLease(r.RangeID()))
+	require.Equal(t, int64(10), readBytes())
+}
+
+// TestSetStoreWriteStall asserts that a node is only restarted when its last
+// stalled store recovers, and that unstalling a store which isn't stalled
+// leaves its node's liveness unchanged.
+func TestSetStoreWriteStall(t *testing.T) {
+	s := LoadClusterInfo(
+		ClusterInfoWithStoreCount(2, 1),
+		config.DefaultSimulationSettings(),
+	)
+	liveFn := s.NodeLivenessFn()
+
+	// Unstalling a store which was never stalled doesn't revive its node,
+	// which is dead for another reason.
+	s.SetNodeLiveness(1, livenesspb.NodeLivenessStatus_DEAD)
+	s.SetStoreWriteStall(1, false)
+	require.Equal(t, livenesspb.NodeLivenessStatus_DEAD, liveFn(1))
+
+	// A stalled store fails its node's liveness until it recovers.
+	s.SetStoreWriteStall(2, true)
+	require.Equal(t, livenesspb.NodeLivenessStatus_DEAD, liveFn(2))
+	s.SetStoreWriteStall(2, false)
+	require.Equal(t, livenesspb.NodeLivenessStatus_LIVE, liveFn(2))
+}
+
+// TestReplicaGCDelay asserts that a store accounts for the disk space of a
+// replica removed from it until the replica GC delay has elapsed.
+func TestReplicaGCDelay(t *testing.T) {
+	settings := config.DefaultSimulationSettings()
+	settings.ReplicaGCDelay = time.Minute
+	s := NewStateEvenDistribution(2, 1, 1, 10000, settings)
+	r := s.RangeFor(100)
+	s.SetRangeBytes(r.RangeID(), 1000)
+	lhStore, ok := s.LeaseholderStore(r.RangeID())
+	require.True(t, ok)
+	source := lhStore.StoreID()
+	target := StoreID(1)
+	if source == target {
+		target = 2
+	}
+	used := func(storeID StoreID) int64 {
+		return s.StoreDescriptors(false /* cached */, storeID)[0].Capacity.Used
+	}
+
+	change := ReplicaChange{
+		RangeID: r.RangeID(),
+		Author:  source,
+		Changes: []kvpb.ReplicationChange{
+			testRC(source, roachpb.REMOVE_VOTER),
+			testRC(target, roachpb.ADD_VOTER),
+		},
+	}
+	change.Apply(s)
+	_, ok = r.Replica(source)
+	require.False(t, ok)
+
+	// The removed replica still uses disk space on the source store until it
+	// is garbage collected.
+	require.Equal(t, 1, s.GCPendingReplicas(source))
+	require.Equal(t, int64(1250), used(source))
+	require.Equal(t, int64(1250), used(target))
+
+	s.TickClock(settings.StartTime.Add(30 * time.Second))
+	require.Equal(t, 1, s.GCPendingReplicas(source))
+	require.Equal(t, int64(1250), used(source))
+
+	s.TickClock(settings.StartTime.Add(time.Minute))
+	require.Equal(t, 0, s.GCPendingReplicas(source))
+	require.Equal(t, int64(0), used(source))
+	require.Equal(t, int64(1250), used(target))
+}
+
 // TestTopology loads cluster configurations and checks that the topology
 // output matches expectations.
 func TestTopology(t *testing.T) {
@@ -717,3 +955,158 @@ func TestCapacityOverride(t *testing.T) {
 	// reason.
 	require.Equal(t, 500.0, capacity.WritesPerSecond)
 }
+
+// TestCapacityLeaseholderCPU asserts that the request CPU of a range is
+// accounted to the CPU capacity of the store holding its lease, and follows the
+// lease when it is transferred.
+func TestCapacityLeaseholderCPU(t *testing.T) {
+	settings := config.DefaultSimulationSettings()
+	s := NewState(settings)
+	start := settings.StartTime
+	cpu := int64(1000)
+
+	var stores []StoreID
+	for i := 0; i < 3; i++ {
+		n := s.AddNode()
+		store, _ := s.AddStore(n.NodeID())
+		stores = append(stores, store.StoreID())
+	}
+	keys := []Key{100, 200, 300}
+	var rangeIDs []RangeID
+	for _, key := range keys {
+		_, rng, _ := s.SplitRange(key)
+		rangeIDs = append(rangeIDs, rng.RangeID())
+		// The first replica added holds the lease, place every lease on s1.
+		for _, storeID := range stores {
+			s.AddReplica(rng.RangeID(), storeID, roachpb.VOTER_FULL)
+		}
+	}
+
+	applyCPU := func(from, to int64) {
+		for i := from; i < to; i++ {
+			for _, key := range keys {
+				s.ApplyLoad(workload.LoadBatch{workload.LoadEvent{
+					Key:        int64(key) + 1,
+					Reads:      1,
+					RequestCPU: cpu,
+				}})
+			}
+			s.TickClock(OffsetTick(start, i))
+		}
+	}
+	storeCPU := func() []float64 {
+		var ret []float64
+		for _, desc := range s.StoreDescriptors(false /* cached */, stores...) {
+			ret = append(ret, desc.Capacity.CPUPerSecond)
+		}
+		return ret
+	}
+
+	s.TickClock(start)
+	for _, rangeID := range rangeIDs {
+		testingResetLoad(s, rangeID)
+	}
+	applyCPU(1, 100)
+	require.InDeltaSlice(t, []float64{3 * float64(cpu), 0, 0}, storeCPU(), 1)
+
+	// Move the leases of r2 and r3 to s2 and s3, the request CPU of each range
+	// should then be accounted to a different store.
+	require.True(t, s.TransferLease(rangeIDs[1], stores[1]))
+	require.True(t, s.TransferLease(rangeIDs[2], stores[2]))
+	testingResetLoad(s, rangeIDs[0])
+	applyCPU(100, 200)
+	require.InDeltaSlice(t, []float64{float64(cpu), float64(cpu), float64(cpu)}, storeCPU(), 1)
+}
+
+// TestValidate asserts that validating a state returns a descriptive error
+// when the state is inconsistent.
+func TestValidate(t *testing.T) {
+	settings := config.DefaultSimulationSettings()
+
+	// leaseholderAndFollower returns the store of the leaseholder replica and
+	// the store of another replica of the range given.
+	leaseholderAndFollower := func(rng *rng) (leaseholder, follower StoreID) {
+		for storeID, repl := range rng.replicas {
+			if repl.holdsLease {
+				leaseholder = storeID
+			} else {
+				follower = storeID
+			}
+		}
+		return leaseholder, follower
+	}
+
+	testCases := []struct {
+		desc     string
+		corrupt  func(s *state, rng *rng)
+		expected string
+	}{
+		{
+			desc:    "valid",
+			corrupt: func(s *state, rng *rng) {},
+		},
+		{
+			desc: "no replicas",
+			corrupt: func(s *state, rng *rng) {
+				rng.replicas = map[StoreID]*replica{}
+			},
+			expected: "r1 has no replicas",
+		},
+		{
+			desc: "negative size",
+			corrupt: func(s *state, rng *rng) {
+				rng.size = -10
+			},
+			expected: "r1 has a negative size of -10 bytes",
+		},
+		{
+			desc: "replica not tracked by store",
+			corrupt: func(s *state, rng *rng) {
+				_, follower := leaseholderAndFollower(rng)
+				delete(s.stores[follower].replicas, rng.rangeID)
+			},
+			expected: "r1 has replica \\d+ on s\\d+, which isn't tracked by the store",
+		},
+		{
+			desc: "multiple leaseholders",
+			corrupt: func(s *state, rng *rng) {
+				_, follower := leaseholderAndFollower(rng)
+				rng.replicas[follower].holdsLease = true
+			},
+			expected: "r1 has replica \\d+ on s\\d+ holding its lease, but its leaseholder is replica \\d+",
+		},
+		{
+			desc: "no leaseholder",
+			corrupt: func(s *state, rng *rng) {
+				leaseholder, _ := leaseholderAndFollower(rng)
+				rng.replicas[leaseholder].holdsLease = false
+			},
+			expected: "r1 has 0 replicas holding its lease, expected 1",
+		},
+		{
+			desc: "store tracks a range which doesn't exist",
+			corrupt: func(s *state, rng *rng) {
+				s.stores[1].replicas[RangeID(1000)] = 1
+			},
+			expected: "s1 tracks a replica of r1000, which doesn't exist",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			s := NewStateEvenDistribution(3, 3, 3, 100, settings).(*state)
+			require.NoError(t, s.Validate())
+
+			rng, ok := s.rng(1)
+			require.True(t, ok)
+			tc.corrupt(s, rng)
+			err := s.Validate()
+			if tc.expected == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Regexp(t, tc.expected, err.Error())
+		})
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package state
+
+import (
+	"sort"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/workload"
+)
+
+// StorageClassUsageInfo contains the number of reads served by the stores of
+// a storage class, along with the distribution of their latency.
+type StorageClassUsageInfo struct {
+	Reads       int64
+	ReadLatency LatencyDistribution
+}
+
+// storageClass returns the storage class of the store with ID storeID, which
+// is the first of its attributes with a configured read latency, or an empty
+// string if it has none.
+func (s *state) storageClass(storeID StoreID) string {
+	store, ok := s.stores[storeID]
+	if !ok {
+		return ""
+	}
+	for _, attr := range store.desc.Attrs.Attrs {
+		if _, ok := s.settings.StorageClassReadLatency[attr]; ok {
+			return attr
+		}
+	}
+	return ""
+}
+
+// storageReadLatency returns the latency added by the storage of the store
+// with ID storeID to the reads it serves.
+func (s *state) storageReadLatency(storeID StoreID) time.Duration {
+	return s.settings.StorageClassReadLatency[s.storageClass(storeID)]
+}
+
+// readReplica returns the store of the replica which serves a read of the
+// range from a client in the given region, along with the latency of the
+// read. The read is served by the replica with the lowest latency, that is
+// the closest replica to the client, whose storage adds the least latency.
+// The leaseholder serves the read when no other replica is faster.
+func (s *state) readReplica(
+	rng *rng, leaseholder StoreID, clientRegion string,
+) (StoreID, time.Duration) {
+	readLatency := func(storeID StoreID) time.Duration {
+		return s.regionLatency(clientRegion, s.storeRegion(storeID)) + s.storageReadLatency(storeID)
+	}
+	// NB: The replicas are visited in order of their store, so that the
+	// replica serving the read is deterministic amongst equally fast ones.
+	storeIDs := make([]StoreID, 0, len(rng.replicas))
+	for storeID := range rng.replicas {
+		storeIDs = append(storeIDs, storeID)
+	}
+	sort.Slice(storeIDs, func(i, j int) bool { return storeIDs[i] < storeIDs[j] })
+
+	served, latency := leaseholder, readLatency(leaseholder)
+	for _, storeID := range storeIDs {
+		if l := readLatency(storeID); l < latency {
+			served, latency = storeID, l
+		}
+	}
+	return served, latency
+}
+
+// recordStorageClassReads records the reads of the load event, and their
+// latency, against the storage class of the store serving them, consistent
+// with recordLatency. Reads served by a store without a storage class aren't
+// counted.
+//
+// NB: As with read locality, the load itself is still applied to the
+// leaseholder, only the routing of the reads is recorded.
+func (s *state) recordStorageClassReads(rng *rng, le workload.LoadEvent) {
+	if le.Reads == 0 || len(s.settings.StorageClassReadLatency) == 0 {
+		return
+	}
+	store, ok := s.LeaseholderStore(rng.rangeID)
+	if !ok {
+		return
+	}
+	served, latency := s.readReplica(rng, store.StoreID(), le.ClientRegion)
+	class := s.storageClass(served)
+	if class == "" {
+		return
+	}
+	usage := s.usageInfo.storageClassRef(class)
+	usage.Reads += le.Reads
+	usage.ReadLatency.Record(latency, le.Reads)
+}
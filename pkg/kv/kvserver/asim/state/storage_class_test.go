@@ -0,0 +1,71 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/config"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/workload"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStorageClassReadRouting asserts that reads served by stores of a slower
+// storage class observe a higher latency, and that reads are routed to a
+// replica on a faster storage class once the range has one.
+func TestStorageClassReadRouting(t *testing.T) {
+	settings := config.DefaultSimulationSettings()
+	settings.StorageClassReadLatency = map[string]time.Duration{
+		"ssd": 100 * time.Microsecond,
+		"hdd": 5 * time.Millisecond,
+	}
+	s := NewState(settings)
+	addStore := func(attrs ...string) StoreID {
+		n := s.AddNode()
+		store, _ := s.AddStore(n.NodeID())
+		s.SetStoreAttrs(store.StoreID(), roachpb.Attributes{Attrs: attrs})
+		return store.StoreID()
+	}
+	hdd1, hdd2 := addStore("hdd"), addStore("rack1", "hdd")
+	ssd := addStore("ssd")
+
+	// The range is only on HDD stores, the first of which holds the lease.
+	_, r, _ := s.SplitRange(100)
+	s.AddReplica(r.RangeID(), hdd1, roachpb.VOTER_FULL)
+	s.AddReplica(r.RangeID(), hdd2, roachpb.VOTER_FULL)
+
+	read := workload.LoadBatch{{Key: 100, Reads: 10, ReadSize: 100}}
+	s.ApplyLoad(read)
+	usage := s.ClusterUsageInfo().StorageClassUsage
+	require.Len(t, usage, 1)
+	require.Equal(t, int64(10), usage["hdd"].Reads)
+	hddLatency := usage["hdd"].ReadLatency.Percentile(50)
+	require.Equal(t, settings.IntraRegionLatency+5*time.Millisecond, hddLatency)
+
+	// Once the range has a replica on an SSD store, reads are routed to it,
+	// even though the lease remains on an HDD store.
+	s.AddReplica(r.RangeID(), ssd, roachpb.VOTER_FULL)
+	s.ApplyLoad(read)
+	usage = s.ClusterUsageInfo().StorageClassUsage
+	require.Equal(t, int64(10), usage["hdd"].Reads)
+	require.Equal(t, int64(10), usage["ssd"].Reads)
+	ssdLatency := usage["ssd"].ReadLatency.Percentile(50)
+	require.Equal(t, settings.IntraRegionLatency+100*time.Microsecond, ssdLatency)
+	require.Greater(t, hddLatency, ssdLatency)
+
+	// The read latency of the leaseholder reflects the routing.
+	leaseholderLatency := s.ClusterUsageInfo().StoreUsage[hdd1].ReadLatency
+	require.Equal(t, int64(20), leaseholderLatency.Count())
+	require.Equal(t, ssdLatency, leaseholderLatency.Percentile(50))
+	require.Equal(t, hddLatency, leaseholderLatency.Percentile(100))
+}
@@ -0,0 +1,75 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package state
+
+import "github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/workload"
+
+// txnOutcome accumulates the writes of a transaction in a load batch.
+type txnOutcome struct {
+	ranges     map[RangeID]struct{}
+	aborted    bool
+	writeKeys  int64
+	writeBytes int64
+}
+
+// applyTxns treats the load events in the load batch which share a TxnID as a
+// single unit. A transaction which writes to an unavailable range is aborted
+// and none of its load events are applied, otherwise all of them are. The
+// outcome of each transaction is recorded in the cluster usage info. The
+// returned load batch contains the non-transactional load events and those of
+// committed transactions, in the same order as the load batch given.
+//
+// NB: Store admission is applied before transactions are considered, and
+// admits or rejects the load events of a transaction together, see admit.
+func (s *state) applyTxns(lb workload.LoadBatch) workload.LoadBatch {
+	txns := make(map[int64]*txnOutcome)
+	for _, le := range lb {
+		if le.TxnID == 0 {
+			continue
+		}
+		txn, ok := txns[le.TxnID]
+		if !ok {
+			txn = &txnOutcome{ranges: make(map[RangeID]struct{})}
+			txns[le.TxnID] = txn
+		}
+		rng := s.rangeFor(Key(le.Key))
+		txn.ranges[rng.rangeID] = struct{}{}
+		txn.aborted = txn.aborted || s.rangeUnavailable(rng)
+		txn.writeKeys += le.Writes
+		txn.writeBytes += le.WriteSize
+	}
+	if len(txns) == 0 {
+		return lb
+	}
+
+	usage := &s.usageInfo.TxnUsage
+	for _, txn := range txns {
+		if txn.aborted {
+			usage.Aborted++
+			continue
+		}
+		usage.Committed++
+		if len(txn.ranges) > 1 {
+			usage.RangeSpanning++
+		}
+		usage.WriteKeys += txn.writeKeys
+		usage.WriteBytes += txn.writeBytes
+	}
+
+	committed := make(workload.LoadBatch, 0, len(lb))
+	for _, le := range lb {
+		if le.TxnID != 0 && txns[le.TxnID].aborted {
+			continue
+		}
+		committed = append(committed, le)
+	}
+	return committed
+}
@@ -0,0 +1,72 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package state
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/config"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/asim/workload"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/liveness/livenesspb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/stretchr/testify/require"
+)
+
+// TestApplyTxns asserts that the load events of a transaction are applied as
+// a unit: either every write of the transaction is accounted for, or none are.
+func TestApplyTxns(t *testing.T) {
+	s := NewState(config.DefaultSimulationSettings())
+	n1 := s.AddNode()
+	s1, _ := s.AddStore(n1.NodeID())
+	n2 := s.AddNode()
+	s2, _ := s.AddStore(n2.NodeID())
+
+	// Range r1 [100, 200) is on s1 and range r2 [200, ∞) is on s2.
+	_, r1, _ := s.SplitRange(100)
+	_, r2, _ := s.SplitRange(200)
+	s.AddReplica(r1.RangeID(), s1.StoreID(), roachpb.VOTER_FULL)
+	s.AddReplica(r2.RangeID(), s2.StoreID(), roachpb.VOTER_FULL)
+
+	// Transaction 1 writes to both ranges, transaction 2 writes only to r1.
+	s.ApplyLoad(workload.LoadBatch{
+		{Key: 100, Writes: 1, WriteSize: 10, TxnID: 1},
+		{Key: 150, Writes: 1, WriteSize: 20, TxnID: 2},
+		{Key: 200, Writes: 1, WriteSize: 30, TxnID: 1},
+	})
+	usage := s.ClusterUsageInfo()
+	require.Equal(t, TxnUsageInfo{
+		Committed:     2,
+		RangeSpanning: 1,
+		WriteKeys:     3,
+		WriteBytes:    60,
+	}, usage.TxnUsage)
+	require.Equal(t, int64(30), usage.StoreUsage[s1.StoreID()].WriteBytes)
+	require.Equal(t, int64(30), usage.StoreUsage[s2.StoreID()].WriteBytes)
+
+	// When r2 is unavailable, a transaction writing to both ranges is aborted
+	// and none of its writes are applied, including the write to r1.
+	s.SetNodeLiveness(n2.NodeID(), livenesspb.NodeLivenessStatus_DEAD)
+	s.ApplyLoad(workload.LoadBatch{
+		{Key: 100, Writes: 1, WriteSize: 10, TxnID: 3},
+		{Key: 150, Writes: 1, WriteSize: 20, TxnID: 4},
+		{Key: 200, Writes: 1, WriteSize: 30, TxnID: 3},
+	})
+	usage = s.ClusterUsageInfo()
+	require.Equal(t, TxnUsageInfo{
+		Committed:     3,
+		Aborted:       1,
+		RangeSpanning: 1,
+		WriteKeys:     4,
+		WriteBytes:    80,
+	}, usage.TxnUsage)
+	require.Equal(t, int64(50), usage.StoreUsage[s1.StoreID()].WriteBytes)
+	require.Equal(t, int64(30), usage.StoreUsage[s2.StoreID()].WriteBytes)
+}
@@ -15,6 +15,8 @@ import (
 	"math/rand"
 	"sort"
 	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/util/admission/admissionpb"
 )
 
 // LoadEvent represent a key access that generates load against the database.
@@ -26,6 +28,20 @@ type LoadEvent struct {
 	WriteSize int64
 	Reads     int64
 	ReadSize  int64
+	// RequestCPU is the CPU time, in nanoseconds, which the leaseholder of the
+	// key spends evaluating the reads and writes of the load event.
+	RequestCPU int64
+	// Priority is the admission priority of the load event. When a store is
+	// overloaded, lower priority load is shed before higher priority load.
+	Priority admissionpb.WorkPriority
+	// TxnID identifies the transaction the load event belongs to. Load events
+	// which share a non-zero TxnID must be applied atomically, either all of
+	// them are applied or none are. A zero TxnID indicates a
+	// non-transactional point operation.
+	TxnID int64
+	// ClientRegion is the region of the client which issued the load event. It
+	// is empty if the region of the client is unknown.
+	ClientRegion string
 }
 
 // LoadBatch is a sorted list of load events.
@@ -54,6 +70,52 @@ type Generator interface {
 	Tick(tick time.Time) LoadBatch
 }
 
+// priorityGenerator wraps a Generator, assigning every load event it
+// generates the same admission priority.
+type priorityGenerator struct {
+	Generator
+	priority admissionpb.WorkPriority
+}
+
+// NewPriorityGenerator returns a generator that generates the same load events
+// as the generator given, with the admission priority given.
+func NewPriorityGenerator(gen Generator, priority admissionpb.WorkPriority) Generator {
+	return &priorityGenerator{Generator: gen, priority: priority}
+}
+
+// Tick returns the load events up till time tick, from the last time the
+// workload generator was called.
+func (pg *priorityGenerator) Tick(tick time.Time) LoadBatch {
+	lb := pg.Generator.Tick(tick)
+	for i := range lb {
+		lb[i].Priority = pg.priority
+	}
+	return lb
+}
+
+// clientRegionGenerator wraps a Generator, assigning every load event it
+// generates the same client region.
+type clientRegionGenerator struct {
+	Generator
+	region string
+}
+
+// NewClientRegionGenerator returns a generator that generates the same load
+// events as the generator given, issued by clients in the region given.
+func NewClientRegionGenerator(gen Generator, region string) Generator {
+	return &clientRegionGenerator{Generator: gen, region: region}
+}
+
+// Tick returns the load events up till time tick, from the last time the
+// workload generator was called.
+func (cg *clientRegionGenerator) Tick(tick time.Time) LoadBatch {
+	lb := cg.Generator.Tick(tick)
+	for i := range lb {
+		lb[i].ClientRegion = cg.region
+	}
+	return lb
+}
+
 // RandomGenerator generates random operations within some limits.
 type RandomGenerator struct {
 	seed           int64
@@ -162,6 +224,165 @@ func (rwg *RandomGenerator) Tick(maxTime time.Time) LoadBatch {
 	return ret
 }
 
+// MixGenerator generates a random mix of reads and writes, at a total rate of
+// operations per second. Each operation is independently a read with the
+// configured probability, so that a workload may be swept from read-heavy to
+// write-heavy by varying a single fraction.
+type MixGenerator struct {
+	keyGenerator KeyGenerator
+	rand         *rand.Rand
+	lastRun      time.Time
+	opsPerSecond float64
+	readFraction float64
+	maxSize      int
+	minSize      int
+}
+
+// NewMixGenerator returns a generator that generates opsPerSec operations per
+// second, of which readFraction are reads and the remainder writes. Reads and
+// writes draw their size from the same range, so that the ratio of bytes read
+// to bytes written follows the ratio of operations.
+func NewMixGenerator(
+	opsPerSec float64,
+	readFraction float64,
+	start time.Time,
+	keyGenerator KeyGenerator,
+	maxSize int,
+	minSize int,
+) Generator {
+	if opsPerSec <= 0 {
+		panic(fmt.Sprintf("ops per second (%f) must be greater than 0", opsPerSec))
+	}
+	if readFraction < 0 || readFraction > 1 {
+		panic(fmt.Sprintf("read fraction (%f) must be within [0, 1]", readFraction))
+	}
+	if maxSize < minSize {
+		panic(fmt.Sprintf("max size (%d) must be at least min size (%d)", maxSize, minSize))
+	}
+	return &MixGenerator{
+		keyGenerator: keyGenerator,
+		rand:         keyGenerator.rand(),
+		lastRun:      start,
+		opsPerSecond: opsPerSec,
+		readFraction: readFraction,
+		maxSize:      maxSize,
+		minSize:      minSize,
+	}
+}
+
+// Tick returns the load events up till time tick, from the last time the
+// workload generator was called.
+func (mg *MixGenerator) Tick(maxTime time.Time) LoadBatch {
+	elapsed := maxTime.Sub(mg.lastRun).Seconds()
+	count := int(elapsed * mg.opsPerSecond)
+	// Similar to the RandomGenerator, don't bump the last run time unless at
+	// least one operation is generated.
+	if count < 1 {
+		return LoadBatch{}
+	}
+
+	// Reads and writes to the same key are aggregated into a single load
+	// event, as in the RandomGenerator.
+	next := make(map[int64]LoadEvent)
+	for op := 0; op < count; op++ {
+		size := int64(mg.rand.Intn(mg.maxSize-mg.minSize+1) + mg.minSize)
+		if mg.rand.Float64() < mg.readFraction {
+			key := mg.keyGenerator.readKey()
+			event := next[key]
+			event.Reads++
+			event.ReadSize += size
+			next[key] = event
+		} else {
+			key := mg.keyGenerator.writeKey()
+			event := next[key]
+			event.Writes++
+			event.WriteSize += size
+			next[key] = event
+		}
+	}
+
+	ret := make(LoadBatch, 0, len(next))
+	for k, v := range next {
+		v.Key = k
+		ret = append(ret, v)
+	}
+
+	sort.Sort(ret)
+	mg.lastRun = maxTime
+	return ret
+}
+
+// TxnGenerator generates transactions, where each transaction writes to a
+// number of keys atomically.
+type TxnGenerator struct {
+	keyGenerator  KeyGenerator
+	rand          *rand.Rand
+	lastRun       time.Time
+	txnsPerSecond float64
+	keysPerTxn    int
+	maxSize       int
+	minSize       int
+	lastTxnID     int64
+}
+
+// NewTxnGenerator returns a generator that generates transactions at the rate
+// given, where each transaction writes to keysPerTxn keys. The load events of
+// a transaction share the same TxnID, so that they are applied as a unit.
+func NewTxnGenerator(
+	start time.Time, keyGenerator KeyGenerator, rate float64, keysPerTxn int, maxSize int, minSize int,
+) Generator {
+	if keysPerTxn < 1 {
+		panic(fmt.Sprintf("keys per txn (%d) must be at least 1", keysPerTxn))
+	}
+	return &TxnGenerator{
+		keyGenerator:  keyGenerator,
+		rand:          keyGenerator.rand(),
+		lastRun:       start,
+		txnsPerSecond: rate,
+		keysPerTxn:    keysPerTxn,
+		maxSize:       maxSize,
+		minSize:       minSize,
+	}
+}
+
+// Tick returns the load events up till time tick, from the last time the
+// workload generator was called.
+func (tg *TxnGenerator) Tick(maxTime time.Time) LoadBatch {
+	elapsed := maxTime.Sub(tg.lastRun).Seconds()
+	count := int(elapsed * tg.txnsPerSecond)
+	// Similar to the RandomGenerator, don't bump the last run time unless at
+	// least one transaction is generated.
+	if count < 1 {
+		return LoadBatch{}
+	}
+
+	ret := make(LoadBatch, 0, count*tg.keysPerTxn)
+	for txn := 0; txn < count; txn++ {
+		tg.lastTxnID++
+		// Writes to the same key within a transaction are aggregated into a
+		// single load event. Writes to the same key from different
+		// transactions are not.
+		next := make(map[int64]LoadEvent, tg.keysPerTxn)
+		for write := 0; write < tg.keysPerTxn; write++ {
+			size := int64(tg.rand.Intn(tg.maxSize-tg.minSize+1) + tg.minSize)
+			key := tg.keyGenerator.writeKey()
+			event := next[key]
+			event.Writes++
+			event.WriteSize += size
+			next[key] = event
+		}
+		for k, v := range next {
+			v.Key = k
+			v.TxnID = tg.lastTxnID
+			ret = append(ret, v)
+		}
+	}
+
+	sort.Stable(ret)
+	tg.lastRun = maxTime
+	return ret
+}
+
 // KeyGenerator generates read and write keys.
 type KeyGenerator interface {
 	writeKey() int64
@@ -162,3 +162,67 @@ func TestRandWorkloadGenerator(t *testing.T) {
 		require.Equal(t, math.Round(tc.readRatio*100), math.Round((float64(stats.reads)/float64(stats.reads+stats.writes))*100))
 	}
 }
+
+// TestTxnWorkloadGenerator asserts that the transaction generator emits the
+// expected number of transactions, each writing to the given number of keys,
+// with every write of a transaction sharing the same TxnID.
+func TestTxnWorkloadGenerator(t *testing.T) {
+	const (
+		rate       = 10
+		keysPerTxn = 4
+		maxSize    = 256
+		minSize    = 128
+	)
+	start := time.Date(2022, 03, 21, 11, 0, 0, 0, time.UTC)
+	keyGenerator := NewUniformKeyGen(0, 10000, rand.New(rand.NewSource(testingSeed)))
+	gen := NewTxnGenerator(start, keyGenerator, rate, keysPerTxn, maxSize, minSize)
+
+	var lb LoadBatch
+	for i := 1; i <= 10; i++ {
+		lb = append(lb, gen.Tick(start.Add(time.Duration(i)*time.Second))...)
+	}
+
+	writes := make(map[int64]int64)
+	for _, le := range lb {
+		require.NotZero(t, le.TxnID)
+		require.Zero(t, le.Reads)
+		require.GreaterOrEqual(t, le.WriteSize, le.Writes*minSize)
+		require.LessOrEqual(t, le.WriteSize, le.Writes*maxSize)
+		writes[le.TxnID] += le.Writes
+	}
+	require.Len(t, writes, rate*10)
+	for txnID, txnWrites := range writes {
+		require.Equal(t, int64(keysPerTxn), txnWrites, "txn %d", txnID)
+	}
+}
+
+// TestMixWorkloadGenerator asserts that the mix generator emits the configured
+// number of operations, and that the ratio of bytes read to bytes written
+// follows the configured read fraction.
+func TestMixWorkloadGenerator(t *testing.T) {
+	const (
+		opsPerSec    = 100
+		readFraction = 0.9
+		maxSize      = 256
+		minSize      = 128
+		tolerance    = 0.02
+	)
+	start := time.Date(2022, 03, 21, 11, 0, 0, 0, time.UTC)
+	keyGenerator := NewUniformKeyGen(0, 10000, rand.New(rand.NewSource(testingSeed)))
+	gen := NewMixGenerator(opsPerSec, readFraction, start, keyGenerator, maxSize, minSize)
+
+	var reads, writes, readBytes, writeBytes int64
+	for i := 1; i <= 100; i++ {
+		for _, le := range gen.Tick(start.Add(time.Duration(i) * time.Second)) {
+			require.Zero(t, le.TxnID)
+			reads += le.Reads
+			writes += le.Writes
+			readBytes += le.ReadSize
+			writeBytes += le.WriteSize
+		}
+	}
+
+	require.Equal(t, int64(opsPerSec*100), reads+writes)
+	require.InDelta(t, readFraction, float64(reads)/float64(reads+writes), tolerance)
+	require.InDelta(t, readFraction, float64(readBytes)/float64(readBytes+writeBytes), tolerance)
+}
@@ -1223,6 +1223,19 @@ func NewServer(cfg Config, stopper *stop.Stopper) (*Server, error) {
 		systemTenantNameContainer,
 		pgPreServer.SendRoutingError,
 	)
+
+	// Tell the status server how to reach the secondary tenant servers
+	// running in this process.
+	sStatus.setTenantStatusServerLookup(func(
+		ctx context.Context, tenantID roachpb.TenantID,
+	) (*statusServer, error) {
+		srv, err := sc.getServerByTenantID(ctx, tenantID)
+		if err != nil {
+			return nil, err
+		}
+		return srv.getStatusServer(), nil
+	})
+
 	drain.serverCtl = sc
 
 	// Create the debug API server.
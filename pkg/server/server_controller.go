@@ -46,6 +46,9 @@ type onDemandServer interface {
 
 	// getRPCAddr() returns the RPC address for this server.
 	getRPCAddr() string
+
+	// getStatusServer returns the status server of this server.
+	getStatusServer() *statusServer
 }
 
 // serverController manages a fleet of multiple servers side-by-side.
@@ -167,6 +170,10 @@ func (t *tenantServerWrapper) getRPCAddr() string {
 	return t.server.sqlServer.cfg.AdvertiseAddr
 }
 
+func (t *tenantServerWrapper) getStatusServer() *statusServer {
+	return t.server.tenantStatus
+}
+
 func (t *tenantServerWrapper) getTenantID() roachpb.TenantID {
 	return t.server.sqlCfg.TenantID
 }
@@ -227,6 +234,10 @@ func (s *systemServerWrapper) getRPCAddr() string {
 	return s.server.sqlServer.cfg.AdvertiseAddr
 }
 
+func (s *systemServerWrapper) getStatusServer() *statusServer {
+	return s.server.status.statusServer
+}
+
 func (s *systemServerWrapper) getTenantID() roachpb.TenantID {
 	return s.server.cfg.TenantID
 }
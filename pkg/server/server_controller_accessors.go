@@ -32,6 +32,19 @@ func (c *serverController) getServer(
 	return nil, errors.Mark(errors.Newf("no server for tenant %q", tenantName), errNoTenantServerRunning)
 }
 
+// getServerByTenantID retrieves a reference to the current server for the
+// given tenant ID.
+func (c *serverController) getServerByTenantID(
+	ctx context.Context, tenantID roachpb.TenantID,
+) (onDemandServer, error) {
+	for _, s := range c.getServers() {
+		if s.getTenantID() == tenantID {
+			return s, nil
+		}
+	}
+	return nil, errors.Mark(errors.Newf("no server for tenant %d", tenantID), errNoTenantServerRunning)
+}
+
 type noTenantServerRunning struct{}
 
 func (noTenantServerRunning) Error() string { return "no server for tenant" }
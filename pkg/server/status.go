@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"os"
 	"os/exec"
@@ -66,6 +67,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
 	"github.com/cockroachdb/cockroach/pkg/sql/roleoption"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/catconstants"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlinstance"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlstats/insights"
 	"github.com/cockroachdb/cockroach/pkg/util"
@@ -85,6 +87,7 @@ import (
 	"github.com/cockroachdb/errors"
 	"github.com/google/pprof/profile"
 	gwruntime "github.com/grpc-ecosystem/grpc-gateway/runtime"
+	gwutil "github.com/grpc-ecosystem/grpc-gateway/utilities"
 	raft "go.etcd.io/raft/v3"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -490,6 +493,12 @@ type statusServer struct {
 	// 256 concurrent queries actively running on a node, then it would
 	// take 2^16 seconds (18 hours) to hit any one of them.
 	cancelSemaphore *quotapool.IntPool
+
+	// tenantStatusServerLookup returns the status server of the secondary
+	// tenant with the given ID, which runs in this process. It is only set on
+	// the system tenant, to let it access the execution details of a secondary
+	// tenant's jobs.
+	tenantStatusServerLookup func(context.Context, roachpb.TenantID) (*statusServer, error)
 }
 
 // systemStatusServer is an extension of the standard
@@ -658,6 +667,15 @@ func (s *statusServer) setStmtDiagnosticsRequester(sr StmtDiagnosticsRequester)
 	s.stmtDiagnosticsRequester = sr
 }
 
+// setTenantStatusServerLookup is used to provide the status server with a
+// way to reach the status servers of the secondary tenants running in this
+// process. It is only set on the system tenant.
+func (s *statusServer) setTenantStatusServerLookup(
+	fn func(context.Context, roachpb.TenantID) (*statusServer, error),
+) {
+	s.tenantStatusServerLookup = fn
+}
+
 // RegisterService registers the GRPC service.
 func (s *statusServer) RegisterService(g *grpc.Server) {
 	serverpb.RegisterStatusServer(g, s)
@@ -669,9 +687,160 @@ func (s *statusServer) RegisterGateway(
 	ctx context.Context, mux *gwruntime.ServeMux, conn *grpc.ClientConn,
 ) error {
 	ctx = s.AnnotateCtx(ctx)
+
+	// Register the /_status/job_profiler_execution_details/{job_id}/{filename}
+	// endpoint ahead of the endpoints defined in the proto, so that it can
+	// additionally serve HTTP range requests for large execution details.
+	executionDetailPattern := gwruntime.MustPattern(gwruntime.NewPattern(
+		1, /* version */
+		[]int{
+			int(gwutil.OpLitPush), 0, int(gwutil.OpLitPush), 1,
+			int(gwutil.OpPush), 0, int(gwutil.OpConcatN), 1, int(gwutil.OpCapture), 2,
+			int(gwutil.OpPush), 0, int(gwutil.OpConcatN), 1, int(gwutil.OpCapture), 3},
+		[]string{"_status", "job_profiler_execution_details", "job_id", "filename"},
+		"", /* verb */
+	))
+	client := serverpb.NewStatusClient(conn)
+	mux.Handle("GET", executionDetailPattern, func(
+		w http.ResponseWriter, req *http.Request, pathParams map[string]string,
+	) {
+		serveJobProfilerExecutionDetail(mux, client, w, req, pathParams)
+	})
+
 	return serverpb.RegisterStatusHandler(ctx, mux, conn)
 }
 
+// serveJobProfilerExecutionDetail serves the execution detail requested by
+// the job_profiler_execution_details endpoint. When the request does not have
+// a Range header, the GetJobProfilerExecutionDetailResponse is returned as it
+// would be by the gateway. Otherwise, the raw bytes of the requested range of
+// the execution detail are returned with a 206 Partial Content status, so that
+// clients can resume partial downloads of large files. A single range is read
+// from the execution detail by the RPC, so only the chunks of the file which
+// overlap it are decompressed and sent; requests for multiple ranges, which
+// clients rarely make, are served from the whole file.
+func serveJobProfilerExecutionDetail(
+	mux *gwruntime.ServeMux,
+	client serverpb.StatusClient,
+	w http.ResponseWriter,
+	req *http.Request,
+	pathParams map[string]string,
+) {
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+	_, outboundMarshaler := gwruntime.MarshalerForRequest(mux, req)
+
+	jobID, err := strconv.ParseInt(pathParams["job_id"], 10, 64)
+	if err != nil {
+		gwruntime.HTTPError(ctx, mux, outboundMarshaler, w, req,
+			status.Errorf(codes.InvalidArgument, "invalid job_id: %v", err))
+		return
+	}
+	filename, ok := pathParams["filename"]
+	if !ok {
+		gwruntime.HTTPError(ctx, mux, outboundMarshaler, w, req,
+			status.Errorf(codes.InvalidArgument, "missing filename"))
+		return
+	}
+	// The tenant_id query parameter is populated by the generated gateway
+	// route, which this handler takes the place of.
+	var tenantID uint64
+	if param := req.URL.Query().Get("tenant_id"); param != "" {
+		if tenantID, err = strconv.ParseUint(param, 10, 64); err != nil {
+			gwruntime.HTTPError(ctx, mux, outboundMarshaler, w, req,
+				status.Errorf(codes.InvalidArgument, "invalid tenant_id: %v", err))
+			return
+		}
+	}
+
+	rctx, err := gwruntime.AnnotateContext(ctx, mux, req)
+	if err != nil {
+		gwruntime.HTTPError(ctx, mux, outboundMarshaler, w, req, err)
+		return
+	}
+	edReq := &serverpb.GetJobProfilerExecutionDetailRequest{
+		JobId:    jobID,
+		Filename: filename,
+		TenantID: tenantID,
+	}
+	rangeHeader := req.Header.Get("Range")
+	start, length, singleRange := parseSingleByteRange(rangeHeader)
+	if singleRange {
+		edReq.RangeStart, edReq.RangeLength = start, length
+	}
+	resp, err := client.GetJobProfilerExecutionDetails(rctx, edReq)
+	if err != nil {
+		gwruntime.HTTPError(ctx, mux, outboundMarshaler, w, req, err)
+		return
+	}
+
+	if rangeHeader == "" {
+		gwruntime.ForwardResponseMessage(ctx, mux, outboundMarshaler, w, req, resp)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if !singleRange {
+		// http.ServeContent validates the requested ranges, responding with
+		// the bytes at the requested offsets, or 416 Range Not Satisfiable if
+		// the ranges are invalid.
+		http.ServeContent(w, req, filename, time.Time{}, bytes.NewReader(resp.Data))
+		return
+	}
+	if start < 0 {
+		start += resp.TotalSize
+		if start < 0 {
+			start = 0
+		}
+	}
+	if start >= resp.TotalSize {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", resp.TotalSize))
+		http.Error(w, "requested range not satisfiable", http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+	w.Header().Set("Content-Range",
+		fmt.Sprintf("bytes %d-%d/%d", start, start+int64(len(resp.Data))-1, resp.TotalSize))
+	w.Header().Set("Content-Length", strconv.Itoa(len(resp.Data)))
+	w.WriteHeader(http.StatusPartialContent)
+	if _, err := w.Write(resp.Data); err != nil {
+		log.Warningf(ctx, "failed to write execution detail %s of job %d: %v", filename, jobID, err)
+	}
+}
+
+// parseSingleByteRange parses a Range header which requests a single range of
+// bytes, returning the offset of the range and its length. The offset of a
+// suffix range, e.g. "bytes=-500", is negative, relative to the end of the
+// file, and the length of an open-ended range, e.g. "bytes=500-", extends to
+// the end of the file.
+func parseSingleByteRange(header string) (start, length int64, ok bool) {
+	spec := strings.TrimPrefix(header, "bytes=")
+	if spec == header || strings.Contains(spec, ",") {
+		return 0, 0, false
+	}
+	startStr, endStr, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return 0, 0, false
+	}
+	if startStr == "" {
+		n, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, false
+		}
+		return -n, n, true
+	}
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, false
+	}
+	if endStr == "" {
+		return start, math.MaxInt64, true
+	}
+	end, err := strconv.ParseInt(endStr, 10, 64)
+	if err != nil || end < start {
+		return 0, 0, false
+	}
+	return start, end - start + 1, true
+}
+
 // RegisterService registers the GRPC service.
 func (s *systemStatusServer) RegisterService(g *grpc.Server) {
 	serverpb.RegisterStatusServer(g, s)
@@ -4138,8 +4307,23 @@ func (s *statusServer) GetJobProfilerExecutionDetails(
 	}
 
 	jobID := jobspb.JobID(req.JobId)
-	execCfg := s.sqlServer.execCfg
+	execCfg, tenantStatus, err := s.jobProfilerExecCfg(ctx, req.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if tenantStatus != nil {
+		fwdReq := *req
+		fwdReq.TenantID = 0
+		return tenantStatus.GetJobProfilerExecutionDetails(ctx, &fwdReq)
+	}
 	eb := sql.MakeJobProfilerExecutionDetailsBuilder(execCfg.SQLStatusServer, execCfg.InternalDB, jobID)
+	if req.RangeLength > 0 {
+		data, totalSize, err := eb.ReadExecutionDetailRange(ctx, req.Filename, req.RangeStart, req.RangeLength)
+		if err != nil {
+			return nil, err
+		}
+		return &serverpb.GetJobProfilerExecutionDetailResponse{Data: data, TotalSize: totalSize}, nil
+	}
 	data, err := eb.ReadExecutionDetail(ctx, req.Filename)
 	if err != nil {
 		return nil, err
@@ -4148,7 +4332,7 @@ func (s *statusServer) GetJobProfilerExecutionDetails(
 }
 
 // ListJobProfilerExecutionDetails lists all the stored execution details for a
-// given job ID.
+// given job ID, or only those collected with a given label.
 func (s *statusServer) ListJobProfilerExecutionDetails(
 	ctx context.Context, req *serverpb.ListJobProfilerExecutionDetailsRequest,
 ) (*serverpb.ListJobProfilerExecutionDetailsResponse, error) {
@@ -4160,11 +4344,151 @@ func (s *statusServer) ListJobProfilerExecutionDetails(
 	}
 
 	jobID := jobspb.JobID(req.JobId)
-	execCfg := s.sqlServer.execCfg
+	execCfg, tenantStatus, err := s.jobProfilerExecCfg(ctx, req.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if tenantStatus != nil {
+		fwdReq := *req
+		fwdReq.TenantID = 0
+		return tenantStatus.ListJobProfilerExecutionDetails(ctx, &fwdReq)
+	}
 	eb := sql.MakeJobProfilerExecutionDetailsBuilder(execCfg.SQLStatusServer, execCfg.InternalDB, jobID)
-	files, err := eb.ListExecutionDetailFiles(ctx)
+	fileDetails, err := eb.ListExecutionDetailFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(fileDetails))
+	filtered := fileDetails[:0]
+	for _, f := range fileDetails {
+		if req.Label != "" && f.Label != req.Label {
+			continue
+		}
+		files = append(files, f.Name)
+		filtered = append(filtered, f)
+	}
+	return &serverpb.ListJobProfilerExecutionDetailsResponse{
+		Files:       files,
+		FileDetails: filtered,
+	}, nil
+}
+
+// RequestJobProfilerExecutionDetails collects the execution details of a given
+// job ID, and persists them alongside the job's other execution details.
+func (s *statusServer) RequestJobProfilerExecutionDetails(
+	ctx context.Context, req *serverpb.RequestJobProfilerExecutionDetailsRequest,
+) (*serverpb.RequestJobProfilerExecutionDetailsResponse, error) {
+	ctx = s.AnnotateCtx(ctx)
+	_, err := s.privilegeChecker.requireAdminUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	jobID := jobspb.JobID(req.JobId)
+	execCfg, tenantStatus, err := s.jobProfilerExecCfg(ctx, req.TenantID)
 	if err != nil {
 		return nil, err
 	}
-	return &serverpb.ListJobProfilerExecutionDetailsResponse{Files: files}, nil
+	if tenantStatus != nil {
+		fwdReq := *req
+		fwdReq.TenantID = 0
+		return tenantStatus.RequestJobProfilerExecutionDetails(ctx, &fwdReq)
+	}
+	opts := eval.ExecutionDetailsOptions{Label: req.Label}
+	if err := sql.RequestJobExecutionDetails(ctx, execCfg, jobID, opts); err != nil {
+		return nil, err
+	}
+	return &serverpb.RequestJobProfilerExecutionDetailsResponse{}, nil
+}
+
+// JobProfilerArtifactTypes describes the types of execution detail files which
+// may be collected for a job.
+func (s *statusServer) JobProfilerArtifactTypes(
+	ctx context.Context, req *serverpb.JobProfilerArtifactTypesRequest,
+) (*serverpb.JobProfilerArtifactTypesResponse, error) {
+	ctx = s.AnnotateCtx(ctx)
+	_, err := s.privilegeChecker.requireAdminUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &serverpb.JobProfilerArtifactTypesResponse{
+		ArtifactTypes: sql.ExecutionDetailArtifactTypes(),
+	}, nil
+}
+
+// jobProfilerExecCfg returns the ExecutorConfig of the tenant whose job
+// execution details are accessed. This is the tenant of this server, unless
+// tenantID refers to another tenant. The execution details of another tenant's
+// jobs are accessed via its server if it runs in this process, and otherwise
+// the request is routed to one of the tenant's SQL instances, through the
+// returned status client. Only the system tenant may access the execution
+// details of other tenants, and only of tenants granted the capability to
+// have their processes debugged. The caller is authorized by the system
+// tenant, so requests routed to the other tenant's SQL instances don't
+// forward its SQL identity, which would be resolved against the other
+// tenant's users, and are served as node (root) requests instead. The stored
+// execution details remain namespaced by tenant since they are read from and
+// written to the other tenant's own system.job_info table.
+func (s *statusServer) jobProfilerExecCfg(
+	ctx context.Context, tenantID uint64,
+) (*sql.ExecutorConfig, serverpb.StatusClient, error) {
+	if tenantID == 0 || tenantID == s.rpcCtx.TenantID.ToUint64() {
+		return s.sqlServer.execCfg, nil, nil
+	}
+	if !s.rpcCtx.TenantID.IsSystem() {
+		return nil, nil, status.Errorf(codes.PermissionDenied,
+			"only the system tenant may access the execution details of another tenant's jobs")
+	}
+	tenID, err := roachpb.MakeTenantID(tenantID)
+	if err != nil {
+		return nil, nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+	if err := s.rpcCtx.TenantRPCAuthorizer.HasProcessDebugCapability(ctx, tenID); err != nil {
+		return nil, nil, status.Errorf(codes.PermissionDenied,
+			"cannot access the execution details of tenant %d: %v", tenantID, err)
+	}
+	log.Infof(ctx, "accessing the job execution details of tenant %d", tenantID)
+	if s.tenantStatusServerLookup != nil {
+		if tenantStatus, err := s.tenantStatusServerLookup(ctx, tenID); err == nil {
+			return tenantStatus.sqlServer.execCfg, nil, nil
+		}
+	}
+	tenantStatus, err := s.dialTenantSQLInstance(ctx, tenID)
+	if err != nil {
+		return nil, nil, status.Errorf(codes.NotFound,
+			"cannot access the execution details of tenant %d: %v", tenantID, err)
+	}
+	return nil, tenantStatus, nil
+}
+
+// dialTenantSQLInstance dials one of the SQL instances of the secondary
+// tenant with the given ID, whichever process it runs in. The instances are
+// read from the tenant's sql_instances table, which the system tenant reads
+// from the tenant's keyspace. Instances which cannot be dialed, e.g. because
+// they have stopped, are skipped.
+func (s *statusServer) dialTenantSQLInstance(
+	ctx context.Context, tenantID roachpb.TenantID,
+) (serverpb.StatusClient, error) {
+	instances, err := s.sqlServer.sqlInstanceReader.GetAllTenantInstancesNoCache(
+		ctx, keys.MakeSQLCodec(tenantID),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(instances) == 0 {
+		return nil, errors.Newf("tenant %d has no SQL instances", tenantID)
+	}
+	var dialErr error
+	for _, instance := range instances {
+		conn, err := s.rpcCtx.GRPCDialPod(
+			instance.InstanceRPCAddr, instance.InstanceID, rpc.DefaultClass,
+		).Connect(ctx)
+		if err != nil {
+			dialErr = errors.CombineErrors(dialErr, err)
+			continue
+		}
+		return serverpb.NewStatusClient(conn), nil
+	}
+	return nil, dialErr
 }
@@ -16,7 +16,9 @@ import (
 	gosql "database/sql"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"math"
+	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -37,6 +39,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/allocator/plan"
+	"github.com/cockroachdb/cockroach/pkg/multitenant/tenantcapabilities"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/rpc"
 	"github.com/cockroachdb/cockroach/pkg/security/username"
@@ -76,6 +79,9 @@ import (
 	"github.com/kr/pretty"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 func getStatusJSONProto(
@@ -3887,3 +3893,233 @@ func TestUnprivilegedUserResetIndexUsageStats(t *testing.T) {
 
 	require.Contains(t, err.Error(), "requires admin privilege")
 }
+
+// TestJobProfilerExecutionDetailsAcrossTenants verifies that the system tenant
+// can request and read the execution details of a secondary tenant's job,
+// whereas a secondary tenant cannot access those of another tenant.
+func TestJobProfilerExecutionDetailsAcrossTenants(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s, systemDB, _ := serverutils.StartServer(t, base.TestServerArgs{
+		DefaultTestTenant: base.TODOTestTenantDisabled,
+	})
+	defer s.Stopper().Stop(ctx)
+
+	tenant, tenantDB, err := s.StartSharedProcessTenant(ctx,
+		base.TestSharedProcessTenantArgs{TenantName: "app"})
+	require.NoError(t, err)
+	tenantID := tenant.RPCContext().TenantID.ToUint64()
+
+	// Run a job in the secondary tenant.
+	tenantRunner := sqlutils.MakeSQLRunner(tenantDB)
+	tenantRunner.Exec(t, `CREATE TABLE t (a INT)`)
+	tenantRunner.Exec(t, `CREATE INDEX ON t (a)`)
+	var jobID int64
+	tenantRunner.QueryRow(t, `SELECT job_id FROM [SHOW JOBS]
+WHERE description LIKE 'CREATE INDEX%' ORDER BY created DESC LIMIT 1`).Scan(&jobID)
+
+	ctx = metadata.NewIncomingContext(ctx, metadata.New(map[string]string{
+		"websessionuser": username.RootUserName().SQLIdentifier(),
+	}))
+	systemStatus := s.StatusServer().(*systemStatusServer)
+
+	t.Run("system tenant", func(t *testing.T) {
+		// The tenant must be granted the capability to have its processes
+		// debugged.
+		_, err := systemStatus.RequestJobProfilerExecutionDetails(ctx,
+			&serverpb.RequestJobProfilerExecutionDetailsRequest{JobId: jobID, TenantID: tenantID})
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+		require.ErrorContains(t, err, "does not have capability to debug the process")
+		grantJobProfilerCapability(t, s, tenant.RPCContext().TenantID)
+
+		_, err = systemStatus.RequestJobProfilerExecutionDetails(ctx,
+			&serverpb.RequestJobProfilerExecutionDetailsRequest{JobId: jobID, TenantID: tenantID})
+		require.NoError(t, err)
+
+		listResp, err := systemStatus.ListJobProfilerExecutionDetails(ctx,
+			&serverpb.ListJobProfilerExecutionDetailsRequest{JobId: jobID, TenantID: tenantID})
+		require.NoError(t, err)
+		require.NotEmpty(t, listResp.Files)
+
+		getResp, err := systemStatus.GetJobProfilerExecutionDetails(ctx,
+			&serverpb.GetJobProfilerExecutionDetailRequest{
+				JobId: jobID, Filename: listResp.Files[0], TenantID: tenantID,
+			})
+		require.NoError(t, err)
+		require.NotEmpty(t, getResp.Data)
+
+		// The execution details are stored by the secondary tenant, so they
+		// aren't listed for the system tenant's job with the same ID.
+		listResp, err = systemStatus.ListJobProfilerExecutionDetails(ctx,
+			&serverpb.ListJobProfilerExecutionDetailsRequest{JobId: jobID})
+		require.NoError(t, err)
+		require.Empty(t, listResp.Files)
+
+		// Tenants without SQL instances can't be accessed.
+		var otherTenantID uint64
+		systemRunner := sqlutils.MakeSQLRunner(systemDB)
+		systemRunner.Exec(t, `CREATE TENANT other`)
+		systemRunner.QueryRow(t, `SELECT id FROM system.tenants WHERE name = 'other'`).Scan(&otherTenantID)
+		grantJobProfilerCapability(t, s, roachpb.MustMakeTenantID(otherTenantID))
+		_, err = systemStatus.ListJobProfilerExecutionDetails(ctx,
+			&serverpb.ListJobProfilerExecutionDetailsRequest{JobId: jobID, TenantID: otherTenantID})
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("secondary tenant", func(t *testing.T) {
+		tenantStatus := tenant.StatusServer().(serverpb.StatusServer)
+
+		// The secondary tenant may access its own execution details by ID.
+		listResp, err := tenantStatus.ListJobProfilerExecutionDetails(ctx,
+			&serverpb.ListJobProfilerExecutionDetailsRequest{JobId: jobID, TenantID: tenantID})
+		require.NoError(t, err)
+		require.NotEmpty(t, listResp.Files)
+
+		// But not those of another tenant.
+		_, err = tenantStatus.ListJobProfilerExecutionDetails(ctx,
+			&serverpb.ListJobProfilerExecutionDetailsRequest{
+				JobId: jobID, TenantID: roachpb.SystemTenantID.ToUint64(),
+			})
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+		require.ErrorContains(t, err, "only the system tenant may access the execution details of another tenant's jobs")
+
+		_, err = tenantStatus.RequestJobProfilerExecutionDetails(ctx,
+			&serverpb.RequestJobProfilerExecutionDetailsRequest{
+				JobId: jobID, TenantID: roachpb.SystemTenantID.ToUint64(),
+			})
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+}
+
+// TestJobProfilerExecutionDetailsOfExternalTenant verifies that the system
+// tenant can collect and download the execution details of the jobs of a
+// secondary tenant whose SQL server runs in another process, over RPC as well
+// as over HTTP.
+func TestJobProfilerExecutionDetailsOfExternalTenant(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s, _, _ := serverutils.StartServer(t, base.TestServerArgs{
+		DefaultTestTenant: base.TODOTestTenantDisabled,
+	})
+	defer s.Stopper().Stop(ctx)
+
+	tenant, tenantDB := serverutils.StartTenant(t, s, base.TestTenantArgs{
+		TenantID: serverutils.TestTenantID(),
+	})
+	tenantID := tenant.RPCContext().TenantID.ToUint64()
+	grantJobProfilerCapability(t, s, tenant.RPCContext().TenantID)
+
+	// Run a job in the secondary tenant.
+	tenantRunner := sqlutils.MakeSQLRunner(tenantDB)
+	tenantRunner.Exec(t, `CREATE TABLE t (a INT)`)
+	tenantRunner.Exec(t, `CREATE INDEX ON t (a)`)
+	var jobID int64
+	tenantRunner.QueryRow(t, `SELECT job_id FROM [SHOW JOBS]
+WHERE description LIKE 'CREATE INDEX%' ORDER BY created DESC LIMIT 1`).Scan(&jobID)
+
+	rpcCtx := metadata.NewIncomingContext(ctx, metadata.New(map[string]string{
+		"websessionuser": username.RootUserName().SQLIdentifier(),
+	}))
+	systemStatus := s.StatusServer().(*systemStatusServer)
+	_, err := systemStatus.RequestJobProfilerExecutionDetails(rpcCtx,
+		&serverpb.RequestJobProfilerExecutionDetailsRequest{JobId: jobID, TenantID: tenantID})
+	require.NoError(t, err)
+	listResp, err := systemStatus.ListJobProfilerExecutionDetails(rpcCtx,
+		&serverpb.ListJobProfilerExecutionDetailsRequest{JobId: jobID, TenantID: tenantID})
+	require.NoError(t, err)
+	require.NotEmpty(t, listResp.Files)
+	filename := listResp.Files[0]
+	getResp, err := systemStatus.GetJobProfilerExecutionDetails(rpcCtx,
+		&serverpb.GetJobProfilerExecutionDetailRequest{
+			JobId: jobID, Filename: filename, TenantID: tenantID,
+		})
+	require.NoError(t, err)
+	require.NotEmpty(t, getResp.Data)
+
+	client, err := s.GetAdminHTTPClient()
+	require.NoError(t, err)
+	download := func(query string) []byte {
+		url := s.AdminURL().String() +
+			fmt.Sprintf("/_status/job_profiler_execution_details/%d/%s%s", jobID, filename, query)
+		req, err := http.NewRequest("GET", url, nil)
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", httputil.ProtoContentType)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+		var edResp serverpb.GetJobProfilerExecutionDetailResponse
+		require.NoError(t, protoutil.Unmarshal(body, &edResp))
+		return edResp.Data
+	}
+
+	// The execution detail is downloaded from the secondary tenant.
+	require.Equal(t, getResp.Data, download(fmt.Sprintf("?tenant_id=%d", tenantID)))
+	// Without the tenant ID, the system tenant's job with the same ID is
+	// accessed, which has no execution details.
+	require.Empty(t, download(""))
+}
+
+// grantJobProfilerCapability grants the given tenant the capability to have
+// its processes debugged, which the system tenant requires to access the
+// execution details of the tenant's jobs, and waits for the grant to be
+// observed by the server.
+func grantJobProfilerCapability(
+	t *testing.T, s serverutils.TestServerInterface, tenantID roachpb.TenantID,
+) {
+	ts := s.(*TestServer)
+	_, err := ts.InternalExecutor().(*sql.InternalExecutor).Exec(
+		context.Background(), "grant-can-debug-process", nil, /* txn */
+		"ALTER TENANT [$1] GRANT CAPABILITY can_debug_process", tenantID.ToUint64())
+	require.NoError(t, err)
+	testutils.SucceedsSoon(t, func() error {
+		capabilities, found := ts.TenantCapabilitiesReader().GetCapabilities(tenantID)
+		if !found || !tenantcapabilities.MustGetBoolByID(
+			capabilities, tenantcapabilities.CanDebugProcess,
+		) {
+			return errors.Newf("capability not yet granted to tenant %s", tenantID)
+		}
+		return nil
+	})
+}
+
+// TestJobProfilerArtifactTypes verifies that the job_profiler_artifact_types
+// endpoint describes the types of execution details which may be collected for
+// a job, and is restricted to admins.
+func TestJobProfilerArtifactTypes(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	ts, _, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer ts.Stopper().Stop(ctx)
+
+	var resp serverpb.JobProfilerArtifactTypesResponse
+	require.NoError(t, getStatusJSONProto(ts, "job_profiler_artifact_types", &resp))
+
+	mediaTypes := make(map[string]string)
+	for _, typ := range resp.ArtifactTypes {
+		require.NotEmpty(t, typ.Name)
+		require.NotEmpty(t, typ.Description)
+		mediaTypes[typ.NamePattern] = typ.MediaType
+	}
+	for pattern, mediaType := range map[string]string{
+		"distsql.<timestamp>.attempt<attempt>.html":  "text/html",
+		"distsql.<timestamp>.attempt<attempt>.binpb": "application/octet-stream",
+		"goroutines.<timestamp>.txt":                 "text/plain",
+		"goroutines.<node>.<timestamp>.pprof":        "application/octet-stream",
+		"retries.<timestamp>.txt":                    "text/plain",
+		"txn_stats.<timestamp>.txt":                  "text/plain",
+	} {
+		require.Equal(t, mediaType, mediaTypes[pattern], "artifact type %s", pattern)
+	}
+
+	err := getStatusJSONProtoWithAdminOption(ts, "job_profiler_artifact_types", &resp, false /* isAdmin */)
+	require.ErrorContains(t, err, "status: 403")
+}
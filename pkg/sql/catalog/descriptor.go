@@ -343,6 +343,10 @@ type TableDescriptor interface {
 	// created at, for materialized views and CREATE TABLE AS. Only valid if
 	// IsAs or MaterializedView returns true.
 	GetCreateAsOfTime() hlc.Timestamp
+	// GetCreateAsProvenance returns the statement and the sources that the data
+	// of a table created with CREATE TABLE AS came from. It is nil if IsAs is
+	// false, or if the table was created before provenance was recorded.
+	GetCreateAsProvenance() *descpb.TableDescriptor_CreateAsProvenance
 
 	// GetViewQuery returns this view's CREATE VIEW declaration. Only valid if
 	// IsView is true.
@@ -749,6 +753,10 @@ type TableDescriptor interface {
 	// IsRefreshViewRequired indicates if a REFRESH VIEW operation needs to be called
 	// on a materialized view.
 	IsRefreshViewRequired() bool
+	// GetViewRefreshAsOf returns the timestamp the view query was evaluated at
+	// when this materialized view was last refreshed, or an empty timestamp if
+	// it hasn't been refreshed since it was created.
+	GetViewRefreshAsOf() hlc.Timestamp
 	// GetInProgressImportStartTime returns the start wall time of the in progress import,
 	// if it exists.
 	GetInProgressImportStartTime() int64
@@ -1562,6 +1562,11 @@ type connExecutor struct {
 		// createdSequences keeps track of sequences created in the current transaction.
 		// The map key is the sequence descpb.ID.
 		createdSequences map[descpb.ID]struct{}
+
+		// onCommitDropTables are the IDs of the temporary tables created with
+		// ON COMMIT DROP in the current transaction, which are dropped when it
+		// commits.
+		onCommitDropTables []descpb.ID
 	}
 
 	// sessionDataStack contains the user-configurable connection variables.
@@ -1970,6 +1975,7 @@ func (ex *connExecutor) resetExtraTxnState(ctx context.Context, ev txnEvent) {
 	ex.extraTxnState.firstStmtExecuted = false
 	ex.extraTxnState.hasAdminRoleCache = HasAdminRoleCache{}
 	ex.extraTxnState.createdSequences = nil
+	ex.extraTxnState.onCommitDropTables = nil
 
 	if ex.extraTxnState.fromOuterTxn {
 		if ex.extraTxnState.shouldResetSyntheticDescriptors {
@@ -3618,6 +3624,7 @@ func (ex *connExecutor) initPlanner(ctx context.Context, p *planner) {
 	p.preparedStatements = ex.getPrepStmtsAccessor()
 	p.sqlCursors = ex.getCursorAccessor()
 	p.createdSequences = ex.getCreatedSequencesAccessor()
+	p.onCommitDropTables = ex.getOnCommitDropTablesAccessor()
 
 	p.queryCacheSession.Init()
 	p.optPlanningCtx.init(p)
@@ -3812,9 +3819,7 @@ func (ex *connExecutor) txnStateTransitionsApplyWrapper(
 			return advanceInfo{}, err
 		}
 		ex.statsCollector.PhaseTimes().SetSessionPhaseTime(sessionphase.SessionStartPostCommitJob, timeutil.Now())
-		if err := ex.server.cfg.JobRegistry.Run(
-			ex.ctxHolder.connCtx, ex.extraTxnState.jobs.created,
-		); err != nil {
+		if err := ex.runCreatedJobs(res); err != nil {
 			handleErr(err)
 		}
 		ex.statsCollector.PhaseTimes().SetSessionPhaseTime(sessionphase.SessionEndPostCommitJob, timeutil.Now())
@@ -4122,6 +4127,12 @@ func (ex *connExecutor) getCreatedSequencesAccessor() createdSequences {
 	}
 }
 
+func (ex *connExecutor) getOnCommitDropTablesAccessor() onCommitDropTables {
+	return connExOnCommitDropTablesAccessor{
+		ex: ex,
+	}
+}
+
 // sessionEventf logs a message to the session event log (if any).
 func (ex *connExecutor) sessionEventf(ctx context.Context, format string, args ...interface{}) {
 	if log.ExpensiveLogEnabled(ctx, 2) {
@@ -4138,7 +4149,13 @@ func (ex *connExecutor) sessionEventf(ctx context.Context, format string, args .
 func (ex *connExecutor) notifyStatsRefresherOfNewTables(ctx context.Context) {
 	for _, desc := range ex.extraTxnState.descCollection.GetUncommittedTables() {
 		// The CREATE STATISTICS run for an async CTAS query is initiated by the
-		// SchemaChanger, so we don't do it here.
+		// SchemaChanger, so we don't do it here. A CTAS query populated within
+		// the transaction only collects statistics if it requested it.
+		if desc.IsTable() && desc.IsAs() && desc.Public() && desc.GetVersion() == 1 &&
+			createTableAsCollectsStats(desc) {
+			collectCreateTableAsStats(ctx, ex.planner.execCfg, desc)
+			continue
+		}
 		if desc.IsTable() && !desc.IsAs() && desc.GetVersion() == 1 {
 			// Initiate a run of CREATE STATISTICS. We use a large number
 			// for rowsAffected because we want to make sure that stats always get
@@ -621,6 +621,19 @@ func (ex *connExecutor) execStmtInOpenState(
 		ast = stmt.Statement.AST
 	}
 
+	// Special top-level handling for CREATE TABLE AS with an EXPLAIN ANALYZE
+	// source, which must execute the inner statement exactly once. Like the
+	// handling of EXPLAIN ANALYZE above, this only replaces the statement for
+	// this execution.
+	if ct, ok := ast.(*tree.CreateTable); ok && ct.As() {
+		materialized, err := p.materializeCreateTableAsExplainAnalyze(ctx, ct)
+		if err != nil {
+			return makeErrEvent(err)
+		}
+		stmt.AST = materialized
+		ast = materialized
+	}
+
 	var needFinish bool
 	// For pausable portal, the instrumentation helper needs to be set up only when
 	// the portal is executed for the first time.
@@ -1312,6 +1325,10 @@ func (ex *connExecutor) commitSQLTransactionInternal(ctx context.Context) error
 		ex.state.mu.txn.ConfigureStepping(ctx, prevSteppingMode)
 	}
 
+	if err := ex.dropOnCommitTables(ctx); err != nil {
+		return err
+	}
+
 	if err := ex.createJobs(ctx); err != nil {
 		return err
 	}
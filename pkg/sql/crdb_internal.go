@@ -216,6 +216,8 @@ var crdbInternal = virtualSchema{
 		catconstants.CrdbInternalKVFlowControllerID:                 crdbInternalKVFlowController,
 		catconstants.CrdbInternalKVFlowTokenDeductions:              crdbInternalKVFlowTokenDeductions,
 		catconstants.CrdbInternalRepairableCatalogCorruptionsViewID: crdbInternalRepairableCatalogCorruptions,
+		catconstants.CrdbInternalTableProvenanceTableID:             crdbInternalTableProvenanceTable,
+		catconstants.CrdbInternalJobProfilerCapabilitiesTableID:     crdbInternalJobProfilerCapabilitiesTable,
 	},
 	validWithNoDatabaseContext: true,
 }
@@ -7379,6 +7381,83 @@ func generateTableSpan(
 	)
 }
 
+// crdbInternalTableProvenanceTable exposes the provenance recorded for tables
+// created with CREATE TABLE AS, i.e. the statement which created them and the
+// sources their data was read from.
+var crdbInternalTableProvenanceTable = virtualSchemaTable{
+	comment: `provenance of tables created with CREATE TABLE AS`,
+	schema: `
+CREATE TABLE crdb_internal.table_provenance (
+  table_id            INT NOT NULL,
+  database_name       STRING NOT NULL,
+  schema_name         STRING NOT NULL,
+  table_name          STRING NOT NULL,
+  statement           STRING NOT NULL,
+  source_ids          INT[] NOT NULL,
+  source_descriptions STRING[] NOT NULL
+);`,
+	populate: func(ctx context.Context, p *planner, dbContext catalog.DatabaseDescriptor, addRow func(...tree.Datum) error) error {
+		return forEachTableDesc(ctx, p, dbContext, hideVirtual,
+			func(db catalog.DatabaseDescriptor, sc catalog.SchemaDescriptor, table catalog.TableDescriptor) error {
+				provenance := table.GetCreateAsProvenance()
+				if provenance == nil {
+					return nil
+				}
+				sourceIDs := tree.NewDArray(types.Int)
+				for _, id := range provenance.SourceIDs {
+					if err := sourceIDs.Append(tree.NewDInt(tree.DInt(id))); err != nil {
+						return err
+					}
+				}
+				sourceDescriptions := tree.NewDArray(types.String)
+				for _, desc := range provenance.SourceDescriptions {
+					if err := sourceDescriptions.Append(tree.NewDString(desc)); err != nil {
+						return err
+					}
+				}
+				return addRow(
+					tree.NewDInt(tree.DInt(table.GetID())),
+					tree.NewDString(db.GetName()),
+					tree.NewDString(sc.GetName()),
+					tree.NewDString(table.GetName()),
+					tree.NewDString(provenance.Statement),
+					sourceIDs,
+					sourceDescriptions,
+				)
+			})
+	},
+}
+
+var crdbInternalJobProfilerCapabilitiesTable = virtualSchemaTable{
+	comment: `types of execution details which can be collected for each registered job type`,
+	schema: `
+CREATE TABLE crdb_internal.job_profiler_capabilities (
+  job_type      STRING NOT NULL,
+  artifact_type STRING NOT NULL,
+  supported     BOOL NOT NULL
+);`,
+	populate: func(ctx context.Context, p *planner, _ catalog.DatabaseDescriptor, addRow func(...tree.Datum) error) error {
+		for _, typ := range jobs.RegisteredJobTypes() {
+			supported, unsupported := executionDetailArtifactSupport(typ)
+			jobType := tree.NewDString(typ.String())
+			for _, artifacts := range []struct {
+				names     []string
+				supported tree.Datum
+			}{
+				{names: supported, supported: tree.DBoolTrue},
+				{names: unsupported, supported: tree.DBoolFalse},
+			} {
+				for _, name := range artifacts.names {
+					if err := addRow(jobType, tree.NewDString(name), artifacts.supported); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	},
+}
+
 // crdbInternalClusterLocksTable exposes the state of locks, as well as lock waiters,
 // in range lock tables across the cluster.
 var crdbInternalClusterLocksTable = virtualSchemaTable{
@@ -0,0 +1,206 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catenumpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/isql"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowexec"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// createTableAsBackfillStateInfoKey is the job info key under which the schema
+// change job of a CREATE TABLE AS statement records the state of its backfill,
+// so that a job which is paused, or whose node fails, can be resumed without
+// ingesting the rows of the table twice.
+const createTableAsBackfillStateInfoKey = "~ctas-backfill-state"
+
+// createTableAsBackfillState is the state of the backfill of a CREATE TABLE AS
+// statement, as recorded in the info storage of its job. It is empty if the job
+// never started the backfill, so that the table is empty.
+type createTableAsBackfillState string
+
+const (
+	// createTableAsBackfillStarted means that the job started the backfill, but
+	// that it may have been interrupted, so that the table may contain part of
+	// the rows of the query.
+	createTableAsBackfillStarted createTableAsBackfillState = "started"
+	// createTableAsBackfillDone means that the backfill completed, so that the
+	// table contains all the rows of the query.
+	createTableAsBackfillDone createTableAsBackfillState = "done"
+)
+
+// readCreateTableAsBackfillState returns the state of the backfill of the
+// CREATE TABLE AS statement run by the job of the schema changer.
+func (sc *SchemaChanger) readCreateTableAsBackfillState(
+	ctx context.Context,
+) (state createTableAsBackfillState, _ error) {
+	err := sc.db.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		value, _, err := sc.job.InfoStorage(txn).Get(ctx, createTableAsBackfillStateInfoKey)
+		state = createTableAsBackfillState(value)
+		return err
+	})
+	return state, err
+}
+
+// writeCreateTableAsBackfillState records the state of the backfill of the
+// CREATE TABLE AS statement run by the job of the schema changer. When the
+// backfill is started, the progress recorded by the processors of a previous
+// attempt, including the rows they published to the sink of the table, is also
+// reset, since its rows are cleared.
+func (sc *SchemaChanger) writeCreateTableAsBackfillState(
+	ctx context.Context, state createTableAsBackfillState,
+) error {
+	return sc.db.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		infoStorage := sc.job.InfoStorage(txn)
+		if state == createTableAsBackfillStarted {
+			var progressKeys []string
+			for _, prefix := range []string{
+				rowexec.CTASProgressInfoKeyPrefix, rowexec.CTASPublishedInfoKeyPrefix,
+			} {
+				if err := infoStorage.Iterate(ctx, prefix, func(infoKey string, _ []byte) error {
+					progressKeys = append(progressKeys, infoKey)
+					return nil
+				}); err != nil {
+					return err
+				}
+			}
+			for _, infoKey := range progressKeys {
+				if err := infoStorage.Delete(ctx, infoKey); err != nil {
+					return err
+				}
+			}
+		}
+		return infoStorage.Write(ctx, createTableAsBackfillStateInfoKey, []byte(state))
+	})
+}
+
+// queryBackfillCheckpoint configures the checkpointing of the backfill of a
+// CREATE TABLE AS statement, see execinfrapb.BulkRowWriterSpec.Checkpoint.
+type queryBackfillCheckpoint struct {
+	// resumeKey is the key of the row from which the backfill is resumed, or nil
+	// if it starts from the first row.
+	resumeKey roachpb.Key
+}
+
+// orderCreateTableAsQueryByPrimaryKey returns the query of a CREATE TABLE AS
+// statement with its rows ordered by the primary key of the table, so that its
+// backfill can be checkpointed, see execinfrapb.BulkRowWriterSpec.Checkpoint.
+// It returns false if the backfill isn't checkpointed, in which case an
+// interrupted backfill is cleared and run again, rather than resumed:
+//
+//   - when the statement didn't request it with the checkpoint storage
+//     parameter, since sorting the rows of the query and ingesting them on a
+//     single stream is slower than a distributed backfill, unless the table is
+//     large enough to be costly to backfill again,
+//   - when the primary key isn't made of the columns of the query, e.g. when it
+//     is the hidden rowid column, whose values are generated by the processors
+//     ingesting the rows, so that a resumed backfill would generate different
+//     rowids for the rows it ingests again, and
+//   - when the table has secondary indexes, whose entries aren't ingested in
+//     the order of the primary index.
+func orderCreateTableAsQueryByPrimaryKey(
+	table catalog.TableDescriptor, query string,
+) (string, bool) {
+	if provenance := table.GetCreateAsProvenance(); provenance == nil || !provenance.Checkpoint {
+		return "", false
+	}
+	if len(table.ActiveIndexes()) != 1 {
+		return "", false
+	}
+	// The columns of the query are the visible columns of the table, in order,
+	// so the key columns are ordered by their ordinal in the query.
+	visible := table.VisibleColumns()
+	primary := table.GetPrimaryIndex()
+	orderBy := make([]string, primary.NumKeyColumns())
+	for i := range orderBy {
+		ordinal := -1
+		for j, col := range visible {
+			if col.GetID() == primary.GetKeyColumnID(i) {
+				ordinal = j
+				break
+			}
+		}
+		if ordinal == -1 || visible[ordinal].IsComputed() || visible[ordinal].HasDefault() {
+			return "", false
+		}
+		direction := "ASC"
+		if primary.GetKeyColumnDirection(i) == catenumpb.IndexColumn_DESC {
+			direction = "DESC"
+		}
+		orderBy[i] = fmt.Sprintf("%d %s", ordinal+1, direction)
+	}
+	return fmt.Sprintf("SELECT * FROM (%s) AS ctas ORDER BY %s", query, strings.Join(orderBy, ", ")), true
+}
+
+// readCreateTableAsCheckpoint returns the key of the row from which the
+// checkpointed backfill of the CREATE TABLE AS statement run by the job of the
+// schema changer is resumed, or nil if it didn't record a checkpoint.
+func (sc *SchemaChanger) readCreateTableAsCheckpoint(
+	ctx context.Context, table catalog.TableDescriptor,
+) (resumeKey roachpb.Key, _ error) {
+	err := sc.db.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		value, ok, err := sc.job.InfoStorage(txn).Get(ctx, rowexec.CTASCheckpointInfoKey)
+		if err != nil || !ok {
+			return err
+		}
+		resumeKey = value
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	prefix := sc.execCfg.Codec.IndexPrefix(uint32(table.GetID()), uint32(table.GetPrimaryIndexID()))
+	if resumeKey != nil && !bytes.HasPrefix(resumeKey, prefix) {
+		log.Warningf(ctx, "ignoring checkpoint %s outside of the primary index of table %d",
+			resumeKey, table.GetID())
+		return nil, nil
+	}
+	return resumeKey, nil
+}
+
+// clearCreateTableAsPartialBackfill removes the rows ingested into the table of
+// a CREATE TABLE AS statement by an interrupted attempt at its backfill, from
+// the given resume key of a checkpointed backfill, or the whole table if it is
+// nil. The rows before the resume key were ingested by the interrupted attempt,
+// so they are kept and skipped by the next attempt, while the rows after it may
+// have been ingested partially, so they are cleared.
+//
+// The rows are written at the AS OF timestamp of the statement, which precedes
+// the current time, so they can't be deleted using tombstones without hiding
+// the rows ingested by the next attempt. Since the table is still being added,
+// no one else can read or write it, so its span is cleared instead.
+func (sc *SchemaChanger) clearCreateTableAsPartialBackfill(
+	ctx context.Context, table catalog.TableDescriptor, resumeKey roachpb.Key,
+) error {
+	prefix := sc.execCfg.Codec.TablePrefix(uint32(table.GetID()))
+	start := prefix
+	if resumeKey != nil {
+		log.Infof(ctx, "resuming the interrupted backfill of table %d from %s", table.GetID(), resumeKey)
+		start = resumeKey
+	} else {
+		log.Infof(ctx, "clearing the rows ingested by an interrupted backfill of table %d", table.GetID())
+	}
+	b := &kv.Batch{}
+	b.AddRawRequest(&kvpb.ClearRangeRequest{
+		RequestHeader: kvpb.RequestHeader{Key: start, EndKey: prefix.PrefixEnd()},
+	})
+	return sc.db.KV().Run(ctx, b)
+}
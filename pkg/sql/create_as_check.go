@@ -0,0 +1,161 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descs"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/schemaexpr"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/tabledesc"
+	"github.com/cockroachdb/cockroach/pkg/sql/paramparse"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+)
+
+// addCheckForCreateTableAs adds the check constraint set by the add_check
+// storage parameter of a CREATE TABLE AS statement, if any, to the new table.
+// The rows of the source query aren't checked against the constraint as the
+// table is populated, so it is validated once the table is populated instead:
+// by the schema change job of the statement, for a table in the ADD state,
+// whose constraint is left in the Validating state until then, or by
+// validateCreateTableAsCheckInTxn otherwise.
+func addCheckForCreateTableAs(
+	params runParams, n *tree.CreateTable, desc *tabledesc.Mutable,
+) (*descpb.TableDescriptor_CheckConstraint, error) {
+	key := tree.CreateTableAsAddCheckStorageParam
+	value := n.StorageParams.GetVal(key)
+	if value == nil {
+		return nil, nil
+	}
+	expr := paramparse.UnresolvedNameToStrVal(value)
+	typedExpr, err := tree.TypeCheck(params.ctx, expr, params.p.SemaCtx(), types.String)
+	if err != nil {
+		return nil, err
+	}
+	s, err := paramparse.DatumAsString(params.ctx, params.p.EvalContext(), key, typedExpr)
+	if err != nil {
+		return nil, err
+	}
+	ckExpr, err := parser.ParseExpr(s)
+	if err != nil {
+		return nil, pgerror.Wrapf(err, pgcode.InvalidParameterValue, "invalid value for %s", key)
+	}
+
+	ckBuilder := schemaexpr.MakeCheckConstraintBuilder(params.ctx, n.Table, desc, params.p.SemaCtx())
+	for _, c := range desc.AllConstraints() {
+		ckBuilder.MarkNameInUse(c.GetName())
+	}
+	ck, err := ckBuilder.Build(
+		&tree.CheckConstraintTableDef{Expr: ckExpr},
+		params.ExecCfg().Settings.Version.ActiveVersion(params.ctx),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if desc.Adding() {
+		ck.Validity = descpb.ConstraintValidity_Validating
+	}
+	desc.Checks = append(desc.Checks, ck)
+	return ck, nil
+}
+
+// validateCreateTableAsCheckInTxn validates the rows of a table populated
+// within the transaction of its CREATE TABLE AS statement against the check
+// constraint added by the add_check storage parameter of the statement.
+func validateCreateTableAsCheckInTxn(
+	params runParams, desc *tabledesc.Mutable, ck *descpb.TableDescriptor_CheckConstraint,
+) error {
+	if err := validateCheckInTxn(
+		params.ctx, params.p.InternalSQLTxn(), params.p.SemaCtx(), params.SessionData(), desc, ck.Expr,
+	); err != nil {
+		return errors.Wrapf(err, "constraint %q", ck.Name)
+	}
+	return nil
+}
+
+// createTableAsValidatingChecks returns the check constraints of a table being
+// created by a CREATE TABLE AS statement, which were added by the add_check
+// storage parameter of the statement and are yet to be validated.
+func createTableAsValidatingChecks(table catalog.TableDescriptor) []catalog.CheckConstraint {
+	if !(table.Adding() && table.IsAs()) {
+		return nil
+	}
+	var checks []catalog.CheckConstraint
+	for _, ck := range table.CheckConstraints() {
+		if ck.GetConstraintValidity() == descpb.ConstraintValidity_Validating {
+			checks = append(checks, ck)
+		}
+	}
+	return checks
+}
+
+// validateCreateTableAsChecks validates the rows ingested by the backfill of a
+// CREATE TABLE AS statement against the check constraints added by the
+// add_check storage parameter of the statement. A violating row fails the job,
+// which then drops the table. The constraints are marked as validated when the
+// table is made public, see maybeMakeAddTablePublic.
+func (sc *SchemaChanger) validateCreateTableAsChecks(
+	ctx context.Context, table catalog.TableDescriptor,
+) error {
+	checks := createTableAsValidatingChecks(table)
+	if len(checks) == 0 {
+		return nil
+	}
+	log.Infof(ctx, "validating %d check constraints of CREATE TABLE AS", len(checks))
+
+	// The table is still being added, so it can't be read by name. A public
+	// copy of its descriptor is used by the validation queries instead.
+	desc := table.MakePublic().(*tabledesc.Mutable)
+	runHistoricalTxn := sc.makeFixedTimestampRunner(sc.clock.Now())
+	return runHistoricalTxn(ctx, func(ctx context.Context, txn descs.Txn, evalCtx *extendedEvalContext) error {
+		// A semaContext which can resolve types is needed to print the check
+		// expression back to the user if it fails validation.
+		evalCtx.Txn = txn.KV()
+		collection := evalCtx.Descs
+		resolver := descs.NewDistSQLTypeResolver(collection, txn.KV())
+		semaCtx := tree.MakeSemaContext()
+		semaCtx.TypeResolver = &resolver
+		semaCtx.NameResolver = NewSkippingCacheSchemaResolver(
+			txn.Descriptors(),
+			sessiondata.NewStack(NewInternalSessionData(ctx, sc.settings, "validate create table as check")),
+			txn.KV(),
+			nil, /* authAccessor */
+		)
+		semaCtx.FunctionResolver = descs.NewDistSQLFunctionResolver(collection, txn.KV())
+		defer func() { collection.ReleaseAll(ctx) }()
+		for _, ck := range checks {
+			if err := validateCheckInTxn(
+				ctx, txn, &semaCtx, evalCtx.SessionData(), desc, ck.GetExpr(),
+			); err != nil {
+				return errors.Wrapf(err, "constraint %q", ck.GetName())
+			}
+		}
+		return nil
+	})
+}
+
+// markCreateTableAsChecksValidated marks the check constraints added by the
+// add_check storage parameter of a CREATE TABLE AS statement as validated, once
+// the schema change job of the statement validated them.
+func markCreateTableAsChecksValidated(table *tabledesc.Mutable) {
+	for _, ck := range createTableAsValidatingChecks(table) {
+		ck.CheckDesc().Validity = descpb.ConstraintValidity_Validated
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/stats"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/logtags"
+)
+
+// createTableAsStatsName is the name of the statistics collected on the new
+// table of a CREATE TABLE AS ... WITH (collect_stats = true) statement. It
+// isn't the name of automatic statistics, so that the collection isn't skipped
+// when another CREATE STATISTICS job is running.
+const createTableAsStatsName = "__create_table_as__"
+
+// createTableAsCollectsStats returns whether the table was created by a CREATE
+// TABLE AS ... WITH (collect_stats = true) statement.
+func createTableAsCollectsStats(desc catalog.TableDescriptor) bool {
+	provenance := desc.GetCreateAsProvenance()
+	return desc.IsAs() && provenance != nil && provenance.CollectStats
+}
+
+// collectCreateTableAsStats starts an asynchronous run of CREATE STATISTICS on
+// the new table of a CREATE TABLE AS ... WITH (collect_stats = true)
+// statement, once the table is populated, regardless of whether automatic
+// statistics collection is enabled. Like automatic runs, it is throttled by
+// sql.stats.automatic_collection.max_fraction_idle, and CREATE STATISTICS
+// only samples a bounded number of rows, so that collecting statistics on a
+// large table stays cheap. The CREATE TABLE AS statement doesn't wait on the
+// collection, and a failure to collect statistics is only logged.
+func collectCreateTableAsStats(
+	ctx context.Context, execCfg *ExecutorConfig, desc catalog.TableDescriptor,
+) {
+	stopper := execCfg.RPCContext.Stopper
+	origCtx := ctx
+	tableID := desc.GetID()
+	if err := stopper.RunAsyncTask(
+		// Note: we don't want to inherit the cancellation of the parent
+		// context, which ends with the statement or the schema change job.
+		context.Background(), "create-table-as-stats", func(ctx context.Context) {
+			ctx, span := execCfg.AmbientCtx.AnnotateCtxWithSpan(ctx, "create-table-as-stats")
+			defer span.Finish()
+			ctx = logtags.AddTags(ctx, logtags.FromContext(origCtx))
+			ctx, stopCancel := stopper.WithCancelOnQuiesce(ctx)
+			defer stopCancel()
+
+			stmt := fmt.Sprintf(
+				"CREATE STATISTICS %s FROM [%d] WITH OPTIONS THROTTLING %g",
+				createTableAsStatsName,
+				tableID,
+				stats.AutomaticStatisticsMaxIdleTime.Get(&execCfg.Settings.SV),
+			)
+			log.Infof(ctx, "collecting statistics of CREATE TABLE AS: %q", stmt)
+			if _, err := execCfg.InternalDB.Executor().Exec(
+				ctx, "create-table-as-stats", nil /* txn */, stmt,
+			); err != nil {
+				log.Warningf(ctx, "failed to collect statistics on table %d: %v", tableID, err)
+			}
+		}); err != nil {
+		expectedStopperError := errors.Is(err, stop.ErrThrottled) || errors.Is(err, stop.ErrUnavailable)
+		if !expectedStopperError {
+			err = errors.NewAssertionErrorWithWrappedErrf(err, "unexpected stopper error")
+		}
+		log.Warningf(ctx, "failed to start task to collect statistics on table %d: %v", tableID, err)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/schemaexpr"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/transform"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+)
+
+// createTableAsSourceAlias is the alias of the source query of a CREATE TABLE
+// AS statement, when it is wrapped to generate the values of the declared
+// columns.
+const createTableAsSourceAlias = "ctas_source"
+
+// createTableAsDeclaredColumns returns the names of the columns of a CREATE
+// TABLE ... AS statement which are declared with a DEFAULT expression, or as
+// identity or SERIAL columns, and so aren't populated by the source query.
+func createTableAsDeclaredColumns(n *tree.CreateTable) map[tree.Name]struct{} {
+	var declared map[tree.Name]struct{}
+	for _, def := range n.Defs {
+		if d, ok := def.(*tree.ColumnTableDef); ok && d.IsCreateAsDeclared() {
+			if declared == nil {
+				declared = make(map[tree.Name]struct{})
+			}
+			declared[d.Name] = struct{}{}
+		}
+	}
+	return declared
+}
+
+// wrapCreateTableAsQuery wraps the source query of a CREATE TABLE ... AS
+// statement which declares columns, so that the query produces a value for
+// every visible column of the new table, in order. The values of the declared
+// columns are generated by their DEFAULT expressions for each row, which lets
+// the schema change that populates the table use the query as is.
+func wrapCreateTableAsQuery(
+	n *tree.CreateTable, desc catalog.TableDescriptor, query string,
+) (string, error) {
+	declared := createTableAsDeclaredColumns(n)
+	if len(declared) == 0 {
+		return query, nil
+	}
+	var sourceCols tree.NameList
+	for _, def := range n.Defs {
+		if d, ok := def.(*tree.ColumnTableDef); ok && !d.IsCreateAsDeclared() {
+			sourceCols = append(sourceCols, d.Name)
+		}
+	}
+
+	sourceName := tree.Name(createTableAsSourceAlias)
+	sel := &tree.SelectClause{}
+	for _, col := range desc.VisibleColumns() {
+		colName := tree.Name(col.GetName())
+		if _, ok := declared[colName]; !ok {
+			sel.Exprs = append(sel.Exprs, tree.SelectExpr{
+				Expr: tree.NewColumnItem(tree.NewUnqualifiedTableName(sourceName), colName),
+			})
+			continue
+		}
+		expr, err := parser.ParseExpr(col.GetDefaultExpr())
+		if err != nil {
+			return "", err
+		}
+		// Cast the value to the type of the column, as the rows produced by the
+		// query are written to the table as is.
+		sel.Exprs = append(sel.Exprs, tree.SelectExpr{
+			Expr: &tree.CastExpr{Expr: expr, Type: col.GetType(), SyntaxMode: tree.CastExplicit},
+		})
+	}
+	// The source query is already serialized, so it is added as is.
+	f := tree.NewFmtCtx(tree.FmtSerializable)
+	f.FormatNode(sel)
+	f.WriteString(" FROM (")
+	f.WriteString(query)
+	f.WriteString(") AS ")
+	f.FormatNode(&sourceName)
+	f.WriteString(" (")
+	f.FormatNode(&sourceCols)
+	f.WriteByte(')')
+	return f.CloseAndGetString(), nil
+}
+
+// createTableAsRowFiller populates the rows of a table created by a CREATE
+// TABLE ... AS statement within the statement's transaction, when the
+// statement declares columns which aren't populated by the source query.
+type createTableAsRowFiller struct {
+	// declared is set for each public column of the table which is populated
+	// by its DEFAULT expression.
+	declared     []bool
+	defaultExprs []tree.TypedExpr
+}
+
+// makeCreateTableAsRowFiller returns a createTableAsRowFiller for the table
+// created by a CREATE TABLE ... AS statement, or nil if the statement doesn't
+// declare any columns.
+func makeCreateTableAsRowFiller(
+	params runParams, n *tree.CreateTable, desc catalog.TableDescriptor,
+) (*createTableAsRowFiller, error) {
+	declared := createTableAsDeclaredColumns(n)
+	if len(declared) == 0 {
+		return nil, nil
+	}
+	cols := desc.PublicColumns()
+	defaultExprs, err := schemaexpr.MakeDefaultExprs(
+		params.ctx, cols, &transform.ExprTransformContext{}, params.EvalContext(), params.p.SemaCtx(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	rf := &createTableAsRowFiller{
+		declared:     make([]bool, len(cols)),
+		defaultExprs: defaultExprs,
+	}
+	for i, col := range cols {
+		_, rf.declared[i] = declared[tree.Name(col.GetName())]
+	}
+	return rf, nil
+}
+
+// fill populates row, which has a datum for each public column of the table,
+// with the values produced by the source query for a row, and the values of
+// the DEFAULT expressions of the declared columns.
+func (rf *createTableAsRowFiller) fill(
+	ctx context.Context, evalCtx *eval.Context, row, values tree.Datums,
+) error {
+	var valIdx int
+	for i := range row {
+		if !rf.declared[i] {
+			row[i] = values[valIdx]
+			valIdx++
+			continue
+		}
+		d, err := eval.Expr(ctx, evalCtx, rf.defaultExprs[i])
+		if err != nil {
+			return err
+		}
+		row[i] = d
+	}
+	return nil
+}
@@ -0,0 +1,252 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/tabledesc"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgnotice"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/cockroachdb/errors"
+)
+
+// createTableAsDistributeSampleRows bounds the memory used to sample the source
+// query of a CREATE TABLE AS ... WITH (distribute = true) statement.
+var createTableAsDistributeSampleRows = settings.RegisterIntSetting(
+	settings.TenantWritable,
+	"sql.create_table_as.distribute.sample_rows",
+	"the number of rows of the source query sampled by CREATE TABLE AS ... "+
+		"WITH (distribute = true) to choose the split points of the new table",
+	100000,
+	settings.PositiveInt,
+)
+
+// createTableAsDistributeRanges is the number of ranges which the new table of
+// a CREATE TABLE AS ... WITH (distribute = true) statement is split into.
+var createTableAsDistributeRanges = settings.RegisterIntSetting(
+	settings.TenantWritable,
+	"sql.create_table_as.distribute.ranges",
+	"the number of ranges which CREATE TABLE AS ... WITH (distribute = true) "+
+		"splits the new table into before populating it",
+	16,
+	settings.PositiveInt,
+)
+
+// createTableAsDistributeMinRowsPerRange is the minimum number of rows per
+// range for the new table to be split. Smaller sources aren't worth splitting.
+const createTableAsDistributeMinRowsPerRange = 1000
+
+// createTableAsDistributeMaxReadFactor bounds the number of rows of the source
+// query read to sample it, as a multiple of the optimizer's estimate of its
+// number of rows, in case the estimate is stale.
+const createTableAsDistributeMaxReadFactor = 2
+
+// createTableAsDistributeSplitExpiration is how long the split points of the
+// new table are kept, which only needs to cover the backfill.
+const createTableAsDistributeSplitExpiration = time.Hour
+
+// distributeCreateTableAs pre-splits the primary index of the new table of a
+// CREATE TABLE AS statement, at split points chosen by sampling the primary
+// key values produced by the source query. This spreads the writes of the
+// backfill evenly across ranges, even when the primary key values of the
+// source are skewed. Similar to IMPORT, at most
+// sql.create_table_as.distribute.sample_rows rows are sampled and the table
+// isn't split if the source is small. Whether the source is small is first
+// decided from the optimizer's estimate of its number of rows, sourceRowCount,
+// so that small sources aren't read.
+func distributeCreateTableAs(
+	params runParams, n *tree.CreateTable, desc *tabledesc.Mutable, sourceRowCount float64,
+) error {
+	// Secondary tenants do not have mandatory split points between tables or
+	// indexes, so don't split theirs either.
+	if !params.ExecCfg().Codec.ForSystemTenant() {
+		params.p.BufferClientNotice(params.ctx, pgnotice.Newf(
+			"table %q was not pre-split; %s is not supported by secondary tenants",
+			desc.GetName(), tree.CreateTableAsDistributeStorageParam,
+		))
+		return nil
+	}
+	// Without a primary key specified by the user, the primary key is the
+	// generated rowid column, whose values can't be sampled up front.
+	keyOrdinals, ok := createTableAsPrimaryKeyOrdinals(n, desc)
+	if !ok {
+		params.p.BufferClientNotice(params.ctx, pgnotice.Newf(
+			"table %q was not pre-split; %s requires a PRIMARY KEY on columns of the source query",
+			desc.GetName(), tree.CreateTableAsDistributeStorageParam,
+		))
+		return nil
+	}
+
+	sv := &params.ExecCfg().Settings.SV
+	numRanges := int(createTableAsDistributeRanges.Get(sv))
+	minRows := numRanges * createTableAsDistributeMinRowsPerRange
+	if numRanges < 2 || sourceRowCount < float64(minRows) {
+		log.VEventf(params.ctx, 2, "not pre-splitting table %d; estimated %.0f rows for %d ranges",
+			desc.GetID(), sourceRowCount, numRanges)
+		return nil
+	}
+	keys, numRows, err := sampleCreateTableAsKeys(
+		params, desc, keyOrdinals, createTableAsDistributeSampleRows.Get(sv), sourceRowCount,
+	)
+	if err != nil {
+		return err
+	}
+	// The estimate may have been too large.
+	if numRows < int64(minRows) || len(keys) < numRanges {
+		log.VEventf(params.ctx, 2, "not pre-splitting table %d; read %d rows and sampled %d for %d ranges",
+			desc.GetID(), numRows, len(keys), numRanges)
+		return nil
+	}
+
+	splitKeys := createTableAsSplitKeys(keys, numRanges)
+	log.Infof(params.ctx, "pre-splitting table %d at %d sampled split points",
+		desc.GetID(), len(splitKeys))
+	db := params.ExecCfg().DB
+	expirationTime := db.Clock().Now().Add(createTableAsDistributeSplitExpiration.Nanoseconds(), 0)
+	for _, key := range splitKeys {
+		if err := splitAndScatter(params.ctx, db, key, expirationTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createTableAsPrimaryKeyOrdinals returns the ordinals of the columns of the
+// source query of a CREATE TABLE AS statement, which make up the primary key
+// of the new table, in the order of the primary key. It returns false if the
+// user didn't specify a primary key, or a primary key column isn't produced by
+// the source query.
+func createTableAsPrimaryKeyOrdinals(
+	n *tree.CreateTable, desc catalog.TableDescriptor,
+) ([]int, bool) {
+	if !n.AsHasUserSpecifiedPrimaryKey() {
+		return nil, false
+	}
+	sourceOrdinals := make(map[tree.Name]int)
+	for _, def := range n.Defs {
+		if d, ok := def.(*tree.ColumnTableDef); ok {
+			sourceOrdinals[d.Name] = len(sourceOrdinals)
+		}
+	}
+	pk := desc.GetPrimaryIndex()
+	ordinals := make([]int, pk.NumKeyColumns())
+	for i := range ordinals {
+		ord, ok := sourceOrdinals[tree.Name(pk.GetKeyColumnName(i))]
+		if !ok {
+			return nil, false
+		}
+		ordinals[i] = ord
+	}
+	return ordinals, true
+}
+
+// sampleCreateTableAsKeys reads the source query of the new table of a CREATE
+// TABLE AS statement, as the session user, and returns the primary index keys
+// of a uniform random sample of at most sampleSize of its rows, sorted, along
+// with the number of rows read. Each row is sampled with the probability which
+// is expected to yield sampleSize rows given the estimated number of rows of
+// the source, sourceRowCount, and reservoir sampling caps the sample at
+// sampleSize rows, so that the sample is representative of the whole source
+// rather than of the rows which happen to be read first. At most
+// createTableAsDistributeMaxReadFactor times sourceRowCount rows are read.
+func sampleCreateTableAsKeys(
+	params runParams,
+	desc *tabledesc.Mutable,
+	keyOrdinals []int,
+	sampleSize int64,
+	sourceRowCount float64,
+) (keys []roachpb.Key, numRows int64, _ error) {
+	pk := desc.GetPrimaryIndex()
+	var colMap catalog.TableColMap
+	for i := 0; i < pk.NumKeyColumns(); i++ {
+		colMap.Set(pk.GetKeyColumnID(i), i)
+	}
+	prefix := rowenc.MakeIndexKeyPrefix(params.ExecCfg().Codec, desc.GetID(), pk.GetID())
+	probability := math.Min(1, float64(sampleSize)/sourceRowCount)
+	maxRows := int64(math.Ceil(sourceRowCount * createTableAsDistributeMaxReadFactor))
+	rng, _ := randutil.NewPseudoRand()
+
+	it, err := params.p.InternalSQLTxn().QueryIteratorEx(
+		params.ctx,
+		"create-table-as-sample",
+		params.p.Txn(),
+		sessiondata.InternalExecutorOverride{User: params.p.User()},
+		fmt.Sprintf("SELECT * FROM (%s) AS src LIMIT %d", desc.GetCreateQuery(), maxRows),
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() { _ = it.Close() }()
+
+	var numSampled int64
+	values := make(tree.Datums, len(keyOrdinals))
+	for {
+		ok, err := it.Next(params.ctx)
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "sampling the source of CREATE TABLE AS")
+		}
+		if !ok {
+			break
+		}
+		numRows++
+		if rng.Float64() >= probability {
+			continue
+		}
+		row := it.Cur()
+		for i, ord := range keyOrdinals {
+			values[i] = row[ord]
+		}
+		key, containsNull, err := rowenc.EncodeIndexKey(desc, pk, colMap, values, prefix)
+		if err != nil {
+			return nil, 0, err
+		}
+		if containsNull {
+			// The row will fail the primary key's NOT NULL constraint during
+			// the backfill.
+			continue
+		}
+		numSampled++
+		if int64(len(keys)) < sampleSize {
+			keys = append(keys, key)
+		} else if i := rng.Int63n(numSampled); i < sampleSize {
+			keys[i] = key
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+	return keys, numRows, nil
+}
+
+// createTableAsSplitKeys returns the split points which divide the sorted
+// sample of keys into numRanges ranges with the same number of sampled keys.
+// Duplicate split points, due to duplicate keys, are omitted.
+func createTableAsSplitKeys(keys []roachpb.Key, numRanges int) []roachpb.Key {
+	var splitKeys []roachpb.Key
+	for i := 1; i < numRanges; i++ {
+		key := keys[i*len(keys)/numRanges]
+		if len(splitKeys) > 0 && splitKeys[len(splitKeys)-1].Equal(key) {
+			continue
+		}
+		splitKeys = append(splitKeys, key)
+	}
+	return splitKeys
+}
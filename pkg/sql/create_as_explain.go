@@ -0,0 +1,290 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descs"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// createTableAsExplainAnalyzeAlias is the alias of the materialized output of
+// an EXPLAIN ANALYZE source of a CREATE TABLE AS statement, when the source
+// isn't aliased.
+const createTableAsExplainAnalyzeAlias = "explain"
+
+// materializeCreateTableAsExplainAnalyze returns a copy of a CREATE TABLE AS
+// statement, in which each [EXPLAIN ANALYZE ...] source in the source query,
+// including those nested in joins, subqueries, set operations and common
+// table expressions, is replaced with a VALUES clause of the textual output of
+// the EXPLAIN ANALYZE. The statement itself is never modified, since it may be
+// shared, e.g. by a prepared statement, and is returned as is if it has no
+// EXPLAIN ANALYZE sources.
+//
+// EXPLAIN ANALYZE can only be used as a top-level statement, since it executes
+// the inner statement. The source query of a CREATE TABLE AS statement is
+// planned once to type the columns of the new table, and executed again by
+// the schema changer to populate it, so the EXPLAIN ANALYZE is executed here
+// instead, exactly once, and both use its output.
+//
+// If the table already exists, the statement either fails or, with IF NOT
+// EXISTS, does nothing, so the EXPLAIN ANALYZE is not executed and its source
+// is replaced with an empty one.
+func (p *planner) materializeCreateTableAsExplainAnalyze(
+	ctx context.Context, n *tree.CreateTable,
+) (*tree.CreateTable, error) {
+	var checkedExists, exists bool
+	materialize := func(explain *tree.ExplainAnalyze) (tree.SelectStatement, colinfo.ResultColumns, error) {
+		if !checkedExists {
+			var err error
+			if exists, err = p.createTableAsTargetExists(ctx, n); err != nil {
+				return nil, nil, err
+			}
+			checkedExists = true
+		}
+		if exists {
+			return &tree.SelectClause{
+				Exprs: tree.SelectExprs{{Expr: &tree.CastExpr{
+					Expr: tree.DNull, Type: types.String, SyntaxMode: tree.CastShort,
+				}}},
+				Where: tree.NewWhere(tree.AstWhere, tree.DBoolFalse),
+			}, colinfo.ExplainPlanColumns, nil
+		}
+
+		rows, cols, err := p.InternalSQLTxn().QueryBufferedExWithCols(
+			ctx,
+			"create-table-as-explain-analyze",
+			p.Txn(),
+			sessiondata.NoSessionDataOverride,
+			tree.AsString(explain),
+		)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "executing the EXPLAIN ANALYZE source of CREATE TABLE AS")
+		}
+		values := &tree.ValuesClause{Rows: make([]tree.Exprs, len(rows))}
+		for i, row := range rows {
+			values.Rows[i] = make(tree.Exprs, len(row))
+			for j, d := range row {
+				values.Rows[i][j] = d
+			}
+		}
+		return values, cols, nil
+	}
+
+	source, changed, err := materializeExplainAnalyzeInSelect(n.AsSource, materialize)
+	if err != nil || !changed {
+		return n, err
+	}
+	ret := *n
+	ret.AsSource = source
+	return &ret, nil
+}
+
+// createTableAsTargetExists returns whether a relation with the name of the
+// table created by a CREATE TABLE AS statement already exists.
+func (p *planner) createTableAsTargetExists(
+	ctx context.Context, n *tree.CreateTable,
+) (bool, error) {
+	db, schema, _, err := p.ResolveTargetObject(ctx, n.Table.ToUnresolvedObjectName())
+	if err != nil {
+		return false, err
+	}
+	if n.Persistence.IsTemporary() {
+		schema, err = p.Descriptors().ByName(p.txn).MaybeGet().Schema(ctx, db, p.TemporarySchemaName())
+		if err != nil || schema == nil {
+			// The temporary schema of the session hasn't been created yet, so
+			// it has no tables.
+			return false, err
+		}
+	}
+	desc, err := descs.GetDescriptorCollidingWithObjectName(
+		ctx, p.Descriptors(), p.txn, db.GetID(), schema.GetID(), n.Table.Table(),
+	)
+	return desc != nil, err
+}
+
+// explainAnalyzeMaterializer returns the statement which replaces an EXPLAIN
+// ANALYZE source, along with its result columns.
+type explainAnalyzeMaterializer func(*tree.ExplainAnalyze) (tree.SelectStatement, colinfo.ResultColumns, error)
+
+// materializeExplainAnalyzeInSelect replaces the EXPLAIN ANALYZE sources in the
+// given select, returning a copy of it if any were replaced. The given select
+// is not modified.
+func materializeExplainAnalyzeInSelect(
+	sel *tree.Select, m explainAnalyzeMaterializer,
+) (*tree.Select, bool, error) {
+	var with *tree.With
+	if sel.With != nil {
+		for i, cte := range sel.With.CTEList {
+			cteSel, ok := cte.Stmt.(*tree.Select)
+			if !ok {
+				continue
+			}
+			newSel, changed, err := materializeExplainAnalyzeInSelect(cteSel, m)
+			if err != nil {
+				return nil, false, err
+			}
+			if !changed {
+				continue
+			}
+			if with == nil {
+				w := *sel.With
+				w.CTEList = append([]*tree.CTE(nil), sel.With.CTEList...)
+				with = &w
+			}
+			newCTE := *cte
+			newCTE.Stmt = newSel
+			with.CTEList[i] = &newCTE
+		}
+	}
+	stmt, changed, err := materializeExplainAnalyzeInSelectStatement(sel.Select, m)
+	if err != nil {
+		return nil, false, err
+	}
+	if !changed && with == nil {
+		return sel, false, nil
+	}
+	ret := *sel
+	ret.Select = stmt
+	if with != nil {
+		ret.With = with
+	}
+	return &ret, true, nil
+}
+
+// materializeExplainAnalyzeInSelectStatement is like
+// materializeExplainAnalyzeInSelect, for a select statement.
+func materializeExplainAnalyzeInSelectStatement(
+	stmt tree.SelectStatement, m explainAnalyzeMaterializer,
+) (tree.SelectStatement, bool, error) {
+	switch t := stmt.(type) {
+	case *tree.SelectClause:
+		var tables tree.TableExprs
+		for i, expr := range t.From.Tables {
+			newExpr, changed, err := materializeExplainAnalyzeInTableExpr(expr, m)
+			if err != nil {
+				return nil, false, err
+			}
+			if !changed {
+				continue
+			}
+			if tables == nil {
+				tables = append(tree.TableExprs(nil), t.From.Tables...)
+			}
+			tables[i] = newExpr
+		}
+		if tables == nil {
+			return stmt, false, nil
+		}
+		ret := *t
+		ret.From.Tables = tables
+		return &ret, true, nil
+
+	case *tree.ParenSelect:
+		sel, changed, err := materializeExplainAnalyzeInSelect(t.Select, m)
+		if err != nil || !changed {
+			return stmt, false, err
+		}
+		return &tree.ParenSelect{Select: sel}, true, nil
+
+	case *tree.UnionClause:
+		left, leftChanged, err := materializeExplainAnalyzeInSelect(t.Left, m)
+		if err != nil {
+			return nil, false, err
+		}
+		right, rightChanged, err := materializeExplainAnalyzeInSelect(t.Right, m)
+		if err != nil {
+			return nil, false, err
+		}
+		if !leftChanged && !rightChanged {
+			return stmt, false, nil
+		}
+		ret := *t
+		ret.Left, ret.Right = left, right
+		return &ret, true, nil
+	}
+	return stmt, false, nil
+}
+
+// materializeExplainAnalyzeInTableExpr is like
+// materializeExplainAnalyzeInSelect, for a FROM item.
+func materializeExplainAnalyzeInTableExpr(
+	expr tree.TableExpr, m explainAnalyzeMaterializer,
+) (tree.TableExpr, bool, error) {
+	switch t := expr.(type) {
+	case *tree.AliasedTableExpr:
+		switch source := t.Expr.(type) {
+		case *tree.StatementSource:
+			explain, ok := source.Statement.(*tree.ExplainAnalyze)
+			if !ok {
+				return expr, false, nil
+			}
+			stmt, cols, err := m(explain)
+			if err != nil {
+				return nil, false, err
+			}
+			ret := *t
+			if len(ret.As.Alias) == 0 {
+				ret.As.Alias = createTableAsExplainAnalyzeAlias
+			}
+			if len(ret.As.Cols) == 0 {
+				ret.As.Cols = make(tree.ColumnDefList, len(cols))
+				for i, col := range cols {
+					ret.As.Cols[i] = tree.ColumnDef{Name: tree.Name(col.Name)}
+				}
+			}
+			ret.Expr = &tree.Subquery{
+				Select: &tree.ParenSelect{Select: &tree.Select{Select: stmt}},
+			}
+			return &ret, true, nil
+
+		case *tree.Subquery:
+			sel, changed, err := materializeExplainAnalyzeInSelectStatement(source.Select, m)
+			if err != nil || !changed {
+				return expr, false, err
+			}
+			subquery := *source
+			subquery.Select = sel
+			ret := *t
+			ret.Expr = &subquery
+			return &ret, true, nil
+		}
+
+	case *tree.ParenTableExpr:
+		inner, changed, err := materializeExplainAnalyzeInTableExpr(t.Expr, m)
+		if err != nil || !changed {
+			return expr, false, err
+		}
+		return &tree.ParenTableExpr{Expr: inner}, true, nil
+
+	case *tree.JoinTableExpr:
+		left, leftChanged, err := materializeExplainAnalyzeInTableExpr(t.Left, m)
+		if err != nil {
+			return nil, false, err
+		}
+		right, rightChanged, err := materializeExplainAnalyzeInTableExpr(t.Right, m)
+		if err != nil {
+			return nil, false, err
+		}
+		if !leftChanged && !rightChanged {
+			return expr, false, nil
+		}
+		ret := *t
+		ret.Left, ret.Right = left, right
+		return &ret, true, nil
+	}
+	return expr, false, nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"fmt"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/paramparse"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+// columnFamiliesForCreateTableAs returns the column families set by the
+// column_families storage parameter of a CREATE TABLE AS statement, if any.
+// The families are parsed as the table elements of a CREATE TABLE statement,
+// so they are written as they would be in one, and only FAMILY definitions are
+// allowed.
+func columnFamiliesForCreateTableAs(
+	params runParams, n *tree.CreateTable,
+) ([]*tree.FamilyTableDef, error) {
+	key := tree.CreateTableAsColumnFamiliesStorageParam
+	value := n.StorageParams.GetVal(key)
+	if value == nil {
+		return nil, nil
+	}
+	expr := paramparse.UnresolvedNameToStrVal(value)
+	typedExpr, err := tree.TypeCheck(params.ctx, expr, params.p.SemaCtx(), types.String)
+	if err != nil {
+		return nil, err
+	}
+	s, err := paramparse.DatumAsString(params.ctx, params.p.EvalContext(), key, typedExpr)
+	if err != nil {
+		return nil, err
+	}
+	invalid := func() error {
+		return pgerror.Newf(pgcode.InvalidParameterValue,
+			"invalid value for %s: expected FAMILY definitions, e.g. 'FAMILY f1 (a, b), FAMILY f2 (c)'", key)
+	}
+	stmt, err := parser.ParseOne(fmt.Sprintf("CREATE TABLE t (%s)", s))
+	if err != nil {
+		return nil, pgerror.Wrapf(err, pgcode.InvalidParameterValue, "invalid value for %s", key)
+	}
+	// The value may close the table elements, and be followed by other clauses
+	// of the statement, which aren't families.
+	create, ok := stmt.AST.(*tree.CreateTable)
+	if !ok || create.As() || len(create.Defs) == 0 || create.PartitionByTable != nil ||
+		create.Locality != nil || create.StorageParams != nil || create.OnCommit != tree.CreateTableOnCommitUnset {
+		return nil, invalid()
+	}
+	families := make([]*tree.FamilyTableDef, 0, len(create.Defs))
+	for _, def := range create.Defs {
+		family, ok := def.(*tree.FamilyTableDef)
+		if !ok {
+			return nil, invalid()
+		}
+		families = append(families, family)
+	}
+	return families, nil
+}
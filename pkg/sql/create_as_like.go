@@ -0,0 +1,188 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/tabledesc"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+)
+
+// createTableAsLikeDef returns the LIKE table definition of a CREATE TABLE ...
+// AS statement, or nil if the statement doesn't have one. The parser only
+// accepts a LIKE definition as the sole table definition of the statement.
+func createTableAsLikeDef(n *tree.CreateTable) *tree.LikeTableDef {
+	if len(n.Defs) != 1 {
+		return nil
+	}
+	d, _ := n.Defs[0].(*tree.LikeTableDef)
+	return d
+}
+
+// newTableDescIfAsLike creates the descriptor of a table created by a CREATE
+// TABLE (LIKE ...) AS statement. The columns of the table, and the defaults,
+// constraints and indexes selected by the INCLUDING options, are copied from
+// the LIKE table, while every column is populated by the source query, in
+// order.
+func newTableDescIfAsLike(
+	params runParams,
+	p *tree.CreateTable,
+	db catalog.DatabaseDescriptor,
+	sc catalog.SchemaDescriptor,
+	id descpb.ID,
+	creationTime hlc.Timestamp,
+	resultColumns []colinfo.ResultColumn,
+	privileges *catpb.PrivilegeDescriptor,
+	evalContext *eval.Context,
+) (*tabledesc.Mutable, error) {
+	like := createTableAsLikeDef(p)
+	mode, err := createTableAsPrecisionLossMode(params, p)
+	if err != nil {
+		return nil, err
+	}
+	defs, err := replaceLikeTableOpts(p, params)
+	if err != nil {
+		return nil, err
+	}
+	copied, err := validateCreateTableAsLikeColumns(like, defs, resultColumns, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	// The statement keeps its LIKE definition, so that the copied columns with
+	// a DEFAULT expression aren't mistaken for columns declared by the
+	// statement, which aren't populated by the source query.
+	expanded := *p
+	expanded.Defs = defs
+	desc, err := newTableDesc(
+		params,
+		&expanded,
+		db, sc, id,
+		creationTime,
+		privileges,
+		nil, /* affected */
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateCreateTableAsLikeDesc(like, desc, copied); err != nil {
+		return nil, err
+	}
+
+	// The rows produced by the source query aren't checked against the
+	// constraints copied from the LIKE table as the table is populated, so
+	// those constraints are left unvalidated. They are still enforced for
+	// subsequent writes, and can be validated with VALIDATE CONSTRAINT.
+	for _, ck := range desc.Checks {
+		ck.Validity = descpb.ConstraintValidity_Unvalidated
+	}
+	for i := range desc.UniqueWithoutIndexConstraints {
+		desc.UniqueWithoutIndexConstraints[i].Validity = descpb.ConstraintValidity_Unvalidated
+	}
+
+	createQuery, err := getFinalSourceQuery(params, p.AsSource, evalContext)
+	if err != nil {
+		return nil, err
+	}
+	// The values of source columns whose type differs from the column they
+	// populate are cast by the query populating the table.
+	desc.CreateQuery = castCreateTableAsQuery(
+		createQuery, mode, desc, resultColumns, createTableAsCastOrdinals(desc, resultColumns),
+	)
+	return desc, nil
+}
+
+// validateCreateTableAsLikeColumns checks that the source query of a CREATE
+// TABLE (LIKE ...) AS statement produces a value for each column copied from
+// the LIKE table, in order and of the same type, unless the precision_loss
+// storage parameter allows a different type. With the widen mode, the type of
+// the copied column is replaced by the type of the source column. It returns
+// the names of the copied columns.
+func validateCreateTableAsLikeColumns(
+	like *tree.LikeTableDef,
+	defs tree.TableDefs,
+	resultColumns []colinfo.ResultColumn,
+	mode createTableAsPrecisionLoss,
+) (map[tree.Name]struct{}, error) {
+	var cols []*tree.ColumnTableDef
+	for _, def := range defs {
+		d, ok := def.(*tree.ColumnTableDef)
+		if !ok {
+			continue
+		}
+		if d.Computed.Computed {
+			return nil, pgerror.Newf(pgcode.FeatureNotSupported,
+				"computed column %q copied from %s can't be populated by CREATE TABLE AS",
+				string(d.Name), like.Name.String())
+		}
+		if d.Hidden {
+			return nil, pgerror.Newf(pgcode.FeatureNotSupported,
+				"hidden column %q copied from %s can't be populated by CREATE TABLE AS",
+				string(d.Name), like.Name.String())
+		}
+		cols = append(cols, d)
+	}
+	if len(cols) != len(resultColumns) {
+		return nil, pgerror.Newf(pgcode.InvalidTableDefinition,
+			"%s has %d column%s, but data source has %d column%s",
+			like.Name.String(), len(cols), util.Pluralize(int64(len(cols))),
+			len(resultColumns), util.Pluralize(int64(len(resultColumns))))
+	}
+	copied := make(map[tree.Name]struct{}, len(cols))
+	for i, d := range cols {
+		typ := d.Type.(*types.T)
+		resTyp := resultColumns[i].Typ
+		if resTyp.Family() != types.UnknownFamily && !resTyp.Identical(typ) {
+			resolved, ok := resolveCreateTableAsLikeType(mode, typ, resTyp)
+			if !ok {
+				return nil, errCreateTableAsLikeType(like, d.Name, typ, resultColumns[i])
+			}
+			d.Type = resolved
+		}
+		copied[d.Name] = struct{}{}
+	}
+	return copied, nil
+}
+
+// validateCreateTableAsLikeDesc checks that every column of a table created by
+// a CREATE TABLE (LIKE ...) AS statement is either copied from the LIKE table,
+// and populated by the source query, or the implicit rowid column. Other
+// columns, e.g. those added for hash-sharded or expression indexes copied from
+// the LIKE table, aren't supported, nor are partial indexes.
+func validateCreateTableAsLikeDesc(
+	like *tree.LikeTableDef, desc *tabledesc.Mutable, copied map[tree.Name]struct{},
+) error {
+	if partial := desc.PartialIndexes(); len(partial) > 0 {
+		return pgerror.Newf(pgcode.FeatureNotSupported,
+			"partial index %q copied from %s is not supported by CREATE TABLE AS",
+			partial[0].GetName(), like.Name.String())
+	}
+	for _, col := range desc.PublicColumns() {
+		if desc.IsPrimaryIndexDefaultRowID() && col.GetID() == desc.GetPrimaryIndex().GetKeyColumnID(0) {
+			continue
+		}
+		if _, ok := copied[tree.Name(col.GetName())]; !ok {
+			return pgerror.Newf(pgcode.FeatureNotSupported,
+				"column %q, added for a definition copied from %s, can't be populated by CREATE TABLE AS",
+				col.GetName(), like.Name.String())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/config/zonepb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/tabledesc"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgnotice"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+)
+
+// partitionByForCreateTableAs returns the PARTITION BY clause which recreates
+// the partitioning of the primary index of the source table of a CREATE TABLE
+// AS statement on the new table, with the partitioning columns renamed after
+// the columns of the new table they are copied into. This only applies when
+// the source query passes through all the columns of a single table, in
+// order, i.e. SELECT * FROM t. Otherwise, or if the source table isn't
+// partitioned, a notice is sent and nil is returned.
+//
+// The column definitions of the statement must already name all the columns
+// populated by the source query.
+func partitionByForCreateTableAs(
+	params runParams, n *tree.CreateTable, asCols colinfo.ResultColumns,
+) (*tree.PartitionBy, error) {
+	srcTable, err := createTableAsPassThroughSource(params, asCols)
+	if err != nil {
+		return nil, err
+	}
+	if srcTable == nil {
+		params.p.BufferClientNotice(params.ctx, pgnotice.Newf(
+			"partitioning was not copied to table %q; %s only applies when selecting all "+
+				"the columns of a single table",
+			n.Table.Table(), tree.CreateTableAsCopyPartitioningStorageParam,
+		))
+		return nil, nil
+	}
+	srcIdx := srcTable.GetPrimaryIndex()
+	srcPart := srcIdx.GetPartitioning()
+	if srcPart.NumColumns() == 0 {
+		params.p.BufferClientNotice(params.ctx, pgnotice.Newf(
+			"partitioning was not copied to table %q; table %q is not partitioned",
+			n.Table.Table(), srcTable.GetName(),
+		))
+		return nil, nil
+	}
+	// Implicit partitioning, such as that of REGIONAL BY ROW tables, is
+	// derived from the locality or PARTITION ALL BY clause of the table, rather
+	// than from the partitioning of its primary index.
+	if srcTable.IsPartitionAllBy() || srcPart.NumImplicitColumns() > 0 {
+		params.p.BufferClientNotice(params.ctx, pgnotice.Newf(
+			"partitioning was not copied to table %q; the implicit partitioning of "+
+				"table %q is not supported",
+			n.Table.Table(), srcTable.GetName(),
+		))
+		return nil, nil
+	}
+
+	partitionBy, err := partitionByFromTableDescImpl(
+		params.ExecCfg().Codec, srcTable, srcIdx, srcPart, 0, /* colOffset */
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// The source query passes through the visible columns of the source table,
+	// in order, so the i-th column populated by the query holds the i-th
+	// visible column of the source table.
+	var populated []tree.Name
+	for _, def := range n.Defs {
+		if d, ok := def.(*tree.ColumnTableDef); ok && !d.IsCreateAsDeclared() {
+			populated = append(populated, d.Name)
+		}
+	}
+	newNames := make(map[tree.Name]tree.Name, len(populated))
+	for i, col := range srcTable.VisibleColumns() {
+		if i < len(populated) {
+			newNames[tree.Name(col.GetName())] = populated[i]
+		}
+	}
+	var renameFields func(partitionBy *tree.PartitionBy) error
+	renameFields = func(partitionBy *tree.PartitionBy) error {
+		if partitionBy == nil {
+			return nil
+		}
+		for i, field := range partitionBy.Fields {
+			newName, ok := newNames[field]
+			if !ok {
+				return errors.AssertionFailedf(
+					"partitioning column %q of table %q is not copied", field, srcTable.GetName())
+			}
+			partitionBy.Fields[i] = newName
+		}
+		for i := range partitionBy.List {
+			if err := renameFields(partitionBy.List[i].Subpartition); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := renameFields(partitionBy); err != nil {
+		return nil, err
+	}
+
+	// The partitioning columns must prefix the primary key of the new table,
+	// which is only the case if the user declared it to be so.
+	fields := partitionByColumns(partitionBy)
+	pkCols := createTableAsPrimaryKeyColumns(n)
+	prefixesPK := len(pkCols) >= len(fields)
+	for i := 0; prefixesPK && i < len(fields); i++ {
+		prefixesPK = pkCols[i] == fields[i]
+	}
+	if !prefixesPK {
+		return nil, errors.WithHintf(
+			pgerror.Newf(pgcode.InvalidTableDefinition,
+				"%s requires the primary key of table %q to begin with the partitioning "+
+					"columns of table %q",
+				tree.CreateTableAsCopyPartitioningStorageParam, n.Table.Table(), srcTable.GetName()),
+			"declare a PRIMARY KEY (%s, ...)", fields.String())
+	}
+	return partitionBy, nil
+}
+
+// partitionByColumns returns the names of the columns partitioned on by the
+// given PARTITION BY clause, including those of its deepest subpartitioning.
+func partitionByColumns(partitionBy *tree.PartitionBy) tree.NameList {
+	if partitionBy == nil {
+		return nil
+	}
+	var deepest tree.NameList
+	for i := range partitionBy.List {
+		if sub := partitionByColumns(partitionBy.List[i].Subpartition); len(sub) > len(deepest) {
+			deepest = sub
+		}
+	}
+	return append(append(tree.NameList(nil), partitionBy.Fields...), deepest...)
+}
+
+// createTableAsPrimaryKeyColumns returns the names of the columns of the
+// primary key declared by a CREATE TABLE AS statement, in order, or nil if the
+// statement doesn't declare a primary key.
+func createTableAsPrimaryKeyColumns(n *tree.CreateTable) tree.NameList {
+	for _, def := range n.Defs {
+		switch d := def.(type) {
+		case *tree.ColumnTableDef:
+			if d.PrimaryKey.IsPrimaryKey {
+				return tree.NameList{d.Name}
+			}
+		case *tree.UniqueConstraintTableDef:
+			if d.PrimaryKey {
+				cols := make(tree.NameList, len(d.Columns))
+				for i, elem := range d.Columns {
+					cols[i] = elem.Column
+				}
+				return cols
+			}
+		}
+	}
+	return nil
+}
+
+// copyPartitionZoneConfigsForCreateTableAs copies the zone configs of the
+// partitions of the primary index of the source table of a CREATE TABLE AS
+// statement onto the same partitions of the new table, whose partitioning was
+// copied by partitionByForCreateTableAs. The zone config of the source table
+// itself isn't copied, so that the new table otherwise inherits the zone config
+// of its database.
+func copyPartitionZoneConfigsForCreateTableAs(
+	params runParams, desc *tabledesc.Mutable, asCols colinfo.ResultColumns,
+) error {
+	newPart := desc.GetPrimaryIndex().GetPartitioning()
+	if newPart.NumColumns() == 0 {
+		return nil
+	}
+	srcTable, err := createTableAsPassThroughSource(params, asCols)
+	if err != nil || srcTable == nil {
+		return err
+	}
+	srcZone, err := params.p.Descriptors().GetZoneConfig(params.ctx, params.p.txn, srcTable.GetID())
+	if err != nil || srcZone == nil {
+		return err
+	}
+
+	partitions := make(map[string]struct{})
+	_ = newPart.ForEachPartitionName(func(name string) error {
+		partitions[name] = struct{}{}
+		return nil
+	})
+	z := zonepb.NewZoneConfig()
+	z.DeleteTableConfig()
+	srcIndexID := uint32(srcTable.GetPrimaryIndexID())
+	for _, s := range srcZone.ZoneConfigProto().Subzones {
+		if s.IndexID != srcIndexID || s.PartitionName == "" {
+			continue
+		}
+		if _, ok := partitions[s.PartitionName]; !ok {
+			continue
+		}
+		s.IndexID = uint32(desc.GetPrimaryIndexID())
+		z.SetSubzone(s)
+	}
+	if len(z.Subzones) == 0 {
+		return nil
+	}
+	_, err = writeZoneConfig(
+		params.ctx, params.p.InternalSQLTxn(), desc.GetID(), desc, z,
+		nil /* expectedExistingRawBytes */, params.ExecCfg(), true, /* hasNewSubzones */
+		params.p.extendedEvalCtx.Tracing.KVTracingEnabled(),
+	)
+	return err
+}
@@ -0,0 +1,241 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
+	"github.com/cockroachdb/cockroach/pkg/sql/lexbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/paramparse"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/cast"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// createTableAsPrecisionLoss controls how a CREATE TABLE (LIKE ...) AS
+// statement populates a column copied from the LIKE table with the values of
+// a source column of a different type, which may not fit in the column
+// without losing precision or being truncated. It is set with the
+// precision_loss storage parameter.
+type createTableAsPrecisionLoss int
+
+const (
+	// precisionLossReject rejects the statement if the type of a source column
+	// isn't identical to the type of the column it populates. This is the
+	// default.
+	precisionLossReject createTableAsPrecisionLoss = iota
+	// precisionLossError casts the values of the source column to the type of
+	// the column, and fails the statement on the first value which doesn't
+	// survive the cast unchanged.
+	precisionLossError
+	// precisionLossTruncate casts the values of the source column to the type
+	// of the column, truncating or rounding them as an explicit CAST would.
+	precisionLossTruncate
+	// precisionLossWiden promotes the type of the column to the type of the
+	// source column, as long as both types are of the same family.
+	precisionLossWiden
+)
+
+var createTableAsPrecisionLossModes = map[string]createTableAsPrecisionLoss{
+	"error":    precisionLossError,
+	"truncate": precisionLossTruncate,
+	"widen":    precisionLossWiden,
+}
+
+// createTableAsPrecisionLossMode returns the mode set by the precision_loss
+// storage parameter of a CREATE TABLE AS statement.
+func createTableAsPrecisionLossMode(
+	params runParams, n *tree.CreateTable,
+) (createTableAsPrecisionLoss, error) {
+	key := tree.CreateTableAsPrecisionLossStorageParam
+	value := n.StorageParams.GetVal(key)
+	if value == nil {
+		return precisionLossReject, nil
+	}
+	expr := paramparse.UnresolvedNameToStrVal(value)
+	typedExpr, err := tree.TypeCheck(params.ctx, expr, params.p.SemaCtx(), types.String)
+	if err != nil {
+		return 0, err
+	}
+	s, err := paramparse.DatumAsString(params.ctx, params.p.EvalContext(), key, typedExpr)
+	if err != nil {
+		return 0, err
+	}
+	mode, ok := createTableAsPrecisionLossModes[strings.ToLower(s)]
+	if !ok {
+		return 0, pgerror.Newf(pgcode.InvalidParameterValue,
+			"invalid value for %s: %q, must be one of error, truncate or widen", key, s)
+	}
+	return mode, nil
+}
+
+// resolveCreateTableAsLikeType returns the type of a column copied from the
+// LIKE table of a CREATE TABLE (LIKE ...) AS statement, whose type typ differs
+// from the type resTyp of the source column populating it. With the widen
+// mode, the column takes the type of the source column. With the error and
+// truncate modes, the column keeps its type, and the values of the source
+// column are cast to it.
+func resolveCreateTableAsLikeType(
+	mode createTableAsPrecisionLoss, typ, resTyp *types.T,
+) (_ *types.T, ok bool) {
+	switch mode {
+	case precisionLossWiden:
+		if typ.Family() == resTyp.Family() {
+			return resTyp, true
+		}
+	case precisionLossError, precisionLossTruncate:
+		if cast.ValidCast(resTyp, typ, cast.ContextAssignment) {
+			return typ, true
+		}
+	}
+	return nil, false
+}
+
+// createTableAsCastOrdinals returns the ordinals of the source columns whose
+// values must be cast to the type of the column they populate, i.e. the
+// column of the table with the same ordinal.
+func createTableAsCastOrdinals(
+	desc catalog.TableDescriptor, sourceCols colinfo.ResultColumns,
+) []int {
+	var ords []int
+	cols := desc.PublicColumns()
+	for i, c := range sourceCols {
+		if i < len(cols) && c.Typ.Family() != types.UnknownFamily && !c.Typ.Identical(cols[i].GetType()) {
+			ords = append(ords, i)
+		}
+	}
+	return ords
+}
+
+// castCreateTableAsQuery wraps the source query of a CREATE TABLE (LIKE ...)
+// AS statement, which is run by the schema change job populating the table,
+// so that the values of the given source columns are cast to the type of the
+// column they populate. With the error mode, the query fails on the first
+// value which doesn't survive the cast unchanged.
+func castCreateTableAsQuery(
+	query string,
+	mode createTableAsPrecisionLoss,
+	desc catalog.TableDescriptor,
+	sourceCols colinfo.ResultColumns,
+	ords []int,
+) string {
+	if len(ords) == 0 {
+		return query
+	}
+	cols := desc.PublicColumns()
+	names := make([]string, len(sourceCols))
+	exprs := make([]string, len(sourceCols))
+	for i := range sourceCols {
+		names[i] = fmt.Sprintf("c%d", i+1)
+		exprs[i] = names[i]
+	}
+	var checks []string
+	for _, i := range ords {
+		typ := cols[i].GetType().SQLString()
+		exprs[i] = fmt.Sprintf("CAST(%s AS %s)", names[i], typ)
+		if mode == precisionLossError {
+			msg := fmt.Sprintf("value of column %q would lose precision as %s", cols[i].GetName(), typ)
+			checks = append(checks, fmt.Sprintf(
+				"(CASE WHEN CAST(CAST(%[1]s AS %[2]s) AS %[3]s) IS DISTINCT FROM %[1]s "+
+					"THEN crdb_internal.force_error('%[4]s', %[5]s) = 0 ELSE true END)",
+				names[i], typ, sourceCols[i].Typ.SQLString(),
+				pgcode.NumericValueOutOfRange.String(), lexbase.EscapeSQLString(msg),
+			))
+		}
+	}
+	wrapped := fmt.Sprintf("SELECT %s FROM (%s) AS ctas_src (%s)",
+		strings.Join(exprs, ", "), query, strings.Join(names, ", "))
+	if len(checks) > 0 {
+		wrapped += " WHERE " + strings.Join(checks, " AND ")
+	}
+	return wrapped
+}
+
+// createTableAsCaster casts the values of the source columns of a CREATE
+// TABLE (LIKE ...) AS statement to the type of the column they populate, for
+// a table which is populated within the statement's transaction.
+type createTableAsCaster struct {
+	mode createTableAsPrecisionLoss
+	cols []catalog.Column
+	ords []int
+}
+
+// makeCreateTableAsCaster returns a createTableAsCaster for the statement, or
+// nil if no value needs to be cast.
+func makeCreateTableAsCaster(
+	params runParams, n *tree.CreateTable, desc catalog.TableDescriptor, sourceCols colinfo.ResultColumns,
+) (*createTableAsCaster, error) {
+	if createTableAsLikeDef(n) == nil {
+		return nil, nil
+	}
+	ords := createTableAsCastOrdinals(desc, sourceCols)
+	if len(ords) == 0 {
+		return nil, nil
+	}
+	mode, err := createTableAsPrecisionLossMode(params, n)
+	if err != nil {
+		return nil, err
+	}
+	return &createTableAsCaster{mode: mode, cols: desc.PublicColumns(), ords: ords}, nil
+}
+
+// cast casts the values of the row in place.
+func (c *createTableAsCaster) cast(ctx context.Context, evalCtx *eval.Context, row tree.Datums) error {
+	for _, i := range c.ords {
+		d := row[i]
+		if d == tree.DNull {
+			continue
+		}
+		typ := c.cols[i].GetType()
+		res, err := eval.PerformCast(ctx, evalCtx, d, typ)
+		if err != nil {
+			return err
+		}
+		if c.mode == precisionLossError {
+			back, err := eval.PerformCast(ctx, evalCtx, res, d.ResolvedType())
+			if err != nil {
+				return err
+			}
+			if cmp, err := back.CompareError(evalCtx, d); err != nil {
+				return err
+			} else if cmp != 0 {
+				return pgerror.Newf(pgcode.NumericValueOutOfRange,
+					"value of column %q would lose precision as %s", c.cols[i].GetName(), typ.SQLString())
+			}
+		}
+		row[i] = res
+	}
+	return nil
+}
+
+// errCreateTableAsLikeType is returned when a column copied from the LIKE
+// table of a CREATE TABLE (LIKE ...) AS statement can't be populated by the
+// source column of a different type, in the mode set by the precision_loss
+// storage parameter.
+func errCreateTableAsLikeType(
+	like *tree.LikeTableDef, colName tree.Name, typ *types.T, resCol colinfo.ResultColumn,
+) error {
+	err := pgerror.Newf(pgcode.DatatypeMismatch,
+		"column %q of %s is of type %s, but data source column %q is of type %s",
+		string(colName), like.Name.String(), typ.SQLString(),
+		resCol.Name, resCol.Typ.SQLString())
+	return errors.WithHintf(err,
+		"cast the results of the query to the types of the columns of the LIKE table, "+
+			"or set the %s storage parameter", tree.CreateTableAsPrecisionLossStorageParam)
+}
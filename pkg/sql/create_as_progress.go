@@ -0,0 +1,137 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/sql/isql"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgnotice"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowexec"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// immediateNoticeSender is implemented by the results which can send notices
+// to the client while the statement is still executing.
+type immediateNoticeSender interface {
+	SendNotice(ctx context.Context, notice pgnotice.Notice) error
+}
+
+// runCreatedJobs runs the jobs created by the transaction that was just
+// committed, and waits for them to complete. If the transaction consisted of a
+// CREATE TABLE AS statement, the number of rows ingested by its backfill is
+// periodically sent to the client as a notice while waiting.
+func (ex *connExecutor) runCreatedJobs(res ResultBase) error {
+	ctx := ex.ctxHolder.connCtx
+	jobIDs := ex.extraTxnState.jobs.created
+	sender, ok := res.(immediateNoticeSender)
+	if !ok || !ex.shouldSendCreateTableAsProgressNotices() {
+		return ex.server.cfg.JobRegistry.Run(ctx, jobIDs)
+	}
+
+	noticesCtx, stopNotices := context.WithCancel(ctx)
+	defer stopNotices()
+	g := ctxgroup.WithContext(noticesCtx)
+	g.GoCtx(func(ctx context.Context) error {
+		ex.sendCreateTableAsProgressNotices(ctx, sender, jobIDs)
+		return nil
+	})
+	err := ex.server.cfg.JobRegistry.Run(ctx, jobIDs)
+	stopNotices()
+	_ = g.Wait()
+	return err
+}
+
+// shouldSendCreateTableAsProgressNotices returns whether the progress of the
+// backfill of the current statement should be sent to the client. Only a
+// CREATE TABLE AS statement run in an implicit transaction is backfilled by a
+// job after its transaction commits, a statement run in an explicit
+// transaction ingests its rows inline, so there is no progress to report.
+func (ex *connExecutor) shouldSendCreateTableAsProgressNotices() bool {
+	ct, ok := ex.curStmtAST.(*tree.CreateTable)
+	if !ok || !ct.As() {
+		return false
+	}
+	sd := ex.sessionData()
+	return sd.CreateTableAsProgressNotices &&
+		pgnotice.DisplaySeverity(sd.NoticeDisplaySeverity) >= pgnotice.DisplaySeverityNotice &&
+		NoticesEnabled.Get(&ex.server.cfg.Settings.SV)
+}
+
+// sendCreateTableAsProgressNotices sends a notice with the number of rows
+// ingested by the backfill of the given jobs to the client, until ctx is
+// canceled. To avoid flooding the client, a notice is sent at most once per
+// sql.create_table_as.progress_interval, and only if more rows have been
+// ingested since the last one.
+func (ex *connExecutor) sendCreateTableAsProgressNotices(
+	ctx context.Context, sender immediateNoticeSender, jobIDs []jobspb.JobID,
+) {
+	var lastRows int64
+	timer := timeutil.NewTimer()
+	defer timer.Stop()
+	for {
+		timer.Reset(rowexec.CTASProgressInterval.Get(&ex.server.cfg.Settings.SV))
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			timer.Read = true
+		}
+
+		var rows int64
+		for _, jobID := range jobIDs {
+			n, err := readCreateTableAsProgress(ctx, ex.server.cfg.InternalDB, jobID)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Warningf(ctx, "failed to read the progress of job %d: %v", jobID, err)
+				}
+				return
+			}
+			rows += n
+		}
+		if rows <= lastRows {
+			continue
+		}
+		lastRows = rows
+		if err := sender.SendNotice(ctx, pgnotice.Newf(
+			"ingested %s rows", humanizeutil.Count(uint64(rows)),
+		)); err != nil {
+			return
+		}
+	}
+}
+
+// readCreateTableAsProgress returns the number of rows ingested so far by the
+// backfill run by the job with the given ID, as recorded by its processors.
+func readCreateTableAsProgress(
+	ctx context.Context, db isql.DB, jobID jobspb.JobID,
+) (rows int64, _ error) {
+	err := db.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		rows = 0
+		return jobs.InfoStorageForJob(txn, jobID).Iterate(
+			ctx, rowexec.CTASProgressInfoKeyPrefix, func(_ string, value []byte) error {
+				n, err := strconv.ParseInt(string(value), 10, 64)
+				if err != nil {
+					return err
+				}
+				rows += n
+				return nil
+			})
+	})
+	return rows, err
+}
@@ -0,0 +1,237 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/tabledesc"
+	"github.com/cockroachdb/cockroach/pkg/sql/paramparse"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowexec"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlerrors"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/retry"
+	"github.com/cockroachdb/errors"
+)
+
+// CreateTableAsSinkAuthorizeCCL is the public hook point for the CCL-licensed
+// authorization of a CREATE TABLE AS ... WITH (sink = ...) statement, which
+// applies the checks of a CREATE CHANGEFEED statement into the sink on the
+// source tables of the statement.
+var CreateTableAsSinkAuthorizeCCL = func(
+	ctx context.Context, p PlanHookState, sinkURI string, sources []catalog.TableDescriptor,
+) error {
+	return sqlerrors.NewCCLRequiredError(errors.New(
+		"publishing the rows of CREATE TABLE AS to a sink requires a CCL binary"))
+}
+
+// createTableAsSinkSecretParams are the query parameters of the URIs of the
+// changefeed sinks which hold secrets, see changefeedbase.
+var createTableAsSinkSecretParams = []string{
+	"sasl_password", "sasl_client_secret", "ca_cert", "client_cert", "client_key",
+}
+
+// createTableAsSinkStorageParam returns the URI of the sink set by the sink
+// storage parameter of a CREATE TABLE AS statement, if any. The statement is
+// authorized like a CREATE CHANGEFEED statement into the sink on its source
+// tables, and the sink is dialed, so that an invalid or unreachable sink fails
+// the statement before the table is created. The rows are published by the
+// backfill of the schema change job of the statement, so the sink can't be set
+// for a table which is populated within the transaction of the statement.
+//
+// The URI is persisted in the descriptor of the table until it is public, so
+// that the job can dial the sink, so it can't hold secrets: a sink which
+// requires them must be referenced through an external connection.
+func createTableAsSinkStorageParam(
+	params runParams, n *createTableNode, desc *tabledesc.Mutable,
+) (string, error) {
+	key := tree.CreateTableAsSinkStorageParam
+	value := n.n.StorageParams.GetVal(key)
+	if value == nil {
+		return "", nil
+	}
+	expr := paramparse.UnresolvedNameToStrVal(value)
+	typedExpr, err := tree.TypeCheck(params.ctx, expr, params.p.SemaCtx(), types.String)
+	if err != nil {
+		return "", err
+	}
+	sinkURI, err := paramparse.DatumAsString(params.ctx, params.p.EvalContext(), key, typedExpr)
+	if err != nil {
+		return "", err
+	}
+	if !params.extendedEvalCtx.TxnIsSingleStmt || n.populateInline(params) {
+		return "", pgerror.Newf(pgcode.FeatureNotSupported,
+			"%s cannot be set for a CREATE TABLE AS statement which populates the table within its transaction",
+			key)
+	}
+	if u, err := url.Parse(sinkURI); err != nil || u.Scheme == "" {
+		return "", pgerror.Newf(pgcode.InvalidParameterValue,
+			"invalid value for %s: expected a sink URI", key)
+	}
+	sources := make([]catalog.TableDescriptor, 0, len(n.provenance.SourceIDs))
+	for _, id := range n.provenance.SourceIDs {
+		source, err := params.p.Descriptors().ByIDWithLeased(params.p.Txn()).WithoutNonPublic().Get().Table(params.ctx, id)
+		if err != nil {
+			return "", err
+		}
+		sources = append(sources, source)
+	}
+	if err := CreateTableAsSinkAuthorizeCCL(params.ctx, params.p, sinkURI, sources); err != nil {
+		return "", err
+	}
+	if hasSecrets, err := createTableAsSinkURIHasSecrets(sinkURI); err != nil {
+		return "", err
+	} else if hasSecrets {
+		return "", errors.WithHint(pgerror.Newf(pgcode.InvalidParameterValue,
+			"invalid value for %s: the sink URI can't hold secrets, as it is persisted until the table is populated",
+			key), "Create an external connection to the sink, and set the sink to external://<connection>.")
+	}
+	sink, err := rowexec.NewCreateTableAsSink(
+		params.ctx, &params.ExecCfg().DistSQLSrv.ServerConfig, params.p.User(), sinkURI, desc,
+		params.p.Txn().ReadTimestamp(),
+	)
+	if err != nil {
+		return "", err
+	}
+	if err := sink.Close(); err != nil {
+		return "", err
+	}
+	return sinkURI, nil
+}
+
+// formatCreateTableRedactingSink formats a CREATE TABLE statement with fully
+// qualified names, like tree.AsStringWithFQNames, but with the secrets of the
+// URI of its sink storage parameter redacted, so that they aren't persisted in
+// the descriptor of the new table or in the description of its job.
+func formatCreateTableRedactingSink(params runParams, n *tree.CreateTable) string {
+	if n.StorageParams.GetVal(tree.CreateTableAsSinkStorageParam) == nil {
+		return tree.AsStringWithFQNames(n, params.Ann())
+	}
+	redacted := *n
+	redacted.StorageParams = make(tree.StorageParams, len(n.StorageParams))
+	copy(redacted.StorageParams, n.StorageParams)
+	for i, param := range redacted.StorageParams {
+		if param.Key != tree.CreateTableAsSinkStorageParam {
+			continue
+		}
+		sinkURI := "redacted"
+		if s, ok := param.Value.(*tree.StrVal); ok {
+			if u, err := redactCreateTableAsSinkURI(s.RawString()); err == nil {
+				sinkURI = u
+			}
+		}
+		redacted.StorageParams[i].Value = tree.NewStrVal(sinkURI)
+	}
+	return tree.AsStringWithFQNames(&redacted, params.Ann())
+}
+
+// redactCreateTableAsSinkURI redacts the user and the secret query parameters
+// of a sink URI, as they are redacted from the description of a changefeed.
+func redactCreateTableAsSinkURI(sinkURI string) (string, error) {
+	sinkURI, err := cloud.SanitizeExternalStorageURI(sinkURI, createTableAsSinkSecretParams)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(sinkURI)
+	if err != nil {
+		return "", err
+	}
+	if u.User != nil {
+		u.User = url.User("redacted")
+	}
+	return u.String(), nil
+}
+
+// createTableAsSinkURIHasSecrets returns whether a sink URI holds a password
+// or one of the secret query parameters redacted by redactCreateTableAsSinkURI.
+func createTableAsSinkURIHasSecrets(sinkURI string) (bool, error) {
+	u, err := url.Parse(sinkURI)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := u.User.Password(); ok {
+		return true, nil
+	}
+	redacted, err := cloud.SanitizeExternalStorageURI(sinkURI, createTableAsSinkSecretParams)
+	if err != nil {
+		return false, err
+	}
+	r, err := url.Parse(redacted)
+	if err != nil {
+		return false, err
+	}
+	return u.Query().Encode() != r.Query().Encode(), nil
+}
+
+// clearCreateTableAsSinkURI clears the URI of the sink of a table created by a
+// CREATE TABLE AS statement once its rows have been published, since it's only
+// needed by the backfill of the table.
+func clearCreateTableAsSinkURI(table *tabledesc.Mutable) {
+	if table.CreateAsProvenance != nil {
+		table.CreateAsProvenance.SinkURI = ""
+	}
+}
+
+// createTableAsSinkRetryOptions are the options of the retries of the backfill
+// of a CREATE TABLE AS statement which failed to publish its rows to its sink.
+var createTableAsSinkRetryOptions = retry.Options{
+	InitialBackoff: time.Second,
+	MaxBackoff:     30 * time.Second,
+	Multiplier:     2,
+	MaxRetries:     5,
+}
+
+// backfillCreateTableAs runs the backfill of a CREATE TABLE AS statement. The
+// rows of a table with a sink are published by the processors of the backfill
+// as they ingest them. A failure to publish them is retried by running the
+// backfill again, once the rows ingested by the failed attempt are cleared from
+// the last checkpoint, if any. Every row of the query is published again, so a
+// row may be delivered more than once, as with changefeeds. If the rows still
+// can't be published, the job fails, and the table is dropped, rather than
+// leaving the sink without some of the rows.
+func (sc *SchemaChanger) backfillCreateTableAs(
+	ctx context.Context, table catalog.TableDescriptor, query string, checkpoint *queryBackfillCheckpoint,
+) error {
+	var err error
+	for r := retry.StartWithCtx(ctx, createTableAsSinkRetryOptions); r.Next(); {
+		err = sc.backfillQueryIntoTable(
+			ctx, table, query, table.GetCreateAsOfTime(), "ctasBackfill", checkpoint,
+		)
+		if err == nil || !errors.Is(err, rowexec.ErrCreateTableAsSink) {
+			return err
+		}
+		log.Warningf(ctx, "failed to publish the rows of CREATE TABLE AS to its sink, retrying: %v", err)
+		var resumeKey roachpb.Key
+		if checkpoint != nil {
+			var readErr error
+			if resumeKey, readErr = sc.readCreateTableAsCheckpoint(ctx, table); readErr != nil {
+				return readErr
+			}
+			checkpoint.resumeKey = resumeKey
+		}
+		if clearErr := sc.clearCreateTableAsPartialBackfill(ctx, table, resumeKey); clearErr != nil {
+			return clearErr
+		}
+	}
+	if err == nil {
+		return ctx.Err()
+	}
+	return errors.Wrap(err, "publishing the rows of CREATE TABLE AS to its sink")
+}
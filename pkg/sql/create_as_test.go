@@ -13,16 +13,35 @@ package sql
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/row"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/jobutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/errors"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 	"github.com/stretchr/testify/require"
 )
 
@@ -97,6 +116,85 @@ func TestCreateAsVTable(t *testing.T) {
 	}
 
 	waitForJobsSuccess(t, sqlRunner)
+
+	// Every table created above read from a virtual table or a system view,
+	// which is recorded by name rather than by ID in its provenance.
+	sqlRunner.CheckQueryResults(t, `
+SELECT count(*) FROM crdb_internal.table_provenance
+WHERE table_name LIKE 'test_table_%' AND cardinality(source_descriptions) > 0`,
+		[][]string{{fmt.Sprint(i)}},
+	)
+}
+
+// TestCreateAsProvenance verifies that tables created with CREATE TABLE AS
+// record the statement which created them and the sources of their data.
+func TestCreateAsProvenance(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s, sqlDB, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+	sqlRunner := sqlutils.MakeSQLRunner(sqlDB)
+
+	sqlRunner.Exec(t, `CREATE TABLE a (k INT PRIMARY KEY, v STRING)`)
+	sqlRunner.Exec(t, `CREATE TABLE b (k INT PRIMARY KEY, w STRING)`)
+	sqlRunner.Exec(t, `CREATE VIEW av AS SELECT k FROM a`)
+	var aID, bID, avID int
+	sqlRunner.QueryRow(t,
+		`SELECT 'a'::REGCLASS::INT, 'b'::REGCLASS::INT, 'av'::REGCLASS::INT`,
+	).Scan(&aID, &bID, &avID)
+
+	testCases := []struct {
+		table string
+		sql   string
+		ids   string
+		descs string
+	}{
+		{
+			table: "j",
+			sql:   `CREATE TABLE j AS SELECT a.k, b.w FROM a JOIN b ON a.k = b.k`,
+			ids:   fmt.Sprintf("%d,%d", aID, bID),
+		},
+		{
+			table: "sub",
+			sql:   `CREATE TABLE sub AS SELECT k FROM a WHERE k IN (SELECT k FROM b)`,
+			ids:   fmt.Sprintf("%d,%d", aID, bID),
+		},
+		{
+			table: "fromview",
+			sql:   `CREATE TABLE fromview AS SELECT k FROM av`,
+			ids:   fmt.Sprintf("%d,%d", aID, avID),
+		},
+		{
+			// A statement source doesn't have an ID, so it is described instead,
+			// along with the virtual table read by the delegated statement.
+			table: "dbs",
+			sql:   `CREATE TABLE dbs AS SELECT database_name FROM [SHOW DATABASES]`,
+			descs: "statement [SHOW DATABASES];virtual table crdb_internal.databases",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.table, func(t *testing.T) {
+			sqlRunner.Exec(t, tc.sql)
+			var statement, ids, descs string
+			sqlRunner.QueryRow(t, `
+SELECT statement, array_to_string(source_ids, ','), array_to_string(source_descriptions, ';')
+FROM crdb_internal.table_provenance
+WHERE table_name = $1`, tc.table,
+			).Scan(&statement, &ids, &descs)
+			// The statement is recorded with fully qualified names.
+			require.True(t, strings.HasPrefix(statement, "CREATE TABLE defaultdb.public."+tc.table), statement)
+			require.Equal(t, tc.ids, ids)
+			require.Equal(t, tc.descs, descs)
+		})
+	}
+
+	// Tables which weren't created with CREATE TABLE AS have no provenance.
+	sqlRunner.CheckQueryResults(t,
+		`SELECT count(*) FROM crdb_internal.table_provenance WHERE table_name IN ('a', 'b')`,
+		[][]string{{"0"}},
+	)
 }
 
 func TestCreateAsShow(t *testing.T) {
@@ -105,7 +203,6 @@ func TestCreateAsShow(t *testing.T) {
 	testCases := []struct {
 		sql   string
 		setup string
-		skip  bool
 	}{
 		{
 			sql: "SHOW CLUSTER SETTINGS",
@@ -137,16 +234,10 @@ func TestCreateAsShow(t *testing.T) {
 		{
 			sql:   "SHOW CREATE TABLE show_create_tbl",
 			setup: "CREATE TABLE show_create_tbl (id int PRIMARY KEY)",
-			// TODO(sql-foundations): Fix `relation "show_create_tbl" does not exist` error in job.
-			//  See https://github.com/cockroachdb/cockroach/issues/106260.
-			skip: true,
 		},
 		{
 			sql:   "SHOW CREATE FUNCTION show_create_fn",
 			setup: "CREATE FUNCTION show_create_fn(i int) RETURNS INT AS 'SELECT i' LANGUAGE SQL",
-			// TODO(sql-foundations): Fix `unknown function: show_create_fn(): function undefined` error in job.
-			//  See https://github.com/cockroachdb/cockroach/issues/106268.
-			skip: true,
 		},
 		{
 			sql: "SHOW CREATE ALL TYPES",
@@ -157,23 +248,14 @@ func TestCreateAsShow(t *testing.T) {
 		{
 			sql:   "SHOW INDEXES FROM show_indexes_tbl",
 			setup: "CREATE TABLE show_indexes_tbl (id int PRIMARY KEY)",
-			// TODO(sql-foundations): Fix `relation "show_indexes_tbl" does not exist` error in job.
-			//  See https://github.com/cockroachdb/cockroach/issues/106260.
-			skip: true,
 		},
 		{
 			sql:   "SHOW COLUMNS FROM show_columns_tbl",
 			setup: "CREATE TABLE show_columns_tbl (id int PRIMARY KEY)",
-			// TODO(sql-foundations): Fix `relation "show_columns_tbl" does not exist` error in job.
-			//  See https://github.com/cockroachdb/cockroach/issues/106260.
-			skip: true,
 		},
 		{
 			sql:   "SHOW CONSTRAINTS FROM show_constraints_tbl",
 			setup: "CREATE TABLE show_constraints_tbl (id int PRIMARY KEY)",
-			// TODO(sql-foundations): Fix `relation "show_constraints_tbl" does not exist` error in job.
-			//  See https://github.com/cockroachdb/cockroach/issues/106260.
-			skip: true,
 		},
 		{
 			sql: "SHOW PARTITIONS FROM DATABASE defaultdb",
@@ -181,16 +263,10 @@ func TestCreateAsShow(t *testing.T) {
 		{
 			sql:   "SHOW PARTITIONS FROM TABLE show_partitions_tbl",
 			setup: "CREATE TABLE show_partitions_tbl (id int PRIMARY KEY)",
-			// TODO(sql-foundations): Fix `relation "show_partitions_tbl" does not exist` error in job.
-			//  See https://github.com/cockroachdb/cockroach/issues/106260.
-			skip: true,
 		},
 		{
 			sql:   "SHOW PARTITIONS FROM INDEX show_partitions_idx_tbl@show_partitions_idx_tbl_pkey",
 			setup: "CREATE TABLE show_partitions_idx_tbl (id int PRIMARY KEY)",
-			// TODO(sql-foundations): Fix `relation "show_partitions_idx_tbl" does not exist` error in job.
-			//  See https://github.com/cockroachdb/cockroach/issues/106260.
-			skip: true,
 		},
 		{
 			sql: "SHOW GRANTS",
@@ -216,9 +292,6 @@ func TestCreateAsShow(t *testing.T) {
 		{
 			sql:   "SHOW RANGE FROM TABLE show_ranges_tbl FOR ROW (0)",
 			setup: "CREATE TABLE show_ranges_tbl (id int PRIMARY KEY)",
-			// TODO(sql-foundations): Fix `invalid memory address or nil pointer dereference` error in job.
-			//  See https://github.com/cockroachdb/cockroach/issues/106397.
-			skip: true,
 		},
 		{
 			sql: "SHOW SURVIVAL GOAL FROM DATABASE",
@@ -291,9 +364,6 @@ func TestCreateAsShow(t *testing.T) {
 
 	for i, testCase := range testCases {
 		t.Run(testCase.sql, func(t *testing.T) {
-			if testCase.skip {
-				return
-			}
 			if testCase.setup != "" {
 				sqlRunner.Exec(t, testCase.setup)
 			}
@@ -311,9 +381,110 @@ func TestCreateAsShow(t *testing.T) {
 		})
 	}
 
+	// The rows of a statement source are read as of the timestamp at which the
+	// table was created, although the schema change job which streams them
+	// into the table re-plans the source.
+	sqlRunner.Exec(t,
+		`CREATE TABLE show_columns_snapshot AS SELECT column_name FROM [SHOW COLUMNS FROM show_columns_tbl]`)
+	sqlRunner.Exec(t, `ALTER TABLE show_columns_tbl ADD COLUMN v INT`)
+	sqlRunner.CheckQueryResults(t, `SELECT column_name FROM show_columns_snapshot`, [][]string{{"id"}})
+
+	// A materialized view re-runs its statement source when it is refreshed,
+	// resolving the objects it refers to in the database of the view.
+	sqlRunner.Exec(t,
+		`CREATE MATERIALIZED VIEW show_columns_view AS SELECT column_name FROM [SHOW COLUMNS FROM show_columns_tbl]`)
+	sqlRunner.Exec(t, `ALTER TABLE show_columns_tbl ADD COLUMN w INT`)
+	sqlRunner.CheckQueryResults(t, `SELECT column_name FROM show_columns_view ORDER BY column_name`,
+		[][]string{{"id"}, {"v"}})
+	sqlRunner.Exec(t, `REFRESH MATERIALIZED VIEW show_columns_view`)
+	sqlRunner.CheckQueryResults(t, `SELECT column_name FROM show_columns_view ORDER BY column_name`,
+		[][]string{{"id"}, {"v"}, {"w"}})
+
+	// The objects referenced by a statement source are resolved in the schema
+	// of the table when the job re-plans the source.
+	conn, err := testCluster.ServerConn(0).Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	connRunner := sqlutils.MakeSQLRunner(conn)
+	connRunner.Exec(t, `CREATE SCHEMA show_sc`)
+	connRunner.Exec(t, `CREATE TABLE show_sc.show_columns_sc_tbl (id INT PRIMARY KEY, s INT)`)
+	connRunner.Exec(t, `SET search_path = show_sc`)
+	connRunner.Exec(t,
+		`CREATE TABLE show_columns_sc_snapshot AS SELECT column_name FROM [SHOW COLUMNS FROM show_columns_sc_tbl]`)
+	sqlRunner.CheckQueryResults(t,
+		`SELECT column_name FROM show_sc.show_columns_sc_snapshot ORDER BY column_name`,
+		[][]string{{"id"}, {"s"}})
+
 	waitForJobsSuccess(t, sqlRunner)
 }
 
+// TestCreateAsExplain tests that the output of EXPLAIN and EXPLAIN ANALYZE can
+// be captured into a table, and that the statement explained by EXPLAIN
+// ANALYZE is executed exactly once.
+func TestCreateAsExplain(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+	sqlRunner := sqlutils.MakeSQLRunner(db)
+
+	sqlRunner.Exec(t, `CREATE TABLE explain_src (k INT PRIMARY KEY, v INT)`)
+	sqlRunner.Exec(t, `INSERT INTO explain_src SELECT i, i FROM generate_series(1, 10) AS g(i)`)
+
+	for _, tc := range []struct {
+		table, source, contains string
+	}{
+		{
+			table:    "plans",
+			source:   "EXPLAIN SELECT * FROM explain_src WHERE k > 5",
+			contains: "table: explain_src@explain_src_pkey",
+		},
+		{
+			table:    "analyzed_plans",
+			source:   "EXPLAIN ANALYZE SELECT * FROM explain_src WHERE k > 5",
+			contains: "actual row count: 5",
+		},
+	} {
+		t.Run(tc.source, func(t *testing.T) {
+			sqlRunner.Exec(t, fmt.Sprintf(`CREATE TABLE %s AS SELECT * FROM [%s]`, tc.table, tc.source))
+			sqlRunner.CheckQueryResults(t, fmt.Sprintf(
+				`SELECT column_name, data_type FROM [SHOW COLUMNS FROM %s] WHERE NOT is_hidden`, tc.table),
+				[][]string{{"info", "STRING"}},
+			)
+			var info string
+			sqlRunner.QueryRow(t, fmt.Sprintf(`SELECT string_agg(info, e'\n') FROM %s`, tc.table)).Scan(&info)
+			require.Contains(t, info, tc.contains)
+		})
+	}
+
+	// The explained statement is executed once, by the CREATE TABLE AS
+	// statement rather than the schema changer which populates the table.
+	sqlRunner.Exec(t, `CREATE TABLE explain_dst (k INT)`)
+	sqlRunner.Exec(t, `CREATE TABLE analyzed_insert AS SELECT * FROM [EXPLAIN ANALYZE INSERT INTO explain_dst VALUES (1)]`)
+	sqlRunner.CheckQueryResults(t, `SELECT count(*) FROM explain_dst`, [][]string{{"1"}})
+	var planRows int
+	sqlRunner.QueryRow(t, `SELECT count(*) FROM analyzed_insert`).Scan(&planRows)
+	require.Positive(t, planRows)
+
+	// EXPLAIN ANALYZE sources nested in joins and subqueries are executed
+	// once each too.
+	sqlRunner.Exec(t, `CREATE TABLE analyzed_nested AS
+SELECT a.info FROM explain_src
+JOIN (SELECT * FROM [EXPLAIN ANALYZE INSERT INTO explain_dst VALUES (2)]) AS a ON true
+WHERE k = 1`)
+	sqlRunner.CheckQueryResults(t, `SELECT count(*) FROM explain_dst`, [][]string{{"2"}})
+	sqlRunner.QueryRow(t, `SELECT count(*) FROM analyzed_nested`).Scan(&planRows)
+	require.Positive(t, planRows)
+
+	// The explained statement isn't executed when the table already exists.
+	sqlRunner.Exec(t, `CREATE TABLE IF NOT EXISTS analyzed_insert AS SELECT * FROM [EXPLAIN ANALYZE INSERT INTO explain_dst VALUES (3)]`)
+	sqlRunner.ExpectErr(t, `relation "analyzed_insert" already exists`,
+		`CREATE TABLE analyzed_insert AS SELECT * FROM [EXPLAIN ANALYZE INSERT INTO explain_dst VALUES (3)]`)
+	sqlRunner.CheckQueryResults(t, `SELECT count(*) FROM explain_dst`, [][]string{{"2"}})
+}
+
 func waitForJobsSuccess(t *testing.T, sqlRunner *sqlutils.SQLRunner) {
 	query := `SELECT job_id, status, error, description 
 FROM [SHOW JOBS] 
@@ -321,3 +492,292 @@ WHERE job_type IN ('SCHEMA CHANGE', 'NEW SCHEMA CHANGE')
 AND status != 'succeeded'`
 	sqlRunner.CheckQueryResultsRetry(t, query, [][]string{})
 }
+
+// TestCreateAsProgressNotices verifies that the number of rows ingested by the
+// backfill of a CREATE TABLE AS statement is sent to the client as a notice,
+// unless the create_table_as_progress_notices session variable is disabled.
+func TestCreateAsProgressNotices(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	var blockBackfill atomic.Bool
+	var progressSeenOnce sync.Once
+	progressSeen := make(chan struct{})
+	s, sqlDB, _ := serverutils.StartServer(t, base.TestServerArgs{
+		Knobs: base.TestingKnobs{
+			SQLSchemaChanger: &SchemaChangerTestingKnobs{
+				// Hold the job open after the backfill until the client has
+				// received a notice of its progress.
+				RunAfterCreateTableAsBackfill: func() error {
+					if !blockBackfill.Load() {
+						return nil
+					}
+					select {
+					case <-progressSeen:
+						return nil
+					case <-time.After(testutils.DefaultSucceedsSoonDuration):
+						return errors.New("timed out waiting for a progress notice")
+					}
+				},
+			},
+		},
+	})
+	defer s.Stopper().Stop(ctx)
+	sqlRunner := sqlutils.MakeSQLRunner(sqlDB)
+	sqlRunner.Exec(t, `SET CLUSTER SETTING sql.create_table_as.progress_interval = '10ms'`)
+
+	pgURL, cleanup := sqlutils.PGUrl(
+		t, s.ServingSQLAddr(), "TestCreateAsProgressNotices", url.User(username.RootUser),
+	)
+	defer cleanup()
+	config, err := pgx.ParseConfig(pgURL.String())
+	require.NoError(t, err)
+	var mu syncutil.Mutex
+	var notices []string
+	config.OnNotice = func(_ *pgconn.PgConn, notice *pgconn.Notice) {
+		mu.Lock()
+		defer mu.Unlock()
+		notices = append(notices, notice.Message)
+		if notice.Message == "ingested 100 rows" {
+			progressSeenOnce.Do(func() { close(progressSeen) })
+		}
+	}
+	conn, err := pgx.ConnectConfig(ctx, config)
+	require.NoError(t, err)
+	defer func() { _ = conn.Close(ctx) }()
+
+	t.Run("enabled", func(t *testing.T) {
+		blockBackfill.Store(true)
+		defer blockBackfill.Store(false)
+		_, err := conn.Exec(ctx, `CREATE TABLE t1 AS SELECT * FROM generate_series(1, 100)`)
+		require.NoError(t, err)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		mu.Lock()
+		notices = nil
+		mu.Unlock()
+		_, err := conn.Exec(ctx, `SET create_table_as_progress_notices = off`)
+		require.NoError(t, err)
+		_, err = conn.Exec(ctx, `CREATE TABLE t2 AS SELECT * FROM generate_series(1, 100)`)
+		require.NoError(t, err)
+		mu.Lock()
+		defer mu.Unlock()
+		for _, notice := range notices {
+			require.NotContains(t, notice, "ingested")
+		}
+	})
+}
+
+// TestCreateAsDistribute verifies that CREATE TABLE AS ... WITH (distribute =
+// true) pre-splits the new table at split points sampled from its source, so
+// that the ranges of the table are balanced even though the primary key values
+// of the source are skewed.
+func TestCreateAsDistribute(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s, sqlDB, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+	sqlRunner := sqlutils.MakeSQLRunner(sqlDB)
+
+	const numRanges = 4
+	sqlRunner.Exec(t, fmt.Sprintf(
+		`SET CLUSTER SETTING sql.create_table_as.distribute.ranges = %d`, numRanges,
+	))
+	// Sample a quarter of the source, so that the split points are only
+	// balanced if the sample is spread over the whole source, rather than
+	// being the rows which are read first.
+	sqlRunner.Exec(t, `SET CLUSTER SETTING sql.create_table_as.distribute.sample_rows = 5000`)
+	// Most of the rows are packed into the start of the key space, the rest are
+	// spread thinly over the remainder, so that splitting the key space evenly
+	// would put most of the rows in the first range.
+	sqlRunner.Exec(t, `CREATE TABLE src (k INT PRIMARY KEY, v STRING)`)
+	sqlRunner.Exec(t, `INSERT INTO src SELECT i, 'v' FROM generate_series(1, 15000) AS g(i)`)
+	sqlRunner.Exec(t, `INSERT INTO src SELECT i * 100000, 'v' FROM generate_series(1, 5000) AS g(i)`)
+	// Sources which the optimizer estimates to be small aren't sampled.
+	sqlRunner.Exec(t, `CREATE STATISTICS src_stats FROM src`)
+
+	rangeRows := func(table string) []int {
+		var counts []int
+		rows := sqlRunner.Query(t, fmt.Sprintf(`
+SELECT (crdb_internal.range_stats(raw_start_key)->>'live_count')::INT
+FROM [SHOW RANGES FROM TABLE %s WITH KEYS]`, table))
+		defer rows.Close()
+		for rows.Next() {
+			var count int
+			require.NoError(t, rows.Scan(&count))
+			counts = append(counts, count)
+		}
+		require.NoError(t, rows.Err())
+		return counts
+	}
+
+	t.Run("distribute", func(t *testing.T) {
+		sqlRunner.Exec(t, `CREATE TABLE dst (k PRIMARY KEY, v) WITH (distribute = true) AS SELECT * FROM src`)
+		sqlRunner.CheckQueryResults(t, `SELECT count(*) FROM dst`, [][]string{{"20000"}})
+		counts := rangeRows("dst")
+		require.Len(t, counts, numRanges)
+		for _, count := range counts {
+			require.InDelta(t, 20000/numRanges, count, 20000/numRanges/2, "%v", counts)
+		}
+	})
+
+	t.Run("small source", func(t *testing.T) {
+		sqlRunner.Exec(t, `CREATE TABLE small (k PRIMARY KEY, v) WITH (distribute = true) AS SELECT * FROM src WHERE k <= 100`)
+		require.Len(t, rangeRows("small"), 1)
+	})
+
+	t.Run("no primary key", func(t *testing.T) {
+		sqlRunner.Exec(t, `CREATE TABLE nopk WITH (distribute = true) AS SELECT * FROM src`)
+		require.Len(t, rangeRows("nopk"), 1)
+	})
+}
+
+// TestCreateAsPauseResume verifies that the schema change job of a CREATE
+// TABLE AS statement can be paused while its backfill is incomplete, and that
+// once resumed, the rows ingested before the pause are replaced rather than
+// duplicated, so that the table contains exactly the rows of the query.
+func TestCreateAsPauseResume(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	var backfills atomic.Int32
+	s, sqlDB, _ := serverutils.StartServer(t, base.TestServerArgs{
+		Knobs: base.TestingKnobs{
+			SQLSchemaChanger: &SchemaChangerTestingKnobs{
+				RunBeforeQueryBackfill: func() error {
+					backfills.Add(1)
+					return nil
+				},
+			},
+			JobsTestingKnobs: jobs.NewTestingKnobsWithShortIntervals(),
+		},
+	})
+	defer s.Stopper().Stop(ctx)
+	sqlRunner := sqlutils.MakeSQLRunner(sqlDB)
+	sqlRunner.Exec(t, `SET CLUSTER SETTING sql.create_table_as.progress_interval = '10ms'`)
+
+	// The new table has a hidden rowid primary key, so that ingesting the rows
+	// of the query twice would duplicate them.
+	sqlRunner.Exec(t, `CREATE TABLE src (k INT, v STRING)`)
+	sqlRunner.Exec(t, `INSERT INTO src SELECT i, 'v' || i::STRING FROM generate_series(1, 1000) AS g(i)`)
+
+	// Pause the job once it has ingested the rows, but before it has recorded
+	// that the backfill completed, as if it had been paused mid-backfill.
+	sqlRunner.Exec(t, `SET CLUSTER SETTING jobs.debug.pausepoints = 'schemachanger.create_table_as.after_backfill'`)
+	sqlRunner.ExpectErr(t, "paused before it completed", `CREATE TABLE dst AS SELECT * FROM src`)
+	var jobID jobspb.JobID
+	sqlRunner.QueryRow(t, `
+SELECT job_id FROM [SHOW JOBS]
+WHERE job_type = 'SCHEMA CHANGE' AND description LIKE 'CREATE TABLE %dst%'`,
+	).Scan(&jobID)
+	jobutils.WaitForJobToPause(t, sqlRunner, jobID)
+
+	backfillState := func() string {
+		var state string
+		sqlRunner.QueryRow(t, `
+SELECT convert_from(value, 'UTF8') FROM system.job_info
+WHERE job_id = $1 AND info_key = '~ctas-backfill-state'`, jobID,
+		).Scan(&state)
+		return state
+	}
+	require.Equal(t, "started", backfillState())
+	var ingested int
+	sqlRunner.QueryRow(t, `
+SELECT sum(convert_from(value, 'UTF8')::INT) FROM system.job_info
+WHERE job_id = $1 AND info_key LIKE '~ctas-rows-%'`, jobID,
+	).Scan(&ingested)
+	require.Equal(t, 1000, ingested)
+
+	sqlRunner.Exec(t, `SET CLUSTER SETTING jobs.debug.pausepoints = ''`)
+	sqlRunner.Exec(t, `RESUME JOB $1`, jobID)
+	jobutils.WaitForJobToSucceed(t, sqlRunner, jobID)
+
+	require.Equal(t, int32(2), backfills.Load())
+	require.Equal(t, "done", backfillState())
+	sqlRunner.CheckQueryResults(t, `SELECT count(*) FROM dst`, [][]string{{"1000"}})
+	sqlRunner.CheckQueryResults(t, `
+SELECT count(*) FROM (
+  (SELECT k, v FROM dst EXCEPT ALL SELECT k, v FROM src)
+  UNION ALL
+  (SELECT k, v FROM src EXCEPT ALL SELECT k, v FROM dst)
+)`, [][]string{{"0"}})
+}
+
+// TestCreateAsResumeFromCheckpoint verifies that a CREATE TABLE AS backfill
+// which fails mid-way, as if its coordinator had died, resumes from the
+// checkpoint recorded by the previous attempt rather than ingesting all of the
+// rows of the query again.
+func TestCreateAsResumeFromCheckpoint(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Ingest the rows in small batches, each flushed and checkpointed, so that
+	// the backfill can be failed after only some of the rows were ingested.
+	defer row.TestingSetDatumRowConverterBatchSize(10)()
+
+	ctx := context.Background()
+	var backfills, chunks atomic.Int32
+	s, sqlDB, _ := serverutils.StartServer(t, base.TestServerArgs{
+		Knobs: base.TestingKnobs{
+			SQLSchemaChanger: &SchemaChangerTestingKnobs{
+				RunBeforeQueryBackfill: func() error {
+					backfills.Add(1)
+					return nil
+				},
+			},
+			DistSQL: &execinfra.TestingKnobs{
+				BulkAdderFlushesEveryBatch: true,
+				RunBeforeBackfillChunk: func(sp roachpb.Span) error {
+					if backfills.Load() == 1 && chunks.Add(1) == 20 {
+						return pgerror.New(pgcode.InternalConnectionFailure, "injected coordinator failure")
+					}
+					return nil
+				},
+			},
+			JobsTestingKnobs: jobs.NewTestingKnobsWithShortIntervals(),
+		},
+	})
+	defer s.Stopper().Stop(ctx)
+	sqlRunner := sqlutils.MakeSQLRunner(sqlDB)
+	sqlRunner.Exec(t, `SET CLUSTER SETTING sql.create_table_as.progress_interval = '1ms'`)
+
+	sqlRunner.Exec(t, `CREATE TABLE src (k INT PRIMARY KEY, v STRING)`)
+	sqlRunner.Exec(t, `INSERT INTO src SELECT i, 'v' || i::STRING FROM generate_series(1, 1000) AS g(i)`)
+	sqlRunner.Exec(t, `CREATE TABLE dst (k PRIMARY KEY, v) WITH (checkpoint = true) AS SELECT k, v FROM src`)
+
+	var jobID jobspb.JobID
+	sqlRunner.QueryRow(t, `
+SELECT job_id FROM [SHOW JOBS]
+WHERE job_type = 'SCHEMA CHANGE' AND description LIKE 'CREATE TABLE %dst%'`,
+	).Scan(&jobID)
+	require.Equal(t, int32(2), backfills.Load())
+
+	// The first attempt recorded a checkpoint before it failed, and the second
+	// attempt only ingested the rows after it.
+	var checkpoints int
+	sqlRunner.QueryRow(t, `
+SELECT count(*) FROM system.job_info
+WHERE job_id = $1 AND info_key = '~ctas-checkpoint'`, jobID,
+	).Scan(&checkpoints)
+	require.Equal(t, 1, checkpoints)
+	var ingested int
+	sqlRunner.QueryRow(t, `
+SELECT sum(convert_from(value, 'UTF8')::INT) FROM system.job_info
+WHERE job_id = $1 AND info_key LIKE '~ctas-rows-%'`, jobID,
+	).Scan(&ingested)
+	require.Greater(t, ingested, 0)
+	require.Less(t, ingested, 1000)
+
+	sqlRunner.CheckQueryResults(t, `SELECT count(*) FROM dst`, [][]string{{"1000"}})
+	sqlRunner.CheckQueryResults(t, `
+SELECT count(*) FROM (
+  (SELECT k, v FROM dst EXCEPT ALL SELECT k, v FROM src)
+  UNION ALL
+  (SELECT k, v FROM src EXCEPT ALL SELECT k, v FROM dst)
+)`, [][]string{{"0"}})
+}
@@ -27,8 +27,10 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/scheduledjobs"
 	"github.com/cockroachdb/cockroach/pkg/security/username"
 	"github.com/cockroachdb/cockroach/pkg/server/telemetry"
+	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catalogkeys"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catenumpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catprivilege"
@@ -68,10 +70,37 @@ import (
 	"github.com/lib/pq/oid"
 )
 
+// createTableAsInlineRowLimit is the maximum estimated number of rows in the
+// source query of a CREATE TABLE AS statement for which the inline storage
+// parameter is honored. Larger sources are populated by the schema change job.
+var createTableAsInlineRowLimit = settings.RegisterIntSetting(
+	settings.TenantWritable,
+	"sql.create_table_as.inline_row_limit",
+	"the maximum estimated number of rows for which CREATE TABLE AS ... WITH (inline = true) "+
+		"populates the table within the statement's transaction",
+	1000,
+	settings.NonNegativeInt,
+)
+
 type createTableNode struct {
 	n          *tree.CreateTable
 	dbDesc     catalog.DatabaseDescriptor
 	sourcePlan planNode
+	// sourceRowCount is the optimizer's estimate of the number of rows produced
+	// by the source query of a CREATE TABLE AS statement.
+	sourceRowCount float64
+	// inline is set if the CREATE TABLE AS statement must populate the table
+	// within the statement's transaction, rather than queueing a schema change
+	// job to do so.
+	inline bool
+	// inlineRequested is set if the inline storage parameter was set on the
+	// CREATE TABLE AS statement, which is honored if sourceRowCount doesn't
+	// exceed sql.create_table_as.inline_row_limit.
+	inlineRequested bool
+	// provenance records the sources read by the source query of a CREATE TABLE
+	// AS statement. The statement itself is filled in when the table descriptor
+	// is created.
+	provenance descpb.TableDescriptor_CreateAsProvenance
 }
 
 // ReadingOwnWrites implements the planNodeReadingOwnWrites interface.
@@ -79,6 +108,169 @@ type createTableNode struct {
 // and expects to see its own writes.
 func (n *createTableNode) ReadingOwnWrites() {}
 
+// notifyForeignKeysNotCarriedOver sends a notice for every foreign key
+// constraint of a table referenced by the source query of a CREATE TABLE AS
+// statement, for which at least one of the constrained columns is copied into
+// the new table. The copied columns are preserved as regular columns, with
+// their data and types, but the constraint is not carried over.
+func notifyForeignKeysNotCarriedOver(
+	params runParams, tableName string, asCols colinfo.ResultColumns,
+) error {
+	var srcTableIDs []descpb.ID
+	srcCols := make(map[descpb.ID]map[descpb.PGAttributeNum]struct{})
+	for _, col := range asCols {
+		if col.TableID == descpb.InvalidID || descpb.IsVirtualTable(col.TableID) {
+			continue
+		}
+		cols, ok := srcCols[col.TableID]
+		if !ok {
+			cols = make(map[descpb.PGAttributeNum]struct{})
+			srcCols[col.TableID] = cols
+			srcTableIDs = append(srcTableIDs, col.TableID)
+		}
+		cols[descpb.PGAttributeNum(col.PGAttributeNum)] = struct{}{}
+	}
+
+	for _, srcTableID := range srcTableIDs {
+		srcTable, err := params.p.Descriptors().ByIDWithLeased(params.p.txn).WithoutNonPublic().Get().Table(
+			params.ctx, srcTableID,
+		)
+		if err != nil {
+			return err
+		}
+		for _, fk := range srcTable.OutboundForeignKeys() {
+			for i := 0; i < fk.NumOriginColumns(); i++ {
+				col, err := catalog.MustFindColumnByID(srcTable, fk.GetOriginColumnID(i))
+				if err != nil {
+					return err
+				}
+				if _, ok := srcCols[srcTableID][col.GetPGAttributeNum()]; ok {
+					params.p.BufferClientNotice(
+						params.ctx,
+						pgnotice.Newf(
+							"foreign key constraint %q on table %q was not carried over to table %q; "+
+								"its columns were copied as regular columns",
+							fk.GetName(), srcTable.GetName(), tableName,
+						),
+					)
+					break
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// createTableAsBoolStorageParam returns the value of the boolean storage
+// parameter with the given key on a CREATE TABLE AS statement, or false if the
+// parameter isn't set.
+func createTableAsBoolStorageParam(
+	params runParams, n *tree.CreateTable, key string,
+) (bool, error) {
+	value := n.StorageParams.GetVal(key)
+	if value == nil {
+		return false, nil
+	}
+	expr := paramparse.UnresolvedNameToStrVal(value)
+	typedExpr, err := tree.TypeCheck(params.ctx, expr, params.p.SemaCtx(), types.Any)
+	if err != nil {
+		return false, err
+	}
+	return paramparse.DatumAsBool(params.ctx, params.p.EvalContext(), key, typedExpr)
+}
+
+// copyCommentsForCreateTableAs copies the table comment and the column
+// comments of the source table of a CREATE TABLE AS statement onto the new
+// table. This only applies when the source query passes through all the
+// columns of a single table, in order, i.e. SELECT * FROM t. Otherwise, a
+// notice is sent and no comments are copied.
+func copyCommentsForCreateTableAs(
+	params runParams, desc *tabledesc.Mutable, asCols colinfo.ResultColumns,
+) error {
+	srcTable, err := createTableAsPassThroughSource(params, asCols)
+	if err != nil {
+		return err
+	}
+	if srcTable == nil {
+		params.p.BufferClientNotice(
+			params.ctx,
+			pgnotice.Newf(
+				"comments were not copied to table %q; %s only applies when selecting all "+
+					"the columns of a single table",
+				desc.GetName(), tree.CreateTableAsCopyCommentsStorageParam,
+			),
+		)
+		return nil
+	}
+
+	if cmt, ok := params.p.Descriptors().GetTableComment(srcTable.GetID()); ok {
+		if err := params.p.updateComment(
+			params.ctx, desc.GetID(), 0 /* subID */, catalogkeys.TableCommentType, cmt,
+		); err != nil {
+			return err
+		}
+	}
+	cols := desc.PublicColumns()
+	for i, asCol := range asCols {
+		cmt, ok := params.p.Descriptors().GetColumnComment(
+			srcTable.GetID(), descpb.PGAttributeNum(asCol.PGAttributeNum),
+		)
+		if !ok {
+			continue
+		}
+		if err := params.p.updateComment(
+			params.ctx, desc.GetID(), uint32(cols[i].GetPGAttributeNum()), catalogkeys.ColumnCommentType, cmt,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createTableAsPassThroughSource returns the source table of a CREATE TABLE AS
+// statement whose source query passes through all the columns of a single
+// table, in order, i.e. SELECT * FROM t. It returns nil if the source query is
+// any other query.
+func createTableAsPassThroughSource(
+	params runParams, asCols colinfo.ResultColumns,
+) (catalog.TableDescriptor, error) {
+	if len(asCols) == 0 {
+		return nil, nil
+	}
+	srcTableID := asCols[0].TableID
+	if srcTableID == descpb.InvalidID || descpb.IsVirtualTable(srcTableID) {
+		return nil, nil
+	}
+	// Read the source table without leasing, so that its comments and zone
+	// configs are read along with its descriptor.
+	srcTable, err := params.p.Descriptors().ByID(params.p.txn).WithoutNonPublic().Get().Table(
+		params.ctx, srcTableID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !isPassThroughOfAllColumns(srcTable, asCols) {
+		return nil, nil
+	}
+	return srcTable, nil
+}
+
+// isPassThroughOfAllColumns returns true if the result columns are exactly the
+// visible columns of the given table, in order.
+func isPassThroughOfAllColumns(tbl catalog.TableDescriptor, cols colinfo.ResultColumns) bool {
+	visible := tbl.VisibleColumns()
+	if len(visible) != len(cols) {
+		return false
+	}
+	for i, col := range visible {
+		if cols[i].TableID != tbl.GetID() ||
+			descpb.PGAttributeNum(cols[i].PGAttributeNum) != col.GetPGAttributeNum() {
+			return false
+		}
+	}
+	return true
+}
+
 // getNonTemporarySchemaForCreate returns the schema in which to create an object.
 // Note that it does not handle the temporary schema -- if the requested schema
 // is temporary, the caller needs to use (*planner).getOrCreateTemporarySchema.
@@ -309,12 +501,15 @@ func (n *createTableNode) startExec(params runParams) error {
 	if n.n.Persistence.IsTemporary() {
 		telemetry.Inc(sqltelemetry.CreateTempTableCounter)
 
-		// TODO(#46556): support ON COMMIT DROP and DELETE ROWS on TEMPORARY TABLE.
+		// TODO(#46556): support ON COMMIT DELETE ROWS on TEMPORARY TABLE.
 		// If we do this, the n.n.OnCommit variable should probably be stored on the
 		// table descriptor.
 		// Note UNSET / PRESERVE ROWS behave the same way so we do not need to do that for now.
+		// ON COMMIT DROP tables are tracked by the session, see
+		// dropOnCommitTables.
 		switch n.n.OnCommit {
-		case tree.CreateTableOnCommitUnset, tree.CreateTableOnCommitPreserveRows:
+		case tree.CreateTableOnCommitUnset, tree.CreateTableOnCommitPreserveRows,
+			tree.CreateTableOnCommitDrop:
 		default:
 			return errors.AssertionFailedf("ON COMMIT value %d is unrecognized", n.n.OnCommit)
 		}
@@ -369,8 +564,10 @@ func (n *createTableNode) startExec(params runParams) error {
 	if err != nil {
 		return err
 	}
+	var asCols colinfo.ResultColumns
+	var addedCheck *descpb.TableDescriptor_CheckConstraint
 	if n.n.As() {
-		asCols := planColumns(n.sourcePlan)
+		asCols = planColumns(n.sourcePlan)
 		if !n.n.AsHasUserSpecifiedPrimaryKey() {
 			// rowID column is already present in the input as the last column
 			// if the user did not specify a PRIMARY KEY. So ignore it for the
@@ -378,18 +575,47 @@ func (n *createTableNode) startExec(params runParams) error {
 			// does it automatically).
 			asCols = asCols[:len(asCols)-1]
 		}
+		// The statement is formatted before newTableDescIfAs adds the column
+		// definitions of the source query to the AST.
+		provenance := n.provenance
+		provenance.Statement = formatCreateTableRedactingSink(params, n.n)
 		desc, err = newTableDescIfAs(
 			params, n.n, n.dbDesc, schema, id, creationTime, asCols, privs, params.p.EvalContext(),
 		)
 		if err != nil {
 			return err
 		}
+		provenance.CollectStats, err = createTableAsBoolStorageParam(
+			params, n.n, tree.CreateTableAsCollectStatsStorageParam,
+		)
+		if err != nil {
+			return err
+		}
+		provenance.Checkpoint, err = createTableAsBoolStorageParam(
+			params, n.n, tree.CreateTableAsCheckpointStorageParam,
+		)
+		if err != nil {
+			return err
+		}
+		provenance.SinkURI, err = createTableAsSinkStorageParam(params, n, desc)
+		if err != nil {
+			return err
+		}
+		desc.CreateAsProvenance = &provenance
+		if err := notifyForeignKeysNotCarriedOver(params, desc.GetName(), asCols); err != nil {
+			return err
+		}
 
 		// If we have a single statement txn we want to run CTAS async, and
-		// consequently ensure it gets queued as a SchemaChange.
-		if params.extendedEvalCtx.TxnIsSingleStmt {
+		// consequently ensure it gets queued as a SchemaChange, unless the
+		// table is to be populated inline.
+		if params.extendedEvalCtx.TxnIsSingleStmt && !n.populateInline(params) {
 			desc.State = descpb.DescriptorState_ADD
 		}
+		addedCheck, err = addCheckForCreateTableAs(params, n.n, desc)
+		if err != nil {
+			return err
+		}
 	} else {
 		affected = make(map[descpb.ID]*tabledesc.Mutable)
 		desc, err = newTableDesc(params, n.n, n.dbDesc, schema, id, creationTime, privs, affected)
@@ -439,11 +665,47 @@ func (n *createTableNode) startExec(params runParams) error {
 	if err := params.p.createDescriptor(
 		params.ctx,
 		desc,
-		tree.AsStringWithFQNames(n.n, params.Ann()),
+		formatCreateTableRedactingSink(params, n.n),
 	); err != nil {
 		return err
 	}
 
+	if n.n.As() {
+		copyComments, err := createTableAsBoolStorageParam(
+			params, n.n, tree.CreateTableAsCopyCommentsStorageParam,
+		)
+		if err != nil {
+			return err
+		}
+		if copyComments {
+			if err := copyCommentsForCreateTableAs(params, desc, asCols); err != nil {
+				return err
+			}
+		}
+		copyPartitioning, err := createTableAsBoolStorageParam(
+			params, n.n, tree.CreateTableAsCopyPartitioningStorageParam,
+		)
+		if err != nil {
+			return err
+		}
+		if copyPartitioning && createTableAsLikeDef(n.n) == nil {
+			if err := copyPartitionZoneConfigsForCreateTableAs(params, desc, asCols); err != nil {
+				return err
+			}
+		}
+		distribute, err := createTableAsBoolStorageParam(
+			params, n.n, tree.CreateTableAsDistributeStorageParam,
+		)
+		if err != nil {
+			return err
+		}
+		if distribute {
+			if err := distributeCreateTableAs(params, n.n, desc, n.sourceRowCount); err != nil {
+				return err
+			}
+		}
+	}
+
 	for _, updated := range affected {
 		if err := params.p.writeSchemaChange(
 			params.ctx, updated, descpb.InvalidMutationID,
@@ -516,9 +778,9 @@ func (n *createTableNode) startExec(params runParams) error {
 		return err
 	}
 
-	// If we are in a multi-statement txn or the source has placeholders, we
-	// execute the CTAS query synchronously.
-	if n.n.As() && !params.extendedEvalCtx.TxnIsSingleStmt {
+	// If we are in a multi-statement txn, the source has placeholders, or the
+	// table is to be populated inline, we execute the CTAS query synchronously.
+	if n.n.As() && (!params.extendedEvalCtx.TxnIsSingleStmt || n.populateInline(params)) {
 		err = func() error {
 			// The data fill portion of CREATE AS must operate on a read snapshot,
 			// so that it doesn't end up observing its own writes.
@@ -563,6 +825,19 @@ func (n *createTableNode) startExec(params runParams) error {
 			// a PRIMARY KEY is not specified by the user.
 			rowBuffer := make(tree.Datums, len(desc.Columns))
 
+			// Columns declared with a DEFAULT expression aren't produced by the
+			// source plan, so their values are generated for each row.
+			rf, err := makeCreateTableAsRowFiller(params, n.n, desc)
+			if err != nil {
+				return err
+			}
+			// Values of the source plan whose type differs from the column of
+			// the LIKE table they populate are cast to the type of the column.
+			caster, err := makeCreateTableAsCaster(params, n.n, desc, planColumns(n.sourcePlan))
+			if err != nil {
+				return err
+			}
+
 			for {
 				if err := params.p.cancelChecker.Check(); err != nil {
 					return err
@@ -587,11 +862,30 @@ func (n *createTableNode) startExec(params runParams) error {
 				}
 
 				// Populate the buffer.
-				copy(rowBuffer, n.sourcePlan.Values())
+				if rf == nil {
+					copy(rowBuffer, n.sourcePlan.Values())
+				} else if err := rf.fill(
+					params.ctx, params.EvalContext(), rowBuffer, n.sourcePlan.Values(),
+				); err != nil {
+					return err
+				}
+				if caster != nil {
+					if err := caster.cast(params.ctx, params.EvalContext(), rowBuffer); err != nil {
+						return err
+					}
+				}
+
+				// Columns copied by LIKE may be NOT NULL, which the source plan
+				// doesn't guarantee.
+				for i, col := range desc.PublicColumns() {
+					if !col.IsNullable() && rowBuffer[i] == tree.DNull {
+						return sqlerrors.NewNonNullViolationError(col.GetName())
+					}
+				}
 
-				// CREATE TABLE AS does not copy indexes from the input table.
-				// An empty row.PartialIndexUpdateHelper is used here because
-				// there are no indexes, partial or otherwise, to update.
+				// CREATE TABLE AS does not create partial indexes. An empty
+				// row.PartialIndexUpdateHelper is used here because there are no
+				// partial indexes to update.
 				var pm row.PartialIndexUpdateHelper
 				if err := tw.row(params.ctx, rowBuffer, pm, params.extendedEvalCtx.Tracing.KVTracingEnabled()); err != nil {
 					return err
@@ -602,11 +896,35 @@ func (n *createTableNode) startExec(params runParams) error {
 		if err != nil {
 			return err
 		}
+		if addedCheck != nil {
+			if err := validateCreateTableAsCheckInTxn(params, desc, addedCheck); err != nil {
+				return err
+			}
+		}
+	}
+
+	if n.n.OnCommit == tree.CreateTableOnCommitDrop {
+		if err := params.p.onCommitDropTables.addOnCommitDropTable(desc.ID); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// populateInline returns true if the table created by a CREATE TABLE AS
+// statement must be populated within the statement's transaction. This is the
+// case when the inline storage parameter is set and the source is estimated
+// to be small enough, and for ON COMMIT DROP tables, which don't survive the
+// transaction.
+func (n *createTableNode) populateInline(params runParams) bool {
+	if n.inline || n.n.OnCommit == tree.CreateTableOnCommitDrop {
+		return true
+	}
+	limit := createTableAsInlineRowLimit.Get(&params.ExecCfg().Settings.SV)
+	return n.inlineRequested && n.sourceRowCount <= float64(limit)
+}
+
 func (*createTableNode) Next(runParams) (bool, error) { return false, nil }
 func (*createTableNode) Values() tree.Datums          { return tree.Datums{} }
 
@@ -1191,23 +1509,55 @@ func newTableDescIfAs(
 	if err := validateUniqueConstraintParamsForCreateTableAs(p); err != nil {
 		return nil, err
 	}
+	if createTableAsLikeDef(p) != nil {
+		// The partitioning of the table, if any, is that of the LIKE table.
+		if p.StorageParams.GetVal(tree.CreateTableAsCopyPartitioningStorageParam) != nil {
+			params.p.BufferClientNotice(params.ctx, pgnotice.Newf(
+				"%s does not apply to CREATE TABLE (LIKE ...) AS",
+				tree.CreateTableAsCopyPartitioningStorageParam,
+			))
+		}
+		// Likewise, the column families of the table are those of the LIKE
+		// table.
+		if p.StorageParams.GetVal(tree.CreateTableAsColumnFamiliesStorageParam) != nil {
+			params.p.BufferClientNotice(params.ctx, pgnotice.Newf(
+				"%s does not apply to CREATE TABLE (LIKE ...) AS",
+				tree.CreateTableAsColumnFamiliesStorageParam,
+			))
+		}
+		return newTableDescIfAsLike(
+			params, p, db, sc, id, creationTime, resultColumns, privileges, evalContext,
+		)
+	}
+	// The columns populated by the source query take the types of its columns,
+	// so their values never lose precision.
+	if p.StorageParams.GetVal(tree.CreateTableAsPrecisionLossStorageParam) != nil {
+		params.p.BufferClientNotice(params.ctx, pgnotice.Newf(
+			"%s only applies to CREATE TABLE (LIKE ...) AS, whose columns may be of "+
+				"different types than those of the source query",
+			tree.CreateTableAsPrecisionLossStorageParam,
+		))
+	}
 
 	colResIndex := 0
 	// TableDefs for a CREATE TABLE ... AS AST node comprise of a ColumnTableDef
 	// for each column, and a ConstraintTableDef for any constraints on those
-	// columns.
+	// columns. Columns declared with a DEFAULT expression already have a type,
+	// and aren't populated by the query.
 	for _, defs := range p.Defs {
 		var d *tree.ColumnTableDef
 		var ok bool
-		if d, ok = defs.(*tree.ColumnTableDef); ok {
+		if d, ok = defs.(*tree.ColumnTableDef); ok && !d.IsCreateAsDeclared() {
 			d.Type = resultColumns[colResIndex].Typ
 			colResIndex++
 		}
 	}
 
-	// If there are no TableDefs defined by the parser, then we construct a
-	// ColumnTableDef for each column using resultColumns.
-	if len(p.Defs) == 0 {
+	// If there are no TableDefs naming the columns of the query defined by the
+	// parser, then we construct a ColumnTableDef for each column using
+	// resultColumns, after any declared columns.
+	namesFromQuery := colResIndex == 0
+	if namesFromQuery {
 		for _, colRes := range resultColumns {
 			var d *tree.ColumnTableDef
 			var ok bool
@@ -1223,11 +1573,55 @@ func newTableDescIfAs(
 			p.Defs = append(p.Defs, tableDef)
 		}
 	}
+	if err := validateUniqueColumnNamesForCreateTableAs(p, namesFromQuery); err != nil {
+		return nil, err
+	}
+	families, err := columnFamiliesForCreateTableAs(params, p)
+	if err != nil {
+		return nil, err
+	}
+	if len(families) > 0 {
+		for _, def := range p.Defs {
+			hasFamily := false
+			switch d := def.(type) {
+			case *tree.FamilyTableDef:
+				hasFamily = true
+			case *tree.ColumnTableDef:
+				hasFamily = d.HasColumnFamily()
+			}
+			if hasFamily {
+				return nil, pgerror.Newf(pgcode.Syntax,
+					"%s cannot be combined with FAMILY definitions",
+					tree.CreateTableAsColumnFamiliesStorageParam)
+			}
+		}
+		for _, family := range families {
+			p.Defs = append(p.Defs, family)
+		}
+	}
+	if err := validateColumnFamiliesForCreateTableAs(p); err != nil {
+		return nil, err
+	}
+	copyPartitioning, err := createTableAsBoolStorageParam(
+		params, p, tree.CreateTableAsCopyPartitioningStorageParam,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if copyPartitioning {
+		partitionBy, err := partitionByForCreateTableAs(params, p, resultColumns)
+		if err != nil {
+			return nil, err
+		}
+		if partitionBy != nil {
+			p.PartitionByTable = &tree.PartitionByTable{PartitionBy: partitionBy}
+		}
+	}
 
 	// Check if there is any reference to a user defined type that belongs to
 	// another database which is not allowed.
 	for _, def := range p.Defs {
-		if d, ok := def.(*tree.ColumnTableDef); ok {
+		if d, ok := def.(*tree.ColumnTableDef); ok && !d.IsCreateAsDeclared() {
 			// In CTAS, ColumnTableDef are generated from resultColumns which are
 			// resolved already. So we may cast it to *types.T directly without
 			// resolving it again.
@@ -1260,6 +1654,10 @@ func newTableDescIfAs(
 	if err != nil {
 		return nil, err
 	}
+	createQuery, err = wrapCreateTableAsQuery(p, desc, createQuery)
+	if err != nil {
+		return nil, err
+	}
 	desc.CreateQuery = createQuery
 	return desc, nil
 }
@@ -1346,12 +1744,36 @@ func NewTableDesc(
 		id, dbID, sc.GetID(), n.Table.Table(), creationTime, privileges, persistence,
 	)
 
+	storageParams := n.StorageParams
+	if n.As() {
+		// The inline, copy_comments, copy_partitioning, distribute,
+		// precision_loss, collect_stats, add_check, sink, checkpoint and
+		// column_families storage parameters only control how CREATE TABLE AS
+		// creates and populates the table, and aren't persisted.
+		storageParams = make(tree.StorageParams, 0, len(n.StorageParams))
+		for _, param := range n.StorageParams {
+			switch param.Key {
+			case tree.CreateTableAsInlineStorageParam,
+				tree.CreateTableAsCopyCommentsStorageParam,
+				tree.CreateTableAsCopyPartitioningStorageParam,
+				tree.CreateTableAsDistributeStorageParam,
+				tree.CreateTableAsPrecisionLossStorageParam,
+				tree.CreateTableAsCollectStatsStorageParam,
+				tree.CreateTableAsAddCheckStorageParam,
+				tree.CreateTableAsSinkStorageParam,
+				tree.CreateTableAsCheckpointStorageParam,
+				tree.CreateTableAsColumnFamiliesStorageParam:
+			default:
+				storageParams = append(storageParams, param)
+			}
+		}
+	}
 	setter := tablestorageparam.NewSetter(&desc)
 	if err := storageparam.Set(
 		ctx,
 		semaCtx,
 		evalCtx,
-		n.StorageParams,
+		storageParams,
 		setter,
 	); err != nil {
 		return nil, err
@@ -2879,6 +3301,63 @@ func validateUniqueConstraintParamsForCreateTableAs(n *tree.CreateTable) error {
 	return nil
 }
 
+// validateUniqueColumnNamesForCreateTableAs validates that the columns of the
+// table created by a `CREATE TABLE...AS...` statement have distinct names. The
+// names are either specified in the statement or taken from the output columns
+// of the query, as indicated by namesFromQuery.
+func validateUniqueColumnNamesForCreateTableAs(n *tree.CreateTable, namesFromQuery bool) error {
+	seen := make(map[tree.Name]struct{}, len(n.Defs))
+	for _, def := range n.Defs {
+		d, ok := def.(*tree.ColumnTableDef)
+		if !ok {
+			continue
+		}
+		if _, ok := seen[d.Name]; ok {
+			err := pgerror.Newf(pgcode.DuplicateColumn, "column %q specified more than once", string(d.Name))
+			if namesFromQuery {
+				err = errors.WithHint(err, "use AS to give each output column of the query a distinct name")
+			}
+			return err
+		}
+		seen[d.Name] = struct{}{}
+	}
+	return nil
+}
+
+// validateColumnFamiliesForCreateTableAs checks that every column referenced
+// by an explicit FAMILY definition of a `CREATE TABLE...AS...` statement is
+// one of the columns of the new table, i.e. one of the columns projected by
+// the query or declared in the statement.
+func validateColumnFamiliesForCreateTableAs(n *tree.CreateTable) error {
+	columns := make(map[tree.Name]struct{}, len(n.Defs))
+	for _, def := range n.Defs {
+		if d, ok := def.(*tree.ColumnTableDef); ok {
+			columns[d.Name] = struct{}{}
+		}
+	}
+	for _, def := range n.Defs {
+		d, ok := def.(*tree.FamilyTableDef)
+		if !ok {
+			continue
+		}
+		for _, col := range d.Columns {
+			if _, ok := columns[col]; !ok {
+				err := pgerror.Newf(pgcode.UndefinedColumn,
+					"column %q referenced by a family definition does not exist", string(col))
+				if d.Name != "" {
+					err = pgerror.Newf(pgcode.UndefinedColumn,
+						"column %q referenced by family %q does not exist", string(col), string(d.Name))
+				}
+				return errors.WithHintf(err,
+					"families of a table created with CREATE TABLE ... AS, whether defined "+
+						"with FAMILY or the %s storage parameter, can only group the columns of the query results",
+					tree.CreateTableAsColumnFamiliesStorageParam)
+			}
+		}
+	}
+	return nil
+}
+
 // Checks if the column was automatically added by the system (e.g. for a rowid
 // primary key or hash sharded index).
 func isImplicitlyCreatedBySystem(td *tabledesc.Mutable, c *descpb.ColumnDescriptor) (bool, error) {
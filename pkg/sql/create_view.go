@@ -15,6 +15,7 @@ import (
 	"fmt"
 
 	"github.com/cockroachdb/cockroach/pkg/docs"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/server/telemetry"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
@@ -68,6 +69,10 @@ type createViewNode struct {
 	// withData indicates if a materialized view should be populated
 	// with data by executing the underlying query.
 	withData bool
+	// refreshSchedule is the schedule expression on which a materialized view
+	// is periodically refreshed. It is empty if the view should not be
+	// refreshed automatically.
+	refreshSchedule string
 }
 
 // ReadingOwnWrites implements the planNodeReadingOwnWrites interface.
@@ -282,6 +287,23 @@ func (n *createViewNode) startExec(params runParams) error {
 				desc.DependsOnTypes = append(desc.DependsOnTypes, orderedTypeDeps.Ordered()...)
 				newDesc = &desc
 
+				// Materialized views which are refreshed periodically require a
+				// scheduled job to be created as well.
+				if n.materialized && n.refreshSchedule != "" {
+					sj, err := CreateMaterializedViewRefreshSchedule(
+						params.ctx,
+						params.ExecCfg().JobsKnobs(),
+						jobs.ScheduledJobTxn(params.p.InternalSQLTxn()),
+						params.p.User(),
+						newDesc.GetID(),
+						n.refreshSchedule,
+					)
+					if err != nil {
+						return err
+					}
+					newDesc.ViewRefreshScheduleID = sj.ScheduleID()
+				}
+
 				// TODO (lucy): I think this needs a NodeFormatter implementation. For now,
 				// do some basic string formatting (not accurate in the general case).
 				if err = params.p.createDescriptor(
@@ -886,6 +886,13 @@ func (p *PlanningCtx) IsLocal() bool {
 	return p.isLocal
 }
 
+// CollectExecStats makes the flows of the physical plans planned with this
+// PlanningCtx collect execution statistics, which are recorded in the trace of
+// the flows if it is being recorded.
+func (p *PlanningCtx) CollectExecStats() {
+	p.collectExecStats = true
+}
+
 // getPortalPauseInfo returns the portal pause info if the current planner is
 // for a pausable portal. Otherwise, returns nil.
 func (p *PlanningCtx) getPortalPauseInfo() *portalPauseInfo {
@@ -45,6 +45,12 @@ func PlanAndRunCTAS(
 		recv.SetError(errors.Wrapf(err, "constructing distSQL plan"))
 		return
 	}
+	if out.BulkRowWriter != nil && out.BulkRowWriter.Checkpoint {
+		// A checkpointed backfill ingests the rows in the order of the primary
+		// index, so the ordered result streams are merged into a single stream,
+		// ingested by a single bulk row writer.
+		physPlan.EnsureSingleStreamOnGateway(ctx)
+	}
 	physPlan.AddNoGroupingStage(
 		out, execinfrapb.PostProcessSpec{}, rowexec.CTASPlanResultTypes, execinfrapb.Ordering{},
 	)
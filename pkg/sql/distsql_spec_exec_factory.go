@@ -1036,7 +1036,14 @@ func (e *distSQLSpecExecFactory) ConstructCreateTable(
 }
 
 func (e *distSQLSpecExecFactory) ConstructCreateTableAs(
-	input exec.Node, schema cat.Schema, ct *tree.CreateTable,
+	input exec.Node,
+	schema cat.Schema,
+	ct *tree.CreateTable,
+	inline bool,
+	inlineRequested bool,
+	sourceRowCount float64,
+	sourceIDs []cat.StableID,
+	sourceDescriptions []string,
 ) (exec.Node, error) {
 	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: create table")
 }
@@ -1053,6 +1060,7 @@ func (e *distSQLSpecExecFactory) ConstructCreateView(
 	deps opt.SchemaDeps,
 	typeDeps opt.SchemaTypeDeps,
 	withData bool,
+	refreshSchedule string,
 ) (exec.Node, error) {
 	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: create view")
 }
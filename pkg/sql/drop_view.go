@@ -329,6 +329,14 @@ func (p *planner) dropViewImpl(
 		return cascadeDroppedViews, err
 	}
 
+	// Remove the schedule which periodically refreshes a materialized view, if
+	// any.
+	if scheduleID := viewDesc.ViewRefreshScheduleID; scheduleID != 0 {
+		if err := DeleteSchedule(ctx, p.ExecCfg(), p.InternalSQLTxn(), scheduleID); err != nil {
+			return cascadeDroppedViews, err
+		}
+	}
+
 	if err := p.initiateDropTable(ctx, viewDesc, queueJob, jobDesc); err != nil {
 		return cascadeDroppedViews, err
 	}
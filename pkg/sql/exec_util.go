@@ -35,6 +35,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/gossip"
 	"github.com/cockroachdb/cockroach/pkg/inspectz/inspectzpb"
 	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/keyvisualizer"
 	"github.com/cockroachdb/cockroach/pkg/kv"
@@ -1538,6 +1539,11 @@ type ExecutorTestingKnobs struct {
 	// BeforeRestart is called before a transaction restarts.
 	BeforeRestart func(ctx context.Context, reason error)
 
+	// BeforeExecutionDetailsCollection is called when the execution details of
+	// a job are about to be collected, once the collection has acquired its
+	// lease.
+	BeforeExecutionDetailsCollection func(ctx context.Context, jobID jobspb.JobID)
+
 	// DisableAutoCommitDuringExec, if set, disables the auto-commit functionality
 	// of some SQL statements. That functionality allows some statements to commit
 	// directly when they're executed in an implicit SQL txn, without waiting for
@@ -3604,6 +3610,10 @@ func (m *sessionDataMutator) SetUnboundedParallelScans(val bool) {
 	m.data.UnboundedParallelScans = val
 }
 
+func (m *sessionDataMutator) SetCreateTableAsProgressNotices(val bool) {
+	m.data.CreateTableAsProgressNotices = val
+}
+
 func (m *sessionDataMutator) SetReplicationMode(val sessiondatapb.ReplicationMode) {
 	m.data.ReplicationMode = val
 }
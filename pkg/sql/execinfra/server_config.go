@@ -223,7 +223,8 @@ type TestingKnobs struct {
 	// current span and returns an error which eventually is returned to the
 	// caller of SchemaChanger.exec(). In the case of a column backfill, it is
 	// called at the start of the backfill function passed into the transaction
-	// executing the chunk.
+	// executing the chunk. In the case of a CREATE TABLE AS backfill, it is
+	// called with the span of each batch of KVs before it is ingested.
 	RunBeforeBackfillChunk func(sp roachpb.Span) error
 
 	// RunAfterBackfillChunk is called after executing each chunk of a backfill
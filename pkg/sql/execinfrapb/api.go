@@ -11,8 +11,10 @@
 package execinfrapb
 
 import (
+	"sort"
 	"strconv"
 
+	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/security/username"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
 	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
@@ -50,6 +52,42 @@ func (f FlowID) IsUnset() bool {
 // DistSQLVersion identifies DistSQL engine versions.
 type DistSQLVersion uint32
 
+// MakePhysicalPlanSpec returns a PhysicalPlanSpec made up of the given flows,
+// ordered by the SQL instance they are scheduled on.
+func MakePhysicalPlanSpec(flows map[base.SQLInstanceID]*FlowSpec) PhysicalPlanSpec {
+	spec := PhysicalPlanSpec{Flows: make([]PhysicalPlanSpec_Flow, 0, len(flows))}
+	for sqlInstanceID, flow := range flows {
+		spec.Flows = append(spec.Flows, PhysicalPlanSpec_Flow{
+			SQLInstanceID: sqlInstanceID,
+			Spec:          *flow,
+		})
+	}
+	sort.Slice(spec.Flows, func(i, j int) bool {
+		return spec.Flows[i].SQLInstanceID < spec.Flows[j].SQLInstanceID
+	})
+	return spec
+}
+
+// FlowSpecs returns the flows of the physical plan, keyed by the SQL instance
+// they are scheduled on. This is the inverse of MakePhysicalPlanSpec.
+func (m *PhysicalPlanSpec) FlowSpecs() map[base.SQLInstanceID]*FlowSpec {
+	flows := make(map[base.SQLInstanceID]*FlowSpec, len(m.Flows))
+	for i := range m.Flows {
+		flows[m.Flows[i].SQLInstanceID] = &m.Flows[i].Spec
+	}
+	return flows
+}
+
+// NumProcessors returns the number of processors across all the flows of the
+// physical plan.
+func (m *PhysicalPlanSpec) NumProcessors() int {
+	var n int
+	for i := range m.Flows {
+		n += len(m.Flows[i].Spec.Processors)
+	}
+	return n
+}
+
 // MakeEvalContext serializes some of the fields of a eval.Context into a
 // execinfrapb.EvalContext proto.
 func MakeEvalContext(evalCtx *eval.Context) EvalContext {
@@ -666,6 +666,10 @@ type FlowDiagram interface {
 	// diagram.
 	ToURL() (string, url.URL, error)
 
+	// ToText returns a plain-text rendering of the diagram, listing the
+	// processors of the flow on each node followed by the streams between them.
+	ToText() string
+
 	// AddSpans adds stats extracted from the input spans to the diagram.
 	AddSpans([]tracingpb.RecordedSpan)
 
@@ -721,6 +725,59 @@ func (d diagramData) ToURL() (string, url.URL, error) {
 	return encodeJSONToURL(buf)
 }
 
+// ToText implements the FlowDiagram interface.
+func (d diagramData) ToText() string {
+	var buf strings.Builder
+	if d.SQL != "" {
+		fmt.Fprintf(&buf, "%s\n", d.SQL)
+	}
+	flowID := d.FlowID
+	if d.Flags.MakeDeterministic {
+		flowID = FlowID{uuid.Nil}
+	}
+	fmt.Fprintf(&buf, "flow %s\n", flowID)
+	writeDetails := func(indent string, details []string) {
+		for _, detail := range details {
+			fmt.Fprintf(&buf, "%s%s\n", indent, detail)
+		}
+	}
+	for n, name := range d.NodeNames {
+		fmt.Fprintf(&buf, "n%s:\n", name)
+		for _, p := range d.Processors {
+			if p.NodeIdx != n {
+				continue
+			}
+			fmt.Fprintf(&buf, "  %s\n", p.Core.Title)
+			writeDetails("    ", p.Core.Details)
+			for i, in := range p.Inputs {
+				fmt.Fprintf(&buf, "    input %d: %s\n", i+1, in.Title)
+				writeDetails("      ", in.Details)
+			}
+			for i, out := range p.Outputs {
+				fmt.Fprintf(&buf, "    output %d: %s\n", i+1, out.Title)
+				writeDetails("      ", out.Details)
+			}
+		}
+	}
+	if len(d.Edges) > 0 {
+		buf.WriteString("streams:\n")
+	}
+	for _, e := range d.Edges {
+		src, dest := d.Processors[e.SourceProc], d.Processors[e.DestProc]
+		fmt.Fprintf(&buf, "  stream %d: n%s %s", e.StreamID, d.NodeNames[src.NodeIdx], src.Core.Title)
+		if e.SourceOutput > 0 {
+			fmt.Fprintf(&buf, " output %d", e.SourceOutput)
+		}
+		fmt.Fprintf(&buf, " -> n%s %s", d.NodeNames[dest.NodeIdx], dest.Core.Title)
+		if e.DestInput > 0 {
+			fmt.Fprintf(&buf, " input %d", e.DestInput)
+		}
+		buf.WriteString("\n")
+		writeDetails("    ", e.Stats)
+	}
+	return buf.String()
+}
+
 // UpdateComponentFractionProgressed implements the FlowDiagram interface.
 func (d *diagramData) UpdateComponentFractionProgressed(
 	perComponentProgress map[ComponentID]float32,
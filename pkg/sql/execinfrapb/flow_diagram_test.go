@@ -784,6 +784,84 @@ func TestPlanDiagramJoin(t *testing.T) {
 	compareDiagrams(t, s, expected)
 }
 
+// TestPlanDiagramText verifies the plain-text rendering of a plan diagram,
+// which includes the synchronizers and routers of the processors along with
+// the streams between them.
+func TestPlanDiagramText(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	flows := make(map[base.SQLInstanceID]*FlowSpec)
+	flows[1] = &FlowSpec{
+		Processors: []ProcessorSpec{
+			{
+				Core: ProcessorCoreUnion{Noop: &NoopCoreSpec{}},
+				Output: []OutputRouterSpec{{
+					Type:        OutputRouterSpec_BY_HASH,
+					HashColumns: []uint32{0},
+					Streams:     []StreamEndpointSpec{{StreamID: 0}, {StreamID: 1}},
+				}},
+				ProcessorID: 0,
+			},
+			{
+				Input: []InputSyncSpec{{
+					Type: InputSyncSpec_ORDERED,
+					Ordering: Ordering{Columns: []Ordering_Column{
+						{ColIdx: 0, Direction: Ordering_Column_ASC}},
+					},
+					Streams: []StreamEndpointSpec{{StreamID: 0}, {StreamID: 2}},
+				}},
+				Core: ProcessorCoreUnion{Noop: &NoopCoreSpec{}},
+				Post: PostProcessSpec{
+					Projection:    true,
+					OutputColumns: []uint32{0},
+				},
+				Output: []OutputRouterSpec{{
+					Type:    OutputRouterSpec_PASS_THROUGH,
+					Streams: []StreamEndpointSpec{{Type: StreamEndpointSpec_SYNC_RESPONSE}},
+				}},
+				ProcessorID: 2,
+			},
+		},
+	}
+	flows[2] = &FlowSpec{
+		Processors: []ProcessorSpec{{
+			Input: []InputSyncSpec{{
+				Type:    InputSyncSpec_PARALLEL_UNORDERED,
+				Streams: []StreamEndpointSpec{{StreamID: 1}},
+			}},
+			Core: ProcessorCoreUnion{Noop: &NoopCoreSpec{}},
+			Output: []OutputRouterSpec{{
+				Type:    OutputRouterSpec_PASS_THROUGH,
+				Streams: []StreamEndpointSpec{{StreamID: 2}},
+			}},
+			ProcessorID: 1,
+		}},
+	}
+
+	diagram, err := GeneratePlanDiagram("SELECT 1", flows, DiagramFlags{})
+	require.NoError(t, err)
+	expected := `SELECT 1
+flow 00000000-0000-0000-0000-000000000000
+n1:
+  No-op/0
+    output 1: by hash
+      @1
+  No-op/2
+    Out: @1
+    input 1: ordered
+      @1+
+  Response
+n2:
+  No-op/1
+streams:
+  stream 0: n1 No-op/0 output 1 -> n1 No-op/2 input 1
+  stream 1: n1 No-op/0 output 1 -> n2 No-op/1
+  stream 0: n1 No-op/2 -> n1 Response
+  stream 2: n2 No-op/1 -> n1 No-op/2 input 1
+`
+	require.Equal(t, expected, diagram.ToText())
+}
+
 func TestProcessorsImplementDiagramCellType(t *testing.T) {
 	pcu := reflect.ValueOf(ProcessorCoreUnion{})
 	for i := 0; i < pcu.NumField(); i++ {
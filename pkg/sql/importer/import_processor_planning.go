@@ -36,6 +36,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing/tracingpb"
 )
 
 var replanThreshold = settings.RegisterFloatSetting(
@@ -80,6 +81,9 @@ func distImport(
 		if err != nil {
 			return nil, nil, err
 		}
+		// The statistics of the flows are recorded in the job's trace, from
+		// which they're stored as part of the job's execution details.
+		planCtx.CollectExecStats()
 
 		r := rand.New(rand.NewSource(int64(job.ID())))
 		// Shuffle node order so that multiple IMPORTs done in parallel will not
@@ -275,6 +279,8 @@ func distImport(
 		// Copy the evalCtx, as dsp.Run() might change it.
 		evalCtxCopy := *evalCtx
 		dsp.Run(ctx, planCtx, nil, p, recv, &evalCtxCopy, testingKnobs.onSetupFinish)
+		jobsprofiler.StoreFlowStats(ctx, execCfg.InternalDB, job.ID(), execinfrapb.ExtractStatsFromSpans(
+			sp.GetRecording(tracingpb.RecordingStructured), false /* makeDeterministic */))
 		return rowResultWriter.Err()
 	})
 
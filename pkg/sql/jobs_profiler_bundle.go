@@ -13,42 +13,570 @@ package sql
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
+	gojson "encoding/json"
 	"fmt"
 	"io"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/clusterversion"
 	"github.com/cockroachdb/cockroach/pkg/jobs"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobsprofiler/profilerconstants"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/liveness/livenesspb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/spanconfig"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/sql/isql"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/admission/admissionpb"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/optional"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/cockroach/pkg/util/rangedesc"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/logtags"
+	"github.com/dustin/go-humanize"
 	"github.com/klauspost/compress/gzip"
 )
 
 const bundleChunkSize = 1 << 20 // 1 MiB
 const finalChunkSuffix = "#_final"
 
+// maxConcurrentExecutionDetailCollections limits the number of execution
+// detail collections which run concurrently across the cluster, whichever jobs
+// they are collected for. Collecting the execution details of a job fans out
+// to every node in the cluster, so a burst of requests could otherwise degrade
+// the cluster.
+var maxConcurrentExecutionDetailCollections = settings.RegisterIntSetting(
+	settings.TenantWritable,
+	"jobs.execution_details.max_concurrent_collections",
+	"the maximum number of execution detail collections which may run concurrently "+
+		"across the cluster, across all jobs; requests beyond the limit are rejected and may be retried",
+	4,
+	settings.PositiveInt,
+)
+
+// executionDetailCollectionLeasesJobID and
+// executionDetailCollectionLeasesInfoKey identify the system.job_info row which
+// stores the expiration of each lease held on an execution detail collection.
+// The leases are counted across all jobs, so rather than being recorded against
+// the jobs whose execution details are collected, which would require a scan of
+// system.job_info to count them, they are all stored in a single row which is
+// read with a point lookup. The row is recorded against the static ID of the
+// job metrics poller job, which is never garbage collected.
+const (
+	executionDetailCollectionLeasesJobID   = jobs.JobMetricsPollerJobID
+	executionDetailCollectionLeasesInfoKey = "~collection-leases"
+)
+
+// executionDetailCollectionLeaseDuration is the duration of a lease held on an
+// execution detail collection. A collection is bounded by the duration of its
+// lease, so that the lease of a collection whose node failed before releasing
+// it only counts against the limit until it expires.
+const executionDetailCollectionLeaseDuration = 10 * time.Minute
+
+// executionDetailCollectionLeaseReleaseTimeout bounds the release of a lease
+// held on an execution detail collection. The release doesn't inherit the
+// cancellation of the collection, which may have been cancelled by a timeout.
+const executionDetailCollectionLeaseReleaseTimeout = 10 * time.Second
+
+// executionDetailCollectionLeases maps the ID of each lease held on an
+// execution detail collection to its expiration, in nanoseconds since the
+// epoch.
+type executionDetailCollectionLeases map[string]int64
+
+// updateExecutionDetailCollectionLeases reads the leases held on execution
+// detail collections, calls fn to update them, and writes them back. The row is
+// removed once no leases are held.
+func updateExecutionDetailCollectionLeases(
+	ctx context.Context, txn isql.Txn, fn func(leases executionDetailCollectionLeases) error,
+) error {
+	infoStorage := jobs.InfoStorageForJob(txn, executionDetailCollectionLeasesJobID)
+	value, exists, err := infoStorage.Get(ctx, executionDetailCollectionLeasesInfoKey)
+	if err != nil {
+		return err
+	}
+	leases := executionDetailCollectionLeases{}
+	if exists {
+		if err := gojson.Unmarshal(value, &leases); err != nil {
+			return errors.Wrap(err, "failed to parse the execution detail collection leases")
+		}
+	}
+	if err := fn(leases); err != nil {
+		return err
+	}
+	if len(leases) == 0 {
+		if !exists {
+			return nil
+		}
+		return infoStorage.Delete(ctx, executionDetailCollectionLeasesInfoKey)
+	}
+	value, err = gojson.Marshal(leases)
+	if err != nil {
+		return err
+	}
+	return infoStorage.Write(ctx, executionDetailCollectionLeasesInfoKey, value)
+}
+
+// acquireExecutionDetailCollectionLease acquires a lease on an execution
+// detail collection, if fewer than
+// jobs.execution_details.max_concurrent_collections unexpired leases are held
+// across the cluster. Expired leases are removed along the way. The returned
+// function releases the lease, and the returned time is its expiration.
+func acquireExecutionDetailCollectionLease(
+	ctx context.Context, execCfg *ExecutorConfig, jobID jobspb.JobID,
+) (release func(), expiration time.Time, _ error) {
+	limit := maxConcurrentExecutionDetailCollections.Get(&execCfg.Settings.SV)
+	leaseID := uuid.MakeV4().String()
+	if err := execCfg.InternalDB.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		now := txn.KV().ReadTimestamp().GoTime()
+		return updateExecutionDetailCollectionLeases(ctx, txn, func(leases executionDetailCollectionLeases) error {
+			for id, nanos := range leases {
+				if !now.Before(timeutil.Unix(0, nanos)) {
+					delete(leases, id)
+				}
+			}
+			// Rather than queueing behind the collections which are already
+			// running, reject the request so that the caller may retry it later.
+			if int64(len(leases)) >= limit {
+				return errors.WithHintf(
+					pgerror.Newf(pgcode.ConfigurationLimitExceeded,
+						"too many concurrent execution detail collections to collect the execution details of job %d",
+						jobID),
+					"try again later, or raise the %s cluster setting",
+					maxConcurrentExecutionDetailCollections.Key())
+			}
+			expiration = now.Add(executionDetailCollectionLeaseDuration)
+			leases[leaseID] = expiration.UnixNano()
+			return nil
+		})
+	}); err != nil {
+		return nil, time.Time{}, err
+	}
+	release = func() {
+		// The collection may have been cancelled, e.g. by the timeout of
+		// crdb_internal.request_job_execution_details_sync, in which case
+		// releasing the lease with its context would fail and leave the lease
+		// counting against the limit until it expires.
+		releaseCtx := execCfg.AmbientCtx.AnnotateCtx(context.Background())
+		// AddTags and not WithTags, so that we combine the tags with those
+		// filled by AnnotateCtx.
+		releaseCtx = logtags.AddTags(releaseCtx, logtags.FromContext(ctx))
+		releaseCtx, cancel := context.WithTimeout(releaseCtx, executionDetailCollectionLeaseReleaseTimeout)
+		defer cancel()
+		if err := execCfg.InternalDB.Txn(releaseCtx, func(ctx context.Context, txn isql.Txn) error {
+			return updateExecutionDetailCollectionLeases(ctx, txn, func(leases executionDetailCollectionLeases) error {
+				delete(leases, leaseID)
+				return nil
+			})
+		}); err != nil {
+			log.Warningf(releaseCtx, "failed to release the execution detail collection lease of job %d, "+
+				"it will be released once it expires: %v", jobID, err)
+		}
+	}
+	return release, expiration, nil
+}
+
 // RequestExecutionDetails implements the JobProfiler interface.
-func (p *planner) RequestExecutionDetails(ctx context.Context, jobID jobspb.JobID) error {
-	execCfg := p.ExecCfg()
+func (p *planner) RequestExecutionDetails(
+	ctx context.Context, jobID jobspb.JobID, opts eval.ExecutionDetailsOptions,
+) error {
+	return RequestJobExecutionDetails(ctx, p.ExecCfg(), jobID, opts)
+}
+
+// RequestExecutionDetailsAndWait implements the JobProfiler interface.
+func (p *planner) RequestExecutionDetailsAndWait(
+	ctx context.Context, jobID jobspb.JobID, timeout time.Duration,
+) (files []string, complete bool, _ error) {
+	if timeout <= 0 {
+		return nil, false, pgerror.Newf(pgcode.InvalidParameterValue,
+			"timeout must be positive, got %s", timeout)
+	}
+	e := MakeJobProfilerExecutionDetailsBuilder(p.ExecCfg().SQLStatusServer, p.ExecCfg().InternalDB, jobID)
+	before, err := e.ListExecutionDetailFiles(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// The collectors log their failures rather than returning them, so a
+	// collection which times out stops at the first collector to observe the
+	// deadline, having written the files of the collectors before it.
+	collectCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err = RequestJobExecutionDetails(collectCtx, p.ExecCfg(), jobID, eval.ExecutionDetailsOptions{})
+	complete = collectCtx.Err() == nil
+	if err != nil && complete {
+		return nil, false, err
+	}
+
+	after, err := e.ListExecutionDetailFiles(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	// The names of the files incorporate the time of the collection, so the
+	// files written by this collection are those which weren't listed before.
+	existing := make(map[string]struct{}, len(before))
+	for _, f := range before {
+		existing[f.Name] = struct{}{}
+	}
+	for _, f := range after {
+		if _, ok := existing[f.Name]; !ok {
+			files = append(files, f.Name)
+		}
+	}
+	return files, complete, nil
+}
+
+// RequestJobExecutionDetails collects the execution details of the specified
+// job, and persists them to the `system.job_info` table of the tenant that
+// execCfg belongs to. It is used outside of a SQL session, e.g. by the status
+// server to collect the execution details of a secondary tenant's job.
+func RequestJobExecutionDetails(
+	ctx context.Context, execCfg *ExecutorConfig, jobID jobspb.JobID, opts eval.ExecutionDetailsOptions,
+) error {
 	if !execCfg.Settings.Version.IsActive(ctx, clusterversion.V23_1) {
 		return errors.Newf("execution details can only be requested on a cluster with version >= %s",
 			clusterversion.V23_1.String())
 	}
 
-	e := MakeJobProfilerExecutionDetailsBuilder(execCfg.SQLStatusServer, execCfg.InternalDB, jobID)
-	// TODO(adityamaru): When we start collecting more information we can consider
-	// parallelize the collection of the various pieces.
-	e.addDistSQLDiagram(ctx)
-	e.addLabelledGoroutines(ctx)
+	return runExecutionDetailCollection(ctx, execCfg, jobID, func(ctx context.Context) error {
+		e := MakeJobProfilerExecutionDetailsBuilder(execCfg.SQLStatusServer, execCfg.InternalDB, jobID)
+		e.label = sanitizeExecutionDetailsLabel(opts.Label)
+		c := executionDetailCollection{execCfg: execCfg, opts: opts}
+		if j, err := execCfg.JobRegistry.LoadJob(ctx, jobID); err != nil {
+			log.Errorf(ctx, "failed to load job %d to collect the details of its descriptors: %+v", jobID, err.Error())
+		} else {
+			p := j.Payload()
+			c.payload = &p
+			c.descIDs = p.DescriptorIDs
+		}
+		// TODO(adityamaru): When we start collecting more information we can consider
+		// parallelize the collection of the various pieces.
+		for _, collector := range executionDetailCollectors {
+			collector.collect(ctx, &e, &c)
+		}
+		return nil
+	})
+}
 
-	return nil
+// runExecutionDetailCollection runs collect, which collects execution details
+// of the specified job, once it has acquired a lease on the collection. The
+// collection is bounded by the expiration of its lease.
+func runExecutionDetailCollection(
+	ctx context.Context,
+	execCfg *ExecutorConfig,
+	jobID jobspb.JobID,
+	collect func(ctx context.Context) error,
+) error {
+	release, expiration, err := acquireExecutionDetailCollectionLease(ctx, execCfg, jobID)
+	if err != nil {
+		return err
+	}
+	defer release()
+	ctx, cancel := context.WithDeadline(ctx, expiration)
+	defer cancel()
+	if fn := execCfg.TestingKnobs.BeforeExecutionDetailsCollection; fn != nil {
+		fn(ctx, jobID)
+	}
+	return collect(ctx)
+}
+
+// The types of execution details that may be collected for a job.
+const (
+	distSQLDiagramArtifact  = "distsql_diagram"
+	distSQLPlanSpecArtifact = "distsql_plan_spec"
+	nodesArtifact           = "nodes"
+	flowStatsArtifact       = "flow_stats"
+	goroutinesArtifact      = "goroutines"
+	retriesArtifact         = "retries"
+	contentionArtifact      = "contention"
+	spanConfigsArtifact     = "span_configs"
+	txnStatsArtifact        = "txn_stats"
+	admissionArtifact       = "admission"
+	storageStatsArtifact    = "storage_stats"
+)
+
+// executionDetailCollection is the state shared by the collectors of the
+// execution details of a job.
+type executionDetailCollection struct {
+	execCfg *ExecutorConfig
+	opts    eval.ExecutionDetailsOptions
+	// payload is the payload of the job, or nil if it could not be loaded.
+	payload *jobspb.Payload
+	// descIDs are the descriptors the job operates on.
+	descIDs []descpb.ID
+}
+
+// executionDetailCollector collects one kind of execution detail of a job.
+type executionDetailCollector struct {
+	// artifactTypes describes the files written by the collector.
+	artifactTypes []serverpb.JobProfilerArtifactType
+	// collect writes the files of the collector. Failures are logged rather
+	// than returned, so that a failing collector doesn't prevent the others
+	// from collecting their execution details.
+	collect func(ctx context.Context, e *ExecutionDetailsBuilder, c *executionDetailCollection)
+}
+
+// executionDetailCollectors are the collectors run by RequestExecutionDetails,
+// in order. The artifact types returned by ExecutionDetailArtifactTypes are
+// those of the collectors, so a new collector must describe its files.
+var executionDetailCollectors = []executionDetailCollector{
+	{
+		artifactTypes: []serverpb.JobProfilerArtifactType{
+			{
+				Name:        distSQLDiagramArtifact,
+				NamePattern: "distsql.<timestamp>.attempt<attempt>.html",
+				MediaType:   "text/html",
+				Description: "A redirect to the diagram of the latest DistSQL plan executed by the job, named after the run of the job which executed it.",
+			},
+			{
+				Name:        distSQLPlanSpecArtifact,
+				NamePattern: "distsql.<timestamp>.attempt<attempt>.binpb",
+				MediaType:   "application/octet-stream",
+				Description: "The latest DistSQL plan executed by the job, as a marshaled execinfrapb.PhysicalPlanSpec, named after the run of the job which executed it.",
+			},
+		},
+		collect: func(ctx context.Context, e *ExecutionDetailsBuilder, _ *executionDetailCollection) {
+			e.addDistSQLDiagram(ctx)
+		},
+	},
+	{
+		artifactTypes: []serverpb.JobProfilerArtifactType{{
+			Name:        nodesArtifact,
+			NamePattern: "nodes.<timestamp>.txt",
+			MediaType:   "text/plain",
+			Description: "The SQL instances which ran a flow of the latest DistSQL plan of the job, and their liveness.",
+		}},
+		collect: func(ctx context.Context, e *ExecutionDetailsBuilder, c *executionDetailCollection) {
+			e.addParticipatingNodes(ctx, c.execCfg.Codec.ForSystemTenant())
+		},
+	},
+	{
+		artifactTypes: []serverpb.JobProfilerArtifactType{{
+			Name:        flowStatsArtifact,
+			NamePattern: "flow_stats.<timestamp>.txt",
+			MediaType:   "text/plain",
+			Description: "The execution statistics of the flows of the latest DistSQL plan of the job.",
+		}},
+		collect: func(ctx context.Context, e *ExecutionDetailsBuilder, _ *executionDetailCollection) {
+			e.addFlowStats(ctx)
+		},
+	},
+	{
+		artifactTypes: []serverpb.JobProfilerArtifactType{{
+			Name:        goroutinesArtifact,
+			NamePattern: "goroutines.<timestamp>.txt",
+			MediaType:   "text/plain",
+			Description: "The stacks of the goroutines labelled with the job, across the cluster.",
+		}},
+		collect: func(ctx context.Context, e *ExecutionDetailsBuilder, _ *executionDetailCollection) {
+			e.addLabelledGoroutines(ctx)
+		},
+	},
+	{
+		artifactTypes: []serverpb.JobProfilerArtifactType{{
+			Name:        goroutinesArtifact,
+			NamePattern: "goroutines.<node>.<timestamp>.pprof",
+			MediaType:   "application/octet-stream",
+			Description: "A goroutine profile of each node, in the pprof format.",
+		}},
+		collect: func(ctx context.Context, e *ExecutionDetailsBuilder, c *executionDetailCollection) {
+			if c.opts.GoroutinesPprof {
+				e.addGoroutinesPprof(ctx)
+			}
+		},
+	},
+	{
+		artifactTypes: []serverpb.JobProfilerArtifactType{{
+			Name:        retriesArtifact,
+			NamePattern: "retries.<timestamp>.txt",
+			MediaType:   "text/plain",
+			Description: "The retry history of the job.",
+		}},
+		collect: func(ctx context.Context, e *ExecutionDetailsBuilder, _ *executionDetailCollection) {
+			e.addRetryHistory(ctx)
+		},
+	},
+	{
+		artifactTypes: []serverpb.JobProfilerArtifactType{{
+			Name:        contentionArtifact,
+			NamePattern: "contention.<timestamp>.txt",
+			MediaType:   "text/plain",
+			Description: "The contention events on the descriptors the job operates on.",
+		}},
+		collect: func(ctx context.Context, e *ExecutionDetailsBuilder, c *executionDetailCollection) {
+			e.addContentionEvents(ctx, c.execCfg.Codec, c.descIDs)
+		},
+	},
+	{
+		artifactTypes: []serverpb.JobProfilerArtifactType{{
+			Name:        spanConfigsArtifact,
+			NamePattern: "span_configs.<timestamp>.txt",
+			MediaType:   "text/plain",
+			Description: "The span configs applying to the descriptors the job operates on.",
+		}},
+		collect: func(ctx context.Context, e *ExecutionDetailsBuilder, c *executionDetailCollection) {
+			e.addSpanConfigs(ctx, c.execCfg.SpanConfigKVAccessor, c.execCfg.Codec, c.descIDs)
+		},
+	},
+	{
+		artifactTypes: []serverpb.JobProfilerArtifactType{{
+			Name:        txnStatsArtifact,
+			NamePattern: "txn_stats.<timestamp>.txt",
+			MediaType:   "text/plain",
+			Description: "The persisted SQL statistics of the statement fingerprint the job originated from.",
+		}},
+		collect: func(ctx context.Context, e *ExecutionDetailsBuilder, c *executionDetailCollection) {
+			if c.payload != nil {
+				e.addTxnStats(ctx, c.payload)
+			}
+		},
+	},
+	{
+		artifactTypes: []serverpb.JobProfilerArtifactType{{
+			Name:        admissionArtifact,
+			NamePattern: "admission.<timestamp>.txt",
+			MediaType:   "text/plain",
+			Description: "The admission control queueing of the work of the job on each node, by queue and priority.",
+		}},
+		collect: func(ctx context.Context, e *ExecutionDetailsBuilder, c *executionDetailCollection) {
+			if c.payload != nil {
+				e.addAdmissionQueueing(ctx, c.payload.Type(), c.execCfg.Codec.ForSystemTenant())
+			}
+		},
+	},
+	{
+		artifactTypes: []serverpb.JobProfilerArtifactType{{
+			Name:        storageStatsArtifact,
+			NamePattern: "storage_stats.<node>.<timestamp>.txt",
+			MediaType:   "text/plain",
+			Description: "The LSM level sizes and compaction backlog of the stores of each node holding the data of the descriptors the job operates on.",
+		}},
+		collect: func(ctx context.Context, e *ExecutionDetailsBuilder, c *executionDetailCollection) {
+			e.addStorageStats(ctx, c.execCfg.RangeDescIteratorFactory, c.execCfg.Codec, c.descIDs,
+				c.execCfg.Codec.ForSystemTenant())
+		},
+	},
+}
+
+// ExecutionDetailArtifactTypes returns a description of the types of
+// execution detail files which may be collected for a job.
+func ExecutionDetailArtifactTypes() []serverpb.JobProfilerArtifactType {
+	var artifactTypes []serverpb.JobProfilerArtifactType
+	for _, collector := range executionDetailCollectors {
+		artifactTypes = append(artifactTypes, collector.artifactTypes...)
+	}
+	return artifactTypes
+}
+
+// jobTypesWithDistSQLPlans are the types of jobs that persist the DistSQL plan
+// they are executing, from which the DistSQL execution details are generated.
+var jobTypesWithDistSQLPlans = map[jobspb.Type]struct{}{
+	jobspb.TypeBackup:          {},
+	jobspb.TypeRestore:         {},
+	jobspb.TypeImport:          {},
+	jobspb.TypeChangefeed:      {},
+	jobspb.TypeStreamIngestion: {},
+}
+
+// jobTypesWithFlowStats are the types of jobs whose DistSQL flows collect
+// execution statistics, which they store via jobsprofiler.StoreFlowStats once
+// the flows have completed, and from which the flow stats are generated.
+var jobTypesWithFlowStats = map[jobspb.Type]struct{}{
+	jobspb.TypeImport: {},
+}
+
+// executionDetailArtifacts is a JSON serializable struct that describes the
+// types of execution details that can be collected for a job.
+type executionDetailArtifacts struct {
+	JobID       jobspb.JobID `json:"job_id"`
+	JobType     string       `json:"job_type"`
+	Supported   []string     `json:"supported"`
+	Unsupported []string     `json:"unsupported"`
+}
+
+// ExecutionDetailArtifactsJSON implements the JobProfiler interface.
+func (p *planner) ExecutionDetailArtifactsJSON(
+	ctx context.Context, jobID jobspb.JobID,
+) ([]byte, error) {
+	j, err := p.ExecCfg().JobRegistry.LoadJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	typ := j.Payload().Type()
+	supported, unsupported := executionDetailArtifactSupport(typ)
+	return gojson.Marshal(executionDetailArtifacts{
+		JobID:       jobID,
+		JobType:     typ.String(),
+		Supported:   supported,
+		Unsupported: unsupported,
+	})
+}
+
+// executionDetailArtifactSupport returns the types of execution details that
+// can, and cannot, be collected for a job of the given type.
+func executionDetailArtifactSupport(typ jobspb.Type) (supported, unsupported []string) {
+	artifacts := executionDetailArtifacts{
+		Supported:   []string{},
+		Unsupported: []string{},
+	}
+	// The nodes which participated in the job are derived from its DistSQL
+	// plan.
+	distSQLArtifacts := []string{distSQLDiagramArtifact, distSQLPlanSpecArtifact, nodesArtifact}
+	if _, ok := jobTypesWithDistSQLPlans[typ]; ok {
+		artifacts.Supported = append(artifacts.Supported, distSQLArtifacts...)
+	} else {
+		artifacts.Unsupported = append(artifacts.Unsupported, distSQLArtifacts...)
+	}
+	// The flow stats are only stored by the jobs whose flows collect them.
+	if _, ok := jobTypesWithFlowStats[typ]; ok {
+		artifacts.Supported = append(artifacts.Supported, flowStatsArtifact)
+	} else {
+		artifacts.Unsupported = append(artifacts.Unsupported, flowStatsArtifact)
+	}
+	// Every job runs with a pprof label identifying it, so its goroutines can
+	// always be collected.
+	artifacts.Supported = append(artifacts.Supported, goroutinesArtifact)
+	// The retry history is read from the persisted state of every job.
+	artifacts.Supported = append(artifacts.Supported, retriesArtifact)
+	// The contention events of every job are collected, although a job which
+	// doesn't record the descriptors it operates on will have none attributed
+	// to it.
+	artifacts.Supported = append(artifacts.Supported, contentionArtifact)
+	// Likewise, the span configs of every job are collected, although a job
+	// which doesn't record its descriptors has no target spans.
+	artifacts.Supported = append(artifacts.Supported, spanConfigsArtifact)
+	// The fingerprint stats of every job are collected, although a job which
+	// didn't originate from a SQL statement has none.
+	artifacts.Supported = append(artifacts.Supported, txnStatsArtifact)
+	// The admission control queueing is only collected for the jobs whose
+	// work is subject to admission control.
+	if _, ok := jobTypesWithAdmissionControl[typ]; ok {
+		artifacts.Supported = append(artifacts.Supported, admissionArtifact)
+	} else {
+		artifacts.Unsupported = append(artifacts.Unsupported, admissionArtifact)
+	}
+	// The storage engine stats of the stores holding the data of every job are
+	// collected, although a job which doesn't record its descriptors has no
+	// data, and those of a secondary tenant's job aren't visible to it.
+	artifacts.Supported = append(artifacts.Supported, storageStatsArtifact)
+	return artifacts.Supported, artifacts.Unsupported
 }
 
 // ExecutionDetailsBuilder can be used to read and write execution details corresponding
@@ -57,6 +585,67 @@ type ExecutionDetailsBuilder struct {
 	srv   serverpb.SQLStatusServer
 	db    isql.DB
 	jobID jobspb.JobID
+	// label is the sanitized label of the collection, incorporated into the
+	// names of the files it writes. It is empty if the collection is
+	// unlabelled.
+	label string
+}
+
+// executionDetailsLabelSeparator separates the timestamp of a collection of
+// execution details from its label, in the names of the files it writes, e.g.
+// `goroutines.<timestamp>@<label>.txt`.
+const executionDetailsLabelSeparator = '@'
+
+// maxExecutionDetailsLabelLength is the maximum length of the label of a
+// collection of execution details, beyond which it is truncated.
+const maxExecutionDetailsLabelLength = 64
+
+// sanitizeExecutionDetailsLabel returns the label of a collection of execution
+// details as it is incorporated into the names of the files it writes. The
+// label is lowercased, and every character other than an ASCII letter, a digit,
+// '-' or '_' is replaced by '_', so that the label can't introduce the '.'
+// separating the components of a file name, the '#' separating the chunks of
+// a file, or a label separator.
+func sanitizeExecutionDetailsLabel(label string) string {
+	label = strings.ToLower(strings.TrimSpace(label))
+	var b strings.Builder
+	for _, r := range label {
+		if b.Len() == maxExecutionDetailsLabelLength {
+			break
+		}
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// ExecutionDetailLabel returns the label of the collection which wrote the
+// execution detail file with the given name, or an empty string if the
+// collection was unlabelled.
+func ExecutionDetailLabel(filename string) string {
+	i := strings.IndexByte(filename, executionDetailsLabelSeparator)
+	if i < 0 {
+		return ""
+	}
+	label := filename[i+1:]
+	if j := strings.IndexByte(label, '.'); j >= 0 {
+		label = label[:j]
+	}
+	return label
+}
+
+// timestamp returns the component of the names of the files written by the
+// collection which identifies it: the current time, followed by the label of
+// the collection if it is labelled.
+func (e *ExecutionDetailsBuilder) timestamp() string {
+	ts := timeutil.Now().Format("20060102_150405.00")
+	if e.label == "" {
+		return ts
+	}
+	return fmt.Sprintf("%s%c%s", ts, executionDetailsLabelSeparator, e.label)
 }
 
 func compressChunk(chunkBuf []byte) ([]byte, error) {
@@ -159,21 +748,122 @@ func (e *ExecutionDetailsBuilder) ReadExecutionDetail(
 	return buf.Bytes(), nil
 }
 
+// ReadExecutionDetailRange returns at most length bytes of the uncompressed
+// data of the file, starting at offset start, along with the size of the whole
+// file. A negative start is relative to the end of the file. The uncompressed
+// size of each chunk is read from its gzip trailer, so that only the chunks
+// which overlap the range are decompressed.
+func (e *ExecutionDetailsBuilder) ReadExecutionDetailRange(
+	ctx context.Context, filename string, start, length int64,
+) (_ []byte, totalSize int64, _ error) {
+	type chunk struct {
+		value []byte
+		size  int64
+	}
+	var chunks []chunk
+	if err := e.db.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		// Reset the chunks inside the txn closure to guard against txn retries.
+		chunks = chunks[:0]
+		totalSize = 0
+		jobInfo := jobs.InfoStorageForJob(txn, e.jobID)
+		var lastInfoKey string
+		if err := jobInfo.Iterate(ctx, profilerconstants.MakeProfilerExecutionDetailsChunkKeyPrefix(filename),
+			func(infoKey string, value []byte) error {
+				lastInfoKey = infoKey
+				size, err := uncompressedChunkSize(value)
+				if err != nil {
+					return err
+				}
+				chunks = append(chunks, chunk{value: value, size: size})
+				totalSize += size
+				return nil
+			}); err != nil {
+			return errors.Wrapf(err, "failed to iterate over chunks for job %d", e.jobID)
+		}
+		if lastInfoKey != "" && !strings.Contains(lastInfoKey, finalChunkSuffix) {
+			return errors.Newf("failed to read all chunks for file %s, last info key read was %s", filename, lastInfoKey)
+		}
+		return nil
+	}); err != nil {
+		return nil, 0, err
+	}
+
+	if start < 0 {
+		start += totalSize
+		if start < 0 {
+			start = 0
+		}
+	}
+	if start >= totalSize || length <= 0 {
+		return nil, totalSize, nil
+	}
+	end := totalSize
+	if length < totalSize-start {
+		end = start + length
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, end-start))
+	var chunkStart int64
+	for _, c := range chunks {
+		chunkEnd := chunkStart + c.size
+		if chunkEnd > start && chunkStart < end {
+			r, err := gzip.NewReader(bytes.NewBuffer(c.value))
+			if err != nil {
+				return nil, 0, err
+			}
+			decompressed, err := io.ReadAll(r)
+			if err != nil {
+				return nil, 0, err
+			}
+			if int64(len(decompressed)) != c.size {
+				return nil, 0, errors.AssertionFailedf("chunk of file %s decompressed to %d bytes, expected %d",
+					filename, len(decompressed), c.size)
+			}
+			lo, hi := int64(0), c.size
+			if start > chunkStart {
+				lo = start - chunkStart
+			}
+			if end < chunkEnd {
+				hi = end - chunkStart
+			}
+			buf.Write(decompressed[lo:hi])
+		}
+		chunkStart = chunkEnd
+	}
+	return buf.Bytes(), totalSize, nil
+}
+
 // ListExecutionDetailFiles lists all the files that have been generated as part
-// of a job's execution details.
-func (e *ExecutionDetailsBuilder) ListExecutionDetailFiles(ctx context.Context) ([]string, error) {
-	var res []string
+// of a job's execution details, along with their uncompressed size and the time
+// at which they were written.
+func (e *ExecutionDetailsBuilder) ListExecutionDetailFiles(
+	ctx context.Context,
+) ([]serverpb.ExecutionDetailFile, error) {
+	var res []serverpb.ExecutionDetailFile
 	if err := e.db.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
 		jobInfo := jobs.InfoStorageForJob(txn, e.jobID)
 
 		// Iterate over all the files that have been stored as part of the job's
-		// execution details.
-		files := make([]string, 0)
-		if err := jobInfo.Iterate(ctx, profilerconstants.ExecutionDetailsChunkKeyPrefix,
-			func(infoKey string, value []byte) error {
+		// execution details. The chunks of a file sort before its final chunk, so
+		// we accumulate the size of the chunks until the final chunk is seen.
+		files := make([]serverpb.ExecutionDetailFile, 0)
+		var size int64
+		if err := jobInfo.IterateWithWritten(ctx, profilerconstants.ExecutionDetailsChunkKeyPrefix,
+			func(infoKey string, value []byte, written time.Time) error {
+				chunkSize, err := uncompressedChunkSize(value)
+				if err != nil {
+					return errors.Wrapf(err, "failed to read size of chunk %s", infoKey)
+				}
+				size += chunkSize
 				// Look for the final chunk of each file to find the unique file name.
 				if strings.HasSuffix(infoKey, finalChunkSuffix) {
-					files = append(files, strings.TrimSuffix(infoKey, finalChunkSuffix))
+					name := strings.TrimSuffix(infoKey, finalChunkSuffix)
+					files = append(files, serverpb.ExecutionDetailFile{
+						Name:      name,
+						SizeBytes: size,
+						Written:   written,
+						Label:     ExecutionDetailLabel(name),
+					})
+					size = 0
 				}
 				return nil
 			}); err != nil {
@@ -188,6 +878,17 @@ func (e *ExecutionDetailsBuilder) ListExecutionDetailFiles(ctx context.Context)
 	return res, nil
 }
 
+// uncompressedChunkSize returns the uncompressed size of a gzip compressed
+// chunk. The size is read from the trailer of the gzip stream, which stores
+// the uncompressed size modulo 2^32. Chunks are at most bundleChunkSize bytes,
+// so this is the exact size of the chunk.
+func uncompressedChunkSize(chunk []byte) (int64, error) {
+	if len(chunk) < 4 {
+		return 0, errors.Newf("chunk of %d bytes is too short to be gzip compressed", len(chunk))
+	}
+	return int64(binary.LittleEndian.Uint32(chunk[len(chunk)-4:])), nil
+}
+
 // MakeJobProfilerExecutionDetailsBuilder returns an instance of an ExecutionDetailsBuilder.
 func MakeJobProfilerExecutionDetailsBuilder(
 	srv serverpb.SQLStatusServer, db isql.DB, jobID jobspb.JobID,
@@ -202,25 +903,205 @@ func MakeJobProfilerExecutionDetailsBuilder(
 // cluster that have a pprof label tying it to the job whose execution details
 // are being collected.
 func (e *ExecutionDetailsBuilder) addLabelledGoroutines(ctx context.Context) {
-	profileRequest := serverpb.ProfileRequest{
-		NodeId:      "all",
-		Type:        serverpb.ProfileRequest_GOROUTINE,
-		Labels:      true,
-		LabelFilter: fmt.Sprintf("%d", e.jobID),
+	if _, err := e.collectLabelledGoroutines(ctx); err != nil {
+		log.Errorf(ctx, "%+v", err.Error())
 	}
-	resp, err := e.srv.Profile(ctx, &profileRequest)
+}
+
+// collectLabelledGoroutines collects and persists goroutines from all nodes in
+// the cluster that have a pprof label tying it to the job, returning the name
+// of the file they were written to.
+func (e *ExecutionDetailsBuilder) collectLabelledGoroutines(ctx context.Context) (string, error) {
+	data, _, err := e.fetchLabelledGoroutines(ctx)
+	if err != nil {
+		return "", err
+	}
+	return e.writeLabelledGoroutines(ctx, data)
+}
+
+// fetchLabelledGoroutines collects the goroutines from each node in the
+// cluster that have a pprof label tying it to the job. The nodes whose
+// goroutines could not be collected are noted in the returned data instead.
+// The number of nodes whose goroutines were collected is also returned.
+func (e *ExecutionDetailsBuilder) fetchLabelledGoroutines(
+	ctx context.Context,
+) (data []byte, collected int, _ error) {
+	resp, err := e.srv.NodesList(ctx, &serverpb.NodesListRequest{})
 	if err != nil {
-		log.Errorf(ctx, "failed to collect goroutines for job %d: %+v", e.jobID, err.Error())
+		return nil, 0, errors.Wrapf(err, "failed to list nodes to collect goroutines for job %d", e.jobID)
+	}
+	var buf bytes.Buffer
+	for _, n := range resp.Nodes {
+		profile, err := e.srv.Profile(ctx, &serverpb.ProfileRequest{
+			NodeId:      fmt.Sprintf("%d", n.NodeID),
+			Type:        serverpb.ProfileRequest_GOROUTINE,
+			Labels:      true,
+			LabelFilter: fmt.Sprintf("%d", e.jobID),
+		})
+		if err != nil {
+			fmt.Fprintf(&buf, "Failed to collect goroutines for node %d: %v\n", n.NodeID, err)
+			continue
+		}
+		collected++
+		buf.Write(profile.Data)
+	}
+	return buf.Bytes(), collected, nil
+}
+
+// writeLabelledGoroutines persists the goroutines collected by
+// fetchLabelledGoroutines, returning the name of the file they were written
+// to.
+func (e *ExecutionDetailsBuilder) writeLabelledGoroutines(
+	ctx context.Context, data []byte,
+) (string, error) {
+	filename := fmt.Sprintf("%s.%s.txt", goroutinesArtifact, e.timestamp())
+	if err := e.WriteExecutionDetail(ctx, filename, data); err != nil {
+		return "", errors.Wrapf(err, "failed to write goroutines for job %d", e.jobID)
+	}
+	return filename, nil
+}
+
+// addGoroutinesPprof collects and persists the goroutines of every node in the
+// cluster in the binary pprof format, as a `goroutines.<node>.<timestamp>.pprof`
+// file per node, which can be opened by `go tool pprof` and pprof UIs. Unlike
+// the text format, the profile contains all the goroutines of the node, and
+// not only those labelled with the job.
+func (e *ExecutionDetailsBuilder) addGoroutinesPprof(ctx context.Context) {
+	resp, err := e.srv.NodesList(ctx, &serverpb.NodesListRequest{})
+	if err != nil {
+		log.Errorf(ctx, "failed to list nodes to collect goroutines for job %d: %+v", e.jobID, err.Error())
 		return
 	}
-	filename := fmt.Sprintf("goroutines.%s.txt", timeutil.Now().Format("20060102_150405.00"))
-	if err := e.WriteExecutionDetail(ctx, filename, resp.Data); err != nil {
-		log.Errorf(ctx, "failed to write goroutine for job %d: %+v", e.jobID, err.Error())
+	timestamp := e.timestamp()
+	for _, n := range resp.Nodes {
+		profile, err := e.srv.Profile(ctx, &serverpb.ProfileRequest{
+			NodeId: fmt.Sprintf("%d", n.NodeID),
+			Type:   serverpb.ProfileRequest_GOROUTINE,
+		})
+		if err != nil {
+			log.Errorf(ctx, "failed to collect goroutines of node %d for job %d: %+v",
+				n.NodeID, e.jobID, err.Error())
+			continue
+		}
+		filename := fmt.Sprintf("%s.%d.%s.pprof", goroutinesArtifact, n.NodeID, timestamp)
+		if err := e.WriteExecutionDetail(ctx, filename, profile.Data); err != nil {
+			log.Errorf(ctx, "failed to write goroutines of node %d for job %d: %+v",
+				n.NodeID, e.jobID, err.Error())
+		}
+	}
+}
+
+// latestGoroutinesReuseWindow is the period during which the most recently
+// collected goroutines of a job are returned by LatestJobGoroutines, instead
+// of collecting them again. This throttles repeated calls, e.g. by a dashboard
+// refreshing in a loop, since collecting goroutines fans out to every node.
+const latestGoroutinesReuseWindow = 10 * time.Second
+
+// LatestJobGoroutines implements the JobProfiler interface.
+func (p *planner) LatestJobGoroutines(ctx context.Context, jobID jobspb.JobID) ([]byte, error) {
+	execCfg := p.ExecCfg()
+	if _, err := execCfg.JobRegistry.LoadJob(ctx, jobID); err != nil {
+		return nil, err
+	}
+	e := MakeJobProfilerExecutionDetailsBuilder(execCfg.SQLStatusServer, execCfg.InternalDB, jobID)
+	files, err := e.ListExecutionDetailFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// File names sort by the time at which they were collected, so the last
+	// goroutines file is the most recent.
+	var filename string
+	var written time.Time
+	for _, f := range files {
+		name := strings.TrimPrefix(f.Name, profilerconstants.ExecutionDetailsChunkKeyPrefix)
+		if strings.HasPrefix(name, goroutinesArtifact+".") && strings.HasSuffix(name, ".txt") {
+			filename, written = name, f.Written
+		}
+	}
+	if filename == "" || timeutil.Since(written) >= latestGoroutinesReuseWindow {
+		// Collecting goroutines fans out to every node, like the collection of
+		// the execution details, so it counts against the same limit.
+		if err := runExecutionDetailCollection(ctx, execCfg, jobID, func(ctx context.Context) error {
+			data, collected, err := e.fetchLabelledGoroutines(ctx)
+			if err != nil {
+				return err
+			}
+			// Unlike the execution details, which note the nodes whose
+			// goroutines could not be collected, there is nothing to return if
+			// no node's could.
+			if collected == 0 {
+				return errors.Newf("no goroutines could be collected for job %d:\n%s", jobID, data)
+			}
+			filename, err = e.writeLabelledGoroutines(ctx, data)
+			return err
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return e.ReadExecutionDetail(ctx, filename)
+}
+
+// JobDistSQLPlanText implements the JobProfiler interface.
+func (p *planner) JobDistSQLPlanText(
+	ctx context.Context, jobID jobspb.JobID, index int,
+) (string, error) {
+	if index < 0 {
+		return "", pgerror.Newf(pgcode.InvalidParameterValue,
+			"DistSQL plan index must be non-negative, got %d", index)
+	}
+	execCfg := p.ExecCfg()
+	if _, err := execCfg.JobRegistry.LoadJob(ctx, jobID); err != nil {
+		return "", err
+	}
+	// The plans are stored under keys suffixed by the time at which they were
+	// stored, so they are iterated from the oldest to the most recent.
+	var planSpecs [][]byte
+	if err := execCfg.InternalDB.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		planSpecs = nil
+		infoStorage := jobs.InfoStorageForJob(txn, jobID)
+		return infoStorage.Iterate(ctx, profilerconstants.DSPPlanSpecInfoKeyPrefix,
+			func(infoKey string, value []byte) error {
+				planSpecs = append(planSpecs, value)
+				return nil
+			})
+	}); err != nil {
+		return "", err
+	}
+	if len(planSpecs) == 0 {
+		return "", pgerror.Newf(pgcode.UndefinedObject,
+			"job %d has not stored a DistSQL plan", jobID)
 	}
+	if index >= len(planSpecs) {
+		return "", pgerror.Newf(pgcode.InvalidParameterValue,
+			"DistSQL plan index %d is out of range, job %d has stored %d DistSQL plans",
+			index, jobID, len(planSpecs))
+	}
+
+	var planSpec execinfrapb.PhysicalPlanSpec
+	if err := protoutil.Unmarshal(planSpecs[len(planSpecs)-1-index], &planSpec); err != nil {
+		return "", err
+	}
+	diagram, err := execinfrapb.GeneratePlanDiagram(
+		fmt.Sprintf("job:%d", jobID), planSpec.FlowSpecs(), execinfrapb.DiagramFlags{})
+	if err != nil {
+		return "", err
+	}
+	return diagram.ToText(), nil
 }
 
-// addDistSQLDiagram generates and persists a `distsql.<timestamp>.html` file.
+// addDistSQLDiagram generates and persists a `distsql.<timestamp>.html` file,
+// along with a `distsql.<timestamp>.binpb` file if the job has stored a
+// structured representation of its plan. The run attempt of the job which
+// executed the plan is added to the names of the files, when it is known, so
+// that the plans of successive runs of the job can be compared.
 func (e *ExecutionDetailsBuilder) addDistSQLDiagram(ctx context.Context) {
+	timestamp := e.timestamp()
+	attempt, err := e.readDistSQLPlanAttempt(ctx)
+	if err != nil {
+		log.Errorf(ctx, "failed to read the run attempt of the DistSQL plan of job %d: %+v", e.jobID, err.Error())
+	}
+	e.addDistSQLPlanSpec(ctx, timestamp, attempt)
+
 	query := `SELECT plan_diagram FROM [SHOW JOB $1 WITH EXECUTION DETAILS]`
 	row, err := e.db.Executor().QueryRowEx(ctx, "profiler-bundler-add-diagram", nil, /* txn */
 		sessiondata.NoSessionDataOverride, query, e.jobID)
@@ -230,10 +1111,799 @@ func (e *ExecutionDetailsBuilder) addDistSQLDiagram(ctx context.Context) {
 	}
 	if row[0] != tree.DNull {
 		dspDiagramURL := string(tree.MustBeDString(row[0]))
-		filename := fmt.Sprintf("distsql.%s.html", timeutil.Now().Format("20060102_150405.00"))
+		filename := distSQLFilename(timestamp, attempt, "html")
 		if err := e.WriteExecutionDetail(ctx, filename,
 			[]byte(fmt.Sprintf(`<meta http-equiv="Refresh" content="0; url=%s">`, dspDiagramURL))); err != nil {
 			log.Errorf(ctx, "failed to write DistSQL diagram for job %d: %+v", e.jobID, err.Error())
 		}
 	}
 }
+
+// addDistSQLPlanSpec persists a `distsql.<timestamp>.binpb` file containing
+// the latest marshaled execinfrapb.PhysicalPlanSpec stored by the job, if any.
+func (e *ExecutionDetailsBuilder) addDistSQLPlanSpec(
+	ctx context.Context, timestamp string, attempt int,
+) {
+	planSpec, err := e.readDistSQLPlanSpec(ctx)
+	if err != nil {
+		log.Errorf(ctx, "failed to read DistSQL plan spec for job %d: %+v", e.jobID, err.Error())
+		return
+	}
+	if len(planSpec) == 0 {
+		return
+	}
+	filename := distSQLFilename(timestamp, attempt, "binpb")
+	if err := e.WriteExecutionDetail(ctx, filename, planSpec); err != nil {
+		log.Errorf(ctx, "failed to write DistSQL plan spec for job %d: %+v", e.jobID, err.Error())
+	}
+}
+
+// distSQLFilename returns the name of a file of the DistSQL plan of the job,
+// with the given extension. It includes the run attempt of the job which
+// executed the plan, unless it is unknown, i.e. 0.
+func distSQLFilename(timestamp string, attempt int, ext string) string {
+	if attempt == 0 {
+		return fmt.Sprintf("distsql.%s.%s", timestamp, ext)
+	}
+	return fmt.Sprintf("distsql.%s.attempt%d.%s", timestamp, attempt, ext)
+}
+
+// readDistSQLPlanAttempt returns the run attempt of the job during which it
+// stored its latest DistSQL plan. The first run of a job is attempt 1. It
+// returns 0 if the job hasn't stored a plan, or if the attempt wasn't recorded
+// along with the plan.
+func (e *ExecutionDetailsBuilder) readDistSQLPlanAttempt(ctx context.Context) (int, error) {
+	var attempt int
+	if err := e.db.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		attempt = 0
+		infoStorage := jobs.InfoStorageForJob(txn, e.jobID)
+		var planSpecKey string
+		if err := infoStorage.GetLast(ctx, profilerconstants.DSPPlanSpecInfoKeyPrefix,
+			func(infoKey string, _ []byte) error {
+				planSpecKey = infoKey
+				return nil
+			}); err != nil || planSpecKey == "" {
+			return err
+		}
+		// The attempt is keyed by the same timestamp as the plan.
+		attemptKey := profilerconstants.DSPAttemptInfoKeyPrefix +
+			strings.TrimPrefix(planSpecKey, profilerconstants.DSPPlanSpecInfoKeyPrefix)
+		value, ok, err := infoStorage.Get(ctx, attemptKey)
+		if err != nil || !ok {
+			return err
+		}
+		attempt, err = strconv.Atoi(string(value))
+		return err
+	}); err != nil {
+		return 0, err
+	}
+	return attempt, nil
+}
+
+// readDistSQLPlanSpec returns the latest marshaled
+// execinfrapb.PhysicalPlanSpec stored by the job, or nil if the job hasn't
+// stored one.
+func (e *ExecutionDetailsBuilder) readDistSQLPlanSpec(ctx context.Context) ([]byte, error) {
+	var planSpec []byte
+	if err := e.db.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		planSpec = nil
+		infoStorage := jobs.InfoStorageForJob(txn, e.jobID)
+		return infoStorage.GetLast(ctx, profilerconstants.DSPPlanSpecInfoKeyPrefix,
+			func(infoKey string, value []byte) error {
+				planSpec = value
+				return nil
+			})
+	}); err != nil {
+		return nil, err
+	}
+	return planSpec, nil
+}
+
+// addParticipatingNodes generates and persists a `nodes.<timestamp>.txt` file
+// listing every SQL instance which ran a flow of the latest DistSQL plan
+// stored by the job, along with its addresses and its current liveness. This
+// is the index of the per-node artifacts expected in the execution details:
+// an instance of the plan which is now down is listed as such, since its
+// per-node artifacts are likely to be missing. Nothing is written if the job
+// hasn't stored a plan.
+//
+// The liveness of KV nodes is only known to the system tenant, so the
+// instances of a secondary tenant are only reported as live or not found.
+func (e *ExecutionDetailsBuilder) addParticipatingNodes(ctx context.Context, forSystemTenant bool) {
+	planSpecBytes, err := e.readDistSQLPlanSpec(ctx)
+	if err != nil {
+		log.Errorf(ctx, "failed to read DistSQL plan spec for job %d: %+v", e.jobID, err.Error())
+		return
+	}
+	var planSpec execinfrapb.PhysicalPlanSpec
+	if err := protoutil.Unmarshal(planSpecBytes, &planSpec); err != nil {
+		log.Errorf(ctx, "failed to unmarshal DistSQL plan spec for job %d: %+v", e.jobID, err.Error())
+		return
+	}
+	if len(planSpec.Flows) == 0 {
+		return
+	}
+
+	// The nodes which are listed are those which are known to the cluster,
+	// their addresses are unknown otherwise.
+	nodes := make(map[int32]serverpb.NodeDetails)
+	if resp, err := e.srv.NodesList(ctx, &serverpb.NodesListRequest{}); err != nil {
+		log.Errorf(ctx, "failed to list nodes for job %d: %+v", e.jobID, err.Error())
+	} else {
+		for _, n := range resp.Nodes {
+			nodes[n.NodeID] = n
+		}
+	}
+	var liveness map[roachpb.NodeID]livenesspb.NodeLivenessStatus
+	if forSystemTenant {
+		if resp, err := e.srv.NodesUI(ctx, &serverpb.NodesRequest{}); err != nil {
+			log.Errorf(ctx, "failed to read node liveness for job %d: %+v", e.jobID, err.Error())
+		} else {
+			liveness = resp.LivenessByNodeID
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "nodes which ran a flow of the DistSQL plan of job %d:\n", e.jobID)
+	for _, flow := range planSpec.Flows {
+		id := int32(flow.SQLInstanceID)
+		fmt.Fprintf(&buf, "n%d", id)
+		n, ok := nodes[id]
+		if ok {
+			fmt.Fprintf(&buf, "\taddress=%s\tsql_address=%s", n.Address.String(), n.SQLAddress.String())
+		} else {
+			buf.WriteString("\taddress=unknown")
+		}
+		status, up := "NOT FOUND", ok
+		if ok {
+			status = "LIVE"
+		}
+		if liveness != nil {
+			// Nodes without liveness information have the UNKNOWN status.
+			l := liveness[roachpb.NodeID(id)]
+			status = strings.TrimPrefix(l.String(), "NODE_STATUS_")
+			switch l {
+			case livenesspb.NodeLivenessStatus_LIVE, livenesspb.NodeLivenessStatus_DECOMMISSIONING,
+				livenesspb.NodeLivenessStatus_DRAINING:
+				up = true
+			default:
+				up = false
+			}
+		}
+		fmt.Fprintf(&buf, "\tliveness=%s", status)
+		if !up {
+			buf.WriteString("\t(node is down; its per-node artifacts may be missing)")
+		}
+		buf.WriteByte('\n')
+	}
+	filename := fmt.Sprintf("%s.%s.txt", nodesArtifact, e.timestamp())
+	if err := e.WriteExecutionDetail(ctx, filename, buf.Bytes()); err != nil {
+		log.Errorf(ctx, "failed to write participating nodes for job %d: %+v", e.jobID, err.Error())
+	}
+}
+
+// addFlowStats generates and persists a `flow_stats.<timestamp>.txt` file
+// summarizing the rows processed, bytes read and sent, and memory used by each
+// processor of the job's DistSQL flows, along with the memory used by each
+// flow, from the latest execution statistics stored by the job. Processors are
+// listed by decreasing execution time, so that the bottleneck of the flows is
+// listed first. If the job's flows haven't started, or haven't collected
+// statistics, the file explains that the statistics are unavailable.
+func (e *ExecutionDetailsBuilder) addFlowStats(ctx context.Context) {
+	var statsBytes []byte
+	if err := e.db.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		statsBytes = nil
+		infoStorage := jobs.InfoStorageForJob(txn, e.jobID)
+		return infoStorage.GetLast(ctx, profilerconstants.DSPFlowStatsInfoKeyPrefix,
+			func(infoKey string, value []byte) error {
+				statsBytes = value
+				return nil
+			})
+	}); err != nil {
+		log.Errorf(ctx, "failed to read flow stats for job %d: %+v", e.jobID, err.Error())
+		return
+	}
+	var statsList execinfrapb.ComponentStatsList
+	if err := protoutil.Unmarshal(statsBytes, &statsList); err != nil {
+		log.Errorf(ctx, "failed to unmarshal flow stats for job %d: %+v", e.jobID, err.Error())
+		return
+	}
+
+	var buf bytes.Buffer
+	writeFlowStats(&buf, e.jobID, statsList.Stats)
+	filename := fmt.Sprintf("%s.%s.txt", flowStatsArtifact, e.timestamp())
+	if err := e.WriteExecutionDetail(ctx, filename, buf.Bytes()); err != nil {
+		log.Errorf(ctx, "failed to write flow stats for job %d: %+v", e.jobID, err.Error())
+	}
+}
+
+// writeFlowStats writes the summary of the given execution statistics of the
+// job's DistSQL flows to buf, see addFlowStats.
+func writeFlowStats(buf *bytes.Buffer, jobID jobspb.JobID, stats []execinfrapb.ComponentStats) {
+	var processors, flows []execinfrapb.ComponentStats
+	for _, s := range stats {
+		switch s.Component.Type {
+		case execinfrapb.ComponentID_PROCESSOR:
+			processors = append(processors, s)
+		case execinfrapb.ComponentID_FLOW:
+			flows = append(flows, s)
+		}
+	}
+	if len(processors) == 0 && len(flows) == 0 {
+		fmt.Fprintf(buf, "flow statistics are unavailable for job %d: "+
+			"its DistSQL flows haven't started or haven't collected execution statistics\n", jobID)
+		return
+	}
+
+	// Statistics which weren't collected are written as "-".
+	fmtUint := func(v optional.Uint, f func(uint64) string) string {
+		if !v.HasValue() {
+			return "-"
+		}
+		return f(v.Value())
+	}
+	count := func(v uint64) string { return fmt.Sprintf("%d", v) }
+	size := func(v uint64) string { return humanize.IBytes(v) }
+
+	sort.SliceStable(processors, func(i, j int) bool {
+		return processors[i].Exec.ExecTime.Value() > processors[j].Exec.ExecTime.Value()
+	})
+	fmt.Fprintf(buf, "processors of the DistSQL flows of job %d, by decreasing execution time:\n", jobID)
+	for _, p := range processors {
+		// Processors read from KV, or from the network when their input is
+		// on another node.
+		bytesRead := p.KV.BytesRead
+		bytesRead.MaybeAdd(p.NetRx.BytesReceived)
+		execTime := "-"
+		if p.Exec.ExecTime.HasValue() {
+			execTime = p.Exec.ExecTime.Value().String()
+		}
+		fmt.Fprintf(buf, "p%d\tn%d\texec_time=%s\trows=%s\tbytes_read=%s\tbytes_sent=%s\tmax_memory=%s\n",
+			p.Component.ID, p.Component.SQLInstanceID, execTime,
+			fmtUint(p.Output.NumTuples, count), fmtUint(bytesRead, size),
+			fmtUint(p.NetTx.BytesSent, size), fmtUint(p.Exec.MaxAllocatedMem, size))
+	}
+	if len(flows) > 0 {
+		buf.WriteString("flows:\n")
+		for _, f := range flows {
+			fmt.Fprintf(buf, "n%d\tmax_memory=%s\tmax_disk=%s\n", f.Component.SQLInstanceID,
+				fmtUint(f.FlowStats.MaxMemUsage, size), fmtUint(f.FlowStats.MaxDiskUsage, size))
+		}
+	}
+}
+
+// addRetryHistory generates and persists a `retries.<timestamp>.txt` file
+// summarizing the number of times the job has been restarted, along with the
+// retriable execution errors it has encountered and when they occurred.
+func (e *ExecutionDetailsBuilder) addRetryHistory(ctx context.Context) {
+	query := `SELECT status, created, num_runs, last_run, execution_errors FROM crdb_internal.jobs WHERE job_id = $1`
+	row, err := e.db.Executor().QueryRowEx(ctx, "profiler-bundler-add-retries", nil, /* txn */
+		sessiondata.NoSessionDataOverride, query, e.jobID)
+	if err != nil {
+		log.Errorf(ctx, "failed to read retry history for job %d: %+v", e.jobID, err.Error())
+		return
+	}
+	if row == nil {
+		log.Errorf(ctx, "failed to read retry history for job %d: job not found", e.jobID)
+		return
+	}
+
+	asString := func(d tree.Datum) string {
+		return tree.AsStringWithFlags(d, tree.FmtBareStrings)
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "status: %s\n", asString(row[0]))
+	fmt.Fprintf(&buf, "created: %s\n", asString(row[1]))
+
+	// The first run of a job is not a retry.
+	var retries int64
+	if row[2] != tree.DNull {
+		retries = int64(tree.MustBeDInt(row[2])) - 1
+	}
+	var executionErrors tree.Datums
+	if row[4] != tree.DNull {
+		executionErrors = tree.MustBeDArray(row[4]).Array
+	}
+	if retries <= 0 && len(executionErrors) == 0 {
+		buf.WriteString("no retries\n")
+	} else {
+		fmt.Fprintf(&buf, "retries: %d\n", retries)
+		fmt.Fprintf(&buf, "last run: %s\n", asString(row[3]))
+		fmt.Fprintf(&buf, "retriable execution errors (%d, oldest first):\n", len(executionErrors))
+		for _, executionErr := range executionErrors {
+			fmt.Fprintf(&buf, "  %s\n", asString(executionErr))
+		}
+	}
+
+	filename := fmt.Sprintf("retries.%s.txt", e.timestamp())
+	if err := e.WriteExecutionDetail(ctx, filename, buf.Bytes()); err != nil {
+		log.Errorf(ctx, "failed to write retry history for job %d: %+v", e.jobID, err.Error())
+	}
+}
+
+// maxContentionEventsPerDetail is the maximum number of contention events
+// written to a contention execution detail.
+const maxContentionEventsPerDetail = 100
+
+// addContentionEvents generates and persists a `contention.<timestamp>.txt`
+// file listing the most recent KV contention events, as recorded by the
+// contention event store, on the keys of the given descriptors, i.e. those
+// which the job operates on. The transactions of a job run through the
+// internal executor and aren't fingerprinted per job, so contention events are
+// attributed to the job by the table of the contending key. If no contention
+// events are attributed to the job, the file explains why it is empty.
+func (e *ExecutionDetailsBuilder) addContentionEvents(
+	ctx context.Context, codec keys.SQLCodec, descIDs []descpb.ID,
+) {
+	var buf bytes.Buffer
+	if len(descIDs) == 0 {
+		fmt.Fprintf(&buf, "no contention events: job %d does not record the descriptors it operates on\n", e.jobID)
+	} else if err := e.writeContentionEvents(ctx, &buf, codec, descIDs); err != nil {
+		log.Errorf(ctx, "failed to read contention events for job %d: %+v", e.jobID, err.Error())
+		return
+	}
+	filename := fmt.Sprintf("%s.%s.txt", contentionArtifact, e.timestamp())
+	if err := e.WriteExecutionDetail(ctx, filename, buf.Bytes()); err != nil {
+		log.Errorf(ctx, "failed to write contention events for job %d: %+v", e.jobID, err.Error())
+	}
+}
+
+// writeContentionEvents writes the most recent contention events on the keys
+// of the given descriptors to buf, most recent first. The events are filtered
+// by the table spans of the descriptors in the query, so that only the events
+// which are written are read from the contention event store.
+func (e *ExecutionDetailsBuilder) writeContentionEvents(
+	ctx context.Context, buf *bytes.Buffer, codec keys.SQLCodec, descIDs []descpb.ID,
+) error {
+	var preds strings.Builder
+	args := make([]interface{}, 0, 2*len(descIDs)+1)
+	for i, id := range descIDs {
+		if i > 0 {
+			preds.WriteString(" OR ")
+		}
+		span := codec.TableSpan(uint32(id))
+		fmt.Fprintf(&preds, "(contending_key >= $%d AND contending_key < $%d)", len(args)+1, len(args)+2)
+		args = append(args, []byte(span.Key), []byte(span.EndKey))
+	}
+	args = append(args, maxContentionEventsPerDetail)
+	query := fmt.Sprintf(`SELECT collection_ts, contention_duration, contending_pretty_key,
+database_name, schema_name, table_name, index_name,
+encode(waiting_txn_fingerprint_id, 'hex'), encode(blocking_txn_fingerprint_id, 'hex')
+FROM crdb_internal.transaction_contention_events
+WHERE %s
+ORDER BY collection_ts DESC
+LIMIT $%d`, preds.String(), len(args))
+	rows, err := e.db.Executor().QueryBufferedEx(ctx, "profiler-bundler-add-contention", nil, /* txn */
+		sessiondata.NodeUserSessionDataOverride, query, args...)
+	if err != nil {
+		return err
+	}
+
+	if len(rows) == 0 {
+		fmt.Fprintf(buf, "no contention events on the descriptors %v of job %d were found; "+
+			"the contention event store only retains recent events\n", descIDs, e.jobID)
+		return nil
+	}
+	asString := func(d tree.Datum) string {
+		return tree.AsStringWithFlags(d, tree.FmtBareStrings)
+	}
+	fmt.Fprintf(buf, "contention events on the descriptors %v of job %d, most recent first:\n",
+		descIDs, e.jobID)
+	for _, row := range rows {
+		fmt.Fprintf(buf, "%s: waited %s on key %s (table %s.%s.%s, index %s), "+
+			"waiting txn fingerprint %s, blocking txn fingerprint %s\n",
+			asString(row[0]), asString(row[1]), asString(row[2]),
+			asString(row[3]), asString(row[4]), asString(row[5]), asString(row[6]),
+			asString(row[7]), asString(row[8]))
+	}
+	return nil
+}
+
+// addSpanConfigs generates and persists a `span_configs.<timestamp>.txt` file
+// listing the span configs which apply to the spans of the given descriptors,
+// i.e. those which the job operates on, as read from the span config
+// subsystem. A job which doesn't record the descriptors it operates on, such
+// as a cluster-wide job, has no well defined target spans, so the file
+// explains why it is empty rather than listing every span config.
+func (e *ExecutionDetailsBuilder) addSpanConfigs(
+	ctx context.Context, accessor spanconfig.KVAccessor, codec keys.SQLCodec, descIDs []descpb.ID,
+) {
+	var buf bytes.Buffer
+	if len(descIDs) == 0 {
+		fmt.Fprintf(&buf, "no span configs: job %d does not record the descriptors it operates on, "+
+			"so it has no target spans\n", e.jobID)
+	} else if err := e.writeSpanConfigs(ctx, &buf, accessor, codec, descIDs); err != nil {
+		log.Errorf(ctx, "failed to read span configs for job %d: %+v", e.jobID, err.Error())
+		return
+	}
+	filename := fmt.Sprintf("%s.%s.txt", spanConfigsArtifact, e.timestamp())
+	if err := e.WriteExecutionDetail(ctx, filename, buf.Bytes()); err != nil {
+		log.Errorf(ctx, "failed to write span configs for job %d: %+v", e.jobID, err.Error())
+	}
+}
+
+// writeSpanConfigs writes the span configs which apply to the table spans of
+// the given descriptors to buf.
+func (e *ExecutionDetailsBuilder) writeSpanConfigs(
+	ctx context.Context,
+	buf *bytes.Buffer,
+	accessor spanconfig.KVAccessor,
+	codec keys.SQLCodec,
+	descIDs []descpb.ID,
+) error {
+	targets := make([]spanconfig.Target, 0, len(descIDs))
+	for _, id := range descIDs {
+		targets = append(targets, spanconfig.MakeTargetFromSpan(codec.TableSpan(uint32(id))))
+	}
+	records, err := accessor.GetSpanConfigRecords(ctx, targets)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		fmt.Fprintf(buf, "no span configs apply to the descriptors %v of job %d; "+
+			"they may not have been reconciled yet\n", descIDs, e.jobID)
+		return nil
+	}
+	fmt.Fprintf(buf, "span configs of the descriptors %v of job %d:\n", descIDs, e.jobID)
+	for _, record := range records {
+		target, conf := record.GetTarget(), record.GetConfig()
+		fmt.Fprintf(buf, "%s\n", target.String())
+		fmt.Fprintf(buf, "\tgc.ttlseconds = %d\n", conf.GCPolicy.TTLSeconds)
+		fmt.Fprintf(buf, "\tnum_replicas = %d, num_voters = %d\n", conf.NumReplicas, conf.GetNumVoters())
+		fmt.Fprintf(buf, "\trange_min_bytes = %d, range_max_bytes = %d\n", conf.RangeMinBytes, conf.RangeMaxBytes)
+		fmt.Fprintf(buf, "\t%s\n", conf.String())
+	}
+	return nil
+}
+
+// maxTxnStatsPerDetail is the maximum number of fingerprints written to a
+// transaction stats execution detail.
+const maxTxnStatsPerDetail = 100
+
+// addTxnStats generates and persists a `txn_stats.<timestamp>.txt` file
+// summarizing the persisted SQL statistics of the statement the job originated
+// from, i.e. the number of executions, retries, rows and service latency of
+// the statement fingerprint in each of the transaction fingerprints and
+// applications it ran in. This ties the job back to the cost of its statement
+// in the SQL layer. A job which didn't originate from a SQL statement, such as
+// an internal job, has no fingerprint, so the file explains why it is empty.
+func (e *ExecutionDetailsBuilder) addTxnStats(ctx context.Context, payload *jobspb.Payload) {
+	var buf bytes.Buffer
+	if fingerprints := jobStatementFingerprints(payload); len(fingerprints) == 0 {
+		fmt.Fprintf(&buf, "no fingerprint stats: job %d did not originate from a tracked SQL statement\n", e.jobID)
+	} else if err := e.writeTxnStats(ctx, &buf, fingerprints); err != nil {
+		log.Errorf(ctx, "failed to read fingerprint stats for job %d: %+v", e.jobID, err.Error())
+		return
+	}
+	filename := fmt.Sprintf("%s.%s.txt", txnStatsArtifact, e.timestamp())
+	if err := e.WriteExecutionDetail(ctx, filename, buf.Bytes()); err != nil {
+		log.Errorf(ctx, "failed to write fingerprint stats for job %d: %+v", e.jobID, err.Error())
+	}
+}
+
+// jobStatementFingerprints returns the fingerprints, i.e. the statements with
+// their constants hidden, of the statements the job originated from. If the
+// job doesn't record its statements, its description is assumed to be the
+// statement. Statements which can't be parsed, such as the descriptions of
+// internal jobs, have no fingerprint.
+func jobStatementFingerprints(payload *jobspb.Payload) []string {
+	statements := payload.Statement
+	if len(statements) == 0 && payload.Description != "" {
+		statements = []string{payload.Description}
+	}
+	var fingerprints []string
+	for _, sql := range statements {
+		stmt, err := parser.ParseOne(sql)
+		if err != nil {
+			continue
+		}
+		fingerprints = append(fingerprints, formatStatementHideConstants(stmt.AST))
+	}
+	return fingerprints
+}
+
+// writeTxnStats writes the persisted statistics of the given statement
+// fingerprints to buf, aggregated over the aggregation intervals, by
+// decreasing number of executions.
+func (e *ExecutionDetailsBuilder) writeTxnStats(
+	ctx context.Context, buf *bytes.Buffer, fingerprints []string,
+) error {
+	queries := tree.NewDArray(types.String)
+	for _, f := range fingerprints {
+		if err := queries.Append(tree.NewDString(f)); err != nil {
+			return err
+		}
+	}
+	query := `WITH stats AS (
+  SELECT fingerprint_id, transaction_fingerprint_id, app_name, metadata->>'query' AS query,
+         (statistics->'statistics'->>'cnt')::FLOAT8 AS cnt,
+         (statistics->'statistics'->>'maxRetries')::INT8 AS max_retries,
+         (statistics->'statistics'->'numRows'->>'mean')::FLOAT8 AS num_rows,
+         (statistics->'statistics'->'svcLat'->>'mean')::FLOAT8 AS svc_lat
+    FROM crdb_internal.statement_statistics_persisted
+   WHERE metadata->>'query' = ANY ($1)
+)
+SELECT encode(fingerprint_id, 'hex'), encode(transaction_fingerprint_id, 'hex'), app_name, query,
+       sum(cnt)::INT8 AS executions, max(max_retries),
+       sum(cnt * num_rows) / sum(cnt), sum(cnt * svc_lat) / sum(cnt)
+  FROM stats
+ WHERE cnt > 0
+ GROUP BY fingerprint_id, transaction_fingerprint_id, app_name, query
+ ORDER BY executions DESC
+ LIMIT $2`
+	it, err := e.db.Executor().QueryIteratorEx(ctx, "profiler-bundler-add-txn-stats", nil, /* txn */
+		sessiondata.NodeUserSessionDataOverride, query, queries, maxTxnStatsPerDetail)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = it.Close() }()
+
+	asString := func(d tree.Datum) string {
+		return tree.AsStringWithFlags(d, tree.FmtBareStrings)
+	}
+	var found bool
+	for {
+		ok, err := it.Next(ctx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		row := it.Cur()
+		if !found {
+			fmt.Fprintf(buf, "fingerprint stats of the statements of job %d, by decreasing executions:\n", e.jobID)
+			found = true
+		}
+		svcLat := time.Duration(float64(tree.MustBeDFloat(row[7])) * float64(time.Second))
+		fmt.Fprintf(buf, "statement fingerprint %s in txn fingerprint %s (app %q): %s\n",
+			asString(row[0]), asString(row[1]), asString(row[2]), asString(row[3]))
+		fmt.Fprintf(buf, "\texecutions: %s, max retries: %s, mean rows: %.1f, mean service latency: %s\n",
+			asString(row[4]), asString(row[5]), float64(tree.MustBeDFloat(row[6])), svcLat)
+	}
+	if !found {
+		fmt.Fprintf(buf, "no fingerprint stats of the statements of job %d were found; "+
+			"statement statistics are only persisted periodically, and are eventually deleted\n", e.jobID)
+	}
+	return nil
+}
+
+// jobAdmissionQueue is an admission control work queue, along with the
+// priority at which the work of a job is admitted through it.
+type jobAdmissionQueue struct {
+	queue    string
+	priority admissionpb.WorkPriority
+}
+
+// jobTypesWithAdmissionControl are the types of jobs whose work is subject to
+// admission control, along with the queues through which it is admitted. The
+// SSTs ingested by IMPORT, RESTORE and index backfills are admitted through the
+// store work queues, whereas the export requests of BACKUP and the event
+// processing of changefeeds are admitted through the elastic CPU work queue.
+// All of them are admitted at BulkNormalPri.
+var jobTypesWithAdmissionControl = map[jobspb.Type][]jobAdmissionQueue{
+	jobspb.TypeBackup:          {{queue: "elastic-cpu", priority: admissionpb.BulkNormalPri}},
+	jobspb.TypeRestore:         {{queue: "kv-stores", priority: admissionpb.BulkNormalPri}},
+	jobspb.TypeImport:          {{queue: "kv-stores", priority: admissionpb.BulkNormalPri}},
+	jobspb.TypeChangefeed:      {{queue: "elastic-cpu", priority: admissionpb.BulkNormalPri}},
+	jobspb.TypeSchemaChange:    {{queue: "kv-stores", priority: admissionpb.BulkNormalPri}},
+	jobspb.TypeNewSchemaChange: {{queue: "kv-stores", priority: admissionpb.BulkNormalPri}},
+}
+
+// addAdmissionQueueing generates and persists an `admission.<timestamp>.txt`
+// file summarizing, for each node, how much work was queued in the admission
+// control queues through which the work of the job is admitted, and how long
+// it waited, as read from the admission control metrics of the node. This
+// tells a job which is throttled by admission control apart from one which is
+// stuck. The metrics of a queue are aggregated over all the work admitted at
+// the same priority, they aren't attributed to individual jobs, which the file
+// points out. If admission control isn't engaged for the job, because the work
+// of its type isn't subject to admission control, or because the metrics of
+// the KV nodes aren't visible to a secondary tenant, the file explains so.
+func (e *ExecutionDetailsBuilder) addAdmissionQueueing(
+	ctx context.Context, typ jobspb.Type, forSystemTenant bool,
+) {
+	var buf bytes.Buffer
+	queues, ok := jobTypesWithAdmissionControl[typ]
+	if !ok {
+		fmt.Fprintf(&buf, "admission control is not engaged for job %d: "+
+			"the work of %s jobs is not subject to admission control\n", e.jobID, typ)
+	} else if !forSystemTenant {
+		fmt.Fprintf(&buf, "admission control queueing is unavailable for job %d: "+
+			"the work of the job is admitted by the KV nodes, whose metrics are only visible "+
+			"to the system tenant\n", e.jobID)
+	} else {
+		resp, err := e.srv.NodesUI(ctx, &serverpb.NodesRequest{})
+		if err != nil {
+			log.Errorf(ctx, "failed to read admission control metrics for job %d: %+v", e.jobID, err.Error())
+			return
+		}
+		nodeMetrics := make(map[roachpb.NodeID]map[string]float64, len(resp.Nodes))
+		for _, n := range resp.Nodes {
+			nodeMetrics[n.Desc.NodeID] = n.Metrics
+		}
+		writeAdmissionQueueing(&buf, e.jobID, typ, queues, nodeMetrics)
+	}
+	filename := fmt.Sprintf("%s.%s.txt", admissionArtifact, e.timestamp())
+	if err := e.WriteExecutionDetail(ctx, filename, buf.Bytes()); err != nil {
+		log.Errorf(ctx, "failed to write admission control queueing for job %d: %+v", e.jobID, err.Error())
+	}
+}
+
+// writeAdmissionQueueing writes the summary of the admission control metrics
+// of the given queues, as read from the metrics of each node, to buf, see
+// addAdmissionQueueing.
+func writeAdmissionQueueing(
+	buf *bytes.Buffer,
+	jobID jobspb.JobID,
+	typ jobspb.Type,
+	queues []jobAdmissionQueue,
+	nodeMetrics map[roachpb.NodeID]map[string]float64,
+) {
+	nodeIDs := make([]roachpb.NodeID, 0, len(nodeMetrics))
+	for id := range nodeMetrics {
+		nodeIDs = append(nodeIDs, id)
+	}
+	sort.Slice(nodeIDs, func(i, j int) bool { return nodeIDs[i] < nodeIDs[j] })
+
+	fmt.Fprintf(buf, "admission control queueing of the work of job %d (%s), by queue and priority.\n", jobID, typ)
+	buf.WriteString("the metrics include all of the work admitted at the same priority on each node, " +
+		"not only that of the job.\n")
+	var waiting bool
+	for _, q := range queues {
+		fmt.Fprintf(buf, "%s at %s:\n", q.queue, q.priority)
+		// The metrics of each queue and priority are registered with names such
+		// as admission.admitted.kv-stores.bulk-normal-pri.
+		name := func(metric string) string {
+			return fmt.Sprintf("admission.%s.%s.%s", metric, q.queue, q.priority)
+		}
+		var found bool
+		for _, id := range nodeIDs {
+			m := nodeMetrics[id]
+			requested, ok := m[name("requested")]
+			if !ok {
+				continue
+			}
+			found = true
+			queued := int64(m[name("wait_queue_length")])
+			waiting = waiting || queued > 0
+			fmt.Fprintf(buf, "n%d\trequested=%d\tadmitted=%d\terrored=%d\twaiting=%d\t"+
+				"total_wait=%s\tmean_wait=%s\tp99_wait=%s\n",
+				id, int64(requested), int64(m[name("admitted")]), int64(m[name("errored")]), queued,
+				time.Duration(m[name("wait_durations")+"-sum"]),
+				time.Duration(m[name("wait_durations")+"-avg"]),
+				time.Duration(m[name("wait_durations")+"-p99"]))
+		}
+		if !found {
+			buf.WriteString("no admission control metrics were found for this queue and priority\n")
+		}
+	}
+	if waiting {
+		buf.WriteString("work is currently waiting for admission, so the job may be throttled by admission control\n")
+	} else {
+		buf.WriteString("no work is currently waiting for admission\n")
+	}
+}
+
+// storageLevels is the number of levels of the LSM of a store.
+const storageLevels = 7
+
+// addStorageStats generates and persists a `storage_stats.<node>.<timestamp>.txt`
+// file for each node with a store holding a replica of the descriptors the job
+// operates on, summarizing the LSM of each of those stores: the size and
+// compaction score of each level, the files and sublevels of L0, and the
+// compaction backlog, as read from the storage engine metrics of the store.
+// An inverted LSM, or a growing compaction backlog, on the stores of a job
+// tells a job which is slowed down by the storage engine apart from one which
+// is slow on its own. Nodes without a replica of the job's data are skipped,
+// so nothing is written for a job which doesn't record its descriptors. The
+// metrics of the stores are only visible to the system tenant, so nothing is
+// written for the jobs of a secondary tenant either.
+func (e *ExecutionDetailsBuilder) addStorageStats(
+	ctx context.Context,
+	rangeDescs rangedesc.IteratorFactory,
+	codec keys.SQLCodec,
+	descIDs []descpb.ID,
+	forSystemTenant bool,
+) {
+	if !forSystemTenant || len(descIDs) == 0 {
+		return
+	}
+	stores, err := storesOfDescriptors(ctx, rangeDescs, codec, descIDs)
+	if err != nil {
+		log.Errorf(ctx, "failed to find the stores of the descriptors of job %d: %+v", e.jobID, err.Error())
+		return
+	}
+	if len(stores) == 0 {
+		return
+	}
+	resp, err := e.srv.NodesUI(ctx, &serverpb.NodesRequest{})
+	if err != nil {
+		log.Errorf(ctx, "failed to read storage engine metrics for job %d: %+v", e.jobID, err.Error())
+		return
+	}
+	timestamp := e.timestamp()
+	for _, n := range resp.Nodes {
+		storeMetrics := make(map[roachpb.StoreID]map[string]float64)
+		for _, s := range n.StoreStatuses {
+			if _, ok := stores[s.Desc.StoreID]; ok {
+				storeMetrics[s.Desc.StoreID] = s.Metrics
+			}
+		}
+		if len(storeMetrics) == 0 {
+			continue
+		}
+		var buf bytes.Buffer
+		writeStorageStats(&buf, e.jobID, n.Desc.NodeID, descIDs, storeMetrics)
+		filename := fmt.Sprintf("%s.%d.%s.txt", storageStatsArtifact, n.Desc.NodeID, timestamp)
+		if err := e.WriteExecutionDetail(ctx, filename, buf.Bytes()); err != nil {
+			log.Errorf(ctx, "failed to write storage engine stats of node %d for job %d: %+v",
+				n.Desc.NodeID, e.jobID, err.Error())
+		}
+	}
+}
+
+// storesOfDescriptors returns the stores holding a replica of a range which
+// overlaps the span of any of the given descriptors.
+func storesOfDescriptors(
+	ctx context.Context, rangeDescs rangedesc.IteratorFactory, codec keys.SQLCodec, descIDs []descpb.ID,
+) (map[roachpb.StoreID]struct{}, error) {
+	stores := make(map[roachpb.StoreID]struct{})
+	for _, id := range descIDs {
+		it, err := rangeDescs.NewIterator(ctx, codec.TableSpan(uint32(id)))
+		if err != nil {
+			return nil, err
+		}
+		for ; it.Valid(); it.Next() {
+			desc := it.CurRangeDescriptor()
+			for _, r := range desc.Replicas().Descriptors() {
+				stores[r.StoreID] = struct{}{}
+			}
+		}
+	}
+	return stores, nil
+}
+
+// writeStorageStats writes the summary of the storage engine metrics of the
+// given stores of a node to buf, see addStorageStats.
+func writeStorageStats(
+	buf *bytes.Buffer,
+	jobID jobspb.JobID,
+	nodeID roachpb.NodeID,
+	descIDs []descpb.ID,
+	storeMetrics map[roachpb.StoreID]map[string]float64,
+) {
+	storeIDs := make([]roachpb.StoreID, 0, len(storeMetrics))
+	for id := range storeMetrics {
+		storeIDs = append(storeIDs, id)
+	}
+	sort.Slice(storeIDs, func(i, j int) bool { return storeIDs[i] < storeIDs[j] })
+
+	fmt.Fprintf(buf, "storage engine stats of the stores of n%d holding the descriptors %v of job %d.\n",
+		nodeID, descIDs, jobID)
+	buf.WriteString("the metrics include all of the data of each store, not only that of the job.\n")
+	size := func(v float64) string { return humanize.IBytes(uint64(v)) }
+	for _, id := range storeIDs {
+		m := storeMetrics[id]
+		fmt.Fprintf(buf, "s%d:\n", id)
+		// The metrics of each level are registered with names such as
+		// storage.l0-level-size.
+		for level := 0; level < storageLevels; level++ {
+			name := func(metric string) string { return fmt.Sprintf("storage.l%d-%s", level, metric) }
+			fmt.Fprintf(buf, "L%d\tsize=%s\tscore=%.2f", level, size(m[name("level-size")]), m[name("level-score")])
+			if level == 0 {
+				fmt.Fprintf(buf, "\tfiles=%d\tsublevels=%d",
+					int64(m[name("num-files")]), int64(m[name("sublevels")]))
+			}
+			buf.WriteByte('\n')
+		}
+		fmt.Fprintf(buf, "read_amplification=%d\tsstables=%d\n",
+			int64(m["rocksdb.read-amplification"]), int64(m["rocksdb.num-sstables"]))
+		fmt.Fprintf(buf, "compactions=%d\tcompacted_bytes_read=%s\tcompacted_bytes_written=%s\t"+
+			"estimated_pending_compaction=%s\n",
+			int64(m["rocksdb.compactions"]), size(m["rocksdb.compacted-bytes-read"]),
+			size(m["rocksdb.compacted-bytes-written"]), size(m["rocksdb.estimated-pending-compaction"]))
+		fmt.Fprintf(buf, "write_stalls=%d\twrite_stall_duration=%s\n",
+			int64(m["storage.write-stalls"]), time.Duration(m["storage.write-stall-nanos"]))
+	}
+}
@@ -13,12 +13,17 @@ package sql_test
 import (
 	"bytes"
 	"context"
+	gosql "database/sql"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
+	neturl "net/url"
+	"regexp"
 	"runtime/pprof"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -26,20 +31,38 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/jobs"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobsprofiler"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobsprofiler/profilerconstants"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/clusterunique"
+	"github.com/cockroachdb/cockroach/pkg/sql/contentionpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/sql/isql"
 	"github.com/cockroachdb/cockroach/pkg/sql/physicalplan"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlstats/persistedsqlstats"
 	"github.com/cockroachdb/cockroach/pkg/sql/tests"
+	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/jobutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/testcluster"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
 	"github.com/cockroachdb/cockroach/pkg/util/httputil"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/optional"
 	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/uint128"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/cockroachdb/errors"
+	"github.com/google/pprof/profile"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 )
 
@@ -86,6 +109,251 @@ func TestReadWriteProfilerExecutionDetails(t *testing.T) {
 		runner.Exec(t, `SELECT crdb_internal.request_job_execution_details($1)`, importJobID)
 		distSQLDiagram := checkExecutionDetails(t, s, jobspb.JobID(importJobID), "distsql")
 		require.Regexp(t, "<meta http-equiv=\"Refresh\" content=\"0\\; url=https://cockroachdb\\.github\\.io/distsqlplan/decode.html.*>", string(distSQLDiagram))
+
+		// Requesting a range of the execution detail should only return the
+		// bytes in that range.
+		resp, partial := getExecutionDetailsRange(t, s, jobspb.JobID(importJobID), "distsql", "bytes=10-19")
+		require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+		require.Equal(t, fmt.Sprintf("bytes 10-19/%d", len(distSQLDiagram)), resp.Header.Get("Content-Range"))
+		require.Equal(t, distSQLDiagram[10:20], partial)
+
+		// Resuming a download from an offset should return the remaining bytes.
+		resp, partial = getExecutionDetailsRange(t, s, jobspb.JobID(importJobID), "distsql", "bytes=20-")
+		require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+		require.Equal(t, distSQLDiagram[20:], partial)
+
+		// A suffix range should return the last bytes.
+		resp, partial = getExecutionDetailsRange(t, s, jobspb.JobID(importJobID), "distsql", "bytes=-5")
+		require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+		require.Equal(t, fmt.Sprintf("bytes %d-%d/%d", len(distSQLDiagram)-5, len(distSQLDiagram)-1,
+			len(distSQLDiagram)), resp.Header.Get("Content-Range"))
+		require.Equal(t, distSQLDiagram[len(distSQLDiagram)-5:], partial)
+
+		// A range past the end of the execution detail cannot be satisfied.
+		resp, _ = getExecutionDetailsRange(t, s, jobspb.JobID(importJobID), "distsql",
+			fmt.Sprintf("bytes=%d-", len(distSQLDiagram)+1))
+		require.Equal(t, http.StatusRequestedRangeNotSatisfiable, resp.StatusCode)
+	})
+
+	t.Run("read range across chunks", func(t *testing.T) {
+		const jobID = jobspb.JobID(12345)
+		e := sql.MakeJobProfilerExecutionDetailsBuilder(nil /* srv */, s.InternalDB().(isql.DB), jobID)
+		// The data is stored in three chunks of at most 1 MiB.
+		data := make([]byte, 5<<19)
+		for i := range data {
+			data[i] = byte(i % 251)
+		}
+		require.NoError(t, e.WriteExecutionDetail(ctx, "large", data))
+
+		for _, tc := range []struct {
+			start, length    int64
+			expStart, expEnd int
+		}{
+			{start: 0, length: 10, expStart: 0, expEnd: 10},
+			// Spans the boundary of the first two chunks.
+			{start: 1<<20 - 5, length: 10, expStart: 1<<20 - 5, expEnd: 1<<20 + 5},
+			// Spans all of the second chunk, into the final chunk.
+			{start: 1<<20 - 1, length: 1<<20 + 2, expStart: 1<<20 - 1, expEnd: 2<<20 + 1},
+			// Suffix of the final chunk.
+			{start: -7, length: 7, expStart: len(data) - 7, expEnd: len(data)},
+			// Open-ended.
+			{start: 2<<20 + 3, length: math.MaxInt64, expStart: 2<<20 + 3, expEnd: len(data)},
+		} {
+			got, totalSize, err := e.ReadExecutionDetailRange(ctx, "large", tc.start, tc.length)
+			require.NoError(t, err)
+			require.Equal(t, int64(len(data)), totalSize)
+			require.Equal(t, data[tc.expStart:tc.expEnd], got)
+		}
+
+		// A range past the end of the file is empty.
+		got, totalSize, err := e.ReadExecutionDetailRange(ctx, "large", int64(len(data)), 1)
+		require.NoError(t, err)
+		require.Equal(t, int64(len(data)), totalSize)
+		require.Empty(t, got)
+	})
+
+	t.Run("read/write DistSQL plan spec", func(t *testing.T) {
+		const numProcessors = 3
+		jobs.RegisterConstructor(jobspb.TypeImport, func(j *jobs.Job, _ *cluster.Settings) jobs.Resumer {
+			return fakeExecResumer{
+				OnResume: func(ctx context.Context) error {
+					p := sql.PhysicalPlan{}
+					infra := physicalplan.NewPhysicalInfrastructure(uuid.FastMakeV4(), base.SQLInstanceID(1))
+					for i := 0; i < numProcessors; i++ {
+						infra.AddProcessor(physicalplan.Processor{
+							SQLInstanceID: base.SQLInstanceID(1),
+							Spec: execinfrapb.ProcessorSpec{
+								Core:        execinfrapb.ProcessorCoreUnion{Noop: &execinfrapb.NoopCoreSpec{}},
+								ProcessorID: int32(i),
+							},
+						})
+					}
+					p.PhysicalInfrastructure = infra
+					jobsprofiler.StorePlanDiagram(ctx, s.Stopper(), &p, s.InternalDB().(isql.DB), j.ID())
+					checkForPlanDiagrams(ctx, t, s.InternalDB().(isql.DB), j.ID(), 1)
+					return nil
+				},
+			}
+		}, jobs.UsesTenantCostControl)
+
+		var importJobID int
+		runner.QueryRow(t, `IMPORT INTO t CSV DATA ('nodelocal://1/foo') WITH DETACHED`).Scan(&importJobID)
+		jobutils.WaitForJobToSucceed(t, runner, jobspb.JobID(importJobID))
+
+		runner.Exec(t, `SELECT crdb_internal.request_job_execution_details($1)`, importJobID)
+		var planSpecFile string
+		for _, f := range listExecutionDetails(t, s, jobspb.JobID(importJobID)) {
+			if strings.HasSuffix(f, ".binpb") {
+				planSpecFile = f
+			}
+		}
+		require.Regexp(t, "distsql\\..*\\.binpb", planSpecFile)
+
+		var planSpec execinfrapb.PhysicalPlanSpec
+		require.NoError(t, protoutil.Unmarshal(
+			checkExecutionDetails(t, s, jobspb.JobID(importJobID), planSpecFile), &planSpec))
+		require.Len(t, planSpec.Flows, 1)
+		require.Equal(t, base.SQLInstanceID(1), planSpec.Flows[0].SQLInstanceID)
+		require.Equal(t, numProcessors, planSpec.NumProcessors())
+	})
+
+	t.Run("read DistSQL plan text", func(t *testing.T) {
+		jobs.RegisterConstructor(jobspb.TypeImport, func(j *jobs.Job, _ *cluster.Settings) jobs.Resumer {
+			return fakeExecResumer{
+				OnResume: func(ctx context.Context) error {
+					// Store two plans, the most recent one has an additional
+					// processor.
+					for numProcessors := 1; numProcessors <= 2; numProcessors++ {
+						p := sql.PhysicalPlan{}
+						infra := physicalplan.NewPhysicalInfrastructure(uuid.FastMakeV4(), base.SQLInstanceID(1))
+						for i := 0; i < numProcessors; i++ {
+							infra.AddProcessor(physicalplan.Processor{
+								SQLInstanceID: base.SQLInstanceID(1),
+								Spec: execinfrapb.ProcessorSpec{
+									Core:        execinfrapb.ProcessorCoreUnion{Noop: &execinfrapb.NoopCoreSpec{}},
+									ProcessorID: int32(i),
+								},
+							})
+						}
+						p.PhysicalInfrastructure = infra
+						jobsprofiler.StorePlanDiagram(ctx, s.Stopper(), &p, s.InternalDB().(isql.DB), j.ID())
+						checkForPlanDiagrams(ctx, t, s.InternalDB().(isql.DB), j.ID(), numProcessors)
+					}
+					return nil
+				},
+			}
+		}, jobs.UsesTenantCostControl)
+
+		var importJobID int
+		runner.QueryRow(t, `IMPORT INTO t CSV DATA ('nodelocal://1/foo') WITH DETACHED`).Scan(&importJobID)
+		jobutils.WaitForJobToSucceed(t, runner, jobspb.JobID(importJobID))
+
+		// The most recent plan is returned by default.
+		var latest string
+		runner.QueryRow(t, `SELECT crdb_internal.job_distsql_plan_text($1)`, importJobID).Scan(&latest)
+		require.Regexp(t, fmt.Sprintf("^job:%d\nflow .+\nn1:\n  No-op/0\n  No-op/1\n$", importJobID), latest)
+		var plan string
+		runner.QueryRow(t, `SELECT crdb_internal.job_distsql_plan_text($1, 0)`, importJobID).Scan(&plan)
+		require.Equal(t, latest, plan)
+
+		// An earlier plan may be selected by its index.
+		runner.QueryRow(t, `SELECT crdb_internal.job_distsql_plan_text($1, 1)`, importJobID).Scan(&plan)
+		require.Regexp(t, fmt.Sprintf("^job:%d\nflow .+\nn1:\n  No-op/0\n$", importJobID), plan)
+		runner.ExpectErr(t, "DistSQL plan index 2 is out of range",
+			`SELECT crdb_internal.job_distsql_plan_text($1, 2)`, importJobID)
+		runner.ExpectErr(t, "DistSQL plan index must be non-negative",
+			`SELECT crdb_internal.job_distsql_plan_text($1, -1)`, importJobID)
+	})
+
+	t.Run("read/write participating nodes", func(t *testing.T) {
+		jobs.RegisterConstructor(jobspb.TypeImport, func(j *jobs.Job, _ *cluster.Settings) jobs.Resumer {
+			return fakeExecResumer{
+				OnResume: func(ctx context.Context) error {
+					p := sql.PhysicalPlan{}
+					infra := physicalplan.NewPhysicalInfrastructure(uuid.FastMakeV4(), base.SQLInstanceID(1))
+					// The plan includes a flow on an instance which isn't part of
+					// the cluster, and so is reported as down.
+					for _, instanceID := range []base.SQLInstanceID{1, 2} {
+						infra.AddProcessor(physicalplan.Processor{
+							SQLInstanceID: instanceID,
+							Spec: execinfrapb.ProcessorSpec{
+								Core:        execinfrapb.ProcessorCoreUnion{Noop: &execinfrapb.NoopCoreSpec{}},
+								ProcessorID: int32(instanceID),
+							},
+						})
+					}
+					p.PhysicalInfrastructure = infra
+					jobsprofiler.StorePlanDiagram(ctx, s.Stopper(), &p, s.InternalDB().(isql.DB), j.ID())
+					checkForPlanDiagrams(ctx, t, s.InternalDB().(isql.DB), j.ID(), 1)
+					return nil
+				},
+			}
+		}, jobs.UsesTenantCostControl)
+
+		var importJobID int
+		runner.QueryRow(t, `IMPORT INTO t CSV DATA ('nodelocal://1/foo') WITH DETACHED`).Scan(&importJobID)
+		jobutils.WaitForJobToSucceed(t, runner, jobspb.JobID(importJobID))
+
+		runner.Exec(t, `SELECT crdb_internal.request_job_execution_details($1)`, importJobID)
+		nodes := strings.Split(strings.TrimSpace(
+			string(checkExecutionDetails(t, s, jobspb.JobID(importJobID), "nodes"))), "\n")
+		require.Len(t, nodes, 3)
+		require.Equal(t, fmt.Sprintf("nodes which ran a flow of the DistSQL plan of job %d:", importJobID), nodes[0])
+		require.Regexp(t, "^n1\taddress=.+\tsql_address=.+\tliveness=LIVE$", nodes[1])
+		require.Equal(t, "n2\taddress=unknown\tliveness=UNKNOWN\t"+
+			"(node is down; its per-node artifacts may be missing)", nodes[2])
+	})
+
+	t.Run("read/write flow stats", func(t *testing.T) {
+		var storeStats atomic.Bool
+		jobs.RegisterConstructor(jobspb.TypeImport, func(j *jobs.Job, _ *cluster.Settings) jobs.Resumer {
+			return fakeExecResumer{
+				OnResume: func(ctx context.Context) error {
+					if !storeStats.Load() {
+						return nil
+					}
+					flowID := execinfrapb.FlowID{UUID: uuid.FastMakeV4()}
+					stats := make(map[execinfrapb.ComponentID]*execinfrapb.ComponentStats)
+					for _, instanceID := range []base.SQLInstanceID{1, 2} {
+						processor := execinfrapb.ProcessorComponentID(instanceID, flowID, int32(instanceID))
+						stats[processor] = &execinfrapb.ComponentStats{
+							Component: processor,
+							KV:        execinfrapb.KVStats{BytesRead: optional.MakeUint(1024)},
+							Exec: execinfrapb.ExecStats{
+								ExecTime:        optional.MakeTimeValue(time.Duration(instanceID) * time.Second),
+								MaxAllocatedMem: optional.MakeUint(2048),
+							},
+							Output: execinfrapb.OutputStats{NumTuples: optional.MakeUint(uint64(100 * instanceID))},
+						}
+					}
+					jobsprofiler.StoreFlowStats(ctx, s.InternalDB().(isql.DB), j.ID(), stats)
+					return nil
+				},
+			}
+		}, jobs.UsesTenantCostControl)
+
+		// A job whose flows haven't collected statistics should say so.
+		var importJobID int
+		runner.QueryRow(t, `IMPORT INTO t CSV DATA ('nodelocal://1/foo') WITH DETACHED`).Scan(&importJobID)
+		jobutils.WaitForJobToSucceed(t, runner, jobspb.JobID(importJobID))
+		runner.Exec(t, `SELECT crdb_internal.request_job_execution_details($1)`, importJobID)
+		flowStats := checkExecutionDetails(t, s, jobspb.JobID(importJobID), "flow_stats")
+		require.Equal(t, fmt.Sprintf("flow statistics are unavailable for job %d: "+
+			"its DistSQL flows haven't started or haven't collected execution statistics\n", importJobID),
+			string(flowStats))
+
+		// Otherwise, the processors are listed with the slowest first.
+		storeStats.Store(true)
+		runner.QueryRow(t, `IMPORT INTO t CSV DATA ('nodelocal://1/foo') WITH DETACHED`).Scan(&importJobID)
+		jobutils.WaitForJobToSucceed(t, runner, jobspb.JobID(importJobID))
+		runner.Exec(t, `SELECT crdb_internal.request_job_execution_details($1)`, importJobID)
+		rows := strings.Split(strings.TrimSpace(
+			string(checkExecutionDetails(t, s, jobspb.JobID(importJobID), "flow_stats"))), "\n")
+		require.Equal(t, []string{
+			fmt.Sprintf("processors of the DistSQL flows of job %d, by decreasing execution time:", importJobID),
+			"p2\tn2\texec_time=2s\trows=200\tbytes_read=1.0 KiB\tbytes_sent=-\tmax_memory=2.0 KiB",
+			"p1\tn1\texec_time=1s\trows=100\tbytes_read=1.0 KiB\tbytes_sent=-\tmax_memory=2.0 KiB",
+		}, rows)
 	})
 
 	t.Run("read/write goroutines", func(t *testing.T) {
@@ -114,6 +382,633 @@ func TestReadWriteProfilerExecutionDetails(t *testing.T) {
 		require.True(t, strings.Contains(string(goroutines), fmt.Sprintf("labels: {\"foo\":\"bar\", \"job\":\"IMPORT id=%d\", \"n\":\"1\"}", importJobID)))
 		require.True(t, strings.Contains(string(goroutines), "github.com/cockroachdb/cockroach/pkg/sql_test.fakeExecResumer.Resume"))
 	})
+
+	t.Run("read/write goroutines pprof", func(t *testing.T) {
+		jobs.RegisterConstructor(jobspb.TypeImport, func(j *jobs.Job, _ *cluster.Settings) jobs.Resumer {
+			return fakeExecResumer{
+				OnResume: func(ctx context.Context) error {
+					return nil
+				},
+			}
+		}, jobs.UsesTenantCostControl)
+		var importJobID int
+		runner.QueryRow(t, `IMPORT INTO t CSV DATA ('nodelocal://1/foo') WITH DETACHED`).Scan(&importJobID)
+		jobutils.WaitForJobToSucceed(t, runner, jobspb.JobID(importJobID))
+		runner.Exec(t, `SELECT crdb_internal.request_job_execution_details($1, '{"goroutines_pprof": true}')`,
+			importJobID)
+
+		var pprofFiles []string
+		for _, f := range listExecutionDetails(t, s, jobspb.JobID(importJobID)) {
+			if strings.HasSuffix(f, ".pprof") {
+				pprofFiles = append(pprofFiles, f)
+			}
+		}
+		require.Len(t, pprofFiles, 1)
+		require.Regexp(t, "goroutines\\.1\\..*\\.pprof", pprofFiles[0])
+		goroutines := checkExecutionDetails(t, s, jobspb.JobID(importJobID), "goroutines.1.")
+		p, err := profile.ParseData(goroutines)
+		require.NoError(t, err)
+		require.NotEmpty(t, p.Sample)
+	})
+
+	t.Run("latest goroutines", func(t *testing.T) {
+		blockCh := make(chan struct{})
+		continueCh := make(chan struct{})
+		defer close(blockCh)
+		defer close(continueCh)
+		jobs.RegisterConstructor(jobspb.TypeImport, func(j *jobs.Job, _ *cluster.Settings) jobs.Resumer {
+			return fakeExecResumer{
+				OnResume: func(ctx context.Context) error {
+					blockCh <- struct{}{}
+					<-continueCh
+					return nil
+				},
+			}
+		}, jobs.UsesTenantCostControl)
+		var importJobID int
+		runner.QueryRow(t, `IMPORT INTO t CSV DATA ('nodelocal://1/foo') WITH DETACHED`).Scan(&importJobID)
+		<-blockCh
+		var goroutines, reused string
+		runner.QueryRow(t, `SELECT crdb_internal.latest_job_goroutines($1)`, importJobID).Scan(&goroutines)
+		// A second call soon after the first reuses the collected goroutines.
+		runner.QueryRow(t, `SELECT crdb_internal.latest_job_goroutines($1)`, importJobID).Scan(&reused)
+		continueCh <- struct{}{}
+		jobutils.WaitForJobToSucceed(t, runner, jobspb.JobID(importJobID))
+		require.Contains(t, goroutines, "github.com/cockroachdb/cockroach/pkg/sql_test.fakeExecResumer.Resume")
+		require.Equal(t, goroutines, reused)
+		files := listExecutionDetails(t, s, jobspb.JobID(importJobID))
+		require.Len(t, files, 1)
+		require.Regexp(t, "goroutines\\..*\\.txt", files[0])
+	})
+
+	t.Run("read/write retries", func(t *testing.T) {
+		var failOnce atomic.Bool
+		jobs.RegisterConstructor(jobspb.TypeImport, func(j *jobs.Job, _ *cluster.Settings) jobs.Resumer {
+			return fakeExecResumer{
+				OnResume: func(ctx context.Context) error {
+					if failOnce.Swap(false) {
+						return jobs.MarkAsRetryJobError(errors.New("boom"))
+					}
+					return nil
+				},
+			}
+		}, jobs.UsesTenantCostControl)
+
+		// A job which has never been retried should say so.
+		var importJobID int
+		runner.QueryRow(t, `IMPORT INTO t CSV DATA ('nodelocal://1/foo') WITH DETACHED`).Scan(&importJobID)
+		jobutils.WaitForJobToSucceed(t, runner, jobspb.JobID(importJobID))
+		runner.Exec(t, `SELECT crdb_internal.request_job_execution_details($1)`, importJobID)
+		retries := checkExecutionDetails(t, s, jobspb.JobID(importJobID), "retries")
+		require.Contains(t, string(retries), "status: succeeded")
+		require.Contains(t, string(retries), "no retries")
+
+		// A job which has been retried should include the error that caused the
+		// retry.
+		failOnce.Store(true)
+		runner.QueryRow(t, `IMPORT INTO t CSV DATA ('nodelocal://1/foo') WITH DETACHED`).Scan(&importJobID)
+		jobutils.WaitForJobToSucceed(t, runner, jobspb.JobID(importJobID))
+		runner.Exec(t, `SELECT crdb_internal.request_job_execution_details($1)`, importJobID)
+		retries = checkExecutionDetails(t, s, jobspb.JobID(importJobID), "retries")
+		require.Contains(t, string(retries), "retries: 1")
+		require.Contains(t, string(retries), "boom")
+		require.NotContains(t, string(retries), "no retries")
+	})
+
+	t.Run("read/write contention", func(t *testing.T) {
+		runner.Exec(t, `SET CLUSTER SETTING sql.contention.event_store.resolution_interval = '10ms'`)
+		var tableID descpb.ID
+		runner.QueryRow(t, `SELECT 't'::REGCLASS::INT`).Scan(&tableID)
+		recordDescriptors := registerDescriptorRecordingImportResumer(tableID)
+
+		// A job which doesn't record its descriptors can't be attributed any
+		// contention events.
+		var importJobID int
+		runner.QueryRow(t, `IMPORT INTO t CSV DATA ('nodelocal://1/foo') WITH DETACHED`).Scan(&importJobID)
+		jobutils.WaitForJobToSucceed(t, runner, jobspb.JobID(importJobID))
+		runner.Exec(t, `SELECT crdb_internal.request_job_execution_details($1)`, importJobID)
+		contention := checkExecutionDetails(t, s, jobspb.JobID(importJobID), "contention")
+		require.Contains(t, string(contention), "does not record the descriptors")
+
+		// A job which records its descriptors should include the contention
+		// events on the keys of its table.
+		recordDescriptors.Store(true)
+		execCfg := s.ExecutorConfig().(sql.ExecutorConfig)
+		key := encoding.EncodeVarintAscending(execCfg.Codec.IndexPrefix(uint32(tableID), 1), 42)
+		execCfg.ContentionRegistry.AddContentionEvent(contentionpb.ExtendedContentionEvent{
+			BlockingEvent: kvpb.ContentionEvent{
+				Key: key,
+				TxnMeta: enginepb.TxnMeta{
+					Key: key,
+					ID:  uuid.FastMakeV4(),
+				},
+				Duration: time.Minute,
+			},
+			BlockingTxnFingerprintID: 9001,
+			WaitingTxnID:             uuid.FastMakeV4(),
+			WaitingTxnFingerprintID:  9002,
+			WaitingStmtID:            clusterunique.ID{Uint128: uint128.Uint128{Lo: 9003, Hi: 1004}},
+			WaitingStmtFingerprintID: 9004,
+		})
+		testutils.SucceedsSoon(t, func() error {
+			var count int
+			runner.QueryRow(t, `SELECT count(*) FROM crdb_internal.transaction_contention_events
+WHERE contending_key = $1`, []byte(key)).Scan(&count)
+			if count == 0 {
+				return errors.New("contention event not yet resolved")
+			}
+			return nil
+		})
+		runner.QueryRow(t, `IMPORT INTO t CSV DATA ('nodelocal://1/foo') WITH DETACHED`).Scan(&importJobID)
+		jobutils.WaitForJobToSucceed(t, runner, jobspb.JobID(importJobID))
+		runner.Exec(t, `SELECT crdb_internal.request_job_execution_details($1)`, importJobID)
+		contention = checkExecutionDetails(t, s, jobspb.JobID(importJobID), "contention")
+		require.Contains(t, string(contention), fmt.Sprintf("contention events on the descriptors [%d]", tableID))
+		require.Contains(t, string(contention), "waited 00:01:00")
+		require.Contains(t, string(contention), ".public.t, index t_pkey")
+	})
+
+	t.Run("read/write span configs", func(t *testing.T) {
+		var tableID descpb.ID
+		runner.QueryRow(t, `SELECT 't'::REGCLASS::INT`).Scan(&tableID)
+		recordDescriptors := registerDescriptorRecordingImportResumer(tableID)
+
+		// A job which doesn't record its descriptors has no target spans, so no
+		// span configs are listed.
+		var importJobID int
+		runner.QueryRow(t, `IMPORT INTO t CSV DATA ('nodelocal://1/foo') WITH DETACHED`).Scan(&importJobID)
+		jobutils.WaitForJobToSucceed(t, runner, jobspb.JobID(importJobID))
+		runner.Exec(t, `SELECT crdb_internal.request_job_execution_details($1)`, importJobID)
+		spanConfigs := checkExecutionDetails(t, s, jobspb.JobID(importJobID), "span_configs")
+		require.Contains(t, string(spanConfigs), "it has no target spans")
+
+		// A job which records its descriptors should list the span configs of
+		// its table, once the zone config has been reconciled.
+		recordDescriptors.Store(true)
+		runner.Exec(t, `ALTER TABLE t CONFIGURE ZONE USING gc.ttlseconds = 1234`)
+		runner.QueryRow(t, `IMPORT INTO t CSV DATA ('nodelocal://1/foo') WITH DETACHED`).Scan(&importJobID)
+		jobutils.WaitForJobToSucceed(t, runner, jobspb.JobID(importJobID))
+		testutils.SucceedsSoon(t, func() error {
+			runner.Exec(t, `SELECT crdb_internal.request_job_execution_details($1)`, importJobID)
+			spanConfigs = checkExecutionDetails(t, s, jobspb.JobID(importJobID), "span_configs")
+			if !strings.Contains(string(spanConfigs), "gc.ttlseconds = 1234") {
+				return errors.Newf("zone config not yet reconciled:\n%s", spanConfigs)
+			}
+			return nil
+		})
+		require.Contains(t, string(spanConfigs), fmt.Sprintf("span configs of the descriptors [%d]", tableID))
+	})
+
+	t.Run("read/write txn stats", func(t *testing.T) {
+		var statement atomic.Value
+		jobs.RegisterConstructor(jobspb.TypeImport, func(j *jobs.Job, _ *cluster.Settings) jobs.Resumer {
+			return fakeExecResumer{
+				OnResume: func(ctx context.Context) error {
+					return j.NoTxn().Update(ctx, func(_ isql.Txn, md jobs.JobMetadata, ju *jobs.JobUpdater) error {
+						md.Payload.Statement = []string{statement.Load().(string)}
+						ju.UpdatePayload(md.Payload)
+						return nil
+					})
+				},
+			}
+		}, jobs.UsesTenantCostControl)
+
+		// A job which doesn't originate from a SQL statement has no fingerprint
+		// stats.
+		statement.Store("reconciling span configurations")
+		var importJobID int
+		runner.QueryRow(t, `IMPORT INTO t CSV DATA ('nodelocal://1/foo') WITH DETACHED`).Scan(&importJobID)
+		jobutils.WaitForJobToSucceed(t, runner, jobspb.JobID(importJobID))
+		runner.Exec(t, `SELECT crdb_internal.request_job_execution_details($1)`, importJobID)
+		txnStats := checkExecutionDetails(t, s, jobspb.JobID(importJobID), "txn_stats")
+		require.Contains(t, string(txnStats), "did not originate from a tracked SQL statement")
+
+		// A job which originates from a statement is tied to the persisted
+		// statistics of its fingerprint.
+		runner.Exec(t, `SELECT count(*) FROM t WHERE id > 7`)
+		runner.Exec(t, `SELECT count(*) FROM t WHERE id > 42`)
+		s.SQLServer().(*sql.Server).GetSQLStatsProvider().(*persistedsqlstats.PersistedSQLStats).Flush(ctx)
+		statement.Store(`SELECT count(*) FROM t WHERE id > 1`)
+		runner.QueryRow(t, `IMPORT INTO t CSV DATA ('nodelocal://1/foo') WITH DETACHED`).Scan(&importJobID)
+		jobutils.WaitForJobToSucceed(t, runner, jobspb.JobID(importJobID))
+		runner.Exec(t, `SELECT crdb_internal.request_job_execution_details($1)`, importJobID)
+		txnStats = checkExecutionDetails(t, s, jobspb.JobID(importJobID), "txn_stats")
+		require.Contains(t, string(txnStats), fmt.Sprintf("fingerprint stats of the statements of job %d", importJobID))
+		require.Contains(t, string(txnStats), "SELECT count(*) FROM t WHERE id > _")
+		require.Contains(t, string(txnStats), "executions: 2, max retries: 0, mean rows: 1.0")
+	})
+
+	t.Run("read/write admission queueing", func(t *testing.T) {
+		jobs.RegisterConstructor(jobspb.TypeImport, func(j *jobs.Job, _ *cluster.Settings) jobs.Resumer {
+			return fakeExecResumer{}
+		}, jobs.UsesTenantCostControl)
+
+		// The SSTs ingested by an IMPORT are admitted through the store work
+		// queues at bulk priority.
+		var importJobID int
+		runner.QueryRow(t, `IMPORT INTO t CSV DATA ('nodelocal://1/foo') WITH DETACHED`).Scan(&importJobID)
+		jobutils.WaitForJobToSucceed(t, runner, jobspb.JobID(importJobID))
+		runner.Exec(t, `SELECT crdb_internal.request_job_execution_details($1)`, importJobID)
+		admission := strings.Split(strings.TrimSpace(
+			string(checkExecutionDetails(t, s, jobspb.JobID(importJobID), "admission"))), "\n")
+		require.Equal(t, fmt.Sprintf("admission control queueing of the work of job %d (IMPORT), "+
+			"by queue and priority.", importJobID), admission[0])
+		require.Equal(t, "kv-stores at bulk-normal-pri:", admission[2])
+		require.Regexp(t, "^n1\trequested=[0-9]+\tadmitted=[0-9]+\terrored=[0-9]+\twaiting=[0-9]+\t"+
+			"total_wait=.+\tmean_wait=.+\tp99_wait=.+$", admission[3])
+
+		// The work of a statistics collection isn't subject to admission control
+		// at bulk priority.
+		runner.Exec(t, `CREATE STATISTICS s FROM t`)
+		var statsJobID int
+		runner.QueryRow(t, `SELECT job_id FROM [SHOW JOBS] WHERE job_type = 'CREATE STATS'`).Scan(&statsJobID)
+		runner.Exec(t, `SELECT crdb_internal.request_job_execution_details($1)`, statsJobID)
+		admission = strings.Split(strings.TrimSpace(
+			string(checkExecutionDetails(t, s, jobspb.JobID(statsJobID), "admission"))), "\n")
+		require.Equal(t, []string{fmt.Sprintf("admission control is not engaged for job %d: "+
+			"the work of CREATE STATS jobs is not subject to admission control", statsJobID)}, admission)
+	})
+
+	t.Run("read/write storage stats", func(t *testing.T) {
+		var tableID descpb.ID
+		runner.QueryRow(t, `SELECT 't'::REGCLASS::INT`).Scan(&tableID)
+		recordDescriptors := registerDescriptorRecordingImportResumer(tableID)
+		storageStatsFiles := func(jobID int) []string {
+			var files []string
+			for _, f := range listExecutionDetails(t, s, jobspb.JobID(jobID)) {
+				if strings.HasPrefix(f, "~profiler/storage_stats.") {
+					files = append(files, f)
+				}
+			}
+			return files
+		}
+
+		// A job which doesn't record its descriptors has no data on any store.
+		var importJobID int
+		runner.QueryRow(t, `IMPORT INTO t CSV DATA ('nodelocal://1/foo') WITH DETACHED`).Scan(&importJobID)
+		jobutils.WaitForJobToSucceed(t, runner, jobspb.JobID(importJobID))
+		runner.Exec(t, `SELECT crdb_internal.request_job_execution_details($1)`, importJobID)
+		require.Empty(t, storageStatsFiles(importJobID))
+
+		// The table of a job which records its descriptors is held by the only
+		// store of the cluster.
+		recordDescriptors.Store(true)
+		runner.QueryRow(t, `IMPORT INTO t CSV DATA ('nodelocal://1/foo') WITH DETACHED`).Scan(&importJobID)
+		jobutils.WaitForJobToSucceed(t, runner, jobspb.JobID(importJobID))
+		// The statuses of the stores are recorded periodically, so they may not
+		// have been recorded yet.
+		var files []string
+		testutils.SucceedsSoon(t, func() error {
+			runner.Exec(t, `SELECT crdb_internal.request_job_execution_details($1)`, importJobID)
+			if files = storageStatsFiles(importJobID); len(files) == 0 {
+				return errors.New("no storage stats were collected")
+			}
+			return nil
+		})
+		require.Len(t, files, 1)
+		require.Regexp(t, "storage_stats\\.1\\..*\\.txt", files[0])
+		stats := strings.Split(strings.TrimSpace(
+			string(checkExecutionDetails(t, s, jobspb.JobID(importJobID), "storage_stats.1."))), "\n")
+		require.Equal(t, fmt.Sprintf("storage engine stats of the stores of n1 holding the descriptors [%d] "+
+			"of job %d.", tableID, importJobID), stats[0])
+		require.Equal(t, "s1:", stats[2])
+		require.Regexp(t, "^L0\tsize=.+\tscore=[0-9.]+\tfiles=[0-9]+\tsublevels=[0-9]+$", stats[3])
+		require.Regexp(t, "^L6\tsize=.+\tscore=[0-9.]+$", stats[9])
+		require.Regexp(t, "^compactions=[0-9]+\t.*\testimated_pending_compaction=.+$", stats[11])
+	})
+
+	t.Run("request for schedule", func(t *testing.T) {
+		blockCh := make(chan struct{})
+		continueCh := make(chan struct{})
+		defer close(blockCh)
+		defer close(continueCh)
+		var block atomic.Bool
+		jobs.RegisterConstructor(jobspb.TypeImport, func(j *jobs.Job, _ *cluster.Settings) jobs.Resumer {
+			return fakeExecResumer{
+				OnResume: func(ctx context.Context) error {
+					if block.Load() {
+						blockCh <- struct{}{}
+						<-continueCh
+					}
+					return nil
+				},
+			}
+		}, jobs.UsesTenantCostControl)
+		const scheduleID = 4242
+		createdBySchedule := func(jobID int) {
+			runner.Exec(t, `UPDATE system.jobs SET created_by_type = 'crdb_schedule', created_by_id = $1
+WHERE id = $2`, scheduleID, jobID)
+		}
+
+		// Only the running jobs of the schedule have their execution details
+		// requested.
+		var succeededJobID, runningJobID int
+		runner.QueryRow(t, `IMPORT INTO t CSV DATA ('nodelocal://1/foo') WITH DETACHED`).Scan(&succeededJobID)
+		jobutils.WaitForJobToSucceed(t, runner, jobspb.JobID(succeededJobID))
+		createdBySchedule(succeededJobID)
+		block.Store(true)
+		runner.QueryRow(t, `IMPORT INTO t CSV DATA ('nodelocal://1/foo') WITH DETACHED`).Scan(&runningJobID)
+		<-blockCh
+		createdBySchedule(runningJobID)
+
+		runner.CheckQueryResults(t, fmt.Sprintf(
+			`SELECT job_id, requested, error FROM crdb_internal.request_job_execution_details_for_schedule(%d)`,
+			scheduleID), [][]string{{fmt.Sprint(runningJobID), "true", "NULL"}})
+		continueCh <- struct{}{}
+		jobutils.WaitForJobToSucceed(t, runner, jobspb.JobID(runningJobID))
+		require.NotEmpty(t, listExecutionDetails(t, s, jobspb.JobID(runningJobID)))
+		require.Empty(t, listExecutionDetails(t, s, jobspb.JobID(succeededJobID)))
+
+		// A schedule without any running jobs returns no rows.
+		runner.CheckQueryResults(t,
+			`SELECT * FROM crdb_internal.request_job_execution_details_for_schedule(1)`, [][]string{})
+	})
+}
+
+// TestValidateProfilerExecutionDetails tests that requesting execution details
+// with the validate_only option reports the artifact types supported for a
+// job, without collecting any execution details.
+func TestValidateProfilerExecutionDetails(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Timeout the test in a few minutes if it hasn't succeeded.
+	ctx := context.Background()
+	ctx, cancel := context.WithTimeout(ctx, time.Minute*2)
+	defer cancel()
+
+	params, _ := tests.CreateTestServerParams()
+	params.Knobs.JobsTestingKnobs = jobs.NewTestingKnobsWithShortIntervals()
+	defer jobs.ResetConstructors()()
+	s, sqlDB, _ := serverutils.StartServer(t, params)
+	defer s.Stopper().Stop(ctx)
+
+	runner := sqlutils.MakeSQLRunner(sqlDB)
+	jobs.RegisterConstructor(jobspb.TypeImport, func(j *jobs.Job, _ *cluster.Settings) jobs.Resumer {
+		return fakeExecResumer{
+			OnResume: func(ctx context.Context) error {
+				p := sql.PhysicalPlan{}
+				infra := physicalplan.NewPhysicalInfrastructure(uuid.FastMakeV4(), base.SQLInstanceID(1))
+				p.PhysicalInfrastructure = infra
+				jobsprofiler.StorePlanDiagram(ctx, s.Stopper(), &p, s.InternalDB().(isql.DB), j.ID())
+				checkForPlanDiagrams(ctx, t, s.InternalDB().(isql.DB), j.ID(), 1)
+				return nil
+			},
+		}
+	}, jobs.UsesTenantCostControl)
+
+	runner.Exec(t, `CREATE TABLE t (id INT)`)
+
+	var importJobID int
+	runner.QueryRow(t, `IMPORT INTO t CSV DATA ('nodelocal://1/foo') WITH DETACHED`).Scan(&importJobID)
+	jobutils.WaitForJobToSucceed(t, runner, jobspb.JobID(importJobID))
+
+	// An IMPORT persists its DistSQL plan, so every artifact type is supported.
+	var artifacts string
+	runner.QueryRow(t, `SELECT crdb_internal.request_job_execution_details($1, '{"validate_only": true}')`,
+		importJobID).Scan(&artifacts)
+	require.Equal(t, fmt.Sprintf(`{"job_id": %d, "job_type": "IMPORT", `+
+		`"supported": ["distsql_diagram", "distsql_plan_spec", "nodes", "flow_stats", "goroutines", "retries", "contention", "span_configs", "txn_stats", "admission", "storage_stats"], "unsupported": []}`,
+		importJobID), artifacts)
+	// Nothing should have been collected.
+	require.Empty(t, listExecutionDetails(t, s, jobspb.JobID(importJobID)))
+
+	// A schema change does not persist its DistSQL plan.
+	runner.Exec(t, `CREATE INDEX ON t (id)`)
+	var schemaChangeJobID int
+	runner.QueryRow(t, `SELECT job_id FROM [SHOW JOBS] WHERE job_type = 'NEW SCHEMA CHANGE'`).
+		Scan(&schemaChangeJobID)
+	runner.QueryRow(t, `SELECT crdb_internal.request_job_execution_details($1, '{"validate_only": true}')`,
+		schemaChangeJobID).Scan(&artifacts)
+	require.Equal(t, fmt.Sprintf(`{"job_id": %d, "job_type": "NEW SCHEMA CHANGE", `+
+		`"supported": ["goroutines", "retries", "contention", "span_configs", "txn_stats", "admission", "storage_stats"], "unsupported": ["distsql_diagram", "distsql_plan_spec", "nodes", "flow_stats"]}`,
+		schemaChangeJobID), artifacts)
+
+	// Without validate_only, the execution details are collected.
+	runner.QueryRow(t, `SELECT crdb_internal.request_job_execution_details($1, '{}')`,
+		importJobID).Scan(&artifacts)
+	files := listExecutionDetails(t, s, jobspb.JobID(importJobID))
+	require.Len(t, files, 7)
+	require.Regexp(t, "admission\\..*\\.txt", files[0])
+	require.Regexp(t, "contention\\..*\\.txt", files[1])
+	require.Regexp(t, "distsql\\..*\\.html", files[2])
+	require.Regexp(t, "goroutines\\..*\\.txt", files[3])
+	require.Regexp(t, "retries\\..*\\.txt", files[4])
+	require.Regexp(t, "span_configs\\..*\\.txt", files[5])
+	require.Regexp(t, "txn_stats\\..*\\.txt", files[6])
+
+	runner.ExpectErr(t, `unknown option "validate"`,
+		`SELECT crdb_internal.request_job_execution_details($1, '{"validate": true}')`, importJobID)
+	runner.ExpectErr(t, `option "validate_only" must be a boolean`,
+		`SELECT crdb_internal.request_job_execution_details($1, '{"validate_only": "yes"}')`, importJobID)
+}
+
+// TestJobProfilerCapabilities asserts that crdb_internal.job_profiler_capabilities
+// reports the same artifact types as supported for a registered job type as
+// the validate_only option of crdb_internal.request_job_execution_details.
+func TestJobProfilerCapabilities(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	params, _ := tests.CreateTestServerParams()
+	defer jobs.ResetConstructors()()
+	s, sqlDB, _ := serverutils.StartServer(t, params)
+	defer s.Stopper().Stop(ctx)
+
+	runner := sqlutils.MakeSQLRunner(sqlDB)
+	jobs.RegisterConstructor(jobspb.TypeImport, func(j *jobs.Job, _ *cluster.Settings) jobs.Resumer {
+		return fakeExecResumer{}
+	}, jobs.UsesTenantCostControl)
+
+	const query = `SELECT artifact_type, supported FROM crdb_internal.job_profiler_capabilities
+WHERE job_type = $1 ORDER BY artifact_type`
+
+	// An IMPORT persists its DistSQL plan, so every artifact type is supported.
+	require.Equal(t, [][]string{
+		{"admission", "true"},
+		{"contention", "true"},
+		{"distsql_diagram", "true"},
+		{"distsql_plan_spec", "true"},
+		{"flow_stats", "true"},
+		{"goroutines", "true"},
+		{"nodes", "true"},
+		{"retries", "true"},
+		{"span_configs", "true"},
+		{"storage_stats", "true"},
+		{"txn_stats", "true"},
+	}, runner.QueryStr(t, query, "IMPORT"))
+
+	// Statistics collection does not persist its DistSQL plan, nor is its work
+	// subject to admission control.
+	require.Equal(t, [][]string{
+		{"admission", "false"},
+		{"contention", "true"},
+		{"distsql_diagram", "false"},
+		{"distsql_plan_spec", "false"},
+		{"flow_stats", "false"},
+		{"goroutines", "true"},
+		{"nodes", "false"},
+		{"retries", "true"},
+		{"span_configs", "true"},
+		{"storage_stats", "true"},
+		{"txn_stats", "true"},
+	}, runner.QueryStr(t, query, "CREATE STATS"))
+
+	// A BACKUP persists its DistSQL plan, but its flows don't store their
+	// statistics.
+	jobs.RegisterConstructor(jobspb.TypeBackup, func(j *jobs.Job, _ *cluster.Settings) jobs.Resumer {
+		return fakeExecResumer{}
+	}, jobs.UsesTenantCostControl)
+	require.Equal(t, [][]string{
+		{"admission", "true"},
+		{"contention", "true"},
+		{"distsql_diagram", "true"},
+		{"distsql_plan_spec", "true"},
+		{"flow_stats", "false"},
+		{"goroutines", "true"},
+		{"nodes", "true"},
+		{"retries", "true"},
+		{"span_configs", "true"},
+		{"storage_stats", "true"},
+		{"txn_stats", "true"},
+	}, runner.QueryStr(t, query, "BACKUP"))
+
+	// A job type without a registered resumer has no capabilities.
+	require.Empty(t, runner.QueryStr(t, query, "UNSPECIFIED"))
+}
+
+// TestExecutionDetailsCollectionLimit asserts that the number of concurrent
+// execution detail collections never exceeds the configured limit, whichever
+// jobs and nodes they are requested from, and that requests beyond the limit are rejected
+// rather than queued.
+func TestExecutionDetailsCollectionLimit(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	ctx, cancel := context.WithTimeout(ctx, time.Minute*2)
+	defer cancel()
+
+	const limit, requests = 2, 8
+	var mu struct {
+		syncutil.Mutex
+		inFlight, maxInFlight int
+	}
+	proceed := make(chan struct{})
+	params, _ := tests.CreateTestServerParams()
+	params.Knobs.JobsTestingKnobs = jobs.NewTestingKnobsWithShortIntervals()
+	params.Knobs.SQLExecutor = &sql.ExecutorTestingKnobs{
+		BeforeExecutionDetailsCollection: func(context.Context, jobspb.JobID) {
+			mu.Lock()
+			mu.inFlight++
+			if mu.inFlight > mu.maxInFlight {
+				mu.maxInFlight = mu.inFlight
+			}
+			mu.Unlock()
+			<-proceed
+			mu.Lock()
+			mu.inFlight--
+			mu.Unlock()
+		},
+	}
+	defer jobs.ResetConstructors()()
+	const numNodes = 3
+	tc := testcluster.StartTestCluster(t, numNodes, base.TestClusterArgs{ServerArgs: params})
+	defer tc.Stopper().Stop(ctx)
+
+	runner := sqlutils.MakeSQLRunner(tc.ServerConn(0))
+	runner.Exec(t, `SET CLUSTER SETTING jobs.execution_details.max_concurrent_collections = $1`, limit)
+	jobs.RegisterConstructor(jobspb.TypeImport, func(j *jobs.Job, _ *cluster.Settings) jobs.Resumer {
+		return fakeExecResumer{}
+	}, jobs.UsesTenantCostControl)
+	runner.Exec(t, `CREATE TABLE t (id INT)`)
+	jobIDs := make([]int, requests)
+	for i := range jobIDs {
+		runner.QueryRow(t, `IMPORT INTO t CSV DATA ('nodelocal://1/foo') WITH DETACHED`).Scan(&jobIDs[i])
+		jobutils.WaitForJobToSucceed(t, runner, jobspb.JobID(jobIDs[i]))
+	}
+
+	// Request the execution details of every job at once, spread across the
+	// nodes of the cluster, as the limit applies across the cluster. The
+	// collections which are admitted block until the others have been
+	// rejected.
+	errCh := make(chan error, requests)
+	for i, jobID := range jobIDs {
+		go func(db *gosql.DB, jobID int) {
+			_, err := db.Exec(`SELECT crdb_internal.request_job_execution_details($1)`, jobID)
+			errCh <- err
+		}(tc.ServerConn(i%numNodes), jobID)
+	}
+	for i := 0; i < requests-limit; i++ {
+		err := <-errCh
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "too many concurrent execution detail collections")
+	}
+	// Fetching the latest goroutines of a job collects them across the
+	// cluster, so it counts against the same limit.
+	_, err := tc.ServerConn(1).Exec(`SELECT crdb_internal.latest_job_goroutines($1)`, jobIDs[0])
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "too many concurrent execution detail collections")
+	close(proceed)
+	for i := 0; i < limit; i++ {
+		require.NoError(t, <-errCh)
+	}
+	mu.Lock()
+	maxInFlight := mu.maxInFlight
+	mu.Unlock()
+	require.Equal(t, limit, maxInFlight)
+
+	// Once the collections have completed, a request is admitted again.
+	runner.Exec(t, `SELECT crdb_internal.request_job_execution_details($1)`, jobIDs[0])
+}
+
+// TestExecutionDetailsCollectionLeaseReleasedOnTimeout asserts that the lease
+// held on an execution detail collection is released when the collection
+// times out, rather than counting against the limit until it expires.
+func TestExecutionDetailsCollectionLeaseReleasedOnTimeout(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	ctx, cancel := context.WithTimeout(ctx, time.Minute*2)
+	defer cancel()
+
+	var blockCollection atomic.Bool
+	params, _ := tests.CreateTestServerParams()
+	params.Knobs.JobsTestingKnobs = jobs.NewTestingKnobsWithShortIntervals()
+	params.Knobs.SQLExecutor = &sql.ExecutorTestingKnobs{
+		BeforeExecutionDetailsCollection: func(ctx context.Context, _ jobspb.JobID) {
+			// Block the collection until the request times out.
+			if blockCollection.Load() {
+				<-ctx.Done()
+			}
+		},
+	}
+	defer jobs.ResetConstructors()()
+	s, sqlDB, _ := serverutils.StartServer(t, params)
+	defer s.Stopper().Stop(ctx)
+
+	runner := sqlutils.MakeSQLRunner(sqlDB)
+	runner.Exec(t, `SET CLUSTER SETTING jobs.execution_details.max_concurrent_collections = 1`)
+	jobs.RegisterConstructor(jobspb.TypeImport, func(j *jobs.Job, _ *cluster.Settings) jobs.Resumer {
+		return fakeExecResumer{}
+	}, jobs.UsesTenantCostControl)
+	runner.Exec(t, `CREATE TABLE t (id INT)`)
+	var jobID int
+	runner.QueryRow(t, `IMPORT INTO t CSV DATA ('nodelocal://1/foo') WITH DETACHED`).Scan(&jobID)
+	jobutils.WaitForJobToSucceed(t, runner, jobspb.JobID(jobID))
+
+	blockCollection.Store(true)
+	runner.Exec(t, `SELECT crdb_internal.request_job_execution_details_sync($1, '100ms')`, jobID)
+	blockCollection.Store(false)
+
+	// The lease of the timed out collection has been released, so the next
+	// collection is admitted although only one may run at a time.
+	runner.CheckQueryResults(t,
+		`SELECT count(*) FROM system.job_info WHERE info_key = '~collection-leases'`,
+		[][]string{{"0"}})
+	runner.Exec(t, `SELECT crdb_internal.request_job_execution_details($1)`, jobID)
 }
 
 func TestListProfilerExecutionDetails(t *testing.T) {
@@ -162,23 +1057,121 @@ func TestListProfilerExecutionDetails(t *testing.T) {
 
 		runner.Exec(t, `SELECT crdb_internal.request_job_execution_details($1)`, importJobID)
 		files := listExecutionDetails(t, s, jobspb.JobID(importJobID))
-		require.Len(t, files, 2)
-		require.Regexp(t, "distsql\\..*\\.html", files[0])
-		require.Regexp(t, "goroutines\\..*\\.txt", files[1])
+		require.Len(t, files, 8)
+		require.Regexp(t, "admission\\..*\\.txt", files[0])
+		require.Regexp(t, "contention\\..*\\.txt", files[1])
+		// The first run of the job is attempt 1.
+		require.Regexp(t, "distsql\\..*\\.attempt1\\.html", files[2])
+		require.Regexp(t, "flow_stats\\..*\\.txt", files[3])
+		require.Regexp(t, "goroutines\\..*\\.txt", files[4])
+		require.Regexp(t, "retries\\..*\\.txt", files[5])
+		require.Regexp(t, "span_configs\\..*\\.txt", files[6])
+		require.Regexp(t, "txn_stats\\..*\\.txt", files[7])
 
-		// Resume the job, so it can write another DistSQL diagram and goroutine
-		// snapshot.
+		// Each file should also be listed with its size and the time at which it
+		// was written.
+		details := listExecutionDetailsResponse(t, s, jobspb.JobID(importJobID), "" /* label */).FileDetails
+		require.Len(t, details, 8)
+		for i, f := range details {
+			require.Equal(t, files[i], f.Name)
+			require.Positive(t, f.SizeBytes)
+			require.WithinDuration(t, timeutil.Now(), f.Written, time.Minute)
+		}
+
+		// Resume the job, so it can write another DistSQL diagram, flow stats,
+		// goroutine snapshot, retry history, contention events, span configs,
+		// fingerprint stats and admission control queueing.
 		runner.Exec(t, `SET CLUSTER SETTING jobs.debug.pausepoints = ''`)
 		expectedDiagrams = 2
 		runner.Exec(t, `RESUME JOB $1`, importJobID)
 		jobutils.WaitForJobToSucceed(t, runner, jobspb.JobID(importJobID))
 		runner.Exec(t, `SELECT crdb_internal.request_job_execution_details($1)`, importJobID)
 		files = listExecutionDetails(t, s, jobspb.JobID(importJobID))
-		require.Len(t, files, 4)
-		require.Regexp(t, "distsql\\..*\\.html", files[0])
-		require.Regexp(t, "distsql\\..*\\.html", files[1])
-		require.Regexp(t, "goroutines\\..*\\.txt", files[2])
-		require.Regexp(t, "goroutines\\..*\\.txt", files[3])
+		require.Len(t, files, 16)
+		require.Regexp(t, "admission\\..*\\.txt", files[0])
+		require.Regexp(t, "admission\\..*\\.txt", files[1])
+		require.Regexp(t, "contention\\..*\\.txt", files[2])
+		require.Regexp(t, "contention\\..*\\.txt", files[3])
+		// The diagram collected after the job was resumed is the plan of its
+		// second run.
+		require.Regexp(t, "distsql\\..*\\.attempt1\\.html", files[4])
+		require.Regexp(t, "distsql\\..*\\.attempt2\\.html", files[5])
+		require.Regexp(t, "flow_stats\\..*\\.txt", files[6])
+		require.Regexp(t, "flow_stats\\..*\\.txt", files[7])
+		require.Regexp(t, "goroutines\\..*\\.txt", files[8])
+		require.Regexp(t, "goroutines\\..*\\.txt", files[9])
+		require.Regexp(t, "retries\\..*\\.txt", files[10])
+		require.Regexp(t, "retries\\..*\\.txt", files[11])
+		require.Regexp(t, "span_configs\\..*\\.txt", files[12])
+		require.Regexp(t, "span_configs\\..*\\.txt", files[13])
+		require.Regexp(t, "txn_stats\\..*\\.txt", files[14])
+		require.Regexp(t, "txn_stats\\..*\\.txt", files[15])
+	})
+
+	t.Run("list labelled execution detail files", func(t *testing.T) {
+		runner.Exec(t, `SET CLUSTER SETTING jobs.debug.pausepoints = 'fakeresumer.pause'`)
+		expectedDiagrams = 1
+		var importJobID int
+		runner.QueryRow(t, `IMPORT INTO t CSV DATA ('nodelocal://1/foo') WITH DETACHED`).Scan(&importJobID)
+		jobutils.WaitForJobToPause(t, runner, jobspb.JobID(importJobID))
+
+		// The label is sanitized, so that it can't introduce the separators of
+		// the file names.
+		runner.Exec(t, `SELECT crdb_internal.request_job_execution_details($1, '{"label": " Before Restart/#1.x "}')`,
+			importJobID)
+		runner.Exec(t, `SELECT crdb_internal.request_job_execution_details($1, '{"label": "after-restart"}')`,
+			importJobID)
+		runner.Exec(t, `SELECT crdb_internal.request_job_execution_details($1)`, importJobID)
+
+		all := listExecutionDetailsResponse(t, s, jobspb.JobID(importJobID), "" /* label */).FileDetails
+		require.Len(t, all, 24)
+		labels := make(map[string]int)
+		for _, f := range all {
+			labels[f.Label]++
+		}
+		require.Equal(t, map[string]int{"before_restart__1_x": 8, "after-restart": 8, "": 8}, labels)
+
+		before := listExecutionDetailsResponse(t, s, jobspb.JobID(importJobID), "before_restart__1_x")
+		require.Len(t, before.Files, 8)
+		for i, f := range before.FileDetails {
+			require.Equal(t, "before_restart__1_x", f.Label)
+			require.Equal(t, before.Files[i], f.Name)
+			require.Regexp(t, "^~profiler/[a-z_]+\\.[0-9_.]+@before_restart__1_x(\\.attempt[0-9]+)?\\.[a-z]+$", f.Name)
+		}
+		sort.Strings(before.Files)
+		require.Regexp(t, "goroutines\\..*@before_restart__1_x\\.txt", before.Files[4])
+		checkExecutionDetails(t, s, jobspb.JobID(importJobID),
+			strings.TrimPrefix(before.Files[4], "~profiler/"))
+
+		runner.ExpectErr(t, `option "label" must be a string`,
+			`SELECT crdb_internal.request_job_execution_details($1, '{"label": 1}')`, importJobID)
+	})
+
+	t.Run("request execution details and wait", func(t *testing.T) {
+		runner.Exec(t, `SET CLUSTER SETTING jobs.debug.pausepoints = 'fakeresumer.pause'`)
+		expectedDiagrams = 1
+		var importJobID int
+		runner.QueryRow(t, `IMPORT INTO t CSV DATA ('nodelocal://1/foo') WITH DETACHED`).Scan(&importJobID)
+		jobutils.WaitForJobToPause(t, runner, jobspb.JobID(importJobID))
+
+		// The files written by the collection are returned once it completes.
+		var files []string
+		runner.QueryRow(t,
+			`SELECT crdb_internal.request_job_execution_details_sync($1, '1m')`, importJobID,
+		).Scan(pq.Array(&files))
+		sort.Strings(files)
+		require.Equal(t, listExecutionDetails(t, s, jobspb.JobID(importJobID)), files)
+		require.Len(t, files, 8)
+
+		// Only the files written by this collection are returned.
+		runner.QueryRow(t,
+			`SELECT crdb_internal.request_job_execution_details_sync($1, '1m')`, importJobID,
+		).Scan(pq.Array(&files))
+		require.Len(t, files, 8)
+		require.Len(t, listExecutionDetails(t, s, jobspb.JobID(importJobID)), 16)
+
+		runner.ExpectErr(t, `timeout must be positive`,
+			`SELECT crdb_internal.request_job_execution_details_sync($1, '0s')`, importJobID)
 	})
 }
 
@@ -187,10 +1180,54 @@ func listExecutionDetails(
 ) []string {
 	t.Helper()
 
+	edResp := listExecutionDetailsResponse(t, s, jobID, "" /* label */)
+	sort.Slice(edResp.Files, func(i, j int) bool {
+		return edResp.Files[i] < edResp.Files[j]
+	})
+	requireListedArtifactTypes(t, edResp.Files)
+	return edResp.Files
+}
+
+// requireListedArtifactTypes asserts that each of the given execution detail
+// files matches the name pattern of one of the artifact types described by
+// sql.ExecutionDetailArtifactTypes.
+func requireListedArtifactTypes(t *testing.T, files []string) {
+	t.Helper()
+
+	placeholder := regexp.MustCompile(`<[a-z]+>`)
+	var patterns []*regexp.Regexp
+	for _, typ := range sql.ExecutionDetailArtifactTypes() {
+		pattern := placeholder.ReplaceAllString(regexp.QuoteMeta(typ.NamePattern), `.+`)
+		patterns = append(patterns, regexp.MustCompile(`^`+pattern+`$`))
+	}
+	for _, f := range files {
+		name := strings.TrimPrefix(f, profilerconstants.ExecutionDetailsChunkKeyPrefix)
+		var listed bool
+		for _, pattern := range patterns {
+			if pattern.MatchString(name) {
+				listed = true
+				break
+			}
+		}
+		require.True(t, listed, "execution detail %s has no listed artifact type", f)
+	}
+}
+
+// listExecutionDetailsResponse returns the response of listing the execution
+// details of the given job, only those collected with the given label if it
+// is set.
+func listExecutionDetailsResponse(
+	t *testing.T, s serverutils.TestServerInterface, jobID jobspb.JobID, label string,
+) *serverpb.ListJobProfilerExecutionDetailsResponse {
+	t.Helper()
+
 	client, err := s.GetAdminHTTPClient()
 	require.NoError(t, err)
 
 	url := s.AdminURL().String() + fmt.Sprintf("/_status/list_job_profiler_execution_details/%d", jobID)
+	if label != "" {
+		url += "?label=" + neturl.QueryEscape(label)
+	}
 	req, err := http.NewRequest("GET", url, nil)
 	require.NoError(t, err)
 
@@ -202,12 +1239,9 @@ func listExecutionDetails(
 	require.NoError(t, err)
 	require.Equal(t, http.StatusOK, resp.StatusCode)
 
-	edResp := serverpb.ListJobProfilerExecutionDetailsResponse{}
-	require.NoError(t, protoutil.Unmarshal(body, &edResp))
-	sort.Slice(edResp.Files, func(i, j int) bool {
-		return edResp.Files[i] < edResp.Files[j]
-	})
-	return edResp.Files
+	edResp := &serverpb.ListJobProfilerExecutionDetailsResponse{}
+	require.NoError(t, protoutil.Unmarshal(body, edResp))
+	return edResp
 }
 
 func checkExecutionDetails(
@@ -239,3 +1273,53 @@ func checkExecutionDetails(
 	require.NotEmpty(t, data)
 	return data
 }
+
+// getExecutionDetailsRange requests the given byte range of an execution
+// detail, returning the response and the bytes read from it.
+func getExecutionDetailsRange(
+	t *testing.T,
+	s serverutils.TestServerInterface,
+	jobID jobspb.JobID,
+	filename string,
+	byteRange string,
+) (*http.Response, []byte) {
+	t.Helper()
+
+	client, err := s.GetAdminHTTPClient()
+	require.NoError(t, err)
+
+	url := s.AdminURL().String() + fmt.Sprintf("/_status/job_profiler_execution_details/%d/%s", jobID, filename)
+	req, err := http.NewRequest("GET", url, nil)
+	require.NoError(t, err)
+
+	req.Header.Set("Range", byteRange)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, body
+}
+
+// registerDescriptorRecordingImportResumer registers a fake IMPORT resumer
+// which records the given table in the payload of the job, as IMPORT does,
+// once the returned flag is set. Jobs resumed before then don't record any
+// descriptors.
+func registerDescriptorRecordingImportResumer(tableID descpb.ID) *atomic.Bool {
+	recordDescriptors := &atomic.Bool{}
+	jobs.RegisterConstructor(jobspb.TypeImport, func(j *jobs.Job, _ *cluster.Settings) jobs.Resumer {
+		return fakeExecResumer{
+			OnResume: func(ctx context.Context) error {
+				if !recordDescriptors.Load() {
+					return nil
+				}
+				return j.NoTxn().Update(ctx, func(_ isql.Txn, md jobs.JobMetadata, ju *jobs.JobUpdater) error {
+					md.Payload.DescriptorIDs = []descpb.ID{tableID}
+					ju.UpdatePayload(md.Payload)
+					return nil
+				})
+			},
+		}
+	}, jobs.UsesTenantCostControl)
+	return recordDescriptors
+}
@@ -0,0 +1,98 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/log/eventpb"
+	"github.com/cockroachdb/errors"
+)
+
+type onCommitDropTables interface {
+	// addOnCommitDropTable adds a temporary table to the set of tables to be
+	// dropped when the current transaction commits.
+	addOnCommitDropTable(id descpb.ID) error
+}
+
+type connExOnCommitDropTablesAccessor struct {
+	ex *connExecutor
+}
+
+func (c connExOnCommitDropTablesAccessor) addOnCommitDropTable(id descpb.ID) error {
+	c.ex.extraTxnState.onCommitDropTables = append(c.ex.extraTxnState.onCommitDropTables, id)
+	return nil
+}
+
+// emptyOnCommitDropTables is the default impl used by the planner when the
+// connExecutor is not available.
+type emptyOnCommitDropTables struct{}
+
+func (emptyOnCommitDropTables) addOnCommitDropTable(id descpb.ID) error {
+	return pgerror.New(pgcode.FeatureNotSupported,
+		"ON COMMIT DROP is not supported outside of a SQL session")
+}
+
+// dropOnCommitTables drops the temporary tables created with ON COMMIT DROP in
+// the current transaction, as part of the transaction. It must be called
+// before the jobs of the transaction are created, since dropping a table
+// queues a schema change job to clean up its data. Tables which were already
+// dropped by the transaction are skipped.
+func (ex *connExecutor) dropOnCommitTables(ctx context.Context) error {
+	if len(ex.extraTxnState.onCommitDropTables) == 0 {
+		return nil
+	}
+	p := &ex.planner
+	for _, id := range ex.extraTxnState.onCommitDropTables {
+		tableDesc, err := p.Descriptors().MutableByID(p.Txn()).Table(ctx, id)
+		if err != nil {
+			if errors.Is(err, catalog.ErrDescriptorNotFound) || catalog.HasInactiveDescriptorError(err) {
+				continue
+			}
+			return err
+		}
+		if tableDesc.Dropped() {
+			continue
+		}
+		tn, err := p.getQualifiedTableName(ctx, tableDesc)
+		if err != nil {
+			return err
+		}
+		// As in Postgres, the objects depending on the table are dropped along
+		// with it.
+		stmt := &tree.DropTable{Names: tree.TableNames{*tn}, DropBehavior: tree.DropCascade}
+		droppedViews, err := p.dropTableImpl(
+			ctx,
+			tableDesc,
+			false, /* droppingParent */
+			tree.AsStringWithFQNames(stmt, p.Ann()),
+			stmt.DropBehavior,
+		)
+		if err != nil {
+			return err
+		}
+		if err := p.logEvent(ctx,
+			tableDesc.ID,
+			&eventpb.DropTable{
+				TableName:           tn.FQString(),
+				CascadeDroppedViews: droppedViews,
+			}); err != nil {
+			return err
+		}
+	}
+	ex.extraTxnState.onCommitDropTables = nil
+	return nil
+}
@@ -12,6 +12,8 @@ package execbuilder
 
 import (
 	"bytes"
+	"fmt"
+	"sort"
 
 	"github.com/cockroachdb/cockroach/pkg/server/telemetry"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
@@ -47,10 +49,71 @@ func (b *Builder) buildCreateTable(ct *memo.CreateTableExpr) (execPlan, error) {
 	if err != nil {
 		return execPlan{}, err
 	}
-	root, err := b.factory.ConstructCreateTableAs(input.root, schema, ct.Syntax)
+	sourceIDs, sourceDescs, err := b.createTableAsSources()
+	if err != nil {
+		return execPlan{}, err
+	}
+	root, err := b.factory.ConstructCreateTableAs(
+		input.root, schema, ct.Syntax, ct.Inline, ct.InlineRequested,
+		ct.Input.Relational().Statistics().RowCount,
+		sourceIDs, sourceDescs,
+	)
 	return execPlan{root: root}, err
 }
 
+// createTableAsSources returns the IDs of the tables and views read by the
+// source query of a CREATE TABLE AS statement, as well as descriptions of the
+// sources which aren't relations with a stable ID, i.e. statement sources such
+// as [SHOW TABLES] and virtual tables. They are recorded as the provenance of
+// the new table.
+func (b *Builder) createTableAsSources() (ids []cat.StableID, descs []string, _ error) {
+	md := b.mem.Metadata()
+	seenIDs := make(map[cat.StableID]struct{})
+	addID := func(id cat.StableID) {
+		if _, ok := seenIDs[id]; !ok {
+			seenIDs[id] = struct{}{}
+			ids = append(ids, id)
+		}
+	}
+	seenDescs := make(map[string]struct{})
+	addDesc := func(desc string) {
+		if _, ok := seenDescs[desc]; !ok {
+			seenDescs[desc] = struct{}{}
+			descs = append(descs, desc)
+		}
+	}
+	for _, stmt := range md.AllStatementSources() {
+		addDesc(fmt.Sprintf("statement [%s]", tree.AsString(stmt)))
+	}
+	for _, tabMeta := range md.AllTables() {
+		tab := tabMeta.Table
+		if !tab.IsVirtualTable() {
+			addID(tab.ID())
+			continue
+		}
+		// Virtual tables don't have a stable ID, so they are described by their
+		// schema-qualified name instead.
+		name := string(tab.Name())
+		if b.catalog != nil {
+			tn, err := b.catalog.FullyQualifiedName(b.ctx, tab)
+			if err != nil {
+				return nil, nil, err
+			}
+			name = fmt.Sprintf("%s.%s", tn.Schema(), tn.Object())
+		}
+		addDesc(fmt.Sprintf("virtual table %s", name))
+	}
+	for _, view := range md.AllViews() {
+		if view.IsSystemView() {
+			addDesc(fmt.Sprintf("system view %s", view.Name()))
+			continue
+		}
+		addID(view.ID())
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, descs, nil
+}
+
 func (b *Builder) buildCreateView(cv *memo.CreateViewExpr) (execPlan, error) {
 	md := b.mem.Metadata()
 	schema := md.Schema(cv.Schema)
@@ -71,6 +134,7 @@ func (b *Builder) buildCreateView(cv *memo.CreateViewExpr) (execPlan, error) {
 		cv.Deps,
 		cv.TypeDeps,
 		cv.WithData,
+		cv.RefreshSchedule,
 	)
 	return execPlan{root: root}, err
 }
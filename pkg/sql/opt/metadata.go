@@ -108,6 +108,11 @@ type Metadata struct {
 	// needed for EXPLAIN (opt, env).
 	views []cat.View
 
+	// statementSources stores the statements used as data sources with the
+	// [ ... ] syntax. This information is only needed for the provenance of
+	// tables created with CREATE TABLE AS.
+	statementSources []tree.Statement
+
 	// currUniqueID is the highest UniqueID that has been assigned.
 	currUniqueID UniqueID
 
@@ -170,6 +175,11 @@ func (md *Metadata) Init() {
 		views[i] = nil
 	}
 
+	statementSources := md.statementSources
+	for i := range statementSources {
+		statementSources[i] = nil
+	}
+
 	dataSourceDeps := md.dataSourceDeps
 	if dataSourceDeps == nil {
 		dataSourceDeps = make(map[cat.StableID]cat.DataSource)
@@ -218,6 +228,7 @@ func (md *Metadata) Init() {
 	md.tables = tables[:0]
 	md.sequences = sequences[:0]
 	md.views = views[:0]
+	md.statementSources = statementSources[:0]
 	md.dataSourceDeps = dataSourceDeps
 	md.udfDeps = udfDeps
 	md.objectRefsByName = objectRefsByName
@@ -235,10 +246,10 @@ func (md *Metadata) Init() {
 // expression.
 func (md *Metadata) CopyFrom(from *Metadata, copyScalarFn func(Expr) Expr) {
 	if len(md.schemas) != 0 || len(md.cols) != 0 || len(md.tables) != 0 ||
-		len(md.sequences) != 0 || len(md.views) != 0 || len(md.userDefinedTypes) != 0 ||
-		len(md.userDefinedTypesSlice) != 0 || len(md.dataSourceDeps) != 0 ||
-		len(md.udfDeps) != 0 || len(md.objectRefsByName) != 0 || len(md.privileges) != 0 ||
-		len(md.builtinRefsByName) != 0 {
+		len(md.sequences) != 0 || len(md.views) != 0 || len(md.statementSources) != 0 ||
+		len(md.userDefinedTypes) != 0 || len(md.userDefinedTypesSlice) != 0 ||
+		len(md.dataSourceDeps) != 0 || len(md.udfDeps) != 0 || len(md.objectRefsByName) != 0 ||
+		len(md.privileges) != 0 || len(md.builtinRefsByName) != 0 {
 		panic(errors.AssertionFailedf("CopyFrom requires empty destination"))
 	}
 	md.schemas = append(md.schemas, from.schemas...)
@@ -313,6 +324,7 @@ func (md *Metadata) CopyFrom(from *Metadata, copyScalarFn func(Expr) Expr) {
 
 	md.sequences = append(md.sequences, from.sequences...)
 	md.views = append(md.views, from.views...)
+	md.statementSources = append(md.statementSources, from.statementSources...)
 	md.currUniqueID = from.currUniqueID
 
 	// We cannot copy the bound expressions; they must be rebuilt in the new memo.
@@ -911,6 +923,18 @@ func (md *Metadata) AllViews() []cat.View {
 	return md.views
 }
 
+// AddStatementSource adds a new reference to a statement used as a data source
+// with the [ ... ] syntax.
+func (md *Metadata) AddStatementSource(stmt tree.Statement) {
+	md.statementSources = append(md.statementSources, stmt)
+}
+
+// AllStatementSources returns all statements used as data sources. The result
+// must not be modified.
+func (md *Metadata) AllStatementSources() []tree.Statement {
+	return md.statementSources
+}
+
 // getAllReferencedTables returns all the tables referenced by the metadata.
 // This includes all tables that are directly stored in the metadata in
 // md.tables, as well as recursive references from foreign keys. The tables are
@@ -45,6 +45,7 @@ func TestMetadata(t *testing.T) {
 	tabID := md.AddTable(&testcat.Table{}, &tree.TableName{})
 	seqID := md.AddSequence(&testcat.Sequence{})
 	md.AddView(&testcat.View{})
+	md.AddStatementSource(&tree.ShowDatabases{})
 	md.AddUserDefinedType(types.MakeEnum(152100, 154180), nil /* name */)
 
 	// Call Init and add objects from catalog, verifying that IDs have been reset.
@@ -96,6 +97,11 @@ func TestMetadata(t *testing.T) {
 		t.Fatalf("unexpected views")
 	}
 
+	md.AddStatementSource(&tree.ShowTables{})
+	if len(md.AllStatementSources()) != 1 {
+		t.Fatalf("unexpected statement sources")
+	}
+
 	md.AddUserDefinedType(types.MakeEnum(151500, 152510), nil /* name */)
 	if len(md.AllUserDefinedTypes()) != 1 {
 		fmt.Println(md)
@@ -168,6 +174,12 @@ func TestMetadata(t *testing.T) {
 		t.Fatalf("unexpected view")
 	}
 
+	if s := mdNew.AllStatementSources(); len(s) != 1 {
+		t.Fatalf("unexpected statement sources")
+	} else if _, ok := s[0].(*tree.ShowTables); !ok {
+		t.Fatalf("unexpected statement source %T", s[0])
+	}
+
 	if ts := mdNew.AllUserDefinedTypes(); len(ts) != 1 && ts[151500].Equal(types.MakeEnum(151500, 152510)) {
 		t.Fatalf("unexpected type")
 	}
@@ -13,6 +13,9 @@ package optbuilder
 import (
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/memo"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/props/physical"
+	"github.com/cockroachdb/cockroach/pkg/sql/paramparse"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/builtins/builtinsregistry"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/catconstants"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
@@ -52,6 +55,7 @@ func (b *Builder) buildCreateTable(ct *tree.CreateTable, inScope *scope) (outSco
 
 	var input memo.RelExpr
 	var inputCols physical.Presentation
+	var inline, inlineRequested bool
 	if ct.As() {
 		// The execution code might need to stringify the query to run it
 		// asynchronously. For that we need the data sources to be fully qualified.
@@ -65,9 +69,32 @@ func (b *Builder) buildCreateTable(ct *tree.CreateTable, inScope *scope) (outSco
 		// Build the input query.
 		outScope = b.buildStmtAtRoot(ct.AsSource, nil /* desiredTypes */)
 
+		// Unless the table is populated inline, the input query is re-planned
+		// and executed by the schema change job which populates the table,
+		// outside of the user's transaction. A data-modifying statement, e.g.
+		// in a WITH clause, would therefore not be atomic with the table
+		// creation and could be applied more than once if the job is retried.
+		//
+		// Data-modifying statement sources, e.g. [DELETE ... RETURNING], return
+		// the rows which populate the table, so the table is populated within
+		// the statement's transaction instead, from the rows returned by the
+		// plan built here, and the source is executed exactly once. Other
+		// data-modifying sources are rejected regardless of the inline storage
+		// parameter, which is only honored at execution time if the source is
+		// estimated to be small enough. Read-only statement sources, e.g. [SHOW INDEXES FROM t], are
+		// re-planned by the job like any other source, which streams their rows
+		// as of the timestamp at which the table was created.
+		mutates := outScope.expr.Relational().CanMutate
+		if mutates && len(b.factory.Metadata().AllStatementSources()) == 0 {
+			panic(pgerror.Newf(pgcode.FeatureNotSupported,
+				"CREATE TABLE AS does not support data-modifying statements in its source query"))
+		}
+
+		// Columns declared with a DEFAULT expression aren't populated by the
+		// input query, so they don't count towards its columns.
 		numColNames := 0
 		for i := 0; i < len(ct.Defs); i++ {
-			if _, ok := ct.Defs[i].(*tree.ColumnTableDef); ok {
+			if d, ok := ct.Defs[i].(*tree.ColumnTableDef); ok && !d.IsCreateAsDeclared() {
 				numColNames++
 			}
 		}
@@ -94,6 +121,8 @@ func (b *Builder) buildCreateTable(ct *tree.CreateTable, inScope *scope) (outSco
 			input = b.factory.CustomFuncs().ProjectExtraCol(outScope.expr, fn, scopeCol.id)
 		}
 		inputCols = outScope.makePhysicalProps().Presentation
+		inline = mutates
+		inlineRequested = b.buildCreateTableAsInline(ct)
 	} else {
 		// Create dummy empty input.
 		input = b.factory.ConstructZeroValues()
@@ -103,10 +132,34 @@ func (b *Builder) buildCreateTable(ct *tree.CreateTable, inScope *scope) (outSco
 	outScope.expr = b.factory.ConstructCreateTable(
 		input,
 		&memo.CreateTablePrivate{
-			Schema:    schID,
-			InputCols: inputCols,
-			Syntax:    ct,
+			Schema:          schID,
+			InputCols:       inputCols,
+			Syntax:          ct,
+			Inline:          inline,
+			InlineRequested: inlineRequested,
 		},
 	)
 	return outScope
 }
+
+// buildCreateTableAsInline returns true if the inline storage parameter was
+// set on a CREATE TABLE AS statement. Whether the table is then populated
+// within the statement's transaction depends on the estimated number of rows
+// produced by its source query, which is compared against the
+// sql.create_table_as.inline_row_limit cluster setting at execution time.
+func (b *Builder) buildCreateTableAsInline(ct *tree.CreateTable) bool {
+	value := ct.StorageParams.GetVal(tree.CreateTableAsInlineStorageParam)
+	if value == nil {
+		return false
+	}
+	expr := paramparse.UnresolvedNameToStrVal(value)
+	typedExpr, err := tree.TypeCheck(b.ctx, expr, b.semaCtx, types.Any)
+	if err != nil {
+		panic(err)
+	}
+	inline, err := paramparse.DatumAsBool(b.ctx, b.evalCtx, tree.CreateTableAsInlineStorageParam, typedExpr)
+	if err != nil {
+		panic(err)
+	}
+	return inline
+}
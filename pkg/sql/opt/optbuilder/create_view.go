@@ -11,9 +11,16 @@
 package optbuilder
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/memo"
+	"github.com/cockroachdb/cockroach/pkg/sql/paramparse"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlerrors"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/cockroachdb/cockroach/pkg/util/intsets"
 	"github.com/cockroachdb/errors"
@@ -21,6 +28,8 @@ import (
 
 func (b *Builder) buildCreateView(cv *tree.CreateView, inScope *scope) (outScope *scope) {
 	b.DisableMemoReuse = true
+	refreshSchedule := b.buildMaterializedViewRefreshSchedule(cv)
+
 	sch, resName := b.resolveSchemaForCreateTable(&cv.Name)
 	schID := b.factory.Metadata().AddSchema(sch)
 	viewName := tree.MakeTableNameFromPrefix(resName, tree.Name(cv.Name.Object()))
@@ -106,18 +115,55 @@ func (b *Builder) buildCreateView(cv *tree.CreateView, inScope *scope) (outScope
 	outScope = b.allocScope()
 	outScope.expr = b.factory.ConstructCreateView(
 		&memo.CreateViewPrivate{
-			Schema:       schID,
-			ViewName:     &viewName,
-			IfNotExists:  cv.IfNotExists,
-			Replace:      cv.Replace,
-			Persistence:  cv.Persistence,
-			Materialized: cv.Materialized,
-			ViewQuery:    tree.AsStringWithFlags(cv.AsSource, tree.FmtParsable),
-			Columns:      p,
-			Deps:         b.schemaDeps,
-			TypeDeps:     b.schemaTypeDeps,
-			WithData:     cv.WithData,
+			Schema:          schID,
+			ViewName:        &viewName,
+			IfNotExists:     cv.IfNotExists,
+			Replace:         cv.Replace,
+			Persistence:     cv.Persistence,
+			Materialized:    cv.Materialized,
+			ViewQuery:       tree.AsStringWithFlags(cv.AsSource, tree.FmtParsable),
+			Columns:         p,
+			Deps:            b.schemaDeps,
+			TypeDeps:        b.schemaTypeDeps,
+			WithData:        cv.WithData,
+			RefreshSchedule: refreshSchedule,
 		},
 	)
 	return outScope
 }
+
+// buildMaterializedViewRefreshSchedule validates the storage parameters of a
+// CREATE MATERIALIZED VIEW statement, and returns the schedule expression on
+// which the view should be refreshed. An empty string is returned if the view
+// should not be refreshed periodically.
+func (b *Builder) buildMaterializedViewRefreshSchedule(cv *tree.CreateView) string {
+	var refreshSchedule string
+	for _, param := range cv.StorageParams {
+		key := string(param.Key)
+		if param.Value == nil {
+			panic(pgerror.Newf(pgcode.InvalidParameterValue,
+				"storage parameter %q requires a value", key))
+		}
+		switch key {
+		case "refresh_interval":
+			expr := paramparse.UnresolvedNameToStrVal(param.Value)
+			typedExpr, err := tree.TypeCheck(b.ctx, expr, b.semaCtx, types.Any)
+			if err != nil {
+				panic(err)
+			}
+			interval, err := paramparse.DatumAsDuration(b.ctx, b.evalCtx, key, typedExpr)
+			if err != nil {
+				panic(err)
+			}
+			if interval < time.Second {
+				panic(pgerror.Newf(pgcode.InvalidParameterValue,
+					"value of %q must be at least 1 second", key))
+			}
+			refreshSchedule = fmt.Sprintf("@every %s", interval)
+		default:
+			panic(pgerror.Newf(pgcode.InvalidParameterValue,
+				"invalid storage parameter %q", key))
+		}
+	}
+	return refreshSchedule
+}
@@ -1814,7 +1814,14 @@ func (ef *execFactory) ConstructCreateTable(
 
 // ConstructCreateTableAs is part of the exec.Factory interface.
 func (ef *execFactory) ConstructCreateTableAs(
-	input exec.Node, schema cat.Schema, ct *tree.CreateTable,
+	input exec.Node,
+	schema cat.Schema,
+	ct *tree.CreateTable,
+	inline bool,
+	inlineRequested bool,
+	sourceRowCount float64,
+	sourceIDs []cat.StableID,
+	sourceDescriptions []string,
 ) (exec.Node, error) {
 	if err := checkSchemaChangeEnabled(
 		ef.ctx,
@@ -1824,10 +1831,20 @@ func (ef *execFactory) ConstructCreateTableAs(
 		return nil, err
 	}
 
+	provenance := descpb.TableDescriptor_CreateAsProvenance{
+		SourceDescriptions: sourceDescriptions,
+	}
+	for _, id := range sourceIDs {
+		provenance.SourceIDs = append(provenance.SourceIDs, descpb.ID(id))
+	}
 	return &createTableNode{
-		n:          ct,
-		dbDesc:     schema.(*optSchema).database,
-		sourcePlan: input.(planNode),
+		n:               ct,
+		dbDesc:          schema.(*optSchema).database,
+		sourcePlan:      input.(planNode),
+		sourceRowCount:  sourceRowCount,
+		inline:          inline,
+		inlineRequested: inlineRequested,
+		provenance:      provenance,
 	}, nil
 }
 
@@ -1844,6 +1861,7 @@ func (ef *execFactory) ConstructCreateView(
 	deps opt.SchemaDeps,
 	typeDeps opt.SchemaTypeDeps,
 	withData bool,
+	refreshSchedule string,
 ) (exec.Node, error) {
 
 	if err := checkSchemaChangeEnabled(
@@ -1860,17 +1878,18 @@ func (ef *execFactory) ConstructCreateView(
 	}
 
 	return &createViewNode{
-		viewName:     viewName,
-		ifNotExists:  ifNotExists,
-		replace:      replace,
-		materialized: materialized,
-		persistence:  persistence,
-		viewQuery:    viewQuery,
-		dbDesc:       schema.(*optSchema).database,
-		columns:      columns,
-		planDeps:     planDeps,
-		typeDeps:     typeDepSet,
-		withData:     withData,
+		viewName:        viewName,
+		ifNotExists:     ifNotExists,
+		replace:         replace,
+		materialized:    materialized,
+		persistence:     persistence,
+		viewQuery:       viewQuery,
+		dbDesc:          schema.(*optSchema).database,
+		columns:         columns,
+		planDeps:        planDeps,
+		typeDeps:        typeDepSet,
+		withData:        withData,
+		refreshSchedule: refreshSchedule,
 	}, nil
 }
 
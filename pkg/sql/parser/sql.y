@@ -9305,6 +9305,9 @@ alter_schema_stmt:
 create_table_stmt:
   CREATE opt_persistence_temp_table TABLE table_name '(' opt_table_elem_list ')' opt_create_table_inherits opt_partition_by_table opt_table_with opt_create_table_on_commit opt_locality
   {
+    if $11.createTableOnCommitSetting() == tree.CreateTableOnCommitDrop {
+      return unimplementedWithIssueDetail(sqllex, 46556, "drop")
+    }
     name := $4.unresolvedObjectName().ToTableName()
     $$.val = &tree.CreateTable{
       Table: name,
@@ -9320,6 +9323,9 @@ create_table_stmt:
   }
 | CREATE opt_persistence_temp_table TABLE IF NOT EXISTS table_name '(' opt_table_elem_list ')' opt_create_table_inherits opt_partition_by_table opt_table_with opt_create_table_on_commit opt_locality
   {
+    if $14.createTableOnCommitSetting() == tree.CreateTableOnCommitDrop {
+      return unimplementedWithIssueDetail(sqllex, 46556, "drop")
+    }
     name := $7.unresolvedObjectName().ToTableName()
     $$.val = &tree.CreateTable{
       Table: name,
@@ -9389,9 +9395,9 @@ opt_create_table_on_commit:
   {
     return unimplementedWithIssueDetail(sqllex, 46556, "delete rows")
   }
-| ON COMMIT DROP error
+| ON COMMIT DROP
   {
-    return unimplementedWithIssueDetail(sqllex, 46556, "drop")
+    $$.val = tree.CreateTableOnCommitDrop
   }
 
 storage_parameter_key:
@@ -9451,6 +9457,58 @@ create_table_as_stmt:
       Persistence: $2.persistence(),
     }
   }
+| CREATE opt_persistence_temp_table TABLE table_name '(' opt_table_elem_list ')' opt_create_table_inherits opt_partition_by_table opt_table_with AS select_stmt opt_create_as_data opt_create_table_on_commit
+  {
+    // The table definitions of CREATE TABLE AS which don't name any column
+    // populated by the source query can't be told apart from those of a
+    // regular CREATE TABLE statement until AS is reached, so they are parsed
+    // alike.
+    if len($6.tblDefs()) == 0 {
+      sqllex.Error("CREATE TABLE AS requires column definitions within parentheses")
+      return 1
+    }
+    if $9.partitionByTable() != nil {
+      sqllex.Error("PARTITION BY is not supported by CREATE TABLE AS")
+      return 1
+    }
+    if err := tree.ValidateCreateAsTableDefs($6.tblDefs()); err != nil {
+      return setErr(sqllex, err)
+    }
+    name := $4.unresolvedObjectName().ToTableName()
+    $$.val = &tree.CreateTable{
+      Table: name,
+      IfNotExists: false,
+      Defs: $6.tblDefs(),
+      AsSource: $12.slct(),
+      StorageParams: $10.storageParams(),
+      OnCommit: $14.createTableOnCommitSetting(),
+      Persistence: $2.persistence(),
+    }
+  }
+| CREATE opt_persistence_temp_table TABLE IF NOT EXISTS table_name '(' opt_table_elem_list ')' opt_create_table_inherits opt_partition_by_table opt_table_with AS select_stmt opt_create_as_data opt_create_table_on_commit
+  {
+    if len($9.tblDefs()) == 0 {
+      sqllex.Error("CREATE TABLE AS requires column definitions within parentheses")
+      return 1
+    }
+    if $12.partitionByTable() != nil {
+      sqllex.Error("PARTITION BY is not supported by CREATE TABLE AS")
+      return 1
+    }
+    if err := tree.ValidateCreateAsTableDefs($9.tblDefs()); err != nil {
+      return setErr(sqllex, err)
+    }
+    name := $7.unresolvedObjectName().ToTableName()
+    $$.val = &tree.CreateTable{
+      Table: name,
+      IfNotExists: true,
+      Defs: $9.tblDefs(),
+      AsSource: $15.slct(),
+      StorageParams: $13.storageParams(),
+      OnCommit: $17.createTableOnCommitSetting(),
+      Persistence: $2.persistence(),
+    }
+  }
 
 opt_create_as_data:
   /* EMPTY */  { /* no error */ }
@@ -10028,6 +10086,28 @@ create_as_table_defs:
     var colToTableDef tree.TableDef = tableDef
     $$.val = tree.TableDefs{colToTableDef}
   }
+| table_elem_list ',' column_name create_as_col_qual_list
+  {
+    // The leading definitions are parsed as those of a regular CREATE TABLE
+    // statement, since they can't be told apart until a column populated by
+    // the source query is named.
+    if err := tree.ValidateCreateAsTableDefs($1.tblDefs()); err != nil {
+      return setErr(sqllex, err)
+    }
+    for _, def := range $1.tblDefs() {
+      if _, ok := def.(*tree.LikeTableDef); ok {
+        return setErr(sqllex, pgerror.New(pgcode.FeatureNotSupported,
+          "LIKE must be the only table definition of CREATE TABLE AS"))
+      }
+    }
+    tableDef, err := tree.NewColumnTableDef(tree.Name($3), nil, false, $4.colQuals())
+    if err != nil {
+      return setErr(sqllex, err)
+    }
+
+    var colToTableDef tree.TableDef = tableDef
+    $$.val = append($1.tblDefs(), colToTableDef)
+  }
 | create_as_table_defs ',' column_name create_as_col_qual_list
   {
     tableDef, err := tree.NewColumnTableDef(tree.Name($3), nil, false, $4.colQuals())
@@ -10037,6 +10117,16 @@ create_as_table_defs:
 
     var colToTableDef tree.TableDef = tableDef
 
+    $$.val = append($1.tblDefs(), colToTableDef)
+  }
+| create_as_table_defs ',' column_table_def
+  {
+    tableDef := $3.colTableDef()
+    if err := tree.ValidateCreateAsDeclaredColumn(tableDef); err != nil {
+      return setErr(sqllex, err)
+    }
+
+    var colToTableDef tree.TableDef = tableDef
     $$.val = append($1.tblDefs(), colToTableDef)
   }
 | create_as_table_defs ',' family_def
@@ -10494,7 +10584,7 @@ role_or_group_or_user:
 // %Category: DDL
 // %Text:
 // CREATE [TEMPORARY | TEMP] VIEW [IF NOT EXISTS] <viewname> [( <colnames...> )] AS <source>
-// CREATE [TEMPORARY | TEMP] MATERIALIZED VIEW [IF NOT EXISTS] <viewname> [( <colnames...> )] AS <source> [WITH [NO] DATA]
+// CREATE [TEMPORARY | TEMP] MATERIALIZED VIEW [IF NOT EXISTS] <viewname> [( <colnames...> )] AS <source> [WITH ( <opt> = <value> [, ...] )] [WITH [NO] DATA]
 // %SeeAlso: CREATE TABLE, SHOW CREATE, WEBDOCS/create-view.html
 create_view_stmt:
   CREATE opt_temp opt_view_recursive VIEW view_name opt_column_list AS select_stmt
@@ -10558,6 +10648,31 @@ create_view_stmt:
       WithData: $11.bool(),
     }
   }
+| CREATE MATERIALIZED VIEW view_name opt_column_list AS select_stmt WITH '(' storage_parameter_list ')' opt_with_data
+  {
+    name := $4.unresolvedObjectName().ToTableName()
+    $$.val = &tree.CreateView{
+      Name: name,
+      ColumnNames: $5.nameList(),
+      AsSource: $7.slct(),
+      Materialized: true,
+      StorageParams: $10.storageParams(),
+      WithData: $12.bool(),
+    }
+  }
+| CREATE MATERIALIZED VIEW IF NOT EXISTS view_name opt_column_list AS select_stmt WITH '(' storage_parameter_list ')' opt_with_data
+  {
+    name := $7.unresolvedObjectName().ToTableName()
+    $$.val = &tree.CreateView{
+      Name: name,
+      ColumnNames: $8.nameList(),
+      AsSource: $10.slct(),
+      Materialized: true,
+      IfNotExists: true,
+      StorageParams: $13.storageParams(),
+      WithData: $15.bool(),
+    }
+  }
 | CREATE opt_temp opt_view_recursive VIEW error // SHOW HELP: CREATE VIEW
 
 opt_with_data:
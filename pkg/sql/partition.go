@@ -32,7 +32,7 @@ func partitionByFromTableDesc(
 // into the subpartitions as required for LIST partitions.
 func partitionByFromTableDescImpl(
 	codec keys.SQLCodec,
-	tableDesc *tabledesc.Mutable,
+	tableDesc catalog.TableDescriptor,
 	idx catalog.Index,
 	part catalog.Partitioning,
 	colOffset int,
@@ -232,6 +232,8 @@ type planner struct {
 
 	createdSequences createdSequences
 
+	onCommitDropTables onCommitDropTables
+
 	// autoCommit indicates whether the plan is allowed (but not required) to
 	// commit the transaction along with other KV operations. Committing the txn
 	// might be beneficial because it may enable the 1PC optimization. Note that
@@ -441,6 +443,7 @@ func newInternalPlanner(
 	p.extendedEvalCtx.JoinTokenCreator = p
 	p.extendedEvalCtx.Gossip = p
 	p.extendedEvalCtx.JobsProfiler = p
+	p.extendedEvalCtx.CatalogBuiltins = &p.evalCatalogBuiltins
 	p.extendedEvalCtx.ClusterID = execCfg.NodeInfo.LogicalClusterID()
 	p.extendedEvalCtx.ClusterName = execCfg.RPCContext.ClusterName()
 	p.extendedEvalCtx.NodeID = execCfg.NodeInfo.NodeID
@@ -461,6 +464,7 @@ func newInternalPlanner(
 	p.sqlCursors = emptySqlCursors{}
 	p.preparedStatements = emptyPreparedStatements{}
 	p.createdSequences = emptyCreatedSequences{}
+	p.onCommitDropTables = emptyOnCommitDropTables{}
 
 	p.schemaResolver.descCollection = p.Descriptors()
 	p.schemaResolver.sessionDataStack = sds
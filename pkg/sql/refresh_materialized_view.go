@@ -14,6 +14,7 @@ import (
 	"context"
 
 	"github.com/cockroachdb/cockroach/pkg/server/telemetry"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/tabledesc"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
@@ -21,6 +22,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgnotice"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqltelemetry"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 )
 
 type refreshMaterializedViewNode struct {
@@ -40,11 +42,8 @@ func (p *planner) RefreshMaterializedView(
 	}
 	// TODO (rohany): Not sure if this is a real restriction, but let's start with
 	//  it to be safe.
-	for i := range desc.Mutations {
-		mut := &desc.Mutations[i]
-		if mut.GetMaterializedViewRefresh() != nil {
-			return nil, pgerror.Newf(pgcode.ObjectNotInPrerequisiteState, "view is already being refreshed")
-		}
+	if isMaterializedViewRefreshing(desc) {
+		return nil, pgerror.Newf(pgcode.ObjectNotInPrerequisiteState, "view is already being refreshed")
 	}
 
 	// Only the owner or an admin (superuser) can refresh the view.
@@ -91,17 +90,34 @@ func (n *refreshMaterializedViewNode) startExec(params runParams) error {
 		)
 	}
 
+	addMaterializedViewRefreshMutation(
+		n.desc, params.p.Txn().ReadTimestamp(), n.n.RefreshDataOption != tree.RefreshDataClear,
+	)
+
+	return params.p.writeSchemaChange(
+		params.ctx,
+		n.desc,
+		n.desc.ClusterVersion().NextMutationID,
+		tree.AsStringWithFQNames(n.n, params.Ann()),
+	)
+}
+
+// addMaterializedViewRefreshMutation queues a mutation on the descriptor of a
+// materialized view which refreshes the view as of the given timestamp.
+func addMaterializedViewRefreshMutation(
+	desc *tabledesc.Mutable, asOf hlc.Timestamp, shouldBackfill bool,
+) {
 	// Prepare the new set of indexes by cloning all existing indexes on the view.
-	newPrimaryIndex := n.desc.GetPrimaryIndex().IndexDescDeepCopy()
-	newIndexes := make([]descpb.IndexDescriptor, len(n.desc.PublicNonPrimaryIndexes()))
-	for i, idx := range n.desc.PublicNonPrimaryIndexes() {
+	newPrimaryIndex := desc.GetPrimaryIndex().IndexDescDeepCopy()
+	newIndexes := make([]descpb.IndexDescriptor, len(desc.PublicNonPrimaryIndexes()))
+	for i, idx := range desc.PublicNonPrimaryIndexes() {
 		newIndexes[i] = idx.IndexDescDeepCopy()
 	}
 
 	// Reset and allocate new IDs for the new indexes.
 	getID := func() descpb.IndexID {
-		res := n.desc.NextIndexID
-		n.desc.NextIndexID++
+		res := desc.NextIndexID
+		desc.NextIndexID++
 		return res
 	}
 	newPrimaryIndex.ID = getID()
@@ -111,21 +127,25 @@ func (n *refreshMaterializedViewNode) startExec(params runParams) error {
 
 	// Set RefreshViewRequired to false. This will allow SELECT operations on the materialized
 	// view to succeed when the view has been created with the NO DATA option.
-	n.desc.RefreshViewRequired = false
+	desc.RefreshViewRequired = false
 	// Queue the refresh mutation.
-	n.desc.AddMaterializedViewRefreshMutation(&descpb.MaterializedViewRefresh{
+	desc.AddMaterializedViewRefreshMutation(&descpb.MaterializedViewRefresh{
 		NewPrimaryIndex: newPrimaryIndex,
 		NewIndexes:      newIndexes,
-		AsOf:            params.p.Txn().ReadTimestamp(),
-		ShouldBackfill:  n.n.RefreshDataOption != tree.RefreshDataClear,
+		AsOf:            asOf,
+		ShouldBackfill:  shouldBackfill,
 	})
+}
 
-	return params.p.writeSchemaChange(
-		params.ctx,
-		n.desc,
-		n.desc.ClusterVersion().NextMutationID,
-		tree.AsStringWithFQNames(n.n, params.Ann()),
-	)
+// isMaterializedViewRefreshing returns whether a refresh of the materialized
+// view is already in progress.
+func isMaterializedViewRefreshing(desc catalog.TableDescriptor) bool {
+	for _, mut := range desc.AllMutations() {
+		if mut.AsMaterializedViewRefresh() != nil {
+			return true
+		}
+	}
+	return false
 }
 
 func (n *refreshMaterializedViewNode) Next(params runParams) (bool, error) { return false, nil }
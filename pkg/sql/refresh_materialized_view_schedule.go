@@ -0,0 +1,316 @@
+// Copyright 2023 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package sql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/scheduledjobs"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descs"
+	"github.com/cockroachdb/cockroach/pkg/sql/isql"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlerrors"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/cockroachdb/errors"
+	pbtypes "github.com/gogo/protobuf/types"
+)
+
+// materializedViewRefreshScheduleLabel returns the label of the schedule which
+// periodically refreshes the materialized view with the given ID.
+func materializedViewRefreshScheduleLabel(viewID descpb.ID) string {
+	return fmt.Sprintf("materialized-view-refresh-%d", viewID)
+}
+
+// CreateMaterializedViewRefreshSchedule creates a new schedule which refreshes
+// the materialized view with the given ID according to scheduleExpr.
+func CreateMaterializedViewRefreshSchedule(
+	ctx context.Context,
+	knobs *jobs.TestingKnobs,
+	s jobs.ScheduledJobStorage,
+	owner username.SQLUsername,
+	viewID descpb.ID,
+	scheduleExpr string,
+) (*jobs.ScheduledJob, error) {
+	sj := jobs.NewScheduledJob(JobSchedulerEnv(knobs))
+	sj.SetScheduleLabel(materializedViewRefreshScheduleLabel(viewID))
+	sj.SetOwner(owner)
+	sj.SetScheduleDetails(jobspb.ScheduleDetails{
+		// A refresh which overruns the refresh interval must not cause
+		// refreshes to pile up, so runs are skipped while one is in progress.
+		Wait: jobspb.ScheduleDetails_SKIP,
+		// If a refresh fails, try again at the next scheduled time.
+		OnError: jobspb.ScheduleDetails_RETRY_SCHED,
+	})
+	if err := sj.SetSchedule(scheduleExpr); err != nil {
+		return nil, err
+	}
+	args, err := pbtypes.MarshalAny(&catpb.ScheduledMaterializedViewRefreshArgs{
+		ViewID: viewID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sj.SetExecutionDetails(
+		tree.ScheduledMaterializedViewRefreshExecutor.InternalName(),
+		jobspb.ExecutionArguments{Args: args},
+	)
+	if err := s.Create(ctx, sj); err != nil {
+		return nil, err
+	}
+	return sj, nil
+}
+
+type materializedViewRefreshMetrics struct {
+	*jobs.ExecutorMetrics
+}
+
+var _ metric.Struct = &materializedViewRefreshMetrics{}
+
+// MetricStruct implements metric.Struct interface.
+func (m *materializedViewRefreshMetrics) MetricStruct() {}
+
+// scheduledMaterializedViewRefreshExecutor is executed by the scheduledjob
+// subsystem to periodically refresh a materialized view through a schema change
+// job.
+type scheduledMaterializedViewRefreshExecutor struct {
+	metrics materializedViewRefreshMetrics
+}
+
+var _ jobs.ScheduledJobExecutor = &scheduledMaterializedViewRefreshExecutor{}
+var _ jobs.ScheduledJobController = &scheduledMaterializedViewRefreshExecutor{}
+
+// OnDrop implements the jobs.ScheduledJobController interface.
+func (e *scheduledMaterializedViewRefreshExecutor) OnDrop(
+	ctx context.Context,
+	scheduleControllerEnv scheduledjobs.ScheduleControllerEnv,
+	env scheduledjobs.JobSchedulerEnv,
+	schedule *jobs.ScheduledJob,
+	txn isql.Txn,
+	descsCol *descs.Collection,
+) (int, error) {
+	var args catpb.ScheduledMaterializedViewRefreshArgs
+	if err := pbtypes.UnmarshalAny(schedule.ExecutionArgs().Args, &args); err != nil {
+		return 0, err
+	}
+	// The schedule may only be dropped once the view it refreshes is gone.
+	view, err := descsCol.ByID(txn.KV()).Get().Table(ctx, args.ViewID)
+	if err != nil {
+		if isMissingDescriptorError(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if view.Dropped() {
+		return 0, nil
+	}
+	tn, err := descs.GetObjectName(ctx, txn.KV(), descsCol, view)
+	if err != nil {
+		return 0, err
+	}
+	return 0, errors.WithHintf(
+		pgerror.Newf(
+			pgcode.ObjectNotInPrerequisiteState,
+			"cannot drop a materialized view refresh schedule",
+		),
+		`use DROP MATERIALIZED VIEW %s, or PAUSE SCHEDULE %d to stop refreshing the view`,
+		tn.FQString(), schedule.ScheduleID(),
+	)
+}
+
+// ExecuteJob implements the jobs.ScheduledJobExecutor interface.
+func (e *scheduledMaterializedViewRefreshExecutor) ExecuteJob(
+	ctx context.Context,
+	txn isql.Txn,
+	cfg *scheduledjobs.JobExecutionConfig,
+	env scheduledjobs.JobSchedulerEnv,
+	sj *jobs.ScheduledJob,
+) error {
+	args := &catpb.ScheduledMaterializedViewRefreshArgs{}
+	if err := pbtypes.UnmarshalAny(sj.ExecutionArgs().Args, args); err != nil {
+		return err
+	}
+
+	descsCol := descs.FromTxn(txn)
+	view, err := descsCol.ByID(txn.KV()).Get().Table(ctx, args.ViewID)
+	if err != nil && !isMissingDescriptorError(err) {
+		return err
+	}
+	if err != nil || view.Dropped() {
+		// The view was dropped through a path which didn't clean up the refresh
+		// schedule, so the schedule removes itself.
+		log.Infof(ctx, "materialized view %d no longer exists, deleting refresh schedule %d",
+			args.ViewID, sj.ScheduleID())
+		return jobs.ScheduledJobTxn(txn).DeleteByID(ctx, env, sj.ScheduleID())
+	}
+
+	p, cleanup := cfg.PlanHookMaker(
+		ctx,
+		fmt.Sprintf("invoke-materialized-view-refresh-%d", args.ViewID),
+		txn.KV(),
+		username.NodeUserName(),
+	)
+	defer cleanup()
+
+	if err := e.createRefreshJob(ctx, p.(*planner).ExecCfg(), txn, sj, args.ViewID); err != nil {
+		e.metrics.NumFailed.Inc(1)
+		return err
+	}
+	e.metrics.NumStarted.Inc(1)
+	return nil
+}
+
+// createRefreshJob queues a refresh mutation on the materialized view and
+// creates the schema change job which performs it.
+func (e *scheduledMaterializedViewRefreshExecutor) createRefreshJob(
+	ctx context.Context,
+	execCfg *ExecutorConfig,
+	txn isql.Txn,
+	sj *jobs.ScheduledJob,
+	viewID descpb.ID,
+) error {
+	descsCol := descs.FromTxn(txn)
+	desc, err := descsCol.MutableByID(txn.KV()).Table(ctx, viewID)
+	if err != nil {
+		return err
+	}
+	if !desc.MaterializedView() {
+		return errors.AssertionFailedf("descriptor %d is not a materialized view", viewID)
+	}
+	// A refresh may have been started outside of the schedule, in which case
+	// the scheduler won't know about it; skip this run rather than queueing a
+	// second refresh.
+	if isMaterializedViewRefreshing(desc) {
+		sj.SetScheduleStatus("skipped, view is already being refreshed")
+		return nil
+	}
+
+	mutationID := desc.ClusterVersion().NextMutationID
+	addMaterializedViewRefreshMutation(desc, txn.KV().ReadTimestamp(), true /* shouldBackfill */)
+
+	tn, err := descs.GetObjectName(ctx, txn.KV(), descsCol, desc)
+	if err != nil {
+		return err
+	}
+	jobID := execCfg.JobRegistry.MakeJobID()
+	record := jobs.Record{
+		JobID:         jobID,
+		Description:   fmt.Sprintf("REFRESH MATERIALIZED VIEW %s", tn.FQString()),
+		Username:      username.NodeUserName(),
+		DescriptorIDs: descpb.IDs{desc.GetID()},
+		Details: jobspb.SchemaChangeDetails{
+			DescID:          desc.GetID(),
+			TableMutationID: mutationID,
+			ResumeSpanList: []jobspb.ResumeSpanList{{
+				ResumeSpans: []roachpb.Span{desc.PrimaryIndexSpan(execCfg.Codec)},
+			}},
+			// The version distinction for database jobs doesn't matter for jobs on
+			// tables.
+			FormatVersion: jobspb.DatabaseJobFormatVersion,
+		},
+		Progress: jobspb.SchemaChangeProgress{},
+		CreatedBy: &jobs.CreatedByInfo{
+			ID:   sj.ScheduleID(),
+			Name: jobs.CreatedByScheduledJobs,
+		},
+	}
+	desc.MutationJobs = append(desc.MutationJobs, descpb.TableDescriptor_MutationJob{
+		MutationID: mutationID, JobID: jobID,
+	})
+	if err := descsCol.WriteDesc(ctx, false /* kvTrace */, desc, txn.KV()); err != nil {
+		return err
+	}
+	_, err = execCfg.JobRegistry.CreateAdoptableJobWithTxn(ctx, record, jobID, txn)
+	return err
+}
+
+// isMissingDescriptorError returns whether err indicates that a descriptor
+// does not exist.
+func isMissingDescriptorError(err error) bool {
+	return errors.Is(err, catalog.ErrDescriptorNotFound) || sqlerrors.IsUndefinedRelationError(err)
+}
+
+// NotifyJobTermination implements the jobs.ScheduledJobExecutor interface.
+func (e *scheduledMaterializedViewRefreshExecutor) NotifyJobTermination(
+	ctx context.Context,
+	txn isql.Txn,
+	jobID jobspb.JobID,
+	jobStatus jobs.Status,
+	details jobspb.Details,
+	env scheduledjobs.JobSchedulerEnv,
+	sj *jobs.ScheduledJob,
+) error {
+	if jobStatus == jobs.StatusFailed {
+		jobs.DefaultHandleFailedRun(sj, "materialized view refresh %d failed", jobID)
+		e.metrics.NumFailed.Inc(1)
+		return nil
+	}
+
+	if jobStatus == jobs.StatusSucceeded {
+		e.metrics.NumSucceeded.Inc(1)
+	}
+
+	sj.SetScheduleStatus(string(jobStatus))
+	return nil
+}
+
+// Metrics implements the jobs.ScheduledJobExecutor interface.
+func (e *scheduledMaterializedViewRefreshExecutor) Metrics() metric.Struct {
+	return &e.metrics
+}
+
+// GetCreateScheduleStatement implements the jobs.ScheduledJobExecutor interface.
+func (e *scheduledMaterializedViewRefreshExecutor) GetCreateScheduleStatement(
+	ctx context.Context, txn isql.Txn, env scheduledjobs.JobSchedulerEnv, sj *jobs.ScheduledJob,
+) (string, error) {
+	descsCol := descs.FromTxn(txn)
+	args := &catpb.ScheduledMaterializedViewRefreshArgs{}
+	if err := pbtypes.UnmarshalAny(sj.ExecutionArgs().Args, args); err != nil {
+		return "", err
+	}
+	view, err := descsCol.ByIDWithLeased(txn.KV()).WithoutNonPublic().Get().Table(ctx, args.ViewID)
+	if err != nil {
+		return "", err
+	}
+	tn, err := descs.GetObjectName(ctx, txn.KV(), descsCol, view)
+	if err != nil {
+		return "", err
+	}
+	interval := strings.TrimPrefix(sj.ScheduleExpr(), "@every ")
+	return fmt.Sprintf(
+		`CREATE MATERIALIZED VIEW %s AS %s WITH (refresh_interval = '%s')`,
+		tn.FQString(), view.GetViewQuery(), interval,
+	), nil
+}
+
+func init() {
+	jobs.RegisterScheduledJobExecutorFactory(
+		tree.ScheduledMaterializedViewRefreshExecutor.InternalName(),
+		func() (jobs.ScheduledJobExecutor, error) {
+			m := jobs.MakeExecutorMetrics(tree.ScheduledMaterializedViewRefreshExecutor.InternalName())
+			return &scheduledMaterializedViewRefreshExecutor{
+				metrics: materializedViewRefreshMetrics{
+					ExecutorMetrics: &m,
+				},
+			}, nil
+		})
+}
@@ -231,6 +231,10 @@ type DatumRowConverter struct {
 	CompletedRowFn func() int64
 	FractionFn     func() float32
 
+	// RowFn, if set, is called by Row with the datums of every row, including
+	// its default and computed columns, in the order of InsertColumns.
+	RowFn func(ctx context.Context, row tree.Datums) error
+
 	db *kv.DB
 }
 
@@ -564,6 +568,11 @@ func (c *DatumRowConverter) Row(ctx context.Context, sourceID int32, rowIndex in
 	); err != nil {
 		return errors.Wrap(err, "insert row")
 	}
+	if c.RowFn != nil {
+		if err := c.RowFn(ctx, insertRow); err != nil {
+			return err
+		}
+	}
 	// If our batch is full, flush it and start a new one.
 	if len(c.KvBatch.KVs) >= kvDatumRowConverterBatchSize || c.KvBatch.MemSize > kvDatumRowConverterBatchMemSize {
 		if err := c.SendBatch(ctx); err != nil {
@@ -573,6 +582,11 @@ func (c *DatumRowConverter) Row(ctx context.Context, sourceID int32, rowIndex in
 	return nil
 }
 
+// InsertColumns returns the columns of the rows passed to RowFn.
+func (c *DatumRowConverter) InsertColumns() []catalog.Column {
+	return c.cols
+}
+
 // SendBatch streams kv operations from the current KvBatch to the destination
 // channel, and resets the KvBatch to empty.
 func (c *DatumRowConverter) SendBatch(ctx context.Context) error {
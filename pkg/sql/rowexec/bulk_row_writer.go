@@ -11,21 +11,35 @@
 package rowexec
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"strconv"
 	"sync/atomic"
+	"time"
 
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverbase"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/isql"
 	"github.com/cockroachdb/cockroach/pkg/sql/row"
 	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlerrors"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/errors"
 )
 
@@ -34,6 +48,70 @@ var CTASPlanResultTypes = []*types.T{
 	types.Bytes, // rows
 }
 
+// CTASProgressInfoKeyPrefix is the prefix of the job info keys under which the
+// bulkRowWriter processors of a backfill record the number of rows they have
+// ingested so far. Every processor writes to its own key, suffixed by its
+// processor ID.
+const CTASProgressInfoKeyPrefix = "~ctas-rows-"
+
+// CTASCheckpointInfoKey is the job info key under which the bulkRowWriter
+// processor of a checkpointed backfill records the key of the row from which
+// the backfill can be resumed, see BulkRowWriterSpec.Checkpoint.
+const CTASCheckpointInfoKey = "~ctas-checkpoint"
+
+// CTASPublishedInfoKeyPrefix is the prefix of the job info keys under which
+// the bulkRowWriter processors of a backfill record the number of rows they
+// have published to the sink of the table, if any, like
+// CTASProgressInfoKeyPrefix.
+const CTASPublishedInfoKeyPrefix = "~ctas-published-"
+
+// CTASProgressInterval is the minimum interval between the progress updates
+// of a CREATE TABLE AS backfill.
+var CTASProgressInterval = settings.RegisterDurationSetting(
+	settings.TenantWritable,
+	"sql.create_table_as.progress_interval",
+	"the minimum interval between progress updates of a CREATE TABLE AS statement "+
+		"which is ingesting rows, including the progress notices sent to the client",
+	10*time.Second,
+	settings.PositiveDuration,
+)
+
+// CreateTableAsSink publishes the rows of the table of a CREATE TABLE AS ...
+// WITH (sink = ...) statement to the sink, in the encoding of a changefeed, as
+// they are ingested by the bulkRowWriter processors of its backfill.
+type CreateTableAsSink interface {
+	// EmitRow enqueues a row of the table, holding the datums of the public
+	// non-virtual columns of the table in order, for delivery to the sink. An
+	// error may be returned if a previously enqueued row failed to be delivered.
+	EmitRow(ctx context.Context, row tree.Datums) error
+	// Flush blocks until every row enqueued by EmitRow has been acknowledged
+	// by the sink.
+	Flush(ctx context.Context) error
+	// Close releases the resources of the sink. It does not guarantee the
+	// delivery of the enqueued rows.
+	Close() error
+}
+
+// NewCreateTableAsSink is implemented in the non-free (CCL) codebase and then
+// injected here via runtime initialization. The sink is dialed before it is
+// returned. The rows published to it are reported as read at ts.
+var NewCreateTableAsSink = func(
+	ctx context.Context,
+	cfg *execinfra.ServerConfig,
+	user username.SQLUsername,
+	sinkURI string,
+	table catalog.TableDescriptor,
+	ts hlc.Timestamp,
+) (CreateTableAsSink, error) {
+	return nil, sqlerrors.NewCCLRequiredError(errors.New(
+		"publishing the rows of CREATE TABLE AS to a sink requires a CCL binary"))
+}
+
+// ErrCreateTableAsSink marks the errors of a bulkRowWriter which failed to
+// publish the rows of the table to its sink, so that the backfill can be
+// retried by its job.
+var ErrCreateTableAsSink = errors.New("failed to publish the rows of CREATE TABLE AS to its sink")
+
 type bulkRowWriter struct {
 	execinfra.ProcessorBase
 	flowCtx        *execinfra.FlowCtx
@@ -43,6 +121,10 @@ type bulkRowWriter struct {
 	spec           execinfrapb.BulkRowWriterSpec
 	input          execinfra.RowSource
 	summary        kvpb.BulkOpSummary
+	// publishes is set when the rows of the table are published to a sink, in
+	// which case publishedAtomic is the number of rows enqueued to it.
+	publishes       bool
+	publishedAtomic int64
 }
 
 var _ execinfra.Processor = &bulkRowWriter{}
@@ -112,6 +194,28 @@ func (sp *bulkRowWriter) work(ctx context.Context) error {
 		panic("uninitialized session data")
 	}
 
+	// The rows of a table with a sink are published as they are converted, so
+	// that a downstream system receives them while the table is populated.
+	var sink CreateTableAsSink
+	if provenance := sp.tableDesc.GetCreateAsProvenance(); provenance != nil && provenance.SinkURI != "" {
+		sink, err = NewCreateTableAsSink(
+			ctx, sp.flowCtx.Cfg, sp.spec.UserProto.Decode(), provenance.SinkURI, sp.tableDesc,
+			sp.spec.Table.CreateAsOfTime,
+		)
+		if err != nil {
+			return errors.Mark(err, ErrCreateTableAsSink)
+		}
+		defer func() {
+			if err := sink.Close(); err != nil {
+				log.Warningf(ctx, "failed to close the sink of CREATE TABLE AS: %v", err)
+			}
+		}()
+		if conv.RowFn, err = sp.makePublishRowFn(sink, conv.InsertColumns()); err != nil {
+			return err
+		}
+		sp.publishes = true
+	}
+
 	g = ctxgroup.WithContext(ctx)
 	g.GoCtx(func(ctx context.Context) error {
 		return sp.ingestLoop(ctx, kvCh)
@@ -119,7 +223,80 @@ func (sp *bulkRowWriter) work(ctx context.Context) error {
 	g.GoCtx(func(ctx context.Context) error {
 		return sp.convertLoop(ctx, kvCh, conv)
 	})
-	return g.Wait()
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	if sink == nil {
+		return nil
+	}
+	if err := sink.Flush(ctx); err != nil {
+		return errors.Mark(err, ErrCreateTableAsSink)
+	}
+	if sp.spec.JobID != 0 {
+		if err := sp.flowCtx.Cfg.DB.Txn(ctx, sp.recordPublished); err != nil {
+			log.Warningf(ctx, "failed to record the rows published by job %d: %v", sp.spec.JobID, err)
+		}
+	}
+	return nil
+}
+
+// makePublishRowFn returns the function which publishes the rows converted by
+// the processor to the sink of the table. The rows are converted with the
+// datums of the insert columns of the table, which are reordered into the
+// public non-virtual columns of the table expected by the sink.
+func (sp *bulkRowWriter) makePublishRowFn(
+	sink CreateTableAsSink, insertCols []catalog.Column,
+) (func(ctx context.Context, row tree.Datums) error, error) {
+	insertOrds := catalog.ColumnIDToOrdinalMap(insertCols)
+	var ords []int
+	for _, col := range sp.tableDesc.PublicColumns() {
+		if col.IsVirtual() {
+			continue
+		}
+		ord, ok := insertOrds.Get(col.GetID())
+		if !ok {
+			return nil, errors.AssertionFailedf("column %q isn't inserted", col.GetName())
+		}
+		ords = append(ords, ord)
+	}
+	datums := make(tree.Datums, len(ords))
+	return func(ctx context.Context, row tree.Datums) error {
+		for i, ord := range ords {
+			datums[i] = row[ord]
+		}
+		if err := sink.EmitRow(ctx, datums); err != nil {
+			return errors.Mark(err, ErrCreateTableAsSink)
+		}
+		atomic.AddInt64(&sp.publishedAtomic, 1)
+		return nil
+	}, nil
+}
+
+// recordPublished records the number of rows published by the processor to
+// the sink of the table in the info storage of the job running the backfill,
+// and sets the running status of the job to the number of rows published by
+// all of its processors.
+func (sp *bulkRowWriter) recordPublished(ctx context.Context, txn isql.Txn) error {
+	infoStorage := jobs.InfoStorageForJob(txn, sp.spec.JobID)
+	infoKey := fmt.Sprintf("%s%d", CTASPublishedInfoKeyPrefix, sp.processorID)
+	published := atomic.LoadInt64(&sp.publishedAtomic)
+	if err := infoStorage.Write(ctx, infoKey, []byte(strconv.FormatInt(published, 10))); err != nil {
+		return err
+	}
+	var total int64
+	if err := infoStorage.Iterate(ctx, CTASPublishedInfoKeyPrefix, func(_ string, value []byte) error {
+		n, err := strconv.ParseInt(string(value), 10, 64)
+		total += n
+		return err
+	}); err != nil {
+		return err
+	}
+	return sp.flowCtx.Cfg.JobRegistry.UpdateJobWithTxn(ctx, sp.spec.JobID, txn, false, /* useReadLock */
+		func(txn isql.Txn, md jobs.JobMetadata, ju *jobs.JobUpdater) error {
+			md.Progress.RunningStatus = fmt.Sprintf("published %d rows to sink", total)
+			ju.UpdateProgress(md.Progress)
+			return nil
+		})
 }
 
 func (sp *bulkRowWriter) wrapDupError(ctx context.Context, orig error) error {
@@ -153,14 +330,48 @@ func (sp *bulkRowWriter) ingestLoop(ctx context.Context, kvCh chan row.KVBatch)
 	}
 	defer adder.Close(ctx)
 
+	// When the backfill is checkpointed, the rows arrive in the order of the
+	// primary index, so lastKey, the last primary index key added to the adder,
+	// is the last key flushed by the adder when it flushes: the adder flushes
+	// its whole buffer, before adding the key which overflows it.
+	primaryIndexPrefix := rowenc.MakeIndexKeyPrefix(
+		sp.flowCtx.Codec(), sp.tableDesc.GetID(), sp.tableDesc.GetPrimaryIndexID())
+	var lastKey roachpb.Key
+	if sp.spec.JobID != 0 {
+		sp.recordProgressOnFlush(ctx, adder, func() roachpb.Key { return lastKey })
+	}
+
 	// ingestKvs drains kvs from the channel until it closes, ingesting them using
 	// the BulkAdder. It handles the required buffering/sorting/etc.
+	knobs := &sp.flowCtx.Cfg.TestingKnobs
 	ingestKvs := func() error {
 		for kvBatch := range kvCh {
+			if knobs.RunBeforeBackfillChunk != nil && len(kvBatch.KVs) > 0 {
+				span := roachpb.Span{
+					Key:    kvBatch.KVs[0].Key,
+					EndKey: kvBatch.KVs[len(kvBatch.KVs)-1].Key.Next(),
+				}
+				if err := knobs.RunBeforeBackfillChunk(span); err != nil {
+					return err
+				}
+			}
 			for _, kv := range kvBatch.KVs {
+				primary := sp.spec.Checkpoint && bytes.HasPrefix(kv.Key, primaryIndexPrefix)
+				if primary && kv.Key.Compare(sp.spec.ResumeKey) < 0 {
+					// The row was ingested by a previous attempt at the backfill.
+					continue
+				}
 				if err := adder.Add(ctx, kv.Key, kv.Value.RawBytes); err != nil {
 					return sp.wrapDupError(ctx, err)
 				}
+				if primary {
+					lastKey = append(lastKey[:0], kv.Key...)
+				}
+			}
+			if knobs.BulkAdderFlushesEveryBatch {
+				if err := adder.Flush(ctx); err != nil {
+					return sp.wrapDupError(ctx, err)
+				}
 			}
 		}
 
@@ -179,6 +390,55 @@ func (sp *bulkRowWriter) ingestLoop(ctx context.Context, kvCh chan row.KVBatch)
 	return nil
 }
 
+// recordProgressOnFlush configures the adder to record the number of rows
+// ingested so far in the info storage of the job running the backfill, so that
+// its progress can be reported while it is running. The progress is recorded at
+// most once per CTASProgressInterval. When the backfill is checkpointed, the
+// key of the row from which it can be resumed is recorded along with the
+// progress: the row of lastKey, the last key flushed, may have column families
+// which weren't flushed yet, so the backfill is resumed from the row, rather
+// than after it. When the rows of the table are published to a sink, the
+// number of rows published is also recorded.
+func (sp *bulkRowWriter) recordProgressOnFlush(
+	ctx context.Context, adder kvserverbase.BulkAdder, lastKey func() roachpb.Key,
+) {
+	var rows int64
+	rowsKey := kvpb.BulkOpSummaryID(uint64(sp.tableDesc.GetID()), uint64(sp.tableDesc.GetPrimaryIndexID()))
+	infoKey := fmt.Sprintf("%s%d", CTASProgressInfoKeyPrefix, sp.processorID)
+	progressEvery := util.Every(CTASProgressInterval.Get(&sp.flowCtx.Cfg.Settings.SV))
+	adder.SetOnFlush(func(summary kvpb.BulkOpSummary) {
+		rows += summary.EntryCounts[rowsKey]
+		if !progressEvery.ShouldProcess(timeutil.Now()) {
+			return
+		}
+		var resumeKey roachpb.Key
+		if key := lastKey(); sp.spec.Checkpoint && key != nil {
+			var err error
+			if resumeKey, err = keys.EnsureSafeSplitKey(key); err != nil {
+				log.Warningf(ctx, "failed to checkpoint job %d at key %s: %v", sp.spec.JobID, key, err)
+			}
+		}
+		if err := sp.flowCtx.Cfg.DB.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+			infoStorage := jobs.InfoStorageForJob(txn, sp.spec.JobID)
+			if err := infoStorage.Write(ctx, infoKey, []byte(strconv.FormatInt(rows, 10))); err != nil {
+				return err
+			}
+			if resumeKey != nil {
+				if err := infoStorage.Write(ctx, CTASCheckpointInfoKey, resumeKey); err != nil {
+					return err
+				}
+			}
+			if !sp.publishes {
+				return nil
+			}
+			return sp.recordPublished(ctx, txn)
+		}); err != nil {
+			// Failing to record the progress shouldn't fail the backfill.
+			log.Warningf(ctx, "failed to record the progress of job %d: %v", sp.spec.JobID, err)
+		}
+	})
+}
+
 func (sp *bulkRowWriter) convertLoop(
 	ctx context.Context, kvCh chan row.KVBatch, conv *row.DatumRowConverter,
 ) error {
@@ -48,6 +48,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/catconstants"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
@@ -288,11 +289,18 @@ func (sc *SchemaChanger) refreshMaterializedView(
 	// data only to the new desired indexes. In SchemaChanger.done(), we'll swap
 	// the indexes from the old versions into the new ones.
 	tableToRefresh := refresh.TableWithNewIndexes(table)
-	return sc.backfillQueryIntoTable(ctx, tableToRefresh, table.GetViewQuery(), refresh.AsOf(), "refreshView")
+	return sc.backfillQueryIntoTable(
+		ctx, tableToRefresh, table.GetViewQuery(), refresh.AsOf(), "refreshView", nil, /* checkpoint */
+	)
 }
 
 func (sc *SchemaChanger) backfillQueryIntoTable(
-	ctx context.Context, table catalog.TableDescriptor, query string, ts hlc.Timestamp, desc string,
+	ctx context.Context,
+	table catalog.TableDescriptor,
+	query string,
+	ts hlc.Timestamp,
+	desc string,
+	checkpoint *queryBackfillCheckpoint,
 ) error {
 	if fn := sc.testingKnobs.RunBeforeQueryBackfill; fn != nil {
 		if err := fn(); err != nil {
@@ -318,6 +326,27 @@ func (sc *SchemaChanger) backfillQueryIntoTable(
 
 		defer cleanup()
 		localPlanner := p.(*planner)
+
+		// The data sources of the query are fully qualified, but the objects
+		// referenced by its statement sources, e.g. [SHOW CREATE TABLE t], are
+		// named relative to the session. Resolve them in the database and the
+		// schema of the table, falling back to the public schema.
+		g := localPlanner.Descriptors().ByIDWithLeased(txn.KV()).WithoutNonPublic().Get()
+		dbDesc, err := g.Database(ctx, table.GetParentID())
+		if err != nil {
+			return err
+		}
+		scDesc, err := g.Schema(ctx, table.GetParentSchemaID())
+		if err != nil {
+			return err
+		}
+		localPlanner.SessionData().Database = dbDesc.GetName()
+		searchPath := []string{scDesc.GetName()}
+		if scDesc.GetName() != catconstants.PublicSchemaName {
+			searchPath = append(searchPath, catconstants.PublicSchemaName)
+		}
+		localPlanner.SessionData().SearchPath = localPlanner.SessionData().SearchPath.UpdatePaths(searchPath)
+
 		stmt, err := parser.ParseOne(query)
 		if err != nil {
 			return err
@@ -390,6 +419,17 @@ func (sc *SchemaChanger) backfillQueryIntoTable(
 			out := execinfrapb.ProcessorCoreUnion{BulkRowWriter: &execinfrapb.BulkRowWriterSpec{
 				Table: *table.TableDesc(),
 			}}
+			if sc.job != nil {
+				// Have the processors record their progress, so that it can be
+				// reported to the client waiting on the job, and publish the rows
+				// to the sink of the table, if any, as the user of the job.
+				out.BulkRowWriter.JobID = sc.job.ID()
+				out.BulkRowWriter.UserProto = sc.job.Payload().UsernameProto
+				if checkpoint != nil {
+					out.BulkRowWriter.Checkpoint = true
+					out.BulkRowWriter.ResumeKey = checkpoint.resumeKey
+				}
+			}
 
 			PlanAndRunCTAS(ctx, sc.distSQLPlanner, localPlanner,
 				txn.KV(), isLocal, localPlanner.curPlan.main, out, recv)
@@ -405,6 +445,17 @@ func (sc *SchemaChanger) backfillQueryIntoTable(
 // maybe backfill a created table by executing the AS query. Return nil if
 // successfully backfilled.
 //
+// The state of the backfill is recorded in the info storage of the job, so that
+// when the job is paused, or otherwise interrupted, and then resumed, a
+// completed backfill isn't run again, and the rows ingested by an interrupted
+// backfill are cleared before it is run again. The backfill reads the source
+// at the AS OF timestamp of the statement, so the rows it ingests when run
+// again are the same. When the statement requested it with the checkpoint
+// storage parameter, and the table has a primary key made of the columns of the
+// query, the backfill is checkpointed, so that an interrupted backfill is
+// resumed from the last row it ingested, on whichever node resumes the job,
+// rather than run again from scratch, see orderCreateTableAsQueryByPrimaryKey.
+//
 // Note that this does not connect to the tracing settings of the
 // surrounding SQL transaction. This should be OK as (at the time of
 // this writing) this code path is only used for standalone CREATE
@@ -415,8 +466,56 @@ func (sc *SchemaChanger) maybeBackfillCreateTableAs(
 	if !(table.Adding() && table.IsAs()) {
 		return nil
 	}
-	log.Infof(ctx, "starting backfill for CREATE TABLE AS with query %q", table.GetCreateQuery())
-	return sc.backfillQueryIntoTable(ctx, table, table.GetCreateQuery(), table.GetCreateAsOfTime(), "ctasBackfill")
+	query := table.GetCreateQuery()
+	var checkpoint *queryBackfillCheckpoint
+	if sc.job != nil {
+		if ordered, ok := orderCreateTableAsQueryByPrimaryKey(table, query); ok {
+			query = ordered
+			checkpoint = &queryBackfillCheckpoint{}
+		}
+		state, err := sc.readCreateTableAsBackfillState(ctx)
+		if err != nil {
+			return err
+		}
+		switch state {
+		case createTableAsBackfillDone:
+			log.Infof(ctx, "backfill for CREATE TABLE AS already completed")
+			return nil
+		case createTableAsBackfillStarted:
+			var resumeKey roachpb.Key
+			if checkpoint != nil {
+				if resumeKey, err = sc.readCreateTableAsCheckpoint(ctx, table); err != nil {
+					return err
+				}
+				checkpoint.resumeKey = resumeKey
+			}
+			if err := sc.clearCreateTableAsPartialBackfill(ctx, table, resumeKey); err != nil {
+				return err
+			}
+		}
+		if err := sc.writeCreateTableAsBackfillState(ctx, createTableAsBackfillStarted); err != nil {
+			return err
+		}
+	}
+	log.Infof(ctx, "starting backfill for CREATE TABLE AS with query %q", query)
+	if err := sc.backfillCreateTableAs(ctx, table, query, checkpoint); err != nil {
+		return err
+	}
+	if fn := sc.testingKnobs.RunAfterCreateTableAsBackfill; fn != nil {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+	if err := sc.validateCreateTableAsChecks(ctx, table); err != nil {
+		return err
+	}
+	if sc.job == nil {
+		return nil
+	}
+	if err := sc.jobRegistry.CheckPausepoint("schemachanger.create_table_as.after_backfill"); err != nil {
+		return err
+	}
+	return sc.writeCreateTableAsBackfillState(ctx, createTableAsBackfillDone)
 }
 
 // maybeUpdateScheduledJobsForRowLevelTTL ensures the scheduled jobs related to the
@@ -456,7 +555,10 @@ func (sc *SchemaChanger) maybeBackfillMaterializedView(
 	}
 	log.Infof(ctx, "starting backfill for CREATE MATERIALIZED VIEW with query %q", table.GetViewQuery())
 
-	return sc.backfillQueryIntoTable(ctx, table, table.GetViewQuery(), table.GetCreateAsOfTime(), "materializedViewBackfill")
+	return sc.backfillQueryIntoTable(
+		ctx, table, table.GetViewQuery(), table.GetCreateAsOfTime(), "materializedViewBackfill",
+		nil, /* checkpoint */
+	)
 }
 
 // maybe make a table PUBLIC if it's in the ADD state.
@@ -476,6 +578,8 @@ func (sc *SchemaChanger) maybeMakeAddTablePublic(
 		if !mut.Adding() {
 			return nil
 		}
+		markCreateTableAsChecksValidated(mut)
+		clearCreateTableAsSinkURI(mut)
 		mut.State = descpb.DescriptorState_PUBLIC
 		return txn.Descriptors().WriteDesc(ctx, true /* kvTrace */, mut, txn.KV())
 	})
@@ -708,7 +812,7 @@ func (sc *SchemaChanger) exec(ctx context.Context) error {
 		// We wait to trigger a stats refresh until we know the leases have been
 		// updated.
 		if refreshStats {
-			sc.refreshStats(latestDesc)
+			sc.refreshStats(ctx, latestDesc)
 		}
 		return nil
 	}
@@ -902,7 +1006,7 @@ func (sc *SchemaChanger) handlePermanentSchemaChangeError(
 		// We wait to trigger a stats refresh until we know the leases have been
 		// updated.
 		if refreshStats {
-			sc.refreshStats(desc)
+			sc.refreshStats(ctx, desc)
 		}
 		return nil
 	}
@@ -1484,6 +1588,7 @@ func (sc *SchemaChanger) done(ctx context.Context) error {
 				// If we are mutation is in the ADD state, then start GC jobs for the
 				// existing indexes on the table.
 				if m.Adding() {
+					scTable.ViewRefreshAsOf = refresh.AsOf()
 					desc := fmt.Sprintf("REFRESH MATERIALIZED VIEW %q cleanup", scTable.Name)
 					for _, idx := range scTable.ActiveIndexes() {
 						if err := sc.createIndexGCJob(ctx, idx.GetID(), txn, desc); err != nil {
@@ -1925,11 +2030,17 @@ func (sc *SchemaChanger) runStateMachineAndBackfill(ctx context.Context) error {
 	return sc.done(ctx)
 }
 
-func (sc *SchemaChanger) refreshStats(desc catalog.Descriptor) {
+func (sc *SchemaChanger) refreshStats(ctx context.Context, desc catalog.Descriptor) {
 	// Initiate an asynchronous run of CREATE STATISTICS. We use a large number
 	// for rowsAffected because we want to make sure that stats always get
 	// created/refreshed here.
 	if tableDesc, ok := desc.(catalog.TableDescriptor); ok {
+		if createTableAsCollectsStats(tableDesc) {
+			// CREATE TABLE AS ... WITH (collect_stats = true) collects statistics
+			// even if automatic statistics collection is disabled.
+			collectCreateTableAsStats(ctx, sc.execCfg, tableDesc)
+			return
+		}
 		sc.execCfg.StatsRefresher.NotifyMutation(tableDesc, math.MaxInt32 /* rowsAffected */)
 	}
 }
@@ -2376,6 +2487,10 @@ type SchemaChangerTestingKnobs struct {
 	// RunBeforeQueryBackfill is called before a query based backfill.
 	RunBeforeQueryBackfill func() error
 
+	// RunAfterCreateTableAsBackfill is called after the backfill of a CREATE
+	// TABLE AS statement completes.
+	RunAfterCreateTableAsBackfill func() error
+
 	// RunBeforeIndexBackfill is called just before starting the index backfill, after
 	// fixing the index backfill scan timestamp.
 	RunBeforeIndexBackfill func()
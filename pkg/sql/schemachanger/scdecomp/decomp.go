@@ -293,11 +293,12 @@ func (w *walkCtx) walkRelation(tbl catalog.TableDescriptor) {
 		}
 	case tbl.IsView():
 		w.ev(descriptorStatus(tbl), &scpb.View{
-			ViewID:          tbl.GetID(),
-			UsesTypeIDs:     catalog.MakeDescriptorIDSet(tbl.GetDependsOnTypes()...).Ordered(),
-			UsesRelationIDs: catalog.MakeDescriptorIDSet(tbl.GetDependsOn()...).Ordered(),
-			IsTemporary:     tbl.IsTemporary(),
-			IsMaterialized:  tbl.MaterializedView(),
+			ViewID:            tbl.GetID(),
+			UsesTypeIDs:       catalog.MakeDescriptorIDSet(tbl.GetDependsOnTypes()...).Ordered(),
+			UsesRelationIDs:   catalog.MakeDescriptorIDSet(tbl.GetDependsOn()...).Ordered(),
+			IsTemporary:       tbl.IsTemporary(),
+			IsMaterialized:    tbl.MaterializedView(),
+			RefreshScheduleID: tbl.TableDesc().ViewRefreshScheduleID,
 			ForwardReferences: func(tbl catalog.TableDescriptor) []*scpb.View_Reference {
 				result := make([]*scpb.View_Reference, 0)
 
@@ -60,6 +60,14 @@ func init() {
 						RelationIDs:      this.UsesRelationIDs,
 					}
 				}),
+				emit(func(this *scpb.View) *scop.DeleteSchedule {
+					if this.RefreshScheduleID == 0 {
+						return nil
+					}
+					return &scop.DeleteSchedule{
+						ScheduleID: this.RefreshScheduleID,
+					}
+				}),
 			),
 			to(scpb.Status_ABSENT,
 				emit(func(this *scpb.View, md *opGenContext) *scop.CreateGCJobForTable {
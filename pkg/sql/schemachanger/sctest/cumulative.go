@@ -32,6 +32,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/oidext"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser/statements"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
@@ -146,6 +147,9 @@ type stageExecStmtMap struct {
 	entries    []stageKeyEntry
 	usedMap    map[*stageExecStmt]struct{}
 	rewriteMap map[string]*stageExecStmt
+	// postRestore are the queries which are checked once a backup has been
+	// restored and the schema change completed.
+	postRestore []*stageExecStmt
 }
 
 func makeStageExecStmtMap() *stageExecStmtMap {
@@ -329,6 +333,35 @@ func (m *stageExecStmtMap) ParseStageExec(t *testing.T, d *datadriven.TestData)
 	m.parseStageCommon(t, d, stageExecuteStmt)
 }
 
+// ParsePostRestoreQuery parses a post-restore-query statement. Unlike
+// stage-query statements, these take no arguments: they are checked by the
+// backup tests after a backup is restored and the schema change it was taken
+// in completes successfully.
+func (m *stageExecStmtMap) ParsePostRestoreQuery(t *testing.T, d *datadriven.TestData) {
+	require.Empty(t, d.CmdArgs, "post-restore-query takes no arguments")
+	stmts := strings.Split(d.Input, ";")
+	require.NotEmpty(t, stmts)
+	// Remove any trailing empty lines.
+	if stmts[len(stmts)-1] == "" {
+		stmts = stmts[0 : len(stmts)-1]
+	}
+	stmt := &stageExecStmt{
+		execType:       stageExecuteQuery,
+		stmts:          stmts,
+		expectedOutput: d.Expected,
+	}
+	m.postRestore = append(m.postRestore, stmt)
+	m.rewriteMap[d.Pos] = stmt
+}
+
+// ExecPostRestore runs the post-restore-query statements and validates their
+// output.
+func (m *stageExecStmtMap) ExecPostRestore(t *testing.T, runner *sqlutils.SQLRunner, rewrite bool) {
+	for _, stmt := range m.postRestore {
+		stmt.Exec(t, runner, &stageExecVariables{}, rewrite)
+	}
+}
+
 // parseStageCommon processes common arguments for "stage-exec"-directive and
 // "stage-query" directive, which include:
 //   - "phase": The phase in which this statement/query should be injected, of the
@@ -536,9 +569,10 @@ func (m *stageExecStmtMap) GetInjectionCallback(t *testing.T, rewrite bool) exec
 //     via the tf callback. The callback can if the rewrite is enabled update
 //     the expected output inside stageExecStmtMap (see stageExecStmt.Exec).
 //
-//  2. The second pass will for any stage-exec/stage-query functions look up
-//     the output using stageExecStmtMap.GetExpectedOutputForPos and return
-//     it, only when rewrite is enabled.
+//  2. The second pass will for any stage-exec/stage-query/post-restore-query
+//     functions look up the output using
+//     stageExecStmtMap.GetExpectedOutputForPos and return it, only when
+//     rewrite is enabled.
 func cumulativeTest(
 	t *testing.T,
 	testKind, relTestCaseDir string,
@@ -589,6 +623,8 @@ func cumulativeTest(
 			stageExecMap.ParseStageExec(t, d)
 		case "stage-query":
 			stageExecMap.ParseStageQuery(t, d)
+		case "post-restore-query":
+			stageExecMap.ParsePostRestoreQuery(t, d)
 		case "test":
 			stmts, err := parser.Parse(d.Input)
 			require.NoError(t, err)
@@ -611,7 +647,7 @@ func cumulativeTest(
 	// d.Expected.
 	if rewrite {
 		datadriven.RunTest(t, testCaseDefinition, func(t *testing.T, d *datadriven.TestData) string {
-			if d.Cmd == "stage-exec" || d.Cmd == "stage-query" {
+			if d.Cmd == "stage-exec" || d.Cmd == "stage-query" || d.Cmd == "post-restore-query" {
 				// Retrieve the actual output of each DML injection block (from first
 				// pass), indexed by file:line.
 				return stageExecMap.GetExpectedOutputForPos(d.Pos)
@@ -741,6 +777,37 @@ WHERE descriptor_id IN (SELECT id FROM system.namespace)
 ORDER BY
 	create_statement;`
 
+// fetchFunctionSignaturesQuery returns the signature and the result type of all
+// user-defined functions in the current database. Functions aren't covered by
+// fetchDescriptorStateQuery, since they don't have namespace entries.
+var fetchFunctionSignaturesQuery = fmt.Sprintf(`
+SELECT
+	n.nspname || '.' || p.proname || '(' || pg_get_function_identity_arguments(p.oid) || ')' AS signature,
+	pg_get_function_result(p.oid) AS result
+FROM
+	pg_catalog.pg_proc AS p
+	JOIN pg_catalog.pg_namespace AS n ON p.pronamespace = n.oid
+WHERE
+	p.oid > %d
+ORDER BY
+	signature, result;`, oidext.CockroachPredefinedOIDMax)
+
+// requireFunctionsCallable asserts that all user-defined functions without
+// parameters in the current database can be resolved and called.
+func requireFunctionsCallable(t *testing.T, tdb *sqlutils.SQLRunner) {
+	rows := tdb.QueryStr(t, fmt.Sprintf(`
+SELECT
+	n.nspname, p.proname
+FROM
+	pg_catalog.pg_proc AS p
+	JOIN pg_catalog.pg_namespace AS n ON p.pronamespace = n.oid
+WHERE
+	p.oid > %d AND pg_get_function_identity_arguments(p.oid) = ''`, oidext.CockroachPredefinedOIDMax))
+	for _, row := range rows {
+		tdb.Exec(t, fmt.Sprintf("SELECT %s.%s()", tree.NameString(row[0]), tree.NameString(row[1])))
+	}
+}
+
 // Pause tests that the schema changer can handle being paused and resumed
 // correctly. This data-driven test uses the same input as EndToEndSideEffects
 // but ignores the expected output.
@@ -1076,7 +1143,11 @@ func Backup(t *testing.T, path string, newCluster NewClusterFunc) {
 	// expect that after the restore, the schema change job (either in reverting
 	// or not) is completed and the database is in an expected state.
 	testBackupRestoreCase := func(
-		t *testing.T, setup, stmts []statements.Statement[tree.Statement], ord int,
+		t *testing.T,
+		setup, stmts []statements.Statement[tree.Statement],
+		ord int,
+		execMap *stageExecStmtMap,
+		rewrite bool,
 	) {
 		// If tables are empty then, backfills should never lead to rollbacks
 		// in restores.
@@ -1296,8 +1367,9 @@ func Backup(t *testing.T, path string, newCluster NewClusterFunc) {
 			}
 
 			// For the third flavor, we restore all tables in the backup.
-			// Skip it if there is no tables or if there is user-defined schemas to
-			// restore.
+			// Skip it if there is no tables, if there is user-defined schemas to
+			// restore or if the database is multi-region, since its tables can't be
+			// restored into the database created for them.
 			backupHasUDS := false
 			rows := tdb.QueryStr(t, `
 			SELECT parent_schema_name, object_name, object_type
@@ -1315,7 +1387,8 @@ func Backup(t *testing.T, path string, newCluster NewClusterFunc) {
 				}
 			}
 
-			if len(tablesToRestore) > 0 && !backupHasUDS {
+			if len(tablesToRestore) > 0 && !backupHasUDS &&
+				!backupHasMultiRegionDatabase(t, tdb, b.url, dbName) {
 				flavors = append(flavors, backupConsumptionFlavor{
 					name: "restore all tables in database",
 					restoreSetup: []string{
@@ -1399,6 +1472,9 @@ func Backup(t *testing.T, path string, newCluster NewClusterFunc) {
 						t.Fatalf("schema change failed when successful completion was expected")
 					}
 					afterRestore := tdb.QueryStr(t, fetchDescriptorStateQuery)
+					if !b.isRollback && wasSchemaChangeSuccessful {
+						execMap.ExecPostRestore(t, tdb, rewrite)
+					}
 
 					if flavor.name != "restore all tables in database" {
 						if b.isRollback || !wasSchemaChangeSuccessful {
@@ -1446,7 +1522,7 @@ SELECT * FROM crdb_internal.invalid_objects WHERE database_name != 'backups'
 		}
 	}
 
-	testFunc := func(t *testing.T, _ string, _ bool, setup, stmts []statements.Statement[tree.Statement], _ *stageExecStmtMap) {
+	testFunc := func(t *testing.T, _ string, rewrite bool, setup, stmts []statements.Statement[tree.Statement], execMap *stageExecStmtMap) {
 		postCommit, nonRevertible := countStages(t, setup, stmts)
 		n := postCommit + nonRevertible
 		t.Logf(
@@ -1458,7 +1534,7 @@ SELECT * FROM crdb_internal.invalid_objects WHERE database_name != 'backups'
 				fmt.Sprintf("backup and restore at stage %d of %d", i, n),
 				func(t *testing.T) {
 					maybeRandomlySkip(t)
-					testBackupRestoreCase(t, setup, stmts, i)
+					testBackupRestoreCase(t, setup, stmts, i, execMap, rewrite)
 				},
 			) {
 				return
@@ -1469,6 +1545,20 @@ SELECT * FROM crdb_internal.invalid_objects WHERE database_name != 'backups'
 	cumulativeTest(t, "Backup", path, testFunc)
 }
 
+// backupHasMultiRegionDatabase returns whether the database with the given
+// name in the latest backup at url is multi-region.
+func backupHasMultiRegionDatabase(
+	t *testing.T, tdb *sqlutils.SQLRunner, url, dbName string,
+) (multiRegion bool) {
+	tdb.QueryRow(t, `
+SELECT count(*) > 0
+  FROM [SHOW BACKUP FROM LATEST IN $1]
+ WHERE database_name = $2
+       AND object_type = 'type'
+       AND object_name = 'crdb_internal_region'`, url, dbName).Scan(&multiRegion)
+	return multiRegion
+}
+
 func maybeGetDatabaseForIDs(
 	t *testing.T, tdb *sqlutils.SQLRunner, ids catalog.DescriptorIDSet,
 ) (dbName string, exists bool) {
@@ -1781,7 +1871,8 @@ func BackupMixedVersionElements(t *testing.T, path string, newCluster NewMixedCl
 	//testVersion := clusterversion.ClusterVersion{
 	//	Version: clusterversion.ByKey(clusterversion.V23_1_SchemaChangerDeprecatedIndexPredicates - 1),
 	//}
-	var after [][]string // CREATE_STATEMENT for all descriptors after finishing `stmts` in each test case.
+	var after [][]string          // CREATE_STATEMENT for all descriptors after finishing `stmts` in each test case.
+	var afterFunctions [][]string // Signatures of all functions after finishing `stmts` in each test case.
 	var dbName string
 	r, _ := randutil.NewTestRand()
 	const runRate = .5
@@ -1815,6 +1906,7 @@ func BackupMixedVersionElements(t *testing.T, path string, newCluster NewMixedCl
 					tdb.Exec(t, fmt.Sprintf("USE %q", dbName))
 				}
 				after = tdb.QueryStr(t, fetchDescriptorStateQuery)
+				afterFunctions = tdb.QueryStr(t, fetchFunctionSignaturesQuery)
 			})
 		return postCommit, nonRevertible
 	}
@@ -1826,7 +1918,11 @@ func BackupMixedVersionElements(t *testing.T, path string, newCluster NewMixedCl
 	// comment below for details) and expect the restore to finish the schema change job
 	// as if the backup/restore had never happened.
 	testBackupRestoreCase := func(
-		t *testing.T, setup, stmts []statements.Statement[tree.Statement], ord int,
+		t *testing.T,
+		setup, stmts []statements.Statement[tree.Statement],
+		ord int,
+		execMap *stageExecStmtMap,
+		rewrite bool,
 	) {
 		// If tables are empty then, backfills should never lead to rollbacks
 		// in restores.
@@ -1893,10 +1989,11 @@ func BackupMixedVersionElements(t *testing.T, path string, newCluster NewMixedCl
 		tdb.SucceedsSoonDuration = testutils.RaceSucceedsSoonDuration
 		tdb.Exec(t, "create database backups")
 		var g errgroup.Group
-		var before [][]string
+		var before, beforeFunctions [][]string
 		beforeFunc := func() {
 			tdb.Exec(t, fmt.Sprintf("USE %q", dbName))
 			before = tdb.QueryStr(t, fetchDescriptorStateQuery)
+			beforeFunctions = tdb.QueryStr(t, fetchFunctionSignaturesQuery)
 		}
 		g.Go(func() error {
 			return executeSchemaChangeTxn(
@@ -2023,9 +2120,10 @@ func BackupMixedVersionElements(t *testing.T, path string, newCluster NewMixedCl
 			// reaches the expected state as if the back/restore had not happened at all.
 			// Skip a backup randomly.
 			type backupConsumptionFlavor struct {
-				name         string
-				restoreSetup []string
-				restoreQuery string
+				name               string
+				restoreSetup       []string
+				restoreQuery       string
+				restoresTablesOnly bool
 			}
 			flavors := []backupConsumptionFlavor{
 				{
@@ -2047,7 +2145,9 @@ func BackupMixedVersionElements(t *testing.T, path string, newCluster NewMixedCl
 			}
 
 			// For the third flavor, we restore all tables in the backup.
-			// Skip it if there is no tables.
+			// Skip it if there is no tables or if the database is multi-region,
+			// since its tables can't be restored into the database created for
+			// them.
 			rows := tdb.QueryStr(t, `
 			SELECT parent_schema_name, object_name
 			FROM [SHOW BACKUP FROM LATEST IN $1]
@@ -2057,7 +2157,7 @@ func BackupMixedVersionElements(t *testing.T, path string, newCluster NewMixedCl
 				tablesToRestore = append(tablesToRestore, fmt.Sprintf("%s.%s.%s", dbName, row[0], row[1]))
 			}
 
-			if len(tablesToRestore) > 0 {
+			if len(tablesToRestore) > 0 && !backupHasMultiRegionDatabase(t, tdb, b.url, dbName) {
 				flavors = append(flavors, backupConsumptionFlavor{
 					name: "restore all tables in database",
 					restoreSetup: []string{
@@ -2067,6 +2167,7 @@ func BackupMixedVersionElements(t *testing.T, path string, newCluster NewMixedCl
 					},
 					restoreQuery: fmt.Sprintf("RESTORE TABLE %s FROM LATEST IN '%s' WITH skip_missing_sequences",
 						strings.Join(tablesToRestore, ","), b.url),
+					restoresTablesOnly: true,
 				})
 			}
 
@@ -2092,11 +2193,26 @@ func BackupMixedVersionElements(t *testing.T, path string, newCluster NewMixedCl
 						t.Fatalf("schema change failed when successful completion was expected")
 					}
 					afterRestore := tdb.QueryStr(t, fetchDescriptorStateQuery)
+					if !b.isRollback && wasSchemaChangeSuccessful {
+						execMap.ExecPostRestore(t, tdb, rewrite)
+					}
 					if b.isRollback || !wasSchemaChangeSuccessful {
 						require.Equal(t, before, afterRestore)
 					} else {
 						require.Equal(t, after, afterRestore)
 					}
+					// Functions are only restored along with their database, so
+					// validate that their signatures match and that they can still
+					// be called.
+					if !flavor.restoresTablesOnly {
+						functionsAfterRestore := tdb.QueryStr(t, fetchFunctionSignaturesQuery)
+						if b.isRollback || !wasSchemaChangeSuccessful {
+							require.Equal(t, beforeFunctions, functionsAfterRestore)
+						} else {
+							require.Equal(t, afterFunctions, functionsAfterRestore)
+						}
+						requireFunctionsCallable(t, tdb)
+					}
 					// Hack to deal with corrupt userfiles tables due to #76764.
 					const validateQuery = `
 SELECT * FROM crdb_internal.invalid_objects WHERE database_name != 'backups'
@@ -2110,7 +2226,7 @@ SELECT * FROM crdb_internal.invalid_objects WHERE database_name != 'backups'
 		}
 	}
 
-	testFunc := func(t *testing.T, _ string, _ bool, setup, stmts []statements.Statement[tree.Statement], _ *stageExecStmtMap) {
+	testFunc := func(t *testing.T, _ string, rewrite bool, setup, stmts []statements.Statement[tree.Statement], execMap *stageExecStmtMap) {
 		// Skip this test if any of the stmts is not fully supported.
 		if err := areStmtsFullySupportedAtClusterVersion(t, setup, stmts, downlevelClusterFunc); err != nil {
 			skip.IgnoreLint(t, "test is skipped because", err.Error())
@@ -2127,7 +2243,7 @@ SELECT * FROM crdb_internal.invalid_objects WHERE database_name != 'backups'
 				fmt.Sprintf("backup/restore stage %d of %d", i, n),
 				func(t *testing.T) {
 					maybeRandomlySkip(t)
-					testBackupRestoreCase(t, setup, stmts, i)
+					testBackupRestoreCase(t, setup, stmts, i, execMap, rewrite)
 				},
 			) {
 				return
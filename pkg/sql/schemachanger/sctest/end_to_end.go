@@ -205,9 +205,10 @@ func EndToEndSideEffects(t *testing.T, relTestCaseDir string, newCluster NewClus
 			b := prettyNamespaceDump(t, tdb)
 			setupStmts = stmts
 			setupOutput = sctestutils.Diff(a, b, sctestutils.DiffArgs{CompactLevel: 1})
-		case "stage-exec", "stage-query":
-			// Both of these commands are DML injections, which is not relevant
-			// for end-to-end side-effect testing, so we ignore them.
+		case "stage-exec", "stage-query", "post-restore-query":
+			// These commands are DML injections and post-restore checks, which
+			// are not relevant for end-to-end side-effect testing, so we ignore
+			// them.
 			break
 		case "test":
 			stmts, execStmts := parseStmts()
@@ -7725,6 +7725,7 @@ specified store on the node it's run from. One of 'mvccGC', 'merge', 'split',
 				if err := evalCtx.JobsProfiler.RequestExecutionDetails(
 					ctx,
 					jobspb.JobID(jobID),
+					eval.ExecutionDetailsOptions{},
 				); err != nil {
 					return nil, err
 				}
@@ -7734,6 +7735,168 @@ specified store on the node it's run from. One of 'mvccGC', 'merge', 'split',
 			Volatility: volatility.Volatile,
 			Info:       `Used to request the collection of execution details for a given job ID`,
 		},
+		tree.Overload{
+			Types: tree.ParamTypes{
+				{Name: "jobID", Typ: types.Int},
+				{Name: "options", Typ: types.Jsonb},
+			},
+			ReturnType: tree.FixedReturnType(types.Jsonb),
+			Fn: func(ctx context.Context, evalCtx *eval.Context, args tree.Datums) (tree.Datum, error) {
+				isAdmin, err := evalCtx.SessionAccessor.HasAdminRole(ctx)
+				if err != nil {
+					return nil, err
+				}
+
+				if !isAdmin {
+					return nil, errors.New("must be admin to request a job profiler bundle")
+				}
+
+				jobID := jobspb.JobID(tree.MustBeDInt(args[0]))
+				validateOnly, opts, err := parseRequestJobExecutionDetailsOptions(tree.MustBeDJSON(args[1]).JSON)
+				if err != nil {
+					return nil, err
+				}
+				if !validateOnly {
+					if err := evalCtx.JobsProfiler.RequestExecutionDetails(ctx, jobID, opts); err != nil {
+						return nil, err
+					}
+				}
+
+				artifacts, err := evalCtx.JobsProfiler.ExecutionDetailArtifactsJSON(ctx, jobID)
+				if err != nil {
+					return nil, err
+				}
+				return tree.ParseDJSON(string(artifacts))
+			},
+			Volatility: volatility.Volatile,
+			Info: `Used to request the collection of execution details for a given job ID,
+with options provided as a JSON object. Returns the types of execution details
+that are supported and unsupported for the job. The option 'validate_only'
+returns the execution detail types without collecting any execution details.
+The option 'goroutines_pprof' additionally collects the goroutines of every
+node in the binary pprof format. The option 'label' labels the collection, and
+is incorporated into the names of the collected files.`,
+		},
+	),
+
+	"crdb_internal.request_job_execution_details_sync": makeBuiltin(
+		tree.FunctionProperties{
+			Category:         builtinconstants.CategorySystemInfo,
+			DistsqlBlocklist: true, // applicable only on the gateway
+		},
+		tree.Overload{
+			Types: tree.ParamTypes{
+				{Name: "jobID", Typ: types.Int},
+				{Name: "timeout", Typ: types.Interval},
+			},
+			ReturnType: tree.FixedReturnType(types.StringArray),
+			Fn: func(ctx context.Context, evalCtx *eval.Context, args tree.Datums) (tree.Datum, error) {
+				isAdmin, err := evalCtx.SessionAccessor.HasAdminRole(ctx)
+				if err != nil {
+					return nil, err
+				}
+
+				if !isAdmin {
+					return nil, errors.New("must be admin to request a job profiler bundle")
+				}
+
+				jobID := jobspb.JobID(tree.MustBeDInt(args[0]))
+				timeout := time.Duration(tree.MustBeDInterval(args[1]).Nanos())
+				files, complete, err := evalCtx.JobsProfiler.RequestExecutionDetailsAndWait(ctx, jobID, timeout)
+				if err != nil {
+					return nil, err
+				}
+				if !complete {
+					evalCtx.ClientNoticeSender.BufferClientNotice(ctx, pgnotice.Newf(
+						"the collection of the execution details of job %d did not complete within %s; "+
+							"the execution details may be incomplete", jobID, timeout,
+					))
+				}
+
+				arr := tree.NewDArray(types.String)
+				for _, f := range files {
+					if err := arr.Append(tree.NewDString(f)); err != nil {
+						return nil, err
+					}
+				}
+				return arr, nil
+			},
+			Volatility: volatility.Volatile,
+			Info: `Used to request the collection of execution details for a given job ID,
+waiting at most the given timeout for the collection to complete. Returns the
+names of the files written by the collection. If the collection doesn't
+complete within the timeout, a notice is sent and the files written before the
+timeout are returned.`,
+		},
+	),
+
+	"crdb_internal.latest_job_goroutines": makeBuiltin(
+		tree.FunctionProperties{
+			Category:         builtinconstants.CategorySystemInfo,
+			DistsqlBlocklist: true, // applicable only on the gateway
+		},
+		tree.Overload{
+			Types: tree.ParamTypes{
+				{Name: "jobID", Typ: types.Int},
+			},
+			ReturnType: tree.FixedReturnType(types.String),
+			Fn: func(ctx context.Context, evalCtx *eval.Context, args tree.Datums) (tree.Datum, error) {
+				isAdmin, err := evalCtx.SessionAccessor.HasAdminRole(ctx)
+				if err != nil {
+					return nil, err
+				}
+
+				if !isAdmin {
+					return nil, errors.New("must be admin to request a job profiler bundle")
+				}
+
+				jobID := jobspb.JobID(tree.MustBeDInt(args[0]))
+				goroutines, err := evalCtx.JobsProfiler.LatestJobGoroutines(ctx, jobID)
+				if err != nil {
+					return nil, err
+				}
+				return tree.NewDString(string(goroutines)), nil
+			},
+			Volatility: volatility.Volatile,
+			Info: `Returns the most recently collected goroutines of the given job ID. The
+goroutines are collected from all nodes first, unless they were collected in
+the last few seconds.`,
+		},
+	),
+
+	"crdb_internal.job_distsql_plan_text": makeBuiltin(
+		tree.FunctionProperties{
+			Category:         builtinconstants.CategorySystemInfo,
+			DistsqlBlocklist: true, // applicable only on the gateway
+		},
+		tree.Overload{
+			Types: tree.ParamTypes{
+				{Name: "jobID", Typ: types.Int},
+			},
+			ReturnType: tree.FixedReturnType(types.String),
+			Fn: func(ctx context.Context, evalCtx *eval.Context, args tree.Datums) (tree.Datum, error) {
+				return jobDistSQLPlanText(ctx, evalCtx, args[0], 0 /* index */)
+			},
+			Volatility: volatility.Volatile,
+			Info: `Returns a plain-text rendering of the most recent DistSQL plan stored by
+the given job ID, listing the processors of the flow on each node and the
+streams between them.`,
+		},
+		tree.Overload{
+			Types: tree.ParamTypes{
+				{Name: "jobID", Typ: types.Int},
+				{Name: "index", Typ: types.Int},
+			},
+			ReturnType: tree.FixedReturnType(types.String),
+			Fn: func(ctx context.Context, evalCtx *eval.Context, args tree.Datums) (tree.Datum, error) {
+				return jobDistSQLPlanText(ctx, evalCtx, args[0], int(tree.MustBeDInt(args[1])))
+			},
+			Volatility: volatility.Volatile,
+			Info: `Returns a plain-text rendering of a DistSQL plan stored by the given job
+ID, listing the processors of the flow on each node and the streams between
+them. The index selects an earlier plan, counting back from the most recent
+plan at index 0.`,
+		},
 	),
 
 	"crdb_internal.request_statement_bundle": makeBuiltin(
@@ -10860,3 +11023,73 @@ func spanToDatum(span roachpb.Span) (tree.Datum, error) {
 	}
 	return result, nil
 }
+
+// jobDistSQLPlanText implements crdb_internal.job_distsql_plan_text, returning
+// the DistSQL plan of the job at the given index as text.
+func jobDistSQLPlanText(
+	ctx context.Context, evalCtx *eval.Context, jobIDArg tree.Datum, index int,
+) (tree.Datum, error) {
+	isAdmin, err := evalCtx.SessionAccessor.HasAdminRole(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isAdmin {
+		return nil, errors.New("must be admin to read the DistSQL plan of a job")
+	}
+
+	jobID := jobspb.JobID(tree.MustBeDInt(jobIDArg))
+	plan, err := evalCtx.JobsProfiler.JobDistSQLPlanText(ctx, jobID, index)
+	if err != nil {
+		return nil, err
+	}
+	return tree.NewDString(plan), nil
+}
+
+// parseRequestJobExecutionDetailsOptions parses the options passed to
+// crdb_internal.request_job_execution_details, returning whether the request
+// should only be validated, and the options for collecting execution details.
+func parseRequestJobExecutionDetailsOptions(
+	options json.JSON,
+) (validateOnly bool, opts eval.ExecutionDetailsOptions, _ error) {
+	it, err := options.ObjectIter()
+	if err != nil {
+		return false, opts, err
+	}
+	if it == nil {
+		return false, opts, pgerror.New(pgcode.InvalidParameterValue,
+			"options must be a JSON object")
+	}
+	for it.Next() {
+		key := it.Key()
+		if key == "label" {
+			if it.Value().Type() != json.StringJSONType {
+				return false, opts, pgerror.Newf(pgcode.InvalidParameterValue,
+					"option %q must be a string", key)
+			}
+			label, err := it.Value().AsText()
+			if err != nil {
+				return false, opts, err
+			}
+			opts.Label = *label
+			continue
+		}
+		var dest *bool
+		switch key {
+		case "validate_only":
+			dest = &validateOnly
+		case "goroutines_pprof":
+			dest = &opts.GoroutinesPprof
+		default:
+			return false, opts, pgerror.Newf(pgcode.InvalidParameterValue,
+				"unknown option %q", key)
+		}
+		b, ok := it.Value().AsBool()
+		if !ok {
+			return false, opts, pgerror.Newf(pgcode.InvalidParameterValue,
+				"option %q must be a boolean", key)
+		}
+		*dest = b
+	}
+	return validateOnly, opts, nil
+}
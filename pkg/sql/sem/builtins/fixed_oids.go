@@ -2429,6 +2429,12 @@ var builtinOidsArray = []string{
 	2456: `crdb_internal.merge_aggregated_stmt_metadata(input: jsonb[]) -> jsonb`,
 	2457: `crdb_internal.request_job_execution_details(jobID: int) -> bool`,
 	2458: `pg_sequence_last_value(sequence_oid: oid) -> int`,
+	2459: `crdb_internal.request_job_execution_details(jobID: int, options: jsonb) -> jsonb`,
+	2460: `crdb_internal.latest_job_goroutines(jobID: int) -> string`,
+	2461: `crdb_internal.request_job_execution_details_for_schedule(scheduleID: int) -> tuple{int AS job_id, bool AS requested, string AS error}`,
+	2462: `crdb_internal.job_distsql_plan_text(jobID: int) -> string`,
+	2463: `crdb_internal.job_distsql_plan_text(jobID: int, index: int) -> string`,
+	2464: `crdb_internal.request_job_execution_details_sync(jobID: int, timeout: interval) -> string[]`,
 }
 
 var builtinOidsBySignature map[string]oid.Oid
@@ -19,6 +19,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
@@ -35,6 +36,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
 	"github.com/cockroachdb/cockroach/pkg/util/arith"
+	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
 	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/cockroach/pkg/util/json"
 	"github.com/cockroachdb/cockroach/pkg/util/mon"
@@ -466,6 +468,23 @@ var generators = map[string]builtinDefinition{
 			volatility.Volatile,
 		),
 	),
+	"crdb_internal.request_job_execution_details_for_schedule": makeBuiltin(
+		tree.FunctionProperties{
+			Category:         builtinconstants.CategorySystemInfo,
+			DistsqlBlocklist: true, // applicable only on the gateway
+		},
+		makeGeneratorOverload(
+			tree.ParamTypes{
+				{Name: "scheduleID", Typ: types.Int},
+			},
+			scheduleExecutionDetailsGeneratorType,
+			makeScheduleExecutionDetailsGenerator,
+			`Used to request the collection of execution details for every running job
+created by the given schedule ID. Returns whether the execution details of each
+job were requested, along with the error if they weren't.`,
+			volatility.Volatile,
+		),
+	),
 	"crdb_internal.show_create_all_schemas": makeBuiltin(
 		tree.FunctionProperties{},
 		makeGeneratorOverload(
@@ -2559,6 +2578,129 @@ func (p *payloadsForTraceGenerator) Close(_ context.Context) {
 	}
 }
 
+var scheduleExecutionDetailsGeneratorType = types.MakeLabeledTuple(
+	[]*types.T{types.Int, types.Bool, types.String},
+	[]string{"job_id", "requested", "error"},
+)
+
+// maxConcurrentScheduleExecutionDetailsRequests is the maximum number of jobs
+// for which crdb_internal.request_job_execution_details_for_schedule requests
+// execution details at once, since each request fans out to every node.
+const maxConcurrentScheduleExecutionDetailsRequests = 4
+
+// scheduleExecutionDetailsGenerator supports the execution of
+// crdb_internal.request_job_execution_details_for_schedule(scheduleID). The
+// execution details of the running jobs of the schedule are requested in
+// Start, and the outcome for each job is returned by Next.
+type scheduleExecutionDetailsGenerator struct {
+	scheduleID int64
+	evalCtx    *eval.Context
+
+	jobIDs []jobspb.JobID
+	errs   []error
+	idx    int
+}
+
+func makeScheduleExecutionDetailsGenerator(
+	ctx context.Context, evalCtx *eval.Context, args tree.Datums,
+) (eval.ValueGenerator, error) {
+	// Enforce the same permissions as crdb_internal.request_job_execution_details.
+	isAdmin, err := evalCtx.SessionAccessor.HasAdminRole(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin {
+		return nil, errors.New("must be admin to request a job profiler bundle")
+	}
+	return &scheduleExecutionDetailsGenerator{
+		scheduleID: int64(tree.MustBeDInt(args[0])),
+		evalCtx:    evalCtx,
+		idx:        -1,
+	}, nil
+}
+
+// ResolvedType implements the eval.ValueGenerator interface.
+func (g *scheduleExecutionDetailsGenerator) ResolvedType() *types.T {
+	return scheduleExecutionDetailsGeneratorType
+}
+
+// Start implements the eval.ValueGenerator interface.
+func (g *scheduleExecutionDetailsGenerator) Start(ctx context.Context, _ *kv.Txn) error {
+	// The jobs created by a schedule are the ones listed by SHOW JOBS FOR
+	// SCHEDULES, see jobs.CreatedByScheduledJobs.
+	const query = `SELECT id FROM system.jobs
+WHERE created_by_type = 'crdb_schedule' AND created_by_id = $1 AND status = 'running'
+ORDER BY id`
+	it, err := g.evalCtx.Planner.QueryIteratorEx(
+		ctx,
+		"crdb_internal.request_job_execution_details_for_schedule",
+		sessiondata.NoSessionDataOverride,
+		query,
+		g.scheduleID,
+	)
+	if err != nil {
+		return err
+	}
+	for {
+		ok, err := it.Next(ctx)
+		if err != nil {
+			_ = it.Close()
+			return err
+		}
+		if !ok {
+			break
+		}
+		g.jobIDs = append(g.jobIDs, jobspb.JobID(tree.MustBeDInt(it.Cur()[0])))
+	}
+	if err := it.Close(); err != nil {
+		return err
+	}
+
+	// Request the execution details of at most a few jobs at once. A failure to
+	// request the execution details of a job is reported for that job, rather
+	// than failing the whole request.
+	g.errs = make([]error, len(g.jobIDs))
+	work := make(chan int, len(g.jobIDs))
+	for i := range g.jobIDs {
+		work <- i
+	}
+	close(work)
+	workers := maxConcurrentScheduleExecutionDetailsRequests
+	if len(g.jobIDs) < workers {
+		workers = len(g.jobIDs)
+	}
+	return ctxgroup.GroupWorkers(ctx, workers, func(ctx context.Context, _ int) error {
+		for i := range work {
+			g.errs[i] = g.evalCtx.JobsProfiler.RequestExecutionDetails(
+				ctx, g.jobIDs[i], eval.ExecutionDetailsOptions{},
+			)
+		}
+		return nil
+	})
+}
+
+// Next implements the eval.ValueGenerator interface.
+func (g *scheduleExecutionDetailsGenerator) Next(_ context.Context) (bool, error) {
+	g.idx++
+	return g.idx < len(g.jobIDs), nil
+}
+
+// Values implements the eval.ValueGenerator interface.
+func (g *scheduleExecutionDetailsGenerator) Values() (tree.Datums, error) {
+	errDatum := tree.DNull
+	if err := g.errs[g.idx]; err != nil {
+		errDatum = tree.NewDString(err.Error())
+	}
+	return tree.Datums{
+		tree.NewDInt(tree.DInt(g.jobIDs[g.idx])),
+		tree.MakeDBool(g.errs[g.idx] == nil),
+		errDatum,
+	}, nil
+}
+
+// Close implements the eval.ValueGenerator interface.
+func (g *scheduleExecutionDetailsGenerator) Close(_ context.Context) {}
+
 var showCreateAllSchemasGeneratorType = types.String
 var showCreateAllTypesGeneratorType = types.String
 var showCreateAllTablesGeneratorType = types.String
@@ -198,6 +198,8 @@ const (
 	CrdbInternalKVFlowHandlesID
 	CrdbInternalKVFlowTokenDeductions
 	CrdbInternalRepairableCatalogCorruptionsViewID
+	CrdbInternalTableProvenanceTableID
+	CrdbInternalJobProfilerCapabilitiesTableID
 	InformationSchemaID
 	InformationSchemaAdministrableRoleAuthorizationsID
 	InformationSchemaApplicableRolesID
@@ -292,7 +292,44 @@ type JobsProfiler interface {
 	// currently includes the following pieces of information:
 	//
 	// - Latest DistSQL diagram of the job
-	RequestExecutionDetails(ctx context.Context, jobID jobspb.JobID) error
+	RequestExecutionDetails(ctx context.Context, jobID jobspb.JobID, opts ExecutionDetailsOptions) error
+
+	// RequestExecutionDetailsAndWait collects the execution details of the
+	// specified jobID, as RequestExecutionDetails does, waiting at most timeout
+	// for the collection to complete. It returns the names of the files written
+	// by the collection, and whether the collection completed within the
+	// timeout. If it didn't, the files are those written before the timeout.
+	RequestExecutionDetailsAndWait(
+		ctx context.Context, jobID jobspb.JobID, timeout time.Duration,
+	) (files []string, complete bool, _ error)
+
+	// ExecutionDetailArtifactsJSON generates a JSON blob describing the types
+	// of execution details that RequestExecutionDetails collects for the
+	// specified jobID, and those it does not support for the job's type. It
+	// does not collect or persist any execution details.
+	ExecutionDetailArtifactsJSON(ctx context.Context, jobID jobspb.JobID) ([]byte, error)
+
+	// LatestJobGoroutines returns the most recently collected goroutines of the
+	// specified jobID. The goroutines are collected and persisted to
+	// `system.job_info` first, unless they were collected very recently.
+	LatestJobGoroutines(ctx context.Context, jobID jobspb.JobID) ([]byte, error)
+
+	// JobDistSQLPlanText returns a plain-text rendering of a DistSQL plan stored
+	// by the specified jobID. The index selects the plan, counting back from
+	// the most recently stored plan at index 0.
+	JobDistSQLPlanText(ctx context.Context, jobID jobspb.JobID, index int) (string, error)
+}
+
+// ExecutionDetailsOptions configures the execution details collected by
+// JobsProfiler.RequestExecutionDetails.
+type ExecutionDetailsOptions struct {
+	// GoroutinesPprof additionally collects the goroutines of every node in the
+	// binary pprof format.
+	GoroutinesPprof bool
+	// Label, when set, labels the collection, so that its files can be
+	// distinguished from those of other collections for the same job. It is
+	// sanitized before being incorporated into the names of the files.
+	Label string
 }
 
 // DescIDGenerator generates unique descriptor IDs.
@@ -718,6 +718,58 @@ func NewColumnTableDef(
 	return d, nil
 }
 
+// ValidateCreateAsDeclaredColumn checks that a column definition with a type,
+// which is part of a CREATE TABLE ... AS statement, declares a column that can
+// be populated without a value from the source query.
+func ValidateCreateAsDeclaredColumn(d *ColumnTableDef) error {
+	if d.IsComputed() {
+		return pgerror.Newf(pgcode.FeatureNotSupported,
+			"computed column %q is not supported by CREATE TABLE AS", d.Name)
+	}
+	if d.HasFKConstraint() {
+		return pgerror.Newf(pgcode.FeatureNotSupported,
+			"foreign key constraint on column %q is not supported by CREATE TABLE AS", d.Name)
+	}
+	if !d.IsCreateAsDeclared() {
+		return pgerror.Newf(pgcode.InvalidTableDefinition,
+			"column %q of CREATE TABLE AS must have a DEFAULT expression, be an identity column or be SERIAL",
+			d.Name)
+	}
+	return nil
+}
+
+// ValidateCreateAsTableDefs checks that the table definitions of a CREATE
+// TABLE ... AS statement, which were parsed like those of a regular CREATE
+// TABLE statement, are supported by CREATE TABLE AS: columns must be declared
+// with a way to populate them without a value from the source query, and the
+// only supported constraint is the primary key. A LIKE definition, whose
+// columns are all populated by the source query, must be the only one.
+func ValidateCreateAsTableDefs(defs TableDefs) error {
+	for _, def := range defs {
+		switch d := def.(type) {
+		case *ColumnTableDef:
+			if err := ValidateCreateAsDeclaredColumn(d); err != nil {
+				return err
+			}
+		case *LikeTableDef:
+			if len(defs) > 1 {
+				return pgerror.New(pgcode.FeatureNotSupported,
+					"LIKE must be the only table definition of CREATE TABLE AS")
+			}
+		case *FamilyTableDef:
+		case *UniqueConstraintTableDef:
+			if !d.PrimaryKey || d.WithoutIndex {
+				return pgerror.Newf(pgcode.FeatureNotSupported,
+					"%s is not supported by CREATE TABLE AS", AsString(def))
+			}
+		default:
+			return pgerror.Newf(pgcode.FeatureNotSupported,
+				"%s is not supported by CREATE TABLE AS", AsString(def))
+		}
+	}
+	return nil
+}
+
 // HasDefaultExpr returns if the ColumnTableDef has a default expression.
 func (node *ColumnTableDef) HasDefaultExpr() bool {
 	return node.DefaultExpr.Expr != nil
@@ -753,7 +805,8 @@ func (node *ColumnTableDef) Format(ctx *FmtCtx) {
 	ctx.FormatNode(&node.Name)
 
 	// ColumnTableDef node type will not be specified if it represents a CREATE
-	// TABLE ... AS query.
+	// TABLE ... AS query, unless it declares a column which isn't populated by
+	// the source query.
 	if node.Type != nil {
 		ctx.WriteByte(' ')
 		node.formatColumnType(ctx)
@@ -1474,6 +1527,75 @@ func (o *StorageParams) GetVal(key string) Expr {
 	return nil
 }
 
+// CreateTableAsInlineStorageParam is the storage parameter which requests that
+// a CREATE TABLE AS statement populates the new table within the statement's
+// transaction, rather than in an asynchronous schema change job. It is not
+// persisted as a parameter of the table.
+const CreateTableAsInlineStorageParam = "inline"
+
+// CreateTableAsCopyCommentsStorageParam is the storage parameter which requests
+// that a CREATE TABLE AS statement, whose source query selects all the columns
+// of a single table, copies the table and column comments of that table onto
+// the new table. It is not persisted as a parameter of the table.
+const CreateTableAsCopyCommentsStorageParam = "copy_comments"
+
+// CreateTableAsDistributeStorageParam is the storage parameter which requests
+// that a CREATE TABLE AS statement samples its source query to pre-split the
+// new table, so that the writes of the backfill are spread evenly across
+// ranges. It is not persisted as a parameter of the table.
+const CreateTableAsDistributeStorageParam = "distribute"
+
+// CreateTableAsPrecisionLossStorageParam is the storage parameter which
+// controls how a CREATE TABLE (LIKE ...) AS statement populates the columns
+// copied from the LIKE table with values of a different type, which may lose
+// precision: 'error' fails the statement on such a value, 'truncate' casts
+// the values as an explicit CAST would and 'widen' promotes the type of the
+// column. It is not persisted as a parameter of the table.
+const CreateTableAsPrecisionLossStorageParam = "precision_loss"
+
+// CreateTableAsCollectStatsStorageParam is the storage parameter which
+// requests that table statistics are collected on the new table of a CREATE
+// TABLE AS statement once it is populated, even if automatic statistics
+// collection is disabled. It is not persisted as a parameter of the table.
+const CreateTableAsCollectStatsStorageParam = "collect_stats"
+
+// CreateTableAsCopyPartitioningStorageParam is the storage parameter which
+// requests that a CREATE TABLE AS statement, whose source query selects all
+// the columns of a single table, recreates the partitioning of the primary
+// index of that table, and the zone configs of its partitions, on the new
+// table. It is not persisted as a parameter of the table.
+const CreateTableAsCopyPartitioningStorageParam = "copy_partitioning"
+
+// CreateTableAsAddCheckStorageParam is the storage parameter which adds a
+// check constraint, given as a string holding its expression, to the new table
+// of a CREATE TABLE AS statement. The rows of the table are validated against
+// the constraint once the table is populated, and the statement fails if any
+// row violates it. It is not persisted as a parameter of the table.
+const CreateTableAsAddCheckStorageParam = "add_check"
+
+// CreateTableAsSinkStorageParam is the storage parameter which requests that
+// the rows of the new table of a CREATE TABLE AS statement are published, in
+// the encoding of a changefeed, to the changefeed sink with the given URI as
+// the table is populated, so that a downstream system receives the initial
+// snapshot of the table. It is not persisted as a parameter of the table.
+const CreateTableAsSinkStorageParam = "sink"
+
+// CreateTableAsCheckpointStorageParam is the storage parameter which requests
+// that the backfill of the new table of a CREATE TABLE AS statement is
+// checkpointed, so that an interrupted backfill is resumed from its last
+// checkpoint rather than run again from scratch. The rows of the query are then
+// sorted by the primary key of the table, and ingested by a single processor on
+// the gateway, so it only pays off for a table which is large enough to be
+// costly to backfill again. It is not persisted as a parameter of the table.
+const CreateTableAsCheckpointStorageParam = "checkpoint"
+
+// CreateTableAsColumnFamiliesStorageParam is the storage parameter which
+// groups the columns of the new table of a CREATE TABLE AS statement into
+// column families, given as a string holding FAMILY definitions as they are
+// written in a CREATE TABLE statement, e.g. 'FAMILY f1 (a, b), FAMILY f2 (c)'.
+// It is not persisted as a parameter of the table.
+const CreateTableAsColumnFamiliesStorageParam = "column_families"
+
 // CreateTableOnCommitSetting represents the CREATE TABLE ... ON COMMIT <action>
 // parameters.
 type CreateTableOnCommitSetting uint32
@@ -1483,6 +1605,8 @@ const (
 	CreateTableOnCommitUnset CreateTableOnCommitSetting = iota
 	// CreateTableOnCommitPreserveRows indicates that ON COMMIT PRESERVE ROWS was set.
 	CreateTableOnCommitPreserveRows
+	// CreateTableOnCommitDrop indicates that ON COMMIT DROP was set.
+	CreateTableOnCommitDrop
 )
 
 // CreateTable represents a CREATE TABLE statement.
@@ -1522,6 +1646,14 @@ func (node *CreateTable) AsHasUserSpecifiedPrimaryKey() bool {
 	return false
 }
 
+// IsCreateAsDeclared returns true if the column definition, which is part of a
+// CREATE TABLE ... AS statement, declares a column that is populated by its
+// DEFAULT expression, or as an identity or SERIAL column, rather than by the
+// source query.
+func (node *ColumnTableDef) IsCreateAsDeclared() bool {
+	return node.HasDefaultExpr() || node.GeneratedIdentity.IsGeneratedAsIdentity || node.IsSerial
+}
+
 // Format implements the NodeFormatter interface.
 func (node *CreateTable) Format(ctx *FmtCtx) {
 	ctx.WriteString("CREATE ")
@@ -1555,6 +1687,9 @@ func (node *CreateTable) FormatBody(ctx *FmtCtx) {
 		}
 		ctx.WriteString(" AS ")
 		ctx.FormatNode(node.AsSource)
+		if node.OnCommit == CreateTableOnCommitDrop {
+			ctx.WriteString(" ON COMMIT DROP")
+		}
 	} else {
 		ctx.WriteString(" (")
 		ctx.FormatNode(&node.Defs)
@@ -1948,6 +2083,8 @@ type CreateView struct {
 	Replace      bool
 	Materialized bool
 	WithData     bool
+	// StorageParams are only supported for materialized views.
+	StorageParams StorageParams
 }
 
 // Format implements the NodeFormatter interface.
@@ -1982,6 +2119,11 @@ func (node *CreateView) Format(ctx *FmtCtx) {
 
 	ctx.WriteString(" AS ")
 	ctx.FormatNode(node.AsSource)
+	if node.Materialized && node.StorageParams != nil {
+		ctx.WriteString(" WITH (")
+		ctx.FormatNode(&node.StorageParams)
+		ctx.WriteString(")")
+	}
 	if node.Materialized && node.WithData {
 		ctx.WriteString(" WITH DATA")
 	} else if node.Materialized && !node.WithData {
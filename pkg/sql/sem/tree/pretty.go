@@ -1345,6 +1345,15 @@ func (node *CreateView) doc(p *PrettyCfg) pretty.Doc {
 		pretty.ConcatSpace(d, pretty.Keyword("AS")),
 		p.Doc(node.AsSource),
 	)
+	if node.Materialized && node.StorageParams != nil {
+		d = pretty.ConcatSpace(
+			d,
+			pretty.ConcatSpace(
+				pretty.Keyword(`WITH`),
+				p.bracket(`(`, p.Doc(&node.StorageParams), `)`),
+			),
+		)
+	}
 	if node.Materialized && node.WithData {
 		d = pretty.ConcatSpace(d, pretty.Keyword("WITH DATA"))
 	} else if node.Materialized && !node.WithData {
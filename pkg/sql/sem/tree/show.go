@@ -1213,15 +1213,20 @@ const (
 	// ScheduledChangefeedExecutor is an executor responsible for
 	// the execution of the scheduled changefeeds.
 	ScheduledChangefeedExecutor
+
+	// ScheduledMaterializedViewRefreshExecutor is an executor responsible for
+	// the periodic refresh of materialized views.
+	ScheduledMaterializedViewRefreshExecutor
 )
 
 var scheduleExecutorInternalNames = map[ScheduledJobExecutorType]string{
-	InvalidExecutor:                     "unknown-executor",
-	ScheduledBackupExecutor:             "scheduled-backup-executor",
-	ScheduledSQLStatsCompactionExecutor: "scheduled-sql-stats-compaction-executor",
-	ScheduledRowLevelTTLExecutor:        "scheduled-row-level-ttl-executor",
-	ScheduledSchemaTelemetryExecutor:    "scheduled-schema-telemetry-executor",
-	ScheduledChangefeedExecutor:         "scheduled-changefeed-executor",
+	InvalidExecutor:                          "unknown-executor",
+	ScheduledBackupExecutor:                  "scheduled-backup-executor",
+	ScheduledSQLStatsCompactionExecutor:      "scheduled-sql-stats-compaction-executor",
+	ScheduledRowLevelTTLExecutor:             "scheduled-row-level-ttl-executor",
+	ScheduledSchemaTelemetryExecutor:         "scheduled-schema-telemetry-executor",
+	ScheduledChangefeedExecutor:              "scheduled-changefeed-executor",
+	ScheduledMaterializedViewRefreshExecutor: "scheduled-materialized-view-refresh-executor",
 }
 
 // InternalName returns an internal executor name.
@@ -1243,6 +1248,8 @@ func (t ScheduledJobExecutorType) UserName() string {
 		return "SCHEMA TELEMETRY"
 	case ScheduledChangefeedExecutor:
 		return "CHANGEFEED"
+	case ScheduledMaterializedViewRefreshExecutor:
+		return "MATERIALIZED VIEW REFRESH"
 	}
 	return "unsupported-executor"
 }
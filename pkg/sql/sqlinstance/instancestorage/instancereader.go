@@ -15,6 +15,7 @@ import (
 	"sort"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlinstance"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlliveness"
@@ -175,6 +176,31 @@ func (r *Reader) GetAllInstancesUsingTxn(
 	return makeInstanceInfos(filteredRows), nil
 }
 
+// GetAllTenantInstancesNoCache reads the instances of the tenant with the
+// given codec directly from its sql_instances table, bypassing any caching.
+// It is used by the system tenant to reach the SQL servers of a secondary
+// tenant, which may run in other processes. The liveness of the instances is
+// recorded in the tenant's own sqlliveness table, so the instances are not
+// filtered by it; the caller must tolerate instances which have stopped.
+func (r *Reader) GetAllTenantInstancesNoCache(
+	ctx context.Context, codec keys.SQLCodec,
+) ([]sqlinstance.InstanceInfo, error) {
+	var rows []instancerow
+	if err := r.db.Txn(ctx, func(ctx context.Context, txn *kv.Txn) (err error) {
+		rows, err = r.storage.getAllTenantInstanceRows(ctx, codec, txn)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	claimedRows := rows[:0]
+	for _, row := range rows {
+		if !row.isAvailable() {
+			claimedRows = append(claimedRows, row)
+		}
+	}
+	return makeInstanceInfos(selectLatestRowPerAddress(claimedRows)), nil
+}
+
 // GetInstance implements sqlinstance.AddressResolver interface. The function
 // first tries to find the instance (and validate that it's alive) using the
 // instance cache. If it can't be found it the cache, it performs a more
@@ -289,6 +315,12 @@ func selectDistinctLiveRows(
 		}
 		rows = truncated
 	}
+	return selectLatestRowPerAddress(rows), nil
+}
+
+// selectLatestRowPerAddress modifies the given slice in-place and returns the
+// latest row for each address.
+func selectLatestRowPerAddress(rows []instancerow) []instancerow {
 	sort.Slice(rows, func(idx1, idx2 int) bool {
 		if rows[idx1].sqlAddr == rows[idx2].sqlAddr {
 			return !rows[idx1].timestamp.Less(rows[idx2].timestamp) // decreasing timestamp order
@@ -305,7 +337,7 @@ func selectDistinctLiveRows(
 		}
 		rows = truncated
 	}
-	return rows, nil
+	return rows
 }
 
 func (r *Reader) initialScanErr() error {
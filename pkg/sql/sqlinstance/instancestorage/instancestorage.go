@@ -86,6 +86,7 @@ var errNoPreallocatedRows = errors.New("no preallocated rows")
 // instances ids if the owning session has expired.
 type Storage struct {
 	db            *kv.DB
+	table         catalog.TableDescriptor
 	slReader      sqlliveness.Reader
 	oldRowCodec   rowCodec
 	newRowCodec   rowCodec
@@ -133,6 +134,7 @@ func NewTestingStorage(
 ) *Storage {
 	s := &Storage{
 		db:            db,
+		table:         table,
 		newRowCodec:   makeRowCodec(codec, table, true),
 		oldRowCodec:   makeRowCodec(codec, table, false),
 		slReader:      slReader,
@@ -513,6 +515,18 @@ func (s *Storage) getAllInstanceRows(ctx context.Context, txn *kv.Txn) ([]instan
 	return s.getInstanceRows(ctx, nil, &version, txn, lock.WaitPolicy_Block)
 }
 
+// getAllTenantInstanceRows returns all instance rows of the sql_instances
+// table of the tenant with the given codec, including instance rows that are
+// pre-allocated.
+func (s *Storage) getAllTenantInstanceRows(
+	ctx context.Context, codec keys.SQLCodec, txn *kv.Txn,
+) ([]instancerow, error) {
+	tenantStorage := *s
+	tenantStorage.newRowCodec = makeRowCodec(codec, s.table, true)
+	tenantStorage.oldRowCodec = makeRowCodec(codec, s.table, false)
+	return tenantStorage.getAllInstanceRows(ctx, txn)
+}
+
 // getInstanceRows decodes and returns all instance rows associated
 // with a given region from the sql_instances table. This returns both used and
 // available instance rows.
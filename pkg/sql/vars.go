@@ -2658,6 +2658,23 @@ var varGen = map[string]sessionVar{
 		GlobalDefault: globalTrue,
 	},
 
+	// CockroachDB extension.
+	`create_table_as_progress_notices`: {
+		GetStringVal: makePostgresBoolGetStringValFn(`create_table_as_progress_notices`),
+		Set: func(_ context.Context, m sessionDataMutator, s string) error {
+			b, err := paramparse.ParseBoolVar("create_table_as_progress_notices", s)
+			if err != nil {
+				return err
+			}
+			m.SetCreateTableAsProgressNotices(b)
+			return nil
+		},
+		Get: func(evalCtx *extendedEvalContext, _ *kv.Txn) (string, error) {
+			return formatBoolAsPostgresSetting(evalCtx.SessionData().CreateTableAsProgressNotices), nil
+		},
+		GlobalDefault: globalTrue,
+	},
+
 	// CockroachDB extension.
 	`enable_create_stats_using_extremes`: {
 		GetStringVal: makePostgresBoolGetStringValFn(`enable_create_stats_using_extremes`),